@@ -138,6 +138,7 @@ var (
 	ValidationSetType   = &AssertionType{"validation-set", []string{"series", "account-id", "name", "sequence"}, nil, assembleValidationSet, sequenceForming}
 	StoreType           = &AssertionType{"store", []string{"store"}, nil, assembleStore, 0}
 	PreseedType         = &AssertionType{"preseed", []string{"series", "brand-id", "model", "system-label"}, nil, assemblePreseed, 0}
+	ConfigProfileType   = &AssertionType{"config-profile", []string{"brand-id", "name"}, nil, assembleConfigProfile, 0}
 
 // ...
 )
@@ -169,6 +170,7 @@ var typeRegistry = map[string]*AssertionType{
 	SerialRequestType.Name:        SerialRequestType,
 	AccountKeyRequestType.Name:    AccountKeyRequestType,
 	PreseedType.Name:              PreseedType,
+	ConfigProfileType.Name:        ConfigProfileType,
 }
 
 // Type returns the AssertionType with name or nil
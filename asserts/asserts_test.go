@@ -53,6 +53,7 @@ func (as *assertsSuite) TestTypeNames(c *C) {
 		"account-key-request",
 		// XXX "authority-delegation",
 		"base-declaration",
+		"config-profile",
 		"device-session-request",
 		"model",
 		"preseed",
@@ -1202,6 +1203,7 @@ func (as *assertsSuite) TestWithAuthority(c *C) {
 		"validation",
 		"validation-set",
 		"repair",
+		"config-profile",
 	}
 	c.Check(withAuthority, HasLen, asserts.NumAssertionType-3) // excluding device-session-request, serial-request, account-key-request
 	for _, name := range withAuthority {
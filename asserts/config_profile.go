@@ -0,0 +1,142 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/snap/naming"
+)
+
+// ConfigProfile holds a config-profile assertion, a named set of system and
+// per-snap configuration values a brand can push to its devices.
+type ConfigProfile struct {
+	assertionBase
+	models    []string
+	config    map[string]map[string]interface{}
+	timestamp time.Time
+}
+
+// BrandID returns the brand identifier that signed this assertion.
+func (cp *ConfigProfile) BrandID() string {
+	return cp.HeaderString("brand-id")
+}
+
+// Name returns the name of the configuration profile.
+func (cp *ConfigProfile) Name() string {
+	return cp.HeaderString("name")
+}
+
+// Models returns the models of the brand this profile is restricted to, if
+// empty the profile applies to all the models of the brand.
+func (cp *ConfigProfile) Models() []string {
+	return cp.models
+}
+
+// Config returns the configuration values carried by the profile, indexed
+// by snap name and then by configuration key. The system configuration is
+// under the "system" name.
+func (cp *ConfigProfile) Config() map[string]map[string]interface{} {
+	return cp.config
+}
+
+// Timestamp returns the time when the config-profile was issued.
+func (cp *ConfigProfile) Timestamp() time.Time {
+	return cp.timestamp
+}
+
+// Implement further consistency checks.
+func (cp *ConfigProfile) checkConsistency(db RODatabase, acck *AccountKey) error {
+	// Do the cross-checks against the device model when the profile is
+	// actually applied, see configstate.ApplyConfigProfile
+
+	return nil
+}
+
+// expected interface is implemented
+var _ consistencyChecker = (*ConfigProfile)(nil)
+
+func checkConfigProfileConfig(body []byte) (map[string]map[string]interface{}, error) {
+	if len(body) == 0 {
+		return nil, fmt.Errorf("body must contain the configuration values")
+	}
+	var config map[string]map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("body must be a JSON object of configuration values by snap name: %v", err)
+	}
+	if len(config) == 0 {
+		return nil, fmt.Errorf("body must contain the configuration values")
+	}
+	for snapName, values := range config {
+		if snapName != "system" {
+			if err := naming.ValidateSnap(snapName); err != nil {
+				return nil, fmt.Errorf("invalid snap name %q in body", snapName)
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("configuration of %q in body cannot be empty", snapName)
+		}
+		for key := range values {
+			if key == "" {
+				return nil, fmt.Errorf("configuration of %q in body cannot have empty keys", snapName)
+			}
+		}
+	}
+	return config, nil
+}
+
+func assembleConfigProfile(assert assertionBase) (Assertion, error) {
+	err := checkAuthorityMatchesBrand(&assert)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = checkStringMatches(assert.headers, "name", validValidationSetName)
+	if err != nil {
+		return nil, err
+	}
+
+	models, err := checkStringListMatches(assert.headers, "models", validModel)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := checkConfigProfileConfig(assert.body)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigProfile{
+		assertionBase: assert,
+		models:        models,
+		config:        config,
+		timestamp:     timestamp,
+	}, nil
+}
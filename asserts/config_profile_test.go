@@ -0,0 +1,134 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+)
+
+var _ = Suite(&configProfileSuite{})
+
+type configProfileSuite struct {
+	ts     time.Time
+	tsLine string
+}
+
+func (s *configProfileSuite) SetUpSuite(c *C) {
+	s.ts = time.Now().Truncate(time.Second).UTC()
+	s.tsLine = "timestamp: " + s.ts.Format(time.RFC3339) + "\n"
+}
+
+const configProfileBody = `{"system": {"refresh.timer": "4:00-6:00", "watchdog.runtime-timeout": "5m"}, "foo": {"bar": 42, "baz": {"enabled": true}}}`
+
+func (s *configProfileSuite) example(body string) string {
+	return "type: config-profile\n" +
+		"authority-id: brand-id1\n" +
+		"brand-id: brand-id1\n" +
+		"name: fleet-defaults\n" +
+		"revision: 2\n" +
+		"models:\n" +
+		"  - pc\n" +
+		"  - kiosk-1\n" +
+		s.tsLine +
+		fmt.Sprintf("body-length: %d\n", len(body)) +
+		"sign-key-sha3-384: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij\n" +
+		"\n" +
+		body +
+		"\n\n" +
+		"AXNpZw=="
+}
+
+func (s *configProfileSuite) TestDecodeOK(c *C) {
+	a, err := asserts.Decode([]byte(s.example(configProfileBody)))
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.ConfigProfileType)
+	profile := a.(*asserts.ConfigProfile)
+
+	c.Check(profile.AuthorityID(), Equals, "brand-id1")
+	c.Check(profile.BrandID(), Equals, "brand-id1")
+	c.Check(profile.Name(), Equals, "fleet-defaults")
+	c.Check(profile.Revision(), Equals, 2)
+	c.Check(profile.Models(), DeepEquals, []string{"pc", "kiosk-1"})
+	c.Check(profile.Timestamp().Equal(s.ts), Equals, true)
+	c.Check(profile.Config(), DeepEquals, map[string]map[string]interface{}{
+		"system": {
+			"refresh.timer":            "4:00-6:00",
+			"watchdog.runtime-timeout": "5m",
+		},
+		"foo": {
+			"bar": json.Number("42"),
+			"baz": map[string]interface{}{"enabled": true},
+		},
+	})
+}
+
+func (s *configProfileSuite) TestModelsOptional(c *C) {
+	encoded := strings.Replace(s.example(configProfileBody), "models:\n  - pc\n  - kiosk-1\n", "", 1)
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.ConfigProfile).Models(), HasLen, 0)
+}
+
+const configProfileErrPrefix = "assertion config-profile: "
+
+func (s *configProfileSuite) TestDecodeInvalidHeaders(c *C) {
+	tests := []struct{ original, invalid, expectedErr string }{
+		{"brand-id: brand-id1\n", "", `"brand-id" header is mandatory`},
+		{"brand-id: brand-id1\n", "brand-id: other\n", `authority-id and brand-id must match, config-profile assertions are expected to be signed by the brand: "brand-id1" != "other"`},
+		{"name: fleet-defaults\n", "", `"name" header is mandatory`},
+		{"name: fleet-defaults\n", "name: Fleet_Defaults\n", `"name" header contains invalid characters: "Fleet_Defaults"`},
+		{"  - kiosk-1\n", "  - kiosk_1\n", `"models" header contains an invalid element: "kiosk_1"`},
+		{s.tsLine, "", `"timestamp" header is mandatory`},
+		{s.tsLine, "timestamp: 12:30\n", `"timestamp" header is not a RFC3339 date: .*`},
+	}
+
+	for _, test := range tests {
+		invalid := strings.Replace(s.example(configProfileBody), test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, configProfileErrPrefix+test.expectedErr)
+	}
+}
+
+func (s *configProfileSuite) TestDecodeInvalidBody(c *C) {
+	tests := []struct{ body, expectedErr string }{
+		{`["foo"]`, `body must be a JSON object of configuration values by snap name: .*`},
+		{`{"foo": "bar"}`, `body must be a JSON object of configuration values by snap name: .*`},
+		{`{}`, `body must contain the configuration values`},
+		{`{"Foo_": {"bar": 1}}`, `invalid snap name "Foo_" in body`},
+		{`{"foo": {}}`, `configuration of "foo" in body cannot be empty`},
+		{`{"system": {"": 1}}`, `configuration of "system" in body cannot have empty keys`},
+	}
+
+	for _, test := range tests {
+		_, err := asserts.Decode([]byte(s.example(test.body)))
+		c.Check(err, ErrorMatches, configProfileErrPrefix+test.expectedErr, Commentf(test.body))
+	}
+
+	encoded := strings.Replace(s.example(""), "body-length: 0\n", "", 1)
+	_, err := asserts.Decode([]byte(encoded))
+	c.Check(err, ErrorMatches, configProfileErrPrefix+`body must contain the configuration values`)
+}
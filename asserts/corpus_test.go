@@ -0,0 +1,46 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type corpusSuite struct{}
+
+var _ = Suite(&corpusSuite{})
+
+// TestModelCorpus decodes the model assertion samples in
+// testdata/corpus, a failing sample can be contributed there as a
+// regression test along with the expected error in a .error file.
+func (s *corpusSuite) TestModelCorpus(c *C) {
+	fixtures := testutil.LoadCorpus(c, "testdata/corpus", "*.model")
+	testutil.CheckCorpus(c, fixtures, func(content []byte) error {
+		a, err := asserts.Decode(content)
+		if err != nil {
+			return err
+		}
+		c.Check(a.Type(), Equals, asserts.ModelType)
+		return nil
+	})
+}
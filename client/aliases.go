@@ -32,6 +32,14 @@ type aliasAction struct {
 	Snap   string `json:"snap,omitempty"`
 	App    string `json:"app,omitempty"`
 	Alias  string `json:"alias,omitempty"`
+	Force  bool   `json:"force,omitempty"`
+}
+
+// AliasOptions holds the options for setting up manual aliases.
+type AliasOptions struct {
+	// Force takes over aliases enabled for other snaps, disabling
+	// them there.
+	Force bool
 }
 
 // performAliasAction performs a single action on aliases.
@@ -45,14 +53,30 @@ func (client *Client) performAliasAction(sa *aliasAction) (changeID string, err
 
 // Alias sets up a manual alias from alias to app in snapName.
 func (client *Client) Alias(snapName, app, alias string) (changeID string, err error) {
+	return client.AliasWithOptions(snapName, app, alias, nil)
+}
+
+// AliasWithOptions sets up a manual alias from alias to app in snapName
+// according to opts.
+func (client *Client) AliasWithOptions(snapName, app, alias string, opts *AliasOptions) (changeID string, err error) {
+	if opts == nil {
+		opts = &AliasOptions{}
+	}
 	return client.performAliasAction(&aliasAction{
 		Action: "alias",
 		Snap:   snapName,
 		App:    app,
 		Alias:  alias,
+		Force:  opts.Force,
 	})
 }
 
+// AliasAllApps sets up manual aliases for all the applications of
+// snapName that can be aliased, each under its own name.
+func (client *Client) AliasAllApps(snapName string, opts *AliasOptions) (changeID string, err error) {
+	return client.AliasWithOptions(snapName, "*", "", opts)
+}
+
 // // DisableAllAliases disables all aliases of a snap, removing all manual ones.
 func (client *Client) DisableAllAliases(snapName string) (changeID string, err error) {
 	return client.performAliasAction(&aliasAction{
@@ -69,6 +93,16 @@ func (client *Client) RemoveManualAlias(alias string) (changeID string, err erro
 	})
 }
 
+// RemoveManualAliases removes all the manual aliases of a snap, leaving
+// its automatic aliases as they are.
+func (client *Client) RemoveManualAliases(snapName string) (changeID string, err error) {
+	return client.performAliasAction(&aliasAction{
+		Action: "unalias",
+		Snap:   snapName,
+		App:    "*",
+	})
+}
+
 // Unalias tears down a manual alias or disables all aliases of a snap (removing all manual ones)
 func (client *Client) Unalias(aliasOrSnap string) (changeID string, err error) {
 	return client.performAliasAction(&aliasAction{
@@ -93,6 +127,8 @@ type AliasStatus struct {
 	Status  string `json:"status"`
 	Manual  string `json:"manual,omitempty"`
 	Auto    string `json:"auto,omitempty"`
+	// Conflict is the snap for which a disabled alias is enabled instead
+	Conflict string `json:"conflict,omitempty"`
 }
 
 // Aliases returns a map snap -> alias -> AliasStatus for all snaps and aliases in the system.
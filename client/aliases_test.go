@@ -56,6 +56,52 @@ func (cs *clientSuite) TestClientAlias(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientAliasWithOptionsForce(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "chgid"
+	}`
+	id, err := cs.cli.AliasWithOptions("alias-snap", "cmd1", "alias1", &client.AliasOptions{Force: true})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "chgid")
+	var body map[string]interface{}
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action": "alias",
+		"snap":   "alias-snap",
+		"app":    "cmd1",
+		"alias":  "alias1",
+		"force":  true,
+	})
+}
+
+func (cs *clientSuite) TestClientAliasAllApps(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "chgid"
+	}`
+	id, err := cs.cli.AliasAllApps("alias-snap", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "chgid")
+	var body map[string]interface{}
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action": "alias",
+		"snap":   "alias-snap",
+		"app":    "*",
+	})
+}
+
 func (cs *clientSuite) TestClientUnaliasCallsEndpoint(c *check.C) {
 	cs.cli.Unalias("alias1")
 	c.Check(cs.req.Method, check.Equals, "POST")
@@ -138,6 +184,28 @@ func (cs *clientSuite) TestClientRemoveManualAlias(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientRemoveManualAliases(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "chgid"
+	}`
+	id, err := cs.cli.RemoveManualAliases("alias-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "chgid")
+	var body map[string]interface{}
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action": "unalias",
+		"snap":   "alias-snap",
+		"app":    "*",
+	})
+}
+
 func (cs *clientSuite) TestClientPreferCallsEndpoint(c *check.C) {
 	cs.cli.Prefer("some-snap")
 	c.Check(cs.req.Method, check.Equals, "POST")
@@ -181,7 +249,7 @@ func (cs *clientSuite) TestClientAliases(c *check.C) {
                     },
                     "bar": {
                         "bar_dump": {"command": "bar.dump", "status": "manual", "manual": "dump"},
-                        "bar_dump.1": {"command": "bar.dump", "status": "disabled", "auto": "dump"}
+                        "bar_dump.1": {"command": "bar.dump", "status": "disabled", "auto": "dump", "conflict": "foo"}
                     }
 		}
 	}`
@@ -194,7 +262,7 @@ func (cs *clientSuite) TestClientAliases(c *check.C) {
 		},
 		"bar": {
 			"bar_dump":   {Command: "bar.dump", Status: "manual", Manual: "dump"},
-			"bar_dump.1": {Command: "bar.dump", Status: "disabled", Auto: "dump"},
+			"bar_dump.1": {Command: "bar.dump", Status: "disabled", Auto: "dump", Conflict: "foo"},
 		},
 	})
 }
@@ -53,8 +53,28 @@ type AppInfo struct {
 	DaemonScope snap.DaemonScope `json:"daemon-scope,omitempty"`
 	Enabled     bool             `json:"enabled,omitempty"`
 	Active      bool             `json:"active,omitempty"`
+	Failed      bool             `json:"failed,omitempty"`
 	CommonID    string           `json:"common-id,omitempty"`
 	Activators  []AppActivator   `json:"activators,omitempty"`
+	// Units are the names of the systemd units generated for a service.
+	Units []string `json:"units,omitempty"`
+	// Failure describes how a service last failed, only reported when
+	// asked for with AppOptions.Failures.
+	Failure *AppFailure `json:"failure,omitempty"`
+}
+
+// AppFailure describes how a service failed or had to be restarted.
+type AppFailure struct {
+	// Result is the reason of the last failure as reported by systemd,
+	// e.g. "exit-code", or "success" if the service was restarted but
+	// did not fail the last time it exited.
+	Result   string `json:"result"`
+	ExitCode int    `json:"exit-code,omitempty"`
+	// Restarts is how many times the service was restarted
+	// automatically.
+	Restarts int `json:"restarts,omitempty"`
+	// Log holds the last lines logged by a failed service.
+	Log []string `json:"log,omitempty"`
 }
 
 // IsService returns true if the application is a background daemon.
@@ -74,6 +94,11 @@ type AppOptions struct {
 	// If Service is true, only return apps that are services
 	// (app.IsService() is true); otherwise, return all.
 	Service bool
+	// If Failures is true, services that failed or were restarted
+	// have their Failure set. The failures are queried from systemd
+	// when the request is made, rather than tracked by snapd; services
+	// whose failure cannot be queried are returned without one.
+	Failures bool
 }
 
 // Apps returns information about all matching apps. Each name can be
@@ -87,6 +112,9 @@ func (client *Client) Apps(names []string, opts AppOptions) ([]*AppInfo, error)
 	if opts.Service {
 		q.Add("select", "service")
 	}
+	if opts.Failures {
+		q.Add("failures", "true")
+	}
 
 	var appInfos []*AppInfo
 	_, err := client.doSync("GET", "/v2/apps", q, nil, nil, &appInfos)
@@ -96,16 +124,19 @@ func (client *Client) Apps(names []string, opts AppOptions) ([]*AppInfo, error)
 
 // LogOptions represent the options of the Logs call.
 type LogOptions struct {
-	N      int  // The maximum number of log lines to retrieve initially. If <0, no limit.
-	Follow bool // Whether to continue returning new lines as they appear
+	N           int    // The maximum number of log lines to retrieve initially. If <0, no limit.
+	Follow      bool   // Whether to continue returning new lines as they appear
+	Priority    string // If set, only return lines of at least this syslog priority
+	AfterCursor string // If set, only return lines after the one with this journal cursor
 }
 
 // A Log holds the information of a single syslog entry
 type Log struct {
-	Timestamp time.Time `json:"timestamp"` // Timestamp of the event, in RFC3339 format to µs precision.
-	Message   string    `json:"message"`   // The log message itself
-	SID       string    `json:"sid"`       // The syslog identifier
-	PID       string    `json:"pid"`       // The process identifier
+	Timestamp time.Time `json:"timestamp"`        // Timestamp of the event, in RFC3339 format to µs precision.
+	Message   string    `json:"message"`          // The log message itself
+	SID       string    `json:"sid"`              // The syslog identifier
+	PID       string    `json:"pid"`              // The process identifier
+	Cursor    string    `json:"cursor,omitempty"` // The journal cursor of the entry
 }
 
 // String will format the log entry with the timestamp in the local timezone
@@ -136,6 +167,12 @@ func (client *Client) Logs(names []string, opts LogOptions) (<-chan Log, error)
 	if opts.Follow {
 		query.Set("follow", strconv.FormatBool(opts.Follow))
 	}
+	if opts.Priority != "" {
+		query.Set("priority", opts.Priority)
+	}
+	if opts.AfterCursor != "" {
+		query.Set("after-cursor", opts.AfterCursor)
+	}
 
 	rsp, err := client.raw(context.Background(), "GET", "/v2/logs", query, nil, nil)
 	if err != nil {
@@ -256,6 +293,9 @@ type RestartOptions struct {
 	// Reload the services, if possible (i.e. if the App has a
 	// ReloadCommand, invoque it), instead of restarting.
 	Reload bool `json:"reload,omitempty"`
+	// Failed restricts the restart to the services that are in the
+	// failed state.
+	Failed bool `json:"failed,omitempty"`
 }
 
 // Restart services.
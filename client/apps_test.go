@@ -87,6 +87,20 @@ func (cs *clientSuite) TestClientServiceGetSad(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientAppsFailures(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [{"snap": "foo", "name": "svc", "daemon": "simple", "failure": {"result": "exit-code", "exit-code": 1, "restarts": 3, "log": ["oops"]}}]}`
+	services, err := cs.cli.Apps([]string{"foo"}, client.AppOptions{Service: true, Failures: true})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Query().Get("failures"), check.Equals, "true")
+	c.Assert(services, check.HasLen, 1)
+	c.Check(services[0].Failure, check.DeepEquals, &client.AppFailure{
+		Result:   "exit-code",
+		ExitCode: 1,
+		Restarts: 3,
+		Log:      []string{"oops"},
+	})
+}
+
 func (cs *clientSuite) TestClientAppCommonID(c *check.C) {
 	expected := []*client.AppInfo{{
 		Snap:     "foo",
@@ -209,6 +223,24 @@ func (cs *clientSuite) TestClientLogsOpts(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientLogsPriorityAndCursor(c *check.C) {
+	cs.rsp = "\x1e" + `{"message":"hello","cursor":"s=abc;i=2"}
+`
+	ch, err := cs.cli.Logs([]string{"foo"}, client.LogOptions{N: -1, Priority: "warning", AfterCursor: "s=abc;i=1"})
+	c.Assert(err, check.IsNil)
+
+	query := cs.req.URL.Query()
+	c.Check(query, check.HasLen, 4)
+	c.Check(query.Get("priority"), check.Equals, "warning")
+	c.Check(query.Get("after-cursor"), check.Equals, "s=abc;i=1")
+
+	var logs []client.Log
+	for log := range ch {
+		logs = append(logs, log)
+	}
+	c.Check(logs, check.DeepEquals, []client.Log{{Message: "hello", Cursor: "s=abc;i=2"}})
+}
+
 func (cs *clientSuite) TestClientLogsNotFound(c *check.C) {
 	cs.rsp = `{"type":"error","status-code":404,"status":"Not Found","result":{"message":"snap \"foo\" not found","kind":"snap-not-found","value":"foo"}}`
 	cs.status = 404
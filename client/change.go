@@ -92,10 +92,20 @@ func (client *Client) Change(id string) (*Change, error) {
 
 // Abort attempts to abort a change that is in not yet ready.
 func (client *Client) Abort(id string) (*Change, error) {
+	return client.changeAction(id, "abort")
+}
+
+// ForceAbort aborts a change that is not yet ready without waiting for its
+// running tasks to stop, they are put in error right away.
+func (client *Client) ForceAbort(id string) (*Change, error) {
+	return client.changeAction(id, "force-abort")
+}
+
+func (client *Client) changeAction(id, action string) (*Change, error) {
 	var postData struct {
 		Action string `json:"action"`
 	}
-	postData.Action = "abort"
+	postData.Action = action
 
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(postData); err != nil {
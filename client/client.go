@@ -613,6 +613,25 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// ExpectedDuration returns how long the maintenance described by the
+// error is expected to last at most, as written by snapd into
+// maintenance.json, or 0 if unknown.
+func (e *Error) ExpectedDuration() time.Duration {
+	value, ok := e.Value.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	s, ok := value["expected-duration"].(string)
+	if !ok {
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
 // IsRetryable returns true if the given error is an error
 // that can be retried later.
 func IsRetryable(err error) bool {
@@ -670,6 +689,18 @@ type RefreshInfo struct {
 	Last     string `json:"last,omitempty"`
 	Hold     string `json:"hold,omitempty"`
 	Next     string `json:"next,omitempty"`
+	// Status is the status of the automatic refreshes: scheduled,
+	// in-progress, held, managed or disabled.
+	Status string `json:"status,omitempty"`
+}
+
+// RebootInfo contains information about pending system reboots.
+type RebootInfo struct {
+	// Pending is set if a system reboot was requested or is needed to
+	// complete some changes.
+	Pending bool `json:"pending"`
+	// Changes contains the IDs of the changes waiting for a reboot.
+	Changes []string `json:"changes,omitempty"`
 }
 
 // SysInfo holds system information
@@ -686,6 +717,7 @@ type SysInfo struct {
 	Virtualization string `json:"virtualization,omitempty"`
 
 	Refresh         RefreshInfo         `json:"refresh,omitempty"`
+	Reboot          RebootInfo          `json:"reboot"`
 	Confinement     string              `json:"confinement"`
 	SandboxFeatures map[string][]string `json:"sandbox-features,omitempty"`
 }
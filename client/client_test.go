@@ -172,6 +172,29 @@ func (cs *clientSuite) TestClientSetMaintenanceForMaintenanceJSON(c *C) {
 	c.Assert(returnedErr, DeepEquals, maintErr)
 }
 
+func (cs *clientSuite) TestClientMaintenanceExpectedDuration(c *C) {
+	makeMaintenanceFile(c, []byte(`{"kind":"system-restart","message":"system is restarting","value":{"op":"reboot","expected-duration":"10m0s"}}`))
+
+	_, err := cs.cli.Do("GET", "/this", nil, nil, nil, nil)
+	c.Check(err, IsNil)
+
+	maintErr, ok := cs.cli.Maintenance().(*client.Error)
+	c.Assert(ok, Equals, true)
+	c.Check(maintErr.ExpectedDuration(), Equals, 10*time.Minute)
+
+	// unknown or invalid durations are ignored
+	for _, value := range []interface{}{
+		nil,
+		"foo",
+		map[string]interface{}{"op": "reboot"},
+		map[string]interface{}{"expected-duration": "soon"},
+		map[string]interface{}{"expected-duration": 600},
+	} {
+		e := &client.Error{Kind: client.ErrorKindSystemRestart, Value: value}
+		c.Check(e.ExpectedDuration(), Equals, time.Duration(0), Commentf("%v", value))
+	}
+}
+
 func (cs *clientSuite) TestClientIgnoresGarbageMaintenanceJSON(c *C) {
 	// write a garbage maintenance.json that can't be unmarshalled
 	makeMaintenanceFile(c, []byte("blah blah blah not json"))
@@ -360,6 +383,8 @@ func (cs *clientSuite) TestClientSysInfo(c *C) {
                       "confinement": "strict",
                       "architecture": "TI-99/4A",
                       "virtualization": "MESS",
+                      "refresh": {"timer": "00:00~24:00/4", "status": "held"},
+                      "reboot": {"pending": true, "changes": ["42"]},
                       "sandbox-features": {"backend": ["feature-1", "feature-2"]}}}`
 	sysInfo, err := cs.cli.SysInfo()
 	c.Check(err, IsNil)
@@ -375,6 +400,14 @@ func (cs *clientSuite) TestClientSysInfo(c *C) {
 		SandboxFeatures: map[string][]string{
 			"backend": {"feature-1", "feature-2"},
 		},
+		Refresh: client.RefreshInfo{
+			Timer:  "00:00~24:00/4",
+			Status: "held",
+		},
+		Reboot: client.RebootInfo{
+			Pending: true,
+			Changes: []string{"42"},
+		},
 		BuildID:        "1234",
 		Architecture:   "TI-99/4A",
 		Virtualization: "MESS",
@@ -22,6 +22,8 @@
 package clientutil
 
 import (
+	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -120,12 +122,37 @@ func ClientAppInfoNotes(app *client.AppInfo) string {
 	if seenDbus {
 		notes = append(notes, "dbus-activated")
 	}
+	if app.Failure != nil {
+		if app.Failure.Result != "success" {
+			notes = append(notes, fmt.Sprintf("failed=%s", app.Failure.Result))
+		}
+		if app.Failure.Restarts > 0 {
+			notes = append(notes, fmt.Sprintf("restarts=%d", app.Failure.Restarts))
+		}
+	}
 	if len(notes) == 0 {
 		return "-"
 	}
 	return strings.Join(notes, ",")
 }
 
+// serviceUnits returns the names of the systemd units generated for the
+// service of the given app: its service unit, then any socket units and
+// timer unit activating it.
+func serviceUnits(app *snap.AppInfo) []string {
+	units := []string{app.ServiceName()}
+	socketNames := make([]string, 0, len(app.Sockets))
+	for _, socket := range app.Sockets {
+		socketNames = append(socketNames, filepath.Base(socket.File()))
+	}
+	sort.Strings(socketNames)
+	units = append(units, socketNames...)
+	if app.Timer != nil {
+		units = append(units, filepath.Base(app.Timer.File()))
+	}
+	return units
+}
+
 // ClientAppInfosFromSnapAppInfos returns client.AppInfos derived from
 // the given snap.AppInfos.
 // If an optional StatusDecorator is provided it will be used to add
@@ -145,6 +172,9 @@ func ClientAppInfosFromSnapAppInfos(apps []*snap.AppInfo, decorator StatusDecora
 
 		appInfo.Daemon = app.Daemon
 		appInfo.DaemonScope = app.DaemonScope
+		if app.IsService() {
+			appInfo.Units = serviceUnits(app)
+		}
 		if !app.IsService() || decorator == nil || !app.Snap.IsActive() {
 			out = append(out, appInfo)
 			continue
@@ -31,6 +31,7 @@ import (
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -219,6 +220,7 @@ func (*cmdSuite) TestClientSnapFromSnapInfoAppsInactive(c *C) {
 			Name:        "svc",
 			Daemon:      "simple",
 			DaemonScope: snap.SystemDaemon,
+			Units:       []string{"snap.the-snap_insta.svc.service"},
 		},
 	})
 	// not called on inactive snaps
@@ -259,12 +261,56 @@ func (*cmdSuite) TestClientSnapFromSnapInfoAppsActive(c *C) {
 			DaemonScope: snap.SystemDaemon,
 			Enabled:     true,
 			Active:      true,
+			Units:       []string{"snap.the-snap_insta.svc.service"},
 		},
 	})
 
 	c.Check(sd.calls, Equals, 1)
 }
 
+func (*cmdSuite) TestClientAppInfosFromSnapAppInfosUnits(c *C) {
+	info := snaptest.MockInfo(c, `
+name: the-snap
+version: 1
+apps:
+  app:
+    command: bin/app
+  svc:
+    command: bin/svc
+    daemon: simple
+  timed:
+    command: bin/timed
+    daemon: oneshot
+    timer: 10:00
+  sockets:
+    command: bin/sockets
+    daemon: simple
+    plugs: [network-bind]
+    sockets:
+      sock2:
+        listen-stream: $SNAP_DATA/sock2
+      sock1:
+        listen-stream: $SNAP_DATA/sock1
+`, &snap.SideInfo{Revision: snap.R(1)})
+
+	apps, err := clientutil.ClientAppInfosFromSnapAppInfos(info.Services(), nil)
+	c.Assert(err, IsNil)
+	units := make(map[string][]string, len(apps))
+	for _, app := range apps {
+		units[app.Name] = app.Units
+	}
+	c.Check(units, DeepEquals, map[string][]string{
+		"svc":     {"snap.the-snap.svc.service"},
+		"timed":   {"snap.the-snap.timed.service", "snap.the-snap.timed.timer"},
+		"sockets": {"snap.the-snap.sockets.service", "snap.the-snap.sockets.sock1.socket", "snap.the-snap.sockets.sock2.socket"},
+	})
+
+	apps, err = clientutil.ClientAppInfosFromSnapAppInfos([]*snap.AppInfo{info.Apps["app"]}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(apps, HasLen, 1)
+	c.Check(apps[0].Units, IsNil)
+}
+
 func (*cmdSuite) TestAppStatusNotes(c *C) {
 	ai := client.AppInfo{}
 	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "-")
@@ -324,4 +370,12 @@ func (*cmdSuite) TestAppStatusNotes(c *C) {
 		},
 	}
 	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "user,timer-activated,socket-activated,dbus-activated")
+
+	ai = client.AppInfo{
+		Daemon:  "simple",
+		Failure: &client.AppFailure{Result: "success", Restarts: 2},
+	}
+	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "restarts=2")
+	ai.Failure = &client.AppFailure{Result: "signal", ExitCode: 9}
+	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "failed=signal")
 }
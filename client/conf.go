@@ -26,13 +26,31 @@ import (
 	"strings"
 )
 
+// SetConfOptions holds the options for applying a configuration patch.
+type SetConfOptions struct {
+	// RevertOnFailure requests the previous configuration to be restored
+	// if the change applying the patch fails, e.g. because restarting
+	// the services of the snap failed.
+	RevertOnFailure bool
+}
+
 // SetConf requests a snap to apply the provided patch to the configuration.
 func (client *Client) SetConf(snapName string, patch map[string]interface{}) (changeID string, err error) {
+	return client.SetConfWithOptions(snapName, patch, nil)
+}
+
+// SetConfWithOptions requests a snap to apply the provided patch to the
+// configuration with the given options.
+func (client *Client) SetConfWithOptions(snapName string, patch map[string]interface{}, opts *SetConfOptions) (changeID string, err error) {
 	b, err := json.Marshal(patch)
 	if err != nil {
 		return "", err
 	}
-	return client.doAsync("PUT", "/v2/snaps/"+snapName+"/conf", nil, nil, bytes.NewReader(b))
+	var query url.Values
+	if opts != nil && opts.RevertOnFailure {
+		query = url.Values{"revert-on-failure": []string{"true"}}
+	}
+	return client.doAsync("PUT", "/v2/snaps/"+snapName+"/conf", query, nil, bytes.NewReader(b))
 }
 
 // Conf asks for a snap's current configuration.
@@ -23,6 +23,8 @@ import (
 	"encoding/json"
 
 	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
 )
 
 func (cs *clientSuite) TestClientSetConfCallsEndpoint(c *check.C) {
@@ -31,6 +33,16 @@ func (cs *clientSuite) TestClientSetConfCallsEndpoint(c *check.C) {
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/snap-name/conf")
 }
 
+func (cs *clientSuite) TestClientSetConfWithOptions(c *check.C) {
+	cs.cli.SetConf("snap-name", map[string]interface{}{"key": "value"})
+	c.Check(cs.req.URL.Query().Get("revert-on-failure"), check.Equals, "")
+
+	cs.cli.SetConfWithOptions("snap-name", map[string]interface{}{"key": "value"}, &client.SetConfOptions{RevertOnFailure: true})
+	c.Check(cs.req.Method, check.Equals, "PUT")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/snap-name/conf")
+	c.Check(cs.req.URL.Query().Get("revert-on-failure"), check.Equals, "true")
+}
+
 func (cs *clientSuite) TestClientGetConfCallsEndpoint(c *check.C) {
 	cs.cli.Conf("snap-name", []string{"test-key"})
 	c.Check(cs.req.Method, check.Equals, "GET")
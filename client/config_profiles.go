@@ -0,0 +1,46 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type configProfileAction struct {
+	Action  string `json:"action"`
+	BrandID string `json:"brand-id,omitempty"`
+	Name    string `json:"name"`
+}
+
+// ApplyConfigProfile applies the configuration carried by the named
+// config-profile assertion, which must have been acknowledged first. If
+// brandID is empty the brand of the device is used. The result for each
+// snap is available in the data of the change once it is ready.
+func (client *Client) ApplyConfigProfile(brandID, name string) (changeID string, err error) {
+	b, err := json.Marshal(&configProfileAction{
+		Action:  "apply",
+		BrandID: brandID,
+		Name:    name,
+	})
+	if err != nil {
+		return "", err
+	}
+	return client.doAsync("POST", "/v2/config-profiles", nil, nil, bytes.NewReader(b))
+}
@@ -47,6 +47,18 @@ type Connections struct {
 	Undesired []Connection `json:"undesired"`
 	Plugs     []Plug       `json:"plugs"`
 	Slots     []Slot       `json:"slots"`
+	// Problems lists the plugs that were not connected automatically,
+	// when requested with ConnectionOptions.Problems.
+	Problems []AutoConnectProblem `json:"problems,omitempty"`
+}
+
+// AutoConnectProblem describes why a plug was not connected automatically.
+type AutoConnectProblem struct {
+	Plug      PlugRef `json:"plug"`
+	Interface string  `json:"interface"`
+	// Candidates lists the slots the plug could be connected to.
+	Candidates []SlotRef `json:"candidates,omitempty"`
+	Reason     string    `json:"reason"`
 }
 
 // ConnectionOptions contains criteria for selecting matching connections, plugs
@@ -60,6 +72,9 @@ type ConnectionOptions struct {
 	// All when true, selects established and undesired connections as well
 	// as all disconnected plugs and slots.
 	All bool
+	// Problems when true, also reports the plugs that were not
+	// connected automatically and why.
+	Problems bool
 }
 
 // Connections returns matching plugs, slots and their connections. Unless
@@ -76,6 +91,9 @@ func (client *Client) Connections(opts *ConnectionOptions) (Connections, error)
 	if opts != nil && opts.All {
 		query.Set("select", "all")
 	}
+	if opts != nil && opts.Problems {
+		query.Set("problems", "true")
+	}
 	_, err := client.doSync("GET", "/v2/connections", query, nil, nil, &conns)
 	return conns, err
 }
@@ -268,3 +268,36 @@ func (cs *clientSuite) TestClientConnectionsFilter(c *check.C) {
 		"snap":      []string{"foo"},
 	})
 }
+
+func (cs *clientSuite) TestClientConnectionsProblems(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"established": [],
+			"plugs": [],
+			"slots": [],
+			"problems": [
+				{
+					"plug": {"snap": "keyboard-lights", "plug": "capslock-led"},
+					"interface": "leds",
+					"candidates": [{"snap": "leds-provider", "slot": "capslock-led"}],
+					"reason": "auto-connection is not allowed by the snap declarations"
+				}
+			]
+		}
+	}`
+
+	conns, err := cs.cli.Connections(&client.ConnectionOptions{Snap: "keyboard-lights", Problems: true})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/connections")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"snap":     []string{"keyboard-lights"},
+		"problems": []string{"true"},
+	})
+	c.Check(conns.Problems, check.DeepEquals, []client.AutoConnectProblem{{
+		Plug:       client.PlugRef{Snap: "keyboard-lights", Name: "capslock-led"},
+		Interface:  "leds",
+		Candidates: []client.SlotRef{{Snap: "leds-provider", Name: "capslock-led"}},
+		Reason:     "auto-connection is not allowed by the snap declarations",
+	}})
+}
@@ -26,12 +26,27 @@ import "time"
 type InternalConsoleConfStartResponse struct {
 	ActiveAutoRefreshChanges []string `json:"active-auto-refreshes,omitempty"`
 	ActiveAutoRefreshSnaps   []string `json:"active-auto-refresh-snaps,omitempty"`
+	// Seeding is true if the device has not finished seeding yet.
+	Seeding bool `json:"seeding,omitempty"`
+	// ActiveDeviceInitChanges are the ids of the in-progress changes
+	// initializing the device.
+	ActiveDeviceInitChanges []string `json:"active-device-init-changes,omitempty"`
+	// StoreOffline is true if the store access is disabled with
+	// store.access=offline or the store cannot be reached.
+	StoreOffline bool `json:"store-offline,omitempty"`
+	// RefreshHeld is true if auto-refreshes are held by refresh.hold.
+	RefreshHeld bool `json:"refresh-held,omitempty"`
+}
+
+// Wait returns whether console-conf should wait before starting.
+func (r *InternalConsoleConfStartResponse) Wait() bool {
+	return r.Seeding || len(r.ActiveDeviceInitChanges) != 0 || len(r.ActiveAutoRefreshChanges) != 0
 }
 
 // InternalConsoleConfStart invokes the dedicated console-conf start support
-// to handle intervening auto-refreshes.
+// to handle intervening auto-refreshes and device initialization.
 // Not for general use.
-func (client *Client) InternalConsoleConfStart() ([]string, []string, error) {
+func (client *Client) InternalConsoleConfStart() (*InternalConsoleConfStartResponse, error) {
 	resp := &InternalConsoleConfStartResponse{}
 	// do the post with a short timeout so that if snapd is not available due to
 	// maintenance we will return very quickly so the caller can handle that
@@ -40,5 +55,8 @@ func (client *Client) InternalConsoleConfStart() ([]string, []string, error) {
 		Retry:   1 * time.Hour,
 	}
 	_, err := client.doSyncWithOpts("POST", "/v2/internal/console-conf-start", nil, nil, nil, resp, opts)
-	return resp.ActiveAutoRefreshChanges, resp.ActiveAutoRefreshSnaps, err
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
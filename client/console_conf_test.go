@@ -21,6 +21,8 @@ package client_test
 
 import (
 	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
 )
 
 func (cs *clientSuite) TestClientInternalConsoleConfEndpointEmpty(c *C) {
@@ -32,10 +34,11 @@ func (cs *clientSuite) TestClientInternalConsoleConfEndpointEmpty(c *C) {
         "result": {}
 	}`
 
-	chgs, snaps, err := cs.cli.InternalConsoleConfStart()
-	c.Assert(chgs, HasLen, 0)
-	c.Assert(snaps, HasLen, 0)
+	res, err := cs.cli.InternalConsoleConfStart()
 	c.Assert(err, IsNil)
+	c.Assert(res.ActiveAutoRefreshChanges, HasLen, 0)
+	c.Assert(res.ActiveAutoRefreshSnaps, HasLen, 0)
+	c.Check(res.Wait(), Equals, false)
 	c.Check(cs.req.Method, Equals, "POST")
 	c.Check(cs.req.URL.Path, Equals, "/v2/internal/console-conf-start")
 	c.Check(cs.doCalls, Equals, 1)
@@ -53,11 +56,52 @@ func (cs *clientSuite) TestClientInternalConsoleConfEndpoint(c *C) {
 		}
 	}`
 
-	chgs, snaps, err := cs.cli.InternalConsoleConfStart()
+	res, err := cs.cli.InternalConsoleConfStart()
 	c.Assert(err, IsNil)
-	c.Assert(chgs, DeepEquals, []string{"1"})
-	c.Assert(snaps, DeepEquals, []string{"pc-kernel"})
+	c.Assert(res.ActiveAutoRefreshChanges, DeepEquals, []string{"1"})
+	c.Assert(res.ActiveAutoRefreshSnaps, DeepEquals, []string{"pc-kernel"})
+	c.Check(res.Wait(), Equals, true)
 	c.Check(cs.req.Method, Equals, "POST")
 	c.Check(cs.req.URL.Path, Equals, "/v2/internal/console-conf-start")
 	c.Check(cs.doCalls, Equals, 1)
 }
+
+func (cs *clientSuite) TestClientInternalConsoleConfEndpointDeviceSetup(c *C) {
+	cs.status = 200
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+        "result": {
+			"seeding": true,
+			"active-device-init-changes": ["2"]
+		}
+	}`
+
+	res, err := cs.cli.InternalConsoleConfStart()
+	c.Assert(err, IsNil)
+	c.Check(res, DeepEquals, &client.InternalConsoleConfStartResponse{
+		Seeding:                 true,
+		ActiveDeviceInitChanges: []string{"2"},
+	})
+	c.Check(res.Wait(), Equals, true)
+}
+
+func (cs *clientSuite) TestClientInternalConsoleConfEndpointOfflineHeld(c *C) {
+	cs.status = 200
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+        "result": {
+			"store-offline": true,
+			"refresh-held": true
+		}
+	}`
+
+	res, err := cs.cli.InternalConsoleConfStart()
+	c.Assert(err, IsNil)
+	c.Check(res, DeepEquals, &client.InternalConsoleConfStartResponse{
+		StoreOffline: true,
+		RefreshHeld:  true,
+	})
+	c.Check(res.Wait(), Equals, false)
+}
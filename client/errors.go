@@ -142,6 +142,28 @@ const (
 
 	// ErrorKindValidationSetNotFound: validation set cannot be found.
 	ErrorKindValidationSetNotFound ErrorKind = "validation-set-not-found"
+
+	// ErrorKindTooManyChanges: the client has too many changes
+	// pending to request more. The error `value` is an object with
+	// the field `limit`.
+	ErrorKindTooManyChanges ErrorKind = "too-many-changes"
+
+	// ErrorKindRequestTooLarge: the request body is larger than
+	// allowed. The error `value` is an object with the field
+	// `limit`, in bytes.
+	ErrorKindRequestTooLarge ErrorKind = "request-too-large"
+
+	// ErrorKindRateLimited: the client made more requests than
+	// allowed by the configured rate limit. The error `value` is an
+	// object with the field `limit`, in requests per minute, and the
+	// Retry-After header says when to try again.
+	ErrorKindRateLimited ErrorKind = "rate-limited"
+
+	// ErrorKindTooManyOperations: too many expensive operations,
+	// like downloads or interface connections, are in progress. The
+	// error `value` is an object with the field `limit`, and the
+	// Retry-After header says when to try again.
+	ErrorKindTooManyOperations ErrorKind = "too-many-operations"
 )
 
 // Maintenance error kinds.
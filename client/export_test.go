@@ -23,6 +23,8 @@ import (
 	"encoding/json"
 	"io"
 	"net/url"
+
+	"github.com/snapcore/snapd/osutil/sys"
 )
 
 // SetDoer sets the client's doer to the given one
@@ -47,6 +49,22 @@ var TestStoreAuthFilename = storeAuthDataFilename
 
 var TestAuthFileEnvKey = authFileEnvKey
 
+func MockWriteUserAuthData(f func(targetFile string, data []byte, uid sys.UserID, gid sys.GroupID) error) (restore func()) {
+	old := writeUserAuthData
+	writeUserAuthData = f
+	return func() {
+		writeUserAuthData = old
+	}
+}
+
+func MockSysGeteuid(f func() sys.UserID) (restore func()) {
+	old := sysGeteuid
+	sysGeteuid = f
+	return func() {
+		sysGeteuid = old
+	}
+}
+
 func UnmarshalSnapshotAction(body io.Reader) (act snapshotAction, err error) {
 	err = json.NewDecoder(body).Decode(&act)
 	return
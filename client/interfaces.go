@@ -75,6 +75,27 @@ type InterfaceAction struct {
 	Forget bool   `json:"forget,omitempty"`
 	Plugs  []Plug `json:"plugs,omitempty"`
 	Slots  []Slot `json:"slots,omitempty"`
+	DryRun bool   `json:"dry-run,omitempty"`
+}
+
+// InterfaceActionEffects describes the effects an interface action would
+// have if it was performed.
+type InterfaceActionEffects struct {
+	// Connections lists the connections that would be established or
+	// removed.
+	Connections []Connection `json:"connections"`
+	// Backends lists the security backends that would regenerate the
+	// profiles of the affected snaps.
+	Backends []string `json:"backends,omitempty"`
+	// Profiles lists the security profiles of the affected snaps.
+	Profiles []SecurityProfiles `json:"profiles"`
+}
+
+// SecurityProfiles lists the security profiles of a snap by their
+// security tags.
+type SecurityProfiles struct {
+	Snap         string   `json:"snap"`
+	SecurityTags []string `json:"security-tags"`
 }
 
 // InterfaceOptions represents opt-in elements include in responses.
@@ -128,6 +149,20 @@ func (client *Client) performInterfaceAction(sa *InterfaceAction) (changeID stri
 	return client.doAsync("POST", "/v2/interfaces", nil, nil, bytes.NewReader(b))
 }
 
+// interfaceActionDryRun reports what performing the action would change.
+func (client *Client) interfaceActionDryRun(sa *InterfaceAction) (*InterfaceActionEffects, error) {
+	sa.DryRun = true
+	b, err := json.Marshal(sa)
+	if err != nil {
+		return nil, err
+	}
+	var effects InterfaceActionEffects
+	if _, err := client.doSync("POST", "/v2/interfaces", nil, nil, bytes.NewReader(b), &effects); err != nil {
+		return nil, err
+	}
+	return &effects, nil
+}
+
 // Connect establishes a connection between a plug and a slot.
 // The plug and the slot must have the same interface.
 func (client *Client) Connect(plugSnapName, plugName, slotSnapName, slotName string) (changeID string, err error) {
@@ -147,3 +182,26 @@ func (client *Client) Disconnect(plugSnapName, plugName, slotSnapName, slotName
 		Slots:  []Slot{{Snap: slotSnapName, Name: slotName}},
 	})
 }
+
+// ConnectDryRun reports which connections Connect would establish and
+// which security profiles would change as a result, without changing
+// anything. The plug name may be a pattern such as "*".
+func (client *Client) ConnectDryRun(plugSnapName, plugName, slotSnapName, slotName string) (*InterfaceActionEffects, error) {
+	return client.interfaceActionDryRun(&InterfaceAction{
+		Action: "connect",
+		Plugs:  []Plug{{Snap: plugSnapName, Name: plugName}},
+		Slots:  []Slot{{Snap: slotSnapName, Name: slotName}},
+	})
+}
+
+// DisconnectDryRun reports which connections Disconnect would remove and
+// which security profiles would change as a result, without changing
+// anything.
+func (client *Client) DisconnectDryRun(plugSnapName, plugName, slotSnapName, slotName string, opts *DisconnectOptions) (*InterfaceActionEffects, error) {
+	return client.interfaceActionDryRun(&InterfaceAction{
+		Action: "disconnect",
+		Forget: opts != nil && opts.Forget,
+		Plugs:  []Plug{{Snap: plugSnapName, Name: plugName}},
+		Slots:  []Slot{{Snap: slotSnapName, Name: slotName}},
+	})
+}
@@ -266,3 +266,62 @@ func (cs *clientSuite) TestClientDisconnectForget(c *check.C) {
 		},
 	})
 }
+
+func (cs *clientSuite) TestClientConnectDryRun(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"connections": [{"plug": {"snap": "producer", "plug": "plug"}, "slot": {"snap": "consumer", "slot": "slot"}, "interface": "test"}],
+			"backends": ["apparmor", "seccomp"],
+			"profiles": [{"snap": "consumer", "security-tags": ["snap.consumer.app"]}, {"snap": "producer", "security-tags": ["snap.producer.app"]}]
+		}
+	}`
+	effects, err := cs.cli.ConnectDryRun("producer", "*", "consumer", "slot")
+	c.Assert(err, check.IsNil)
+	c.Check(effects, check.DeepEquals, &client.InterfaceActionEffects{
+		Connections: []client.Connection{{
+			Plug:      client.PlugRef{Snap: "producer", Name: "plug"},
+			Slot:      client.SlotRef{Snap: "consumer", Name: "slot"},
+			Interface: "test",
+		}},
+		Backends: []string{"apparmor", "seccomp"},
+		Profiles: []client.SecurityProfiles{
+			{Snap: "consumer", SecurityTags: []string{"snap.consumer.app"}},
+			{Snap: "producer", SecurityTags: []string{"snap.producer.app"}},
+		},
+	})
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action":  "connect",
+		"dry-run": true,
+		"plugs": []interface{}{
+			map[string]interface{}{"snap": "producer", "plug": "*"},
+		},
+		"slots": []interface{}{
+			map[string]interface{}{"snap": "consumer", "slot": "slot"},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientDisconnectDryRun(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {"connections": [], "profiles": []}}`
+	effects, err := cs.cli.DisconnectDryRun("producer", "plug", "consumer", "slot", &client.DisconnectOptions{Forget: true})
+	c.Assert(err, check.IsNil)
+	c.Check(effects.Connections, check.HasLen, 0)
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action":  "disconnect",
+		"forget":  true,
+		"dry-run": true,
+		"plugs": []interface{}{
+			map[string]interface{}{"snap": "producer", "plug": "plug"},
+		},
+		"slots": []interface{}{
+			map[string]interface{}{"snap": "consumer", "slot": "slot"},
+		},
+	})
+}
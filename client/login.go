@@ -27,6 +27,7 @@ import (
 	"os/user"
 	"path/filepath"
 
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/sys"
 )
@@ -45,15 +46,31 @@ type loginData struct {
 	Email    string `json:"email,omitempty"`
 	Password string `json:"password,omitempty"`
 	Otp      string `json:"otp,omitempty"`
+
+	Macaroon   string   `json:"macaroon,omitempty"`
+	Discharges []string `json:"discharges,omitempty"`
 }
 
 // Login logs user in.
 func (client *Client) Login(email, password, otp string) (*User, error) {
-	postData := loginData{
+	return client.login(&loginData{
 		Email:    email,
 		Password: password,
 		Otp:      otp,
-	}
+	})
+}
+
+// LoginWithStoreMacaroon logs user in using store credentials, a root
+// macaroon and its discharges, that were obtained elsewhere. No email
+// address is recorded for the user as the store does not vouch for one.
+func (client *Client) LoginWithStoreMacaroon(macaroon string, discharges []string) (*User, error) {
+	return client.login(&loginData{
+		Macaroon:   macaroon,
+		Discharges: discharges,
+	})
+}
+
+func (client *Client) login(postData *loginData) (*User, error) {
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(postData); err != nil {
 		return nil, err
@@ -65,6 +82,9 @@ func (client *Client) Login(email, password, otp string) (*User, error) {
 	}
 
 	if err := writeAuthData(user); err != nil {
+		if reason := osutil.HomeNotWritableReason(err); reason != "" {
+			return nil, fmt.Errorf("cannot persist login information: %s %s, set %s to store it elsewhere", storeAuthDataFilename(""), reason, authFileEnvKey)
+		}
 		return nil, fmt.Errorf("cannot persist login information: %v", err)
 	}
 	return &user, nil
@@ -121,6 +141,25 @@ func realUidGid() (*user.User, sys.UserID, sys.GroupID, error) {
 	return real, uid, gid, err
 }
 
+// authDataFallbackFilename returns the file holding the authentication
+// details of the user with the given uid when they cannot be stored in the
+// home directory of the user.
+func authDataFallbackFilename(uid sys.UserID) string {
+	return filepath.Join(dirs.SnapAuthDataFallbackDir, fmt.Sprintf("%d.json", uid))
+}
+
+var sysGeteuid = sys.Geteuid
+
+var writeUserAuthData = func(targetFile string, data []byte, uid sys.UserID, gid sys.GroupID) error {
+	return sys.RunAsUidGid(uid, gid, func() error {
+		if err := os.MkdirAll(filepath.Dir(targetFile), 0700); err != nil {
+			return err
+		}
+
+		return osutil.AtomicWriteFile(targetFile, data, 0600, 0)
+	})
+}
+
 // writeAuthData saves authentication details for later reuse through ReadAuthData
 func writeAuthData(user User) error {
 	real, uid, gid, err := realUidGid()
@@ -135,13 +174,17 @@ func writeAuthData(user User) error {
 		return err
 	}
 
-	return sys.RunAsUidGid(uid, gid, func() error {
-		if err := os.MkdirAll(filepath.Dir(targetFile), 0700); err != nil {
-			return err
-		}
-
-		return osutil.AtomicWriteFile(targetFile, out, 0600, 0)
-	})
+	err = writeUserAuthData(targetFile, out, uid, gid)
+	if err == nil || osutil.HomeNotWritableReason(err) == "" || sysGeteuid() != 0 {
+		return err
+	}
+	// the home directory is read-only or on NFS with root squashing, keep
+	// the details in a directory only root can create files in instead,
+	// the file itself is owned by the user
+	if err := os.MkdirAll(dirs.SnapAuthDataFallbackDir, 0711); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFileChown(authDataFallbackFilename(uid), out, 0600, 0, uid, gid)
 }
 
 // readAuthData reads previously written authentication details
@@ -153,6 +196,9 @@ func readAuthData() (*User, error) {
 
 	var user User
 	sourceFile := storeAuthDataFilename("")
+	if fallback := authDataFallbackFilename(uid); !osutil.FileExists(sourceFile) && osutil.FileExists(fallback) {
+		sourceFile = fallback
+	}
 
 	if err := sys.RunAsUidGid(uid, gid, func() error {
 		f, err := os.Open(sourceFile)
@@ -180,7 +226,12 @@ func removeAuthData() error {
 
 	filename := storeAuthDataFilename("")
 
-	return sys.RunAsUidGid(uid, gid, func() error {
+	err = sys.RunAsUidGid(uid, gid, func() error {
 		return os.Remove(filename)
 	})
+	if fallback := authDataFallbackFilename(uid); os.IsNotExist(err) && osutil.FileExists(fallback) {
+		// the details were kept outside of the home directory
+		return os.Remove(fallback)
+	}
+	return err
 }
@@ -20,13 +20,18 @@
 package client_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"syscall"
 
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/sys"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -55,6 +60,33 @@ func (cs *clientSuite) TestClientLogin(c *check.C) {
 	c.Check(outfile, testutil.FileEquals, `{"username":"the-user-name","macaroon":"the-root-macaroon","discharges":["discharge-macaroon"]}`)
 }
 
+func (cs *clientSuite) TestClientLoginWithStoreMacaroon(c *check.C) {
+	cs.rsp = `{"type": "sync", "result":
+                     {"macaroon": "the-root-macaroon",
+                      "discharges": ["discharge-macaroon"]}}`
+
+	outfile := filepath.Join(c.MkDir(), "json")
+	os.Setenv(client.TestAuthFileEnvKey, outfile)
+	defer os.Unsetenv(client.TestAuthFileEnvKey)
+
+	user, err := cs.cli.LoginWithStoreMacaroon("store-macaroon", []string{"store-discharge"})
+	c.Check(err, check.IsNil)
+	c.Check(user, check.DeepEquals, &client.User{
+		Macaroon:   "the-root-macaroon",
+		Discharges: []string{"discharge-macaroon"}})
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/login")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"macaroon":   "store-macaroon",
+		"discharges": []interface{}{"store-discharge"},
+	})
+
+	c.Check(outfile, testutil.FileEquals, `{"macaroon":"the-root-macaroon","discharges":["discharge-macaroon"]}`)
+}
+
 func (cs *clientSuite) TestClientLoginWhenLoggedIn(c *check.C) {
 	cs.rsp = `{"type": "sync", "result":
                      {"username": "the-user-name",
@@ -110,6 +142,51 @@ func (cs *clientSuite) TestClientLoginError(c *check.C) {
 	c.Check(osutil.FileExists(outfile), check.Equals, false)
 }
 
+func (cs *clientSuite) TestClientLoginReadOnlyHome(c *check.C) {
+	cs.rsp = `{"type": "sync", "result":
+                     {"username": "the-user-name",
+                      "macaroon": "the-root-macaroon",
+                      "discharges": ["discharge-macaroon"]}}`
+
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+
+	outfile := filepath.Join(c.MkDir(), "json")
+	os.Setenv(client.TestAuthFileEnvKey, outfile)
+	defer os.Unsetenv(client.TestAuthFileEnvKey)
+
+	restore := client.MockWriteUserAuthData(func(targetFile string, data []byte, uid sys.UserID, gid sys.GroupID) error {
+		c.Check(targetFile, check.Equals, outfile)
+		return &os.PathError{Op: "mkdir", Path: targetFile, Err: syscall.EROFS}
+	})
+	defer restore()
+
+	// only root can fall back to a directory outside of the home
+	restore = client.MockSysGeteuid(func() sys.UserID { return 1000 })
+	_, err := cs.cli.Login("username", "pass", "")
+	restore()
+	c.Check(err, check.ErrorMatches, fmt.Sprintf(`cannot persist login information: %s is on a read-only filesystem, set SNAPD_AUTH_DATA_FILENAME to store it elsewhere`, outfile))
+
+	restore = client.MockSysGeteuid(func() sys.UserID { return 0 })
+	defer restore()
+	_, err = cs.cli.Login("username", "pass", "")
+	c.Assert(err, check.IsNil)
+
+	fallback := filepath.Join(dirs.SnapAuthDataFallbackDir, fmt.Sprintf("%d.json", os.Getuid()))
+	c.Check(fallback, testutil.FileEquals, `{"username":"the-user-name","macaroon":"the-root-macaroon","discharges":["discharge-macaroon"]}`)
+	fi, err := os.Stat(fallback)
+	c.Assert(err, check.IsNil)
+	c.Check(fi.Mode().Perm(), check.Equals, os.FileMode(0600))
+	c.Check(cs.cli.LoggedInUser(), check.DeepEquals, &client.User{
+		Username:   "the-user-name",
+		Macaroon:   "the-root-macaroon",
+		Discharges: []string{"discharge-macaroon"}})
+
+	cs.rsp = `{"type": "sync", "result": {}}`
+	c.Assert(cs.cli.Logout(), check.IsNil)
+	c.Check(fallback, testutil.FileAbsent)
+}
+
 func (cs *clientSuite) TestClientLogout(c *check.C) {
 	cs.rsp = `{"type": "sync", "result": {}}`
 
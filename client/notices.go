@@ -0,0 +1,144 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NoticeType is the type of a notice, it determines the meaning of its key.
+type NoticeType string
+
+const (
+	// ChangeUpdateNotice is recorded when a change is spawned or its
+	// status is updated, the key is the change ID.
+	ChangeUpdateNotice NoticeType = "change-update"
+	// WarningNotice is recorded when a warning is added or repeated,
+	// the key is the warning message.
+	WarningNotice NoticeType = "warning"
+	// MaintenanceNotice is recorded when snapd or the system is about
+	// to restart, the key is "daemon-restart" or "system-restart".
+	MaintenanceNotice NoticeType = "maintenance"
+)
+
+// A Notice records the occurrences of an event of interest, like a change
+// being updated or a warning being added. There is only ever one Notice
+// with the same type and key.
+type Notice struct {
+	ID            string            `json:"id"`
+	Type          NoticeType        `json:"type"`
+	Key           string            `json:"key"`
+	FirstOccurred time.Time         `json:"first-occurred"`
+	LastOccurred  time.Time         `json:"last-occurred"`
+	LastRepeated  time.Time         `json:"last-repeated"`
+	Occurrences   int               `json:"occurrences"`
+	LastData      map[string]string `json:"last-data,omitempty"`
+	RepeatAfter   time.Duration     `json:"repeat-after,omitempty"`
+	ExpireAfter   time.Duration     `json:"expire-after,omitempty"`
+}
+
+type jsonNotice struct {
+	Notice
+	RepeatAfter string `json:"repeat-after,omitempty"`
+	ExpireAfter string `json:"expire-after,omitempty"`
+}
+
+func (n *jsonNotice) toNotice() *Notice {
+	notice := n.Notice
+	notice.RepeatAfter, _ = time.ParseDuration(n.RepeatAfter)
+	notice.ExpireAfter, _ = time.ParseDuration(n.ExpireAfter)
+	return &notice
+}
+
+// NoticesOptions contains options for querying snapd for notices
+// supported options:
+// - Types: only return notices of these types.
+// - Keys: only return notices with these keys.
+// - After: only return notices last repeated after this time.
+type NoticesOptions struct {
+	Types []NoticeType
+	Keys  []string
+	After time.Time
+}
+
+func (opts *NoticesOptions) query() url.Values {
+	q := make(url.Values)
+	if opts == nil {
+		return q
+	}
+	if len(opts.Types) != 0 {
+		types := make([]string, len(opts.Types))
+		for i, t := range opts.Types {
+			types[i] = string(t)
+		}
+		q.Set("types", strings.Join(types, ","))
+	}
+	if len(opts.Keys) != 0 {
+		q.Set("keys", strings.Join(opts.Keys, ","))
+	}
+	if !opts.After.IsZero() {
+		q.Set("after", opts.After.Format(time.RFC3339Nano))
+	}
+	return q
+}
+
+// Notices returns the notices matching the given options, sorted by the
+// time they were last repeated.
+func (client *Client) Notices(opts *NoticesOptions) ([]*Notice, error) {
+	return client.notices(opts.query(), nil)
+}
+
+// WaitNotices waits up to serverTimeout for notices matching the given
+// options to be available and returns them. If no notices are available
+// within the timeout an empty list is returned. Use After to only wait
+// for notices newer than the ones already seen.
+func (client *Client) WaitNotices(serverTimeout time.Duration, opts *NoticesOptions) ([]*Notice, error) {
+	q := opts.query()
+	q.Set("timeout", serverTimeout.String())
+	// give the server a chance to reply before timing out the request
+	doOpts := &doOptions{
+		Timeout: serverTimeout + doTimeout,
+		Retry:   doRetry,
+	}
+	return client.notices(q, doOpts)
+}
+
+func (client *Client) notices(q url.Values, doOpts *doOptions) ([]*Notice, error) {
+	var jns []*jsonNotice
+	if _, err := client.doSyncWithOpts("GET", "/v2/notices", q, nil, nil, &jns, doOpts); err != nil {
+		return nil, err
+	}
+	notices := make([]*Notice, len(jns))
+	for i, jn := range jns {
+		notices[i] = jn.toNotice()
+	}
+	return notices, nil
+}
+
+// Notice returns the notice with the given ID.
+func (client *Client) Notice(id string) (*Notice, error) {
+	var jn jsonNotice
+	if _, err := client.doSync("GET", "/v2/notices/"+id, nil, nil, nil, &jn); err != nil {
+		return nil, err
+	}
+	return jn.toNotice(), nil
+}
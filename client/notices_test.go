@@ -0,0 +1,157 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+const noticesResponse = `{
+	"result": [
+	    {
+		"id": "1",
+		"type": "change-update",
+		"key": "42",
+		"first-occurred": "2023-09-19T12:41:18.505007495Z",
+		"last-occurred": "2023-09-19T12:44:19.680362867Z",
+		"last-repeated": "2023-09-19T12:44:19.680362867Z",
+		"occurrences": 3,
+		"last-data": {"kind": "install-snap", "status": "Done"},
+		"expire-after": "168h0m0s"
+	    },
+	    {
+		"id": "2",
+		"type": "warning",
+		"key": "danger",
+		"first-occurred": "2023-09-19T12:44:19.680362867Z",
+		"last-occurred": "2023-09-19T12:44:19.680362867Z",
+		"last-repeated": "2023-09-19T12:44:19.680362867Z",
+		"occurrences": 1,
+		"repeat-after": "24h0m0s",
+		"expire-after": "168h0m0s"
+	    }
+	],
+	"status": "OK",
+	"status-code": 200,
+	"type": "sync"
+}`
+
+func (cs *clientSuite) TestNotices(c *check.C) {
+	t1 := time.Date(2023, 9, 19, 12, 41, 18, 505007495, time.UTC)
+	t2 := time.Date(2023, 9, 19, 12, 44, 19, 680362867, time.UTC)
+	cs.rsp = noticesResponse
+
+	notices, err := cs.cli.Notices(nil)
+	c.Assert(err, check.IsNil)
+	c.Check(notices, check.DeepEquals, []*client.Notice{
+		{
+			ID:            "1",
+			Type:          client.ChangeUpdateNotice,
+			Key:           "42",
+			FirstOccurred: t1,
+			LastOccurred:  t2,
+			LastRepeated:  t2,
+			Occurrences:   3,
+			LastData:      map[string]string{"kind": "install-snap", "status": "Done"},
+			ExpireAfter:   7 * 24 * time.Hour,
+		},
+		{
+			ID:            "2",
+			Type:          client.WarningNotice,
+			Key:           "danger",
+			FirstOccurred: t2,
+			LastOccurred:  t2,
+			LastRepeated:  t2,
+			Occurrences:   1,
+			RepeatAfter:   24 * time.Hour,
+			ExpireAfter:   7 * 24 * time.Hour,
+		},
+	})
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/notices")
+	c.Check(cs.req.URL.Query(), check.HasLen, 0)
+}
+
+func (cs *clientSuite) TestNoticesFilters(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": []}`
+
+	after := time.Date(2023, 9, 19, 12, 41, 18, 505007495, time.UTC)
+	notices, err := cs.cli.Notices(&client.NoticesOptions{
+		Types: []client.NoticeType{client.ChangeUpdateNotice, client.MaintenanceNotice},
+		Keys:  []string{"42", "system-restart"},
+		After: after,
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(notices, check.HasLen, 0)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/notices")
+	q := cs.req.URL.Query()
+	c.Check(q.Get("types"), check.Equals, "change-update,maintenance")
+	c.Check(q.Get("keys"), check.Equals, "42,system-restart")
+	c.Check(q.Get("after"), check.Equals, "2023-09-19T12:41:18.505007495Z")
+	c.Check(q.Get("timeout"), check.Equals, "")
+}
+
+func (cs *clientSuite) TestWaitNotices(c *check.C) {
+	cs.rsp = noticesResponse
+
+	notices, err := cs.cli.WaitNotices(30*time.Second, &client.NoticesOptions{
+		Types: []client.NoticeType{client.WarningNotice},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(notices, check.HasLen, 2)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/notices")
+	q := cs.req.URL.Query()
+	c.Check(q.Get("types"), check.Equals, "warning")
+	c.Check(q.Get("timeout"), check.Equals, "30s")
+}
+
+func (cs *clientSuite) TestNotice(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": {
+		"id": "2",
+		"type": "warning",
+		"key": "danger",
+		"occurrences": 1,
+		"repeat-after": "24h0m0s"
+	}}`
+
+	notice, err := cs.cli.Notice("2")
+	c.Assert(err, check.IsNil)
+	c.Check(notice, check.DeepEquals, &client.Notice{
+		ID:          "2",
+		Type:        client.WarningNotice,
+		Key:         "danger",
+		Occurrences: 1,
+		RepeatAfter: 24 * time.Hour,
+	})
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/notices/2")
+}
+
+func (cs *clientSuite) TestNoticeNotFound(c *check.C) {
+	cs.status = 404
+	cs.rsp = `{"type": "error", "status-code": 404, "result": {"message": "cannot find notice with ID \"3\""}}`
+
+	_, err := cs.cli.Notice("3")
+	c.Check(err, check.ErrorMatches, `cannot find notice with ID "3"`)
+}
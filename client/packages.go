@@ -138,6 +138,12 @@ type FindOptions struct {
 	Private bool
 	Scope   string
 
+	// Publisher restricts the search to snaps from the given publisher
+	Publisher string
+	// Interface restricts the search to snaps providing a slot of the
+	// given interface
+	Interface string
+
 	Refresh bool
 }
 
@@ -237,6 +243,12 @@ func (client *Client) Find(opts *FindOptions) ([]*Snap, *ResultInfo, error) {
 	if opts.Scope != "" {
 		q.Set("scope", opts.Scope)
 	}
+	if opts.Publisher != "" {
+		q.Set("publisher", opts.Publisher)
+	}
+	if opts.Interface != "" {
+		q.Set("interface", opts.Interface)
+	}
 
 	return client.snapsFromPath("/v2/find", q)
 }
@@ -119,6 +119,19 @@ func (cs *clientSuite) TestClientFindWithScopeSetsQuery(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientFindWithPublisherAndInterfaceSetsQuery(c *check.C) {
+	_, _, _ = cs.cli.Find(&client.FindOptions{
+		Publisher: "canonical",
+		Interface: "content",
+	})
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/find")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"publisher": []string{"canonical"},
+		"interface": []string{"content"},
+	})
+}
+
 func (cs *clientSuite) TestClientSnapsInvalidSnapsJSON(c *check.C) {
 	cs.rsp = `{
 		"type": "sync",
@@ -28,6 +28,8 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+
+	"github.com/snapcore/snapd/snap"
 )
 
 // TransactionType says whether we want to treat each snap separately
@@ -56,12 +58,15 @@ type SnapOptions struct {
 	Unaliased        bool            `json:"unaliased,omitempty"`
 	Prefer           bool            `json:"prefer,omitempty"`
 	Purge            bool            `json:"purge,omitempty"`
+	Terminate        bool            `json:"terminate,omitempty"`
+	DataOnly         bool            `json:"data-only,omitempty"`
 	Amend            bool            `json:"amend,omitempty"`
 	Transaction      TransactionType `json:"transaction,omitempty"`
 	QuotaGroupName   string          `json:"quota-group,omitempty"`
 	ValidationSets   []string        `json:"validation-sets,omitempty"`
 	Time             string          `json:"time,omitempty"`
 	HoldLevel        string          `json:"hold-level,omitempty"`
+	QueueOnConflict  bool            `json:"queue-on-conflict,omitempty"`
 
 	Users []string `json:"users,omitempty"`
 }
@@ -121,6 +126,7 @@ type actionData struct {
 	Action   string `json:"action"`
 	Name     string `json:"name,omitempty"`
 	SnapPath string `json:"snap-path,omitempty"`
+	DryRun   bool   `json:"dry-run,omitempty"`
 	*SnapOptions
 }
 
@@ -131,9 +137,11 @@ type multiActionData struct {
 	Transaction    TransactionType `json:"transaction,omitempty"`
 	IgnoreRunning  bool            `json:"ignore-running,omitempty"`
 	Purge          bool            `json:"purge,omitempty"`
+	Terminate      bool            `json:"terminate,omitempty"`
 	ValidationSets []string        `json:"validation-sets,omitempty"`
 	Time           string          `json:"time,omitempty"`
 	HoldLevel      string          `json:"hold-level,omitempty"`
+	DryRun         bool            `json:"dry-run,omitempty"`
 }
 
 // Install adds the snap with the given name from the given channel (or
@@ -219,6 +227,68 @@ func (client *Client) SnapshotMany(names []string, users []string) (setID uint64
 
 var ErrDangerousNotApplicable = fmt.Errorf("dangerous option only meaningful when installing from a local file")
 
+// BatchAction is one of the actions of a batch: install, refresh or
+// remove a single snap. Actions of the same kind are performed together,
+// so only the purge and terminate options of remove actions are supported.
+type BatchAction struct {
+	Action string `json:"action"`
+	Snap   string `json:"snap"`
+	*SnapOptions
+}
+
+// BatchActionResult is the outcome of one of the actions of a batch:
+// either the summary and the IDs of the tasks performing the action or
+// the error that prevented it.
+type BatchActionResult struct {
+	Action  string   `json:"action"`
+	Snap    string   `json:"snap"`
+	Summary string   `json:"summary,omitempty"`
+	TaskIDs []string `json:"task-ids,omitempty"`
+	Error   *Error   `json:"error,omitempty"`
+}
+
+type batchData struct {
+	Action      string          `json:"action"`
+	Actions     []*BatchAction  `json:"actions"`
+	Transaction TransactionType `json:"transaction,omitempty"`
+}
+
+// Batch performs several install, refresh and remove actions, each on a
+// different snap, in a single change. Unless the transaction is for all
+// snaps, actions that cannot be performed are reported in the results
+// without failing the others.
+func (client *Client) Batch(actions []*BatchAction, transaction TransactionType) (changeID string, results []*BatchActionResult, err error) {
+	for _, action := range actions {
+		if action.SnapOptions != nil && action.SnapOptions.Dangerous {
+			return "", nil, ErrDangerousNotApplicable
+		}
+	}
+	data, err := json.Marshal(&batchData{
+		Action:      "batch",
+		Actions:     actions,
+		Transaction: transaction,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot marshal batch action: %s", err)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	result, changeID, err := client.doAsyncFull("POST", "/v2/snaps", nil, headers, bytes.NewBuffer(data), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	var batchResult struct {
+		Results []*BatchActionResult `json:"results"`
+	}
+	if err := json.Unmarshal(result, &batchResult); err != nil {
+		return "", nil, fmt.Errorf("cannot unmarshal batch results: %v", err)
+	}
+	return changeID, batchResult.Results, nil
+}
+
 func (client *Client) doSnapAction(actionName string, snapName string, options *SnapOptions) (changeID string, err error) {
 	if options != nil && options.Dangerous {
 		return "", ErrDangerousNotApplicable
@@ -246,8 +316,8 @@ func (client *Client) doMultiSnapAction(actionName string, snaps []string, optio
 	return changeID, err
 }
 
-func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, options *SnapOptions) (result json.RawMessage, changeID string, err error) {
-	action := multiActionData{
+func newMultiActionData(actionName string, snaps []string, options *SnapOptions) *multiActionData {
+	action := &multiActionData{
 		Action: actionName,
 		Snaps:  snaps,
 	}
@@ -257,12 +327,18 @@ func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, o
 		action.Transaction = options.Transaction
 		action.IgnoreRunning = options.IgnoreRunning
 		action.Purge = options.Purge
+		action.Terminate = options.Terminate
 		action.ValidationSets = options.ValidationSets
 		action.Time = options.Time
 		action.HoldLevel = options.HoldLevel
 	}
+	return action
+}
 
-	data, err := json.Marshal(&action)
+func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, options *SnapOptions) (result json.RawMessage, changeID string, err error) {
+	action := newMultiActionData(actionName, snaps, options)
+
+	data, err := json.Marshal(action)
 	if err != nil {
 		return nil, "", fmt.Errorf("cannot marshal multi-snap action: %s", err)
 	}
@@ -274,6 +350,87 @@ func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, o
 	return client.doAsyncFull("POST", "/v2/snaps", nil, headers, bytes.NewBuffer(data), nil)
 }
 
+// SnapActionPlan describes what a snap action would do if it was
+// performed.
+type SnapActionPlan struct {
+	// Tasks lists the tasks the action would run, the IDs of the tasks
+	// are only meaningful within the plan.
+	Tasks []PlannedTask `json:"tasks"`
+	// Downloads lists the snaps the action would download.
+	Downloads []PlannedDownload `json:"downloads,omitempty"`
+	// DownloadSize is the total size of the downloads in bytes.
+	DownloadSize int64 `json:"download-size"`
+	// Connections lists how the action would affect the connections of
+	// the snaps.
+	Connections []PlannedConnections `json:"connections,omitempty"`
+}
+
+// PlannedTask is a task of a SnapActionPlan.
+type PlannedTask struct {
+	ID        string   `json:"id"`
+	Kind      string   `json:"kind"`
+	Summary   string   `json:"summary"`
+	WaitTasks []string `json:"wait-tasks,omitempty"`
+}
+
+// PlannedDownload is a snap a SnapActionPlan would download.
+type PlannedDownload struct {
+	Snap     string        `json:"snap"`
+	Revision snap.Revision `json:"revision"`
+	Channel  string        `json:"channel,omitempty"`
+	Size     int64         `json:"size"`
+}
+
+// PlannedConnections describes how a SnapActionPlan would affect the
+// connections of a snap. Action is either "auto-connect", in which case
+// the connections are only known once the snap is installed, or
+// "disconnect" with the connections that would be removed.
+type PlannedConnections struct {
+	Snap        string       `json:"snap"`
+	Action      string       `json:"action"`
+	Connections []Connection `json:"connections,omitempty"`
+}
+
+// SnapActionDryRun reports what performing the action on the snap with
+// the given name would do, without changing anything.
+func (client *Client) SnapActionDryRun(actionName, snapName string, options *SnapOptions) (*SnapActionPlan, error) {
+	if options != nil && options.Dangerous {
+		return nil, ErrDangerousNotApplicable
+	}
+	data, err := json.Marshal(&actionData{
+		Action:      actionName,
+		DryRun:      true,
+		SnapOptions: options,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal snap action: %s", err)
+	}
+	return client.snapActionDryRun(fmt.Sprintf("/v2/snaps/%s", snapName), data)
+}
+
+// MultiSnapActionDryRun reports what performing the action on the snaps
+// with the given names would do, without changing anything.
+func (client *Client) MultiSnapActionDryRun(actionName string, snaps []string, options *SnapOptions) (*SnapActionPlan, error) {
+	action := newMultiActionData(actionName, snaps, options)
+	action.DryRun = true
+	data, err := json.Marshal(action)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal multi-snap action: %s", err)
+	}
+	return client.snapActionDryRun("/v2/snaps", data)
+}
+
+func (client *Client) snapActionDryRun(path string, data []byte) (*SnapActionPlan, error) {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	var plan SnapActionPlan
+	if _, err := client.doSync("POST", path, nil, headers, bytes.NewReader(data), &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
 // InstallPath sideloads the snap with the given path under optional provided name,
 // returning the UUID of the background operation upon success.
 func (client *Client) InstallPath(path, name string, options *SnapOptions) (changeID string, err error) {
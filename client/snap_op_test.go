@@ -34,6 +34,7 @@ import (
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -254,6 +255,78 @@ func (cs *clientSuite) TestClientMultiOpSnapIgnoreRunning(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientRemoveManyTerminate(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	id, err := cs.cli.RemoveMany([]string{pkgName}, &client.SnapOptions{Purge: true, Terminate: true})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "d728")
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var jsonBody map[string]interface{}
+	c.Assert(json.Unmarshal(body, &jsonBody), check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":    "remove",
+		"snaps":     []interface{}{pkgName},
+		"purge":     true,
+		"terminate": true,
+	})
+}
+
+func (cs *clientSuite) TestClientBatch(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"result": {"results": [
+			{"action": "install", "snap": "foo", "summary": "Install \"foo\" snap", "task-ids": ["1", "2"]},
+			{"action": "remove", "snap": "bar", "error": {"message": "snap \"bar\" is not installed", "kind": "snap-not-installed"}}
+		]},
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	id, results, err := cs.cli.Batch([]*client.BatchAction{
+		{Action: "install", Snap: "foo", SnapOptions: &client.SnapOptions{Channel: "edge"}},
+		{Action: "remove", Snap: "bar"},
+	}, client.TransactionPerSnap)
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "d728")
+	c.Check(results, check.DeepEquals, []*client.BatchActionResult{
+		{Action: "install", Snap: "foo", Summary: `Install "foo" snap`, TaskIDs: []string{"1", "2"}},
+		{Action: "remove", Snap: "bar", Error: &client.Error{
+			Kind:    client.ErrorKindSnapNotInstalled,
+			Message: `snap "bar" is not installed`,
+		}},
+	})
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var jsonBody map[string]interface{}
+	c.Assert(json.Unmarshal(body, &jsonBody), check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":      "batch",
+		"transaction": "per-snap",
+		"actions": []interface{}{
+			map[string]interface{}{"action": "install", "snap": "foo", "channel": "edge"},
+			map[string]interface{}{"action": "remove", "snap": "bar"},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientBatchDangerous(c *check.C) {
+	_, _, err := cs.cli.Batch([]*client.BatchAction{
+		{Action: "install", Snap: "foo", SnapOptions: &client.SnapOptions{Dangerous: true}},
+	}, "")
+	c.Check(err, check.Equals, client.ErrDangerousNotApplicable)
+}
+
 func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 	// Note body is essentially the same as TestClientMultiOpSnap; keep in sync
 	cs.status = 202
@@ -740,6 +813,8 @@ func (cs *clientSuite) TestSnapOptionsSerialises(c *check.C) {
 		`{"ignore-validation":true}`: {IgnoreValidation: true},
 		`{"unaliased":true}`:         {Unaliased: true},
 		`{"purge":true}`:             {Purge: true},
+		`{"terminate":true}`:         {Terminate: true},
+		`{"data-only":true}`:         {DataOnly: true},
 		`{"amend":true}`:             {Amend: true},
 		`{"prefer":true}`:            {Prefer: true},
 	}
@@ -915,3 +990,57 @@ func (cs *clientSuite) TestClientHoldMany(c *check.C) {
 	})
 	c.Check(cs.req.Header["Content-Type"], check.DeepEquals, []string{"application/json"})
 }
+
+func (cs *clientSuite) TestClientSnapActionDryRun(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"tasks": [
+				{"id": "1", "kind": "download-snap", "summary": "Download snap \"foo\""},
+				{"id": "2", "kind": "auto-connect", "summary": "Automatically connect", "wait-tasks": ["1"]}
+			],
+			"downloads": [{"snap": "foo", "revision": "7", "channel": "stable", "size": 1234}],
+			"download-size": 1234,
+			"connections": [{"snap": "foo", "action": "auto-connect"}]
+		}
+	}`
+	plan, err := cs.cli.SnapActionDryRun("refresh", "foo", &client.SnapOptions{Channel: "stable"})
+	c.Assert(err, check.IsNil)
+	c.Check(plan, check.DeepEquals, &client.SnapActionPlan{
+		Tasks: []client.PlannedTask{
+			{ID: "1", Kind: "download-snap", Summary: `Download snap "foo"`},
+			{ID: "2", Kind: "auto-connect", Summary: "Automatically connect", WaitTasks: []string{"1"}},
+		},
+		Downloads:    []client.PlannedDownload{{Snap: "foo", Revision: snap.R(7), Channel: "stable", Size: 1234}},
+		DownloadSize: 1234,
+		Connections:  []client.PlannedConnections{{Snap: "foo", Action: "auto-connect"}},
+	})
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action":  "refresh",
+		"channel": "stable",
+		"dry-run": true,
+	})
+
+	_, err = cs.cli.SnapActionDryRun("install", "foo", &client.SnapOptions{Dangerous: true})
+	c.Check(err, check.Equals, client.ErrDangerousNotApplicable)
+}
+
+func (cs *clientSuite) TestClientMultiSnapActionDryRun(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {"tasks": [], "download-size": 0}}`
+	plan, err := cs.cli.MultiSnapActionDryRun("refresh", nil, &client.SnapOptions{IgnoreRunning: true})
+	c.Assert(err, check.IsNil)
+	c.Check(plan.Tasks, check.HasLen, 0)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action":         "refresh",
+		"ignore-running": true,
+		"dry-run":        true,
+	})
+}
@@ -0,0 +1,59 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SnapSizes holds the disk usage of an installed snap, in bytes.
+type SnapSizes struct {
+	Snap string `json:"snap"`
+	// BlobSize is the size of the snap files of all the revisions
+	// kept on the system.
+	BlobSize uint64 `json:"blob-size"`
+	// DataSize is the size of the system data of all the revisions
+	// kept on the system.
+	DataSize uint64 `json:"data-size"`
+	// CommonDataSize is the size of the system data shared across
+	// revisions.
+	CommonDataSize uint64 `json:"common-data-size"`
+	// SnapshotSize is the size of the snapshots of the snap.
+	SnapshotSize uint64 `json:"snapshot-size"`
+}
+
+// Total returns the sum of all the sizes.
+func (sz *SnapSizes) Total() uint64 {
+	return sz.BlobSize + sz.DataSize + sz.CommonDataSize + sz.SnapshotSize
+}
+
+// SnapSizes returns the disk usage of the given installed snaps, or of
+// all of them if none are given.
+func (client *Client) SnapSizes(snapNames []string) ([]*SnapSizes, error) {
+	q := make(url.Values)
+	if len(snapNames) > 0 {
+		q.Add("snaps", strings.Join(snapNames, ","))
+	}
+
+	var sizes []*SnapSizes
+	_, err := client.doSync("GET", "/v2/snap-sizes", q, nil, nil, &sizes)
+	return sizes, err
+}
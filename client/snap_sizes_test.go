@@ -0,0 +1,52 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestClientSnapSizes(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [
+{"snap": "foo", "blob-size": 100, "data-size": 20, "common-data-size": 3, "snapshot-size": 4000}
+]}`
+
+	sizes, err := cs.cli.SnapSizes([]string{"foo", "bar"})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snap-sizes")
+	c.Check(cs.req.URL.Query().Get("snaps"), check.Equals, "foo,bar")
+
+	c.Assert(sizes, check.DeepEquals, []*client.SnapSizes{
+		{Snap: "foo", BlobSize: 100, DataSize: 20, CommonDataSize: 3, SnapshotSize: 4000},
+	})
+	c.Check(sizes[0].Total(), check.Equals, uint64(4123))
+}
+
+func (cs *clientSuite) TestClientSnapSizesAll(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": []}`
+
+	sizes, err := cs.cli.SnapSizes(nil)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+	c.Check(sizes, check.HasLen, 0)
+}
@@ -53,6 +53,8 @@ type System struct {
 	Brand snap.StoreAccount `json:"brand,omitempty"`
 	// Actions available for this system
 	Actions []SystemAction `json:"actions,omitempty"`
+	// Broken is set to the reason the system cannot be used, if any
+	Broken string `json:"broken,omitempty"`
 }
 
 type SystemAction struct {
@@ -221,6 +223,30 @@ type InstallSystemOptions struct {
 	OnVolumes map[string]*gadget.Volume `json:"on-volumes,omitempty"`
 }
 
+// CreateSystem requests the creation of a new recovery system with the
+// given label from the snaps of the running system.
+func (client *Client) CreateSystem(systemLabel string) (changeID string, err error) {
+	if systemLabel == "" {
+		return "", fmt.Errorf("cannot create a system with an empty label")
+	}
+
+	req := struct {
+		Action string `json:"action"`
+	}{
+		Action: "create",
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&req); err != nil {
+		return "", err
+	}
+	chgID, err := client.doAsync("POST", "/v2/systems/"+systemLabel, nil, nil, &body)
+	if err != nil {
+		return "", xerrors.Errorf("cannot request creation of system %q: %v", systemLabel, err)
+	}
+	return chgID, nil
+}
+
 // InstallSystem will perform the given install step for the given volumes
 func (client *Client) InstallSystem(systemLabel string, opts *InstallSystemOptions) (changeID string, err error) {
 	if systemLabel == "" {
@@ -335,6 +335,42 @@ func (cs *clientSuite) TestRequestSystemInstallEmptySystemLabel(c *check.C) {
 	c.Check(cs.req, check.IsNil)
 }
 
+func (cs *clientSuite) TestRequestSystemCreateHappy(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+	chgID, err := cs.cli.CreateSystem("1234")
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/systems/1234")
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]interface{}{
+		"action": "create",
+	})
+}
+
+func (cs *clientSuite) TestRequestSystemCreateError(c *check.C) {
+	cs.rsp = `{
+	    "type": "error",
+	    "status-code": 400,
+	    "result": {"message": "failed"}
+	}`
+	_, err := cs.cli.CreateSystem("1234")
+	c.Assert(err, check.ErrorMatches, `cannot request creation of system "1234": failed`)
+
+	_, err = cs.cli.CreateSystem("")
+	c.Assert(err, check.ErrorMatches, `cannot create a system with an empty label`)
+}
+
 func (cs *clientSuite) TestRequestSystemInstallHappy(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
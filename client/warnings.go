@@ -37,6 +37,10 @@ type Warning struct {
 	LastShown   time.Time     `json:"last-shown,omitempty"`
 	ExpireAfter time.Duration `json:"expire-after,omitempty"`
 	RepeatAfter time.Duration `json:"repeat-after,omitempty"`
+	// Count is the number of times the warning occurred
+	Count int `json:"count,omitempty"`
+	// Severity is how important the warning is: low, normal or high
+	Severity string `json:"severity,omitempty"`
 }
 
 type jsonWarning struct {
@@ -38,7 +38,9 @@ func (cs *clientSuite) testWarnings(c *check.C, all bool) {
 			"first-added": "2018-09-19T12:41:18.505007495Z",
 			"last-added": "2018-09-19T12:41:18.505007495Z",
 			"message": "hello world number one",
-			"repeat-after": "24h0m0s"
+			"repeat-after": "24h0m0s",
+			"count": 2,
+			"severity": "high"
 		    },
 		    {
 			"expire-after": "672h0m0s",
@@ -64,6 +66,8 @@ func (cs *clientSuite) testWarnings(c *check.C, all bool) {
 			LastAdded:   t1,
 			ExpireAfter: time.Hour * 24 * 28,
 			RepeatAfter: time.Hour * 24,
+			Count:       2,
+			Severity:    "high",
 		},
 		{
 			Message:     "hello world number two",
@@ -23,10 +23,9 @@ import (
 	"net/url"
 	"time"
 
-	"gopkg.in/retry.v1"
-
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/retryutil"
 )
 
 var (
@@ -46,19 +45,19 @@ func MockBaseURL(baseurl string) (restore func()) {
 	}
 }
 
-func MockFetchRetryStrategy(strategy retry.Strategy) (restore func()) {
-	originalFetchRetryStrategy := fetchRetryStrategy
-	fetchRetryStrategy = strategy
+func MockFetchRetryPolicy(policy retryutil.Policy) (restore func()) {
+	originalFetchRetryPolicy := fetchRetryPolicy
+	fetchRetryPolicy = policy
 	return func() {
-		fetchRetryStrategy = originalFetchRetryStrategy
+		fetchRetryPolicy = originalFetchRetryPolicy
 	}
 }
 
-func MockPeekRetryStrategy(strategy retry.Strategy) (restore func()) {
-	originalPeekRetryStrategy := peekRetryStrategy
-	peekRetryStrategy = strategy
+func MockPeekRetryPolicy(policy retryutil.Policy) (restore func()) {
+	originalPeekRetryPolicy := peekRetryPolicy
+	peekRetryPolicy = policy
 	return func() {
-		peekRetryStrategy = originalPeekRetryStrategy
+		peekRetryPolicy = originalPeekRetryPolicy
 	}
 }
 
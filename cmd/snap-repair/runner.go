@@ -39,7 +39,6 @@ import (
 	"time"
 
 	"github.com/mvo5/goconfigparser"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/arch"
 	"github.com/snapcore/snapd/asserts"
@@ -50,6 +49,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/strutil"
@@ -306,19 +306,21 @@ func NewRunner() *Runner {
 }
 
 var (
-	fetchRetryStrategy = retry.LimitCount(7, retry.LimitTime(90*time.Second,
-		retry.Exponential{
+	fetchRetryPolicy = retryutil.Policy{
+		Strategy: retryutil.Exponential{
 			Initial: 500 * time.Millisecond,
 			Factor:  2.5,
 		},
-	))
+		Limits: retryutil.Limits{MaxAttempts: 7, MaxElapsed: 90 * time.Second},
+	}
 
-	peekRetryStrategy = retry.LimitCount(6, retry.LimitTime(44*time.Second,
-		retry.Exponential{
+	peekRetryPolicy = retryutil.Policy{
+		Strategy: retryutil.Exponential{
 			Initial: 500 * time.Millisecond,
 			Factor:  2.5,
 		},
-	))
+		Limits: retryutil.Limits{MaxAttempts: 6, MaxElapsed: 44 * time.Second},
+	}
 )
 
 var (
@@ -376,7 +378,7 @@ func (run *Runner) Fetch(brandID string, repairID int, revision int) (*asserts.R
 			}
 		}
 		return nil
-	}, fetchRetryStrategy)
+	}, fetchRetryPolicy)
 
 	if err != nil {
 		return nil, nil, err
@@ -465,7 +467,7 @@ func (run *Runner) Peek(brandID string, repairID int) (headers map[string]interf
 			return dec.Decode(&rsp)
 		}
 		return nil
-	}, peekRetryStrategy)
+	}, peekRetryPolicy)
 
 	if err != nil {
 		return nil, err
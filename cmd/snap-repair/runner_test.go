@@ -35,7 +35,6 @@ import (
 	"time"
 
 	. "gopkg.in/check.v1"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/arch"
 	"github.com/snapcore/snapd/asserts"
@@ -46,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -317,16 +317,17 @@ func (s *runnerSuite) TestFetchScriptTooBig(c *C) {
 }
 
 var (
-	testRetryStrategy = retry.LimitCount(5, retry.LimitTime(1*time.Second,
-		retry.Exponential{
+	testRetryPolicy = retryutil.Policy{
+		Strategy: retryutil.Exponential{
 			Initial: 1 * time.Millisecond,
 			Factor:  1,
 		},
-	))
+		Limits: retryutil.Limits{MaxAttempts: 5, MaxElapsed: 1 * time.Second},
+	}
 )
 
 func (s *runnerSuite) TestFetch500(c *C) {
-	restore := repair.MockFetchRetryStrategy(testRetryStrategy)
+	restore := repair.MockFetchRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	n := 0
@@ -347,7 +348,7 @@ func (s *runnerSuite) TestFetch500(c *C) {
 }
 
 func (s *runnerSuite) TestFetchEmpty(c *C) {
-	restore := repair.MockFetchRetryStrategy(testRetryStrategy)
+	restore := repair.MockFetchRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	n := 0
@@ -368,7 +369,7 @@ func (s *runnerSuite) TestFetchEmpty(c *C) {
 }
 
 func (s *runnerSuite) TestFetchBroken(c *C) {
-	restore := repair.MockFetchRetryStrategy(testRetryStrategy)
+	restore := repair.MockFetchRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	n := 0
@@ -390,7 +391,7 @@ func (s *runnerSuite) TestFetchBroken(c *C) {
 }
 
 func (s *runnerSuite) TestFetchNotFound(c *C) {
-	restore := repair.MockFetchRetryStrategy(testRetryStrategy)
+	restore := repair.MockFetchRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	n := 0
@@ -416,7 +417,7 @@ func (s *runnerSuite) TestFetchNotFound(c *C) {
 }
 
 func (s *runnerSuite) TestFetchIfNoneMatchNotModified(c *C) {
-	restore := repair.MockFetchRetryStrategy(testRetryStrategy)
+	restore := repair.MockFetchRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	n := 0
@@ -541,7 +542,7 @@ func (s *runnerSuite) TestPeek(c *C) {
 }
 
 func (s *runnerSuite) TestPeek500(c *C) {
-	restore := repair.MockPeekRetryStrategy(testRetryStrategy)
+	restore := repair.MockPeekRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	n := 0
@@ -562,7 +563,7 @@ func (s *runnerSuite) TestPeek500(c *C) {
 }
 
 func (s *runnerSuite) TestPeekInvalid(c *C) {
-	restore := repair.MockPeekRetryStrategy(testRetryStrategy)
+	restore := repair.MockPeekRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	n := 0
@@ -1162,7 +1163,7 @@ AXNpZw==`
 }
 
 func (s *runnerSuite) TestNext500(c *C) {
-	restore := repair.MockPeekRetryStrategy(testRetryStrategy)
+	restore := repair.MockPeekRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1183,7 +1184,7 @@ func (s *runnerSuite) TestNext500(c *C) {
 func (s *runnerSuite) TestNextNotFound(c *C) {
 	s.freshState(c)
 
-	restore := repair.MockPeekRetryStrategy(testRetryStrategy)
+	restore := repair.MockPeekRetryPolicy(testRetryPolicy)
 	defer restore()
 
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -25,12 +25,18 @@ import (
 	"github.com/snapcore/snapd/i18n"
 )
 
-type cmdAbort struct{ changeIDMixin }
+type cmdAbort struct {
+	changeIDMixin
+	Force bool `long:"force"`
+}
 
 var shortAbortHelp = i18n.G("Abort a pending change")
 
 var longAbortHelp = i18n.G(`
 The abort command attempts to abort a change that still has pending tasks.
+
+With --force the tasks currently being run are not waited for: they are put
+in error right away and the rest of the change is undone as usual.
 `)
 
 func init() {
@@ -40,7 +46,10 @@ func init() {
 		func() flags.Commander {
 			return &cmdAbort{}
 		},
-		changeIDMixinOptDesc,
+		changeIDMixinOptDesc.also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"force": i18n.G("Do not wait for the running tasks of the change to stop"),
+		}),
 		changeIDMixinArgDesc,
 	)
 }
@@ -57,6 +66,10 @@ func (x *cmdAbort) Execute(args []string) error {
 		}
 		return err
 	}
-	_, err = x.client.Abort(id)
+	if x.Force {
+		_, err = x.client.ForceAbort(id)
+	} else {
+		_, err = x.client.Abort(id)
+	}
 	return err
 }
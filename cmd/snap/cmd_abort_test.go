@@ -55,6 +55,29 @@ func (s *SnapSuite) TestAbortLast(c *check.C) {
 	c.Assert(n, check.Equals, 2)
 }
 
+func (s *SnapSuite) TestAbortForce(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{"action": "force-abort"})
+			fmt.Fprintln(w, mockChangeJSON)
+		default:
+			c.Errorf("expected 1 query, currently on %d", n)
+		}
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"abort", "--force", "42"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "")
+
+	c.Assert(n, check.Equals, 1)
+}
+
 func (s *SnapSuite) TestAbortLastQuestionmark(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
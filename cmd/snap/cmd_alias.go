@@ -33,9 +33,10 @@ import (
 
 type cmdAlias struct {
 	waitMixin
+	Force       bool `long:"force"`
 	Positionals struct {
 		SnapApp appName `required:"yes"`
-		Alias   string  `required:"yes"`
+		Alias   string
 	} `positional-args:"true"`
 }
 
@@ -47,12 +48,24 @@ The alias command aliases the given snap application to the given alias.
 
 Once this manual alias is setup the respective application command can be
 invoked just using the alias.
+
+$ snap alias <snap>.*
+
+Sets up a manual alias for each application of the snap, other than its
+services, using the name of the application as the alias.
+
+Aliases already enabled for other snaps are not taken over unless --force is
+given, in which case they are disabled for those snaps and reported as
+removed.
 `)
 
 func init() {
 	addCommand("alias", shortAliasHelp, longAliasHelp, func() flags.Commander {
 		return &cmdAlias{}
-	}, waitDescs, []argDesc{
+	}, waitDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"force": i18n.G("Take over aliases enabled for other snaps"),
+	}), []argDesc{
 		{name: "<snap.app>"},
 		// TRANSLATORS: This needs to begin with < and end with >
 		{name: i18n.G("<alias>")},
@@ -66,8 +79,21 @@ func (x *cmdAlias) Execute(args []string) error {
 
 	snapName, appName := snap.SplitSnapApp(string(x.Positionals.SnapApp))
 	alias := x.Positionals.Alias
+	opts := &client.AliasOptions{Force: x.Force}
 
-	id, err := x.client.Alias(snapName, appName, alias)
+	var id string
+	var err error
+	if appName == "*" {
+		if alias != "" {
+			return fmt.Errorf(i18n.G("cannot use an alias when aliasing all applications of snap %q"), snapName)
+		}
+		id, err = x.client.AliasAllApps(snapName, opts)
+	} else {
+		if alias == "" {
+			return fmt.Errorf(i18n.G("the required argument `<alias>` was not provided"))
+		}
+		id, err = x.client.AliasWithOptions(snapName, appName, alias, opts)
+	}
 	if err != nil {
 		return err
 	}
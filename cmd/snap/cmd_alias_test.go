@@ -30,16 +30,26 @@ import (
 
 func (s *SnapSuite) TestAliasHelp(c *C) {
 	msg := `Usage:
-  snap.test alias [alias-OPTIONS] <snap.app> <alias>
+  snap.test alias [alias-OPTIONS] <snap.app> [<alias>]
 
 The alias command aliases the given snap application to the given alias.
 
 Once this manual alias is setup the respective application command can be
 invoked just using the alias.
 
+$ snap alias <snap>.*
+
+Sets up a manual alias for each application of the snap, other than its
+services, using the name of the application as the alias.
+
+Aliases already enabled for other snaps are not taken over unless --force is
+given, in which case they are disabled for those snaps and reported as
+removed.
+
 [alias command options]
       --no-wait       Do not wait for the operation to finish but just print
                       the change id.
+      --force         Take over aliases enabled for other snaps
 `
 	s.testSubCommandHelp(c, "alias", msg)
 }
@@ -73,3 +83,46 @@ func (s *SnapSuite) TestAlias(c *C) {
 	)
 	c.Assert(s.Stderr(), Equals, "")
 }
+
+func (s *SnapSuite) TestAliasAllAppsForce(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/aliases":
+			c.Check(r.Method, Equals, "POST")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action": "alias",
+				"snap":   "alias-snap",
+				"app":    "*",
+				"force":  true,
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+		case "/v2/changes/zzz":
+			c.Check(r.Method, Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"ready": true, "status": "Done", "data": {"aliases-added": [{"alias": "cmd1", "snap": "alias-snap", "app": "cmd1"}, {"alias": "cmd2", "snap": "alias-snap", "app": "cmd2"}], "aliases-removed": [{"alias": "cmd1", "snap": "other-snap", "app": "cmd"}]}}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"alias", "--force", "alias-snap.*"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Assert(s.Stdout(), Equals, ""+
+		"Added:\n"+
+		"  - alias-snap.cmd1 as cmd1\n"+
+		"  - alias-snap.cmd2 as cmd2\n"+
+		"Removed:\n"+
+		"  - other-snap.cmd as cmd1\n",
+	)
+	c.Assert(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestAliasErrors(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request %q", r.URL.Path)
+	})
+	_, err := Parser(Client()).ParseArgs([]string{"alias", "alias-snap.cmd1"})
+	c.Assert(err, ErrorMatches, "the required argument `<alias>` was not provided")
+	_, err = Parser(Client()).ParseArgs([]string{"alias", "alias-snap.*", "alias1"})
+	c.Assert(err, ErrorMatches, `cannot use an alias when aliasing all applications of snap "alias-snap"`)
+}
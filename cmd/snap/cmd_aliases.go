@@ -32,6 +32,7 @@ import (
 
 type cmdAliases struct {
 	clientMixin
+	Conflicts   bool `long:"conflicts"`
 	Positionals struct {
 		Snap installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"true"`
@@ -44,20 +45,28 @@ The aliases command lists all aliases available in the system and their status.
 $ snap aliases <snap>
 
 Lists only the aliases defined by the specified snap.
+
+With --conflicts only the aliases that are disabled because they are enabled
+for another snap are listed. Those can be taken over with 'snap prefer' or
+'snap alias --force'.
 `)
 
 func init() {
 	addCommand("aliases", shortAliasesHelp, longAliasesHelp, func() flags.Commander {
 		return &cmdAliases{}
-	}, nil, nil)
+	}, map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"conflicts": i18n.G("Only list aliases disabled in favour of another snap"),
+	}, nil)
 }
 
 type aliasInfo struct {
-	Snap    string
-	Command string
-	Alias   string
-	Status  string
-	Auto    string
+	Snap     string
+	Command  string
+	Alias    string
+	Status   string
+	Auto     string
+	Conflict string
 }
 
 type aliasInfos []*aliasInfo
@@ -100,12 +109,16 @@ func (x *cmdAliases) Execute(args []string) error {
 	}
 	for snapName, aliasStatuses := range allStatuses {
 		for alias, aliasStatus := range aliasStatuses {
+			if x.Conflicts && aliasStatus.Conflict == "" {
+				continue
+			}
 			infos = append(infos, &aliasInfo{
-				Snap:    snapName,
-				Command: aliasStatus.Command,
-				Alias:   alias,
-				Status:  aliasStatus.Status,
-				Auto:    aliasStatus.Auto,
+				Snap:     snapName,
+				Command:  aliasStatus.Command,
+				Alias:    alias,
+				Status:   aliasStatus.Status,
+				Auto:     aliasStatus.Auto,
+				Conflict: aliasStatus.Conflict,
 			})
 		}
 	}
@@ -124,6 +137,9 @@ func (x *cmdAliases) Execute(args []string) error {
 				if info.Status == "manual" && info.Auto != "" {
 					notes = append(notes, "override")
 				}
+				if info.Conflict != "" {
+					notes = append(notes, "conflict="+info.Conflict)
+				}
 			}
 			notesStr := strings.Join(notes, ",")
 			if notesStr == "" {
@@ -131,6 +147,8 @@ func (x *cmdAliases) Execute(args []string) error {
 			}
 			fmt.Fprintf(w, "%s\t%s\t%s\n", info.Command, info.Alias, notesStr)
 		}
+	} else if x.Conflicts {
+		fmt.Fprintln(Stderr, i18n.G("No aliases are currently disabled in favour of another snap."))
 	} else {
 		if filterSnap != "" {
 			fmt.Fprintf(Stderr, i18n.G("No aliases are currently defined for snap %q.\n"), filterSnap)
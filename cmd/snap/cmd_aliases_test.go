@@ -31,13 +31,20 @@ import (
 
 func (s *SnapSuite) TestAliasesHelp(c *C) {
 	msg := `Usage:
-  snap.test aliases [<snap>]
+  snap.test aliases [aliases-OPTIONS] [<snap>]
 
 The aliases command lists all aliases available in the system and their status.
 
 $ snap aliases <snap>
 
 Lists only the aliases defined by the specified snap.
+
+With --conflicts only the aliases that are disabled because they are enabled
+for another snap are listed. Those can be taken over with 'snap prefer' or
+'snap alias --force'.
+
+[aliases command options]
+      --conflicts    Only list aliases disabled in favour of another snap
 `
 	s.testSubCommandHelp(c, "aliases", msg)
 }
@@ -110,6 +117,45 @@ func (s *SnapSuite) TestAliasesFilterSnap(c *C) {
 	c.Assert(s.Stderr(), Equals, "")
 }
 
+func (s *SnapSuite) TestAliasesConflicts(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/aliases")
+		EncodeResponseBody(c, w, map[string]interface{}{
+			"type": "sync",
+			"result": map[string]map[string]client.AliasStatus{
+				"foo": {
+					"foo0": {Command: "foo", Status: "auto", Auto: "foo"},
+					"dump": {Command: "foo.dump", Status: "manual", Manual: "dump"},
+				},
+				"bar": {
+					"bar_dump.1": {Command: "bar.dump", Status: "disabled", Auto: "dump"},
+					"dump":       {Command: "bar.dump", Status: "disabled", Auto: "dump", Conflict: "foo"},
+				},
+			},
+		})
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"aliases"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"Command   Alias       Notes\n"+
+		"bar.dump  bar_dump.1  disabled\n"+
+		"bar.dump  dump        disabled,conflict=foo\n"+
+		"foo       foo0        -\n"+
+		"foo.dump  dump        manual\n")
+	c.Check(s.Stderr(), Equals, "")
+
+	s.ResetStdStreams()
+	rest, err = Parser(Client()).ParseArgs([]string{"aliases", "--conflicts"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"Command   Alias  Notes\n"+
+		"bar.dump  dump   disabled,conflict=foo\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
 func (s *SnapSuite) TestAliasesNone(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		c.Check(r.Method, Equals, "GET")
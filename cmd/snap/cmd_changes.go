@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
@@ -34,6 +36,14 @@ var shortChangesHelp = i18n.G("List system changes")
 var shortTasksHelp = i18n.G("List a change's tasks")
 var longChangesHelp = i18n.G(`
 The changes command displays a summary of system changes performed recently.
+
+The list can be narrowed down by snap name, change status and change kind.
+When --limit or --skip are given, they apply to the most recent changes that
+match the given filters.
+
+With --follow, once the list is displayed, the changes that are still in
+progress are followed, and status updates of them and their tasks are
+displayed as they happen until all of them are ready.
 `)
 var longTasksHelp = i18n.G(`
 The tasks command displays a summary of tasks associated with an individual
@@ -43,6 +53,12 @@ change.
 type cmdChanges struct {
 	clientMixin
 	timeMixin
+	tableMixin
+	Status     string `long:"status"`
+	Kind       string `long:"kind"`
+	Limit      int    `long:"limit"`
+	Skip       int    `long:"skip"`
+	Follow     bool   `long:"follow"`
 	Positional struct {
 		Snap string `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
@@ -50,15 +66,27 @@ type cmdChanges struct {
 
 type cmdTasks struct {
 	timeMixin
+	tableMixin
 	changeIDMixin
 }
 
 func init() {
 	addCommand("changes", shortChangesHelp, longChangesHelp,
-		func() flags.Commander { return &cmdChanges{} }, timeDescs, nil)
+		func() flags.Commander { return &cmdChanges{} }, timeDescs.also(tableDescs).also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"status": i18n.G("Only show changes with the given status (e.g. Doing, Done, Error)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"kind": i18n.G("Only show changes of the given kind (e.g. refresh-snap)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"limit": i18n.G("Only show the given number of most recent changes"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"skip": i18n.G("Skip the given number of most recent changes"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"follow": i18n.G("Follow the changes in progress until they are ready"),
+		}), nil)
 	addCommand("tasks", shortTasksHelp, longTasksHelp,
 		func() flags.Commander { return &cmdTasks{} },
-		changeIDMixinOptDesc.also(timeDescs),
+		changeIDMixinOptDesc.also(timeDescs).also(tableDescs),
 		changeIDMixinArgDesc).alias = "change"
 }
 
@@ -96,6 +124,13 @@ func (c *cmdChanges) Execute(args []string) error {
 		return nil
 	}
 
+	if c.Limit < 0 {
+		return fmt.Errorf(i18n.G("cannot use negative --limit: %d"), c.Limit)
+	}
+	if c.Skip < 0 {
+		return fmt.Errorf(i18n.G("cannot use negative --skip: %d"), c.Skip)
+	}
+
 	opts := client.ChangesOptions{
 		SnapName: c.Positional.Snap,
 		Selector: client.ChangesAll,
@@ -106,28 +141,119 @@ func (c *cmdChanges) Execute(args []string) error {
 		return err
 	}
 
+	sort.Sort(changesByTime(changes))
+	changes = c.filter(changes)
+
 	if len(changes) == 0 {
 		fmt.Fprintln(Stderr, i18n.G("no changes found"))
 		return nil
 	}
 
-	sort.Sort(changesByTime(changes))
-
-	w := tabWriter()
-
-	fmt.Fprintf(w, i18n.G("ID\tStatus\tSpawn\tReady\tSummary\n"))
+	t, err := c.newTable(Stdout,
+		tableColumn{"id", i18n.G("ID")},
+		tableColumn{"status", i18n.G("Status")},
+		tableColumn{"spawn", i18n.G("Spawn")},
+		tableColumn{"ready", i18n.G("Ready")},
+		tableColumn{"summary", i18n.G("Summary")},
+	)
+	if err != nil {
+		return err
+	}
 	for _, chg := range changes {
 		spawnTime := c.fmtTime(chg.SpawnTime)
 		readyTime := c.fmtTime(chg.ReadyTime)
 		if chg.ReadyTime.IsZero() {
 			readyTime = "-"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", chg.ID, chg.Status, spawnTime, readyTime, chg.Summary)
+		t.addRow(chg.ID, chg.Status, spawnTime, readyTime, chg.Summary)
 	}
 
-	w.Flush()
+	if err := t.render(); err != nil {
+		return err
+	}
 	fmt.Fprintln(Stdout)
 
+	if c.Follow {
+		return c.follow(changes)
+	}
+
+	return nil
+}
+
+// filter applies the status, kind and pagination options to the given
+// changes, which are expected to be sorted by spawn time.
+func (c *cmdChanges) filter(changes []*client.Change) []*client.Change {
+	filtered := make([]*client.Change, 0, len(changes))
+	for _, chg := range changes {
+		if c.Status != "" && !strings.EqualFold(chg.Status, c.Status) {
+			continue
+		}
+		if c.Kind != "" && chg.Kind != c.Kind {
+			continue
+		}
+		filtered = append(filtered, chg)
+	}
+
+	// --skip and --limit count from the most recent change
+	end := len(filtered) - c.Skip
+	if end < 0 {
+		end = 0
+	}
+	start := 0
+	if c.Limit > 0 && end > c.Limit {
+		start = end - c.Limit
+	}
+	return filtered[start:end]
+}
+
+// follow polls the changes that are not yet ready and reports the status
+// transitions of them and of their tasks until all of them are ready.
+func (c *cmdChanges) follow(changes []*client.Change) error {
+	taskStatus := make(map[string]string)
+	chgStatus := make(map[string]string)
+	var pending []string
+	for _, chg := range changes {
+		if chg.Ready {
+			continue
+		}
+		pending = append(pending, chg.ID)
+		chgStatus[chg.ID] = chg.Status
+		for _, t := range chg.Tasks {
+			taskStatus[t.ID] = t.Status
+		}
+	}
+
+	for len(pending) > 0 {
+		time.Sleep(pollTime)
+
+		stillPending := pending[:0]
+		for _, id := range pending {
+			chg, err := c.client.Change(id)
+			if err != nil {
+				return err
+			}
+			for _, t := range chg.Tasks {
+				if taskStatus[t.ID] == t.Status {
+					continue
+				}
+				taskStatus[t.ID] = t.Status
+				fmt.Fprintf(Stdout, "%s\t%s\t%s\t%s\n", c.fmtTime(timeNow()), chg.ID, t.Status, t.Summary)
+			}
+			if chgStatus[chg.ID] != chg.Status {
+				chgStatus[chg.ID] = chg.Status
+				fmt.Fprintf(Stdout, "%s\t%s\t%s\t%s\n", c.fmtTime(timeNow()), chg.ID, chg.Status, chg.Summary)
+			}
+			if chg.Ready {
+				if chg.Err != "" {
+					fmt.Fprintf(Stderr, i18n.G("error: change %s: %s\n"), chg.ID, chg.Err)
+				}
+				continue
+			}
+			stillPending = append(stillPending, id)
+		}
+		pending = stillPending
+	}
+
 	return nil
 }
 
@@ -160,9 +286,15 @@ func (c *cmdTasks) showChange(chid string) error {
 		return err
 	}
 
-	w := tabWriter()
-
-	fmt.Fprintf(w, i18n.G("Status\tSpawn\tReady\tSummary\n"))
+	tbl, err := c.newTable(Stdout,
+		tableColumn{"status", i18n.G("Status")},
+		tableColumn{"spawn", i18n.G("Spawn")},
+		tableColumn{"ready", i18n.G("Ready")},
+		tableColumn{"summary", i18n.G("Summary")},
+	)
+	if err != nil {
+		return err
+	}
 	for _, t := range chg.Tasks {
 		spawnTime := c.fmtTime(t.SpawnTime)
 		readyTime := c.fmtTime(t.ReadyTime)
@@ -173,10 +305,12 @@ func (c *cmdTasks) showChange(chid string) error {
 		if t.Status == "Doing" && t.Progress.Total > 1 {
 			summary = fmt.Sprintf("%s (%.2f%%)", summary, float64(t.Progress.Done)/float64(t.Progress.Total)*100.0)
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Status, spawnTime, readyTime, summary)
+		tbl.addRow(t.Status, spawnTime, readyTime, summary)
 	}
 
-	w.Flush()
+	if err := tbl.render(); err != nil {
+		return err
+	}
 
 	for _, t := range chg.Tasks {
 		if len(t.Log) == 0 {
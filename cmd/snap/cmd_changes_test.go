@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -250,3 +251,81 @@ func (s *SnapSuite) TestNoChanges(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Check(s.Stderr(), check.Equals, "no changes found\n")
 }
+
+func (s *SnapSuite) TestChangesFilterKindAndPagination(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/changes")
+		c.Check(r.URL.Query().Get("select"), check.Equals, "all")
+		fmt.Fprintln(w, mockChangesJSON)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"changes", "--abs-time", "--kind=install-snap", "--skip=1", "--limit=1"})
+	c.Assert(err, check.IsNil)
+	// install-snap changes by spawn time are four, three and two; skipping
+	// the most recent one and limiting to one leaves three
+	c.Check(s.Stdout(), check.Equals, `ID     Status  Spawn                 Ready                 Summary
+three  Do      2016-01-21T01:02:03Z  2016-01-21T01:02:04Z  ...
+
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestChangesFilterStatusNoMatch(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, mockChangesJSON)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"changes", "--status=error"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "no changes found\n")
+}
+
+func (s *SnapSuite) TestChangesNegativeLimit(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"changes", "--limit=-1"})
+	c.Assert(err, check.ErrorMatches, "cannot use negative --limit: -1")
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"changes", "--skip=-1"})
+	c.Assert(err, check.ErrorMatches, "cannot use negative --skip: -1")
+}
+
+func (s *SnapSuite) TestChangesFollow(c *check.C) {
+	restore := snap.MockPollTime(time.Millisecond)
+	defer restore()
+
+	const chgTemplate = `{
+  "id": "42", "kind": "refresh-snap", "summary": "Refresh foo", "status": %q, "ready": %v,
+  "spawn-time": "2016-04-21T01:02:03Z",
+  "tasks": [
+    {"id": "1", "kind": "download-snap", "summary": "Download foo", "status": %q, "spawn-time": "2016-04-21T01:02:03Z"},
+    {"id": "2", "kind": "link-snap", "summary": "Link foo", "status": %q, "spawn-time": "2016-04-21T01:02:03Z"}
+  ]}`
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, check.Equals, "/v2/changes")
+			fmt.Fprintf(w, `{"type": "sync", "result": [`+chgTemplate+`]}`, "Doing", false, "Doing", "Do")
+		case 1:
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			fmt.Fprintf(w, `{"type": "sync", "result": `+chgTemplate+`}`, "Doing", false, "Done", "Doing")
+		case 2:
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			fmt.Fprintf(w, `{"type": "sync", "result": `+chgTemplate+`}`, "Done", true, "Done", "Done")
+		default:
+			c.Fatalf("expected to get 3 requests, now on %d", n+1)
+		}
+		n++
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"changes", "--abs-time", "--follow"})
+	c.Assert(err, check.IsNil)
+	c.Check(n, check.Equals, 3)
+	c.Check(s.Stdout(), check.Matches, `(?ms)ID +Status +Spawn +Ready +Summary
+42 +Doing +2016-04-21T01:02:03Z +- +Refresh foo
+
+\S+\t42\tDone\tDownload foo
+\S+\t42\tDoing\tLink foo
+\S+\t42\tDone\tLink foo
+\S+\t42\tDone\tRefresh foo
+$`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
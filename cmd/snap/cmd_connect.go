@@ -20,13 +20,18 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 )
 
 type cmdConnect struct {
 	waitMixin
+	DryRun      bool `long:"dry-run"`
 	Positionals struct {
 		PlugSpec connectPlugSpec `required:"yes"`
 		SlotSpec connectSlotSpec
@@ -52,12 +57,25 @@ $ snap connect <snap>:<plug>
 
 Connects the provided plug to the slot in the core snap with a name matching
 the plug name.
+
+$ snap connect '<snap>:<pattern>' [<snap>[:<slot>]]
+
+Connects all the plugs of the snap with names matching the shell pattern,
+such as '*', that can be connected to the given slot side, in a single
+change. Plugs that are already connected are skipped, and the outcome of
+each connection is reported once the change is done.
+
+With --dry-run, the connections that would be made and the security profiles
+that would be regenerated as a result are shown, without connecting anything.
 `)
 
 func init() {
 	addCommand("connect", shortConnectHelp, longConnectHelp, func() flags.Commander {
 		return &cmdConnect{}
-	}, waitDescs, []argDesc{
+	}, waitDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"dry-run": i18n.G("Show what would be connected without connecting anything"),
+	}), []argDesc{
 		// TRANSLATORS: This needs to begin with < and end with >
 		{name: i18n.G("<snap>:<plug>")},
 		// TRANSLATORS: This needs to begin with < and end with >
@@ -77,17 +95,52 @@ func (x *cmdConnect) Execute(args []string) error {
 		x.Positionals.PlugSpec.Snap = ""
 	}
 
-	id, err := x.client.Connect(x.Positionals.PlugSpec.Snap, x.Positionals.PlugSpec.Name, x.Positionals.SlotSpec.Snap, x.Positionals.SlotSpec.Name)
+	plug, slot := x.Positionals.PlugSpec, x.Positionals.SlotSpec
+	if x.DryRun {
+		effects, err := x.client.ConnectDryRun(plug.Snap, plug.Name, slot.Snap, slot.Name)
+		if err != nil {
+			return err
+		}
+		showInterfaceActionEffects(effects, i18n.G("No connections would be made"))
+		return nil
+	}
+
+	id, err := x.client.Connect(plug.Snap, plug.Name, slot.Snap, slot.Name)
 	if err != nil {
 		return err
 	}
 
-	if _, err := x.wait(id); err != nil {
-		if err == noWait {
-			return nil
+	_, err = x.wait(id)
+	if err == noWait {
+		return nil
+	}
+	if strings.ContainsAny(plug.Name, "*?[") {
+		// report on each of the connections even if some failed
+		if chg, err := x.client.Change(id); err == nil {
+			showConnectTasks(chg)
 		}
-		return err
 	}
+	return err
+}
 
-	return nil
+// showConnectTasks reports the status of each connection of a change
+// connecting many plugs.
+func showConnectTasks(chg *client.Change) {
+	var connects []*client.Task
+	for _, t := range chg.Tasks {
+		if t.Kind == "connect" {
+			connects = append(connects, t)
+		}
+	}
+	if len(connects) == 0 {
+		fmt.Fprintln(Stdout, i18n.G("No plugs needed connecting"))
+		return
+	}
+
+	w := tabWriter()
+	fmt.Fprintln(w, i18n.G("Status\tSummary"))
+	for _, t := range connects {
+		fmt.Fprintf(w, "%s\t%s\n", t.Status, t.Summary)
+	}
+	w.Flush()
 }
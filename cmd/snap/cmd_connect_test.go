@@ -53,9 +53,21 @@ $ snap connect <snap>:<plug>
 Connects the provided plug to the slot in the core snap with a name matching
 the plug name.
 
+$ snap connect '<snap>:<pattern>' [<snap>[:<slot>]]
+
+Connects all the plugs of the snap with names matching the shell pattern,
+such as '*', that can be connected to the given slot side, in a single
+change. Plugs that are already connected are skipped, and the outcome of
+each connection is reported once the change is done.
+
+With --dry-run, the connections that would be made and the security profiles
+that would be regenerated as a result are shown, without connecting anything.
+
 [connect command options]
       --no-wait          Do not wait for the operation to finish but just print
                          the change id.
+      --dry-run          Show what would be connected without connecting
+                         anything
 `
 	s.testSubCommandHelp(c, "connect", msg)
 }
@@ -94,6 +106,108 @@ func (s *SnapSuite) TestConnectExplicitEverything(c *C) {
 	c.Assert(rest, DeepEquals, []string{})
 }
 
+func (s *SnapSuite) TestConnectDryRun(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/interfaces":
+			c.Check(r.Method, Equals, "POST")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action":  "connect",
+				"dry-run": true,
+				"plugs": []interface{}{
+					map[string]interface{}{
+						"snap": "consumer",
+						"plug": "*",
+					},
+				},
+				"slots": []interface{}{
+					map[string]interface{}{
+						"snap": "producer",
+						"slot": "",
+					},
+				},
+			})
+			fmt.Fprintln(w, `{"type":"sync", "result":{
+"connections": [
+  {"plug": {"snap": "consumer", "plug": "plug"}, "slot": {"snap": "producer", "slot": "slot"}, "interface": "test"},
+  {"plug": {"snap": "consumer", "plug": "plug2"}, "slot": {"snap": "producer", "slot": "slot"}, "interface": "test"}
+],
+"backends": ["apparmor", "seccomp"],
+"profiles": [
+  {"snap": "consumer", "security-tags": ["snap.consumer.app"]},
+  {"snap": "producer", "security-tags": ["snap.producer.app", "snap.producer.hook.configure"]}
+]}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"connect", "--dry-run", "consumer:*", "producer"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `Interface  Plug            Slot
+test       consumer:plug   producer:slot
+test       consumer:plug2  producer:slot
+
+Security profiles that would be regenerated (apparmor, seccomp):
+  snap.consumer.app
+  snap.producer.app
+  snap.producer.hook.configure
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestConnectDryRunNothing(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v2/interfaces")
+		fmt.Fprintln(w, `{"type":"sync", "result":{"connections": [], "profiles": []}}`)
+	})
+	_, err := Parser(Client()).ParseArgs([]string{"connect", "--dry-run", "consumer:plug", "producer:slot"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "No connections would be made\n")
+}
+
+func (s *SnapSuite) TestConnectPattern(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/interfaces":
+			c.Check(r.Method, Equals, "POST")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action": "connect",
+				"plugs": []interface{}{
+					map[string]interface{}{
+						"snap": "consumer",
+						"plug": "plug*",
+					},
+				},
+				"slots": []interface{}{
+					map[string]interface{}{
+						"snap": "producer",
+						"slot": "",
+					},
+				},
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+		case "/v2/changes/zzz":
+			c.Check(r.Method, Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"ready": true, "status": "Error", "err": "cannot perform the following tasks:\n- Connect consumer:plug2 to producer:slot (boom)", "tasks": [
+{"kind": "connect", "summary": "Connect consumer:plug to producer:slot", "status": "Done"},
+{"kind": "run-hook", "summary": "Run hook prepare-plug-plug of snap \"consumer\"", "status": "Done"},
+{"kind": "connect", "summary": "Connect consumer:plug2 to producer:slot", "status": "Error"}
+]}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	_, err := Parser(Client()).ParseArgs([]string{"connect", "consumer:plug*", "producer"})
+	c.Assert(err, ErrorMatches, `cannot perform the following tasks:
+- Connect consumer:plug2 to producer:slot \(boom\)`)
+	c.Check(s.Stdout(), Equals, `Status  Summary
+Done    Connect consumer:plug to producer:slot
+Error   Connect consumer:plug2 to producer:slot
+`)
+}
+
 func (s *SnapSuite) TestConnectExplicitPlugImplicitSlot(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
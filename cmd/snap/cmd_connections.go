@@ -20,6 +20,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -32,7 +33,11 @@ import (
 
 type cmdConnections struct {
 	clientMixin
-	All         bool `long:"all"`
+	tableMixin
+	All         bool   `long:"all"`
+	Interface   string `long:"interface"`
+	Attrs       bool   `long:"attrs"`
+	Problems    bool   `long:"problems"`
 	Positionals struct {
 		Snap installedSnapName
 	} `positional-args:"true"`
@@ -51,14 +56,25 @@ $ snap connections <snap>
 
 Lists connected and unconnected plugs and slots for the specified
 snap.
+
+$ snap connections --problems [<snap>]
+
+Lists the plugs that were not connected automatically, the slots they
+could have been connected to and why they were not.
 `)
 
 func init() {
 	addCommand("connections", shortConnectionsHelp, longConnectionsHelp, func() flags.Commander {
 		return &cmdConnections{}
-	}, map[string]string{
+	}, tableDescs.also(map[string]string{
 		"all": i18n.G("Show connected and unconnected plugs and slots"),
-	}, []argDesc{{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"interface": i18n.G("Constrain listing to plugs and slots of the given interface"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"attrs": i18n.G("Show the attributes of plugs and slots"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"problems": i18n.G("Show plugs that were not connected automatically and why"),
+	}), []argDesc{{
 		// TRANSLATORS: This needs to be wrapped in <>s.
 		name: "<snap>",
 		// TRANSLATORS: This should not start with a lowercase letter.
@@ -84,6 +100,8 @@ type connection struct {
 	interfaceDeterminant string
 	manual               bool
 	gadget               bool
+	plugAttrs            string
+	slotAttrs            string
 }
 
 func (cn connection) String() string {
@@ -131,13 +149,70 @@ func interfaceDeterminant(conn *client.Connection) string {
 	return fmt.Sprintf("[%v]", value)
 }
 
+// formatAttrs formats attributes as a comma-separated list of key=value
+// pairs, sorted by key, with values that are not strings in JSON.
+func formatAttrs(attrs map[string]interface{}) string {
+	if len(attrs) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		value, ok := attrs[k].(string)
+		if !ok {
+			raw, err := json.Marshal(attrs[k])
+			if err != nil {
+				value = fmt.Sprintf("%v", attrs[k])
+			} else {
+				value = string(raw)
+			}
+		}
+		pairs[i] = k + "=" + value
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (x *cmdConnections) showProblems(problems []client.AutoConnectProblem) error {
+	if len(problems) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No problems with automatic connections found."))
+		return nil
+	}
+	t, err := x.newTable(Stdout,
+		tableColumn{"plug", i18n.G("Plug")},
+		tableColumn{"interface", i18n.G("Interface")},
+		tableColumn{"candidates", i18n.G("Candidates")},
+		tableColumn{"reason", i18n.G("Reason")},
+	)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		candidates := "-"
+		if len(problem.Candidates) > 0 {
+			slots := make([]string, len(problem.Candidates))
+			for i, slot := range problem.Candidates {
+				slots[i] = endpoint(slot.Snap, slot.Name)
+			}
+			candidates = strings.Join(slots, ",")
+		}
+		t.addRow(endpoint(problem.Plug.Snap, problem.Plug.Name), problem.Interface, candidates, problem.Reason)
+	}
+	return t.render()
+}
+
 func (x *cmdConnections) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
 
 	opts := client.ConnectionOptions{
-		All: x.All,
+		All:       x.All,
+		Interface: x.Interface,
+		Problems:  x.Problems,
 	}
 	wanted := string(x.Positionals.Snap)
 	if wanted != "" {
@@ -158,6 +233,9 @@ func (x *cmdConnections) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
+	if x.Problems {
+		return x.showProblems(connections.Problems)
+	}
 	if len(connections.Plugs) == 0 && len(connections.Slots) == 0 {
 		return nil
 	}
@@ -171,11 +249,27 @@ func (x *cmdConnections) Execute(args []string) error {
 			gadget:               conn.Gadget,
 			interfaceName:        conn.Interface,
 			interfaceDeterminant: interfaceDeterminant(&conn),
+			plugAttrs:            formatAttrs(conn.PlugAttrs),
+			slotAttrs:            formatAttrs(conn.SlotAttrs),
 		})
 	}
 
-	w := tabWriter()
-	fmt.Fprintln(w, i18n.G("Interface\tPlug\tSlot\tNotes"))
+	columns := []tableColumn{
+		{"interface", i18n.G("Interface")},
+		{"plug", i18n.G("Plug")},
+		{"slot", i18n.G("Slot")},
+		{"notes", i18n.G("Notes")},
+	}
+	if x.Attrs {
+		columns = append(columns,
+			tableColumn{"plug-attrs", i18n.G("Plug attributes")},
+			tableColumn{"slot-attrs", i18n.G("Slot attributes")},
+		)
+	}
+	t, err := x.newTable(Stdout, columns...)
+	if err != nil {
+		return err
+	}
 
 	for _, plug := range connections.Plugs {
 		if len(plug.Connections) == 0 && x.All {
@@ -183,6 +277,8 @@ func (x *cmdConnections) Execute(args []string) error {
 				plug:          endpoint(plug.Snap, plug.Name),
 				slot:          "-",
 				interfaceName: plug.Interface,
+				plugAttrs:     formatAttrs(plug.Attrs),
+				slotAttrs:     "-",
 			})
 		}
 	}
@@ -197,6 +293,8 @@ func (x *cmdConnections) Execute(args []string) error {
 				plug:          "-",
 				slot:          endpoint(slot.Snap, slot.Name),
 				interfaceName: slot.Interface,
+				plugAttrs:     "-",
+				slotAttrs:     formatAttrs(slot.Attrs),
 			})
 		}
 	}
@@ -204,11 +302,15 @@ func (x *cmdConnections) Execute(args []string) error {
 	sort.Sort(byConnectionData(annotatedConns))
 
 	for _, note := range annotatedConns {
-		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\n", note.interfaceName, note.interfaceDeterminant, note.plug, note.slot, note)
+		cells := []string{note.interfaceName + note.interfaceDeterminant, note.plug, note.slot, note.String()}
+		if x.Attrs {
+			cells = append(cells, note.plugAttrs, note.slotAttrs)
+		}
+		t.addRow(cells...)
 	}
 
 	if len(annotatedConns) > 0 {
-		w.Flush()
+		return t.render()
 	}
 	return nil
 }
@@ -851,3 +851,119 @@ func (s *SnapSuite) TestConnectionsDefiningAttribute(c *C) {
 	c.Assert(s.Stdout(), Equals, expectedStdout)
 	c.Assert(s.Stderr(), Equals, "")
 }
+
+func (s *SnapSuite) TestConnectionsAttrsAndInterface(c *C) {
+	result := client.Connections{
+		Established: []client.Connection{
+			{
+				Plug:      client.PlugRef{Snap: "foo", Name: "serial"},
+				Slot:      client.SlotRef{Snap: "gadget", Name: "ttyS0"},
+				Interface: "serial-port",
+				SlotAttrs: map[string]interface{}{
+					"path":       "/dev/ttyS0",
+					"usb-vendor": 1234,
+				},
+			},
+		},
+		Plugs: []client.Plug{
+			{
+				Snap:      "foo",
+				Name:      "serial",
+				Interface: "serial-port",
+				Connections: []client.SlotRef{{
+					Snap: "gadget",
+					Name: "ttyS0",
+				}},
+			}, {
+				Snap:      "foo",
+				Name:      "other-serial",
+				Interface: "serial-port",
+				Attrs:     map[string]interface{}{"optional": true},
+			},
+		},
+		Slots: []client.Slot{
+			{
+				Snap:      "gadget",
+				Name:      "ttyS0",
+				Interface: "serial-port",
+				Attrs: map[string]interface{}{
+					"path":       "/dev/ttyS0",
+					"usb-vendor": 1234,
+				},
+				Connections: []client.PlugRef{{
+					Snap: "foo",
+					Name: "serial",
+				}},
+			},
+		},
+	}
+	query := url.Values{
+		"select":    []string{"all"},
+		"interface": []string{"serial-port"},
+	}
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/connections")
+		c.Check(r.URL.Query(), DeepEquals, query)
+		EncodeResponseBody(c, w, map[string]interface{}{
+			"type":   "sync",
+			"result": result,
+		})
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"connections", "--all", "--interface=serial-port", "--attrs"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	expectedStdout := "" +
+		"Interface    Plug              Slot          Notes  Plug attributes  Slot attributes\n" +
+		"serial-port  foo:other-serial  -             -      optional=true    -\n" +
+		"serial-port  foo:serial        gadget:ttyS0  -      -                path=/dev/ttyS0,usb-vendor=1234\n"
+	c.Assert(s.Stdout(), Equals, expectedStdout)
+	c.Assert(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestConnectionsProblems(c *C) {
+	result := client.Connections{
+		Problems: []client.AutoConnectProblem{
+			{
+				Plug:       client.PlugRef{Snap: "foo", Name: "serial"},
+				Interface:  "serial-port",
+				Candidates: []client.SlotRef{{Snap: "gadget", Name: "ttyS0"}, {Snap: "gadget", Name: "ttyS1"}},
+				Reason:     "more than one slot is a candidate for auto-connection",
+			}, {
+				Plug:      client.PlugRef{Snap: "foo", Name: "leds"},
+				Interface: "leds",
+				Reason:    `no slots of the "leds" interface are available`,
+			},
+		},
+	}
+	query := url.Values{
+		"select":   []string{"all"},
+		"snap":     []string{"foo"},
+		"problems": []string{"true"},
+	}
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/connections")
+		c.Check(r.URL.Query(), DeepEquals, query)
+		EncodeResponseBody(c, w, map[string]interface{}{
+			"type":   "sync",
+			"result": result,
+		})
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"connections", "--problems", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	expectedStdout := "" +
+		"Plug        Interface    Candidates                 Reason\n" +
+		"foo:serial  serial-port  gadget:ttyS0,gadget:ttyS1  more than one slot is a candidate for auto-connection\n" +
+		"foo:leds    leds         -                          no slots of the \"leds\" interface are available\n"
+	c.Assert(s.Stdout(), Equals, expectedStdout)
+	c.Assert(s.Stderr(), Equals, "")
+
+	s.ResetStdStreams()
+	result.Problems = nil
+	_, err = Parser(Client()).ParseArgs([]string{"connections", "--problems", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(s.Stdout(), Equals, "")
+	c.Assert(s.Stderr(), Equals, "No problems with automatic connections found.\n")
+}
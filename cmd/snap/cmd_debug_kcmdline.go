@@ -0,0 +1,77 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+)
+
+type cmdDebugKcmdline struct {
+	clientMixin
+}
+
+func init() {
+	cmd := addDebugCommand("kcmdline",
+		"(internal) show the kernel command line arguments and where they come from",
+		"(internal) show the kernel command line arguments and where they come from",
+		func() flags.Commander {
+			return &cmdDebugKcmdline{}
+		}, nil, nil)
+	cmd.hidden = true
+}
+
+func (x *cmdDebugKcmdline) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	var resp struct {
+		Cmdline   string `json:"cmdline"`
+		Arguments []struct {
+			Param  string `json:"param"`
+			Value  string `json:"value"`
+			Source string `json:"source"`
+		} `json:"arguments"`
+		Rejected []string `json:"rejected"`
+	}
+	if err := x.client.DebugGet("kcmdline", &resp, nil); err != nil {
+		return err
+	}
+
+	w := tabWriter()
+	fmt.Fprintf(w, "Param\tValue\tSource\n")
+	for _, arg := range resp.Arguments {
+		value := arg.Value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", arg.Param, value, arg.Source)
+	}
+	w.Flush()
+
+	if len(resp.Rejected) > 0 {
+		fmt.Fprintf(Stdout, "\nArguments of system.kernel.cmdline-append not allowed by the gadget:\n")
+		for _, arg := range resp.Rejected {
+			fmt.Fprintf(Stdout, "  %s\n", arg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugKcmdline(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, Equals, "GET")
+			c.Check(r.URL.Path, Equals, "/v2/debug")
+			c.Check(r.URL.RawQuery, Equals, "aspect=kcmdline")
+			fmt.Fprintln(w, `{"type": "sync", "result": {
+"cmdline": "snapd_recovery_mode=run quiet foo=bar",
+"arguments": [
+  {"param": "snapd_recovery_mode", "value": "run", "source": "snapd"},
+  {"param": "quiet", "source": "gadget-extra"},
+  {"param": "foo", "value": "bar", "source": "unknown"}
+],
+"rejected": ["bar=baz"]
+}}`)
+		default:
+			c.Fatalf("expected to get 1 request, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "kcmdline"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `Param                Value  Source
+snapd_recovery_mode  run    snapd
+quiet                -      gadget-extra
+foo                  bar    unknown
+
+Arguments of system.kernel.cmdline-append not allowed by the gadget:
+  bar=baz
+`)
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(n, Equals, 1)
+}
+
+func (s *SnapSuite) TestDebugKcmdlineNothingRejected(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+"cmdline": "quiet",
+"arguments": [{"param": "quiet", "source": "unknown"}]
+}}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "kcmdline"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, `Param  Value  Source
+quiet  -      unknown
+`)
+}
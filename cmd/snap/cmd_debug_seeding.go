@@ -64,6 +64,13 @@ func (x *cmdSeeding) Execute(args []string) error {
 		SeedRestartSystemKey *json.RawMessage `json:"seed-restart-system-key,omitempty"`
 
 		SeedError string `json:"seed-error,omitempty"`
+
+		FailedSeedTasks []struct {
+			ID      string   `json:"id"`
+			Kind    string   `json:"kind"`
+			Summary string   `json:"summary"`
+			Errors  []string `json:"errors,omitempty"`
+		} `json:"failed-seed-tasks,omitempty"`
 	}
 	if err := x.client.DebugGet("seeding", &resp, nil); err != nil {
 		return err
@@ -111,6 +118,21 @@ func (x *cmdSeeding) Execute(args []string) error {
 	}
 	fmt.Fprintf(w, "seed-completion:\t%s\n", seedDuration)
 
+	if len(resp.FailedSeedTasks) > 0 {
+		fmt.Fprintln(w, "failed-seed-tasks:")
+		for _, t := range resp.FailedSeedTasks {
+			fmt.Fprintf(w, "  - id: %s\n", t.ID)
+			fmt.Fprintf(w, "    kind: %s\n", t.Kind)
+			fmt.Fprintf(w, "    summary: %q\n", t.Summary)
+			if len(t.Errors) > 0 {
+				fmt.Fprintln(w, "    errors:")
+				for _, e := range t.Errors {
+					fmt.Fprintf(w, "      - %q\n", e)
+				}
+			}
+		}
+	}
+
 	// we flush the tabwriter now because if we have more output, it will be
 	// the system keys, which are JSON and thus will never display cleanly in
 	// line with the other keys we did above
@@ -224,7 +224,11 @@ var seedingError = `{
         "preseed-start-time": "2020-07-24T21:41:33.838194712Z",
         "preseed-time": "2020-07-24T21:41:43.156401424Z",
         "preseeded": true,
-        "seed-error": "cannot perform the following tasks:\n- xxx"
+        "seed-error": "cannot perform the following tasks:\n- xxx",
+        "failed-seed-tasks": [
+            {"id": "12", "kind": "mount-snap", "summary": "xxx", "errors": ["cannot mount"]},
+            {"id": "15", "kind": "run-hook", "summary": "yyy"}
+        ]
     },
     "status": "OK",
     "status-code": 200,
@@ -448,6 +452,15 @@ seed-error: |
 preseeded:         true
 image-preseeding:  9.318s
 seed-completion:   --
+failed-seed-tasks:
+  - id: 12
+    kind: mount-snap
+    summary: "xxx"
+    errors:
+      - "cannot mount"
+  - id: 15
+    kind: run-hook
+    summary: "yyy"
 `[1:],
 			comment: "preseeded, error during seeding",
 		},
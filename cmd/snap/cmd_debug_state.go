@@ -54,6 +54,8 @@ type cmdDebugState struct {
 
 	// flags for --change=N output
 	DotOutput bool `long:"dot"` // XXX: mildly useful (too crowded in many cases), but let's have it just in case
+	// list the tasks in dependency order along with their dependencies
+	Graph bool `long:"graph"`
 	// When inspecting errors/undone tasks, those in Hold state are usually irrelevant, make it possible to ignore them
 	NoHoldState bool `long:"no-hold"`
 
@@ -92,6 +94,7 @@ func init() {
 		"change":      i18n.G("ID of the change to inspect"),
 		"task":        i18n.G("ID of the task to inspect"),
 		"dot":         i18n.G("Dot (graphviz) output"),
+		"graph":       i18n.G("List the tasks of the change in dependency order"),
 		"no-hold":     i18n.G("Omit tasks in 'Hold' state in the change output"),
 		"changes":     i18n.G("List all changes"),
 		"connections": i18n.G("List all connections"),
@@ -134,7 +137,7 @@ func (t *byLaneAndWaitTaskChain) waitChainSearch(startT, searchT *state.Task, se
 	return false
 }
 
-func (c *cmdDebugState) writeDotOutput(st *state.State, changeID string) error {
+func (c *cmdDebugState) writeTaskGraph(st *state.State, changeID string) error {
 	st.Lock()
 	defer st.Unlock()
 
@@ -143,22 +146,12 @@ func (c *cmdDebugState) writeDotOutput(st *state.State, changeID string) error {
 		return fmt.Errorf("no such change: %s", changeID)
 	}
 
-	fmt.Fprintf(Stdout, "digraph D{\n")
-	tasks := chg.Tasks()
-	for _, t := range tasks {
-		if c.NoHoldState && t.Status() == state.HoldStatus {
-			continue
-		}
-		fmt.Fprintf(Stdout, "  %s [label=%q];\n", t.ID(), t.Kind())
-		for _, wt := range t.WaitTasks() {
-			if c.NoHoldState && wt.Status() == state.HoldStatus {
-				continue
-			}
-			fmt.Fprintf(Stdout, "  %s -> %s;\n", t.ID(), wt.ID())
-		}
+	g := newTaskGraph(chg, c.NoHoldState)
+	if c.DotOutput {
+		g.writeDot()
+	} else {
+		g.writeASCII()
 	}
-	fmt.Fprintf(Stdout, "}\n")
-
 	return nil
 }
 
@@ -531,6 +524,12 @@ func (c *cmdDebugState) Execute(args []string) error {
 	if c.DotOutput && c.ChangeID == "" {
 		return fmt.Errorf("--dot can only be used with --change=")
 	}
+	if c.Graph && c.ChangeID == "" {
+		return fmt.Errorf("--graph can only be used with --change=")
+	}
+	if c.Graph && c.DotOutput {
+		return fmt.Errorf("cannot use --graph and --dot together")
+	}
 	if c.NoHoldState && c.ChangeID == "" {
 		return fmt.Errorf("--no-hold can only be used with --change=")
 	}
@@ -547,8 +546,8 @@ func (c *cmdDebugState) Execute(args []string) error {
 		if err != nil {
 			return fmt.Errorf("invalid change: %s", c.ChangeID)
 		}
-		if c.DotOutput {
-			return c.writeDotOutput(st, c.ChangeID)
+		if c.DotOutput || c.Graph {
+			return c.writeTaskGraph(st, c.ChangeID)
 		}
 		if c.Check {
 			return c.checkTasks(st, c.ChangeID)
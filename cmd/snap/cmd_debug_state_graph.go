@@ -0,0 +1,324 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// taskEdge is a dependency between two tasks; the after task waits
+// for the before task.
+type taskEdge struct {
+	before, after *state.Task
+	// the dependency is recorded as a wait task of after
+	wait bool
+	// the dependency is recorded as a halt task of before
+	halt bool
+}
+
+// consistent returns whether the dependency is recorded on both tasks.
+func (e *taskEdge) consistent() bool {
+	return e.wait && e.halt
+}
+
+type taskGraph struct {
+	chg   *state.Change
+	tasks []*state.Task
+	// tasks of other changes connected to the change
+	external []*state.Task
+	edges    []*taskEdge
+}
+
+func byTaskID(tasks []*state.Task) func(i, j int) bool {
+	return func(i, j int) bool {
+		return taskIDLess(tasks[i].ID(), tasks[j].ID())
+	}
+}
+
+func taskIDLess(a, b string) bool {
+	na, erra := strconv.Atoi(a)
+	nb, errb := strconv.Atoi(b)
+	if erra != nil || errb != nil {
+		return a < b
+	}
+	return na < nb
+}
+
+func newTaskGraph(chg *state.Change, skipHold bool) *taskGraph {
+	g := &taskGraph{chg: chg}
+
+	skip := func(t *state.Task) bool {
+		return skipHold && t.Status() == state.HoldStatus
+	}
+
+	edges := make(map[[2]string]*taskEdge)
+	edge := func(before, after *state.Task) *taskEdge {
+		key := [2]string{before.ID(), after.ID()}
+		e := edges[key]
+		if e == nil {
+			e = &taskEdge{before: before, after: after}
+			edges[key] = e
+			g.edges = append(g.edges, e)
+		}
+		return e
+	}
+	seenExternal := make(map[string]bool)
+	external := func(t *state.Task) {
+		if t.Change() == chg || seenExternal[t.ID()] {
+			return
+		}
+		seenExternal[t.ID()] = true
+		g.external = append(g.external, t)
+	}
+
+	for _, t := range chg.Tasks() {
+		if skip(t) {
+			continue
+		}
+		g.tasks = append(g.tasks, t)
+		for _, wt := range t.WaitTasks() {
+			if skip(wt) {
+				continue
+			}
+			external(wt)
+			edge(wt, t).wait = true
+			if wt.Change() != chg {
+				// the other side is not visited below
+				edge(wt, t).halt = strutil.ListContains(taskIDs(wt.HaltTasks()), t.ID())
+			}
+		}
+		for _, ht := range t.HaltTasks() {
+			if skip(ht) {
+				continue
+			}
+			external(ht)
+			edge(t, ht).halt = true
+			if ht.Change() != chg {
+				edge(t, ht).wait = strutil.ListContains(taskIDs(ht.WaitTasks()), t.ID())
+			}
+		}
+	}
+
+	sort.Slice(g.tasks, byTaskID(g.tasks))
+	sort.Slice(g.external, byTaskID(g.external))
+	sort.Slice(g.edges, func(i, j int) bool {
+		if g.edges[i].before != g.edges[j].before {
+			return taskIDLess(g.edges[i].before.ID(), g.edges[j].before.ID())
+		}
+		return taskIDLess(g.edges[i].after.ID(), g.edges[j].after.ID())
+	})
+
+	return g
+}
+
+func taskIDs(tasks []*state.Task) []string {
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID()
+	}
+	return ids
+}
+
+// depths returns, for each task of the change, the length of the longest
+// chain of tasks of the change it waits for. Tasks that are part of, or
+// wait for, a dependency cycle are not included.
+func (g *taskGraph) depths() map[string]int {
+	pending := make(map[string]int, len(g.tasks))
+	next := make(map[string][]string, len(g.tasks))
+	for _, t := range g.tasks {
+		pending[t.ID()] = 0
+	}
+	for _, e := range g.edges {
+		_, inBefore := pending[e.before.ID()]
+		_, inAfter := pending[e.after.ID()]
+		if !inBefore || !inAfter {
+			continue
+		}
+		pending[e.after.ID()]++
+		next[e.before.ID()] = append(next[e.before.ID()], e.after.ID())
+	}
+
+	depths := make(map[string]int, len(g.tasks))
+	var ready []string
+	for _, t := range g.tasks {
+		depths[t.ID()] = 0
+		if pending[t.ID()] == 0 {
+			ready = append(ready, t.ID())
+		}
+	}
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		for _, after := range next[id] {
+			if depths[id]+1 > depths[after] {
+				depths[after] = depths[id] + 1
+			}
+			pending[after]--
+			if pending[after] == 0 {
+				ready = append(ready, after)
+			}
+		}
+	}
+	for id, n := range pending {
+		if n > 0 {
+			delete(depths, id)
+		}
+	}
+	return depths
+}
+
+var dotStatusColors = map[state.Status]string{
+	state.DoStatus:      "white",
+	state.DoingStatus:   "yellow",
+	state.DoneStatus:    "palegreen",
+	state.AbortStatus:   "lightgrey",
+	state.UndoStatus:    "orange",
+	state.UndoingStatus: "orange",
+	state.UndoneStatus:  "lightsalmon",
+	state.HoldStatus:    "lightgrey",
+	state.ErrorStatus:   "red",
+	state.WaitStatus:    "lightblue",
+}
+
+func dotTaskLabel(t *state.Task) string {
+	return fmt.Sprintf("%s %s\n%s", t.ID(), t.Kind(), t.Status())
+}
+
+func (g *taskGraph) writeDot() {
+	fmt.Fprintf(Stdout, "digraph \"change-%s\" {\n", g.chg.ID())
+	fmt.Fprintf(Stdout, "  label=%q;\n", fmt.Sprintf("%s %s: %s", g.chg.ID(), g.chg.Kind(), g.chg.Summary()))
+	fmt.Fprintf(Stdout, "  node [shape=box, style=filled];\n")
+
+	var lanes []string
+	byLanes := make(map[string][]*state.Task)
+	for _, t := range g.tasks {
+		l := strutil.IntsToCommaSeparated(t.Lanes())
+		if _, ok := byLanes[l]; !ok {
+			lanes = append(lanes, l)
+		}
+		byLanes[l] = append(byLanes[l], t)
+	}
+	writeNode := func(indent string, t *state.Task) {
+		fmt.Fprintf(Stdout, "%s%q [label=%q, fillcolor=%q];\n", indent, t.ID(), dotTaskLabel(t), dotStatusColors[t.Status()])
+	}
+	for i, l := range lanes {
+		indent := "  "
+		if l != "0" {
+			fmt.Fprintf(Stdout, "  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(Stdout, "    label=%q;\n", "lanes "+l)
+			indent = "    "
+		}
+		for _, t := range byLanes[l] {
+			writeNode(indent, t)
+		}
+		if l != "0" {
+			fmt.Fprintf(Stdout, "  }\n")
+		}
+	}
+	for _, t := range g.external {
+		label := fmt.Sprintf("%s\n(change %s)", dotTaskLabel(t), t.Change().ID())
+		fmt.Fprintf(Stdout, "  %q [label=%q, style=dashed];\n", t.ID(), label)
+	}
+	for _, e := range g.edges {
+		attrs := ""
+		switch {
+		case !e.halt:
+			attrs = ` [color=red, label="wait only"]`
+		case !e.wait:
+			attrs = ` [color=red, label="halt only"]`
+		}
+		// edges go from the waiting task to the task it waits for
+		fmt.Fprintf(Stdout, "  %q -> %q%s;\n", e.after.ID(), e.before.ID(), attrs)
+	}
+	fmt.Fprintf(Stdout, "}\n")
+}
+
+func (g *taskGraph) writeASCII() {
+	depths := g.depths()
+	tasks := make([]*state.Task, len(g.tasks))
+	copy(tasks, g.tasks)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		di, oki := depths[tasks[i].ID()]
+		dj, okj := depths[tasks[j].ID()]
+		if oki != okj {
+			// tasks in cycles go last
+			return oki
+		}
+		return di < dj
+	})
+
+	after := make(map[string][]string)
+	before := make(map[string][]string)
+	for _, e := range g.edges {
+		mark := ""
+		if !e.consistent() {
+			mark = "!"
+		}
+		after[e.after.ID()] = append(after[e.after.ID()], e.before.ID()+mark)
+		before[e.before.ID()] = append(before[e.before.ID()], e.after.ID()+mark)
+	}
+	list := func(ids []string) string {
+		if len(ids) == 0 {
+			return "-"
+		}
+		return strings.Join(ids, ",")
+	}
+
+	w := tabwriter.NewWriter(Stdout, 5, 3, 2, ' ', 0)
+	fmt.Fprintf(w, "Depth\tLanes\tID\tStatus\tKind\tAfter\tBefore\n")
+	for _, t := range tasks {
+		depth := "cycle"
+		if d, ok := depths[t.ID()]; ok {
+			depth = strconv.Itoa(d)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			depth,
+			strutil.IntsToCommaSeparated(t.Lanes()),
+			t.ID(),
+			t.Status(),
+			t.Kind(),
+			list(after[t.ID()]),
+			list(before[t.ID()]))
+	}
+	w.Flush()
+
+	if len(g.external) > 0 {
+		fmt.Fprintf(Stdout, "\nTasks of other changes:\n")
+		w := tabwriter.NewWriter(Stdout, 5, 3, 2, ' ', 0)
+		fmt.Fprintf(w, "Change\tID\tStatus\tKind\n")
+		for _, t := range g.external {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Change().ID(), t.ID(), t.Status(), t.Kind())
+		}
+		w.Flush()
+	}
+	for _, e := range g.edges {
+		if !e.consistent() {
+			fmt.Fprintf(Stdout, "\n! dependency recorded on only one of the tasks\n")
+			break
+		}
+	}
+}
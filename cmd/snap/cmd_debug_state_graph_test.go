@@ -0,0 +1,156 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	main "github.com/snapcore/snapd/cmd/snap"
+)
+
+var stateGraphJSON = []byte(`
+{
+	"last-task-id": 5,
+	"last-change-id": 2,
+
+	"data": {},
+	"changes": {
+		"1": {
+			"id": "1",
+			"kind": "install-snap",
+			"summary": "Install \"a\" snap",
+			"task-ids": ["1","2","3","4"]
+		},
+		"2": {
+			"id": "2",
+			"kind": "refresh-snap",
+			"summary": "Refresh \"b\" snap",
+			"task-ids": ["5"]
+		}
+	},
+	"tasks": {
+		"1": {"id": "1", "change": "1", "kind": "prerequisites", "status": 4, "halt-tasks": ["2"]},
+		"2": {"id": "2", "change": "1", "kind": "download-snap", "status": 3, "wait-tasks": ["1"], "halt-tasks": ["3"], "lanes": [1]},
+		"3": {"id": "3", "change": "1", "kind": "mount-snap", "status": 2, "wait-tasks": ["2", "5"], "lanes": [1]},
+		"4": {"id": "4", "change": "1", "kind": "run-hook", "status": 1, "wait-tasks": ["3"]},
+		"5": {"id": "5", "change": "2", "kind": "link-snap", "status": 9, "halt-tasks": ["3"]}
+	}
+}
+`)
+
+func (s *SnapSuite) writeStateGraphState(c *C) string {
+	stateFile := filepath.Join(c.MkDir(), "test-state.json")
+	c.Assert(os.WriteFile(stateFile, stateGraphJSON, 0644), IsNil)
+	return stateFile
+}
+
+func (s *SnapSuite) TestDebugStateGraphDot(c *C) {
+	stateFile := s.writeStateGraphState(c)
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--change=1", "--dot", stateFile})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `digraph "change-1" {
+  label="1 install-snap: Install \"a\" snap";
+  node [shape=box, style=filled];
+  "1" [label="1 prerequisites\nDone", fillcolor="palegreen"];
+  "4" [label="4 run-hook\nHold", fillcolor="lightgrey"];
+  subgraph cluster_1 {
+    label="lanes 1";
+    "2" [label="2 download-snap\nDoing", fillcolor="yellow"];
+    "3" [label="3 mount-snap\nDo", fillcolor="white"];
+  }
+  "5" [label="5 link-snap\nError\n(change 2)", style=dashed];
+  "2" -> "1";
+  "3" -> "2";
+  "4" -> "3" [color=red, label="wait only"];
+  "3" -> "5";
+}
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDebugStateGraphASCII(c *C) {
+	stateFile := s.writeStateGraphState(c)
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--change=1", "--graph", stateFile})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"Depth  Lanes  ID   Status  Kind           After  Before\n"+
+		"0      0      1    Done    prerequisites  -      2\n"+
+		"1      1      2    Doing   download-snap  1      3\n"+
+		"2      1      3    Do      mount-snap     2,5    4!\n"+
+		"3      0      4    Hold    run-hook       3!     -\n"+
+		"\n"+
+		"Tasks of other changes:\n"+
+		"Change  ID   Status  Kind\n"+
+		"2       5    Error   link-snap\n"+
+		"\n"+
+		"! dependency recorded on only one of the tasks\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDebugStateGraphNoHold(c *C) {
+	stateFile := s.writeStateGraphState(c)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--change=1", "--graph", "--no-hold", stateFile})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"Depth  Lanes  ID   Status  Kind           After  Before\n"+
+		"0      0      1    Done    prerequisites  -      2\n"+
+		"1      1      2    Doing   download-snap  1      3\n"+
+		"2      1      3    Do      mount-snap     2,5    -\n"+
+		"\n"+
+		"Tasks of other changes:\n"+
+		"Change  ID   Status  Kind\n"+
+		"2       5    Error   link-snap\n")
+}
+
+func (s *SnapSuite) TestDebugStateGraphCycles(c *C) {
+	stateFile := filepath.Join(c.MkDir(), "test-state.json")
+	c.Assert(os.WriteFile(stateFile, stateCyclesJSON, 0644), IsNil)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--change=1", "--graph", stateFile})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"Depth  Lanes  ID   Status  Kind  After    Before\n"+
+		"cycle  1,2    11   Done    foo   13!      13!\n"+
+		"cycle  1      12   Do      bar   13!      13!\n"+
+		"cycle  2      13   Do      bar   11!,12!  11!,12!\n"+
+		"\n"+
+		"! dependency recorded on only one of the tasks\n")
+}
+
+func (s *SnapSuite) TestDebugStateGraphErrors(c *C) {
+	stateFile := s.writeStateGraphState(c)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--graph", stateFile})
+	c.Check(err, ErrorMatches, "--graph can only be used with --change=")
+
+	_, err = main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--change=1", "--graph", "--dot", stateFile})
+	c.Check(err, ErrorMatches, "cannot use --graph and --dot together")
+
+	_, err = main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--change=9", "--graph", stateFile})
+	c.Check(err, ErrorMatches, "no such change: 9")
+}
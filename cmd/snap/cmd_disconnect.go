@@ -31,6 +31,7 @@ import (
 type cmdDisconnect struct {
 	waitMixin
 	Forget      bool `long:"forget"`
+	DryRun      bool `long:"dry-run"`
 	Positionals struct {
 		Offer disconnectSlotOrPlugSpec `required:"true"`
 		Use   disconnectSlotSpec
@@ -55,12 +56,20 @@ When an automatic connection is manually disconnected, its disconnected state
 is retained after a snap refresh. The --forget flag can be added to the
 disconnect command to reset this behaviour, and consequently re-enable
 an automatic reconnection after a snap refresh.
+
+With --dry-run, the connections that would be removed and the security
+profiles that would be regenerated as a result are shown, without
+disconnecting anything.
 `)
 
 func init() {
 	addCommand("disconnect", shortDisconnectHelp, longDisconnectHelp, func() flags.Commander {
 		return &cmdDisconnect{}
-	}, waitDescs.also(map[string]string{"forget": "Forget remembered state about the given connection."}), []argDesc{
+	}, waitDescs.also(map[string]string{
+		"forget": "Forget remembered state about the given connection.",
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"dry-run": i18n.G("Show what would be disconnected without disconnecting anything"),
+	}), []argDesc{
 		// TRANSLATORS: This needs to begin with < and end with >
 		{name: i18n.G("<snap>:<plug>")},
 		// TRANSLATORS: This needs to begin with < and end with >
@@ -84,6 +93,20 @@ func (x *cmdDisconnect) Execute(args []string) error {
 	}
 
 	opts := &client.DisconnectOptions{Forget: x.Forget}
+	if x.DryRun {
+		effects, err := x.client.DisconnectDryRun(offer.Snap, offer.Name, use.Snap, use.Name, opts)
+		if err != nil {
+			if client.IsInterfacesUnchangedError(err) {
+				fmt.Fprintf(Stdout, i18n.G("No connections to disconnect"))
+				fmt.Fprintf(Stdout, "\n")
+				return nil
+			}
+			return err
+		}
+		showInterfaceActionEffects(effects, i18n.G("No connections to disconnect"))
+		return nil
+	}
+
 	id, err := x.client.Disconnect(offer.Snap, offer.Name, use.Snap, use.Name, opts)
 	if err != nil {
 		if client.IsInterfacesUnchangedError(err) {
@@ -51,10 +51,16 @@ is retained after a snap refresh. The --forget flag can be added to the
 disconnect command to reset this behaviour, and consequently re-enable
 an automatic reconnection after a snap refresh.
 
+With --dry-run, the connections that would be removed and the security
+profiles that would be regenerated as a result are shown, without
+disconnecting anything.
+
 [disconnect command options]
       --no-wait          Do not wait for the operation to finish but just print
                          the change id.
       --forget           Forget remembered state about the given connection.
+      --dry-run          Show what would be disconnected without disconnecting
+                         anything
 `
 	s.testSubCommandHelp(c, "disconnect", msg)
 }
@@ -95,6 +101,48 @@ func (s *SnapSuite) TestDisconnectExplicitEverything(c *C) {
 	c.Assert(s.Stderr(), Equals, "")
 }
 
+func (s *SnapSuite) TestDisconnectDryRun(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/interfaces":
+			c.Check(r.Method, Equals, "POST")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action":  "disconnect",
+				"dry-run": true,
+				"plugs": []interface{}{
+					map[string]interface{}{
+						"snap": "consumer",
+						"plug": "plug",
+					},
+				},
+				"slots": []interface{}{
+					map[string]interface{}{
+						"snap": "producer",
+						"slot": "slot",
+					},
+				},
+			})
+			fmt.Fprintln(w, `{"type":"sync", "result":{
+"connections": [{"plug": {"snap": "consumer", "plug": "plug"}, "slot": {"snap": "producer", "slot": "slot"}, "interface": "test"}],
+"profiles": [{"snap": "consumer", "security-tags": ["snap.consumer.app"]}, {"snap": "producer", "security-tags": ["snap.producer.app"]}]
+}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"disconnect", "--dry-run", "consumer:plug", "producer:slot"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `Interface  Plug           Slot
+test       consumer:plug  producer:slot
+
+Security profiles that would be regenerated:
+  snap.consumer.app
+  snap.producer.app
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
 func (s *SnapSuite) TestDisconnectWithForgetFlag(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
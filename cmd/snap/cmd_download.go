@@ -143,7 +143,7 @@ func (x *cmdDownload) downloadFromStore(snapName string, revision snap.Revision)
 	dlOpts := tooling.DownloadSnapOptions{
 		TargetDir: x.TargetDir,
 		Basename:  x.Basename,
-		Channel:   x.Channel,
+		Channel:   string(x.Channel),
 		CohortKey: x.CohortKey,
 		Revision:  revision,
 		// if something goes wrong, don't force it to start over again
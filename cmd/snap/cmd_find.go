@@ -45,6 +45,10 @@ With the --private flag, which requires the user to be logged-in to the store
 has developer access to, either directly or through the store's collaboration
 feature.
 
+The results can be restricted to the snaps of a given publisher with
+--publisher, or to snaps providing a slot of a given interface with
+--provides-interface.
+
 A green check mark (given color and unicode support) after a publisher name
 indicates that the publisher has been verified.
 `)
@@ -151,9 +155,11 @@ func showSections(cli *client.Client) error {
 
 type cmdFind struct {
 	clientMixin
-	Private    bool        `long:"private"`
-	Narrow     bool        `long:"narrow"`
-	Section    SectionName `long:"section" optional:"true" optional-value:"show-all-sections-please" default:"no-section-specified" default-mask:"-"`
+	Private    bool          `long:"private"`
+	Narrow     bool          `long:"narrow"`
+	Section    SectionName   `long:"section" optional:"true" optional-value:"show-all-sections-please" default:"no-section-specified" default-mask:"-"`
+	Publisher  string        `long:"publisher"`
+	Interface  interfaceName `long:"provides-interface"`
 	Positional struct {
 		Query []string
 	} `positional-args:"yes"`
@@ -170,6 +176,10 @@ func init() {
 		"narrow": i18n.G("Only search for snaps in “stable”."),
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"section": i18n.G("Restrict the search to a given section."),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"publisher": i18n.G("Restrict the search to snaps from the given publisher."),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"provides-interface": i18n.G("Restrict the search to snaps providing a slot of the given interface."),
 	}), []argDesc{{
 		// TRANSLATORS: This needs to begin with < and end with >
 		name: i18n.G("<query>"),
@@ -201,7 +211,7 @@ func (x *cmdFind) Execute(args []string) error {
 	}
 
 	// magic! `snap find` returns the featured snaps
-	showFeatured := (query == "" && x.Section == "")
+	showFeatured := (query == "" && x.Section == "" && x.Publisher == "" && x.Interface == "")
 	if showFeatured {
 		x.Section = "featured"
 	}
@@ -228,6 +238,9 @@ func (x *cmdFind) Execute(args []string) error {
 		Query:   query,
 		Section: string(x.Section),
 		Private: x.Private,
+
+		Publisher: x.Publisher,
+		Interface: string(x.Interface),
 	}
 
 	if !x.Narrow {
@@ -372,6 +372,36 @@ hello-huge +1.0 +noise +- +a really big snap
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *SnapSuite) TestFindPublisherAndInterface(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/find")
+			q := r.URL.Query()
+			c.Check(q, check.HasLen, 3)
+			c.Check(q.Get("publisher"), check.Equals, "canonical")
+			c.Check(q.Get("interface"), check.Equals, "content")
+			c.Check(q.Get("scope"), check.Equals, "wide")
+			fmt.Fprint(w, findHelloJSON)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"find", "--publisher=canonical", "--provides-interface=content"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	// no featured snaps header
+	c.Check(s.Stdout(), check.Matches, `Name +Version +Publisher +Notes +Summary
+hello +2.10 +canonical\*\* +- +GNU Hello, the "hello world" snap
+hello-huge +1.0 +noise +- +a really big snap
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
 const findPricedJSON = `
 {
   "type": "sync",
@@ -48,6 +48,16 @@ Nested values may be retrieved via a dotted path:
 
     $ snap get snap-name author.name
     frank
+
+With -d, the requested values are printed as a single JSON document in which
+dotted paths are expanded into nested objects:
+
+    $ snap get -d snap-name author.name
+    {
+    	"author": {
+    		"name": "frank"
+    	}
+    }
 `)
 
 type cmdGet struct {
@@ -143,6 +153,34 @@ func flattenConfig(cfg map[string]interface{}, root bool) (values []ConfigValue)
 	return values
 }
 
+// nestConfig expands the dotted keys of the given configuration into nested
+// documents, so that {"a.b": 1} becomes {"a": {"b": 1}}.
+func nestConfig(conf map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(conf))
+	for k := range conf {
+		keys = append(keys, k)
+	}
+	// shorter paths first, so that values of nested paths are merged into
+	// the documents of their parents
+	sort.Strings(keys)
+
+	nested := make(map[string]interface{}, len(conf))
+	for _, k := range keys {
+		subkeys := strings.Split(k, ".")
+		doc := nested
+		for _, subkey := range subkeys[:len(subkeys)-1] {
+			sub, ok := doc[subkey].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				doc[subkey] = sub
+			}
+			doc = sub
+		}
+		doc[subkeys[len(subkeys)-1]] = conf[k]
+	}
+	return nested
+}
+
 func rootRequested(confKeys []string) bool {
 	return len(confKeys) == 0
 }
@@ -249,7 +287,7 @@ func (x *cmdGet) Execute(args []string) error {
 
 	switch {
 	case x.Document:
-		return x.outputJson(conf)
+		return x.outputJson(nestConfig(conf))
 	case x.List:
 		return x.outputList(conf)
 	default:
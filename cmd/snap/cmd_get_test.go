@@ -81,6 +81,12 @@ var getTests = []getCmdArgs{{
 }, {
 	args:   "get -d snapname document",
 	stdout: "{\n\t\"document\": {\n\t\t\"key1\": \"value1\",\n\t\t\"key2\": \"value2\"\n\t}\n}\n",
+}, {
+	args:   "get -d snapname document.key1",
+	stdout: "{\n\t\"document\": {\n\t\t\"key1\": \"value1\"\n\t}\n}\n",
+}, {
+	args:   "get -d snapname document.key1 document.sub.key3 test-key2",
+	stdout: "{\n\t\"document\": {\n\t\t\"key1\": \"value1\",\n\t\t\"sub\": {\n\t\t\t\"key3\": [\n\t\t\t\t1,\n\t\t\t\t2\n\t\t\t]\n\t\t}\n\t},\n\t\"test-key2\": 2\n}\n",
 }, {
 	args:   "get -l snapname",
 	stdout: "Key  Value\nbar  100\nfoo  {...}\n",
@@ -202,6 +208,10 @@ func (s *SnapSuite) mockGetConfigServer(c *C) {
 			fmt.Fprintln(w, `{"type":"sync", "status-code": 200, "result": {"test-key3":{"a":1,"b":2},"test-key3-a":9,"test-key4":{"a":3,"b":4}}}`)
 		case "missing-key":
 			fmt.Fprintln(w, `{"type":"sync", "status-code": 200, "result": {}}`)
+		case "document.key1":
+			fmt.Fprintln(w, `{"type":"sync", "status-code": 200, "result": {"document.key1":"value1"}}`)
+		case "document.key1,document.sub.key3,test-key2":
+			fmt.Fprintln(w, `{"type":"sync", "status-code": 200, "result": {"document.key1":"value1","document.sub.key3":[1,2],"test-key2":2}}`)
 		case "document":
 			fmt.Fprintln(w, `{"type":"sync", "status-code": 200, "result": {"document":{"key1":"value1","key2":"value2"}}}`)
 		case "":
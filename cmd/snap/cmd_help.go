@@ -223,7 +223,7 @@ var helpCategories = []helpCategory{
 	}, {
 		Label:       i18n.G("Configuration"),
 		Description: i18n.G("system administration and configuration"),
-		Commands:    []string{"get", "set", "unset", "wait"},
+		Commands:    []string{"get", "set", "unset", "saveconfig", "restoreconfig", "wait"},
 	}, {
 		Label:       i18n.G("App Aliases"),
 		Description: i18n.G("manage aliases"),
@@ -260,7 +260,7 @@ var helpCategories = []helpCategory{
 	}, {
 		Label:           i18n.G("Development"),
 		Description:     i18n.G("developer-oriented features"),
-		Commands:        []string{"download", "pack", "run", "try"},
+		Commands:        []string{"download", "mirror", "pack", "run", "try"},
 		AllOnlyCommands: []string{"prepare-image"},
 	}, {
 		Label:       i18n.G("Quota Groups"),
@@ -38,6 +38,11 @@ The list command displays a summary of snaps installed in the current system.
 
 A green check mark (given color and unicode support) after a publisher name
 indicates that the publisher has been verified.
+
+If the --sizes option is given, the disk space used by each snap is shown
+instead: the size of its snap files for all the revisions kept on the system,
+of its system data, of the system data shared across revisions, and of its
+snapshots, followed by the totals.
 `)
 
 type cmdList struct {
@@ -46,15 +51,19 @@ type cmdList struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
 
-	All bool `long:"all"`
+	All   bool `long:"all"`
+	Sizes bool `long:"sizes"`
 	colorMixin
+	tableMixin
 }
 
 func init() {
 	addCommand("list", shortListHelp, longListHelp, func() flags.Commander { return &cmdList{} },
-		colorDescs.also(map[string]string{
+		colorDescs.also(tableDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"all": i18n.G("Show all revisions"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"sizes": i18n.G("Show the disk space used by the snaps"),
 		}), nil)
 }
 
@@ -98,6 +107,13 @@ func (x *cmdList) Execute(args []string) error {
 	}
 
 	names := installedSnapNames(x.Positional.Snaps)
+	if x.Sizes {
+		if x.All {
+			return fmt.Errorf(i18n.G("cannot use --sizes with --all"))
+		}
+		return x.listSizes(names)
+	}
+
 	snaps, err := x.client.List(names, &client.ListOptions{All: x.All})
 	if err != nil {
 		if err == client.ErrNoSnapsInstalled {
@@ -115,26 +131,85 @@ func (x *cmdList) Execute(args []string) error {
 	sort.Sort(snapsByName(snaps))
 
 	esc := x.getEscapes()
-	w := tabWriter()
-
-	// TRANSLATORS: the %s is to insert a filler escape sequence (please keep it flush to the column header, with no extra spaces)
-	fmt.Fprintf(w, i18n.G("Name\tVersion\tRev\tTracking\tPublisher%s\tNotes\n"), fillerPublisher(esc))
+	t, err := x.newTable(Stdout,
+		tableColumn{"name", i18n.G("Name")},
+		tableColumn{"version", i18n.G("Version")},
+		tableColumn{"rev", i18n.G("Rev")},
+		tableColumn{"tracking", i18n.G("Tracking")},
+		tableColumn{"publisher", i18n.G("Publisher")},
+		tableColumn{"notes", i18n.G("Notes")},
+	)
+	if err != nil {
+		return err
+	}
 
 	for _, snap := range snaps {
-		// doing it this way because otherwise it's a sea of %s\t%s\t%s
-		line := []string{
+		t.addRow(
 			snap.Name,
 			fmtVersion(snap.Version),
 			snap.Revision.String(),
 			fmtChannel(snap.TrackingChannel),
 			shortPublisher(esc, snap.Publisher),
 			NotesFromLocal(snap).String(),
+		)
+	}
+
+	return t.render()
+}
+
+func (x *cmdList) listSizes(names []string) error {
+	sizes, err := x.client.SnapSizes(names)
+	if err != nil {
+		return err
+	}
+	if len(sizes) == 0 {
+		if len(names) == 0 {
+			fmt.Fprintln(Stderr, i18n.G("No snaps are installed yet. Try 'snap install hello-world'."))
+			return nil
 		}
-		fmt.Fprintln(w, strings.Join(line, "\t"))
+		return ErrNoMatchingSnaps
+	}
+
+	t, err := x.newTable(Stdout,
+		tableColumn{"name", i18n.G("Name")},
+		tableColumn{"snap", i18n.G("Snap")},
+		tableColumn{"data", i18n.G("Data")},
+		tableColumn{"common", i18n.G("Common")},
+		tableColumn{"snapshots", i18n.G("Snapshots")},
+		tableColumn{"total", i18n.G("Total")},
+	)
+	if err != nil {
+		return err
+	}
+
+	var total client.SnapSizes
+	for _, sz := range sizes {
+		t.addRow(
+			sz.Snap,
+			fmtSize(int64(sz.BlobSize)),
+			fmtSize(int64(sz.DataSize)),
+			fmtSize(int64(sz.CommonDataSize)),
+			fmtSize(int64(sz.SnapshotSize)),
+			fmtSize(int64(sz.Total())),
+		)
+		total.BlobSize += sz.BlobSize
+		total.DataSize += sz.DataSize
+		total.CommonDataSize += sz.CommonDataSize
+		total.SnapshotSize += sz.SnapshotSize
+	}
+	if len(sizes) > 1 {
+		t.addRow(
+			// TRANSLATORS: label of the row with the sum of the sizes of all snaps
+			i18n.G("(total)"),
+			fmtSize(int64(total.BlobSize)),
+			fmtSize(int64(total.DataSize)),
+			fmtSize(int64(total.CommonDataSize)),
+			fmtSize(int64(total.SnapshotSize)),
+			fmtSize(int64(total.Total())),
+		)
 	}
-	w.Flush()
 
-	return nil
+	return t.render()
 }
 
 func tabWriter() *tabwriter.Writer {
@@ -37,12 +37,21 @@ The list command displays a summary of snaps installed in the current system.
 A green check mark (given color and unicode support) after a publisher name
 indicates that the publisher has been verified.
 
+If the --sizes option is given, the disk space used by each snap is shown
+instead: the size of its snap files for all the revisions kept on the system,
+of its system data, of the system data shared across revisions, and of its
+snapshots, followed by the totals.
+
 [list command options]
       --all                           Show all revisions
+      --sizes                         Show the disk space used by the snaps
       --color=[auto|never|always]     Use a little bit of color to highlight
                                       some things. (default: auto)
       --unicode=[auto|never|always]   Use a little bit of Unicode to improve
                                       legibility. (default: auto)
+      --columns=                      Comma-separated list of the columns to
+                                      show, in order
+      --no-header                     Do not show the header line
 `
 	s.testSubCommandHelp(c, "list", msg)
 }
@@ -82,6 +91,57 @@ foo   4.2      17   potatoes  bar        blocked
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *SnapSuite) TestListSizes(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/snap-sizes")
+			c.Check(r.URL.RawQuery, check.Equals, "")
+			fmt.Fprintln(w, `{"type": "sync", "result": [
+{"snap": "bar", "blob-size": 4096, "data-size": 0, "common-data-size": 1000, "snapshot-size": 0},
+{"snap": "foo", "blob-size": 20480000, "data-size": 3000000, "common-data-size": 0, "snapshot-size": 1500000}
+]}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--sizes"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `
+Name     Snap    Data    Common  Snapshots  Total
+bar       4096B      0B   1000B      0B     5.10kB
+foo      20.5MB  3.00MB      0B  1.50MB     25.0MB
+(total)  20.5MB  3.00MB   1000B  1.50MB     25.0MB
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestListSizesOneSnap(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snap-sizes")
+		c.Check(r.URL.RawQuery, check.Equals, "snaps=foo")
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+{"snap": "foo", "blob-size": 20480000, "data-size": 3000000, "common-data-size": 0, "snapshot-size": 1500000}
+]}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--sizes", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `
+Name  Snap    Data    Common  Snapshots  Total
+foo   20.5MB  3.00MB      0B  1.50MB     25.0MB
+`[1:])
+}
+
+func (s *SnapSuite) TestListSizesAll(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--sizes", "--all"})
+	c.Assert(err, check.ErrorMatches, "cannot use --sizes with --all")
+}
+
 func (s *SnapSuite) TestListAll(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -258,3 +318,47 @@ func (s *SnapSuite) TestFormatChannel(c *check.C) {
 		c.Check(snap.FormatChannel(ch), check.Not(check.Equals), "", check.Commentf(ch))
 	}
 }
+
+func (s *SnapSuite) TestListColumnsNoHeader(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+{"name": "foo", "status": "active", "version": "4.2", "revision": 17, "tracking-channel": "latest/stable"},
+{"name": "bar", "status": "active", "version": "版本", "revision": 1, "tracking-channel": "2/edge"}
+]}`)
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--columns=version,Name, rev", "--no-header"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	// wide characters take up two columns
+	c.Check(s.Stdout(), check.Equals, `
+版本  bar  1
+4.2   foo  17
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestListUnknownColumn(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "foo", "status": "active", "version": "4.2", "revision": 17}]}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--columns=name,size"})
+	c.Assert(err, check.ErrorMatches, `unknown column "size" \(available: name, version, rev, tracking, publisher, notes\)`)
+	c.Check(s.Stdout(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestDisplayWidth(c *check.C) {
+	for _, t := range []struct {
+		s     string
+		width int
+	}{
+		{"", 0},
+		{"foo", 3},
+		{"canonical✓", 10},
+		{"\033[32mcanonical✓\033[0m", 10},
+		{"版本", 4},
+		{"é", 1},
+	} {
+		c.Check(snap.DisplayWidth(t.s), check.Equals, t.width, check.Commentf("%q", t.s))
+	}
+}
@@ -21,7 +21,9 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strings"
 
 	"github.com/jessevdk/go-flags"
@@ -32,7 +34,8 @@ import (
 
 type cmdLogin struct {
 	clientMixin
-	Positional struct {
+	MacaroonFile string `long:"macaroon-file"`
+	Positional   struct {
 		Email string
 	} `positional-args:"yes"`
 }
@@ -49,6 +52,11 @@ interactions without sudo, as well as some some developer-oriented features as
 detailed in the help for the find, install and refresh commands.
 
 An account can be set up at https://login.ubuntu.com
+
+For automation, the --macaroon-file option logs in with existing store
+credentials instead of asking for an email address and password. The file
+must hold a JSON object with the root store macaroon under "macaroon" and its
+discharges under "discharges".
 `)
 
 func init() {
@@ -57,7 +65,10 @@ func init() {
 		longLoginHelp,
 		func() flags.Commander {
 			return &cmdLogin{}
-		}, nil, []argDesc{{
+		}, map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"macaroon-file": i18n.G("Log in with the store credentials in the given file"),
+		}, []argDesc{{
 			// TRANSLATORS: This is a noun, and it needs to begin with < and end with >
 			name: i18n.G("<email>"),
 			// TRANSLATORS: This should not start with a lowercase letter (unless it's "login.ubuntu.com")
@@ -106,15 +117,52 @@ func requestLogin(cli *client.Client, email string) error {
 	return requestLoginWith2faRetry(cli, email, strings.TrimSpace(string(password)))
 }
 
+func loginWithMacaroonFile(cli *client.Client, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var creds struct {
+		Macaroon   string   `json:"macaroon"`
+		Discharges []string `json:"discharges"`
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return fmt.Errorf(i18n.G("cannot parse macaroon file %q: %v"), path, err)
+	}
+	if creds.Macaroon == "" {
+		return fmt.Errorf(i18n.G("cannot use macaroon file %q: no macaroon found"), path)
+	}
+	_, err = cli.LoginWithStoreMacaroon(creds.Macaroon, creds.Discharges)
+	return err
+}
+
 func (x *cmdLogin) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
+	if x.MacaroonFile != "" && x.Positional.Email != "" {
+		return fmt.Errorf(i18n.G("cannot use an email address with --macaroon-file"))
+	}
 
 	//TRANSLATORS: after the "... at" follows a URL in the next line
 	fmt.Fprint(Stdout, i18n.G("Personal information is handled as per our privacy notice at\n"))
 	fmt.Fprint(Stdout, "https://www.ubuntu.com/legal/dataprivacy/snap-store\n\n")
 
+	var err error
+	if x.MacaroonFile != "" {
+		err = loginWithMacaroonFile(x.client, x.MacaroonFile)
+	} else {
+		err = x.loginWithPassword()
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(Stdout, i18n.G("Login successful"))
+
+	return nil
+}
+
+func (x *cmdLogin) loginWithPassword() error {
 	email := x.Positional.Email
 	if email == "" {
 		fmt.Fprint(Stdout, i18n.G("Email address: "))
@@ -125,11 +173,5 @@ func (x *cmdLogin) Execute(args []string) error {
 		email = string(in)
 	}
 
-	err := requestLogin(x.client, email)
-	if err != nil {
-		return err
-	}
-	fmt.Fprintln(Stdout, i18n.G("Login successful"))
-
-	return nil
+	return requestLogin(x.client, email)
 }
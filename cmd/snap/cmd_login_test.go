@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"path/filepath"
 
 	. "gopkg.in/check.v1"
 
@@ -91,3 +92,61 @@ Login successful
 	c.Check(s.Stderr(), Equals, "")
 	c.Check(n, Equals, 1)
 }
+
+func (s *SnapSuite) TestLoginMacaroonFile(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, Equals, "/v2/login")
+			c.Check(r.Method, Equals, "POST")
+			postData, err := ioutil.ReadAll(r.Body)
+			c.Assert(err, IsNil)
+			c.Check(string(postData), Equals, `{"macaroon":"root","discharges":["discharge"]}`+"\n")
+			fmt.Fprintln(w, mockLoginRsp)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+		n++
+	})
+
+	macaroonFile := filepath.Join(c.MkDir(), "creds")
+	err := ioutil.WriteFile(macaroonFile, []byte(`{"macaroon": "root", "discharges": ["discharge"]}`), 0600)
+	c.Assert(err, IsNil)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"login", "--macaroon-file", macaroonFile})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `Personal information is handled as per our privacy notice at
+https://www.ubuntu.com/legal/dataprivacy/snap-store
+
+Login successful
+`)
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(n, Equals, 1)
+}
+
+func (s *SnapSuite) TestLoginMacaroonFileInvalid(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request %q", r.URL.Path)
+	})
+
+	macaroonFile := filepath.Join(c.MkDir(), "creds")
+	for _, tc := range []struct {
+		content string
+		err     string
+	}{
+		{"junk", `cannot parse macaroon file ".*/creds": .*`},
+		{`{"discharges": ["discharge"]}`, `cannot use macaroon file ".*/creds": no macaroon found`},
+	} {
+		err := ioutil.WriteFile(macaroonFile, []byte(tc.content), 0600)
+		c.Assert(err, IsNil)
+
+		_, err = snap.Parser(snap.Client()).ParseArgs([]string{"login", "--macaroon-file", macaroonFile})
+		c.Check(err, ErrorMatches, tc.err)
+	}
+
+	// the store does not vouch for an email address with a macaroon
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"login", "--macaroon-file", macaroonFile, "foo@example.com"})
+	c.Check(err, ErrorMatches, "cannot use an email address with --macaroon-file")
+}
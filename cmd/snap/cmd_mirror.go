@@ -0,0 +1,121 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016-2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/snap/channel"
+	"github.com/snapcore/snapd/store/tooling"
+)
+
+type cmdMirror struct {
+	TargetDir string `long:"target-directory"`
+
+	Positional struct {
+		Snaps []string `positional-arg-name:"<snap>" required:"1"`
+	} `positional-args:"true" required:"true"`
+}
+
+var shortMirrorHelp = i18n.G("Publish snaps to a static mirror")
+var longMirrorHelp = i18n.G(`
+The mirror command downloads the given snaps, optionally from the given
+channels, together with their supporting assertions and writes them out with
+an index.json describing them, in a layout that can be served by any static
+HTTP server or object storage:
+
+  index.json
+  blobs/<snap>_<revision>.snap
+  assertions/<snap>_<revision>.assert
+
+Mirroring into a directory again rewrites the index with the given snaps.
+`)
+
+func init() {
+	addCommand("mirror", shortMirrorHelp, longMirrorHelp, func() flags.Commander {
+		return &cmdMirror{}
+	}, map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"target-directory": i18n.G("Publish to this directory (defaults to the current directory)"),
+	}, []argDesc{{
+		name: "<snap>",
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("Snap to publish, optionally followed by =<channel>"),
+	}})
+}
+
+// for testing
+var imageMirror = image.Mirror
+
+func parseMirrorSnaps(args []string) ([]image.MirrorSnap, error) {
+	snaps := make([]image.MirrorSnap, 0, len(args))
+	for _, arg := range args {
+		l := strings.SplitN(arg, "=", 2)
+		name, ch := l[0], ""
+		if len(l) == 2 {
+			ch = l[1]
+		}
+		if name == "" {
+			return nil, fmt.Errorf(i18n.G("cannot mirror %q: missing snap name"), arg)
+		}
+		if ch != "" {
+			if _, err := channel.ParseVerbatim(ch, "-"); err != nil {
+				return nil, fmt.Errorf(i18n.G("cannot mirror %q: %v"), arg, err)
+			}
+		}
+		snaps = append(snaps, image.MirrorSnap{Name: name, Channel: ch})
+	}
+	return snaps, nil
+}
+
+func (x *cmdMirror) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	snaps, err := parseMirrorSnaps(x.Positional.Snaps)
+	if err != nil {
+		return err
+	}
+
+	tsto, err := tooling.NewToolingStore()
+	if err != nil {
+		return err
+	}
+	tsto.Stdout = Stdout
+
+	targetDir := x.TargetDir
+	if targetDir == "" {
+		targetDir = "."
+	}
+	index, err := imageMirror(tsto, snaps, targetDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range index.Snaps {
+		fmt.Fprintf(Stdout, i18n.G("Published %s %s (%s)\n"), entry.Name, entry.Version, entry.Revision)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2019 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+
+	"gopkg.in/check.v1"
+
+	snapCmd "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store/tooling"
+)
+
+func (s *SnapSuite) TestMirror(c *check.C) {
+	n := 0
+	restore := snapCmd.MockImageMirror(func(tsto *tooling.ToolingStore, snaps []image.MirrorSnap, targetDir string) (*image.MirrorIndex, error) {
+		n++
+		c.Check(tsto, check.NotNil)
+		c.Check(snaps, check.DeepEquals, []image.MirrorSnap{
+			{Name: "foo"},
+			{Name: "bar", Channel: "latest/edge"},
+		})
+		c.Check(targetDir, check.Equals, "/some/dir")
+		return &image.MirrorIndex{Snaps: []*image.MirrorIndexEntry{
+			{Name: "foo", Version: "1.0", Revision: snap.R(3)},
+			{Name: "bar", Version: "2.0", Revision: snap.R(7)},
+		}}, nil
+	})
+	defer restore()
+
+	rest, err := snapCmd.Parser(snapCmd.Client()).ParseArgs([]string{
+		"mirror", "--target-directory=/some/dir", "foo", "bar=latest/edge",
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, "Published foo 1.0 (3)\nPublished bar 2.0 (7)\n")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestMirrorError(c *check.C) {
+	restore := snapCmd.MockImageMirror(func(tsto *tooling.ToolingStore, snaps []image.MirrorSnap, targetDir string) (*image.MirrorIndex, error) {
+		c.Check(targetDir, check.Equals, ".")
+		return nil, fmt.Errorf("boom")
+	})
+	defer restore()
+
+	_, err := snapCmd.Parser(snapCmd.Client()).ParseArgs([]string{"mirror", "foo"})
+	c.Check(err, check.ErrorMatches, "boom")
+}
+
+func (s *SnapSuite) TestMirrorBadArgs(c *check.C) {
+	restore := snapCmd.MockImageMirror(func(tsto *tooling.ToolingStore, snaps []image.MirrorSnap, targetDir string) (*image.MirrorIndex, error) {
+		c.Fatalf("unexpected call")
+		return nil, nil
+	})
+	defer restore()
+
+	for _, tc := range []struct {
+		arg, err string
+	}{
+		{"=stable", `cannot mirror "=stable": missing snap name`},
+		{"foo=a/b/c/d", `cannot mirror "foo=a/b/c/d": channel name has too many components: a/b/c/d`},
+	} {
+		_, err := snapCmd.Parser(snapCmd.Client()).ParseArgs([]string{"mirror", tc.arg})
+		c.Check(err, check.ErrorMatches, tc.err, check.Commentf(tc.arg))
+	}
+}
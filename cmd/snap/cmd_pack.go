@@ -22,6 +22,8 @@ package main
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/jessevdk/go-flags"
 	"golang.org/x/xerrors"
@@ -37,8 +39,10 @@ import (
 type packCmd struct {
 	CheckSkeleton bool   `long:"check-skeleton"`
 	AppendVerity  bool   `long:"append-integrity-data" hidden:"yes"`
+	VerityFile    bool   `long:"integrity-data-alongside" hidden:"yes"`
 	Filename      string `long:"filename"`
 	Compression   string `long:"compression"`
+	BlockSize     string `long:"block-size"`
 	Positional    struct {
 		SnapDir   string `positional-arg-name:"<snap-dir>"`
 		TargetDir string `positional-arg-name:"<target-dir>"`
@@ -66,6 +70,9 @@ messages.`,
 /*
 When used with --append-integrity-data, pack will append dm-verity data at the end
 of the snap to be used with snapd's snap integrity verification mechanism.
+
+When used with --integrity-data-alongside, pack will instead store the dm-verity
+data in a file named after the snap with a .verity suffix.
 */
 )
 
@@ -81,9 +88,13 @@ func init() {
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"filename": i18n.G("Output to this filename"),
 			// TRANSLATORS: This should not start with a lowercase letter.
-			"compression": i18n.G("Compression to use (e.g. xz or lzo)"),
+			"compression": i18n.G("Compression to use (e.g. xz, lzo or zstd)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"block-size": i18n.G("Size of the compressed blocks (e.g. 128K or 1M)"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"append-integrity-data": i18n.G("Generate and append dm-verity data"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"integrity-data-alongside": i18n.G("Generate dm-verity data in a file next to the snap"),
 		}, nil)
 	cmd.extra = func(cmd *flags.Command) {
 		// TRANSLATORS: this describes the default filename for a snap, e.g. core_16-2.35.2_amd64.snap
@@ -91,6 +102,23 @@ func init() {
 	}
 }
 
+// parseBlockSize parses a block size given in bytes or, like mksquashfs
+// does, with a K or M suffix for KiB or MiB.
+func parseBlockSize(s string) (int, error) {
+	num, mul := s, 1
+	switch {
+	case strings.HasSuffix(strings.ToUpper(s), "K"):
+		num, mul = s[:len(s)-1], 1024
+	case strings.HasSuffix(strings.ToUpper(s), "M"):
+		num, mul = s[:len(s)-1], 1024*1024
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf(i18n.G("cannot parse block size %q"), s)
+	}
+	return n * mul, nil
+}
+
 func (x *packCmd) Execute([]string) error {
 	// plug/slot sanitization is disabled (no-op) by default at the package level for "snap" command,
 	// for "snap pack" however we want real validation.
@@ -115,11 +143,22 @@ func (x *packCmd) Execute([]string) error {
 		return err
 	}
 
+	var blockSize int
+	if x.BlockSize != "" {
+		var err error
+		blockSize, err = parseBlockSize(x.BlockSize)
+		if err != nil {
+			return err
+		}
+	}
+
 	snapPath, err := pack.Snap(x.Positional.SnapDir, &pack.Options{
-		TargetDir:   x.Positional.TargetDir,
-		SnapName:    x.Filename,
-		Compression: x.Compression,
-		Integrity:   x.AppendVerity,
+		TargetDir:          x.Positional.TargetDir,
+		SnapName:           x.Filename,
+		Compression:        x.Compression,
+		BlockSize:          blockSize,
+		Integrity:          x.AppendVerity,
+		IntegrityAlongside: x.VerityFile,
 	})
 	if err != nil {
 		// TRANSLATORS: the %q is the snap-dir (the first positional
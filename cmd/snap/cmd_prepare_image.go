@@ -54,6 +54,7 @@ type cmdPrepareImage struct {
 	// TODO: introduce SnapWithChannel?
 	Snaps              []string `long:"snap" value-name:"<snap>[=<channel>]"`
 	ExtraSnaps         []string `long:"extra-snaps" hidden:"yes"` // DEPRECATED
+	Assertions         []string `long:"assert" value-name:"<file>"`
 	RevisionsFile      string   `long:"revisions"`
 	WriteRevisionsFile string   `long:"write-revisions" optional:"true" optional-value:"./seed.manifest"`
 }
@@ -88,6 +89,8 @@ For preparing classic images it supports a --classic mode`),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"extra-snaps": i18n.G("Extra snaps to be installed (DEPRECATED)"),
 			// TRANSLATORS: This should not start with a lowercase letter.
+			"assert": i18n.G("Use the assertions in the given file, e.g. for local snaps, instead of fetching them from the store"),
+			// TRANSLATORS: This should not start with a lowercase letter.
 			"revisions": i18n.G("Specify a seeds.manifest file referencing the exact revisions of the provided snaps which should be installed"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"write-revisions": i18n.G("Writes a manifest file containing references to the exact snap revisions used for the image. A path for the manifest is optional."),
@@ -156,6 +159,9 @@ func (x *cmdPrepareImage) Execute(args []string) error {
 	if len(snapChannels) != 0 {
 		opts.SnapChannels = snapChannels
 	}
+	if len(x.Assertions) != 0 {
+		opts.AssertionFiles = x.Assertions
+	}
 
 	// store-wide cohort key via env, see image/options.go
 	opts.WideCohortKey = os.Getenv("UBUNTU_STORE_COHORT_KEY")
@@ -144,6 +144,27 @@ func (s *SnapPrepareImageSuite) TestPrepareImageExtraSnaps(c *C) {
 	})
 }
 
+func (s *SnapPrepareImageSuite) TestPrepareImageLocalSnapsWithAssertions(c *C) {
+	var opts *image.Options
+	prep := func(o *image.Options) error {
+		opts = o
+		return nil
+	}
+	r := cmdsnap.MockImagePrepare(prep)
+	defer r()
+
+	rest, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "model", "prepare-dir", "--snap", "local.snap", "--assert", "local.assert", "--assert", "other.assert"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+
+	c.Check(opts, DeepEquals, &image.Options{
+		ModelFile:      "model",
+		PrepareDir:     "prepare-dir",
+		Snaps:          []string{"local.snap"},
+		AssertionFiles: []string{"local.assert", "other.assert"},
+	})
+}
+
 func (s *SnapPrepareImageSuite) TestPrepareImageCustomize(c *C) {
 	var opts *image.Options
 	prep := func(o *image.Options) error {
@@ -28,40 +28,80 @@ import (
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/strutil"
 )
 
 type cmdRecovery struct {
-	clientMixin
+	waitMixin
 	colorMixin
 
-	ShowKeys bool `long:"show-keys"`
+	ShowKeys   bool   `long:"show-keys"`
+	RebootInto string `long:"reboot-into" value-name:"<label>"`
+	Create     string `long:"create" value-name:"<label>"`
+
+	Positional struct {
+		Mode string
+	} `positional-args:"true"`
 }
 
-var shortRecoveryHelp = i18n.G("List available recovery systems")
+var shortRecoveryHelp = i18n.G("List and manage recovery systems")
 var longRecoveryHelp = i18n.G(`
-The recovery command lists the available recovery systems.
+The recovery command lists the available recovery systems, along with the
+modes they can be booted into.
 
 With --show-keys it displays recovery keys that can be used to unlock the encrypted partitions if the device-specific automatic unlocking does not work.
+
+With --reboot-into <label> <mode> it reboots into the given mode of the
+recovery system with the given label.
+
+With --create <label> it creates a new recovery system with the given label
+from the snaps of the running system.
 `)
 
 func init() {
 	addCommand("recovery", shortRecoveryHelp, longRecoveryHelp, func() flags.Commander {
 		// XXX: if we want more/nicer details we can add `snap recovery <system>` later
 		return &cmdRecovery{}
-	}, colorDescs.also(
+	}, colorDescs.also(waitDescs).also(
 		map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"show-keys": i18n.G("Show recovery keys (if available) to unlock encrypted partitions."),
-		}), nil)
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"reboot-into": i18n.G("Reboot into the given recovery system, in the mode given as argument"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"create": i18n.G("Create a recovery system with the given label"),
+		}), []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<mode>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("The mode to reboot into, with --reboot-into"),
+	}})
 }
 
 func notesForSystem(sys *client.System) string {
-	if sys.Current {
+	switch {
+	case sys.Broken != "":
+		return "broken"
+	case sys.Current:
 		return "current"
 	}
 	return "-"
 }
 
+func modesForSystem(sys *client.System) string {
+	var modes []string
+	for _, action := range sys.Actions {
+		if action.Mode == "" || strutil.ListContains(modes, action.Mode) {
+			continue
+		}
+		modes = append(modes, action.Mode)
+	}
+	if len(modes) == 0 {
+		return "-"
+	}
+	return strings.Join(modes, ",")
+}
+
 func (x *cmdRecovery) showKeys(w io.Writer) error {
 	var srk *client.SystemRecoveryKeysResponse
 	err := x.client.SystemRecoveryKeys(&srk)
@@ -75,11 +115,58 @@ func (x *cmdRecovery) showKeys(w io.Writer) error {
 	return nil
 }
 
+func (x *cmdRecovery) rebootInto() error {
+	mode := x.Positional.Mode
+	if mode == "" {
+		return fmt.Errorf(i18n.G("--reboot-into requires the mode to reboot into"))
+	}
+	if err := x.client.RebootToSystem(x.RebootInto, mode); err != nil {
+		return err
+	}
+	fmt.Fprintf(Stdout, i18n.G("Reboot into %q %q mode.\n"), x.RebootInto, mode)
+	return nil
+}
+
+func (x *cmdRecovery) create() error {
+	id, err := x.client.CreateSystem(x.Create)
+	if err != nil {
+		return err
+	}
+	if _, err := x.wait(id); err != nil {
+		if err == noWait {
+			return nil
+		}
+		return err
+	}
+	fmt.Fprintf(Stdout, i18n.G("Created recovery system %q.\n"), x.Create)
+	return nil
+}
+
 func (x *cmdRecovery) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
 
+	n := 0
+	for _, set := range []bool{x.ShowKeys, x.RebootInto != "", x.Create != ""} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf(i18n.G("cannot use --show-keys, --reboot-into and --create together"))
+	}
+	if x.Positional.Mode != "" && x.RebootInto == "" {
+		return ErrExtraArgs
+	}
+
+	switch {
+	case x.RebootInto != "":
+		return x.rebootInto()
+	case x.Create != "":
+		return x.create()
+	}
+
 	esc := x.getEscapes()
 	w := tabWriter()
 	defer w.Flush()
@@ -97,13 +184,19 @@ func (x *cmdRecovery) Execute(args []string) error {
 		return nil
 	}
 
-	fmt.Fprintf(w, i18n.G("Label\tBrand%s\tModel\tNotes\n"), fillerPublisher(esc))
+	fmt.Fprintf(w, i18n.G("Label\tBrand%s\tModel\tModes\tNotes\n"), fillerPublisher(esc))
 	for _, sys := range systems {
+		brand, model := "-", "-"
+		if sys.Broken == "" {
+			brand = shortPublisher(esc, &sys.Brand)
+			model = sys.Model.Model
+		}
 		// doing it this way because otherwise it's a sea of %s\t%s\t%s
 		line := []string{
 			sys.Label,
-			shortPublisher(esc, &sys.Brand),
-			sys.Model.Model,
+			brand,
+			model,
+			modesForSystem(&sys),
 			notesForSystem(&sys),
 		}
 		fmt.Fprintln(w, strings.Join(line, "\t"))
@@ -31,20 +31,37 @@ import (
 
 func (s *SnapSuite) TestRecoveryHelp(c *C) {
 	msg := `Usage:
-  snap.test recovery [recovery-OPTIONS]
+  snap.test recovery [recovery-OPTIONS] [<mode>]
 
-The recovery command lists the available recovery systems.
+The recovery command lists the available recovery systems, along with the
+modes they can be booted into.
 
 With --show-keys it displays recovery keys that can be used to unlock the
 encrypted partitions if the device-specific automatic unlocking does not work.
 
+With --reboot-into <label> <mode> it reboots into the given mode of the
+recovery system with the given label.
+
+With --create <label> it creates a new recovery system with the given label
+from the snaps of the running system.
+
 [recovery command options]
-      --color=[auto|never|always]     Use a little bit of color to highlight
-                                      some things. (default: auto)
-      --unicode=[auto|never|always]   Use a little bit of Unicode to improve
-                                      legibility. (default: auto)
-      --show-keys                     Show recovery keys (if available) to
-                                      unlock encrypted partitions.
+      --no-wait                          Do not wait for the operation to
+                                         finish but just print the change id.
+      --color=[auto|never|always]        Use a little bit of color to highlight
+                                         some things. (default: auto)
+      --unicode=[auto|never|always]      Use a little bit of Unicode to improve
+                                         legibility. (default: auto)
+      --show-keys                        Show recovery keys (if available) to
+                                         unlock encrypted partitions.
+      --reboot-into=<label>              Reboot into the given recovery system,
+                                         in the mode given as argument
+      --create=<label>                   Create a recovery system with the
+                                         given label
+
+[recovery command arguments]
+  <mode>:                                The mode to reboot into, with
+                                         --reboot-into
 `
 	s.testSubCommandHelp(c, "recovery", msg)
 }
@@ -90,8 +107,7 @@ func (s *SnapSuite) TestRecovery(c *C) {
                     "display-name": "Other Publishing"
                 },
                 "actions": [
-                    {"title": "recover", "mode": "recover"},
-                    {"title": "reinstall", "mode": "install"}
+                    {"title": "install", "mode": "install"}
                 ]
            }
         ]
@@ -106,13 +122,126 @@ func (s *SnapSuite) TestRecovery(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(rest, DeepEquals, []string{})
 	c.Check(s.Stdout(), Equals, `
-Label     Brand    Model       Notes
-20200101  brand-1  model-id-1  current
-20200802  brand-2  model-id-2  -
+Label     Brand    Model       Modes            Notes
+20200101  brand-1  model-id-1  recover,install  current
+20200802  brand-2  model-id-2  install          -
+`[1:])
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestRecoveryBrokenSystem(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/systems")
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+        "systems": [
+           {
+                "label": "20200101",
+                "broken": "cannot load assertions for label \"20200101\": boom"
+           },
+           {
+                "current": true,
+                "label": "20200802",
+                "model": {
+                    "model": "model-id-2",
+                    "brand-id": "brand-id-1",
+                    "display-name": "Other Model"
+                },
+                "brand": {
+                    "id": "brand-id-2",
+                    "username": "brand-2",
+                    "display-name": "Other Publishing"
+                },
+                "actions": [
+                    {"title": "Reinstall", "mode": "install"},
+                    {"title": "Recover", "mode": "recover"},
+                    {"title": "Run normally", "mode": "run"}
+                ]
+           }
+        ]
+}}`)
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"recovery"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `
+Label     Brand    Model       Modes                Notes
+20200101  -        -           -                    broken
+20200802  brand-2  model-id-2  install,recover,run  current
 `[1:])
 	c.Check(s.Stderr(), Equals, "")
 }
 
+func (s *SnapSuite) TestRecoveryRebootInto(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, Equals, "POST")
+			c.Check(r.URL.Path, Equals, "/v2/systems/20200101")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action": "reboot",
+				"mode":   "recover",
+			})
+			fmt.Fprintln(w, `{"type": "sync", "result": {}}`)
+		default:
+			c.Fatalf("expected to get 1 request, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"recovery", "--reboot-into", "20200101", "recover"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, "Reboot into \"20200101\" \"recover\" mode.\n")
+	c.Check(n, Equals, 1)
+}
+
+func (s *SnapSuite) TestRecoveryCreate(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, Equals, "POST")
+			c.Check(r.URL.Path, Equals, "/v2/systems/20230101")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action": "create",
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type": "async", "status-code": 202, "change": "42"}`)
+		case 1:
+			c.Check(r.Method, Equals, "GET")
+			c.Check(r.URL.Path, Equals, "/v2/changes/42")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+		default:
+			c.Fatalf("expected to get 2 requests, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"recovery", "--create", "20230101"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, "Created recovery system \"20230101\".\n")
+	c.Check(n, Equals, 2)
+}
+
+func (s *SnapSuite) TestRecoveryErrors(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"recovery", "--reboot-into", "20200101"}, "--reboot-into requires the mode to reboot into"},
+		{[]string{"recovery", "--reboot-into", "20200101", "--create", "foo"}, "cannot use --show-keys, --reboot-into and --create together"},
+		{[]string{"recovery", "--show-keys", "--create", "foo"}, "cannot use --show-keys, --reboot-into and --create together"},
+		{[]string{"recovery", "recover"}, "too many arguments for command"},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(tc.args)
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.args))
+	}
+}
+
 func (s *SnapSuite) TestNoRecoverySystems(c *C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -20,6 +20,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -30,10 +31,14 @@ import (
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/retryutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 type cmdRoutineConsoleConfStart struct {
 	clientMixin
+	RebootTimeout time.Duration `long:"reboot-timeout"`
+	JSON          bool          `long:"json"`
 }
 
 var shortRoutineConsoleConfStartHelp = i18n.G("Start console-conf snapd routine")
@@ -41,34 +46,92 @@ var longRoutineConsoleConfStartHelp = i18n.G(`
 The console-conf-start command starts synchronization with console-conf
 
 This command is used by console-conf when it starts up. It delays refreshes if
-there are none currently ongoing, and exits with a specific error code if there
-are ongoing refreshes which console-conf should wait for before prompting the 
-user to begin configuring the device.
+there are none currently ongoing, and waits for ongoing refreshes, seeding and
+device initialization to finish before console-conf prompts the user to begin
+configuring the device.
+
+If the store is offline, that is store access is disabled with
+store.access=offline or the store cannot be reached, or auto-refreshes are held
+by refresh.hold, the command does not wait for anything, as refreshes and
+device initialization may never complete, and exits immediately with status 3
+or 4 respectively.
+
+With --json the progress is reported as a stream of JSON objects on standard
+output, one per line, for frontends to render.
 `)
 
-// TODO: move these to their own package for unified time constants for how
-// often or long we do things like waiting for a reboot, etc. ?
 var snapdAPIInterval = 2 * time.Second
+
+// snapdWaitForFullSystemReboot is only used when snapd did not say in
+// maintenance.json how long the reboot is expected to take.
 var snapdWaitForFullSystemReboot = 10 * time.Minute
 
+// exit statuses used when console-conf is started without waiting for
+// refreshes and device initialization
+const (
+	consoleConfStoreOfflineExitCode = 3
+	consoleConfRefreshHeldExitCode  = 4
+)
+
+// consoleConfTimeSource is used to wait between polls of the snapd API
+var consoleConfTimeSource timeutil.TimeSource = timeutil.RealTimeSource
+
 func init() {
 	c := addRoutineCommand("console-conf-start", shortRoutineConsoleConfStartHelp, longRoutineConsoleConfStartHelp, func() flags.Commander {
 		return &cmdRoutineConsoleConfStart{}
-	}, nil, nil)
+	}, map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"reboot-timeout": i18n.G("How long to wait for the system to reboot (default 10m)"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"json": i18n.G("Report progress as JSON objects on standard output"),
+	}, nil)
 	c.hidden = true
 }
 
-func printfFunc(msg string, format ...interface{}) func() {
-	return func() {
-		fmt.Fprintf(Stderr, msg, format...)
+// consoleConfProgress is a progress event reported with --json.
+type consoleConfProgress struct {
+	// Event is one of "snapd-restart", "system-restart", "seeding",
+	// "device-init", "refreshing-snaps", "store-offline", "refresh-held"
+	// or "ready".
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	// Maintenance is the kind of maintenance snapd is undergoing.
+	Maintenance client.ErrorKind `json:"maintenance,omitempty"`
+	Snaps       []string         `json:"snaps,omitempty"`
+	Changes     []string         `json:"changes,omitempty"`
+	// ETA is when the event is expected to be over at the latest.
+	ETA *time.Time `json:"eta,omitempty"`
+}
+
+// report reports the progress event either as JSON or with the given
+// human readable message.
+func (x *cmdRoutineConsoleConfStart) report(ev *consoleConfProgress, msg string) {
+	if !x.JSON {
+		fmt.Fprint(Stderr, msg)
+		return
+	}
+	ev.Time = timeNow()
+	b, err := json.Marshal(ev)
+	if err != nil {
+		// cannot happen
+		panic(err)
 	}
+	fmt.Fprintf(Stdout, "%s\n", b)
+}
+
+func (x *cmdRoutineConsoleConfStart) reportOnce(once *sync.Once, ev *consoleConfProgress, msg string) {
+	once.Do(func() { x.report(ev, msg) })
 }
 
 func (x *cmdRoutineConsoleConfStart) Execute(args []string) error {
 	var snapdReloadMsgOnce, systemReloadMsgOnce, snapRefreshMsgOnce sync.Once
+	var seedingMsgOnce, deviceInitMsgOnce sync.Once
 
-	for {
-		chgs, snaps, err := x.client.InternalConsoleConfStart()
+	// poll the snapd API at a fixed interval until there is nothing left
+	// to wait for, not more often so as not to DDOS snapd
+	poll := retryutil.Policy{Strategy: retryutil.Constant{Interval: snapdAPIInterval}}
+	for attempt := retryutil.Start(poll, consoleConfTimeSource); attempt.Next(); {
+		res, err := x.client.InternalConsoleConfStart()
 		if err != nil {
 			// snapd may be under maintenance right now, either for base/kernel
 			// snap refreshes which result in a reboot, or for snapd itself
@@ -89,7 +152,10 @@ func (x *cmdRoutineConsoleConfStart) Execute(args []string) error {
 			if maintErr.Kind == client.ErrorKindDaemonRestart {
 				// then we need to wait for snapd to restart, so keep trying
 				// the console-conf-start endpoint until it works
-				snapdReloadMsgOnce.Do(printfFunc("Snapd is reloading, please wait...\n"))
+				x.reportOnce(&snapdReloadMsgOnce, &consoleConfProgress{
+					Event:       "snapd-restart",
+					Maintenance: maintErr.Kind,
+				}, "Snapd is reloading, please wait...\n")
 
 				// we know that snapd isn't available because it is in
 				// maintenance so we don't gain anything by hitting it
@@ -97,44 +163,95 @@ func (x *cmdRoutineConsoleConfStart) Execute(args []string) error {
 				// for the user when it comes back, but it will be busy
 				// doing things when it starts up anyways so it won't be
 				// able to respond immediately
-				time.Sleep(snapdAPIInterval)
 				continue
 			} else if maintErr.Kind == client.ErrorKindSystemRestart {
-				// system is rebooting, just wait for the reboot
-				systemReloadMsgOnce.Do(printfFunc("System is rebooting, please wait for reboot...\n"))
-				time.Sleep(snapdWaitForFullSystemReboot)
-				// if we didn't reboot after 10 minutes something's probably broken
-				return fmt.Errorf("system didn't reboot after 10 minutes even though snapd daemon is in maintenance")
+				// system is rebooting, just wait for the reboot, for
+				// as long as snapd said it would take unless told
+				// otherwise
+				rebootTimeout := snapdWaitForFullSystemReboot
+				if x.RebootTimeout > 0 {
+					rebootTimeout = x.RebootTimeout
+				} else if expected := maintErr.ExpectedDuration(); expected > 0 {
+					rebootTimeout = expected
+				}
+				eta := timeNow().Add(rebootTimeout)
+				x.reportOnce(&systemReloadMsgOnce, &consoleConfProgress{
+					Event:       "system-restart",
+					Maintenance: maintErr.Kind,
+					ETA:         &eta,
+				}, "System is rebooting, please wait for reboot...\n")
+				<-consoleConfTimeSource.After(rebootTimeout)
+				// if we didn't reboot by then something's probably broken
+				return fmt.Errorf("system didn't reboot after %v even though snapd daemon is in maintenance", rebootTimeout)
 			}
+			// unknown kind of maintenance
+			return err
+		}
+
+		// refreshes and device initialization may not complete at
+		// all in these cases, so don't wait for anything
+		if res.StoreOffline {
+			x.report(&consoleConfProgress{Event: "store-offline"}, "Store is offline, not waiting for snap refreshes or device initialization.\n")
+			panic(&exitStatus{consoleConfStoreOfflineExitCode})
+		}
+		if res.RefreshHeld {
+			x.report(&consoleConfProgress{Event: "refresh-held"}, "Snap refreshes are held, not waiting for them.\n")
+			panic(&exitStatus{consoleConfRefreshHeldExitCode})
 		}
 
-		if len(chgs) == 0 {
+		if !res.Wait() {
+			x.report(&consoleConfProgress{Event: "ready"}, "")
 			return nil
 		}
 
-		if len(snaps) == 0 {
-			// internal error if we have chg id's, but no snaps
-			return fmt.Errorf("internal error: returned changes (%v) but no snap names", chgs)
+		if res.Seeding {
+			x.reportOnce(&seedingMsgOnce, &consoleConfProgress{Event: "seeding"}, "Device is being seeded, please wait...\n")
 		}
 
-		snapRefreshMsgOnce.Do(func() {
-			sort.Strings(snaps)
-
-			var snapNameList string
-			switch len(snaps) {
-			case 1:
-				snapNameList = snaps[0]
-			case 2:
-				snapNameList = fmt.Sprintf("%s and %s", snaps[0], snaps[1])
-			default:
-				// don't forget the oxford comma!
-				snapNameList = fmt.Sprintf("%s, and %s", strings.Join(snaps[:len(snaps)-1], ", "), snaps[len(snaps)-1])
-			}
+		if len(res.ActiveDeviceInitChanges) != 0 {
+			x.reportOnce(&deviceInitMsgOnce, &consoleConfProgress{
+				Event:   "device-init",
+				Changes: res.ActiveDeviceInitChanges,
+			}, "Device is being initialized, please wait...\n")
+		}
 
-			fmt.Fprintf(Stderr, "Snaps (%s) are refreshing, please wait...\n", snapNameList)
-		})
+		if len(res.ActiveAutoRefreshChanges) != 0 {
+			if err := x.reportRefreshingSnapsOnce(&snapRefreshMsgOnce, res); err != nil {
+				return err
+			}
+		}
+	}
+	// the polling has no limits
+	return fmt.Errorf("internal error: stopped polling snapd")
+}
 
-		// don't DDOS snapd by hitting it's API too often
-		time.Sleep(snapdAPIInterval)
+func (x *cmdRoutineConsoleConfStart) reportRefreshingSnapsOnce(once *sync.Once, res *client.InternalConsoleConfStartResponse) error {
+	snaps := res.ActiveAutoRefreshSnaps
+	if len(snaps) == 0 {
+		// internal error if we have chg id's, but no snaps
+		return fmt.Errorf("internal error: returned changes (%v) but no snap names", res.ActiveAutoRefreshChanges)
 	}
+
+	once.Do(func() {
+		sort.Strings(snaps)
+
+		var snapNameList string
+		switch len(snaps) {
+		case 1:
+			snapNameList = snaps[0]
+		case 2:
+			snapNameList = fmt.Sprintf("%s and %s", snaps[0], snaps[1])
+		default:
+			// don't forget the oxford comma!
+			snapNameList = fmt.Sprintf("%s, and %s", strings.Join(snaps[:len(snaps)-1], ", "), snaps[len(snaps)-1])
+		}
+
+		x.report(&consoleConfProgress{
+			Event:   "refreshing-snaps",
+			Snaps:   snaps,
+			Changes: res.ActiveAutoRefreshChanges,
+		}, fmt.Sprintf("Snaps (%s) are refreshing, please wait...\n", snapNameList))
+	})
+
+	return nil
 }
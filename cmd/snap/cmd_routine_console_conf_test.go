@@ -25,6 +25,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -32,6 +33,7 @@ import (
 	snap "github.com/snapcore/snapd/cmd/snap"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeutil/clocktest"
 )
 
 func (s *SnapSuite) TestRoutineConsoleConfStartTrivialCase(c *C) {
@@ -157,6 +159,54 @@ func (s *SnapSuite) TestRoutineConsoleConfStartSingleSnap(c *C) {
 	c.Assert(n, Equals, 5)
 }
 
+func (s *SnapSuite) TestRoutineConsoleConfStartSeedingAndDeviceInit(c *C) {
+	// make the command hit the API as fast as possible for testing
+	r := snap.MockSnapdAPIInterval(0)
+	defer r()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.URL.Path, Equals, "/v2/internal/console-conf-start")
+		switch n {
+		// first we are still seeding
+		case 1, 2:
+			fmt.Fprintf(w, `{
+				"type":"sync",
+				"status-code": 200,
+				"result": {
+					"seeding": true
+				}
+			}`)
+		// then the device gets registered while a refresh is happening
+		case 3, 4:
+			fmt.Fprintf(w, `{
+				"type":"sync",
+				"status-code": 200,
+				"result": {
+					"active-device-init-changes": ["2"],
+					"active-auto-refreshes": ["3"],
+					"active-auto-refresh-snaps": ["pc-kernel"]
+				}
+			}`)
+		case 5:
+			fmt.Fprintf(w, `{"type":"sync", "status-code": 200, "result": {}}`)
+		default:
+			c.Errorf("unexpected request %v", n)
+		}
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, `Device is being seeded, please wait...
+Device is being initialized, please wait...
+Snaps (pc-kernel) are refreshing, please wait...
+`)
+	c.Assert(n, Equals, 5)
+}
+
 func (s *SnapSuite) TestRoutineConsoleConfStartTwoSnaps(c *C) {
 	// make the command hit the API as fast as possible for testing
 	r := snap.MockSnapdAPIInterval(0)
@@ -342,12 +392,48 @@ func (s *SnapSuite) TestRoutineConsoleConfStartSystemRebootMaintenanceJSON(c *C)
 	})
 
 	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start"})
-	c.Assert(err, ErrorMatches, "system didn't reboot after 10 minutes even though snapd daemon is in maintenance")
+	c.Assert(err, ErrorMatches, "system didn't reboot after 0s even though snapd daemon is in maintenance")
 	c.Check(s.Stdout(), Equals, "")
 	c.Check(s.Stderr(), testutil.Contains, "System is rebooting, please wait for reboot...\n")
 	c.Assert(n, Equals, 1)
 }
 
+func (s *SnapSuite) TestRoutineConsoleConfStartSystemRebootWaitsOnTimeSource(c *C) {
+	clock := clocktest.New(time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC))
+	restore := snap.MockConsoleConfTimeSource(clock)
+	defer restore()
+
+	maintErr := client.Error{
+		Kind:    client.ErrorKindSystemRestart,
+		Message: "system is restarting",
+	}
+	b, err := json.Marshal(&maintErr)
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapdMaintenanceFile), 0755), IsNil)
+	c.Assert(os.WriteFile(dirs.SnapdMaintenanceFile, b, 0644), IsNil)
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v2/internal/console-conf-start")
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start"})
+		errCh <- err
+	}()
+
+	// the command waits for the reboot on the time source
+	clock.BlockUntil(1)
+	select {
+	case err := <-errCh:
+		c.Fatalf("command returned before the reboot timeout: %v", err)
+	default:
+	}
+	clock.Advance(10 * time.Minute)
+
+	c.Check(<-errCh, ErrorMatches, "system didn't reboot after 10m0s even though snapd daemon is in maintenance")
+}
+
 func (s *SnapSuite) TestRoutineConsoleConfStartSnapdRefreshRestart(c *C) {
 	// make the command hit the API as fast as possible for testing
 	r := snap.MockSnapdAPIInterval(0)
@@ -487,9 +573,199 @@ func (s *SnapSuite) TestRoutineConsoleConfStartKernelRefreshReboot(c *C) {
 	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start"})
 	// this is the internal error, which we will hit immediately for testing,
 	// in a real scenario a reboot would happen OOTB from the snap client
-	c.Assert(err, ErrorMatches, "system didn't reboot after 10 minutes even though snapd daemon is in maintenance")
+	c.Assert(err, ErrorMatches, "system didn't reboot after 0s even though snapd daemon is in maintenance")
 	c.Check(s.Stdout(), Equals, "")
 	c.Check(s.Stderr(), testutil.Contains, "System is rebooting, please wait for reboot...\n")
 	c.Check(s.Stderr(), testutil.Contains, "Snaps (pc-kernel) are refreshing, please wait...\n")
 	c.Assert(n, Equals, 3)
 }
+
+func (s *SnapSuite) TestRoutineConsoleConfStartJSONProgress(c *C) {
+	r := snap.MockSnapdAPIInterval(0)
+	defer r()
+	r = snap.MockTimeNow(func() time.Time {
+		return time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	})
+	defer r()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.URL.Path, Equals, "/v2/internal/console-conf-start")
+		switch n {
+		case 1:
+			fmt.Fprintf(w, `{
+				"type":"sync",
+				"status-code": 200,
+				"result": {
+					"active-device-init-changes": ["2"]
+				}
+			}`)
+		case 2, 3:
+			fmt.Fprintf(w, `{
+				"type":"sync",
+				"status-code": 200,
+				"result": {
+					"active-auto-refreshes": ["1"],
+					"active-auto-refresh-snaps": ["pc-kernel", "core20"]
+				}
+			}`)
+		case 4:
+			fmt.Fprintf(w, `{"type":"sync", "status-code": 200, "result": {}}`)
+		default:
+			c.Errorf("unexpected request %v", n)
+		}
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start", "--json"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		`{"event":"device-init","time":"2023-06-01T10:00:00Z","changes":["2"]}`+"\n"+
+		`{"event":"refreshing-snaps","time":"2023-06-01T10:00:00Z","snaps":["core20","pc-kernel"],"changes":["1"]}`+"\n"+
+		`{"event":"ready","time":"2023-06-01T10:00:00Z"}`+"\n")
+	c.Check(s.Stderr(), Equals, "")
+	c.Assert(n, Equals, 4)
+}
+
+func (s *SnapSuite) TestRoutineConsoleConfStartRebootTimeoutJSON(c *C) {
+	clock := clocktest.New(time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC))
+	restore := snap.MockConsoleConfTimeSource(clock)
+	defer restore()
+	restore = snap.MockTimeNow(clock.Now)
+	defer restore()
+
+	maintErr := client.Error{
+		Kind:    client.ErrorKindSystemRestart,
+		Message: "system is restarting",
+	}
+	b, err := json.Marshal(&maintErr)
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapdMaintenanceFile), 0755), IsNil)
+	c.Assert(os.WriteFile(dirs.SnapdMaintenanceFile, b, 0644), IsNil)
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v2/internal/console-conf-start")
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start", "--reboot-timeout=2m", "--json"})
+		errCh <- err
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Minute)
+
+	c.Check(<-errCh, ErrorMatches, "system didn't reboot after 2m0s even though snapd daemon is in maintenance")
+	c.Check(s.Stdout(), Equals, `{"event":"system-restart","time":"2023-06-01T10:00:00Z","maintenance":"system-restart","eta":"2023-06-01T10:02:00Z"}`+"\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestRoutineConsoleConfStartRebootExpectedDurationJSON(c *C) {
+	clock := clocktest.New(time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC))
+	restore := snap.MockConsoleConfTimeSource(clock)
+	defer restore()
+	restore = snap.MockTimeNow(clock.Now)
+	defer restore()
+
+	// snapd says how long the reboot is expected to take
+	maintErr := client.Error{
+		Kind:    client.ErrorKindSystemRestart,
+		Message: "system is restarting",
+		Value: map[string]interface{}{
+			"op":                "reboot",
+			"expected-duration": "5m0s",
+		},
+	}
+	b, err := json.Marshal(&maintErr)
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapdMaintenanceFile), 0755), IsNil)
+	c.Assert(os.WriteFile(dirs.SnapdMaintenanceFile, b, 0644), IsNil)
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v2/internal/console-conf-start")
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start", "--json"})
+		errCh <- err
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(5 * time.Minute)
+
+	c.Check(<-errCh, ErrorMatches, "system didn't reboot after 5m0s even though snapd daemon is in maintenance")
+	c.Check(s.Stdout(), Equals, `{"event":"system-restart","time":"2023-06-01T10:00:00Z","maintenance":"system-restart","eta":"2023-06-01T10:05:00Z"}`+"\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestRoutineConsoleConfStartStoreOffline(c *C) {
+	r := snap.MockSnapdAPIInterval(0)
+	defer r()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.URL.Path, Equals, "/v2/internal/console-conf-start")
+		switch n {
+		// neither seeding nor device initialization are waited for
+		case 1:
+			fmt.Fprintf(w, `{
+				"type":"sync",
+				"status-code": 200,
+				"result": {
+					"seeding": true,
+					"active-device-init-changes": ["2"],
+					"store-offline": true
+				}
+			}`)
+		default:
+			c.Errorf("unexpected request %v", n)
+		}
+	})
+
+	c.Check(func() {
+		snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start"})
+	}, PanicMatches, `internal error: exitStatus\{3\} .*`)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "Store is offline, not waiting for snap refreshes or device initialization.\n")
+	c.Assert(n, Equals, 1)
+}
+
+func (s *SnapSuite) TestRoutineConsoleConfStartRefreshHeldJSON(c *C) {
+	r := snap.MockSnapdAPIInterval(0)
+	defer r()
+	r = snap.MockTimeNow(func() time.Time {
+		return time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	})
+	defer r()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.URL.Path, Equals, "/v2/internal/console-conf-start")
+		switch n {
+		// the ongoing refresh is not waited for
+		case 1:
+			fmt.Fprintf(w, `{
+				"type":"sync",
+				"status-code": 200,
+				"result": {
+					"active-auto-refreshes": ["1"],
+					"active-auto-refresh-snaps": ["pc-kernel"],
+					"refresh-held": true
+				}
+			}`)
+		default:
+			c.Errorf("unexpected request %v", n)
+		}
+	})
+
+	c.Check(func() {
+		snap.Parser(snap.Client()).ParseArgs([]string{"routine", "console-conf-start", "--json"})
+	}, PanicMatches, `internal error: exitStatus\{4\} .*`)
+	c.Check(s.Stdout(), Equals, `{"event":"refresh-held","time":"2023-06-01T10:00:00Z"}`+"\n")
+	c.Check(s.Stderr(), Equals, "")
+	c.Assert(n, Equals, 1)
+}
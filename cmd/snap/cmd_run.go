@@ -61,6 +61,7 @@ var (
 	syscallExec              = syscall.Exec
 	userCurrent              = user.Current
 	osGetenv                 = os.Getenv
+	osMkdirAll               = os.MkdirAll
 	timeNow                  = time.Now
 	selinuxIsEnabled         = selinux.IsEnabled
 	selinuxVerifyPathContext = selinux.VerifyPathContext
@@ -398,7 +399,11 @@ func createUserDataDirs(info *snap.Info, opts *dirs.SnapDirOptions) error {
 	}
 
 	snapDir := snap.SnapDir(usr.HomeDir, opts)
-	if err := os.MkdirAll(snapDir, 0700); err != nil {
+	if err := osMkdirAll(snapDir, 0700); err != nil {
+		if reason := osutil.HomeNotWritableReason(err); reason != "" {
+			// TRANSLATORS: %q is the home directory of the user, %s why it cannot be written to (e.g. "is on a read-only filesystem")
+			return fmt.Errorf(i18n.G("cannot create snap home dir: home directory %q %s"), usr.HomeDir, reason)
+		}
 		return fmt.Errorf(i18n.G("cannot create snap home dir: %w"), err)
 	}
 	// see snapenv.User
@@ -415,7 +420,11 @@ func createUserDataDirs(info *snap.Info, opts *dirs.SnapDirOptions) error {
 		createDirs = append(createDirs, snapUserDir)
 	}
 	for _, d := range createDirs {
-		if err := os.MkdirAll(d, 0755); err != nil {
+		if err := osMkdirAll(d, 0755); err != nil {
+			if reason := osutil.HomeNotWritableReason(err); reason != "" {
+				// TRANSLATORS: %q is the directory whose creation failed, %s why the home directory cannot be written to (e.g. "is on a read-only filesystem")
+				return fmt.Errorf(i18n.G("cannot create %q: home directory %s"), d, reason)
+			}
 			// TRANSLATORS: %q is the directory whose creation failed, %v the error message
 			return fmt.Errorf(i18n.G("cannot create %q: %v"), d, err)
 		}
@@ -928,6 +937,9 @@ func (x *cmdRun) runCmdWithTraceExec(origCmd []string, envForExec envForExecFunc
 	// wait for strace reader
 	<-doneCh
 	if straceErr == nil {
+		if x.HookName != "" {
+			slg.AppStageName = "hook"
+		}
 		slg.Display(Stderr)
 	} else {
 		logger.Noticef("cannot extract runtime data: %v", straceErr)
@@ -1214,7 +1226,18 @@ func (x *cmdRun) runSnapConfine(info *snap.Info, securityTag, snapApp, hook stri
 	// Track, or confirm existing tracking from systemd.
 	if needsTracking {
 		opts := &cgroup.TrackingOptions{AllowSessionBus: allowSessionBus}
-		if err = cgroupCreateTransientScopeForTracking(securityTag, opts); err != nil {
+		if hook != "" {
+			opts.MemoryMax = hookMemoryMax
+			opts.CPUQuota = hookCPUQuota
+		}
+		err = cgroupCreateTransientScopeForTracking(securityTag, opts)
+		if err != nil && err != cgroup.ErrCannotTrackProcess && hook != "" {
+			// older systemd may not know about the limits
+			logger.Debugf("cannot create transient scope with resource limits for hook %q, retrying without: %v", hook, err)
+			opts = &cgroup.TrackingOptions{AllowSessionBus: allowSessionBus}
+			err = cgroupCreateTransientScopeForTracking(securityTag, opts)
+		}
+		if err != nil {
 			if err != cgroup.ErrCannotTrackProcess {
 				return err
 			}
@@ -1273,5 +1296,14 @@ func getSnapDirOptions(snap string) (*dirs.SnapDirOptions, error) {
 	return &opts, nil
 }
 
+var (
+	// hookMemoryMax is the memory, in bytes, the processes of a hook can
+	// use before getting killed.
+	hookMemoryMax uint64 = 2 << 30
+	// hookCPUQuota is the CPU time the processes of a hook can use per
+	// second, that is one full CPU.
+	hookCPUQuota = time.Second
+)
+
 var cgroupCreateTransientScopeForTracking = cgroup.CreateTransientScopeForTracking
 var cgroupConfirmSystemdServiceTracking = cgroup.ConfirmSystemdServiceTracking
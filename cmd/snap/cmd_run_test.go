@@ -28,6 +28,8 @@ import (
 	"os/user"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"gopkg.in/check.v1"
@@ -101,6 +103,9 @@ func (s *RunSuite) SetUpTest(c *check.C) {
 	}))
 	restoreIsGraphicalSession := snaprun.MockIsGraphicalSession(false)
 	s.AddCleanup(restoreIsGraphicalSession)
+	s.AddCleanup(snaprun.MockRefreshProgress(func(snapName string) (string, int, int, error) {
+		return "", 0, 0, nil
+	}))
 }
 
 func (s *RunSuite) TestInvalidParameters(c *check.C) {
@@ -516,6 +521,46 @@ func (s *RunSuite) testSnapRunCreateDataDirs(c *check.C, snapDir string, opts *d
 	c.Check(osutil.FileExists(filepath.Join(s.fakeHome, nonExistentDir)), check.Equals, false)
 }
 
+func (s *RunSuite) TestSnapRunCreateDataDirsReadOnlyHome(c *check.C) {
+	info, err := snap.InfoFromSnapYaml(mockYaml)
+	c.Assert(err, check.IsNil)
+	info.SideInfo.Revision = snap.R(42)
+
+	failOn := filepath.Join(s.fakeHome, "snap")
+	restore := snaprun.MockOsMkdirAll(func(path string, perm os.FileMode) error {
+		if path == failOn {
+			return &os.PathError{Op: "mkdir", Path: path, Err: syscall.EROFS}
+		}
+		return os.MkdirAll(path, perm)
+	})
+	defer restore()
+
+	err = snaprun.CreateUserDataDirs(info, nil)
+	c.Check(err, check.ErrorMatches, fmt.Sprintf(`cannot create snap home dir: home directory "%s" is on a read-only filesystem`, s.fakeHome))
+
+	// ~/snap exists but the snap directories cannot be created
+	c.Assert(os.MkdirAll(failOn, 0700), check.IsNil)
+	failOn = filepath.Join(s.fakeHome, "snap/snapname/42")
+	err = snaprun.CreateUserDataDirs(info, nil)
+	c.Check(err, check.ErrorMatches, fmt.Sprintf(`cannot create "%s": home directory is on a read-only filesystem`, failOn))
+}
+
+func (s *RunSuite) TestSnapRunCreateDataDirsNFSHome(c *check.C) {
+	info, err := snap.InfoFromSnapYaml(mockYaml)
+	c.Assert(err, check.IsNil)
+	info.SideInfo.Revision = snap.R(42)
+
+	restore := osutil.MockIsHomeUsingNFS(func() (bool, error) { return true, nil })
+	defer restore()
+	restore = snaprun.MockOsMkdirAll(func(path string, perm os.FileMode) error {
+		return &os.PathError{Op: "mkdir", Path: path, Err: syscall.EACCES}
+	})
+	defer restore()
+
+	err = snaprun.CreateUserDataDirs(info, nil)
+	c.Check(err, check.ErrorMatches, fmt.Sprintf(`cannot create snap home dir: home directory "%s" is on NFS and does not allow writing to it`, s.fakeHome))
+}
+
 func (s *RunSuite) TestParallelInstanceSnapRunCreateDataDirs(c *check.C) {
 	info, err := snap.InfoFromSnapYaml(mockYaml)
 	c.Assert(err, check.IsNil)
@@ -1518,6 +1563,8 @@ func (s *RunSuite) TestSnapRunTrackingHooks(c *check.C) {
 		c.Assert(securityTag, check.Equals, "snap.snapname.hook.configure")
 		c.Assert(opts, check.NotNil)
 		c.Assert(opts.AllowSessionBus, check.Equals, false)
+		c.Check(opts.MemoryMax, check.Equals, uint64(2<<30))
+		c.Check(opts.CPUQuota, check.Equals, time.Second)
 		created = true
 		return nil
 	})
@@ -1554,6 +1601,45 @@ func (s *RunSuite) TestSnapRunTrackingHooks(c *check.C) {
 	c.Assert(created, check.Equals, true)
 }
 
+func (s *RunSuite) TestSnapRunTrackingHooksWithoutLimits(c *check.C) {
+	restore := mockSnapConfine(filepath.Join(dirs.SnapMountDir, "core", "111", dirs.CoreLibExecDir))
+	defer restore()
+
+	// mock installed snap
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+
+	// pretend to be running from core
+	restore = snaprun.MockOsReadlink(func(string) (string, error) {
+		return filepath.Join(dirs.SnapMountDir, "core/111/usr/bin/snap"), nil
+	})
+	defer restore()
+
+	var createdOpts []cgroup.TrackingOptions
+	restore = snaprun.MockCreateTransientScopeForTracking(func(securityTag string, opts *cgroup.TrackingOptions) error {
+		createdOpts = append(createdOpts, *opts)
+		if opts.MemoryMax != 0 {
+			// systemd does not know about the limits
+			return fmt.Errorf("cannot create transient scope: DBus error \"org.freedesktop.DBus.Error.InvalidArgs\"")
+		}
+		return nil
+	})
+	defer restore()
+
+	restore = snaprun.MockSyscallExec(func(arg0 string, args []string, envv []string) error {
+		return nil
+	})
+	defer restore()
+
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--hook", "configure", "-r", "x2", "snapname"})
+	c.Assert(err, check.IsNil)
+	c.Check(createdOpts, check.DeepEquals, []cgroup.TrackingOptions{
+		{MemoryMax: 2 << 30, CPUQuota: time.Second},
+		{},
+	})
+}
+
 func (s *RunSuite) TestSnapRunTrackingServices(c *check.C) {
 	restore := mockSnapConfine(filepath.Join(dirs.SnapMountDir, "core", "111", dirs.CoreLibExecDir))
 	defer restore()
@@ -1891,6 +1977,89 @@ func (s *RunSuite) TestWaitWhileInhibitedTextFlow(c *check.C) {
 	c.Check(meter.Labels, check.DeepEquals, []string{"please wait..."})
 }
 
+func (s *RunSuite) TestWaitWhileInhibitedTextFlowProgress(c *check.C) {
+	defer snaprun.MockRefreshProgressInterval(time.Millisecond)()
+
+	var mu sync.Mutex
+	progressCalls := 0
+	restore := snaprun.MockRefreshProgress(func(snapName string) (string, int, int, error) {
+		c.Check(snapName, check.Equals, "some-snap")
+		mu.Lock()
+		defer mu.Unlock()
+		progressCalls++
+		switch progressCalls {
+		case 1:
+			return "", 0, 0, fmt.Errorf("boom")
+		case 2:
+			return `Refresh snap "some-snap"`, 2, 5, nil
+		default:
+			return `Refresh snap "some-snap"`, 4, 5, nil
+		}
+	})
+	defer restore()
+
+	restore = snaprun.MockIsLocked(func(snapName string) (runinhibit.Hint, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if progressCalls < 3 {
+			return runinhibit.HintInhibitedForRefresh, nil
+		}
+		return runinhibit.HintNotInhibited, nil
+	})
+	defer restore()
+
+	meter := &progresstest.Meter{}
+	defer progress.MockMeter(meter)()
+
+	c.Assert(runinhibit.LockWithHint("some-snap", runinhibit.HintInhibitedForRefresh), check.IsNil)
+	c.Assert(snaprun.WaitWhileInhibited("some-snap"), check.IsNil)
+
+	c.Check(s.Stdout(), check.Equals, "snap package \"some-snap\" is being refreshed, please wait\n")
+	c.Check(meter.Finishes, check.Equals, 1)
+	c.Check(meter.Labels, check.DeepEquals, []string{"please wait...", `Refresh snap "some-snap"`})
+	c.Check(meter.Totals, check.DeepEquals, []float64{5})
+	c.Assert(len(meter.Values) >= 2, check.Equals, true)
+	c.Check(meter.Values[:2], check.DeepEquals, []float64{2, 4})
+	for _, v := range meter.Values[2:] {
+		c.Check(v, check.Equals, float64(4))
+	}
+}
+
+func (s *RunSuite) TestRefreshProgress(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/changes")
+		c.Check(r.URL.Query().Get("for"), check.Equals, "some-snap")
+		c.Check(r.URL.Query().Get("select"), check.Equals, "in-progress")
+		switch n {
+		case 1:
+			fmt.Fprintln(w, `{"type": "sync", "result": [
+{"id": "2", "kind": "connect-snap", "summary": "Connect", "status": "Do", "spawn-time": "2023-03-01T10:00:00Z",
+ "tasks": [{"status": "Do"}]},
+{"id": "1", "kind": "auto-refresh", "summary": "Auto-refresh snap \"some-snap\"", "status": "Doing", "spawn-time": "2023-03-01T09:00:00Z",
+ "tasks": [{"status": "Done"}, {"status": "Done"}, {"status": "Undone"}, {"status": "Doing"}, {"status": "Do"}]}
+]}`)
+		case 2:
+			fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+		default:
+			c.Fatalf("expected 2 requests, now on %d", n)
+		}
+	})
+
+	label, done, total, err := snaprun.RefreshProgress("some-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(label, check.Equals, `Auto-refresh snap "some-snap"`)
+	c.Check(done, check.Equals, 3)
+	c.Check(total, check.Equals, 5)
+
+	_, _, total, err = snaprun.RefreshProgress("some-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(total, check.Equals, 0)
+	c.Check(n, check.Equals, 2)
+}
+
 func (s *RunSuite) TestWaitWhileInhibitedGraphicalSessionFlow(c *check.C) {
 	_, r := logger.MockLogger()
 	defer r()
@@ -0,0 +1,189 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/jsonutil"
+	"github.com/snapcore/snapd/osutil"
+)
+
+var shortSaveConfigHelp = i18n.G("Save the configuration of a snap to a file")
+var longSaveConfigHelp = i18n.G(`
+The saveconfig command saves the whole configuration of the given snap as a
+JSON document to the given file, or to standard output if the file is "-".
+
+    $ snap saveconfig snap-name snap-name-config.json
+
+The saved configuration can be applied again with 'snap restoreconfig', to the
+same snap or to another one.
+`)
+
+var shortRestoreConfigHelp = i18n.G("Restore the configuration of a snap from a file")
+var longRestoreConfigHelp = i18n.G(`
+The restoreconfig command replaces the whole configuration of the given snap
+with the JSON document read from the given file, or from standard input if the
+file is "-". Options that are not present in the document are unset.
+
+    $ snap restoreconfig snap-name snap-name-config.json
+
+All the changes are applied at once, and the snap's configure hook is run a
+single time.
+`)
+
+type cmdSaveConfig struct {
+	clientMixin
+	Positional struct {
+		Snap installedSnapName `required:"yes"`
+		File flags.Filename    `required:"yes"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type cmdRestoreConfig struct {
+	waitMixin
+	Positional struct {
+		Snap installedSnapName `required:"yes"`
+		File flags.Filename    `required:"yes"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addCommand("saveconfig", shortSaveConfigHelp, longSaveConfigHelp, func() flags.Commander {
+		return &cmdSaveConfig{}
+	}, nil, []argDesc{{
+		name: "<snap>",
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("The snap whose configuration is saved"),
+	}, {
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<file>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("The file to save the configuration to"),
+	}})
+	addCommand("restoreconfig", shortRestoreConfigHelp, longRestoreConfigHelp, func() flags.Commander {
+		return &cmdRestoreConfig{}
+	}, waitDescs, []argDesc{{
+		name: "<snap>",
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("The snap whose configuration is restored"),
+	}, {
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<file>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("The file to restore the configuration from"),
+	}})
+}
+
+func (x *cmdSaveConfig) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	snapName := string(x.Positional.Snap)
+	conf, err := x.client.Conf(snapName, nil)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(conf, "", "\t")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	fname := string(x.Positional.File)
+	if fname == "-" {
+		_, err := Stdout.Write(data)
+		return err
+	}
+	// the configuration may contain secrets
+	if err := osutil.AtomicWriteFile(fname, data, 0600, 0); err != nil {
+		return fmt.Errorf(i18n.G("cannot save configuration of snap %q: %v"), snapName, err)
+	}
+	return nil
+}
+
+func readConfigDocument(fname string) (map[string]interface{}, error) {
+	var r io.Reader = Stdin
+	if fname != "-" {
+		f, err := os.Open(fname)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var conf map[string]interface{}
+	if err := jsonutil.DecodeWithNumber(r, &conf); err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot parse configuration document: %v"), err)
+	}
+	if conf == nil {
+		return nil, fmt.Errorf(i18n.G("cannot parse configuration document: expected a JSON object"))
+	}
+	return conf, nil
+}
+
+func (x *cmdRestoreConfig) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	snapName := string(x.Positional.Snap)
+	conf, err := readConfigDocument(string(x.Positional.File))
+	if err != nil {
+		return err
+	}
+
+	current, err := x.client.Conf(snapName, nil)
+	if err != nil {
+		return err
+	}
+	// unset the options missing from the document, top-level options
+	// which are documents are replaced as a whole
+	for k := range current {
+		if _, ok := conf[k]; !ok {
+			conf[k] = nil
+		}
+	}
+	if len(conf) == 0 {
+		// nothing to do
+		return nil
+	}
+
+	id, err := x.client.SetConf(snapName, conf)
+	if err != nil {
+		return err
+	}
+	if _, err := x.wait(id); err != nil {
+		if err == noWait {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const savedConfig = `{
+	"bar": 100,
+	"foo": {
+		"key1": "value1",
+		"key2": [
+			1,
+			2
+		]
+	}
+}
+`
+
+func (s *SnapSuite) mockConfigServer(c *C, expectedPatch map[string]interface{}) *int {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/snaps/snapname/conf" && r.Method == "GET":
+			c.Check(r.URL.Query().Get("keys"), Equals, "")
+			fmt.Fprintln(w, `{"type":"sync", "status-code": 200, "result": {"foo":{"key1":"value1","key2":[1,2]},"bar":100,"baz":true}}`)
+		case r.URL.Path == "/v2/snaps/snapname/conf" && r.Method == "PUT":
+			n++
+			c.Check(DecodedRequestBody(c, r), DeepEquals, expectedPatch)
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+		case r.URL.Path == "/v2/changes/zzz":
+			c.Check(r.Method, Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"ready": true, "status": "Done"}}`)
+		default:
+			c.Fatalf("unexpected %s request to %q", r.Method, r.URL.Path)
+		}
+	})
+	return &n
+}
+
+func (s *SnapSuite) TestSaveConfig(c *C) {
+	s.mockConfigServer(c, nil)
+
+	fname := filepath.Join(c.MkDir(), "config.json")
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"saveconfig", "snapname", fname})
+	c.Assert(err, IsNil)
+	c.Check(rest, HasLen, 0)
+	c.Check(fname, testutil.FileEquals, `{
+	"bar": 100,
+	"baz": true,
+	"foo": {
+		"key1": "value1",
+		"key2": [
+			1,
+			2
+		]
+	}
+}
+`)
+	st, err := os.Stat(fname)
+	c.Assert(err, IsNil)
+	c.Check(st.Mode().Perm(), Equals, os.FileMode(0600))
+	c.Check(s.Stdout(), Equals, "")
+}
+
+func (s *SnapSuite) TestSaveConfigStdout(c *C) {
+	s.mockConfigServer(c, nil)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"saveconfig", "snapname", "-"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), testutil.Contains, `"baz": true`)
+}
+
+func (s *SnapSuite) TestRestoreConfig(c *C) {
+	n := s.mockConfigServer(c, map[string]interface{}{
+		"bar": json.Number("100"),
+		"foo": map[string]interface{}{
+			"key1": "value1",
+			"key2": []interface{}{json.Number("1"), json.Number("2")},
+		},
+		// missing from the saved configuration
+		"baz": nil,
+	})
+
+	fname := filepath.Join(c.MkDir(), "config.json")
+	c.Assert(os.WriteFile(fname, []byte(savedConfig), 0600), IsNil)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"restoreconfig", "snapname", fname})
+	c.Assert(err, IsNil)
+	// the whole configuration is applied at once
+	c.Check(*n, Equals, 1)
+}
+
+func (s *SnapSuite) TestRestoreConfigStdin(c *C) {
+	n := s.mockConfigServer(c, map[string]interface{}{
+		"bar": json.Number("1"),
+		"foo": nil,
+		"baz": nil,
+	})
+	s.stdin.WriteString(`{"bar": 1}`)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"restoreconfig", "snapname", "-"})
+	c.Assert(err, IsNil)
+	c.Check(*n, Equals, 1)
+}
+
+func (s *SnapSuite) TestRestoreConfigErrors(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to %q", r.URL.Path)
+	})
+
+	dir := c.MkDir()
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"restoreconfig", "snapname", filepath.Join(dir, "missing")})
+	c.Check(err, ErrorMatches, "open .*/missing: no such file or directory")
+
+	for _, content := range []string{`[1]`, `{"a": `, `null`} {
+		fname := filepath.Join(dir, "config.json")
+		c.Assert(os.WriteFile(fname, []byte(content), 0600), IsNil)
+		_, err := snap.Parser(snap.Client()).ParseArgs([]string{"restoreconfig", "snapname", fname})
+		c.Check(err, ErrorMatches, "cannot parse configuration document: .*")
+	}
+}
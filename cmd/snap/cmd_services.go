@@ -22,6 +22,8 @@ package main
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
@@ -33,6 +35,7 @@ import (
 
 type svcStatus struct {
 	clientMixin
+	tableMixin
 	Positional struct {
 		ServiceNames []serviceName
 	} `positional-args:"yes"`
@@ -43,6 +46,7 @@ type svcLogs struct {
 	timeMixin
 	N          string `short:"n" default:"10"`
 	Follow     bool   `short:"f"`
+	Priority   string `long:"priority"`
 	Positional struct {
 		ServiceNames []serviceName `required:"1"`
 	} `positional-args:"yes" required:"yes"`
@@ -53,11 +57,24 @@ var (
 	longServicesHelp  = i18n.G(`
 The services command lists information about the services specified, or about
 the services in all currently installed snaps.
+
+The systemd units generated for each service can be listed with
+--columns=service,unit. Rather than editing those units, some of their
+settings can be overridden with 'snap set system service-overrides.<snap>.<app>.<setting>',
+where the setting is one of nice, oom-score-adjust or environment.<name>.
 `)
 	shortLogsHelp = i18n.G("Retrieve logs for services")
 	longLogsHelp  = i18n.G(`
 The logs command fetches logs of the given services and displays them in
 chronological order.
+
+If the --priority option is given, only lines of the given syslog priority
+or a more important one are shown. The priority can be given by name (emerg,
+alert, crit, err, warning, notice, info, debug) or by number (0 to 7).
+
+When following the logs with -f, the command resumes from the last line it
+showed if its connection to snapd is interrupted, for example because snapd
+was restarted.
 `)
 	shortStartHelp = i18n.G("Start services")
 	longStartHelp  = i18n.G(`
@@ -73,6 +90,12 @@ The restart command restarts the given services.
 
 If the --reload option is given, for each service whose app has a reload
 command, a reload is performed instead of a restart.
+
+If the --failed option is given, only the services that are in the failed
+state are restarted.
+
+The services of a snap are restarted following the ordering declared by
+the snap through the before and after service options.
 `)
 )
 
@@ -83,13 +106,15 @@ func init() {
 		// TRANSLATORS: This should not start with a lowercase letter.
 		desc: i18n.G("A service specification, which can be just a snap name (for all services in the snap), or <snap>.<app> for a single service."),
 	}}
-	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, nil, argdescs)
+	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, tableDescs, argdescs)
 	addCommand("logs", shortLogsHelp, longLogsHelp, func() flags.Commander { return &svcLogs{} },
 		timeDescs.also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"n": i18n.G("Show only the given number of lines, or 'all'."),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"f": i18n.G("Wait for new lines and print them as they come in."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"priority": i18n.G("Show only lines of the given priority or a more important one."),
 		}), argdescs)
 
 	addCommand("start", shortStartHelp, longStartHelp, func() flags.Commander { return &svcStart{} },
@@ -106,6 +131,8 @@ func init() {
 		waitDescs.also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"reload": i18n.G("If the service has a reload command, use it instead of restarting."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"failed": i18n.G("Only restart the services that are in the failed state."),
 		}), argdescs)
 }
 
@@ -122,7 +149,7 @@ func (s *svcStatus) Execute(args []string) error {
 		return ErrExtraArgs
 	}
 
-	services, err := s.client.Apps(svcNames(s.Positional.ServiceNames), client.AppOptions{Service: true})
+	services, err := s.client.Apps(svcNames(s.Positional.ServiceNames), client.AppOptions{Service: true, Failures: true})
 	if err != nil {
 		return err
 	}
@@ -132,10 +159,17 @@ func (s *svcStatus) Execute(args []string) error {
 		return nil
 	}
 
-	w := tabWriter()
-	defer w.Flush()
-
-	fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes"))
+	t, err := s.newTableWithOptional(Stdout, []tableColumn{
+		{"service", i18n.G("Service")},
+		{"startup", i18n.G("Startup")},
+		{"current", i18n.G("Current")},
+		{"notes", i18n.G("Notes")},
+	}, []tableColumn{
+		{"unit", i18n.G("Unit")},
+	})
+	if err != nil {
+		return err
+	}
 
 	for _, svc := range services {
 		startup := i18n.G("disabled")
@@ -147,11 +181,17 @@ func (s *svcStatus) Execute(args []string) error {
 			current = "-"
 		} else if svc.Active {
 			current = i18n.G("active")
+		} else if svc.Failed {
+			current = i18n.G("failed")
+		}
+		units := "-"
+		if len(svc.Units) > 0 {
+			units = strings.Join(svc.Units, ",")
 		}
-		fmt.Fprintf(w, "%s.%s\t%s\t%s\t%s\n", svc.Snap, svc.Name, startup, current, clientutil.ClientAppInfoNotes(svc))
+		t.addRow(svc.Snap+"."+svc.Name, startup, current, clientutil.ClientAppInfoNotes(svc), units)
 	}
 
-	return nil
+	return t.render()
 }
 
 func (s *svcLogs) Execute(args []string) error {
@@ -168,20 +208,58 @@ func (s *svcLogs) Execute(args []string) error {
 		sN = int(n)
 	}
 
-	logs, err := s.client.Logs(svcNames(s.Positional.ServiceNames), client.LogOptions{N: sN, Follow: s.Follow})
+	names := svcNames(s.Positional.ServiceNames)
+	opts := client.LogOptions{N: sN, Follow: s.Follow, Priority: s.Priority}
+	logs, err := s.client.Logs(names, opts)
 	if err != nil {
 		return err
 	}
 
-	for log := range logs {
-		if s.AbsTime {
-			fmt.Fprintln(Stdout, log.StringInUTC())
-		} else {
-			fmt.Fprintln(Stdout, log)
+	var cursor string
+	for {
+		for log := range logs {
+			if s.AbsTime {
+				fmt.Fprintln(Stdout, log.StringInUTC())
+			} else {
+				fmt.Fprintln(Stdout, log)
+			}
+			if log.Cursor != "" {
+				cursor = log.Cursor
+			}
+		}
+		if !s.Follow || cursor == "" {
+			// without a cursor there is no way to resume without
+			// repeating or losing lines
+			return nil
+		}
+
+		// the stream ended while following, most likely because
+		// snapd went away; resume after the last line shown
+		opts.N = -1
+		opts.AfterCursor = cursor
+		logs, err = s.reconnectLogs(names, opts)
+		if err != nil {
+			return err
 		}
 	}
+}
 
-	return nil
+var (
+	logsReconnectDelay    = 1 * time.Second
+	logsReconnectAttempts = 30
+)
+
+func (s *svcLogs) reconnectLogs(names []string, opts client.LogOptions) (<-chan client.Log, error) {
+	var err error
+	for i := 0; i < logsReconnectAttempts; i++ {
+		time.Sleep(logsReconnectDelay)
+		var logs <-chan client.Log
+		logs, err = s.client.Logs(names, opts)
+		if err == nil {
+			return logs, nil
+		}
+	}
+	return nil, err
 }
 
 type svcStart struct {
@@ -248,6 +326,7 @@ type svcRestart struct {
 		ServiceNames []serviceName `required:"1"`
 	} `positional-args:"yes" required:"yes"`
 	Reload bool `long:"reload"`
+	Failed bool `long:"failed"`
 }
 
 func (s *svcRestart) Execute(args []string) error {
@@ -255,7 +334,7 @@ func (s *svcRestart) Execute(args []string) error {
 		return ErrExtraArgs
 	}
 	names := svcNames(s.Positional.ServiceNames)
-	changeID, err := s.client.Restart(names, client.RestartOptions{Reload: s.Reload})
+	changeID, err := s.client.Restart(names, client.RestartOptions{Reload: s.Reload, Failed: s.Failed})
 	if err != nil {
 		return err
 	}
@@ -168,14 +168,22 @@ func (s *appOpSuite) TestAppOps(c *check.C) {
 	}
 }
 
+func (s *appOpSuite) TestRestartFailed(c *check.C) {
+	for _, extra := range [][]string{{"failed"}, {"reload", "failed"}} {
+		s.testOp(c, "restart", "Restarted.", []string{"foo", "bar.baz"}, extra, false)
+		s.stdout.Reset()
+	}
+}
+
 func (s *appOpSuite) TestAppStatus(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		switch n {
 		case 0:
 			c.Check(r.URL.Path, check.Equals, "/v2/apps")
-			c.Check(r.URL.Query(), check.HasLen, 1)
+			c.Check(r.URL.Query(), check.HasLen, 2)
 			c.Check(r.URL.Query().Get("select"), check.Equals, "service")
+			c.Check(r.URL.Query().Get("failures"), check.Equals, "true")
 			c.Check(r.Method, check.Equals, "GET")
 			w.WriteHeader(200)
 			enc := json.NewEncoder(w)
@@ -215,6 +223,24 @@ func (s *appOpSuite) TestAppStatus(c *check.C) {
 						"name":    "zed",
 						"active":  true,
 						"enabled": true,
+					}, {
+						"snap":    "foo",
+						"name":    "zoo",
+						"active":  false,
+						"failed":  true,
+						"enabled": true,
+					}, {
+						"snap":    "foo",
+						"name":    "zzz",
+						"daemon":  "simple",
+						"active":  false,
+						"failed":  true,
+						"enabled": true,
+						"failure": map[string]interface{}{
+							"result":    "exit-code",
+							"exit-code": 1,
+							"restarts":  3,
+						},
 					},
 				},
 				"status":      "OK",
@@ -235,6 +261,8 @@ foo.bar  enabled  inactive  timer-activated
 foo.baz  enabled  inactive  socket-activated
 foo.qux  enabled  -         user
 foo.zed  enabled  active    -
+foo.zoo  enabled  failed    -
+foo.zzz  enabled  failed    failed=exit-code,restarts=3
 `)
 	// ensure that the fake server api was actually hit
 	c.Check(n, check.Equals, 1)
@@ -291,8 +319,9 @@ func (s *appOpSuite) TestAppStatusNoServices(c *check.C) {
 		switch n {
 		case 0:
 			c.Check(r.URL.Path, check.Equals, "/v2/apps")
-			c.Check(r.URL.Query(), check.HasLen, 1)
+			c.Check(r.URL.Query(), check.HasLen, 2)
 			c.Check(r.URL.Query().Get("select"), check.Equals, "service")
+			c.Check(r.URL.Query().Get("failures"), check.Equals, "true")
 			c.Check(r.Method, check.Equals, "GET")
 			w.WriteHeader(200)
 			enc := json.NewEncoder(w)
@@ -402,3 +431,124 @@ func (s *appOpSuite) TestLogsCommandWithAbsTimeFlag(c *check.C) {
 	// ensure that the fake server api was actually hit
 	c.Check(n, check.Equals, 1)
 }
+
+func (s *appOpSuite) TestLogsCommandFollowResumesAfterCursor(c *check.C) {
+	restore := snap.MockLogsReconnect(time.Millisecond, 2)
+	defer restore()
+
+	n := 0
+	writeLog := func(w http.ResponseWriter, message, cursor string) {
+		w.WriteHeader(200)
+		_, err := w.Write([]byte{0x1E})
+		c.Assert(err, check.IsNil)
+		err = json.NewEncoder(w).Encode(map[string]interface{}{
+			"timestamp": "2021-08-16T17:33:55Z",
+			"message":   message,
+			"sid":       "service1",
+			"pid":       "1000",
+			"cursor":    cursor,
+		})
+		c.Assert(err, check.IsNil)
+	}
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/logs")
+		query := r.URL.Query()
+		c.Check(query.Get("follow"), check.Equals, "true")
+		c.Check(query.Get("priority"), check.Equals, "err")
+		switch n {
+		case 0:
+			c.Check(query.Get("n"), check.Equals, "10")
+			c.Check(query.Get("after-cursor"), check.Equals, "")
+			writeLog(w, "one", "s=abc;i=1")
+		case 1:
+			// snapd went away
+			w.WriteHeader(500)
+		case 2:
+			c.Check(query.Get("n"), check.Equals, "-1")
+			c.Check(query.Get("after-cursor"), check.Equals, "s=abc;i=1")
+			writeLog(w, "two", "s=abc;i=2")
+		case 3, 4:
+			c.Check(query.Get("n"), check.Equals, "-1")
+			c.Check(query.Get("after-cursor"), check.Equals, "s=abc;i=2")
+			w.WriteHeader(500)
+		default:
+			c.Fatalf("expected to get 5 requests, now on %d", n+1)
+		}
+		n++
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"logs", "-f", "--priority=err", "--abs-time", "snap"})
+	c.Assert(err, check.NotNil)
+
+	c.Check(s.Stdout(), check.Equals, `
+2021-08-16T17:33:55Z service1[1000]: one
+2021-08-16T17:33:55Z service1[1000]: two
+`[1:])
+	c.Check(n, check.Equals, 5)
+}
+
+func (s *appOpSuite) TestAppStatusColumnsNoHeader(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/apps")
+		enc := json.NewEncoder(w)
+		enc.Encode(map[string]interface{}{
+			"type": "sync",
+			"result": []map[string]interface{}{
+				{"snap": "foo", "name": "bar", "daemon": "simple", "daemon-scope": "system", "active": true, "enabled": true},
+				{"snap": "foo", "name": "baz", "daemon": "simple", "daemon-scope": "system", "active": false, "enabled": false},
+			},
+			"status":      "OK",
+			"status-code": 200,
+		})
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"services", "--no-header", "--columns=service,current"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `foo.bar  active
+foo.baz  inactive
+`)
+}
+
+func (s *appOpSuite) TestAppStatusUnitColumn(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/apps")
+		enc := json.NewEncoder(w)
+		enc.Encode(map[string]interface{}{
+			"type": "sync",
+			"result": []map[string]interface{}{
+				{"snap": "foo", "name": "bar", "daemon": "simple", "daemon-scope": "system", "active": true, "enabled": true,
+					"units": []string{"snap.foo.bar.service"}},
+				{"snap": "foo", "name": "baz", "daemon": "oneshot", "daemon-scope": "system", "active": false, "enabled": true,
+					"units": []string{"snap.foo.baz.service", "snap.foo.baz.timer"}},
+				// from an older snapd
+				{"snap": "foo", "name": "qux", "daemon": "simple", "daemon-scope": "system", "active": false, "enabled": true},
+			},
+			"status":      "OK",
+			"status-code": 200,
+		})
+	})
+
+	// not shown by default
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"services"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `Service  Startup  Current   Notes
+foo.bar  enabled  active    -
+foo.baz  enabled  inactive  -
+foo.qux  enabled  inactive  -
+`)
+	s.ResetStdStreams()
+
+	rest, err = snap.Parser(snap.Client()).ParseArgs([]string{"services", "--columns=service,unit"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `Service  Unit
+foo.bar  snap.foo.bar.service
+foo.baz  snap.foo.baz.service,snap.foo.baz.timer
+foo.qux  -
+`)
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/jsonutil"
 )
@@ -42,8 +43,24 @@ Nested values may be modified via a dotted path:
 
     $ snap set snap-name author.name=frank
 
+Values are parsed as JSON when possible, so whole documents may be set at
+once. Use -t to require the value to be valid JSON, or -s to set it as a
+plain string:
+
+    $ snap set snap-name author='{"name": "frank", "age": 42}'
+
+Options under env are exported as environment variables to the apps and
+services of the snap, which are restarted if needed. Dashes in option names
+are mapped to underscores:
+
+    $ snap set snap-name env.log-level=debug
+
 Configuration option may be unset with exclamation mark:
     $ snap set snap-name author!
+
+With --revert-on-failure the previous configuration is restored if applying
+the new one fails, including when restarting the services of the snap fails
+after the configure hook succeeded.
 `)
 
 type cmdSet struct {
@@ -53,8 +70,9 @@ type cmdSet struct {
 		ConfValues []string `required:"1"`
 	} `positional-args:"yes" required:"yes"`
 
-	Typed  bool `short:"t"`
-	String bool `short:"s"`
+	Typed           bool `short:"t"`
+	String          bool `short:"s"`
+	RevertOnFailure bool `long:"revert-on-failure"`
 }
 
 func init() {
@@ -64,6 +82,8 @@ func init() {
 			"t": i18n.G("Parse the value strictly as JSON document"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"s": i18n.G("Parse the value as a string"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"revert-on-failure": i18n.G("Restore the previous configuration if applying the new one fails"),
 		}), []argDesc{
 			{
 				name: "<snap>",
@@ -78,6 +98,13 @@ func init() {
 		})
 }
 
+// looksLikeJSONDocument returns whether the given value is meant to be a
+// JSON object or array.
+func looksLikeJSONDocument(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[")
+}
+
 func (x *cmdSet) Execute(args []string) error {
 	if x.String && x.Typed {
 		return fmt.Errorf(i18n.G("cannot use -t and -s together"))
@@ -103,6 +130,12 @@ func (x *cmdSet) Execute(args []string) error {
 					return fmt.Errorf("failed to parse JSON: %w", err)
 				}
 
+				if looksLikeJSONDocument(parts[1]) {
+					// most likely a mistake in a document, don't
+					// silently store it as a string
+					return fmt.Errorf(i18n.G("cannot parse value of %q as a JSON document: %v (use -s to set it as a string)"), parts[0], err)
+				}
+
 				// Not valid JSON-- just save the string as-is.
 				patchValues[parts[0]] = parts[1]
 			} else {
@@ -112,7 +145,8 @@ func (x *cmdSet) Execute(args []string) error {
 	}
 
 	snapName := string(x.Positional.Snap)
-	id, err := x.client.SetConf(snapName, patchValues)
+	opts := &client.SetConfOptions{RevertOnFailure: x.RevertOnFailure}
+	id, err := x.client.SetConfWithOptions(snapName, patchValues, opts)
 	if err != nil {
 		return err
 	}
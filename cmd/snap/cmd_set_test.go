@@ -109,6 +109,23 @@ func (s *snapSetSuite) TestSnapSetIntegrationStringWithExclamationMark(c *check.
 	c.Check(s.setConfApiCalls, check.Equals, 1)
 }
 
+func (s *snapSetSuite) TestSnapSetIntegrationNestedJSONList(c *check.C) {
+	// mock server
+	s.mockSetConfigServer(c, []interface{}{map[string]interface{}{"a": json.Number("1")}, "b"})
+
+	_, err := snapset.Parser(snapset.Client()).ParseArgs([]string{"set", "snapname", `key=[{"a": 1}, "b"]`})
+	c.Assert(err, check.IsNil)
+	c.Check(s.setConfApiCalls, check.Equals, 1)
+}
+
+func (s *snapSetSuite) TestSnapSetFailParsingInvalidDocument(c *check.C) {
+	for _, value := range []string{`{"a": 1`, `{a: 1}`, ` [1, 2`} {
+		_, err := snapset.Parser(snapset.Client()).ParseArgs([]string{"set", "snapname", "key=" + value})
+		c.Check(err, check.ErrorMatches, `cannot parse value of "key" as a JSON document: .* \(use -s to set it as a string\)`)
+	}
+	c.Check(s.setConfApiCalls, check.Equals, 0)
+}
+
 func (s *snapSetSuite) TestSnapSetParseStrictJSON(c *check.C) {
 	// mock server
 	s.mockSetConfigServer(c, map[string]interface{}{"a": "b", "c": json.Number("1"), "d": map[string]interface{}{"e": "f"}})
@@ -138,6 +155,31 @@ func (s *snapSetSuite) TestSnapSetAsString(c *check.C) {
 	c.Check(s.setConfApiCalls, check.Equals, 1)
 }
 
+func (s *snapSetSuite) TestSnapSetRevertOnFailure(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps/snapname/conf":
+			c.Check(r.Method, check.Equals, "PUT")
+			c.Check(r.URL.Query().Get("revert-on-failure"), check.Equals, "true")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+				"key": "value",
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+			s.setConfApiCalls += 1
+		case "/v2/changes/zzz":
+			c.Check(r.Method, check.Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"ready": true, "status": "Error", "err": "cannot perform the following tasks:\n- Restart services (boom)"}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+
+	_, err := snapset.Parser(snapset.Client()).ParseArgs([]string{"set", "--revert-on-failure", "snapname", "key=value"})
+	c.Assert(err, check.ErrorMatches, `cannot perform the following tasks:\n- Restart services \(boom\)`)
+	c.Check(s.setConfApiCalls, check.Equals, 1)
+}
+
 func (s *snapSetSuite) mockSetConfigServer(c *check.C, expectedValue interface{}) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
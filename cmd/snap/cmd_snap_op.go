@@ -20,12 +20,18 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 	"unicode/utf8"
 
@@ -35,7 +41,9 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/channel"
 	"github.com/snapcore/snapd/strutil"
 )
@@ -70,6 +78,10 @@ override, taking the snap back to the current revision of the channel it's
 tracking.
 
 Use --name to set the instance name when installing from snap file.
+
+A snap file can also be installed from an http or https URL, in which case it
+is downloaded first. Use --sha3-384 to make sure the downloaded file is the
+expected one.
 `)
 
 var longRemoveHelp = i18n.G(`
@@ -81,7 +93,12 @@ revision is removed.
 
 Unless automatic snapshots are disabled, a snapshot of all data for the snap is 
 saved upon removal, which is then available for future restoration with snap
-restore. The --purge option disables automatically creating snapshots.
+restore. The --purge option disables automatically creating snapshots, and
+also forgets the existing snapshots of the snap when it is removed completely.
+
+Running apps of the snap are left alone unless the --terminate option is
+passed, in which case they are killed once confirmed, along with the snap
+services.
 `)
 
 var longRefreshHelp = i18n.G(`
@@ -113,6 +130,11 @@ The unpacked snap content continues to be used even after installation, so
 non-metadata changes there go live instantly. Metadata changes such as those
 performed in snap.yaml will require reinstallation to go live.
 
+With --watch, the try command keeps watching the snap directory after
+installation and reinstalls the snap whenever its metadata under meta/ changes
+or the directory is recreated, which also regenerates its security profiles
+and wrappers. Press Ctrl-C to stop watching.
+
 If snap-dir argument is omitted, the try command will attempt to infer it if
 either snapcraft.yaml file and prime directory or meta/snap.yaml file can be
 found relative to current working directory.
@@ -133,11 +155,35 @@ type cmdRemove struct {
 
 	Revision   string `long:"revision"`
 	Purge      bool   `long:"purge"`
+	Terminate  bool   `long:"terminate"`
 	Positional struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>" required:"1"`
 	} `positional-args:"yes" required:"yes"`
 }
 
+// confirmTerminate asks for confirmation before terminating the running
+// apps of the snaps being removed, when used interactively.
+func (x *cmdRemove) confirmTerminate() error {
+	if !isStdinTTY {
+		return nil
+	}
+	names := make([]string, len(x.Positional.Snaps))
+	for i, name := range x.Positional.Snaps {
+		names[i] = string(name)
+	}
+	// TRANSLATORS: %s is a list of snap names
+	fmt.Fprintf(Stdout, i18n.G("Running apps of %s will be terminated. Continue? [y/N] "), strutil.Quoted(names))
+	in, _, err := bufio.NewReader(Stdin).ReadLine()
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(string(in))) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.New(i18n.G("aborted"))
+}
+
 func (x *cmdRemove) removeOne(opts *client.SnapOptions) error {
 	name := string(x.Positional.Snaps[0])
 
@@ -216,7 +262,12 @@ func (x *cmdRemove) removeMany(opts *client.SnapOptions) error {
 }
 
 func (x *cmdRemove) Execute([]string) error {
-	opts := &client.SnapOptions{Revision: x.Revision, Purge: x.Purge}
+	opts := &client.SnapOptions{Revision: x.Revision, Purge: x.Purge, Terminate: x.Terminate}
+	if x.Terminate {
+		if err := x.confirmTerminate(); err != nil {
+			return err
+		}
+	}
 	if len(x.Positional.Snaps) == 1 {
 		return x.removeOne(opts)
 	}
@@ -228,7 +279,7 @@ func (x *cmdRemove) Execute([]string) error {
 }
 
 type channelMixin struct {
-	Channel string `long:"channel"`
+	Channel channelName `long:"channel"`
 
 	// shortcuts
 	EdgeChannel      bool `long:"edge"`
@@ -279,12 +330,12 @@ func (mx *channelMixin) setChannelFromCommandline() error {
 		if mx.Channel != "" {
 			return fmt.Errorf("Please specify a single channel")
 		}
-		mx.Channel = ch.chName
+		mx.Channel = channelName(ch.chName)
 	}
 
 	if mx.Channel != "" {
-		if _, err := channel.Parse(mx.Channel, ""); err != nil {
-			full, er := channel.Full(mx.Channel)
+		if _, err := channel.Parse(string(mx.Channel), ""); err != nil {
+			full, er := channel.Full(string(mx.Channel))
 			if er != nil {
 				// the parse error has more detailed info
 				return err
@@ -295,7 +346,7 @@ func (mx *channelMixin) setChannelFromCommandline() error {
 			msg := i18n.G("Specifying a channel %q is relying on undefined behaviour. Interpreting it as %q for now, but this will be an error later.\n")
 			warn := fill(fmt.Sprintf(msg, mx.Channel, full), utf8.RuneCountInString(head)+1) // +1 for the space
 			fmt.Fprint(Stderr, head, " ", warn, "\n\n")
-			mx.Channel = full // so a malformed-but-eh channel will always be full, i.e. //stable// -> latest/stable
+			mx.Channel = channelName(full) // so a malformed-but-eh channel will always be full, i.e. //stable// -> latest/stable
 		}
 	}
 
@@ -414,6 +465,11 @@ func showDone(cli *client.Client, chg *client.Change, names []string, op string,
 				fmt.Fprintf(Stdout, i18n.G("%s%s %s refreshed\n"), snap.Name, channelStr, snap.Version)
 			}
 		case "revert":
+			if opts != nil && opts.DataOnly {
+				// TRANSLATORS: first %s is a snap name, second %s is a version
+				fmt.Fprintf(Stdout, i18n.G("%s data reverted, keeping %s\n"), snap.Name, snap.Version)
+				break
+			}
 			// TRANSLATORS: first %s is a snap name, second %s is a revision
 			fmt.Fprintf(Stdout, i18n.G("%s reverted to %s\n"), snap.Name, snap.Version)
 		case "switch":
@@ -517,18 +573,66 @@ type cmdInstall struct {
 	IgnoreRunning    bool                   `long:"ignore-running" hidden:"yes"`
 	Transaction      client.TransactionType `long:"transaction" default:"per-snap" choice:"all-snaps" choice:"per-snap"`
 	QuotaGroupName   string                 `long:"quota-group"`
+	Sha3_384         string                 `long:"sha3-384"`
 	Positional       struct {
 		Snaps []remoteSnapName `positional-arg-name:"<snap>" required:"1"`
 	} `positional-args:"yes" required:"yes"`
 }
 
+func isSnapURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// downloadSnapFromURL downloads the snap file at the given URL into
+// targetDir, using the store transport for proxy settings, retries and
+// progress reporting, and checks it against the given sha3-384 hash if
+// not empty.
+func downloadSnapFromURL(snapURL, sha3_384, targetDir string) (string, error) {
+	u, err := url.Parse(snapURL)
+	if err != nil {
+		return "", fmt.Errorf(i18n.G("cannot parse snap URL: %v"), err)
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = "snap"
+	}
+	if !strings.HasSuffix(name, ".snap") {
+		name += ".snap"
+	}
+	targetPath := filepath.Join(targetDir, name)
+
+	dlInfo := &snap.DownloadInfo{
+		DownloadURL: snapURL,
+		Sha3_384:    sha3_384,
+	}
+	pb := progress.MakeProgressBar(Stdout)
+	sto := storeNew(nil, nil)
+	if err := sto.Download(context.Background(), name, targetPath, dlInfo, pb, nil, nil); err != nil {
+		return "", fmt.Errorf(i18n.G("cannot download snap from %q: %v"), snapURL, err)
+	}
+	return targetPath, nil
+}
+
 func (x *cmdInstall) installOne(nameOrPath, desiredName string, opts *client.SnapOptions) error {
 	var err error
 	var changeID string
 	var snapName string
 	var path string
 
-	if isLocalSnap(nameOrPath) {
+	if isSnapURL(nameOrPath) {
+		tmpDir, err := os.MkdirTemp("", "snap-install-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		path, err = downloadSnapFromURL(nameOrPath, x.Sha3_384, tmpDir)
+		if err != nil {
+			return err
+		}
+		// don't log the request's body because the encoded snap is large.
+		x.client.SetMayLogBody(false)
+		changeID, err = x.client.InstallPath(path, x.Name, opts)
+	} else if isLocalSnap(nameOrPath) {
 		// don't log the request's body because the encoded snap is large.
 		x.client.SetMayLogBody(false)
 		path = nameOrPath
@@ -658,7 +762,7 @@ func (x *cmdInstall) Execute([]string) error {
 
 	dangerous := x.Dangerous || x.ForceDangerous
 	opts := &client.SnapOptions{
-		Channel:          x.Channel,
+		Channel:          string(x.Channel),
 		Revision:         x.Revision,
 		Dangerous:        dangerous,
 		Unaliased:        x.Unaliased,
@@ -678,10 +782,20 @@ func (x *cmdInstall) Execute([]string) error {
 		}
 	}
 
+	if x.Sha3_384 != "" && (len(names) != 1 || !isSnapURL(names[0])) {
+		return errors.New(i18n.G("a single snap URL is needed to specify the sha3-384 flag"))
+	}
+
 	if len(names) == 1 {
 		return x.installOne(names[0], x.Name, opts)
 	}
 
+	for _, name := range names {
+		if isSnapURL(name) {
+			return errors.New(i18n.G("cannot install multiple snaps when installing from a URL"))
+		}
+	}
+
 	if x.asksForChannel() {
 		return errors.New(i18n.G("a single snap name is needed to specify channel flags"))
 	}
@@ -900,7 +1014,7 @@ func (x *cmdRefresh) Execute([]string) error {
 	if len(names) == 1 {
 		opts := &client.SnapOptions{
 			Amend:            x.Amend,
-			Channel:          x.Channel,
+			Channel:          string(x.Channel),
 			IgnoreValidation: x.IgnoreValidation,
 			IgnoreRunning:    x.IgnoreRunning,
 			Revision:         x.Revision,
@@ -1018,6 +1132,7 @@ type cmdTry struct {
 	waitMixin
 
 	modeMixin
+	Watch      bool `long:"watch"`
 	Positional struct {
 		SnapDir string `positional-arg-name:"<snap-dir>"`
 	} `positional-args:"yes"`
@@ -1064,6 +1179,25 @@ func (x *cmdTry) Execute([]string) error {
 		return fmt.Errorf(i18n.G("cannot get full path for %q: %v"), name, err)
 	}
 
+	if !x.Watch {
+		return x.try(name, path, opts)
+	}
+	if x.NoWait {
+		return errors.New(i18n.G("cannot use --watch with --no-wait"))
+	}
+	// take the fingerprint first so that changes done while the snap
+	// is being installed are not missed
+	fingerprint, err := tryFingerprint(path)
+	if err != nil {
+		return err
+	}
+	if err := x.try(name, path, opts); err != nil {
+		return err
+	}
+	return x.watch(name, path, fingerprint, opts)
+}
+
+func (x *cmdTry) try(name, path string, opts *client.SnapOptions) error {
 	changeID, err := x.client.Try(path, opts)
 	if err != nil {
 		msg, err := errorToCmdMessage(name, "try", err, opts)
@@ -1105,6 +1239,72 @@ func (x *cmdTry) Execute([]string) error {
 	return nil
 }
 
+var (
+	tryWatchInterval = time.Second
+	// tryWatchInterrupt returns a channel receiving a value when watching
+	// should stop, and a function to release it.
+	tryWatchInterrupt = func() (ch <-chan os.Signal, stop func()) {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt)
+		return c, func() { signal.Stop(c) }
+	}
+)
+
+// tryFingerprint summarizes the state of what requires reinstalling a
+// tried snap when it changes: the identity of the snap directory itself,
+// which build tools might recreate, and the metadata under meta/.
+func tryFingerprint(path string) (string, error) {
+	var buf strings.Builder
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		fmt.Fprintf(&buf, "%d:%d\n", st.Dev, st.Ino)
+	}
+	metaDir := filepath.Join(path, "meta")
+	err = filepath.Walk(metaDir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(metaDir, p)
+		fmt.Fprintf(&buf, "%s %s %d %d\n", rel, fi.Mode(), fi.Size(), fi.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (x *cmdTry) watch(name, path, fingerprint string, opts *client.SnapOptions) error {
+	interrupt, stop := tryWatchInterrupt()
+	defer stop()
+
+	fmt.Fprintf(Stdout, i18n.G("Watching %s for changes, press Ctrl-C to stop\n"), path)
+	for {
+		select {
+		case <-interrupt:
+			return nil
+		case <-time.After(tryWatchInterval):
+		}
+		cur, err := tryFingerprint(path)
+		if err != nil {
+			// the directory may be in the middle of being rebuilt
+			continue
+		}
+		if cur == fingerprint {
+			continue
+		}
+		fingerprint = cur
+		fmt.Fprintf(Stdout, i18n.G("Changes detected in %s, reinstalling\n"), path)
+		if err := x.try(name, path, opts); err != nil {
+			// keep watching, the next change may fix it
+			fmt.Fprintf(Stderr, i18n.G("error: %v\n"), err)
+		}
+	}
+}
+
 type cmdEnable struct {
 	waitMixin
 
@@ -1164,6 +1364,7 @@ type cmdRevert struct {
 
 	modeMixin
 	Revision      string `long:"revision"`
+	DataOnly      bool   `long:"data-only"`
 	IgnoreRunning bool   `long:"ignore-running" hidden:"yes"`
 	Positional    struct {
 		Snap installedSnapName `positional-arg-name:"<snap>"`
@@ -1178,6 +1379,15 @@ and will use the original data that was associated with that revision,
 discarding any data changes that were done by the latest revision. As
 an exception, data which the snap explicitly chooses to share across
 revisions is not touched by the revert process.
+
+With --revision, the given older revision is reactivated instead of
+the previous one.
+
+With --data-only, the snap itself is left at its current revision and
+only its data is restored, from the most recent snapshot taken of the
+previous (or the given) revision. This is refused if the current
+revision cannot read data written by that revision (see 'snap help
+saved' for details on snapshots).
 `)
 
 func (x *cmdRevert) Execute(args []string) error {
@@ -1188,10 +1398,14 @@ func (x *cmdRevert) Execute(args []string) error {
 	if err := x.validateMode(); err != nil {
 		return err
 	}
+	if x.DataOnly && x.asksForMode() {
+		return errors.New(i18n.G("cannot use --data-only with --devmode, --jailmode or --classic"))
+	}
 
 	name := string(x.Positional.Snap)
 	opts := &client.SnapOptions{
 		Revision:      x.Revision,
+		DataOnly:      x.DataOnly,
 		IgnoreRunning: x.IgnoreRunning,
 	}
 	x.setModes(opts)
@@ -1208,7 +1422,7 @@ func (x *cmdRevert) Execute(args []string) error {
 		return err
 	}
 
-	return showDone(x.client, chg, []string{name}, "revert", nil, nil)
+	return showDone(x.client, chg, []string{name}, "revert", opts, nil)
 }
 
 var shortSwitchHelp = i18n.G("Switches snap to a different channel")
@@ -1279,7 +1493,9 @@ func init() {
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"revision": i18n.G("Remove only the given revision"),
 			// TRANSLATORS: This should not start with a lowercase letter.
-			"purge": i18n.G("Remove the snap without saving a snapshot of its data"),
+			"purge": i18n.G("Remove the snap without saving a snapshot of its data, forgetting existing snapshots"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"terminate": i18n.G("Terminate the running apps of the snap before removing it"),
 		}), nil)
 	addCommand("install", shortInstallHelp, longInstallHelp, func() flags.Commander { return &cmdInstall{} },
 		colorDescs.also(waitDescs).also(channelDescs).also(modeDescs).also(map[string]string{
@@ -1305,6 +1521,8 @@ func init() {
 			"quota-group": i18n.G("Add the snap to a quota group on install"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"prefer": i18n.G("Enable all aliases of the given snap in preference to conflicting aliases of other snaps"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"sha3-384": i18n.G("Check that the snap downloaded from a URL has the given SHA3-384 hash"),
 		}), nil)
 	addCommand("refresh", shortRefreshHelp, longRefreshHelp, func() flags.Commander { return &cmdRefresh{} },
 		colorDescs.also(waitDescs).also(channelDescs).also(modeDescs).also(timeDescs).also(map[string]string{
@@ -1331,13 +1549,18 @@ func init() {
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"unhold": i18n.G("Remove refresh hold"),
 		}), nil)
-	addCommand("try", shortTryHelp, longTryHelp, func() flags.Commander { return &cmdTry{} }, waitDescs.also(modeDescs), nil)
+	addCommand("try", shortTryHelp, longTryHelp, func() flags.Commander { return &cmdTry{} }, waitDescs.also(modeDescs).also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"watch": i18n.G("Keep watching the snap directory and reinstall the snap when its metadata changes"),
+	}), nil)
 	addCommand("enable", shortEnableHelp, longEnableHelp, func() flags.Commander { return &cmdEnable{} }, waitDescs, nil)
 	addCommand("disable", shortDisableHelp, longDisableHelp, func() flags.Commander { return &cmdDisable{} }, waitDescs, nil)
 	addCommand("revert", shortRevertHelp, longRevertHelp, func() flags.Commander { return &cmdRevert{} }, waitDescs.also(modeDescs).also(map[string]string{
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"revision": i18n.G("Revert to the given revision"),
 		// TRANSLATORS: This should not start with a lowercase letter.
+		"data-only": i18n.G("Only restore the data of the older revision, from its most recent snapshot"),
+		// TRANSLATORS: This should not start with a lowercase letter.
 		"ignore-running": i18n.G("Ignore running hooks or applications blocking the revert"),
 	}), nil)
 	addCommand("switch", shortSwitchHelp, longSwitchHelp, func() flags.Commander { return &cmdSwitch{} }, waitDescs.also(channelDescs).also(map[string]string{
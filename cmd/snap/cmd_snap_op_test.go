@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -33,11 +34,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jessevdk/go-flags"
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
 	snap "github.com/snapcore/snapd/cmd/snap"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/progress/progresstest"
 	"github.com/snapcore/snapd/release"
@@ -990,6 +993,73 @@ func (s *SnapOpSuite) TestInstallPath(c *check.C) {
 	c.Check(s.srv.n, check.Equals, s.srv.total)
 }
 
+func (s *SnapOpSuite) mockSnapURLServer(c *check.C, body []byte) string {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/builds/foo.snap")
+		w.Write(body)
+	}))
+	s.AddCleanup(mockServer.Close)
+	return mockServer.URL + "/builds/foo.snap"
+}
+
+func (s *SnapOpSuite) TestInstallURL(c *check.C) {
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+
+		form := testForm(r, c)
+		defer form.RemoveAll()
+
+		c.Check(form.Value["action"], check.DeepEquals, []string{"install"})
+		c.Check(form.Value["snap-path"], check.HasLen, 1)
+		c.Check(filepath.Base(form.Value["snap-path"][0]), check.Equals, "foo.snap")
+
+		name, _, body := formFile(form, c)
+		c.Check(name, check.Equals, "snap")
+		c.Check(string(body), check.Equals, "snap-data")
+	}
+	s.RedirectClientToTestServer(s.srv.handle)
+
+	snapBody := []byte("snap-data")
+	snapURL := s.mockSnapURLServer(c, snapBody)
+	digest, _, err := osutil.FileDigest(s.writeTmpFile(c, snapBody), crypto.SHA3_384)
+	c.Assert(err, check.IsNil)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"install", "--sha3-384", fmt.Sprintf("%x", digest), snapURL})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Matches, `(?sm).*foo 1.0 from Bar installed`)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
+func (s *SnapOpSuite) writeTmpFile(c *check.C, content []byte) string {
+	p := filepath.Join(c.MkDir(), "file")
+	c.Assert(os.WriteFile(p, content, 0644), check.IsNil)
+	return p
+}
+
+func (s *SnapOpSuite) TestInstallURLHashMismatch(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to snapd: %v", r.URL)
+	})
+	snapURL := s.mockSnapURLServer(c, []byte("snap-data"))
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"install", "--sha3-384", "deadbeef", snapURL})
+	c.Assert(err, check.ErrorMatches, fmt.Sprintf(`cannot download snap from %q: sha3-384 mismatch for "foo.snap": got [0-9a-f]+ but expected deadbeef`, snapURL))
+}
+
+func (s *SnapOpSuite) TestInstallURLErrors(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to snapd: %v", r.URL)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"install", "--sha3-384", "deadbeef", "foo"})
+	c.Check(err, check.ErrorMatches, "a single snap URL is needed to specify the sha3-384 flag")
+
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"install", "https://example.com/foo.snap", "bar.snap"})
+	c.Check(err, check.ErrorMatches, "cannot install multiple snaps when installing from a URL")
+}
+
 func (s *SnapOpSuite) TestInstallPathDevMode(c *check.C) {
 	s.srv.checker = func(r *http.Request) {
 		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
@@ -1413,6 +1483,31 @@ func (s *SnapOpSuite) TestRevertClassic(c *check.C) {
 	s.runRevertTest(c, &client.SnapOptions{Classic: true})
 }
 
+func (s *SnapOpSuite) TestRevertDataOnly(c *check.C) {
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":    "revert",
+			"revision":  "1",
+			"data-only": true,
+		})
+	}
+
+	s.RedirectClientToTestServer(s.srv.handle)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert", "--data-only", "--revision=1", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "foo data reverted, keeping 1.0\n")
+	c.Check(s.Stderr(), check.Equals, "")
+	// ensure that the fake server api was actually hit
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
+func (s *SnapOpSuite) TestRevertDataOnlyWithMode(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert", "--data-only", "--devmode", "foo"})
+	c.Assert(err, check.ErrorMatches, "cannot use --data-only with --devmode, --jailmode or --classic")
+}
+
 func (s *SnapOpSuite) TestRevertMissingName(c *check.C) {
 	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert"})
 	c.Assert(err, check.NotNil)
@@ -2312,6 +2407,58 @@ func (s *SnapOpSuite) TestTryClassic(c *check.C) {
 	s.runTryTest(c, &client.SnapOptions{Classic: true})
 }
 
+func (s *SnapOpSuite) TestTryWatch(c *check.C) {
+	tryDir := filepath.Join(c.MkDir(), "prime")
+	snapYaml := filepath.Join(tryDir, "meta", "snap.yaml")
+	c.Assert(os.MkdirAll(filepath.Dir(snapYaml), 0755), check.IsNil)
+	c.Assert(os.WriteFile(snapYaml, []byte("name: foo\nversion: 1.0\n"), 0644), check.IsNil)
+
+	interrupt := make(chan os.Signal, 1)
+	restore := snap.MockTryWatch(time.Millisecond, interrupt)
+	defer restore()
+
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+		form := testForm(r, c)
+		defer form.RemoveAll()
+		c.Check(form.Value["action"], check.DeepEquals, []string{"try"})
+		c.Check(form.Value["snap-path"], check.DeepEquals, []string{tryDir})
+	}
+	tries := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		s.srv.handle(w, r)
+		if s.srv.n < s.srv.total {
+			return
+		}
+		// a complete try
+		s.srv.n = 0
+		tries++
+		switch tries {
+		case 1:
+			// metadata changes trigger a reinstall
+			c.Assert(os.WriteFile(snapYaml, []byte("name: foo\nversion: 1.1\n"), 0644), check.IsNil)
+		case 2:
+			interrupt <- os.Interrupt
+		}
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"try", "--watch", tryDir})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(tries, check.Equals, 2)
+	c.Check(s.Stdout(), check.Matches, fmt.Sprintf(`(?s)foo 1.0 mounted from %[1]s
+Watching %[1]s for changes, press Ctrl-C to stop
+Changes detected in %[1]s, reinstalling
+foo 1.0 mounted from %[1]s
+`, regexp.QuoteMeta(tryDir)))
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapOpSuite) TestTryWatchNoWait(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"try", "--watch", "--no-wait", "/"})
+	c.Assert(err, check.ErrorMatches, "cannot use --watch with --no-wait")
+}
+
 func (s *SnapOpSuite) TestTryNoSnapDirErrors(c *check.C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		c.Check(r.Method, check.Equals, "POST")
@@ -2563,6 +2710,62 @@ func (s *SnapOpSuite) TestRemoveWithPurge(c *check.C) {
 	c.Check(s.srv.n, check.Equals, s.srv.total)
 }
 
+func (s *SnapOpSuite) TestRemoveWithTerminate(c *check.C) {
+	restore := snap.MockIsStdinTTY(false)
+	defer restore()
+
+	s.srv.total = 3
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":    "remove",
+			"terminate": true,
+		})
+	}
+
+	s.RedirectClientToTestServer(s.srv.handle)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"remove", "--terminate", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Matches, `(?sm).*foo removed`)
+	c.Check(s.Stderr(), check.Equals, "")
+	// ensure that the fake server api was actually hit
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
+func (s *SnapOpSuite) TestRemoveWithTerminateConfirmed(c *check.C) {
+	restore := snap.MockIsStdinTTY(true)
+	defer restore()
+	s.stdin.WriteString("y\n")
+
+	s.srv.total = 3
+	s.srv.checker = func(r *http.Request) {
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":    "remove",
+			"terminate": true,
+		})
+	}
+
+	s.RedirectClientToTestServer(s.srv.handle)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"remove", "--terminate", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?sm)Running apps of "foo" will be terminated. Continue\? \[y/N\] .*foo removed`)
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
+func (s *SnapOpSuite) TestRemoveWithTerminateAborted(c *check.C) {
+	restore := snap.MockIsStdinTTY(true)
+	defer restore()
+	s.stdin.WriteString("n\n")
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to %s", r.URL.Path)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"remove", "--terminate", "foo", "bar"})
+	c.Assert(err, check.ErrorMatches, "aborted")
+	c.Check(s.Stdout(), check.Equals, `Running apps of "foo", "bar" will be terminated. Continue? [y/N] `)
+}
+
 func (s *SnapOpSuite) TestRemoveInsufficientDiskSpace(c *check.C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, `{
@@ -3114,3 +3317,51 @@ func (s *SnapOpSuite) TestWaitReportsInfoStatus(c *check.C) {
 	c.Check(meter.Notices, testutil.Contains, "INFO: Task set to wait until a manual system restart allows to continue")
 	c.Check(n, check.Equals, 2)
 }
+
+func (s *SnapOpSuite) TestChannelCompletion(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0, 1:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/find")
+			c.Check(r.URL.Query().Get("name"), check.Equals, "foo")
+			fmt.Fprint(w, `{"type": "sync", "result": [{"name": "foo", "channels": {
+"latest/stable": {"revision": "1"},
+"latest/edge": {"revision": "2"},
+"2.0/beta": {"revision": "3"}
+}}]}`)
+		default:
+			c.Fatalf("expected to get 2 requests, now on %d", n+1)
+		}
+		n++
+	})
+
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+
+	os.Args = []string{"snap", "install", "--channel", ""}
+	c.Check(snap.ChannelName("").Complete(""), check.DeepEquals, []flags.Completion{
+		{Item: "beta"},
+		{Item: "candidate"},
+		{Item: "edge"},
+		{Item: "stable"},
+	})
+	c.Check(n, check.Equals, 0)
+
+	os.Args = []string{"snap", "install", "foo", "--channel", ""}
+	c.Check(snap.ChannelName("").Complete(""), check.DeepEquals, []flags.Completion{
+		{Item: "2.0/beta"},
+		{Item: "edge"},
+		{Item: "latest/edge"},
+		{Item: "latest/stable"},
+		{Item: "stable"},
+	})
+
+	os.Args = []string{"snap", "refresh", "--channel=l", "foo"}
+	c.Check(snap.ChannelName("").Complete("l"), check.DeepEquals, []flags.Completion{
+		{Item: "latest/edge"},
+		{Item: "latest/stable"},
+	})
+	c.Check(n, check.Equals, 2)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/jessevdk/go-flags"
 
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/snap"
 )
 
 type cmdUnalias struct {
@@ -37,6 +38,11 @@ var longUnaliasHelp = i18n.G(`
 The unalias command removes a single alias if the provided argument is a manual
 alias, or disables all aliases of a snap, including manual ones, if the
 argument is a snap name.
+
+$ snap unalias <snap>.*
+
+Removes all the manual aliases of the snap, leaving its automatic aliases
+as they are.
 `)
 
 func init() {
@@ -53,7 +59,14 @@ func (x *cmdUnalias) Execute(args []string) error {
 		return ErrExtraArgs
 	}
 
-	id, err := x.client.Unalias(string(x.Positionals.AliasOrSnap))
+	var id string
+	var err error
+	aliasOrSnap := string(x.Positionals.AliasOrSnap)
+	if snapName, app := snap.SplitSnapApp(aliasOrSnap); app == "*" {
+		id, err = x.client.RemoveManualAliases(snapName)
+	} else {
+		id, err = x.client.Unalias(aliasOrSnap)
+	}
 	if err != nil {
 		return err
 	}
@@ -36,6 +36,11 @@ The unalias command removes a single alias if the provided argument is a manual
 alias, or disables all aliases of a snap, including manual ones, if the
 argument is a snap name.
 
+$ snap unalias <snap>.*
+
+Removes all the manual aliases of the snap, leaving its automatic aliases
+as they are.
+
 [unalias command options]
       --no-wait            Do not wait for the operation to finish but just
                            print the change id.
@@ -71,3 +76,32 @@ func (s *SnapSuite) TestUnalias(c *C) {
 	)
 	c.Assert(s.Stderr(), Equals, "")
 }
+
+func (s *SnapSuite) TestUnaliasManualAliasesOfSnap(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/aliases":
+			c.Check(r.Method, Equals, "POST")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action": "unalias",
+				"snap":   "foo",
+				"app":    "*",
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+		case "/v2/changes/zzz":
+			c.Check(r.Method, Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"ready": true, "status": "Done", "data": {"aliases-removed": [{"alias": "alias1", "snap": "foo", "app": "foo"}]}}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"unalias", "foo.*"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Assert(s.Stdout(), Equals, ""+
+		"Removed:\n"+
+		"  - foo as alias1\n",
+	)
+	c.Assert(s.Stderr(), Equals, "")
+}
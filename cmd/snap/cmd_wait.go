@@ -33,6 +33,7 @@ import (
 
 type cmdWait struct {
 	clientMixin
+	Timeout    time.Duration `long:"timeout"`
 	Positional struct {
 		Snap installedSnapName `required:"yes"`
 		Key  string
@@ -45,7 +46,10 @@ func init() {
 		"The wait command waits until a configuration becomes true.",
 		func() flags.Commander {
 			return &cmdWait{}
-		}, nil, []argDesc{
+		}, map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"timeout": i18n.G("Give up waiting after the given duration (e.g. 30s or 5m)"),
+		}, []argDesc{
 			{
 				name: "<snap>",
 				// TRANSLATORS: This should not start with a lowercase letter.
@@ -120,6 +124,14 @@ func (x *cmdWait) Execute(args []string) error {
 		return fmt.Errorf("the required argument `<key>` was not provided")
 	}
 
+	if x.Timeout < 0 {
+		return fmt.Errorf(i18n.G("timeout cannot be negative"))
+	}
+	var deadline time.Time
+	if x.Timeout > 0 {
+		deadline = time.Now().Add(x.Timeout)
+	}
+
 	for {
 		conf, err := x.client.Conf(snapName, []string{confKey})
 		if err != nil && !isNoOption(err) {
@@ -132,6 +144,9 @@ func (x *cmdWait) Execute(args []string) error {
 		if res {
 			break
 		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return fmt.Errorf(i18n.G("timeout waiting for %q of snap %q to become true"), confKey, snapName)
+		}
 		time.Sleep(waitConfTimeout)
 	}
 
@@ -53,6 +53,31 @@ func (s *SnapSuite) TestCmdWaitHappy(c *C) {
 	c.Check(n > 2, Equals, true)
 }
 
+func (s *SnapSuite) TestCmdWaitTimeout(c *C) {
+	restore := snap.MockWaitConfTimeout(10 * time.Millisecond)
+	defer restore()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v2/snaps/system/conf")
+		fmt.Fprintln(w, `{"type":"sync", "status-code": 200, "result": {"seed.loaded": false}}`)
+		n++
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"wait", "--timeout=50ms", "system", "seed.loaded"})
+	c.Assert(err, ErrorMatches, `timeout waiting for "seed.loaded" of snap "system" to become true`)
+	c.Check(n > 1, Equals, true)
+}
+
+func (s *SnapSuite) TestCmdWaitNegativeTimeout(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"wait", "--timeout=-1s", "system", "seed.loaded"})
+	c.Assert(err, ErrorMatches, "timeout cannot be negative")
+}
+
 func (s *SnapSuite) TestCmdWaitMissingConfKey(c *C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -113,6 +113,12 @@ func (cmd *cmdWarnings) Execute(args []string) error {
 			fmt.Fprintf(w, "first-occurrence:\t%s\n", cmd.fmtTime(warning.FirstAdded))
 		}
 		fmt.Fprintf(w, "last-occurrence:\t%s\n", cmd.fmtTime(warning.LastAdded))
+		if cmd.Verbose && warning.Count > 0 {
+			fmt.Fprintf(w, "occurrences:\t%d\n", warning.Count)
+		}
+		if cmd.Verbose && warning.Severity != "" {
+			fmt.Fprintf(w, "severity:\t%s\n", warning.Severity)
+		}
 		if cmd.Verbose {
 			lastShown := esc.dash
 			if !warning.LastShown.IsZero() {
@@ -43,7 +43,9 @@ const twoWarnings = `{
 				"first-added": "2018-09-19T12:41:18.505007495Z",
 				"last-added": "2018-09-19T12:41:18.505007495Z",
 				"message": "hello world number one",
-				"repeat-after": "24h0m0s"
+				"repeat-after": "24h0m0s",
+				"count": 3,
+				"severity": "high"
 			    },
 			    {
 				"expire-after": "672h0m0s",
@@ -127,6 +129,8 @@ func (s *warningSuite) TestVerboseWarnings(c *check.C) {
 	c.Check(s.Stdout(), check.Equals, `
 first-occurrence:  2018-09-19T12:41:18Z
 last-occurrence:   2018-09-19T12:41:18Z
+occurrences:       3
+severity:          high
 acknowledged:      --
 repeats-after:     1d00h
 expires-after:     28d0h
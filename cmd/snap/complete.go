@@ -23,6 +23,8 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -33,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
 )
 
 type installedSnapName string
@@ -413,6 +416,76 @@ func (s interfaceName) Complete(match string) []flags.Completion {
 	return ret
 }
 
+// channelName is a channel given on the command line; it completes
+// from the channels the store offers for the snaps named alongside it,
+// or from the plain risk levels when there are none.
+type channelName string
+
+// completionSnapNames returns the words of the command line being
+// completed that look like snap names, skipping the command itself,
+// any flags and the word being completed.
+func completionSnapNames(match string) []string {
+	if len(os.Args) < 2 {
+		return nil
+	}
+	args := os.Args[1:]
+	if len(args) > 0 && args[len(args)-1] == match {
+		args = args[:len(args)-1]
+	}
+	var names []string
+	seenCmd := false
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if !seenCmd {
+			seenCmd = true
+			continue
+		}
+		if naming.ValidateInstance(arg) != nil {
+			continue
+		}
+		names = append(names, arg)
+	}
+	return names
+}
+
+func (s channelName) Complete(match string) []flags.Completion {
+	candidates := make(map[string]bool)
+	names := completionSnapNames(match)
+	if len(names) == 0 {
+		for _, risk := range channelRisks {
+			candidates[risk] = true
+		}
+	}
+	cli := mkClient()
+	for _, name := range names {
+		snapName, _ := snap.SplitInstanceName(name)
+		remote, _, err := cli.FindOne(snapName)
+		if err != nil {
+			continue
+		}
+		for ch := range remote.Channels {
+			candidates[ch] = true
+			if track := strings.TrimSuffix(ch, "/"+path.Base(ch)); track == "latest" {
+				// channels on the default track can be given
+				// as just the risk
+				candidates[path.Base(ch)] = true
+			}
+		}
+	}
+
+	ret := make([]flags.Completion, 0, len(candidates))
+	for ch := range candidates {
+		if strings.HasPrefix(ch, match) {
+			ret = append(ret, flags.Completion{Item: ch})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Item < ret[j].Item })
+
+	return ret
+}
+
 type appName string
 
 func (s appName) Complete(match string) []flags.Completion {
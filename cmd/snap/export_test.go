@@ -36,6 +36,7 @@ import (
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/store/tooling"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeutil"
 	usersessionclient "github.com/snapcore/snapd/usersession/client"
 )
 
@@ -48,6 +49,7 @@ var (
 
 	CreateUserDataDirs  = createUserDataDirs
 	ResolveApp          = resolveApp
+	DisplayWidth        = displayWidth
 	SnapdHelperPath     = snapdHelperPath
 	SortByPath          = sortByPath
 	AdviseCommand       = adviseCommand
@@ -153,6 +155,7 @@ var (
 	WaitWhileInhibited                            = waitWhileInhibited
 	IsLocked                                      = isLocked
 	TryNotifyRefreshViaSnapDesktopIntegrationFlow = tryNotifyRefreshViaSnapDesktopIntegrationFlow
+	RefreshProgress                               = refreshProgress
 )
 
 func MockPollTime(d time.Duration) (restore func()) {
@@ -163,6 +166,14 @@ func MockPollTime(d time.Duration) (restore func()) {
 	}
 }
 
+func MockLogsReconnect(delay time.Duration, attempts int) (restore func()) {
+	oldDelay, oldAttempts := logsReconnectDelay, logsReconnectAttempts
+	logsReconnectDelay, logsReconnectAttempts = delay, attempts
+	return func() {
+		logsReconnectDelay, logsReconnectAttempts = oldDelay, oldAttempts
+	}
+}
+
 func MockMaxGoneTime(d time.Duration) (restore func()) {
 	d0 := maxGoneTime
 	maxGoneTime = d
@@ -179,6 +190,14 @@ func MockSyscallExec(f func(string, []string, []string) error) (restore func())
 	}
 }
 
+func MockOsMkdirAll(f func(string, os.FileMode) error) (restore func()) {
+	osMkdirAllOrig := osMkdirAll
+	osMkdirAll = f
+	return func() {
+		osMkdirAll = osMkdirAllOrig
+	}
+}
+
 func MockUserCurrent(f func() (*user.User, error)) (restore func()) {
 	userCurrentOrig := userCurrent
 	userCurrent = f
@@ -339,6 +358,7 @@ func MockSignalNotify(newSignalNotify func(sig ...os.Signal) (chan os.Signal, fu
 }
 
 type ServiceName = serviceName
+type ChannelName = channelName
 
 func MockCreateTransientScopeForTracking(fn func(securityTag string, opts *cgroup.TrackingOptions) error) (restore func()) {
 	old := cgroupCreateTransientScopeForTracking
@@ -412,6 +432,14 @@ func MockSnapdWaitForFullSystemReboot(t time.Duration) (restore func()) {
 	}
 }
 
+func MockConsoleConfTimeSource(ts timeutil.TimeSource) (restore func()) {
+	old := consoleConfTimeSource
+	consoleConfTimeSource = ts
+	return func() {
+		consoleConfTimeSource = old
+	}
+}
+
 func MockOsChmod(f func(string, os.FileMode) error) (restore func()) {
 	old := osChmod
 	osChmod = f
@@ -436,6 +464,22 @@ func MockIsLocked(f func(snapName string) (runinhibit.Hint, error)) (restore fun
 	}
 }
 
+func MockRefreshProgress(f func(snapName string) (label string, done, total int, err error)) (restore func()) {
+	old := refreshProgress
+	refreshProgress = f
+	return func() {
+		refreshProgress = old
+	}
+}
+
+func MockRefreshProgressInterval(d time.Duration) (restore func()) {
+	old := refreshProgressInterval
+	refreshProgressInterval = d
+	return func() {
+		refreshProgressInterval = old
+	}
+}
+
 func MockIsGraphicalSession(graphical bool) (restore func()) {
 	old := isGraphicalSession
 	isGraphicalSession = func() bool {
@@ -496,3 +540,24 @@ func MockSeedWriterReadManifest(f func(manifestFile string) (*seedwriter.Manifes
 	seedwriterReadManifest = f
 	return restore
 }
+
+func MockImageMirror(f func(tsto *tooling.ToolingStore, snaps []image.MirrorSnap, targetDir string) (*image.MirrorIndex, error)) (restore func()) {
+	old := imageMirror
+	imageMirror = f
+	return func() {
+		imageMirror = old
+	}
+}
+
+func MockTryWatch(interval time.Duration, interrupt <-chan os.Signal) (restore func()) {
+	oldInterval := tryWatchInterval
+	oldInterrupt := tryWatchInterrupt
+	tryWatchInterval = interval
+	tryWatchInterrupt = func() (<-chan os.Signal, func()) {
+		return interrupt, func() {}
+	}
+	return func() {
+		tryWatchInterval = oldInterval
+		tryWatchInterrupt = oldInterrupt
+	}
+}
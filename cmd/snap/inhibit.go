@@ -1,7 +1,7 @@
 // -*- Mode: Go; indent-tabs-mode: t -*-
 
 /*
- * Copyright (C) 2020 Canonical Ltd
+ * Copyright (C) 2020-2023 Canonical Ltd
  *
  * This program is free software: you can redistribute it and/or modify
  * it under the terms of the GNU General Public License version 3 as
@@ -26,12 +26,14 @@ import (
 	"time"
 
 	"github.com/godbus/dbus"
+
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/cmd/snaplock/runinhibit"
 	"github.com/snapcore/snapd/dbusutil"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/progress"
-	"github.com/snapcore/snapd/usersession/client"
+	usersessionclient "github.com/snapcore/snapd/usersession/client"
 )
 
 func waitWhileInhibited(snapName string) error {
@@ -64,10 +66,40 @@ func waitWhileInhibited(snapName string) error {
 		if notifiedDesktopIntegration {
 			return nil
 		}
-		return graphicalSessionFlow(snapName, hint)
+	}
+
+	flow := newInhibitionFlow(snapName)
+	if err := flow.StartInhibitionNotification(hint); err != nil {
+		return err
+	}
+	if err := waitInhibitUnlockWithProgress(snapName, flow); err != nil {
+		return err
+	}
+	return flow.FinishInhibitionNotification()
+}
+
+// inhibitionFlow informs the user that a snap cannot be run for the time
+// being, and of the progress of the operation it is waiting for.
+type inhibitionFlow interface {
+	// StartInhibitionNotification notifies the user that running the
+	// snap is blocked, for the reason given by the hint.
+	StartInhibitionNotification(hint runinhibit.Hint) error
+	// ShowProgress reports the progress of the change the snap is
+	// waiting for, as the number of its tasks that are done.
+	ShowProgress(label string, done, total int)
+	// FinishInhibitionNotification notifies the user that the snap can
+	// be run again.
+	FinishInhibitionNotification() error
+}
+
+// newInhibitionFlow returns the flow notifying the user through the
+// session agent in graphical sessions and on the terminal otherwise.
+var newInhibitionFlow = func(snapName string) inhibitionFlow {
+	if isGraphicalSession() {
+		return &graphicalFlow{snapName: snapName}
 	}
 	// terminal and headless
-	return textFlow(snapName, hint)
+	return &textFlow{snapName: snapName}
 }
 
 func inhibitMessage(snapName string, hint runinhibit.Hint) string {
@@ -85,16 +117,16 @@ var isGraphicalSession = func() bool {
 	return false
 }
 
-var pendingRefreshNotification = func(refreshInfo *client.PendingSnapRefreshInfo) error {
-	userclient := client.NewForUids(os.Getuid())
+var pendingRefreshNotification = func(refreshInfo *usersessionclient.PendingSnapRefreshInfo) error {
+	userclient := usersessionclient.NewForUids(os.Getuid())
 	if err := userclient.PendingRefreshNotification(context.TODO(), refreshInfo); err != nil {
 		return err
 	}
 	return nil
 }
 
-var finishRefreshNotification = func(refreshInfo *client.FinishedSnapRefreshInfo) error {
-	userclient := client.NewForUids(os.Getuid())
+var finishRefreshNotification = func(refreshInfo *usersessionclient.FinishedSnapRefreshInfo) error {
+	userclient := usersessionclient.NewForUids(os.Getuid())
 	if err := userclient.FinishRefreshNotification(context.TODO(), refreshInfo); err != nil {
 		return err
 	}
@@ -121,31 +153,120 @@ var tryNotifyRefreshViaSnapDesktopIntegrationFlow = func(snapName string) (bool,
 	return true, nil
 }
 
-func graphicalSessionFlow(snapName string, hint runinhibit.Hint) error {
-	refreshInfo := client.PendingSnapRefreshInfo{
-		InstanceName: snapName,
+type graphicalFlow struct {
+	snapName string
+}
+
+func (gf *graphicalFlow) StartInhibitionNotification(hint runinhibit.Hint) error {
+	refreshInfo := usersessionclient.PendingSnapRefreshInfo{
+		InstanceName: gf.snapName,
 		// Remaining time = 0 results in "Snap .. is refreshing now" message from
 		// usersession agent.
 		TimeRemaining: 0,
 	}
+	return pendingRefreshNotification(&refreshInfo)
+}
 
-	if err := pendingRefreshNotification(&refreshInfo); err != nil {
-		return err
+// ShowProgress does nothing, the notification shown by the session agent
+// stays up until the refresh completes.
+func (gf *graphicalFlow) ShowProgress(label string, done, total int) {}
+
+func (gf *graphicalFlow) FinishInhibitionNotification() error {
+	finishRefreshInfo := usersessionclient.FinishedSnapRefreshInfo{InstanceName: gf.snapName}
+	return finishRefreshNotification(&finishRefreshInfo)
+}
+
+type textFlow struct {
+	snapName string
+	pb       progress.Meter
+	label    string
+	total    int
+}
+
+func (tf *textFlow) StartInhibitionNotification(hint runinhibit.Hint) error {
+	fmt.Fprintf(Stdout, "%s\n", inhibitMessage(tf.snapName, hint))
+	tf.pb = progress.MakeProgressBar(Stdout)
+	tf.pb.Spin(i18n.G("please wait..."))
+	return nil
+}
+
+func (tf *textFlow) ShowProgress(label string, done, total int) {
+	if total <= 0 {
+		return
 	}
-	if _, err := waitInhibitUnlock(snapName, runinhibit.HintNotInhibited); err != nil {
-		return err
+	if label != tf.label || total != tf.total {
+		tf.pb.Start(label, float64(total))
+		tf.label = label
+		tf.total = total
 	}
+	tf.pb.Set(float64(done))
+}
 
-	finishRefreshInfo := client.FinishedSnapRefreshInfo{InstanceName: snapName}
-	return finishRefreshNotification(&finishRefreshInfo)
+func (tf *textFlow) FinishInhibitionNotification() error {
+	tf.pb.Finished()
+	return nil
+}
+
+// refreshProgress returns the summary of the change in progress for the
+// given snap, with the number of its tasks that are done and the total,
+// or a zero total if there is none.
+var refreshProgress = func(snapName string) (label string, done, total int, err error) {
+	chgs, err := mkClient().Changes(&client.ChangesOptions{
+		SnapName: snapName,
+		Selector: client.ChangesInProgress,
+	})
+	if err != nil {
+		return "", 0, 0, err
+	}
+	// the earliest change is the one holding the snap
+	var chg *client.Change
+	for _, c := range chgs {
+		if chg == nil || c.SpawnTime.Before(chg.SpawnTime) {
+			chg = c
+		}
+	}
+	if chg == nil {
+		return "", 0, 0, nil
+	}
+	for _, t := range chg.Tasks {
+		switch t.Status {
+		case "Done", "Undone", "Error", "Hold":
+			done++
+		}
+	}
+	return chg.Summary, done, len(chg.Tasks), nil
 }
 
-func textFlow(snapName string, hint runinhibit.Hint) error {
-	fmt.Fprintf(Stdout, "%s\n", inhibitMessage(snapName, hint))
-	pb := progress.MakeProgressBar(Stdout)
-	pb.Spin(i18n.G("please wait..."))
+var refreshProgressInterval = time.Second
+
+// waitInhibitUnlockWithProgress waits until the snap is not inhibited
+// anymore, reporting the progress of the change the snap is waiting for
+// through the flow meanwhile.
+func waitInhibitUnlockWithProgress(snapName string, flow inhibitionFlow) error {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(refreshProgressInterval)
+		defer ticker.Stop()
+		for {
+			label, done, total, err := refreshProgress(snapName)
+			if err != nil {
+				// progress is informational only
+				logger.Debugf("cannot get progress of the refresh of %q: %v", snapName, err)
+			} else {
+				flow.ShowProgress(label, done, total)
+			}
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
 	_, err := waitInhibitUnlock(snapName, runinhibit.HintNotInhibited)
-	pb.Finished()
+	close(stop)
+	<-stopped
 	return err
 }
 
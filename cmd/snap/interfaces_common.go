@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 )
 
@@ -84,3 +85,35 @@ func (sn *SnapAndNameStrict) UnmarshalFlag(value string) error {
 	sn.Snap, sn.Name = parts[0], parts[1]
 	return nil
 }
+
+// showInterfaceActionEffects prints the outcome of a dry-run of an
+// interface action.
+func showInterfaceActionEffects(effects *client.InterfaceActionEffects, noneMsg string) {
+	if len(effects.Connections) == 0 {
+		fmt.Fprintln(Stdout, noneMsg)
+		return
+	}
+
+	w := tabWriter()
+	fmt.Fprintln(w, i18n.G("Interface\tPlug\tSlot"))
+	for _, conn := range effects.Connections {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", conn.Interface, conn.Plug.Snap+":"+conn.Plug.Name, conn.Slot.Snap+":"+conn.Slot.Name)
+	}
+	w.Flush()
+
+	if len(effects.Profiles) == 0 {
+		return
+	}
+	fmt.Fprintln(Stdout)
+	if len(effects.Backends) > 0 {
+		// TRANSLATORS: %s is a list of security backends, e.g. "apparmor, seccomp"
+		fmt.Fprintf(Stdout, i18n.G("Security profiles that would be regenerated (%s):\n"), strings.Join(effects.Backends, ", "))
+	} else {
+		fmt.Fprintln(Stdout, i18n.G("Security profiles that would be regenerated:"))
+	}
+	for _, profiles := range effects.Profiles {
+		for _, tag := range profiles.SecurityTags {
+			fmt.Fprintf(Stdout, "  %s\n", tag)
+		}
+	}
+}
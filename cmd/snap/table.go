@@ -0,0 +1,205 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+// tableMixin adds options to select what is shown of tabular output.
+type tableMixin struct {
+	Columns  string `long:"columns"`
+	NoHeader bool   `long:"no-header"`
+}
+
+var tableDescs = mixinDescs{
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"columns": i18n.G("Comma-separated list of the columns to show, in order"),
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"no-header": i18n.G("Do not show the header line"),
+}
+
+// tableColumn describes a column of a table.
+type tableColumn struct {
+	// name identifies the column for --columns
+	name string
+	// header is the (translated) title of the column
+	header string
+}
+
+// table renders rows of cells aligned in columns, like a tabwriter
+// configured as by tabWriter, but measuring cells by their width on
+// the terminal rather than their number of runes, and showing only the
+// columns selected by a tableMixin.
+type table struct {
+	w        io.Writer
+	selected []int
+	noHeader bool
+	header   []string
+	rows     [][]string
+}
+
+const (
+	tableMinWidth = 5
+	tablePadding  = 2
+)
+
+// newTable returns a table with the given columns, restricted to the ones
+// selected with --columns, if any.
+func (mx tableMixin) newTable(w io.Writer, columns ...tableColumn) (*table, error) {
+	return mx.newTableWithOptional(w, columns, nil)
+}
+
+// newTableWithOptional is like newTable, but the table also has the
+// optional columns, following the given ones, which are shown only when
+// selected with --columns. Rows need cells for the optional columns too.
+func (mx tableMixin) newTableWithOptional(w io.Writer, columns, optional []tableColumn) (*table, error) {
+	defaults := len(columns)
+	columns = append(columns[:defaults:defaults], optional...)
+	t := &table{
+		w:        w,
+		noHeader: mx.NoHeader,
+		header:   make([]string, len(columns)),
+	}
+	for i, col := range columns {
+		t.header[i] = col.header
+	}
+	if mx.Columns == "" {
+		t.selected = make([]int, defaults)
+		for i := range t.selected {
+			t.selected[i] = i
+		}
+		return t, nil
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.name
+	}
+	for _, name := range strings.Split(mx.Columns, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		idx := -1
+		for i, col := range columns {
+			if col.name == name {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf(i18n.G("unknown column %q (available: %s)"), name, strings.Join(names, ", "))
+		}
+		t.selected = append(t.selected, idx)
+	}
+	return t, nil
+}
+
+// addRow adds a row to the table; there must be a cell for each of the
+// columns the table was created with.
+func (t *table) addRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// render writes out the header, unless disabled, and the rows of the table.
+func (t *table) render() error {
+	rows := t.rows
+	if !t.noHeader {
+		rows = append([][]string{t.header}, rows...)
+	}
+
+	// the last selected column is not padded
+	widths := make([]int, len(t.selected))
+	for i := range t.selected[:len(t.selected)-1] {
+		widths[i] = tableMinWidth
+		for _, row := range rows {
+			if w := displayWidth(row[t.selected[i]]) + tablePadding; w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	var buf strings.Builder
+	for _, row := range rows {
+		for i, idx := range t.selected {
+			cell := row[idx]
+			buf.WriteString(cell)
+			if i < len(t.selected)-1 {
+				buf.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)))
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := io.WriteString(t.w, buf.String())
+	return err
+}
+
+// wideRanges are the ranges of runes that take up two columns on a
+// terminal, roughly the East Asian wide and fullwidth characters and
+// emoji.
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1},
+		{0x2e80, 0x303e, 1},
+		{0x3041, 0x33ff, 1},
+		{0x3400, 0x4dbf, 1},
+		{0x4e00, 0x9fff, 1},
+		{0xa000, 0xa4cf, 1},
+		{0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1},
+		{0xfe30, 0xfe4f, 1},
+		{0xff00, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x1f300, 0x1f64f, 1},
+		{0x1f900, 0x1f9ff, 1},
+		{0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// displayWidth returns the number of columns s takes up on a terminal,
+// ignoring any ANSI escape sequences in it.
+func displayWidth(s string) int {
+	width := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case inEscape:
+			// CSI sequences end with a letter
+			if unicode.IsLetter(r) {
+				inEscape = false
+			}
+		case r == '\033':
+			inEscape = true
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc):
+			// combining marks and control characters
+		case unicode.In(r, wideRanges):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
@@ -44,9 +44,11 @@ var api = []*Command{
 	appIconCmd,
 	findCmd,
 	snapsCmd,
+	snapSizesCmd,
 	snapCmd,
 	snapFileCmd,
 	snapDownloadCmd,
+	snapDownloadNameCmd,
 	snapConfCmd,
 	interfacesCmd,
 	assertsCmd,
@@ -64,7 +66,10 @@ var api = []*Command{
 	appsCmd,
 	logsCmd,
 	warningsCmd,
+	noticesCmd,
+	noticeCmd,
 	debugPprofCmd,
+	debugAuditLogCmd,
 	debugCmd,
 	snapshotCmd,
 	snapshotExportCmd,
@@ -83,12 +88,15 @@ var api = []*Command{
 	quotaGroupsCmd,
 	quotaGroupInfoCmd,
 	aspectsCmd,
+	configProfilesCmd,
+	metricsCmd,
 }
 
 const (
-	polkitActionLogin            = "io.snapcraft.snapd.login"
-	polkitActionManage           = "io.snapcraft.snapd.manage"
-	polkitActionManageInterfaces = "io.snapcraft.snapd.manage-interfaces"
+	polkitActionLogin               = "io.snapcraft.snapd.login"
+	polkitActionManage              = "io.snapcraft.snapd.manage"
+	polkitActionManageInterfaces    = "io.snapcraft.snapd.manage-interfaces"
+	polkitActionManageConfiguration = "io.snapcraft.snapd.manage-configuration"
 )
 
 // userFromRequest extracts user information from request and return the respective user in state, if valid
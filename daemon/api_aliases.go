@@ -42,12 +42,18 @@ var (
 	}
 )
 
+// allApps is used as the app of an alias action to refer to all the
+// applications of a snap.
+const allApps = "*"
+
 // aliasAction is an action performed on aliases
 type aliasAction struct {
 	Action string `json:"action"`
 	Snap   string `json:"snap"`
 	App    string `json:"app"`
 	Alias  string `json:"alias"`
+	// Force takes over the aliases enabled for other snaps
+	Force bool `json:"force,omitempty"`
 	// old now unsupported api
 	Aliases []string `json:"aliases"`
 }
@@ -73,8 +79,21 @@ func changeAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 	default:
 		return BadRequest("unsupported alias action: %q", a.Action)
 	case "alias":
-		taskset, err = snapstate.Alias(st, a.Snap, a.App, a.Alias)
+		flags := &snapstate.AliasFlags{Force: a.Force}
+		if a.App == allApps {
+			if a.Alias != "" {
+				return BadRequest("cannot use an alias name when aliasing all applications of a snap")
+			}
+			taskset, err = snapstate.AliasAllApps(st, a.Snap, flags)
+		} else {
+			taskset, err = snapstate.AliasWithFlags(st, a.Snap, a.App, a.Alias, flags)
+		}
 	case "unalias":
+		if a.App == allApps {
+			a.Alias = ""
+			taskset, err = snapstate.RemoveAllManualAliases(st, a.Snap)
+			break
+		}
 		if a.Alias == a.Snap {
 			// Do What I mean:
 			// check if a snap is referred/intended
@@ -104,9 +123,15 @@ func changeAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 	var summary string
 	switch a.Action {
 	case "alias":
-		summary = fmt.Sprintf(i18n.G("Setup alias %q => %q for snap %q"), a.Alias, a.App, a.Snap)
+		if a.App == allApps {
+			summary = fmt.Sprintf(i18n.G("Setup aliases for all applications of snap %q"), a.Snap)
+		} else {
+			summary = fmt.Sprintf(i18n.G("Setup alias %q => %q for snap %q"), a.Alias, a.App, a.Snap)
+		}
 	case "unalias":
-		if a.Alias != "" {
+		if a.App == allApps {
+			summary = fmt.Sprintf(i18n.G("Remove manual aliases for snap %q"), a.Snap)
+		} else if a.Alias != "" {
 			summary = fmt.Sprintf(i18n.G("Remove manual alias %q for snap %q"), a.Alias, a.Snap)
 		} else {
 			summary = fmt.Sprintf(i18n.G("Disable all aliases for snap %q"), a.Snap)
@@ -126,6 +151,8 @@ type aliasStatus struct {
 	Status  string `json:"status"`
 	Manual  string `json:"manual,omitempty"`
 	Auto    string `json:"auto,omitempty"`
+	// Conflict is the snap for which a disabled alias is enabled instead
+	Conflict string `json:"conflict,omitempty"`
 }
 
 // getAliases produces a response with a map snap -> alias -> aliasStatus
@@ -141,6 +168,16 @@ func getAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 		return InternalError("cannot list local snaps: %v", err)
 	}
 
+	// snaps for which aliases are enabled
+	enabledFor := make(map[string]string)
+	for snapName, snapst := range allStates {
+		for alias, aliasTarget := range snapst.Aliases {
+			if aliasTarget.Effective(snapst.AutoAliasesDisabled) != "" {
+				enabledFor[alias] = snapName
+			}
+		}
+	}
+
 	for snapName, snapst := range allStates {
 		if len(snapst.Aliases) != 0 {
 			snapAliases := make(map[string]aliasStatus)
@@ -156,6 +193,7 @@ func getAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 				if tgt == "" {
 					status = "disabled"
 					tgt = aliasTarget.Auto
+					aliasStatus.Conflict = enabledFor[alias]
 				} else if aliasTarget.Manual != "" {
 					status = "manual"
 				}
@@ -163,6 +163,7 @@ func (s *aliasesSuite) TestAliasErrors(c *check.C) {
 		{func(a *daemon.AliasAction) { a.Snap = "lalala" }, `snap "lalala" is not installed`},
 		{func(a *daemon.AliasAction) { a.Alias = ".foo" }, `invalid alias name: ".foo"`},
 		{func(a *daemon.AliasAction) { a.Aliases = []string{"baz"} }, `cannot interpret request, snaps can no longer be expected to declare their aliases`},
+		{func(a *daemon.AliasAction) { a.App = "*" }, `cannot use an alias name when aliasing all applications of a snap`},
 	}
 
 	for _, scen := range errScenarios {
@@ -186,6 +187,80 @@ func (s *aliasesSuite) TestAliasErrors(c *check.C) {
 	}
 }
 
+func (s *aliasesSuite) postAliasAction(c *check.C, d *daemon.Daemon, action *daemon.AliasAction) *state.Change {
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	buf := bytes.NewBuffer(text)
+	req, err := http.NewRequest("POST", "/v2/aliases", buf)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, 202)
+	var body map[string]interface{}
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	c.Check(err, check.IsNil)
+	id := body["change"].(string)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(id)
+	c.Assert(chg, check.NotNil)
+	return chg
+}
+
+func (s *aliasesSuite) TestAliasAllAppsAndUnaliasSuccess(c *check.C) {
+	err := os.MkdirAll(dirs.SnapBinariesDir, 0755)
+	c.Assert(err, check.IsNil)
+	d := s.daemon(c)
+
+	s.mockSnap(c, aliasYaml)
+
+	oldAutoAliases := snapstate.AutoAliases
+	snapstate.AutoAliases = func(*state.State, *snap.Info) (map[string]string, error) {
+		return nil, nil
+	}
+	defer func() { snapstate.AutoAliases = oldAutoAliases }()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	chg := s.postAliasAction(c, d, &daemon.AliasAction{
+		Action: "alias",
+		Snap:   "alias-snap",
+		App:    "*",
+		Force:  true,
+	})
+	<-chg.Ready()
+
+	st := d.Overlord().State()
+	st.Lock()
+	c.Check(chg.Summary(), check.Equals, `Setup aliases for all applications of snap "alias-snap"`)
+	c.Check(chg.Err(), check.IsNil)
+	var force bool
+	c.Check(chg.Tasks()[0].Get("force", &force), check.IsNil)
+	c.Check(force, check.Equals, true)
+	st.Unlock()
+
+	c.Check(osutil.IsSymlink(filepath.Join(dirs.SnapBinariesDir, "app")), check.Equals, true)
+	c.Check(osutil.IsSymlink(filepath.Join(dirs.SnapBinariesDir, "app2")), check.Equals, true)
+
+	chg = s.postAliasAction(c, d, &daemon.AliasAction{
+		Action: "unalias",
+		Snap:   "alias-snap",
+		App:    "*",
+	})
+	<-chg.Ready()
+
+	st.Lock()
+	c.Check(chg.Summary(), check.Equals, `Remove manual aliases for snap "alias-snap"`)
+	c.Check(chg.Err(), check.IsNil)
+	st.Unlock()
+
+	c.Check(osutil.IsSymlink(filepath.Join(dirs.SnapBinariesDir, "app")), check.Equals, false)
+	c.Check(osutil.IsSymlink(filepath.Join(dirs.SnapBinariesDir, "app2")), check.Equals, false)
+}
+
 func (s *aliasesSuite) TestUnaliasSnapSuccess(c *check.C) {
 	err := os.MkdirAll(dirs.SnapBinariesDir, 0755)
 	c.Assert(err, check.IsNil)
@@ -566,9 +641,10 @@ func (s *aliasesSuite) TestAliases(c *check.C) {
 		},
 		"alias-snap2": {
 			"alias2": {
-				Command: "alias-snap2.cmd2",
-				Status:  "disabled",
-				Auto:    "cmd2",
+				Command:  "alias-snap2.cmd2",
+				Status:   "disabled",
+				Auto:     "cmd2",
+				Conflict: "alias-snap1",
 			},
 			"alias3": {
 				Command: "alias-snap2.cmd3",
@@ -28,12 +28,14 @@ import (
 	"strings"
 
 	"github.com/snapcore/snapd/client/clientutil"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/systemd"
 )
 
 var (
@@ -64,6 +66,7 @@ func getAppsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	default:
 		return BadRequest("invalid select parameter: %q", sel)
 	}
+	failures := query.Get("failures") == "true"
 
 	appInfos, rspe := appInfosFor(c.d.overlord.State(), strutil.CommaSeparatedList(query.Get("names")), opts)
 	if rspe != nil {
@@ -76,6 +79,17 @@ func getAppsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	if err != nil {
 		return InternalError("%v", err)
 	}
+	if failures {
+		// the failures are queried from systemd as the request is
+		// made instead of being tracked in the state, a service
+		// whose failure cannot be queried is reported without one;
+		// client app infos are in the same order as the apps
+		for i, app := range appInfos {
+			if err := sd.DecorateWithFailure(&clientAppInfos[i], app); err != nil {
+				logger.Noticef("%v", err)
+			}
+		}
+	}
 
 	return SyncResponse(clientAppInfos)
 }
@@ -199,6 +213,12 @@ func getLogs(c *Command, r *http.Request, user *auth.UserState) Response {
 		}
 		follow = f
 	}
+	priority := query.Get("priority")
+	if priority != "" {
+		if err := systemd.ValidateLogPriority(priority); err != nil {
+			return BadRequest("%v", err)
+		}
+	}
 
 	// only services have logs for now
 	opts := appInfoOptions{service: true}
@@ -210,7 +230,12 @@ func getLogs(c *Command, r *http.Request, user *auth.UserState) Response {
 		return AppNotFound("no matching services")
 	}
 
-	reader, err := servicestate.LogReader(appInfos, n, follow)
+	reader, err := servicestate.LogReader(appInfos, &systemd.LogOptions{
+		N:           n,
+		Follow:      follow,
+		Priority:    priority,
+		AfterCursor: query.Get("after-cursor"),
+	})
 	if err != nil {
 		return InternalError("cannot get logs: %v", err)
 	}
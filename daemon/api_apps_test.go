@@ -37,6 +37,7 @@ import (
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -56,6 +57,8 @@ type appsSuite struct {
 	jctlNs             []int
 	jctlFollows        []bool
 	jctlNamespaces     []bool
+	jctlPriorities     []string
+	jctlCursors        []string
 	jctlRCs            []io.ReadCloser
 	jctlErrs           []error
 
@@ -65,11 +68,13 @@ type appsSuite struct {
 	infoA, infoB, infoC, infoD, infoE *snap.Info
 }
 
-func (s *appsSuite) journalctl(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+func (s *appsSuite) journalctl(svcs []string, opts *systemd.LogOptions) (rc io.ReadCloser, err error) {
 	s.jctlSvcses = append(s.jctlSvcses, svcs)
-	s.jctlNs = append(s.jctlNs, n)
-	s.jctlFollows = append(s.jctlFollows, follow)
-	s.jctlNamespaces = append(s.jctlNamespaces, namespaces)
+	s.jctlNs = append(s.jctlNs, opts.N)
+	s.jctlFollows = append(s.jctlFollows, opts.Follow)
+	s.jctlNamespaces = append(s.jctlNamespaces, opts.Namespaces)
+	s.jctlPriorities = append(s.jctlPriorities, opts.Priority)
+	s.jctlCursors = append(s.jctlCursors, opts.AfterCursor)
 
 	if len(s.jctlErrs) > 0 {
 		err, s.jctlErrs = s.jctlErrs[0], s.jctlErrs[1:]
@@ -100,6 +105,9 @@ func (s *appsSuite) fakeServiceControl(st *state.State, appInfos []*snap.AppInfo
 	if inst.RestartOptions.Reload {
 		serviceCommand.options = "reload"
 	}
+	if inst.RestartOptions.Failed {
+		serviceCommand.options += "failed"
+	}
 	// only one flag should ever be set (depending on Action), but appending
 	// them below acts as an extra validity check.
 	if inst.StartOptions.Enable {
@@ -135,6 +143,8 @@ func (s *appsSuite) SetUpTest(c *check.C) {
 	s.jctlNs = nil
 	s.jctlFollows = nil
 	s.jctlNamespaces = nil
+	s.jctlPriorities = nil
+	s.jctlCursors = nil
 	s.jctlRCs = nil
 	s.jctlErrs = nil
 
@@ -215,6 +225,7 @@ NeedDaemonReload=no
 			Name:        app,
 			Daemon:      "simple",
 			DaemonScope: snap.SystemDaemon,
+			Units:       []string{"snap." + name + ".service"},
 		}
 		if snapName != "snap-b" {
 			// snap-b is not active (all the others are)
@@ -305,6 +316,7 @@ NeedDaemonReload=no
 			Name:        app,
 			Daemon:      "simple",
 			DaemonScope: snap.SystemDaemon,
+			Units:       []string{"snap." + name + ".service"},
 		}
 		if snapName != "snap-b" {
 			// snap-b is not active (all the others are)
@@ -326,6 +338,65 @@ NeedDaemonReload=no
 	c.Check(sort.StringsAreSorted(appNames), check.Equals, true)
 }
 
+func (s *appsSuite) TestGetAppsInfoFailures(c *check.C) {
+	s.SysctlBufs = [][]byte{
+		[]byte(`Id=snap.snap-a.svc1.service
+Names=snap.snap-a.svc1.service
+Type=simple
+ActiveState=failed
+UnitFileState=enabled
+NeedDaemonReload=no
+`),
+		[]byte("Result=exit-code\nExecMainStatus=2\nNRestarts=4\n"),
+	}
+	s.jctlRCs = []io.ReadCloser{ioutil.NopCloser(strings.NewReader(`{"MESSAGE": "oops"}`))}
+
+	req, err := http.NewRequest("GET", "/v2/apps?select=service&names=snap-a.svc1&failures=true", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+	svcs := rsp.Result.([]client.AppInfo)
+	c.Assert(svcs, check.HasLen, 1)
+	c.Check(svcs[0].Failed, check.Equals, true)
+	c.Check(svcs[0].Failure, check.DeepEquals, &client.AppFailure{
+		Result:   "exit-code",
+		ExitCode: 2,
+		Restarts: 4,
+		Log:      []string{"oops"},
+	})
+	c.Check(s.jctlSvcses, check.DeepEquals, [][]string{{"snap.snap-a.svc1.service"}})
+	c.Check(s.jctlNs, check.DeepEquals, []int{5})
+}
+
+func (s *appsSuite) TestGetAppsInfoFailuresError(c *check.C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	s.SysctlBufs = [][]byte{
+		[]byte(`Id=snap.snap-a.svc1.service
+Names=snap.snap-a.svc1.service
+Type=simple
+ActiveState=failed
+UnitFileState=enabled
+NeedDaemonReload=no
+`),
+		// no result reported
+		[]byte(""),
+	}
+
+	req, err := http.NewRequest("GET", "/v2/apps?select=service&names=snap-a.svc1&failures=true", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+	svcs := rsp.Result.([]client.AppInfo)
+	c.Assert(svcs, check.HasLen, 1)
+	c.Check(svcs[0].Failed, check.Equals, true)
+	c.Check(svcs[0].Failure, check.IsNil)
+	c.Check(logbuf.String(), testutil.Contains, `cannot get the result of service "svc1"`)
+}
+
 func (s *appsSuite) TestGetAppsInfoBadSelect(c *check.C) {
 	req, err := http.NewRequest("GET", "/v2/apps?select=potato", nil)
 	c.Assert(err, check.IsNil)
@@ -563,6 +634,15 @@ func (s *appsSuite) TestPostAppsReload(c *check.C) {
 	s.testPostApps(c, inst, expected)
 }
 
+func (s *appsSuite) TestPostAppsRestartFailed(c *check.C) {
+	inst := servicestate.Instruction{Action: "restart", Names: []string{"snap-a"}}
+	inst.Failed = true
+	expected := []serviceControlArgs{
+		{action: "restart", options: "failed", names: []string{"snap-a.svc1", "snap-a.svc2"}},
+	}
+	s.testPostApps(c, inst, expected)
+}
+
 func (s *appsSuite) TestPostAppsEnableNow(c *check.C) {
 	inst := servicestate.Instruction{Action: "start", Names: []string{"snap-a.svc2"}}
 	inst.Enable = true
@@ -668,6 +748,39 @@ func (s *appsSuite) TestLogs(c *check.C) {
 `[1:])
 }
 
+func (s *appsSuite) TestLogsPriorityAndCursor(c *check.C) {
+	s.expectLogsAccess()
+
+	s.jctlRCs = []io.ReadCloser{ioutil.NopCloser(strings.NewReader(`
+{"MESSAGE": "hello1", "SYSLOG_IDENTIFIER": "xyzzy", "_PID": "42", "__REALTIME_TIMESTAMP": "42", "__CURSOR": "s=abc;i=2"}
+	`))}
+
+	req, err := http.NewRequest("GET", "/v2/logs?names=snap-a.svc2&n=-1&follow=true&priority=err&after-cursor=s%3Dabc%3Bi%3D1", nil)
+	c.Assert(err, check.IsNil)
+
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+
+	c.Check(s.jctlPriorities, check.DeepEquals, []string{"err"})
+	c.Check(s.jctlCursors, check.DeepEquals, []string{"s=abc;i=1"})
+	c.Check(s.jctlFollows, check.DeepEquals, []bool{true})
+
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.String(), check.Equals, "\x1e"+`{"timestamp":"1970-01-01T00:00:00.000042Z","message":"hello1","sid":"xyzzy","pid":"42","cursor":"s=abc;i=2"}
+`)
+}
+
+func (s *appsSuite) TestLogsBadPriority(c *check.C) {
+	s.expectLogsAccess()
+
+	req, err := http.NewRequest("GET", "/v2/logs?priority=loud", nil)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Assert(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Matches, `invalid log priority "loud", .*`)
+}
+
 func (s *appsSuite) TestLogsNoNamespaceOption(c *check.C) {
 	restore := systemd.MockSystemdVersion(237, nil)
 	defer restore()
@@ -0,0 +1,101 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+)
+
+var (
+	configProfilesCmd = &Command{
+		Path:        "/v2/config-profiles",
+		POST:        postConfigProfile,
+		WriteAccess: authenticatedAccess{},
+	}
+)
+
+var configstateApplyConfigProfile = configstate.ApplyConfigProfile
+
+type configProfileAction struct {
+	Action  string `json:"action"`
+	BrandID string `json:"brand-id"`
+	Name    string `json:"name"`
+}
+
+func postConfigProfile(c *Command, r *http.Request, user *auth.UserState) Response {
+	var req configProfileAction
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		return BadRequest("cannot decode request body into config-profile action: %v", err)
+	}
+	if decoder.More() {
+		return BadRequest("extra content found in request body")
+	}
+	if req.Action != "apply" {
+		return BadRequest("unsupported action %q", req.Action)
+	}
+	if req.Name == "" {
+		return BadRequest("config-profile name is required")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if req.BrandID == "" {
+		model, err := c.d.overlord.DeviceManager().Model()
+		if err != nil {
+			return InternalError("cannot get the device model: %v", err)
+		}
+		req.BrandID = model.BrandID()
+	}
+
+	a, err := assertstate.DB(st).Find(asserts.ConfigProfileType, map[string]string{
+		"brand-id": req.BrandID,
+		"name":     req.Name,
+	})
+	if err != nil {
+		if errors.Is(err, &asserts.NotFoundError{}) {
+			return NotFound("cannot find config-profile %q of brand %q", req.Name, req.BrandID)
+		}
+		return InternalError("%v", err)
+	}
+
+	chg, err := configstateApplyConfigProfile(st, a.(*asserts.ConfigProfile))
+	if err != nil {
+		var conflErr *snapstate.ChangeConflictError
+		if errors.As(err, &conflErr) {
+			return SnapChangeConflict(conflErr)
+		}
+		return BadRequest("%v", err)
+	}
+
+	ensureStateSoon(st)
+
+	return AsyncResponse(nil, chg.ID())
+}
@@ -0,0 +1,131 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/assertstate/assertstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type configProfilesSuite struct {
+	apiBaseSuite
+}
+
+var _ = Suite(&configProfilesSuite{})
+
+func (s *configProfilesSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectWriteAccess(daemon.AuthenticatedAccess{})
+
+	_, restore := daemon.MockEnsureStateSoon(func(*state.State) {})
+	s.AddCleanup(restore)
+}
+
+func (s *configProfilesSuite) mockProfile(c *C, st *state.State) {
+	body := []byte(`{"system": {"refresh.timer": "4:00-6:00"}}`)
+	profile, err := s.Brands.Signing("my-brand").Sign(asserts.ConfigProfileType, map[string]interface{}{
+		"authority-id": "my-brand",
+		"brand-id":     "my-brand",
+		"name":         "fleet",
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, body, "")
+	c.Assert(err, IsNil)
+	assertstatetest.AddMany(st, s.StoreSigning.StoreAccountKey(""))
+	assertstatetest.AddMany(st, s.Brands.AccountsAndKeys("my-brand")...)
+	assertstatetest.AddMany(st, profile)
+}
+
+func (s *configProfilesSuite) TestApplyConfigProfile(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	s.mockProfile(c, st)
+	s.mockModel(st, s.Brands.Model("my-brand", "pc", map[string]interface{}{
+		"architecture": "amd64",
+		"gadget":       "gadget",
+		"kernel":       "kernel",
+	}))
+	st.Unlock()
+
+	var applied *asserts.ConfigProfile
+	defer daemon.MockConfigstateApplyConfigProfile(func(st *state.State, profile *asserts.ConfigProfile) (*state.Change, error) {
+		applied = profile
+		return st.NewChange("apply-config-profile", "..."), nil
+	})()
+
+	for _, body := range []string{
+		`{"action": "apply", "name": "fleet"}`,
+		`{"action": "apply", "brand-id": "my-brand", "name": "fleet"}`,
+	} {
+		applied = nil
+		req, err := http.NewRequest("POST", "/v2/config-profiles", bytes.NewBufferString(body))
+		c.Assert(err, IsNil)
+		rsp := s.asyncReq(c, req, nil)
+		c.Check(rsp.Status, Equals, 202)
+
+		st.Lock()
+		chg := st.Change(rsp.Change)
+		st.Unlock()
+		c.Check(chg, NotNil)
+		c.Assert(applied, NotNil)
+		c.Check(applied.BrandID(), Equals, "my-brand")
+		c.Check(applied.Name(), Equals, "fleet")
+	}
+}
+
+func (s *configProfilesSuite) TestApplyConfigProfileErrors(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	s.mockProfile(c, st)
+	st.Unlock()
+
+	defer daemon.MockConfigstateApplyConfigProfile(func(st *state.State, profile *asserts.ConfigProfile) (*state.Change, error) {
+		return nil, errors.New("cannot apply it")
+	})()
+
+	for _, t := range []struct {
+		body   string
+		status int
+		err    string
+	}{
+		{`{"action": "foo", "name": "fleet"}`, 400, `unsupported action "foo"`},
+		{`{"action": "apply"}`, 400, `config-profile name is required`},
+		{`{"action": "apply", "brand-id": "my-brand", "name": "other"}`, 404, `cannot find config-profile "other" of brand "my-brand"`},
+		{`{"action": "apply", "brand-id": "my-brand", "name": "fleet"}`, 400, `cannot apply it`},
+		{`{"action": "apply"} {}`, 400, `extra content found in request body`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/config-profiles", bytes.NewBufferString(t.body))
+		c.Assert(err, IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, Equals, t.status, Commentf(t.body))
+		c.Check(rspe.Message, Equals, t.err, Commentf(t.body))
+	}
+}
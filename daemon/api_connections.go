@@ -284,5 +284,24 @@ func getConnections(c *Command, r *http.Request, user *auth.UserState) Response
 	sort.Sort(byCrefConnJSON(connsjson.Established))
 	sort.Sort(byCrefConnJSON(connsjson.Undesired))
 
+	if query.Get("problems") == "true" {
+		problems, err := c.d.overlord.InterfaceManager().AutoConnectProblems(snapName)
+		if err != nil {
+			return InternalError("cannot check auto-connection problems: %v", err)
+		}
+		connsjson.Problems = make([]autoConnectProblemJSON, 0, len(problems))
+		for _, problem := range problems {
+			if ifaceName != "" && problem.Interface != ifaceName {
+				continue
+			}
+			connsjson.Problems = append(connsjson.Problems, autoConnectProblemJSON{
+				Plug:       problem.Plug,
+				Interface:  problem.Interface,
+				Candidates: problem.Candidates,
+				Reason:     problem.Reason,
+			})
+		}
+	}
+
 	return SyncResponse(connsjson)
 }
@@ -1042,3 +1042,46 @@ slots:
 		"type":        "sync",
 	})
 }
+
+func (s *interfacesSuite) TestConnectionsProblems(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+
+	s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	s.testConnections(c, "/v2/connections?snap=consumer&problems=true", map[string]interface{}{
+		"result": map[string]interface{}{
+			"established": []interface{}{},
+			"plugs":       []interface{}{},
+			"slots":       []interface{}{},
+			"problems": []interface{}{
+				map[string]interface{}{
+					"plug":      map[string]interface{}{"snap": "consumer", "plug": "plug"},
+					"interface": "test",
+					"candidates": []interface{}{
+						map[string]interface{}{"snap": "producer", "slot": "slot"},
+					},
+					"reason": "auto-connection is allowed but did not happen",
+				},
+			},
+		},
+		"status":      "OK",
+		"status-code": 200.0,
+		"type":        "sync",
+	})
+
+	// problems are filtered by interface too
+	s.testConnections(c, "/v2/connections?snap=consumer&interface=other&problems=true", map[string]interface{}{
+		"result": map[string]interface{}{
+			"established": []interface{}{},
+			"plugs":       []interface{}{},
+			"slots":       []interface{}{},
+		},
+		"status":      "OK",
+		"status-code": 200.0,
+		"type":        "sync",
+	})
+}
@@ -21,12 +21,16 @@ package daemon
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"time"
 
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
 )
 
 var (
@@ -44,6 +48,24 @@ var delayTime = 20 * time.Minute
 type consoleConfStartRoutineResult struct {
 	ActiveAutoRefreshChanges []string `json:"active-auto-refreshes,omitempty"`
 	ActiveAutoRefreshSnaps   []string `json:"active-auto-refresh-snaps,omitempty"`
+	// Seeding is true if the device has not finished seeding yet.
+	Seeding bool `json:"seeding,omitempty"`
+	// ActiveDeviceInitChanges are the ids of the in-progress changes
+	// initializing the device, i.e. registering it.
+	ActiveDeviceInitChanges []string `json:"active-device-init-changes,omitempty"`
+	// StoreOffline is true if the store access is disabled with
+	// store.access=offline or the store cannot be reached, in which
+	// case no refreshes nor device registration can happen.
+	StoreOffline bool `json:"store-offline,omitempty"`
+	// RefreshHeld is true if auto-refreshes are held, by means of
+	// refresh.hold, for longer than console-conf delays them.
+	RefreshHeld bool `json:"refresh-held,omitempty"`
+}
+
+// deviceInitChangeKinds are the kinds of changes that initialize the
+// device after seeding and which console-conf needs to wait for.
+var deviceInitChangeKinds = map[string]bool{
+	"become-operational": true,
 }
 
 func consoleConfStartRoutine(c *Command, r *http.Request, _ *auth.UserState) Response {
@@ -62,17 +84,56 @@ func consoleConfStartRoutine(c *Command, r *http.Request, _ *auth.UserState) Res
 	st.Lock()
 	defer st.Unlock()
 
-	snapAutoRefreshChanges, err := c.d.overlord.SnapManager().EnsureAutoRefreshesAreDelayed(delayTime)
+	// check the hold before it is extended below
+	snapMgr := c.d.overlord.SnapManager()
+	refreshHold, err := snapMgr.EffectiveRefreshHold()
+	if err != nil {
+		return InternalError("cannot get refresh.hold configuration: %v", err)
+	}
+	refreshHeld := refreshHold.After(time.Now().Add(delayTime))
+
+	tr := config.NewTransaction(st)
+	var storeAccess string
+	if err := tr.GetMaybe("core", "store.access", &storeAccess); err != nil {
+		return InternalError("cannot get store.access configuration: %v", err)
+	}
+	storeOffline := storeAccess == "offline"
+	if !storeOffline {
+		storeOffline = !storeReachable(st)
+	}
+
+	snapAutoRefreshChanges, err := snapMgr.EnsureAutoRefreshesAreDelayed(delayTime)
 	if err != nil {
 		return InternalError(err.Error())
 	}
 
 	logger.Debugf("Ensured that new auto refreshes are delayed by %s to allow console-conf to run", delayTime)
 
+	var seeded bool
+	if err := st.Get("seeded", &seeded); err != nil && !errors.Is(err, state.ErrNoState) {
+		return InternalError(err.Error())
+	}
+
+	var deviceInitChgIds []string
+	for _, chg := range st.Changes() {
+		if chg.IsReady() || !deviceInitChangeKinds[chg.Kind()] {
+			continue
+		}
+		deviceInitChgIds = append(deviceInitChgIds, chg.ID())
+	}
+
+	res := &consoleConfStartRoutineResult{
+		Seeding:                 !seeded,
+		ActiveDeviceInitChanges: deviceInitChgIds,
+		StoreOffline:            storeOffline,
+		RefreshHeld:             refreshHeld,
+	}
+
 	if len(snapAutoRefreshChanges) == 0 {
-		// no changes yet, and we delayed the refresh successfully so
-		// console-conf is okay to run normally
-		return SyncResponse(&consoleConfStartRoutineResult{})
+		// no refreshes yet, and we delayed the refresh successfully so
+		// console-conf is okay to run normally, unless the device is
+		// still being set up
+		return SyncResponse(res)
 	}
 
 	chgIds := make([]string, 0, len(snapAutoRefreshChanges))
@@ -88,9 +149,28 @@ func consoleConfStartRoutine(c *Command, r *http.Request, _ *auth.UserState) Res
 	}
 
 	// we have changes that the client should wait for before being ready
-	return SyncResponse(&consoleConfStartRoutineResult{
-		ActiveAutoRefreshChanges: chgIds,
-		ActiveAutoRefreshSnaps:   snapNames,
-	})
+	res.ActiveAutoRefreshChanges = chgIds
+	res.ActiveAutoRefreshSnaps = snapNames
+	return SyncResponse(res)
+}
 
+// storeReachable returns whether the store passes the connectivity check.
+// It must be called with the state locked, which it releases while the
+// check runs.
+func storeReachable(st *state.State) bool {
+	theStore := snapstate.Store(st, nil)
+	st.Unlock()
+	defer st.Lock()
+	checkResult, err := theStore.ConnectivityCheck()
+	if err != nil {
+		logger.Noticef("cannot run connectivity check: %v", err)
+		return false
+	}
+	for host, reachable := range checkResult {
+		if !reachable {
+			logger.Debugf("Store host %s is unreachable", host)
+			return false
+		}
+	}
+	return true
 }
@@ -21,6 +21,7 @@ package daemon_test
 
 import (
 	"bytes"
+	"errors"
 	"net/http"
 	"sort"
 	"time"
@@ -41,12 +42,15 @@ type consoleConfSuite struct {
 
 func (s *consoleConfSuite) TestPostConsoleConfStartRoutine(c *C) {
 	t0 := time.Now()
-	d := s.daemonWithOverlordMock()
+	d := s.daemonWithOverlordMockAndStore()
 	snapMgr, err := snapstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
 	c.Assert(err, IsNil)
 	d.Overlord().AddManager(snapMgr)
 
 	st := d.Overlord().State()
+	st.Lock()
+	st.Set("seeded", true)
+	st.Unlock()
 
 	body := bytes.NewBuffer(nil)
 	req, err := http.NewRequest("POST", "/v2/internal/console-conf-start", body)
@@ -113,3 +117,121 @@ func (s *consoleConfSuite) TestPostConsoleConfStartRoutine(c *C) {
 		ActiveAutoRefreshSnaps:   []string{"do-snap", "doing-snap"},
 	})
 }
+
+func (s *consoleConfSuite) TestPostConsoleConfStartRoutineSeedingAndDeviceInit(c *C) {
+	d := s.daemonWithOverlordMockAndStore()
+	snapMgr, err := snapstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, IsNil)
+	d.Overlord().AddManager(snapMgr)
+
+	st := d.Overlord().State()
+
+	// not seeded yet
+	req, err := http.NewRequest("POST", "/v2/internal/console-conf-start", bytes.NewBuffer(nil))
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Result, DeepEquals, &daemon.ConsoleConfStartRoutineResult{
+		Seeding: true,
+	})
+
+	// seeded but the device is still being registered
+	st.Lock()
+	st.Set("seeded", true)
+	chg0 := st.NewChange("become-operational", "Initialize device")
+	chg0.AddTask(st.NewTask("nop", "do nothing"))
+	chg0.SetStatus(state.DoingStatus)
+	// ready ones are ignored
+	chg1 := st.NewChange("become-operational", "Initialize device")
+	chg1.AddTask(st.NewTask("nop", "do nothing"))
+	chg1.SetStatus(state.ErrorStatus)
+	// as are unrelated ones
+	chg2 := st.NewChange("install", "install things")
+	chg2.AddTask(st.NewTask("nop", "do nothing"))
+	chg2.SetStatus(state.DoingStatus)
+	st.Unlock()
+
+	req, err = http.NewRequest("POST", "/v2/internal/console-conf-start", bytes.NewBuffer(nil))
+	c.Assert(err, IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Assert(rsp.Result, DeepEquals, &daemon.ConsoleConfStartRoutineResult{
+		ActiveDeviceInitChanges: []string{chg0.ID()},
+	})
+}
+
+func (s *consoleConfSuite) TestPostConsoleConfStartRoutineStoreOfflineRefreshHeld(c *C) {
+	d := s.daemonWithOverlordMockAndStore()
+	snapMgr, err := snapstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, IsNil)
+	d.Overlord().AddManager(snapMgr)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("seeded", true)
+	tr := config.NewTransaction(st)
+	tr.Set("core", "store.access", "offline")
+	tr.Set("core", "refresh.hold", "forever")
+	tr.Commit()
+	st.Unlock()
+
+	req, err := http.NewRequest("POST", "/v2/internal/console-conf-start", bytes.NewBuffer(nil))
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Result, DeepEquals, &daemon.ConsoleConfStartRoutineResult{
+		StoreOffline: true,
+		RefreshHeld:  true,
+	})
+}
+
+func (s *consoleConfSuite) TestPostConsoleConfStartRoutineOwnDelayIsNotHeld(c *C) {
+	d := s.daemonWithOverlordMockAndStore()
+	snapMgr, err := snapstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, IsNil)
+	d.Overlord().AddManager(snapMgr)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("seeded", true)
+	st.Unlock()
+
+	// the hold set by a previous run of the routine is not reported
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("POST", "/v2/internal/console-conf-start", bytes.NewBuffer(nil))
+		c.Assert(err, IsNil)
+		rsp := s.syncReq(c, req, nil)
+		c.Assert(rsp.Result, DeepEquals, &daemon.ConsoleConfStartRoutineResult{})
+	}
+}
+
+func (s *consoleConfSuite) TestPostConsoleConfStartRoutineStoreUnreachable(c *C) {
+	d := s.daemonWithOverlordMockAndStore()
+	snapMgr, err := snapstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, IsNil)
+	d.Overlord().AddManager(snapMgr)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("seeded", true)
+	st.Unlock()
+
+	s.connectivityResult = map[string]bool{
+		"api.snapcraft.io": false,
+	}
+
+	req, err := http.NewRequest("POST", "/v2/internal/console-conf-start", bytes.NewBuffer(nil))
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Result, DeepEquals, &daemon.ConsoleConfStartRoutineResult{
+		StoreOffline: true,
+	})
+
+	// the connectivity check failing is the same
+	s.connectivityResult = nil
+	s.err = errors.New("no network")
+
+	req, err = http.NewRequest("POST", "/v2/internal/console-conf-start", bytes.NewBuffer(nil))
+	c.Assert(err, IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Assert(rsp.Result, DeepEquals, &daemon.ConsoleConfStartRoutineResult{
+		StoreOffline: true,
+	})
+}
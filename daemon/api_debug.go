@@ -368,6 +368,8 @@ func getDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return getGadgetDiskMapping(st)
 	case "disks":
 		return getDisks(st)
+	case "kcmdline":
+		return getKernelCmdlineInfo(st)
 	default:
 		return BadRequest("unknown debug aspect %q", aspect)
 	}
@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+var debugAuditLogCmd = &Command{
+	Path:       "/v2/debug/audit-log",
+	GET:        getAuditLog,
+	ReadAccess: rootAccess{},
+}
+
+func getAuditLog(c *Command, r *http.Request, _ *auth.UserState) Response {
+	query := r.URL.Query()
+
+	filter := &auditFilter{}
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return BadRequest("invalid since timestamp %q: %v", since, err)
+		}
+		filter.Since = t
+	}
+	if s := query.Get("uid"); s != "" {
+		uid, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return BadRequest("invalid uid %q", s)
+		}
+		uid32 := uint32(uid)
+		filter.UID = &uid32
+	}
+	limit := 0
+	if s := query.Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return BadRequest("invalid limit %q", s)
+		}
+	}
+
+	entries, err := c.d.auditLog.entries(filter)
+	if err != nil {
+		return InternalError("cannot read audit log: %v", err)
+	}
+	// keep the most recent entries
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return SyncResponse(entries)
+}
@@ -0,0 +1,167 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/osutil/kcmdline"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// Sources of the kernel command line arguments.
+const (
+	kcmdlineSourceSnapd                  = "snapd"
+	kcmdlineSourceGadgetFull             = "gadget-full"
+	kcmdlineSourceGadgetExtra            = "gadget-extra"
+	kcmdlineSourceCmdlineAppend          = "cmdline-append"
+	kcmdlineSourceDangerousCmdlineAppend = "dangerous-cmdline-append"
+	kcmdlineSourceUnknown                = "unknown"
+)
+
+type kcmdlineArgument struct {
+	Param  string `json:"param"`
+	Value  string `json:"value,omitempty"`
+	Source string `json:"source"`
+}
+
+type kcmdlineInfo struct {
+	// Cmdline is the kernel command line the system was booted with.
+	Cmdline string `json:"cmdline"`
+	// Arguments are the parsed arguments of Cmdline, annotated with
+	// where they come from.
+	Arguments []kcmdlineArgument `json:"arguments"`
+	// Rejected are the arguments set with system.kernel.cmdline-append
+	// that are not allowed by the gadget.
+	Rejected []string `json:"rejected,omitempty"`
+}
+
+// kcmdlineArgSet is a set of kernel command line arguments, keyed by
+// their string form.
+type kcmdlineArgSet map[string]bool
+
+func newKcmdlineArgSet(cmdline string) kcmdlineArgSet {
+	set := make(kcmdlineArgSet)
+	for _, arg := range kcmdline.Parse(cmdline) {
+		set[arg.String()] = true
+	}
+	return set
+}
+
+func gadgetKernelCmdline(st *state.State) (cmdline string, full bool, allowed []kcmdline.ArgumentPattern, err error) {
+	deviceCtx, err := devicestate.DeviceCtx(st, nil, nil)
+	if err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return "", false, nil, nil
+		}
+		return "", false, nil, err
+	}
+	gadgetInfo, err := snapstate.GadgetInfo(st, deviceCtx)
+	if err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			// e.g. classic without a gadget
+			return "", false, nil, nil
+		}
+		return "", false, nil, err
+	}
+	gadgetDir := gadgetInfo.MountDir()
+
+	info, err := gadget.ReadInfo(gadgetDir, deviceCtx.Model())
+	if err != nil {
+		return "", false, nil, err
+	}
+	cmdline, full, err = gadget.KernelCommandLineFromGadget(gadgetDir)
+	if err != nil {
+		return "", false, nil, err
+	}
+	return cmdline, full, info.KernelCmdline.Allow, nil
+}
+
+func getKernelCmdlineInfo(st *state.State) Response {
+	cmdline, err := kcmdline.KernelCommandLine()
+	if err != nil {
+		return InternalError("cannot read kernel command line: %v", err)
+	}
+
+	gadgetCmdline, full, allowed, err := gadgetKernelCmdline(st)
+	if err != nil {
+		return InternalError("cannot get kernel command line from gadget: %v", err)
+	}
+	gadgetSource := kcmdlineSourceGadgetExtra
+	if full {
+		gadgetSource = kcmdlineSourceGadgetFull
+	}
+
+	var cmdlineAppend, dangerousCmdlineAppend string
+	tr := config.NewTransaction(st)
+	if err := tr.GetMaybe("core", "system.kernel.cmdline-append", &cmdlineAppend); err != nil {
+		return InternalError("cannot get system.kernel.cmdline-append: %v", err)
+	}
+	if err := tr.GetMaybe("core", "system.kernel.dangerous-cmdline-append", &dangerousCmdlineAppend); err != nil {
+		return InternalError("cannot get system.kernel.dangerous-cmdline-append: %v", err)
+	}
+
+	// the first source an argument is found in wins
+	sources := []struct {
+		name string
+		args kcmdlineArgSet
+	}{
+		{gadgetSource, newKcmdlineArgSet(gadgetCmdline)},
+		{kcmdlineSourceCmdlineAppend, newKcmdlineArgSet(cmdlineAppend)},
+		{kcmdlineSourceDangerousCmdlineAppend, newKcmdlineArgSet(dangerousCmdlineAppend)},
+	}
+
+	info := kcmdlineInfo{
+		Cmdline:   cmdline,
+		Arguments: []kcmdlineArgument{},
+	}
+	for _, arg := range kcmdline.Parse(cmdline) {
+		source := kcmdlineSourceUnknown
+		if strings.HasPrefix(arg.Param, "snapd_") {
+			source = kcmdlineSourceSnapd
+		} else {
+			for _, src := range sources {
+				if src.args[arg.String()] {
+					source = src.name
+					break
+				}
+			}
+		}
+		info.Arguments = append(info.Arguments, kcmdlineArgument{
+			Param:  arg.Param,
+			Value:  arg.Value,
+			Source: source,
+		})
+	}
+
+	matcher := kcmdline.NewMatcher(allowed)
+	for _, arg := range kcmdline.Parse(cmdlineAppend) {
+		if !matcher.Match(arg) {
+			info.Rejected = append(info.Rejected, arg.String())
+		}
+	}
+
+	return SyncResponse(info)
+}
@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/osutil/kcmdline"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+var _ = Suite(&kcmdlineDebugSuite{})
+
+type kcmdlineDebugSuite struct {
+	apiBaseSuite
+}
+
+func (s *kcmdlineDebugSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.expectOpenAccess()
+}
+
+func (s *kcmdlineDebugSuite) mockProcCmdline(c *C, cmdline string) {
+	procCmdline := filepath.Join(c.MkDir(), "cmdline")
+	c.Assert(os.WriteFile(procCmdline, []byte(cmdline), 0644), IsNil)
+	s.AddCleanup(kcmdline.MockProcCmdline(procCmdline))
+}
+
+const kcmdlineGadgetYaml = `
+volumes:
+  pc:
+    bootloader: grub
+kernel-cmdline:
+  allow:
+    - foo=*
+    - quiet
+`
+
+func (s *kcmdlineDebugSuite) getKcmdlineDebug(c *C) *daemon.KcmdlineInfo {
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=kcmdline", nil)
+	c.Assert(err, IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Type, Equals, daemon.ResponseTypeSync)
+	info, ok := rsp.Result.(daemon.KcmdlineInfo)
+	c.Assert(ok, Equals, true)
+	return &info
+}
+
+func (s *kcmdlineDebugSuite) TestKcmdlineNoGadget(c *C) {
+	s.daemonWithOverlordMock()
+	s.mockProcCmdline(c, "BOOT_IMAGE=/vmlinuz quiet splash\n")
+
+	info := s.getKcmdlineDebug(c)
+	c.Check(info, DeepEquals, &daemon.KcmdlineInfo{
+		Cmdline: "BOOT_IMAGE=/vmlinuz quiet splash",
+		Arguments: []daemon.KcmdlineArgument{
+			{Param: "BOOT_IMAGE", Value: "/vmlinuz", Source: "unknown"},
+			{Param: "quiet", Source: "unknown"},
+			{Param: "splash", Source: "unknown"},
+		},
+	})
+}
+
+func (s *kcmdlineDebugSuite) TestKcmdlineWithGadget(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	s.mockModel(st, nil)
+
+	si := &snap.SideInfo{RealName: "gadget", Revision: snap.R(1)}
+	snaptest.MockSnapWithFiles(c, "name: gadget\ntype: gadget\nversion: 1.0", si, [][]string{
+		{"meta/gadget.yaml", kcmdlineGadgetYaml},
+		{"cmdline.extra", "extra=1 panic=-1\n"},
+	})
+	snapstate.Set(st, "gadget", &snapstate.SnapState{
+		SnapType: "gadget",
+		Sequence: []*snap.SideInfo{si},
+		Current:  si.Revision,
+		Active:   true,
+	})
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "system.kernel.cmdline-append", "foo=bar bar=baz")
+	tr.Set("core", "system.kernel.dangerous-cmdline-append", "debug")
+	tr.Commit()
+	st.Unlock()
+
+	s.mockProcCmdline(c, "snapd_recovery_mode=run console=ttyS0 extra=1 panic=-1 foo=bar debug")
+
+	info := s.getKcmdlineDebug(c)
+	c.Check(info, DeepEquals, &daemon.KcmdlineInfo{
+		Cmdline: "snapd_recovery_mode=run console=ttyS0 extra=1 panic=-1 foo=bar debug",
+		Arguments: []daemon.KcmdlineArgument{
+			{Param: "snapd_recovery_mode", Value: "run", Source: "snapd"},
+			{Param: "console", Value: "ttyS0", Source: "unknown"},
+			{Param: "extra", Value: "1", Source: "gadget-extra"},
+			{Param: "panic", Value: "-1", Source: "gadget-extra"},
+			{Param: "foo", Value: "bar", Source: "cmdline-append"},
+			{Param: "debug", Source: "dangerous-cmdline-append"},
+		},
+		Rejected: []string{"bar=baz"},
+	})
+}
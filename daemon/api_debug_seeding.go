@@ -21,6 +21,8 @@ package daemon
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/overlord/state"
@@ -62,6 +64,40 @@ type seedingInfo struct {
 	// least one was in error. It is set to the error of the
 	// oldest known in error one.
 	SeedError string `json:"seed-error,omitempty"`
+
+	// FailedSeedTasks are the tasks of the seed changes that failed.
+	FailedSeedTasks []seedTaskError `json:"failed-seed-tasks,omitempty"`
+}
+
+// seedTaskError describes a failed seed task.
+type seedTaskError struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	Summary string `json:"summary"`
+	// Errors are the errors logged by the task.
+	Errors []string `json:"errors,omitempty"`
+}
+
+func failedSeedTasks(chg *state.Change) []seedTaskError {
+	var failed []seedTaskError
+	for _, t := range chg.Tasks() {
+		if t.Status() != state.ErrorStatus {
+			continue
+		}
+		taskErr := seedTaskError{
+			ID:      t.ID(),
+			Kind:    t.Kind(),
+			Summary: t.Summary(),
+		}
+		for _, msg := range t.Log() {
+			// log entries are of the form "<time> <level> <message>"
+			if i := strings.Index(msg, " ERROR "); i >= 0 {
+				taskErr.Errors = append(taskErr.Errors, msg[i+len(" ERROR "):])
+			}
+		}
+		failed = append(failed, taskErr)
+	}
+	return failed
 }
 
 func getSeedingInfo(st *state.State) Response {
@@ -84,6 +120,19 @@ func getSeedingInfo(st *state.State) Response {
 
 	var seedError string
 	var seedErrorChangeTime time.Time
+	var seedChgs []*state.Change
+	for _, chg := range st.Changes() {
+		if chg.Kind() == "seed" {
+			seedChgs = append(seedChgs, chg)
+		}
+	}
+	sort.Slice(seedChgs, func(i, j int) bool {
+		return seedChgs[i].SpawnTime().Before(seedChgs[j].SpawnTime())
+	})
+	var failedTasks []seedTaskError
+	for _, chg := range seedChgs {
+		failedTasks = append(failedTasks, failedSeedTasks(chg)...)
+	}
 	if !seeded {
 		for _, chg := range st.Changes() {
 			if chg.Kind() != "seed" && !chg.IsReady() {
@@ -104,6 +153,7 @@ func getSeedingInfo(st *state.State) Response {
 		Preseeded:            preseeded,
 		PreseedSystemKey:     preseedSysKey,
 		SeedRestartSystemKey: seedRestartSysKey,
+		FailedSeedTasks:      failedTasks,
 	}
 
 	for _, t := range []struct {
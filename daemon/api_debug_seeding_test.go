@@ -214,5 +214,9 @@ func (s *seedingDebugSuite) TestSeedingDebugPreseededSeedError(c *C) {
 		SeedRestartTime:      &seedRestartTime,
 		SeedError: `cannot perform the following tasks:
 - t12 (t12: fail)`,
+		FailedSeedTasks: []daemon.SeedTaskError{
+			{ID: t12.ID(), Kind: "seed task", Summary: "t12", Errors: []string{"t12: fail"}},
+			{ID: t21.ID(), Kind: "seed task", Summary: "t21", Errors: []string{"t21: error"}},
+		},
 	})
 }
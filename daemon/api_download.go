@@ -41,11 +41,24 @@ import (
 	"github.com/snapcore/snapd/store"
 )
 
-var snapDownloadCmd = &Command{
-	Path:        "/v2/download",
-	POST:        postSnapDownload,
-	WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
-}
+var (
+	snapDownloadCmd = &Command{
+		Path:        "/v2/download",
+		POST:        postSnapDownload,
+		WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+		Expensive:   true,
+	}
+
+	// snapDownloadNameCmd lets unprivileged local clients download
+	// snaps from the store through snapd, using its store
+	// configuration and device session.
+	snapDownloadNameCmd = &Command{
+		Path:       "/v2/download/{name}",
+		GET:        getSnapDownload,
+		ReadAccess: openAccess{},
+		Expensive:  true,
+	}
+)
 
 var validRangeRegexp = regexp.MustCompile(`^\s*bytes=(\d+)-\s*$`)
 
@@ -90,17 +103,32 @@ func postSnapDownload(c *Command, r *http.Request, user *auth.UserState) Respons
 	if decoder.More() {
 		return BadRequest("extra content found after download operation")
 	}
-	if rangestr := r.Header.Get("Range"); rangestr != "" {
-		// "An origin server MUST ignore a Range header field
-		//  that contains a range unit it does not understand."
-		subs := validRangeRegexp.FindStringSubmatch(rangestr)
-		if len(subs) == 2 {
-			n, err := strconv.ParseInt(subs[1], 10, 64)
-			if err == nil {
-				action.resumePosition = n
-			}
+	action.resumePosition = resumePosition(r)
+	if err := action.validate(); err != nil {
+		return BadRequest(err.Error())
+	}
+
+	return streamOneSnap(c, action, user)
+}
+
+func getSnapDownload(c *Command, r *http.Request, user *auth.UserState) Response {
+	query := r.URL.Query()
+	action := snapDownloadAction{
+		SnapName: muxVars(r)["name"],
+		snapRevisionOptions: snapRevisionOptions{
+			Channel:   query.Get("channel"),
+			CohortKey: query.Get("cohort-key"),
+		},
+		ResumeToken: query.Get("resume-token"),
+	}
+	if rev := query.Get("revision"); rev != "" {
+		revision, err := snap.ParseRevision(rev)
+		if err != nil {
+			return BadRequest("invalid revision %q: %v", rev, err)
 		}
+		action.Revision = revision
 	}
+	action.resumePosition = resumePosition(r)
 	if err := action.validate(); err != nil {
 		return BadRequest(err.Error())
 	}
@@ -108,6 +136,25 @@ func postSnapDownload(c *Command, r *http.Request, user *auth.UserState) Respons
 	return streamOneSnap(c, action, user)
 }
 
+// resumePosition returns the position to resume a download from as
+// requested with the Range header, or 0.
+func resumePosition(r *http.Request) int64 {
+	rangestr := r.Header.Get("Range")
+	if rangestr == "" {
+		return 0
+	}
+	// "An origin server MUST ignore a Range header field
+	//  that contains a range unit it does not understand."
+	subs := validRangeRegexp.FindStringSubmatch(rangestr)
+	if len(subs) == 2 {
+		n, err := strconv.ParseInt(subs[1], 10, 64)
+		if err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
 func streamOneSnap(c *Command, action snapDownloadAction, user *auth.UserState) Response {
 	secret, err := downloadTokensSecret(c.d)
 	if err != nil {
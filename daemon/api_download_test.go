@@ -28,6 +28,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 
 	"gopkg.in/check.v1"
@@ -415,3 +416,64 @@ func (s *snapDownloadSuite) TestStreamRangeHeaderErrors(c *check.C) {
 		c.Assert(w.Code, check.Equals, 200)
 	}
 }
+
+func (s *snapDownloadSuite) TestGetSnapDownload(c *check.C) {
+	s.expectReadAccess(daemon.OpenAccess{})
+
+	sec, err := daemon.DownloadTokensSecret(s.d)
+	c.Assert(err, check.IsNil)
+	fooResume3SS, err := daemon.NewSnapStream("foo-resume-3", storeSnaps["foo-resume-3"], sec)
+	c.Assert(err, check.IsNil)
+
+	for _, t := range []struct {
+		snapName string
+		query    url.Values
+		resume   int
+		status   int
+	}{
+		{snapName: "bar", status: 200},
+		{snapName: "edge-bar", query: url.Values{"channel": {"edge"}}, status: 200},
+		{snapName: "rev7-bar", query: url.Values{"revision": {"7"}}, status: 200},
+		{snapName: "foo-resume-3", query: url.Values{"resume-token": {fooResume3SS.Token}}, resume: 3, status: 206},
+	} {
+		req, err := http.NewRequest("GET", "/v2/download/"+t.snapName+"?"+t.query.Encode(), nil)
+		c.Assert(err, check.IsNil)
+		if t.resume != 0 {
+			req.Header.Add("Range", fmt.Sprintf("bytes=%d-", t.resume))
+		}
+
+		rsp := s.req(c, req, nil)
+		c.Assert(rsp, check.FitsTypeOf, &daemon.SnapStream{}, check.Commentf("unexpected result for %s", t.snapName))
+		ss := rsp.(*daemon.SnapStream)
+		c.Check(ss.SnapName, check.Equals, t.snapName)
+
+		w := httptest.NewRecorder()
+		ss.ServeHTTP(w, nil)
+
+		info := storeSnaps[t.snapName]
+		c.Check(w.Code, check.Equals, t.status)
+		c.Check(w.Header().Get("Content-Disposition"), check.Equals, fmt.Sprintf("attachment; filename=%s_%s.snap", t.snapName, info.Revision))
+		c.Check(w.Body.Bytes(), check.DeepEquals, []byte(snapContent)[t.resume:])
+	}
+}
+
+func (s *snapDownloadSuite) TestGetSnapDownloadErrors(c *check.C) {
+	s.expectReadAccess(daemon.OpenAccess{})
+
+	for _, t := range []struct {
+		url    string
+		status int
+		err    string
+	}{
+		{"/v2/download/doom", 404, "snap not found"},
+		{"/v2/download/bar?revision=potato", 400, `invalid revision "potato": .*`},
+		{"/v2/download/download-error-trigger-snap", 500, "error triggered by download-error-trigger-snap"},
+	} {
+		req, err := http.NewRequest("GET", t.url, nil)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, t.status, check.Commentf(t.url))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf(t.url))
+	}
+}
@@ -56,6 +56,8 @@ func searchStore(c *Command, r *http.Request, user *auth.UserState) Response {
 	category := query.Get("category")
 	name := query.Get("name")
 	scope := query.Get("scope")
+	publisher := query.Get("publisher")
+	iface := query.Get("interface")
 	private := false
 	prefix := false
 
@@ -104,6 +106,17 @@ func searchStore(c *Command, r *http.Request, user *auth.UserState) Response {
 		category = section
 	}
 
+	if iface != "" {
+		if err := snap.ValidateInterfaceName(iface); err != nil {
+			return BadRequest(err.Error())
+		}
+	}
+
+	pageOpts, apiErr := pageOptionsFromQuery(query)
+	if apiErr != nil {
+		return apiErr
+	}
+
 	theStore := storeFrom(c.d)
 	ctx := store.WithClientUserAgent(r.Context(), r)
 	found, err := theStore.Find(ctx, &store.Search{
@@ -113,6 +126,9 @@ func searchStore(c *Command, r *http.Request, user *auth.UserState) Response {
 		Category: category,
 		Private:  private,
 		Scope:    scope,
+
+		Publisher: publisher,
+		Interface: iface,
 	}, user)
 	switch err {
 	case nil:
@@ -152,12 +168,22 @@ func searchStore(c *Command, r *http.Request, user *auth.UserState) Response {
 		return InternalError("%v", err)
 	}
 
+	start, end, page := pageOpts.bounds(len(found))
 	fresp := &findResponse{
 		Sources:           []string{"store"},
 		SuggestedCurrency: theStore.SuggestedCurrency(),
+		Page:              page,
 	}
 
-	return sendStorePackages(route, found, fresp)
+	resp := sendStorePackages(route, found[start:end], fresp)
+	if fresp, ok := resp.(*findResponse); ok {
+		selected, err := pageOpts.selectFields(fresp.Results)
+		if err != nil {
+			return InternalError("cannot select snap fields: %v", err)
+		}
+		fresp.Results = selected
+	}
+	return resp
 }
 
 func findOne(c *Command, r *http.Request, user *auth.UserState, name string) Response {
@@ -257,6 +283,7 @@ type findResponse struct {
 	Results           interface{}
 	Sources           []string
 	SuggestedCurrency string
+	Page              *pageInfo
 }
 
 func (r *findResponse) JSON() *respJSON {
@@ -266,6 +293,7 @@ func (r *findResponse) JSON() *respJSON {
 		Result:            r.Results,
 		Sources:           r.Sources,
 		SuggestedCurrency: r.SuggestedCurrency,
+		Page:              r.Page,
 	}
 }
 
@@ -20,6 +20,7 @@
 package daemon_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -77,6 +78,31 @@ func (s *findSuite) TestFind(c *check.C) {
 	c.Check(s.actions, check.HasLen, 0)
 }
 
+func (s *findSuite) TestFindPaginated(c *check.C) {
+	s.daemon(c)
+
+	for _, name := range []string{"store-a", "store-b", "store-c"} {
+		s.rsnaps = append(s.rsnaps, &snap.Info{
+			SideInfo: snap.SideInfo{
+				RealName: name,
+			},
+		})
+	}
+
+	req, err := http.NewRequest("GET", "/v2/find?q=hi&limit=2&fields=name", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	c.Assert(rsp.Page, check.NotNil)
+	c.Check(rsp.Page.Offset, check.Equals, 0)
+	c.Check(rsp.Page.Limit, check.Equals, 2)
+	c.Check(rsp.Page.Total, check.Equals, 3)
+
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, check.IsNil)
+	c.Check(string(data), check.Equals, `[{"name":"store-a"},{"name":"store-b"}]`)
+}
+
 func (s *findSuite) TestFindRefreshes(c *check.C) {
 	s.daemon(c)
 
@@ -163,6 +189,34 @@ func (s *findSuite) TestFindPrivate(c *check.C) {
 	})
 }
 
+func (s *findSuite) TestFindPublisherAndInterface(c *check.C) {
+	s.daemon(c)
+
+	s.rsnaps = []*snap.Info{}
+
+	req, err := http.NewRequest("GET", "/v2/find?q=foo&publisher=canonical&interface=content", nil)
+	c.Assert(err, check.IsNil)
+
+	_ = s.syncReq(c, req, nil)
+
+	c.Check(s.storeSearch, check.DeepEquals, store.Search{
+		Query:     "foo",
+		Publisher: "canonical",
+		Interface: "content",
+	})
+}
+
+func (s *findSuite) TestFindBadInterface(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/find?q=foo&interface=Bad_Iface", nil)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Matches, `invalid interface name: "Bad_Iface"`)
+}
+
 func (s *findSuite) TestFindUserAgentContextCreated(c *check.C) {
 	s.daemon(c)
 
@@ -23,9 +23,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"os/exec"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/snapcore/snapd/arch"
@@ -36,9 +38,11 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/sandbox"
+	"github.com/snapcore/snapd/sandbox/cgroup"
 	"github.com/snapcore/snapd/snap"
 )
 
@@ -62,6 +66,8 @@ var (
 		POST:        abortChange,
 		ReadAccess:  openAccess{},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+		// aborting changes must be possible at the limit
+		NoPendingChangesLimit: true,
 	}
 
 	stateChangesCmd = &Command{
@@ -82,6 +88,8 @@ var (
 var (
 	buildID     = "unknown"
 	systemdVirt = ""
+
+	cgroupVersion = cgroup.Version
 )
 
 func init() {
@@ -113,15 +121,20 @@ func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	if err != nil {
 		return InternalError("cannot get refresh schedule: %s", err)
 	}
+	refreshStatus, err := snapMgr.AutoRefreshStatus()
+	if err != nil {
+		return InternalError("cannot get refresh status: %s", err)
+	}
 	users, err := auth.Users(st)
 	if err != nil && !errors.Is(err, state.ErrNoState) {
 		return InternalError("cannot get user auth data: %s", err)
 	}
 
 	refreshInfo := client.RefreshInfo{
-		Last: formatRefreshTime(lastRefresh),
-		Hold: formatRefreshTime(refreshHold),
-		Next: formatRefreshTime(nextRefresh),
+		Last:   formatRefreshTime(lastRefresh),
+		Hold:   formatRefreshTime(refreshHold),
+		Next:   formatRefreshTime(nextRefresh),
+		Status: refreshStatus,
 	}
 	if !legacySchedule {
 		refreshInfo.Timer = refreshScheduleStr
@@ -142,6 +155,7 @@ func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 			"snap-bin-dir":   dirs.SnapBinariesDir,
 		},
 		"refresh":      refreshInfo,
+		"reboot":       pendingReboot(st),
 		"architecture": arch.DpkgArchitecture(),
 		"system-mode":  deviceMgr.SystemMode(devicestate.SysAny),
 	}
@@ -168,6 +182,21 @@ func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(m)
 }
 
+// pendingReboot returns whether a system reboot was requested or is needed
+// to complete some changes.
+func pendingReboot(st *state.State) client.RebootInfo {
+	var info client.RebootInfo
+	switch _, rst := restart.Pending(st); rst {
+	case restart.RestartSystem, restart.RestartSystemNow:
+		info.Pending = true
+	}
+	for _, chg := range restart.ChangesPendingSystemRestart(st) {
+		info.Pending = true
+		info.Changes = append(info.Changes, chg.ID())
+	}
+	return info
+}
+
 func formatRefreshTime(t time.Time) string {
 	if t.IsZero() {
 		return ""
@@ -197,6 +226,11 @@ func sandboxFeatures(backends []interfaces.SecurityBackend) map[string][]string
 	sort.Strings(features)
 	result["confinement-options"] = features
 
+	// Add the version of cgroup in use as another fake backend
+	if version, err := cgroupVersion(); err == nil {
+		result["cgroup"] = []string{fmt.Sprintf("version:%d", version)}
+	}
+
 	return result
 }
 
@@ -258,10 +292,17 @@ func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 		}
 	}
 
+	pageOpts, apiErr := pageOptionsFromQuery(query)
+	if apiErr != nil {
+		return apiErr
+	}
+
 	state := c.d.overlord.State()
 	state.Lock()
 	defer state.Unlock()
 	chgs := state.Changes()
+	// changes are paginated in the order they were created
+	sort.Sort(byChangeID(chgs))
 	chgInfos := make([]*changeInfo, 0, len(chgs))
 	for _, chg := range chgs {
 		if !filter(chg) {
@@ -269,7 +310,34 @@ func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 		}
 		chgInfos = append(chgInfos, change2changeInfo(chg))
 	}
-	return SyncResponse(chgInfos)
+	start, end, page := pageOpts.bounds(len(chgInfos))
+	selected, err := pageOpts.selectFields(chgInfos[start:end])
+	if err != nil {
+		return InternalError("cannot select change fields: %v", err)
+	}
+	if page == nil {
+		return SyncResponse(selected)
+	}
+	return &respJSON{
+		Type:   ResponseTypeSync,
+		Status: 200,
+		Result: selected,
+		Page:   page,
+	}
+}
+
+type byChangeID []*state.Change
+
+func (b byChangeID) Len() int      { return len(b) }
+func (b byChangeID) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byChangeID) Less(i, j int) bool {
+	// change IDs are sequential numbers
+	idI, errI := strconv.Atoi(b[i].ID())
+	idJ, errJ := strconv.Atoi(b[j].ID())
+	if errI != nil || errJ != nil {
+		return b[i].ID() < b[j].ID()
+	}
+	return idI < idJ
 }
 
 func abortChange(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -291,7 +359,7 @@ func abortChange(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("cannot decode data from request body: %v", err)
 	}
 
-	if reqData.Action != "abort" {
+	if reqData.Action != "abort" && reqData.Action != "force-abort" {
 		return BadRequest("change action %q is unsupported", reqData.Action)
 	}
 
@@ -299,8 +367,16 @@ func abortChange(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("cannot abort change %s with nothing pending", chID)
 	}
 
-	// flag the change
-	chg.Abort()
+	if reqData.Action == "force-abort" {
+		// flag the change and abandon the tasks being run, the task
+		// runner needs to be entered without the state lock
+		state.Unlock()
+		c.d.overlord.TaskRunner().ForceAbort(chg)
+		state.Lock()
+	} else {
+		// flag the change
+		chg.Abort()
+	}
 
 	// actually ask to proceed with the abort
 	ensureStateSoon(state)
@@ -33,11 +33,13 @@ import (
 
 	"github.com/snapcore/snapd/arch"
 	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/sandbox"
@@ -49,6 +51,12 @@ type generalSuite struct {
 	apiBaseSuite
 }
 
+func (s *generalSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.AddCleanup(daemon.MockCgroupVersion(2, nil))
+}
+
 func (s *generalSuite) TestRoot(c *check.C) {
 	s.daemon(c)
 
@@ -126,12 +134,18 @@ func (s *generalSuite) TestSysInfo(c *check.C) {
 		"refresh": map[string]interface{}{
 			// only the "timer" field
 			"timer": "8:00~9:00/2",
+			// not seeded
+			"status": "disabled",
+		},
+		"reboot":      map[string]interface{}{"pending": false},
+		"confinement": "partial",
+		"sandbox-features": map[string]interface{}{
+			"confinement-options": []interface{}{"classic", "devmode"},
+			"cgroup":              []interface{}{"version:2"},
 		},
-		"confinement":      "partial",
-		"sandbox-features": map[string]interface{}{"confinement-options": []interface{}{"classic", "devmode"}},
-		"architecture":     arch.DpkgArchitecture(),
-		"virtualization":   "magic",
-		"system-mode":      "run",
+		"architecture":   arch.DpkgArchitecture(),
+		"virtualization": "magic",
+		"system-mode":    "run",
 	}
 	var rsp daemon.RespJSON
 	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
@@ -204,10 +218,13 @@ func (s *generalSuite) TestSysInfoLegacyRefresh(c *check.C) {
 		"refresh": map[string]interface{}{
 			// only the "schedule" field
 			"schedule": "00:00-9:00/12:00-13:00",
+			"status":   "disabled",
 		},
+		"reboot":      map[string]interface{}{"pending": false},
 		"confinement": "partial",
 		"sandbox-features": map[string]interface{}{
 			"apparmor":            []interface{}{"feature-1", "feature-2"},
+			"cgroup":              []interface{}{"version:2"},
 			"confinement-options": []interface{}{"classic", "devmode"}, // we know it's this because of the release.Mock... calls above
 		},
 		"architecture":   arch.DpkgArchitecture(),
@@ -282,11 +299,14 @@ func (s *generalSuite) testSysInfoSystemMode(c *check.C, mode string) {
 			"snap-bin-dir":   dirs.SnapBinariesDir,
 		},
 		"refresh": map[string]interface{}{
-			"timer": "00:00~24:00/4",
+			"timer":  "00:00~24:00/4",
+			"status": "disabled",
 		},
+		"reboot":      map[string]interface{}{"pending": false},
 		"confinement": "strict",
 		"sandbox-features": map[string]interface{}{
 			"apparmor":            []interface{}{"feature-1", "feature-2"},
+			"cgroup":              []interface{}{"version:2"},
 			"confinement-options": []interface{}{"devmode", "strict"}, // we know it's this because of the release.Mock... calls above
 		},
 		"architecture": arch.DpkgArchitecture(),
@@ -312,6 +332,51 @@ func (s *generalSuite) TestSysInfoSystemModeRecover(c *check.C) {
 func (s *generalSuite) TestSysInfoSystemModeInstall(c *check.C) {
 	s.testSysInfoSystemMode(c, "install")
 }
+func (s *generalSuite) TestSysInfoPendingReboot(c *check.C) {
+	d := s.daemon(c)
+
+	st := d.Overlord().State()
+	st.Lock()
+	chg := st.NewChange("refresh-snap", "...")
+	chg.Set("wait-for-system-restart", true)
+	t := st.NewTask("link-snap", "...")
+	chg.AddTask(t)
+	t.SetToWait(state.DoneStatus)
+	t.Set("wait-for-system-restart-from-boot-id", "boot-id-0")
+	restart.ReplaceBootID(st, "boot-id-0")
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/system-info", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result.(map[string]interface{})["reboot"], check.DeepEquals, client.RebootInfo{
+		Pending: true,
+		Changes: []string{chg.ID()},
+	})
+
+	st.Lock()
+	restart.MockPending(st, restart.RestartSystemNow)
+	chg.SetStatus(state.AbortStatus)
+	t.SetStatus(state.UndoneStatus)
+	st.Unlock()
+
+	rsp = s.syncReq(c, req, nil)
+	c.Check(rsp.Result.(map[string]interface{})["reboot"], check.DeepEquals, client.RebootInfo{
+		Pending: true,
+	})
+}
+
+func (s *generalSuite) TestSysInfoNoCgroupVersion(c *check.C) {
+	s.AddCleanup(daemon.MockCgroupVersion(0, fmt.Errorf("boom")))
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/system-info", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	features := rsp.Result.(map[string]interface{})["sandbox-features"].(map[string][]string)
+	c.Check(features["cgroup"], check.IsNil)
+}
+
 func (s *generalSuite) TestSysInfoIsManaged(c *check.C) {
 	d := s.daemon(c)
 
@@ -447,6 +512,47 @@ func (s *generalSuite) TestStateChangesAll(c *check.C) {
 	c.Check(string(res), check.Matches, `.*{"id":"\w+","kind":"remove","summary":"remove..","status":"Error","tasks":\[{"id":"\w+","kind":"unlink","summary":"1...","status":"Error","log":\["2016-04-21T01:02:03Z ERROR rm failed"],"progress":{"label":"","done":1,"total":1},"spawn-time":"2016-04-21T01:02:03Z","ready-time":"2016-04-21T01:02:03Z"}.*],"ready":true,"err":"[^"]+".*`)
 }
 
+func (s *generalSuite) TestStateChangesPaginated(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/changes?select=all&offset=1&limit=1&fields=id,kind", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	c.Assert(rsp.Page, check.NotNil)
+	c.Check(rsp.Page.Offset, check.Equals, 1)
+	c.Check(rsp.Page.Limit, check.Equals, 1)
+	c.Check(rsp.Page.Total, check.Equals, 2)
+
+	// changes are returned in the order they were made
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, check.IsNil)
+	c.Check(string(data), check.Equals, fmt.Sprintf(`[{"id":%q,"kind":"remove"}]`, ids[1]))
+
+	// past the end
+	req, err = http.NewRequest("GET", "/v2/changes?select=all&offset=5", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.HasLen, 0)
+	c.Check(rsp.Page.Total, check.Equals, 2)
+}
+
+func (s *generalSuite) TestStateChangesInvalidPagination(c *check.C) {
+	s.daemon(c)
+
+	for _, query := range []string{"offset=-1", "limit=many"} {
+		req, err := http.NewRequest("GET", "/v2/changes?"+query, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Matches, `invalid (offset|limit) parameter: .*`)
+	}
+}
+
 func (s *generalSuite) TestStateChangesReady(c *check.C) {
 	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
 	defer restore()
@@ -674,6 +780,43 @@ func (s *generalSuite) TestStateChangeAbort(c *check.C) {
 	})
 }
 
+func (s *generalSuite) TestStateChangeForceAbort(c *check.C) {
+	soon := 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	// Setup
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	s.expectManageAccess()
+
+	buf := bytes.NewBufferString(`{"action": "force-abort"}`)
+
+	// Execute
+	req, err := http.NewRequest("POST", "/v2/changes/"+ids[0], buf)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	// Ensure scheduled
+	c.Check(soon, check.Equals, 1)
+
+	// Verify
+	c.Check(rsp.Status, check.Equals, 200)
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(ids[0])
+	c.Check(chg.Status(), check.Equals, state.HoldStatus)
+	c.Check(chg.IsReady(), check.Equals, true)
+}
+
 func (s *generalSuite) TestStateChangeAbortIsReady(c *check.C) {
 	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
 	defer restore()
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"sort"
 	"strings"
 
@@ -41,6 +42,7 @@ var (
 		POST:        changeInterfaces,
 		ReadAccess:  openAccess{},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManageInterfaces},
+		Expensive:   true,
 	}
 )
 
@@ -147,6 +149,7 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 
 	var tasksets []*state.TaskSet
 	var affected []string
+	var dryRunConns []*interfaces.ConnRef
 
 	st := c.d.overlord.State()
 	st.Lock()
@@ -181,10 +184,13 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 		}
 	}
 
+	repo := c.d.overlord.InterfaceManager().Repository()
 	switch a.Action {
 	case "connect":
+		if strings.ContainsAny(a.Plugs[0].Name, "*?[") {
+			return connectPattern(c, st, &a)
+		}
 		var connRef *interfaces.ConnRef
-		repo := c.d.overlord.InterfaceManager().Repository()
 		connRef, err = repo.ResolveConnect(a.Plugs[0].Snap, a.Plugs[0].Name, a.Slots[0].Snap, a.Slots[0].Name)
 		if err == nil {
 			var ts *state.TaskSet
@@ -192,11 +198,15 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 			summary = fmt.Sprintf("Connect %s:%s to %s:%s", connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
 			ts, err = ifacestate.Connect(st, connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
 			if _, ok := err.(*ifacestate.ErrAlreadyConnected); ok {
+				if a.DryRun {
+					return interfacesDryRun(st, repo, nil, nil)
+				}
 				change := newChange(st, a.Action+"-snap", summary, nil, affected)
 				change.SetStatus(state.DoneStatus)
 				return AsyncResponse(nil, change.ID())
 			}
 			tasksets = append(tasksets, ts)
+			dryRunConns = []*interfaces.ConnRef{connRef}
 		}
 	case "disconnect":
 		var conns []*interfaces.ConnRef
@@ -206,7 +216,6 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 			if len(conns) == 0 {
 				return InterfacesUnchanged("nothing to do")
 			}
-			repo := c.d.overlord.InterfaceManager().Repository()
 			for _, connRef := range conns {
 				var ts *state.TaskSet
 				var conn *interfaces.Connection
@@ -229,11 +238,17 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 				tasksets = append(tasksets, ts)
 			}
 			affected = snapNamesFromConns(conns)
+			dryRunConns = conns
 		}
 	}
 	if err != nil {
 		return errToResponse(err, nil, BadRequest, "%v")
 	}
+	if a.DryRun {
+		// the task sets were created, and the conflict and policy
+		// checks done, as for the actual action
+		return interfacesDryRun(st, repo, dryRunConns, tasksets)
+	}
 
 	change := newChange(st, a.Action+"-snap", summary, tasksets, affected)
 	st.EnsureBefore(0)
@@ -241,6 +256,135 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 	return AsyncResponse(nil, change.ID())
 }
 
+// resolveConnectPattern returns the connections between the plugs of the
+// given snap whose names match the pattern and the given slot side.
+// Plugs that cannot be connected to the slot side or that are already
+// connected to it are skipped.
+func resolveConnectPattern(repo *interfaces.Repository, plugSnapName, pattern, slotSnapName, slotName string) ([]*interfaces.ConnRef, error) {
+	if plugSnapName == "" {
+		return nil, fmt.Errorf("cannot connect plugs matching %q: snap name of the plug side is required", pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("cannot connect plugs matching %q: %v", pattern, err)
+	}
+
+	var conns []*interfaces.ConnRef
+	matched := false
+	for _, plug := range repo.Plugs(plugSnapName) {
+		if ok, _ := path.Match(pattern, plug.Name); !ok {
+			continue
+		}
+		matched = true
+		connRef, err := repo.ResolveConnect(plugSnapName, plug.Name, slotSnapName, slotName)
+		if err != nil {
+			continue
+		}
+		if _, err := repo.Connection(connRef); err == nil {
+			// already connected
+			continue
+		}
+		conns = append(conns, connRef)
+	}
+	if !matched {
+		return nil, fmt.Errorf("snap %q has no plugs matching %q", plugSnapName, pattern)
+	}
+	return conns, nil
+}
+
+// connectPattern connects all the plugs matching the pattern in the plug
+// name of the action, using a separate lane for each connection so that
+// they succeed or fail independently.
+func connectPattern(c *Command, st *state.State, a *interfaceAction) Response {
+	repo := c.d.overlord.InterfaceManager().Repository()
+	plug, slot := a.Plugs[0], a.Slots[0]
+	conns, err := resolveConnectPattern(repo, plug.Snap, plug.Name, slot.Snap, slot.Name)
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+
+	slotSide := slot.Snap
+	if slot.Name != "" {
+		slotSide += ":" + slot.Name
+	}
+	if slotSide == "" {
+		slotSide = "system slots"
+	}
+	summary := fmt.Sprintf("Connect %s:%s to %s", plug.Snap, plug.Name, slotSide)
+
+	var tasksets []*state.TaskSet
+	for _, connRef := range conns {
+		ts, err := ifacestate.Connect(st, connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
+		if err != nil {
+			return errToResponse(err, nil, BadRequest, "%v")
+		}
+		ts.JoinLane(st.NewLane())
+		tasksets = append(tasksets, ts)
+	}
+	if len(tasksets) == 0 {
+		// all the matching plugs are connected already
+		return InterfacesUnchanged("nothing to do")
+	}
+	if a.DryRun {
+		return interfacesDryRun(st, repo, conns, tasksets)
+	}
+
+	change := newChange(st, a.Action+"-snap", summary, tasksets, snapNamesFromConns(conns))
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, change.ID())
+}
+
+// interfacesDryRun describes the connections an interface action would
+// change together with the security profiles that would be regenerated
+// as a result, and then discards the tasks of the action so that nothing
+// is performed.
+func interfacesDryRun(st *state.State, repo *interfaces.Repository, conns []*interfaces.ConnRef, tsets []*state.TaskSet) Response {
+	var tasks []*state.Task
+	for _, ts := range tsets {
+		tasks = append(tasks, ts.Tasks()...)
+	}
+	defer st.DiscardTasks(tasks)
+
+	result := interfacesDryRunJSON{
+		Connections: make([]connectionJSON, 0, len(conns)),
+		Profiles:    []securityProfilesJSON{},
+	}
+	for _, connRef := range conns {
+		cj := connectionJSON{
+			Plug: connRef.PlugRef,
+			Slot: connRef.SlotRef,
+		}
+		if plug := repo.Plug(connRef.PlugRef.Snap, connRef.PlugRef.Name); plug != nil {
+			cj.Interface = plug.Interface
+		}
+		result.Connections = append(result.Connections, cj)
+	}
+	if len(conns) > 0 {
+		for _, backend := range repo.Backends() {
+			result.Backends = append(result.Backends, string(backend.Name()))
+		}
+	}
+	for _, snapName := range snapNamesFromConns(conns) {
+		info, err := snapstate.CurrentInfo(st, snapName)
+		if err != nil {
+			return InternalError("cannot get information about snap %q: %v", snapName, err)
+		}
+		var tags []string
+		for _, app := range info.Apps {
+			tags = append(tags, app.SecurityTag())
+		}
+		for _, hook := range info.Hooks {
+			tags = append(tags, hook.SecurityTag())
+		}
+		sort.Strings(tags)
+		result.Profiles = append(result.Profiles, securityProfilesJSON{
+			Snap:         snapName,
+			SecurityTags: tags,
+		})
+	}
+	return SyncResponse(result)
+}
+
 func snapNamesFromConns(conns []*interfaces.ConnRef) []string {
 	m := make(map[string]bool)
 	for _, conn := range conns {
@@ -1185,3 +1185,245 @@ func (s *interfacesSuite) TestInterfacesModern(c *check.C) {
 		"type":        "sync",
 	})
 }
+
+const multiPlugConsumerYaml = `
+name: consumer
+version: 1
+apps:
+ app:
+plugs:
+ plug:
+  interface: test
+ plug2:
+  interface: test
+ other:
+  interface: different
+`
+
+func (s *interfacesSuite) postInterfaceAction(c *check.C, action *client.InterfaceAction) (*httptest.ResponseRecorder, map[string]interface{}) {
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+	var body map[string]interface{}
+	c.Check(json.Unmarshal(rec.Body.Bytes(), &body), check.IsNil)
+	return rec, body
+}
+
+func (s *interfacesSuite) TestConnectPatternSuccess(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	restore = builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "different"})
+	defer restore()
+
+	d := s.daemon(c)
+
+	s.mockSnap(c, multiPlugConsumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	rec, body := s.postInterfaceAction(c, &client.InterfaceAction{
+		Action: "connect",
+		Plugs:  []client.Plug{{Snap: "consumer", Name: "*"}},
+		Slots:  []client.Slot{{Snap: "producer"}},
+	})
+	c.Check(rec.Code, check.Equals, 202)
+	id := body["change"].(string)
+
+	st := d.Overlord().State()
+	st.Lock()
+	chg := st.Change(id)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Summary(), check.Equals, "Connect consumer:* to producer")
+	lanes := make(map[int]bool)
+	for _, t := range chg.Tasks() {
+		if t.Kind() == "connect" {
+			lanes[t.Lanes()[0]] = true
+		}
+	}
+	c.Check(lanes, check.HasLen, 2)
+	st.Unlock()
+
+	<-chg.Ready()
+
+	st.Lock()
+	err := chg.Err()
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	ifaces := repo.Interfaces()
+	c.Check(ifaces.Connections, check.DeepEquals, []*interfaces.ConnRef{{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}, {
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug2"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}})
+}
+
+func (s *interfacesSuite) TestConnectPatternSkipsConnected(c *check.C) {
+	d := s.daemon(c)
+
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "test"})
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "different"})
+	s.mockSnap(c, multiPlugConsumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	for _, plug := range []string{"plug", "plug2"} {
+		_, err := repo.Connect(&interfaces.ConnRef{
+			PlugRef: interfaces.PlugRef{Snap: "consumer", Name: plug},
+			SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+		}, nil, nil, nil, nil, nil)
+		c.Assert(err, check.IsNil)
+	}
+
+	rec, body := s.postInterfaceAction(c, &client.InterfaceAction{
+		Action: "connect",
+		Plugs:  []client.Plug{{Snap: "consumer", Name: "plug*"}},
+		Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+	})
+	c.Check(rec.Code, check.Equals, 400)
+	c.Check(body["result"], check.DeepEquals, map[string]interface{}{
+		"message": "nothing to do",
+		"kind":    "interfaces-unchanged",
+	})
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
+
+func (s *interfacesSuite) TestConnectPatternNoMatch(c *check.C) {
+	d := s.daemon(c)
+
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	rec, body := s.postInterfaceAction(c, &client.InterfaceAction{
+		Action: "connect",
+		Plugs:  []client.Plug{{Snap: "consumer", Name: "foo*"}},
+		Slots:  []client.Slot{{Snap: "producer"}},
+	})
+	c.Check(rec.Code, check.Equals, 400)
+	c.Check(body["result"], check.DeepEquals, map[string]interface{}{
+		"message": `snap "consumer" has no plugs matching "foo*"`,
+	})
+
+	rec, body = s.postInterfaceAction(c, &client.InterfaceAction{
+		Action: "connect",
+		Plugs:  []client.Plug{{Snap: "consumer", Name: "[plug"}},
+		Slots:  []client.Slot{{Snap: "producer"}},
+	})
+	c.Check(rec.Code, check.Equals, 400)
+	c.Check(body["result"], check.DeepEquals, map[string]interface{}{
+		"message": `cannot connect plugs matching "[plug": syntax error in pattern`,
+	})
+}
+
+func (s *interfacesSuite) TestConnectDryRun(c *check.C) {
+	d := s.daemon(c)
+
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "test"})
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "different"})
+	s.mockSnap(c, multiPlugConsumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	rec, body := s.postInterfaceAction(c, &client.InterfaceAction{
+		Action: "connect",
+		Plugs:  []client.Plug{{Snap: "consumer", Name: "plug*"}},
+		Slots:  []client.Slot{{Snap: "producer"}},
+		DryRun: true,
+	})
+	c.Check(rec.Code, check.Equals, 200)
+	result := body["result"].(map[string]interface{})
+	c.Check(result["connections"], check.DeepEquals, []interface{}{
+		map[string]interface{}{
+			"plug":      map[string]interface{}{"snap": "consumer", "plug": "plug"},
+			"slot":      map[string]interface{}{"snap": "producer", "slot": "slot"},
+			"interface": "test",
+		},
+		map[string]interface{}{
+			"plug":      map[string]interface{}{"snap": "consumer", "plug": "plug2"},
+			"slot":      map[string]interface{}{"snap": "producer", "slot": "slot"},
+			"interface": "test",
+		},
+	})
+	c.Check(result["profiles"], check.DeepEquals, []interface{}{
+		map[string]interface{}{"snap": "consumer", "security-tags": []interface{}{"snap.consumer.app"}},
+		map[string]interface{}{"snap": "producer", "security-tags": []interface{}{"snap.producer.app"}},
+	})
+
+	// nothing was changed
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	c.Check(st.Tasks(), check.HasLen, 0)
+	repo := d.Overlord().InterfaceManager().Repository()
+	c.Check(repo.Interfaces().Connections, check.HasLen, 0)
+}
+
+func (s *interfacesSuite) TestConnectDryRunConflict(c *check.C) {
+	d := s.daemon(c)
+
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	s.simulateConflict("consumer")
+
+	for _, plug := range []string{"plug", "plug*"} {
+		rec, body := s.postInterfaceAction(c, &client.InterfaceAction{
+			Action: "connect",
+			Plugs:  []client.Plug{{Snap: "consumer", Name: plug}},
+			Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+			DryRun: true,
+		})
+		c.Check(rec.Code, check.Equals, 409, check.Commentf(plug))
+		result := body["result"].(map[string]interface{})
+		c.Check(result["kind"], check.Equals, "snap-change-conflict", check.Commentf(plug))
+	}
+}
+
+func (s *interfacesSuite) TestDisconnectDryRun(c *check.C) {
+	d := s.daemon(c)
+
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	connRef := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}
+	_, err := repo.Connect(connRef, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	rec, body := s.postInterfaceAction(c, &client.InterfaceAction{
+		Action: "disconnect",
+		Plugs:  []client.Plug{{Snap: "consumer", Name: "plug"}},
+		Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+		DryRun: true,
+	})
+	c.Check(rec.Code, check.Equals, 200)
+	result := body["result"].(map[string]interface{})
+	c.Check(result["connections"], check.DeepEquals, []interface{}{
+		map[string]interface{}{
+			"plug":      map[string]interface{}{"snap": "consumer", "plug": "plug"},
+			"slot":      map[string]interface{}{"snap": "producer", "slot": "slot"},
+			"interface": "test",
+		},
+	})
+	c.Check(result["profiles"], check.HasLen, 2)
+
+	c.Check(repo.Interfaces().Connections, check.HasLen, 1)
+}
@@ -21,6 +21,7 @@ package daemon
 
 import (
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/snap"
 )
 
 // plugJSON aids in marshaling snap.PlugInfo into JSON.
@@ -62,6 +63,57 @@ type interfaceAction struct {
 	Forget bool       `json:"forget,omitempty"`
 	Plugs  []plugJSON `json:"plugs,omitempty"`
 	Slots  []slotJSON `json:"slots,omitempty"`
+	DryRun bool       `json:"dry-run,omitempty"`
+}
+
+// interfacesDryRunJSON describes the effects an interface action would
+// have without performing it.
+type interfacesDryRunJSON struct {
+	Connections []connectionJSON       `json:"connections"`
+	Backends    []string               `json:"backends,omitempty"`
+	Profiles    []securityProfilesJSON `json:"profiles"`
+}
+
+// snapActionPlanJSON describes the tasks a snap action would run and
+// their effects without performing it.
+type snapActionPlanJSON struct {
+	Tasks        []plannedTaskJSON        `json:"tasks"`
+	Downloads    []plannedDownloadJSON    `json:"downloads,omitempty"`
+	DownloadSize int64                    `json:"download-size"`
+	Connections  []plannedConnectionsJSON `json:"connections,omitempty"`
+}
+
+// plannedTaskJSON describes a task of a snap action plan, the IDs are
+// only meaningful within the plan.
+type plannedTaskJSON struct {
+	ID        string   `json:"id"`
+	Kind      string   `json:"kind"`
+	Summary   string   `json:"summary"`
+	WaitTasks []string `json:"wait-tasks,omitempty"`
+}
+
+// plannedDownloadJSON describes a snap a snap action would download.
+type plannedDownloadJSON struct {
+	Snap     string        `json:"snap"`
+	Revision snap.Revision `json:"revision"`
+	Channel  string        `json:"channel,omitempty"`
+	Size     int64         `json:"size"`
+}
+
+// plannedConnectionsJSON describes how a snap action would affect the
+// connections of a snap. The connections that auto-connect would
+// establish depend on the installed revision so only the removed ones
+// are listed.
+type plannedConnectionsJSON struct {
+	Snap        string           `json:"snap"`
+	Action      string           `json:"action"`
+	Connections []connectionJSON `json:"connections,omitempty"`
+}
+
+// securityProfilesJSON lists the security profiles of a snap.
+type securityProfilesJSON struct {
+	Snap         string   `json:"snap"`
+	SecurityTags []string `json:"security-tags"`
 }
 
 // connectionsJSON aids in marshalling information about a single connection
@@ -88,4 +140,16 @@ type connectionsJSON struct {
 	Undesired   []connectionJSON `json:"undesired,omitempty"`
 	Plugs       []*plugJSON      `json:"plugs"`
 	Slots       []*slotJSON      `json:"slots"`
+	// Problems lists the plugs that were not connected automatically
+	// and why, when requested.
+	Problems []autoConnectProblemJSON `json:"problems,omitempty"`
+}
+
+// autoConnectProblemJSON describes why a plug was not connected
+// automatically.
+type autoConnectProblemJSON struct {
+	Plug       interfaces.PlugRef   `json:"plug"`
+	Interface  string               `json:"interface"`
+	Candidates []interfaces.SlotRef `json:"candidates,omitempty"`
+	Reason     string               `json:"reason"`
 }
@@ -0,0 +1,81 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+var metricsCmd = &Command{
+	Path:       "/v2/metrics",
+	GET:        getMetrics,
+	ReadAccess: openAccess{},
+}
+
+var (
+	apiRequests = metrics.NewCounter("snapd_api_requests_total",
+		"Number of API requests by method, path and status code.",
+		"method", "path", "code")
+	apiRequestDuration = metrics.NewHistogram("snapd_api_request_duration_seconds",
+		"Time taken to serve API requests by method and path.",
+		metrics.DefaultDurationBuckets, "method", "path")
+)
+
+// observeAPIRequest records the outcome of a request served by the command.
+func (c *Command) observeAPIRequest(method string, status int, duration time.Duration) {
+	path := c.Path
+	if path == "" {
+		path = c.PathPrefix
+	}
+	if status == 0 {
+		// nothing called WriteHeader explicitly
+		status = http.StatusOK
+	}
+	apiRequests.Inc(method, path, strconv.Itoa(status))
+	apiRequestDuration.Observe(duration.Seconds(), method, path)
+}
+
+// metricsResponse serves the metrics in the Prometheus text exposition
+// format.
+type metricsResponse struct{}
+
+func (metricsResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := metrics.WriteTo(&buf); err != nil {
+		InternalError("cannot write metrics: %v", err).ServeHTTP(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.Debugf("cannot write metrics response: %v", err)
+	}
+}
+
+func getMetrics(c *Command, r *http.Request, user *auth.UserState) Response {
+	return metricsResponse{}
+}
@@ -0,0 +1,134 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	noticesCmd = &Command{
+		Path:       "/v2/notices",
+		GET:        getNotices,
+		ReadAccess: openAccess{},
+	}
+
+	noticeCmd = &Command{
+		Path:       "/v2/notices/{id}",
+		GET:        getNotice,
+		ReadAccess: openAccess{},
+	}
+)
+
+// maxNoticesTimeout is the longest time a client can wait for notices,
+// clients wanting to wait longer can repeat the request.
+const maxNoticesTimeout = 10 * time.Minute
+
+func getNotices(c *Command, r *http.Request, _ *auth.UserState) Response {
+	query := r.URL.Query()
+
+	filter := &state.NoticeFilter{}
+	for _, t := range strutil.CommaSeparatedList(query.Get("types")) {
+		noticeType := state.NoticeType(t)
+		if !noticeType.Valid() {
+			return BadRequest("invalid notice type %q", t)
+		}
+		filter.Types = append(filter.Types, noticeType)
+	}
+	filter.Keys = strutil.CommaSeparatedList(query.Get("keys"))
+
+	if after := query.Get("after"); after != "" {
+		t, err := time.Parse(time.RFC3339Nano, after)
+		if err != nil {
+			return BadRequest("invalid after timestamp %q: %v", after, err)
+		}
+		filter.After = t
+	}
+
+	var timeout time.Duration
+	if s := query.Get("timeout"); s != "" {
+		var err error
+		timeout, err = time.ParseDuration(s)
+		if err != nil || timeout < 0 {
+			return BadRequest("invalid timeout %q", s)
+		}
+		if timeout > maxNoticesTimeout {
+			timeout = maxNoticesTimeout
+		}
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var notices []*state.Notice
+	if timeout == 0 {
+		notices = st.Notices(filter)
+	} else {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		// stop waiting when the daemon is going down
+		go func() {
+			select {
+			case <-c.d.Dying():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		var err error
+		notices, err = st.WaitNotices(ctx, filter)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			// no notices in time
+		case errors.Is(err, context.Canceled):
+			return BadRequest("request canceled")
+		case err != nil:
+			return InternalError("cannot wait for notices: %v", err)
+		}
+	}
+
+	if len(notices) == 0 {
+		// no need to confuse the issue
+		return SyncResponse([]*state.Notice{})
+	}
+	return SyncResponse(notices)
+}
+
+func getNotice(c *Command, r *http.Request, _ *auth.UserState) Response {
+	noticeID := muxVars(r)["id"]
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	notice := st.Notice(noticeID)
+	if notice == nil {
+		return NotFound("cannot find notice with ID %q", noticeID)
+	}
+	return SyncResponse(notice)
+}
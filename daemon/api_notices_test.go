@@ -0,0 +1,163 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = Suite(&noticesSuite{})
+
+type noticesSuite struct {
+	apiBaseSuite
+}
+
+func (s *noticesSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.daemonWithOverlordMock()
+}
+
+func (s *noticesSuite) addNotice(c *C, noticeType state.NoticeType, key string, t time.Time) string {
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	id, err := st.AddNotice(noticeType, key, &state.AddNoticeOptions{Time: t})
+	c.Assert(err, IsNil)
+	return id
+}
+
+func (s *noticesSuite) getNotices(c *C, query url.Values) []map[string]interface{} {
+	req, err := http.NewRequest("GET", "/v2/notices?"+query.Encode(), nil)
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, Equals, 200)
+
+	// round-trip through JSON like a client would see it
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, IsNil)
+	var notices []map[string]interface{}
+	c.Assert(json.Unmarshal(data, &notices), IsNil)
+	return notices
+}
+
+func noticeKeys(notices []map[string]interface{}) []string {
+	keys := make([]string, 0, len(notices))
+	for _, n := range notices {
+		keys = append(keys, n["key"].(string))
+	}
+	return keys
+}
+
+func (s *noticesSuite) TestNoticesNone(c *C) {
+	req, err := http.NewRequest("GET", "/v2/notices", nil)
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 200)
+	c.Check(rsp.Result, DeepEquals, []*state.Notice{})
+}
+
+func (s *noticesSuite) TestNoticesFilters(c *C) {
+	now := time.Now()
+	s.addNotice(c, state.ChangeUpdateNotice, "1", now.Add(-3*time.Minute))
+	s.addNotice(c, state.WarningNotice, "danger", now.Add(-2*time.Minute))
+	s.addNotice(c, state.MaintenanceNotice, "daemon-restart", now.Add(-time.Minute))
+
+	notices := s.getNotices(c, nil)
+	c.Check(noticeKeys(notices), DeepEquals, []string{"1", "danger", "daemon-restart"})
+	c.Check(notices[0]["type"], Equals, "change-update")
+	c.Check(notices[0]["occurrences"], Equals, 1.0)
+
+	notices = s.getNotices(c, url.Values{"types": {"warning,maintenance"}})
+	c.Check(noticeKeys(notices), DeepEquals, []string{"danger", "daemon-restart"})
+
+	notices = s.getNotices(c, url.Values{"keys": {"1,daemon-restart"}})
+	c.Check(noticeKeys(notices), DeepEquals, []string{"1", "daemon-restart"})
+
+	after := now.Add(-2 * time.Minute).UTC().Format(time.RFC3339Nano)
+	notices = s.getNotices(c, url.Values{"after": {after}})
+	c.Check(noticeKeys(notices), DeepEquals, []string{"daemon-restart"})
+}
+
+func (s *noticesSuite) TestNoticesInvalid(c *C) {
+	for _, t := range []struct {
+		query url.Values
+		err   string
+	}{
+		{url.Values{"types": {"foo"}}, `invalid notice type "foo"`},
+		{url.Values{"after": {"yesterday"}}, `invalid after timestamp "yesterday": .*`},
+		{url.Values{"timeout": {"forever"}}, `invalid timeout "forever"`},
+		{url.Values{"timeout": {"-1s"}}, `invalid timeout "-1s"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/notices?"+t.query.Encode(), nil)
+		c.Assert(err, IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, Equals, 400)
+		c.Check(rspe.Message, Matches, t.err)
+	}
+}
+
+func (s *noticesSuite) TestNoticesWaitTimeout(c *C) {
+	start := time.Now()
+	notices := s.getNotices(c, url.Values{"timeout": {"10ms"}})
+	c.Check(notices, HasLen, 0)
+	c.Check(time.Since(start) >= 10*time.Millisecond, Equals, true)
+}
+
+func (s *noticesSuite) TestNoticesWaitNew(c *C) {
+	s.addNotice(c, state.WarningNotice, "old", time.Now().Add(-time.Minute))
+	after := time.Now().UTC().Format(time.RFC3339Nano)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.addNotice(c, state.ChangeUpdateNotice, "42", time.Time{})
+	}()
+
+	notices := s.getNotices(c, url.Values{
+		"after":   {after},
+		"timeout": {"5s"},
+	})
+	c.Check(noticeKeys(notices), DeepEquals, []string{"42"})
+}
+
+func (s *noticesSuite) TestNotice(c *C) {
+	id := s.addNotice(c, state.WarningNotice, "danger", time.Time{})
+
+	req, err := http.NewRequest("GET", "/v2/notices/"+id, nil)
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 200)
+	notice, ok := rsp.Result.(*state.Notice)
+	c.Assert(ok, Equals, true)
+	c.Check(notice.ID(), Equals, id)
+	c.Check(notice.Key(), Equals, "danger")
+
+	req, err = http.NewRequest("GET", "/v2/notices/1234", nil)
+	c.Assert(err, IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, Equals, 404)
+	c.Check(rspe.Message, Equals, `cannot find notice with ID "1234"`)
+}
@@ -129,6 +129,13 @@ func sideloadOrTrySnap(c *Command, body io.ReadCloser, boundary string, user *au
 		return InternalError("cannot find route for change")
 	}
 
+	// installing snaps regenerates security profiles
+	release, rspe := c.acquireOperation()
+	if rspe != nil {
+		return rspe
+	}
+	defer release()
+
 	// POSTs to sideload snaps must be a multipart/form-data file upload.
 	mpReader := multipart.NewReader(body, boundary)
 	form, errRsp := readForm(mpReader)
@@ -201,6 +208,7 @@ func sideloadOrTrySnap(c *Command, body io.ReadCloser, boundary string, user *au
 	}
 
 	chg.Set("system-restart-immediate", isTrue(form, "system-restart-immediate"))
+	chg.Set(expensiveChangeKey, true)
 
 	ensureStateSoon(st)
 
@@ -490,6 +498,7 @@ func trySnap(st *state.State, trydir string, flags snapstate.Flags) Response {
 	msg := fmt.Sprintf(i18n.G("Try %q snap from %s"), info.InstanceName(), trydir)
 	chg := newChange(st, "try-snap", msg, []*state.TaskSet{tset}, []string{info.InstanceName()})
 	chg.Set("api-data", map[string]string{"snap-name": info.InstanceName()})
+	chg.Set(expensiveChangeKey, true)
 
 	ensureStateSoon(st)
 
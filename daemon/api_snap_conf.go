@@ -28,6 +28,7 @@ import (
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
@@ -39,7 +40,7 @@ var (
 		GET:         getSnapConf,
 		PUT:         setSnapConf,
 		ReadAccess:  authenticatedAccess{},
-		WriteAccess: authenticatedAccess{},
+		WriteAccess: authenticatedAccess{Polkit: polkitActionManageConfiguration},
 	}
 )
 
@@ -100,11 +101,23 @@ func setSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("cannot decode request body into patch values: %v", err)
 	}
 
+	var flags int
+	switch r.URL.Query().Get("revert-on-failure") {
+	case "", "false":
+	case "true":
+		if snapName == "core" {
+			return BadRequest("cannot revert the system configuration on failure")
+		}
+		flags |= snapstate.RevertConfigOnFailure
+	default:
+		return BadRequest("invalid value for revert-on-failure: %q", r.URL.Query().Get("revert-on-failure"))
+	}
+
 	st := c.d.overlord.State()
 	st.Lock()
 	defer st.Unlock()
 
-	taskset, err := configstate.ConfigureInstalled(st, snapName, patchValues, 0)
+	taskset, err := configstate.ConfigureInstalled(st, snapName, patchValues, flags)
 	if err != nil {
 		// TODO: just return snap-not-installed instead ?
 		if _, ok := err.(*snap.NotInstalledError); ok {
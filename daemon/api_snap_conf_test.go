@@ -30,6 +30,7 @@ import (
 
 	"gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/testutil"
@@ -44,7 +45,8 @@ type snapConfSuite struct {
 func (s *snapConfSuite) SetUpTest(c *check.C) {
 	s.apiBaseSuite.SetUpTest(c)
 
-	s.expectAuthenticatedAccess()
+	s.expectReadAccess(daemon.AuthenticatedAccess{})
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage-configuration"})
 }
 
 func (s *snapConfSuite) runGetConf(c *check.C, snapName string, keys []string, statusCode int) map[string]interface{} {
@@ -335,3 +337,79 @@ func (s *snapConfSuite) TestSetConfChangeConflict(c *check.C) {
 		},
 		"type": "error"})
 }
+
+func (s *snapConfSuite) TestSetConfRevertOnFailure(c *check.C) {
+	d := s.daemon(c)
+	s.mockSnap(c, configYaml)
+
+	// Mock the hook runner
+	hookRunner := testutil.MockCommand(c, "snap", "")
+	defer hookRunner.Restore()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	buffer := bytes.NewBufferString(`{"key": "value"}`)
+	req, err := http.NewRequest("PUT", "/v2/snaps/config-snap/conf?revert-on-failure=true", buffer)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.asyncReq(c, req, nil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	var hookContext map[string]interface{}
+	c.Assert(tasks[0].Get("hook-context", &hookContext), check.IsNil)
+	c.Check(hookContext["revert-on-failure"], check.Equals, true)
+}
+
+func (s *snapConfSuite) TestSetConfNoRevertOnFailureByDefault(c *check.C) {
+	d := s.daemon(c)
+	s.mockSnap(c, configYaml)
+
+	// Mock the hook runner
+	hookRunner := testutil.MockCommand(c, "snap", "")
+	defer hookRunner.Restore()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	buffer := bytes.NewBufferString(`{"key": "value"}`)
+	req, err := http.NewRequest("PUT", "/v2/snaps/config-snap/conf", buffer)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.asyncReq(c, req, nil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	var hookContext map[string]interface{}
+	c.Assert(tasks[0].Get("hook-context", &hookContext), check.IsNil)
+	c.Check(hookContext["revert-on-failure"], check.IsNil)
+}
+
+func (s *snapConfSuite) TestSetConfRevertOnFailureErrors(c *check.C) {
+	s.daemon(c)
+	s.mockSnap(c, configYaml)
+
+	for _, t := range []struct {
+		url, err string
+	}{
+		{"/v2/snaps/config-snap/conf?revert-on-failure=maybe", `invalid value for revert-on-failure: "maybe"`},
+		{"/v2/snaps/system/conf?revert-on-failure=true", `cannot revert the system configuration on failure`},
+	} {
+		req, err := http.NewRequest("PUT", t.url, bytes.NewBufferString(`{"key": "value"}`))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, t.err)
+	}
+}
@@ -0,0 +1,143 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var snapSizesCmd = &Command{
+	Path:       "/v2/snap-sizes",
+	GET:        getSnapSizes,
+	ReadAccess: openAccess{},
+}
+
+var (
+	osutilDirSize = osutil.DirSize
+
+	// snapSizesMu makes requests walk the data directories one at a
+	// time, as the endpoint is open to everyone
+	snapSizesMu sync.Mutex
+)
+
+func getSnapSizes(c *Command, r *http.Request, user *auth.UserState) Response {
+	names := strutil.CommaSeparatedList(r.URL.Query().Get("snaps"))
+
+	st := c.d.overlord.State()
+	st.Lock()
+	names, revisions, snapshotSizes, rsp := snapSizesInputs(st, names)
+	st.Unlock()
+	if rsp != nil {
+		return rsp
+	}
+
+	// walking the data directories can take a while, it is done
+	// without holding the state lock
+	snapSizesMu.Lock()
+	defer snapSizesMu.Unlock()
+
+	result := make([]*client.SnapSizes, 0, len(names))
+	for _, name := range names {
+		sizes, err := snapSizes(name, revisions[name])
+		if err != nil {
+			return InternalError("cannot get sizes of snap %q: %v", name, err)
+		}
+		sizes.SnapshotSize = snapshotSizes[name]
+		result = append(result, sizes)
+	}
+	return SyncResponse(result)
+}
+
+// snapSizesInputs returns the given snaps, or all snaps sorted if none is
+// given, their revisions kept on the system and the size of their
+// snapshots. The state must be locked.
+func snapSizesInputs(st *state.State, names []string) (snapNames []string, revisions map[string][]snap.Revision, snapshotSizes map[string]uint64, rsp Response) {
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, nil, nil, InternalError("cannot list local snaps: %v", err)
+	}
+	if len(names) == 0 {
+		for name := range snapStates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	revisions = make(map[string][]snap.Revision, len(names))
+	for _, name := range names {
+		snapst := snapStates[name]
+		if snapst == nil {
+			return nil, nil, nil, errToResponse(&snap.NotInstalledError{Snap: name}, []string{name}, InternalError, "cannot get snap sizes: %v")
+		}
+		for _, si := range snapst.Sequence {
+			revisions[name] = append(revisions[name], si.Revision)
+		}
+	}
+
+	sets, err := snapshotList(context.TODO(), st, 0, names)
+	if err != nil {
+		return nil, nil, nil, InternalError("cannot list snapshots: %v", err)
+	}
+	snapshotSizes = make(map[string]uint64, len(names))
+	for _, set := range sets {
+		for _, sh := range set.Snapshots {
+			snapshotSizes[sh.Snap] += uint64(sh.Size)
+		}
+	}
+	return names, revisions, snapshotSizes, nil
+}
+
+// snapSizes computes the disk usage of the given revisions of the snap
+// kept on the system, and of its system data.
+func snapSizes(instanceName string, revisions []snap.Revision) (*client.SnapSizes, error) {
+	sizes := &client.SnapSizes{Snap: instanceName}
+	for _, rev := range revisions {
+		fi, err := os.Stat(snap.MountFile(instanceName, rev))
+		if err == nil {
+			sizes.BlobSize += uint64(fi.Size())
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		sz, err := osutilDirSize(snap.DataDir(instanceName, rev))
+		if err != nil {
+			return nil, err
+		}
+		sizes.DataSize += sz
+	}
+
+	sz, err := osutilDirSize(snap.CommonDataDir(instanceName))
+	if err != nil {
+		return nil, err
+	}
+	sizes.CommonDataSize = sz
+	return sizes, nil
+}
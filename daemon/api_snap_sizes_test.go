@@ -0,0 +1,155 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = check.Suite(&snapSizesSuite{})
+
+type snapSizesSuite struct {
+	apiBaseSuite
+}
+
+func (s *snapSizesSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.expectOpenAccess()
+}
+
+func writeSized(c *check.C, path string, size int) {
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), check.IsNil)
+	c.Assert(os.WriteFile(path, make([]byte, size), 0644), check.IsNil)
+}
+
+func (s *snapSizesSuite) TestSnapSizes(c *check.C) {
+	d := s.daemon(c)
+
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), false, "")
+	s.mkInstalledInState(c, d, "foo", "", "v2", snap.R(2), true, "")
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(7), true, "")
+
+	writeSized(c, snap.MountFile("foo", snap.R(1)), 100)
+	writeSized(c, snap.MountFile("foo", snap.R(2)), 200)
+	writeSized(c, filepath.Join(snap.DataDir("foo", snap.R(1)), "a"), 10)
+	writeSized(c, filepath.Join(snap.DataDir("foo", snap.R(2)), "sub", "b"), 20)
+	writeSized(c, filepath.Join(snap.CommonDataDir("foo"), "c"), 5)
+	// no blob for bar, e.g. snap try
+	c.Assert(os.Remove(snap.MountFile("bar", snap.R(7))), check.IsNil)
+
+	var listedSnaps []string
+	defer daemon.MockSnapshotList(func(_ context.Context, _ *state.State, setID uint64, snaps []string) ([]client.SnapshotSet, error) {
+		c.Check(setID, check.Equals, uint64(0))
+		listedSnaps = snaps
+		return []client.SnapshotSet{
+			{ID: 1, Snapshots: []*client.Snapshot{{Snap: "foo", Size: 1000}, {Snap: "bar", Size: 3}}},
+			{ID: 2, Snapshots: []*client.Snapshot{{Snap: "foo", Size: 2000}}},
+		}, nil
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/snap-sizes", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+
+	c.Check(listedSnaps, check.DeepEquals, []string{"bar", "foo"})
+	c.Check(rsp.Result, check.DeepEquals, []*client.SnapSizes{
+		{Snap: "bar", SnapshotSize: 3},
+		{Snap: "foo", BlobSize: 300, DataSize: 30, CommonDataSize: 5, SnapshotSize: 3000},
+	})
+}
+
+func (s *snapSizesSuite) TestSnapSizesSomeSnaps(c *check.C) {
+	d := s.daemon(c)
+
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(7), true, "")
+	writeSized(c, filepath.Join(dirs.SnapBlobDir, "foo_1.snap"), 100)
+
+	defer daemon.MockSnapshotList(func(_ context.Context, _ *state.State, setID uint64, snaps []string) ([]client.SnapshotSet, error) {
+		c.Check(snaps, check.DeepEquals, []string{"foo"})
+		return nil, nil
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/snap-sizes?snaps=foo", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, []*client.SnapSizes{
+		{Snap: "foo", BlobSize: 100},
+	})
+}
+
+func (s *snapSizesSuite) TestSnapSizesWithoutStateLock(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "")
+
+	defer daemon.MockSnapshotList(func(context.Context, *state.State, uint64, []string) ([]client.SnapshotSet, error) {
+		return nil, nil
+	})()
+	st := d.Overlord().State()
+	walked := 0
+	defer daemon.MockOsutilDirSize(func(path string) (uint64, error) {
+		walked++
+		// the state is not locked while walking the directories
+		locked := make(chan bool)
+		go func() {
+			st.Lock()
+			st.Unlock()
+			close(locked)
+		}()
+		select {
+		case <-locked:
+		case <-time.After(5 * time.Second):
+			c.Fatalf("state locked while computing sizes")
+		}
+		return 10, nil
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/snap-sizes?snaps=foo", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, []*client.SnapSizes{
+		{Snap: "foo", BlobSize: 12, DataSize: 10, CommonDataSize: 10},
+	})
+	c.Check(walked, check.Equals, 2)
+}
+
+func (s *snapSizesSuite) TestSnapSizesNotInstalled(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "")
+
+	req, err := http.NewRequest("GET", "/v2/snap-sizes?snaps=foo,baz", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapNotInstalled)
+}
@@ -36,6 +36,10 @@ var (
 		Path:        "/v2/snapctl",
 		POST:        runSnapctl,
 		WriteAccess: snapAccess{},
+		// hooks run as part of changes that are already pending
+		NoPendingChangesLimit: true,
+		// hooks and apps call snapctl too often to audit it
+		NoAudit: true,
 	}
 )
 
@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"mime"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -124,6 +125,15 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 	inst.ctx = r.Context()
 
+	expensive := inst.expensive()
+	if expensive {
+		release, rspe := c.acquireOperation()
+		if rspe != nil {
+			return rspe
+		}
+		defer release()
+	}
+
 	st := c.d.overlord.State()
 	st.Lock()
 	defer st.Unlock()
@@ -149,10 +159,17 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 		return inst.errToResponse(err)
 	}
 
+	if inst.DryRun {
+		return snapActionDryRun(c, st, tsets)
+	}
+
 	chg := newChange(st, inst.Action+"-snap", msg, tsets, inst.Snaps)
 	if len(tsets) == 0 {
 		chg.SetStatus(state.DoneStatus)
 	}
+	if expensive {
+		chg.Set(expensiveChangeKey, true)
+	}
 
 	if inst.SystemRestartImmediate {
 		chg.Set("system-restart-immediate", true)
@@ -204,6 +221,8 @@ type snapInstruction struct {
 	Unaliased              bool                             `json:"unaliased"`
 	Prefer                 bool                             `json:"prefer"`
 	Purge                  bool                             `json:"purge,omitempty"`
+	Terminate              bool                             `json:"terminate,omitempty"`
+	DataOnly               bool                             `json:"data-only"`
 	SystemRestartImmediate bool                             `json:"system-restart-immediate"`
 	Transaction            client.TransactionType           `json:"transaction"`
 	Snaps                  []string                         `json:"snaps"`
@@ -213,6 +232,9 @@ type snapInstruction struct {
 	QuotaGroupName         string                           `json:"quota-group"`
 	Time                   string                           `json:"time"`
 	HoldLevel              string                           `json:"hold-level"`
+	Actions                []*batchAction                   `json:"actions"`
+	DryRun                 bool                             `json:"dry-run,omitempty"`
+	QueueOnConflict        bool                             `json:"queue-on-conflict,omitempty"`
 
 	// The fields below should not be unmarshalled into. Do not export them.
 	userID int
@@ -250,6 +272,7 @@ func (inst *snapInstruction) installFlags() (snapstate.Flags, error) {
 		flags.Prefer = true
 	}
 	flags.QuotaGroupName = inst.QuotaGroupName
+	flags.QueueOnConflict = inst.QueueOnConflict
 
 	return flags, nil
 }
@@ -325,7 +348,7 @@ func (inst *snapInstruction) validate() error {
 	switch inst.Transaction {
 	case "":
 	case client.TransactionPerSnap, client.TransactionAllSnaps:
-		if inst.Action != "install" && inst.Action != "refresh" {
+		if inst.Action != "install" && inst.Action != "refresh" && inst.Action != "batch" {
 			return fmt.Errorf(`transaction type is unsupported for %q actions`, inst.Action)
 		}
 	default:
@@ -334,6 +357,9 @@ func (inst *snapInstruction) validate() error {
 	if inst.QuotaGroupName != "" && inst.Action != "install" {
 		return fmt.Errorf("quota-group can only be specified on install")
 	}
+	if inst.QueueOnConflict && inst.Action != "install" && inst.Action != "refresh" {
+		return fmt.Errorf("queue-on-conflict can only be specified for install or refresh")
+	}
 
 	if inst.Action == "hold" {
 		if inst.Time == "" {
@@ -365,6 +391,26 @@ func (inst *snapInstruction) validate() error {
 	if inst.Prefer && inst.Action != "install" {
 		return fmt.Errorf("the prefer flag can only be specified on install")
 	}
+	if inst.DataOnly && inst.Action != "revert" {
+		return fmt.Errorf("the data-only flag can only be specified on revert")
+	}
+	if inst.Terminate && inst.Action != "remove" {
+		return fmt.Errorf("the terminate flag can only be specified on remove")
+	}
+	if len(inst.Actions) != 0 && inst.Action != "batch" {
+		return fmt.Errorf("actions can only be specified for a batch")
+	}
+	// the other actions change the state while planning
+	if inst.DryRun {
+		switch inst.Action {
+		case "install", "refresh", "remove", "revert", "switch", "enable", "disable":
+		default:
+			return fmt.Errorf("dry-run can only be specified for install, refresh, remove, revert, switch, enable or disable")
+		}
+		if len(inst.ValidationSets) > 0 {
+			return fmt.Errorf("dry-run cannot be specified when enforcing validation sets")
+		}
+	}
 
 	if err := inst.validateSnapshotOptions(); err != nil {
 		return err
@@ -456,10 +502,14 @@ func snapUpdate(inst *snapInstruction, st *state.State) (string, []*state.TaskSe
 	if inst.Amend {
 		flags.Amend = true
 	}
+	flags.QueueOnConflict = inst.QueueOnConflict
 
-	// we need refreshed snap-declarations to enforce refresh-control as best as we can
-	if err = assertstateRefreshSnapAssertions(st, inst.userID, nil); err != nil {
-		return "", nil, err
+	// we need refreshed snap-declarations to enforce refresh-control as best as we can,
+	// but a dry run must not change the assertion database
+	if !inst.DryRun {
+		if err = assertstateRefreshSnapAssertions(st, inst.userID, nil); err != nil {
+			return "", nil, err
+		}
 	}
 
 	ts, err := snapstateUpdate(st, inst.Snaps[0], inst.revnoOpts(), inst.userID, flags)
@@ -476,7 +526,7 @@ func snapUpdate(inst *snapInstruction, st *state.State) (string, []*state.TaskSe
 }
 
 func snapRemove(inst *snapInstruction, st *state.State) (string, []*state.TaskSet, error) {
-	ts, err := snapstate.Remove(st, inst.Snaps[0], inst.Revision, &snapstate.RemoveFlags{Purge: inst.Purge})
+	ts, err := snapstate.Remove(st, inst.Snaps[0], inst.Revision, &snapstate.RemoveFlags{Purge: inst.Purge, Terminate: inst.Terminate})
 	if err != nil {
 		return "", nil, err
 	}
@@ -486,6 +536,10 @@ func snapRemove(inst *snapInstruction, st *state.State) (string, []*state.TaskSe
 }
 
 func snapRevert(inst *snapInstruction, st *state.State) (string, []*state.TaskSet, error) {
+	if inst.DataOnly {
+		return snapRevertData(inst, st)
+	}
+
 	var ts *state.TaskSet
 
 	flags, err := inst.modeFlags()
@@ -506,6 +560,20 @@ func snapRevert(inst *snapInstruction, st *state.State) (string, []*state.TaskSe
 	return msg, []*state.TaskSet{ts}, nil
 }
 
+func snapRevertData(inst *snapInstruction, st *state.State) (string, []*state.TaskSet, error) {
+	if inst.DevMode || inst.JailMode || inst.Classic {
+		return "", nil, errors.New("cannot change confinement mode when reverting data only")
+	}
+
+	setID, ts, err := snapshotRevertData(st, inst.Snaps[0], inst.Revision, inst.Users)
+	if err != nil {
+		return "", nil, err
+	}
+
+	msg := fmt.Sprintf(i18n.G("Revert data of %q snap from snapshot set #%d"), inst.Snaps[0], setID)
+	return msg, []*state.TaskSet{ts}, nil
+}
+
 func snapEnable(inst *snapInstruction, st *state.State) (string, []*state.TaskSet, error) {
 	if !inst.Revision.Unset() {
 		return "", nil, errors.New("enable takes no revision")
@@ -598,6 +666,26 @@ func (inst *snapInstruction) dispatch() snapActionFunc {
 	return snapInstructionDispTable[inst.Action]
 }
 
+// expensive returns whether the instruction downloads snaps or regenerates
+// security profiles, so that it counts against the limit of concurrent
+// operations.
+func (inst *snapInstruction) expensive() bool {
+	if inst.DryRun {
+		return false
+	}
+	switch inst.Action {
+	case "install", "refresh", "revert":
+		return true
+	case "batch":
+		for _, action := range inst.Actions {
+			if action.Action != "remove" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (inst *snapInstruction) errToResponse(err error) *apiError {
 	if len(inst.Snaps) == 0 {
 		return errToResponse(err, nil, BadRequest, "cannot %s: %v", inst.Action)
@@ -640,15 +728,25 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 	if err := decoder.Decode(&inst); err != nil {
 		return BadRequest("cannot decode request body into snap instruction: %v", err)
 	}
+	inst.ctx = r.Context()
 
 	// TODO: inst.Amend, etc?
-	if inst.Channel != "" || !inst.Revision.Unset() || inst.DevMode || inst.JailMode || inst.CohortKey != "" || inst.LeaveCohort || inst.Prefer {
+	if inst.Channel != "" || !inst.Revision.Unset() || inst.DevMode || inst.JailMode || inst.CohortKey != "" || inst.LeaveCohort || inst.Prefer || inst.QueueOnConflict {
 		return BadRequest("unsupported option provided for multi-snap operation")
 	}
 	if err := inst.validate(); err != nil {
 		return BadRequest("%v", err)
 	}
 
+	expensive := inst.expensive()
+	if expensive {
+		release, rspe := c.acquireOperation()
+		if rspe != nil {
+			return rspe
+		}
+		defer release()
+	}
+
 	st := c.d.overlord.State()
 	st.Lock()
 	defer st.Unlock()
@@ -666,10 +764,17 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 		return inst.errToResponse(err)
 	}
 
+	if inst.DryRun {
+		return snapActionDryRun(c, st, res.Tasksets)
+	}
+
 	chg := newChange(st, inst.Action+"-snap", res.Summary, res.Tasksets, res.Affected)
 	if len(res.Tasksets) == 0 {
 		chg.SetStatus(state.DoneStatus)
 	}
+	if expensive {
+		chg.Set(expensiveChangeKey, true)
+	}
 
 	if inst.SystemRestartImmediate {
 		chg.Set("system-restart-immediate", true)
@@ -682,6 +787,98 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 	return AsyncResponse(res.Result, chg.ID())
 }
 
+// snapActionDryRun describes the tasks of the given task sets, the snaps
+// they would download and the connections they would affect, and then
+// discards the tasks so that nothing is performed.
+func snapActionDryRun(c *Command, st *state.State, tsets []*state.TaskSet) Response {
+	var tasks []*state.Task
+	for _, ts := range tsets {
+		tasks = append(tasks, ts.Tasks()...)
+	}
+	defer st.DiscardTasks(tasks)
+
+	plan := snapActionPlanJSON{
+		Tasks: make([]plannedTaskJSON, 0, len(tasks)),
+	}
+	// the setup of most tasks is held by another task of the same snap,
+	// which cannot be looked up in the state as it is not in a change
+	setups := make(map[string]*snapstate.SnapSetup)
+	for _, t := range tasks {
+		var snapsup snapstate.SnapSetup
+		if err := t.Get("snap-setup", &snapsup); err == nil {
+			setups[t.ID()] = &snapsup
+		}
+	}
+	setupOf := func(t *state.Task) *snapstate.SnapSetup {
+		if snapsup := setups[t.ID()]; snapsup != nil {
+			return snapsup
+		}
+		var id string
+		if err := t.Get("snap-setup-task", &id); err != nil {
+			return nil
+		}
+		return setups[id]
+	}
+
+	for _, t := range tasks {
+		pt := plannedTaskJSON{
+			ID:      t.ID(),
+			Kind:    t.Kind(),
+			Summary: t.Summary(),
+		}
+		for _, wt := range t.WaitTasks() {
+			pt.WaitTasks = append(pt.WaitTasks, wt.ID())
+		}
+		plan.Tasks = append(plan.Tasks, pt)
+
+		snapsup := setupOf(t)
+		if snapsup == nil {
+			continue
+		}
+		switch t.Kind() {
+		case "download-snap":
+			dl := plannedDownloadJSON{
+				Snap:     snapsup.InstanceName(),
+				Revision: snapsup.Revision(),
+				Channel:  snapsup.Channel,
+			}
+			if snapsup.DownloadInfo != nil {
+				dl.Size = snapsup.DownloadInfo.Size
+			}
+			plan.Downloads = append(plan.Downloads, dl)
+			plan.DownloadSize += dl.Size
+		case "auto-connect":
+			plan.Connections = append(plan.Connections, plannedConnectionsJSON{
+				Snap:   snapsup.InstanceName(),
+				Action: "auto-connect",
+			})
+		case "auto-disconnect":
+			repo := c.d.overlord.InterfaceManager().Repository()
+			conns, err := repo.Connections(snapsup.InstanceName())
+			if err != nil {
+				return InternalError("cannot get connections of snap %q: %v", snapsup.InstanceName(), err)
+			}
+			pc := plannedConnectionsJSON{
+				Snap:   snapsup.InstanceName(),
+				Action: "disconnect",
+			}
+			for _, connRef := range conns {
+				cj := connectionJSON{
+					Plug: connRef.PlugRef,
+					Slot: connRef.SlotRef,
+				}
+				if plug := repo.Plug(connRef.PlugRef.Snap, connRef.PlugRef.Name); plug != nil {
+					cj.Interface = plug.Interface
+				}
+				pc.Connections = append(pc.Connections, cj)
+			}
+			plan.Connections = append(plan.Connections, pc)
+		}
+	}
+
+	return SyncResponse(plan)
+}
+
 type snapManyActionFunc func(*snapInstruction, *state.State) (*snapInstructionResult, error)
 
 func (inst *snapInstruction) dispatchForMany() (op snapManyActionFunc) {
@@ -703,6 +900,9 @@ func (inst *snapInstruction) dispatchForMany() (op snapManyActionFunc) {
 		op = snapHoldMany
 	case "unhold":
 		op = snapUnholdMany
+	case "batch":
+		// see api_snaps_batch.go
+		op = snapBatch
 	}
 	return op
 }
@@ -742,12 +942,15 @@ func snapUpdateMany(inst *snapInstruction, st *state.State) (*snapInstructionRes
 	// we need refreshed snap-declarations to enforce refresh-control as best as
 	// we can, this also ensures that snap-declarations and their prerequisite
 	// assertions are updated regularly; update validation sets assertions only
-	// if refreshing all snaps (no snap names explicitly requested).
+	// if refreshing all snaps (no snap names explicitly requested). A dry
+	// run must not change the assertion database.
 	opts := &assertstate.RefreshAssertionsOptions{
-		IsRefreshOfAllSnaps: len(inst.Snaps) == 0,
+		IsRefreshOfAllSnaps: len(inst.Snaps) == 0 && !inst.DryRun,
 	}
-	if err := assertstateRefreshSnapAssertions(st, inst.userID, opts); err != nil {
-		return nil, err
+	if !inst.DryRun {
+		if err := assertstateRefreshSnapAssertions(st, inst.userID, opts); err != nil {
+			return nil, err
+		}
 	}
 
 	transaction := inst.Transaction
@@ -855,7 +1058,7 @@ func meetSnapConstraintsForEnforce(inst *snapInstruction, st *state.State, vErr
 }
 
 func snapRemoveMany(inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
-	flags := &snapstate.RemoveFlags{Purge: inst.Purge}
+	flags := &snapstate.RemoveFlags{Purge: inst.Purge, Terminate: inst.Terminate}
 	removed, tasksets, err := snapstateRemoveMany(st, inst.Snaps, flags)
 	if err != nil {
 		return nil, err
@@ -913,10 +1116,22 @@ func getSnapsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		}
 	}
 
+	pageOpts, apiErr := pageOptionsFromQuery(query)
+	if apiErr != nil {
+		return apiErr
+	}
+
 	found, err := allLocalSnapInfos(c.d.overlord.State(), all, wanted)
 	if err != nil {
 		return InternalError("cannot list local snaps! %v", err)
 	}
+	// paginate in a stable order, keeping the revisions of each snap
+	// in sequence order
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].info.InstanceName() < found[j].info.InstanceName()
+	})
+	start, end, page := pageOpts.bounds(len(found))
+	found = found[start:end]
 
 	results := make([]*json.RawMessage, len(found))
 
@@ -939,9 +1154,15 @@ func getSnapsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		results[i] = &raw
 	}
 
+	selected, err := pageOpts.selectFields(results)
+	if err != nil {
+		return InternalError("cannot select snap fields: %v", err)
+	}
+
 	return &findResponse{
-		Results: results,
+		Results: selected,
 		Sources: []string{"local"},
+		Page:    page,
 	}
 }
 
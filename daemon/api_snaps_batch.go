@@ -0,0 +1,290 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// batchActions are the actions that can be part of a batch.
+var batchActions = []string{"install", "refresh", "remove"}
+
+// batchAction is one of the actions of a "batch" multi-snap operation.
+// Actions of the same kind are performed together like multi-snap
+// operations, so only remove actions accept options, purge and terminate.
+type batchAction struct {
+	Snap string `json:"snap"`
+	snapInstruction
+}
+
+// batchActionResult reports what was done for one of the actions of a
+// batch: either the summary and the IDs of the tasks of the action or
+// the error that prevented it.
+type batchActionResult struct {
+	Action  string       `json:"action"`
+	Snap    string       `json:"snap"`
+	Summary string       `json:"summary,omitempty"`
+	TaskIDs []string     `json:"task-ids,omitempty"`
+	Error   *errorResult `json:"error,omitempty"`
+}
+
+func (inst *snapInstruction) validateBatch() error {
+	if len(inst.Snaps) != 0 {
+		return errors.New(`snaps cannot be specified for a batch, use "actions" instead`)
+	}
+	if len(inst.Actions) == 0 {
+		return errors.New("cannot perform a batch without actions")
+	}
+	seen := make(map[string]bool, len(inst.Actions))
+	for i, action := range inst.Actions {
+		if action.Snap == "" {
+			return fmt.Errorf("batch action #%d: snap name cannot be empty", i+1)
+		}
+		if seen[action.Snap] {
+			return fmt.Errorf("cannot use snap %q in more than one batch action", action.Snap)
+		}
+		seen[action.Snap] = true
+		if !strutil.ListContains(batchActions, action.Action) {
+			return fmt.Errorf("batch action for snap %q: unsupported action %q", action.Snap, action.Action)
+		}
+		// the options the multi-snap operations cannot honour
+		// per snap
+		opts := action.snapInstruction
+		opts.Action = ""
+		if action.Action == "remove" {
+			opts.Purge, opts.Terminate = false, false
+		}
+		if !reflect.DeepEqual(opts, snapInstruction{}) {
+			return fmt.Errorf("batch action for snap %q: unsupported option provided for batch action", action.Snap)
+		}
+		action.Snaps = []string{action.Snap}
+	}
+	return nil
+}
+
+// checkBatchAction checks that the given batch action can be performed,
+// so that it can be reported as failed without failing the other actions
+// of its kind.
+func checkBatchAction(st *state.State, action *batchAction) error {
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, action.Snap, &snapst); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	switch {
+	case action.Action == "install" && snapst.IsInstalled():
+		return &snap.AlreadyInstalledError{Snap: action.Snap}
+	case action.Action != "install" && !snapst.IsInstalled():
+		return &snap.NotInstalledError{Snap: action.Snap}
+	}
+	return snapstate.CheckChangeConflict(st, action.Snap, nil)
+}
+
+// batchGroup is a set of actions of a batch performed together by one of
+// the multi-snap operations.
+type batchGroup struct {
+	action  string
+	flags   snapstate.RemoveFlags
+	actions []*batchAction
+}
+
+func (g *batchGroup) snaps() []string {
+	names := make([]string, len(g.actions))
+	for i, action := range g.actions {
+		names[i] = action.Snap
+	}
+	return names
+}
+
+// snapOfTaskSet returns the snap the tasks of the given task set act on,
+// if any.
+func snapOfTaskSet(ts *state.TaskSet) string {
+	for _, t := range ts.Tasks() {
+		var snapsup snapstate.SnapSetup
+		if err := t.Get("snap-setup", &snapsup); err == nil {
+			return snapsup.InstanceName()
+		}
+	}
+	return ""
+}
+
+// snapBatch performs several install, refresh and remove actions in a
+// single change. Actions of the same kind are grouped and performed by the
+// multi-snap operations, which order them as needed, e.g. bases before the
+// snaps using them. Actions that cannot be performed, for example because
+// of a conflict, are reported in the results without failing the whole
+// batch, unless the transaction is for all snaps or no action could be
+// performed at all. A failing multi-snap operation fails all the actions
+// of its group.
+func snapBatch(inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
+	if err := inst.validateBatch(); err != nil {
+		return nil, err
+	}
+
+	var transactionLane int
+	if inst.Transaction == client.TransactionAllSnaps {
+		transactionLane = st.NewLane()
+	}
+
+	var errs []error
+	results := make(map[string]*batchActionResult, len(inst.Actions))
+	orderedResults := make([]*batchActionResult, 0, len(inst.Actions))
+	setError := func(result *batchActionResult, action *batchAction, err error) {
+		rspe := action.errToResponse(err)
+		result.Error = &errorResult{
+			Message: rspe.Message,
+			Kind:    rspe.Kind,
+			Value:   rspe.Value,
+		}
+		errs = append(errs, err)
+	}
+
+	// removes first, then installs and refreshes
+	var groups []*batchGroup
+	groupFor := func(action *batchAction) *batchGroup {
+		flags := snapstate.RemoveFlags{Purge: action.Purge, Terminate: action.Terminate}
+		for _, g := range groups {
+			if g.action == action.Action && g.flags == flags {
+				return g
+			}
+		}
+		g := &batchGroup{action: action.Action, flags: flags}
+		groups = append(groups, g)
+		return g
+	}
+	for _, kind := range []string{"remove", "install", "refresh"} {
+		for _, action := range inst.Actions {
+			if action.Action != kind {
+				continue
+			}
+			result := &batchActionResult{
+				Action: action.Action,
+				Snap:   action.Snap,
+			}
+			results[action.Snap] = result
+			if err := checkBatchAction(st, action); err != nil {
+				if inst.Transaction == client.TransactionAllSnaps {
+					return nil, err
+				}
+				setError(result, action, err)
+				continue
+			}
+			g := groupFor(action)
+			g.actions = append(g.actions, action)
+		}
+	}
+	for _, action := range inst.Actions {
+		orderedResults = append(orderedResults, results[action.Snap])
+	}
+
+	flags := &snapstate.Flags{Transaction: inst.Transaction, Lane: transactionLane}
+	var tasksets []*state.TaskSet
+	for _, g := range groups {
+		var tss []*state.TaskSet
+		var err error
+		switch g.action {
+		case "remove":
+			_, tss, err = snapstateRemoveMany(st, g.snaps(), &g.flags)
+			for _, ts := range tss {
+				if transactionLane != 0 {
+					ts.JoinLane(transactionLane)
+				}
+			}
+		case "install":
+			_, tss, err = snapstateInstallMany(st, g.snaps(), nil, inst.userID, flags)
+		case "refresh":
+			err = assertstateRefreshSnapAssertions(st, inst.userID, nil)
+			if err == nil {
+				_, tss, err = snapstateUpdateMany(context.TODO(), st, g.snaps(), nil, inst.userID, flags)
+			}
+		}
+		if err != nil {
+			if inst.Transaction == client.TransactionAllSnaps {
+				return nil, err
+			}
+			for _, action := range g.actions {
+				setError(results[action.Snap], action, err)
+			}
+			continue
+		}
+		for _, ts := range tss {
+			if result := results[snapOfTaskSet(ts)]; result != nil {
+				for _, t := range ts.Tasks() {
+					result.TaskIDs = append(result.TaskIDs, t.ID())
+				}
+			}
+		}
+		tasksets = append(tasksets, tss...)
+	}
+
+	var summaries []string
+	var affected []string
+	for _, action := range inst.Actions {
+		result := results[action.Snap]
+		if result.Error != nil {
+			continue
+		}
+		result.Summary = batchActionSummary(action.Action, action.Snap, len(result.TaskIDs) != 0)
+		summaries = append(summaries, result.Summary)
+		affected = append(affected, action.Snap)
+	}
+
+	if len(summaries) == 0 {
+		if len(errs) == 1 {
+			return nil, errs[0]
+		}
+		msgs := make([]string, len(orderedResults))
+		for i, result := range orderedResults {
+			msgs[i] = fmt.Sprintf("- %s", result.Error.Message)
+		}
+		return nil, fmt.Errorf("all batch actions failed:\n%s", strings.Join(msgs, "\n"))
+	}
+
+	return &snapInstructionResult{
+		Summary:  strings.Join(summaries, "; "),
+		Affected: affected,
+		Tasksets: tasksets,
+		Result:   map[string]interface{}{"results": orderedResults},
+	}, nil
+}
+
+func batchActionSummary(action, name string, hasTasks bool) string {
+	switch action {
+	case "install":
+		return fmt.Sprintf(i18n.G("Install snap %q"), name)
+	case "refresh":
+		if !hasTasks {
+			return fmt.Sprintf(i18n.G("Refresh snap %q: no updates"), name)
+		}
+		return fmt.Sprintf(i18n.G("Refresh snap %q"), name)
+	default:
+		return fmt.Sprintf(i18n.G("Remove snap %q"), name)
+	}
+}
@@ -0,0 +1,288 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func fakeSnapTaskSet(st *state.State, kind, name string, n int) *state.TaskSet {
+	ts := state.NewTaskSet()
+	var prev *state.Task
+	for i := 0; i < n; i++ {
+		t := st.NewTask(kind, "...")
+		t.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: name}})
+		if prev != nil {
+			t.WaitFor(prev)
+		}
+		ts.AddTask(t)
+		prev = t
+	}
+	return ts
+}
+
+func (s *snapsSuite) mockBatchBackends(c *check.C) (calls map[string][][]string) {
+	calls = make(map[string][][]string)
+	s.AddCleanup(daemon.MockAssertstateRefreshSnapAssertions(func(*state.State, int, *assertstate.RefreshAssertionsOptions) error { return nil }))
+	s.AddCleanup(daemon.MockSnapstateInstallMany(func(st *state.State, names []string, revOpts []*snapstate.RevisionOptions, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error) {
+		calls["install"] = append(calls["install"], names)
+		var tss []*state.TaskSet
+		for _, name := range names {
+			ts := fakeSnapTaskSet(st, "fake-install-snap", name, 1)
+			if flags.Lane != 0 {
+				ts.JoinLane(flags.Lane)
+			} else {
+				ts.JoinLane(st.NewLane())
+			}
+			tss = append(tss, ts)
+		}
+		return names, tss, nil
+	}))
+	s.AddCleanup(daemon.MockSnapstateUpdateMany(func(_ context.Context, st *state.State, names []string, revOpts []*snapstate.RevisionOptions, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error) {
+		calls["refresh"] = append(calls["refresh"], names)
+		var tss []*state.TaskSet
+		for _, name := range names {
+			if name == "failing" {
+				return nil, nil, fmt.Errorf("store is down")
+			}
+			ts := fakeSnapTaskSet(st, "fake-refresh-snap", name, 2)
+			if flags.Lane != 0 {
+				ts.JoinLane(flags.Lane)
+			} else {
+				ts.JoinLane(st.NewLane())
+			}
+			tss = append(tss, ts)
+		}
+		return names, tss, nil
+	}))
+	s.AddCleanup(daemon.MockSnapstateRemoveMany(func(st *state.State, names []string, flags *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error) {
+		calls["remove"] = append(calls["remove"], names)
+		if flags.Purge {
+			calls["remove-purge"] = append(calls["remove-purge"], names)
+		}
+		var tss []*state.TaskSet
+		for _, name := range names {
+			ts := fakeSnapTaskSet(st, "fake-remove-snap", name, 1)
+			ts.JoinLane(st.NewLane())
+			tss = append(tss, ts)
+		}
+		return names, tss, nil
+	}))
+	return calls
+}
+
+func (s *snapsSuite) postBatch(c *check.C, body string) *http.Request {
+	req, err := http.NewRequest("POST", "/v2/snaps", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// batchResults returns the results as seen by the client
+func batchResults(c *check.C, result interface{}) []*client.BatchActionResult {
+	data, err := json.Marshal(result)
+	c.Assert(err, check.IsNil)
+	var results struct {
+		Results []*client.BatchActionResult `json:"results"`
+	}
+	c.Assert(json.Unmarshal(data, &results), check.IsNil)
+	return results.Results
+}
+
+func (s *snapsSuite) TestPostSnapsBatch(c *check.C) {
+	calls := s.mockBatchBackends(c)
+	d := s.daemonWithOverlordMockAndStore()
+	s.mkInstalledInState(c, d, "core", "", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "baz", "", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "old", "", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "older", "", "v1", snap.R(1), true, "")
+
+	req := s.postBatch(c, `{"action": "batch", "actions": [
+		{"action": "install", "snap": "foo"},
+		{"action": "refresh", "snap": "bar"},
+		{"action": "remove", "snap": "not-installed"},
+		{"action": "install", "snap": "core"},
+		{"action": "refresh", "snap": "baz"},
+		{"action": "remove", "snap": "old"},
+		{"action": "remove", "snap": "older", "purge": true}
+	]}`)
+	rsp := s.asyncReq(c, req, nil)
+
+	// actions of the same kind are performed together
+	c.Check(calls, check.DeepEquals, map[string][][]string{
+		"install":      {{"foo"}},
+		"refresh":      {{"bar", "baz"}},
+		"remove":       {{"old"}, {"older"}},
+		"remove-purge": {{"older"}},
+	})
+
+	results := batchResults(c, rsp.Result)
+	c.Assert(results, check.HasLen, 7)
+	c.Check(results[0].Action, check.Equals, "install")
+	c.Check(results[0].Snap, check.Equals, "foo")
+	c.Check(results[0].Summary, check.Equals, `Install snap "foo"`)
+	c.Check(results[0].TaskIDs, check.HasLen, 1)
+	c.Check(results[0].Error, check.IsNil)
+	c.Check(results[1].Summary, check.Equals, `Refresh snap "bar"`)
+	c.Check(results[1].TaskIDs, check.HasLen, 2)
+	c.Check(results[2].Action, check.Equals, "remove")
+	c.Check(results[2].Snap, check.Equals, "not-installed")
+	c.Check(results[2].TaskIDs, check.HasLen, 0)
+	c.Assert(results[2].Error, check.NotNil)
+	c.Check(results[2].Error.Kind, check.Equals, client.ErrorKindSnapNotInstalled)
+	c.Check(results[2].Error.Message, check.Equals, `snap "not-installed" is not installed`)
+	c.Assert(results[3].Error, check.NotNil)
+	c.Check(results[3].Error.Kind, check.Equals, client.ErrorKindSnapAlreadyInstalled)
+	c.Check(results[4].Summary, check.Equals, `Refresh snap "baz"`)
+	c.Check(results[5].Summary, check.Equals, `Remove snap "old"`)
+	c.Check(results[6].Summary, check.Equals, `Remove snap "older"`)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "batch-snap")
+	c.Check(chg.Summary(), check.Equals, `Install snap "foo"; Refresh snap "bar"; Refresh snap "baz"; Remove snap "old"; Remove snap "older"`)
+	c.Check(chg.Tasks(), check.HasLen, 7)
+	var apiData map[string]interface{}
+	c.Check(chg.Get("api-data", &apiData), check.IsNil)
+	c.Check(apiData["snap-names"], check.DeepEquals, []interface{}{"foo", "bar", "baz", "old", "older"})
+}
+
+func (s *snapsSuite) TestPostSnapsBatchFailedGroup(c *check.C) {
+	s.mockBatchBackends(c)
+	d := s.daemonWithOverlordMockAndStore()
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "failing", "", "v1", snap.R(1), true, "")
+
+	req := s.postBatch(c, `{"action": "batch", "actions": [
+		{"action": "install", "snap": "foo"},
+		{"action": "refresh", "snap": "bar"},
+		{"action": "refresh", "snap": "failing"}
+	]}`)
+	rsp := s.asyncReq(c, req, nil)
+
+	// the whole refresh group failed
+	results := batchResults(c, rsp.Result)
+	c.Assert(results, check.HasLen, 3)
+	c.Check(results[0].Error, check.IsNil)
+	c.Assert(results[1].Error, check.NotNil)
+	c.Check(results[1].Error.Message, check.Equals, `cannot refresh "bar": store is down`)
+	c.Assert(results[2].Error, check.NotNil)
+	c.Check(results[2].Error.Message, check.Equals, `cannot refresh "failing": store is down`)
+}
+
+func (s *snapsSuite) TestPostSnapsBatchAllSnapsTransaction(c *check.C) {
+	s.mockBatchBackends(c)
+	d := s.daemonWithOverlordMockAndStore()
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "baz", "", "v1", snap.R(1), true, "")
+
+	req := s.postBatch(c, `{"action": "batch", "transaction": "all-snaps", "actions": [
+		{"action": "install", "snap": "foo"},
+		{"action": "refresh", "snap": "bar"},
+		{"action": "remove", "snap": "baz"}
+	]}`)
+	rsp := s.asyncReq(c, req, nil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	results := batchResults(c, rsp.Result)
+	c.Assert(results, check.HasLen, 3)
+	// all actions share a lane
+	lanes := st.Task(results[0].TaskIDs[0]).Lanes()
+	c.Check(lanes, check.HasLen, 1)
+	c.Check(st.Task(results[1].TaskIDs[0]).Lanes(), check.DeepEquals, lanes)
+	c.Check(st.Task(results[2].TaskIDs[0]).Lanes(), testutil.DeepContains, lanes[0])
+
+	// with a transaction for all snaps a failure fails the whole batch
+	st.Unlock()
+	req = s.postBatch(c, `{"action": "batch", "transaction": "all-snaps", "actions": [
+		{"action": "refresh", "snap": "bar"},
+		{"action": "remove", "snap": "not-installed"}
+	]}`)
+	rspe := s.errorReq(c, req, nil)
+	st.Lock()
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapNotInstalled)
+	c.Check(rspe.Message, check.Equals, `snap "not-installed" is not installed`)
+}
+
+func (s *snapsSuite) TestPostSnapsBatchAllFailed(c *check.C) {
+	s.mockBatchBackends(c)
+	d := s.daemonWithOverlordMockAndStore()
+	s.mkInstalledInState(c, d, "installed", "", "v1", snap.R(1), true, "")
+
+	req := s.postBatch(c, `{"action": "batch", "actions": [
+		{"action": "remove", "snap": "not-installed"}
+	]}`)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapNotInstalled)
+
+	req = s.postBatch(c, `{"action": "batch", "actions": [
+		{"action": "remove", "snap": "not-installed"},
+		{"action": "install", "snap": "installed"}
+	]}`)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `cannot batch: all batch actions failed:
+- snap "not-installed" is not installed
+- snap "installed" is already installed`)
+}
+
+func (s *snapsSuite) TestPostSnapsBatchInvalid(c *check.C) {
+	s.daemonWithOverlordMockAndStore()
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "batch"}`, `cannot batch: cannot perform a batch without actions`},
+		{`{"action": "batch", "snaps": ["foo"], "actions": [{"action": "remove", "snap": "foo"}]}`, `cannot batch "foo": snaps cannot be specified for a batch, use "actions" instead`},
+		{`{"action": "batch", "actions": [{"action": "remove"}]}`, `cannot batch: batch action #1: snap name cannot be empty`},
+		{`{"action": "batch", "actions": [{"action": "remove", "snap": "foo"}, {"action": "install", "snap": "foo"}]}`, `cannot batch: cannot use snap "foo" in more than one batch action`},
+		{`{"action": "batch", "actions": [{"action": "revert", "snap": "foo"}]}`, `cannot batch: batch action for snap "foo": unsupported action "revert"`},
+		{`{"action": "batch", "actions": [{"action": "install", "snap": "foo", "transaction": "per-snap"}]}`, `cannot batch: batch action for snap "foo": unsupported option provided for batch action`},
+		{`{"action": "batch", "actions": [{"action": "install", "snap": "foo", "channel": "edge"}]}`, `cannot batch: batch action for snap "foo": unsupported option provided for batch action`},
+		{`{"action": "batch", "actions": [{"action": "install", "snap": "foo", "purge": true}]}`, `cannot batch: batch action for snap "foo": unsupported option provided for batch action`},
+		{`{"action": "remove", "snaps": ["foo"], "actions": [{"action": "remove", "snap": "foo"}]}`, `actions can only be specified for a batch`},
+	} {
+		rspe := s.errorReq(c, s.postBatch(c, t.body), nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%s", t.body))
+		c.Check(rspe.Message, check.Equals, t.err, check.Commentf("%s", t.body))
+	}
+}
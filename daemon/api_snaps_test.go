@@ -42,8 +42,11 @@ import (
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/healthstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -208,6 +211,27 @@ func (s *snapsSuite) TestSnapsInfoOnlyLocal(c *check.C) {
 	})
 }
 
+func (s *snapsSuite) TestSnapsInfoPaginated(c *check.C) {
+	d := s.daemon(c)
+
+	s.mkInstalledInState(c, d, "snap-a", "foo", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "snap-b", "foo", "v1", snap.R(1), true, "")
+	s.mkInstalledInState(c, d, "snap-c", "foo", "v1", snap.R(1), true, "")
+
+	req, err := http.NewRequest("GET", "/v2/snaps?offset=1&limit=1&fields=name,version", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	c.Assert(rsp.Page, check.NotNil)
+	c.Check(rsp.Page.Offset, check.Equals, 1)
+	c.Check(rsp.Page.Limit, check.Equals, 1)
+	c.Check(rsp.Page.Total, check.Equals, 3)
+
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, check.IsNil)
+	c.Check(string(data), check.Equals, `[{"name":"snap-b","version":"v1"}]`)
+}
+
 func (s *snapsSuite) TestSnapsInfoAllMixedPublishers(c *check.C) {
 	d := s.daemon(c)
 
@@ -956,6 +980,25 @@ func (s *snapsSuite) TestRemoveManyWithPurge(c *check.C) {
 	c.Check(res.Summary, check.Equals, `Remove snaps "foo", "bar"`)
 	c.Check(res.Affected, check.DeepEquals, inst.Snaps)
 }
+
+func (s *snapsSuite) TestRemoveManyWithTerminate(c *check.C) {
+	defer daemon.MockSnapstateRemoveMany(func(s *state.State, names []string, opts *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error) {
+		c.Check(names, check.HasLen, 2)
+		c.Check(opts, check.DeepEquals, &snapstate.RemoveFlags{Purge: true, Terminate: true})
+		t := s.NewTask("fake-remove-2", "Remove two")
+		return names, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{Action: "remove", Purge: true, Terminate: true, Snaps: []string{"foo", "bar"}}
+	st := d.Overlord().State()
+	st.Lock()
+	res, err := inst.DispatchForMany()(inst, st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(res.Summary, check.Equals, `Remove snaps "foo", "bar"`)
+}
+
 func (s *snapsSuite) TestSnapInfoOneIntegration(c *check.C) {
 	d := s.daemon(c)
 
@@ -1154,30 +1197,35 @@ UnitFileState=enabled
 					DaemonScope: snap.SystemDaemon,
 					Enabled:     true,
 					Active:      false,
+					Units:       []string{"snap.foo.svc1.service"},
 				}, {
 					Snap: "foo", Name: "svc2",
 					Daemon:      "forking",
 					DaemonScope: snap.SystemDaemon,
 					Enabled:     false,
 					Active:      true,
+					Units:       []string{"snap.foo.svc2.service"},
 				}, {
 					Snap: "foo", Name: "svc3",
 					Daemon:      "oneshot",
 					DaemonScope: snap.SystemDaemon,
 					Enabled:     true,
 					Active:      true,
+					Units:       []string{"snap.foo.svc3.service"},
 				}, {
 					Snap: "foo", Name: "svc4",
 					Daemon:      "notify",
 					DaemonScope: snap.SystemDaemon,
 					Enabled:     false,
 					Active:      false,
+					Units:       []string{"snap.foo.svc4.service"},
 				}, {
 					Snap: "foo", Name: "svc5",
 					Daemon:      "simple",
 					DaemonScope: snap.SystemDaemon,
 					Enabled:     true,
 					Active:      false,
+					Units:       []string{"snap.foo.svc5.service", "snap.foo.svc5.timer"},
 					Activators: []client.AppActivator{
 						{Name: "svc5", Type: "timer", Active: true, Enabled: true},
 					},
@@ -1187,6 +1235,7 @@ UnitFileState=enabled
 					DaemonScope: snap.SystemDaemon,
 					Enabled:     true,
 					Active:      false,
+					Units:       []string{"snap.foo.svc6.service", "snap.foo.svc6.sock.socket"},
 					Activators: []client.AppActivator{
 						{Name: "sock", Type: "socket", Active: true, Enabled: true},
 					},
@@ -1196,6 +1245,7 @@ UnitFileState=enabled
 					DaemonScope: snap.SystemDaemon,
 					Enabled:     true,
 					Active:      false,
+					Units:       []string{"snap.foo.svc7.service", "snap.foo.svc7.other-sock.socket"},
 					Activators: []client.AppActivator{
 						{Name: "other-sock", Type: "socket", Active: false, Enabled: true},
 					},
@@ -1495,6 +1545,41 @@ func (s *snapsSuite) TestPostSnap(c *check.C) {
 	c.Check(systemRestartImmediate, check.Equals, false)
 }
 
+func (s *snapsSuite) TestPostSnapConcurrentOperationsLimit(c *check.C) {
+	d := s.daemonWithOverlordMock()
+
+	defer daemon.MockSnapstateInstall(func(ctx context.Context, s *state.State, name string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags) (*state.TaskSet, error) {
+		t := s.NewTask("fake-install-snap", "Doing a fake install")
+		return state.NewTaskSet(t), nil
+	})()
+	defer daemon.MockSnapstateSwitch(func(s *state.State, name string, opts *snapstate.RevisionOptions) (*state.TaskSet, error) {
+		t := s.NewTask("fake-switch", "Doing a fake switch")
+		return state.NewTaskSet(t), nil
+	})()
+
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "api.max-concurrent-operations", 1), check.IsNil)
+	tr.Commit()
+	st.Unlock()
+
+	post := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/v2/snaps/foo", bytes.NewBufferString(body))
+		c.Assert(err, check.IsNil)
+		return req
+	}
+
+	// the first install is still in progress
+	s.asyncReq(c, post(`{"action": "install"}`), nil)
+	rspe := s.errorReq(c, post(`{"action": "install"}`), nil)
+	c.Check(rspe.Status, check.Equals, 429)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindTooManyOperations)
+
+	// switching does not download nor regenerate profiles
+	s.asyncReq(c, post(`{"action": "switch", "channel": "edge"}`), nil)
+}
+
 func (s *snapsSuite) TestPostSnapWithChannel(c *check.C) {
 	checkOpts := func(opts *snapstate.RevisionOptions) {
 		// channel in -> channel out
@@ -1564,6 +1649,211 @@ func (s *snapsSuite) testPostSnap(c *check.C, extraJSON string, checkOpts func(o
 	return summary, systemRestartImmediate
 }
 
+func (s *snapsSuite) TestPostSnapDryRun(c *check.C) {
+	d := s.daemonWithOverlordMock()
+
+	defer daemon.MockSnapstateInstall(func(ctx context.Context, st *state.State, name string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags) (*state.TaskSet, error) {
+		snapsup := &snapstate.SnapSetup{
+			Channel:      "stable",
+			SideInfo:     &snap.SideInfo{RealName: name, Revision: snap.R(7)},
+			DownloadInfo: &snap.DownloadInfo{Size: 1234},
+		}
+		prereq := st.NewTask("prerequisites", "Ensure prerequisites")
+		prereq.Set("snap-setup", snapsup)
+		download := st.NewTask("download-snap", "Download")
+		download.Set("snap-setup", snapsup)
+		download.WaitFor(prereq)
+		autoConnect := st.NewTask("auto-connect", "Automatically connect")
+		autoConnect.Set("snap-setup-task", download.ID())
+		autoConnect.WaitFor(download)
+		return state.NewTaskSet(prereq, download, autoConnect), nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action": "install", "dry-run": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+
+	// round-trip through JSON like a client would see it
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, check.IsNil)
+	var plan client.SnapActionPlan
+	c.Assert(json.Unmarshal(data, &plan), check.IsNil)
+	c.Assert(plan.Tasks, check.HasLen, 3)
+	c.Check(plan.Tasks[0].Kind, check.Equals, "prerequisites")
+	c.Check(plan.Tasks[1].Kind, check.Equals, "download-snap")
+	c.Check(plan.Tasks[1].WaitTasks, check.DeepEquals, []string{plan.Tasks[0].ID})
+	c.Check(plan.Tasks[2].Kind, check.Equals, "auto-connect")
+	c.Check(plan.Tasks[2].WaitTasks, check.DeepEquals, []string{plan.Tasks[1].ID})
+	c.Check(plan.Downloads, check.DeepEquals, []client.PlannedDownload{
+		{Snap: "foo", Revision: snap.R(7), Channel: "stable", Size: 1234},
+	})
+	c.Check(plan.DownloadSize, check.Equals, int64(1234))
+	c.Check(plan.Connections, check.DeepEquals, []client.PlannedConnections{
+		{Snap: "foo", Action: "auto-connect"},
+	})
+
+	// nothing was left behind
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	c.Check(st.TaskCount(), check.Equals, 0)
+}
+
+func (s *snapsSuite) TestPostSnapsRefreshDryRunKeepsAssertionsAndState(c *check.C) {
+	refreshSnapAssertions := 0
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(s *state.State, userID int, opts *assertstate.RefreshAssertionsOptions) error {
+		refreshSnapAssertions++
+		return assertstate.RefreshSnapAssertions(s, userID, opts)
+	})()
+	refreshTaskSet := func(st *state.State, name string) *state.TaskSet {
+		download := st.NewTask("download-snap", "Download")
+		download.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo: &snap.SideInfo{RealName: name, Revision: snap.R(2)},
+		})
+		return state.NewTaskSet(download)
+	}
+	defer daemon.MockSnapstateUpdate(func(st *state.State, name string, _ *snapstate.RevisionOptions, _ int, _ snapstate.Flags) (*state.TaskSet, error) {
+		return refreshTaskSet(st, name), nil
+	})()
+	defer daemon.MockSnapstateUpdateMany(func(_ context.Context, st *state.State, names []string, _ []*snapstate.RevisionOptions, _ int, _ *snapstate.Flags) ([]string, []*state.TaskSet, error) {
+		return []string{"foo"}, []*state.TaskSet{refreshTaskSet(st, "foo")}, nil
+	})()
+
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	stateData := func() string {
+		st.Lock()
+		defer st.Unlock()
+		data, err := json.Marshal(st)
+		c.Assert(err, check.IsNil)
+		var dump struct {
+			Data json.RawMessage `json:"data"`
+		}
+		c.Assert(json.Unmarshal(data, &dump), check.IsNil)
+		return string(dump.Data)
+	}
+	assertions := func() int {
+		st.Lock()
+		defer st.Unlock()
+		n := 0
+		for _, t := range []*asserts.AssertionType{asserts.AccountType, asserts.AccountKeyType, asserts.SnapDeclarationType, asserts.ValidationSetType} {
+			as, err := assertstate.DB(st).FindMany(t, nil)
+			if err == nil {
+				n += len(as)
+			}
+		}
+		return n
+	}
+	before := stateData()
+	beforeAssertions := assertions()
+
+	for _, t := range []struct {
+		path, body string
+	}{
+		{"/v2/snaps/foo", `{"action": "refresh", "dry-run": true}`},
+		{"/v2/snaps", `{"action": "refresh", "dry-run": true}`},
+		{"/v2/snaps", `{"action": "refresh", "snaps": ["foo"], "dry-run": true}`},
+	} {
+		req, err := http.NewRequest("POST", t.path, bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rsp := s.syncReq(c, req, nil)
+		c.Check(rsp.Status, check.Equals, 200, check.Commentf(t.body))
+	}
+
+	c.Check(refreshSnapAssertions, check.Equals, 0)
+	c.Check(assertions(), check.Equals, beforeAssertions)
+	c.Check(stateData(), check.Equals, before)
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	c.Check(st.TaskCount(), check.Equals, 0)
+}
+
+func (s *snapsSuite) TestPostSnapsDryRunUnsupportedAction(c *check.C) {
+	s.daemonWithOverlordMock()
+
+	defer daemon.MockSnapstateHoldRefreshesBySystem(func(st *state.State, level snapstate.HoldLevel, time string, snaps []string) error {
+		c.Fatalf("unexpected hold")
+		return nil
+	})()
+
+	for _, body := range []string{
+		`{"action": "hold", "snaps": ["foo"], "time": "forever", "hold-level": "general", "dry-run": true}`,
+		`{"action": "unhold", "snaps": ["foo"], "dry-run": true}`,
+		`{"action": "snapshot", "snaps": ["foo"], "dry-run": true}`,
+		`{"action": "refresh", "validation-sets": ["foo/bar"], "dry-run": true}`,
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps", bytes.NewBufferString(body))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(body))
+		c.Check(rspe.Message, check.Matches, "dry-run can.*", check.Commentf(body))
+	}
+}
+
+func (s *snapsSuite) TestPostSnapsRemoveDryRun(c *check.C) {
+	d := s.daemon(c)
+
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+	repo := d.Overlord().InterfaceManager().Repository()
+	_, err := repo.Connect(&interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	defer daemon.MockSnapstateRemoveMany(func(st *state.State, names []string, opts *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error) {
+		c.Check(names, check.DeepEquals, []string{"consumer"})
+		disconnect := st.NewTask("auto-disconnect", "Disconnect interfaces")
+		disconnect.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo: &snap.SideInfo{RealName: "consumer", Revision: snap.R(1)},
+		})
+		return names, []*state.TaskSet{state.NewTaskSet(disconnect)}, nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action": "remove", "snaps": ["consumer"], "dry-run": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, check.IsNil)
+	var plan client.SnapActionPlan
+	c.Assert(json.Unmarshal(data, &plan), check.IsNil)
+	c.Check(plan.Tasks, check.HasLen, 1)
+	c.Check(plan.Downloads, check.HasLen, 0)
+	c.Check(plan.Connections, check.DeepEquals, []client.PlannedConnections{{
+		Snap:   "consumer",
+		Action: "disconnect",
+		Connections: []client.Connection{{
+			Plug:      client.PlugRef{Snap: "consumer", Name: "plug"},
+			Slot:      client.SlotRef{Snap: "producer", Name: "slot"},
+			Interface: "test",
+		}},
+	}})
+
+	// the connection is still there
+	c.Check(repo.Interfaces().Connections, check.HasLen, 1)
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
+
 func (s *snapsSuite) TestPostSnapVerifySnapInstruction(c *check.C) {
 	s.daemonWithOverlordMock()
 
@@ -1606,6 +1896,21 @@ func (s *snapsSuite) TestPostSnapQuotaGroupWrongAction(c *check.C) {
 	}
 }
 
+func (s *snapsSuite) TestPostSnapQueueOnConflictWrongAction(c *check.C) {
+	s.daemonWithOverlordMock()
+	const expectedErr = "queue-on-conflict can only be specified for install or refresh"
+
+	for _, action := range []string{"remove", "revert", "enable", "disable", "xyzzy"} {
+		buf := strings.NewReader(fmt.Sprintf(`{"action": "%s", "queue-on-conflict": true}`, action))
+		req, err := http.NewRequest("POST", "/v2/snaps/some-snap", buf)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%q", action))
+		c.Check(rspe.Message, check.Equals, expectedErr, check.Commentf("%q", action))
+	}
+}
+
 func (s *snapsSuite) TestPostSnapLeaveCohortUnsupportedAction(c *check.C) {
 	s.daemonWithOverlordMock()
 	const expectedErr = "leave-cohort can only be specified for refresh or switch"
@@ -1636,6 +1941,36 @@ func (s *snapsSuite) TestPostSnapPreferWrongAction(c *check.C) {
 	}
 }
 
+func (s *snapsSuite) TestPostSnapDataOnlyWrongAction(c *check.C) {
+	s.daemonWithOverlordMock()
+	const expectedErr = "the data-only flag can only be specified on revert"
+
+	for _, action := range []string{"install", "remove", "refresh", "enable", "disable", "xyzzy"} {
+		buf := strings.NewReader(fmt.Sprintf(`{"action": "%s", "data-only": true}`, action))
+		req, err := http.NewRequest("POST", "/v2/snaps/some-snap", buf)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%q", action))
+		c.Check(rspe.Message, check.Equals, expectedErr, check.Commentf("%q", action))
+	}
+}
+
+func (s *snapsSuite) TestPostSnapTerminateWrongAction(c *check.C) {
+	s.daemonWithOverlordMock()
+	const expectedErr = "the terminate flag can only be specified on remove"
+
+	for _, action := range []string{"install", "refresh", "revert", "enable", "disable", "xyzzy"} {
+		buf := strings.NewReader(fmt.Sprintf(`{"action": "%s", "terminate": true}`, action))
+		req, err := http.NewRequest("POST", "/v2/snaps/some-snap", buf)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%q", action))
+		c.Check(rspe.Message, check.Equals, expectedErr, check.Commentf("%q", action))
+	}
+}
+
 func (s *snapsSuite) TestPostSnapCohortIncompat(c *check.C) {
 	s.daemonWithOverlordMock()
 	type T struct {
@@ -1831,6 +2166,32 @@ func (s *snapsSuite) TestInstallJailMode(c *check.C) {
 	c.Check(calledFlags.JailMode, check.Equals, true)
 }
 
+func (s *snapsSuite) TestInstallQueueOnConflict(c *check.C) {
+	var calledFlags snapstate.Flags
+
+	defer daemon.MockSnapstateInstall(func(ctx context.Context, s *state.State, name string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags) (*state.TaskSet, error) {
+		calledFlags = flags
+
+		t := s.NewTask("fake-install-snap", "Doing a fake install")
+		return state.NewTaskSet(t), nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{
+		Action:          "install",
+		QueueOnConflict: true,
+		Snaps:           []string{"fake"},
+	}
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	_, _, err := inst.Dispatch()(inst, st)
+	c.Check(err, check.IsNil)
+
+	c.Check(calledFlags.QueueOnConflict, check.Equals, true)
+}
+
 func (s *snapsSuite) TestInstallJailModeDevModeOS(c *check.C) {
 	restore := sandbox.MockForceDevMode(true)
 	defer restore()
@@ -2242,6 +2603,32 @@ func (s *snapsSuite) TestRefreshIgnoreRunning(c *check.C) {
 	c.Check(summary, check.Equals, `Refresh "some-snap" snap`)
 }
 
+func (s *snapsSuite) TestRefreshQueueOnConflict(c *check.C) {
+	var calledFlags snapstate.Flags
+	defer daemon.MockSnapstateUpdate(func(s *state.State, name string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags) (*state.TaskSet, error) {
+		calledFlags = flags
+		t := s.NewTask("fake-refresh-snap", "Doing a fake install")
+		return state.NewTaskSet(t), nil
+	})()
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(s *state.State, userID int, opts *assertstate.RefreshAssertionsOptions) error {
+		return nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{
+		Action:          "refresh",
+		QueueOnConflict: true,
+		Snaps:           []string{"some-snap"},
+	}
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	_, _, err := inst.Dispatch()(inst, st)
+	c.Check(err, check.IsNil)
+	c.Check(calledFlags, check.DeepEquals, snapstate.Flags{QueueOnConflict: true})
+}
+
 func (s *snapsSuite) TestRefreshCohort(c *check.C) {
 	cohort := ""
 
@@ -2432,6 +2819,39 @@ func (s *snapsSuite) TestRevertSnapToRevisionClassic(c *check.C) {
 	s.testRevertSnap(inst, c)
 }
 
+func (s *snapsSuite) TestRevertSnapDataOnly(c *check.C) {
+	var calls []string
+	defer daemon.MockSnapstateRevert(func(s *state.State, name string, flags snapstate.Flags, fromChange string) (*state.TaskSet, error) {
+		c.Fatalf("unexpected call to snapstate.Revert")
+		return nil, nil
+	})()
+	defer daemon.MockSnapshotRevertData(func(s *state.State, name string, rev snap.Revision, users []string) (uint64, *state.TaskSet, error) {
+		calls = append(calls, fmt.Sprintf("%s (%s) %v", name, rev, users))
+		return 42, state.NewTaskSet(), nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{
+		Action:   "revert",
+		DataOnly: true,
+		Snaps:    []string{"some-snap"},
+		Users:    []string{"user1"},
+	}
+	inst.Revision = snap.R(3)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	summary, _, err := inst.Dispatch()(inst, st)
+	c.Assert(err, check.IsNil)
+	c.Check(calls, check.DeepEquals, []string{"some-snap (3) [user1]"})
+	c.Check(summary, check.Equals, `Revert data of "some-snap" snap from snapshot set #42`)
+
+	inst.DevMode = true
+	_, _, err = inst.Dispatch()(inst, st)
+	c.Check(err, check.ErrorMatches, "cannot change confinement mode when reverting data only")
+}
+
 func (s *snapsSuite) TestErrToResponseNoSnapsDoesNotPanic(c *check.C) {
 	si := &daemon.SnapInstruction{Action: "frobble"}
 	errors := []error{
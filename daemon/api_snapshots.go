@@ -52,13 +52,14 @@ var snapshotExportCmd = &Command{
 }
 
 var (
-	snapshotList    = snapshotstate.List
-	snapshotCheck   = snapshotstate.Check
-	snapshotForget  = snapshotstate.Forget
-	snapshotRestore = snapshotstate.Restore
-	snapshotSave    = snapshotstate.Save
-	snapshotExport  = snapshotstate.Export
-	snapshotImport  = snapshotstate.Import
+	snapshotList       = snapshotstate.List
+	snapshotCheck      = snapshotstate.Check
+	snapshotForget     = snapshotstate.Forget
+	snapshotRestore    = snapshotstate.Restore
+	snapshotRevertData = snapshotstate.RevertData
+	snapshotSave       = snapshotstate.Save
+	snapshotExport     = snapshotstate.Export
+	snapshotImport     = snapshotstate.Import
 )
 
 func listSnapshots(c *Command, r *http.Request, user *auth.UserState) Response {
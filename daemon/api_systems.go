@@ -75,6 +75,14 @@ func getAllSystems(c *Command, r *http.Request, user *auth.UserState) Response {
 	rsp.Systems = make([]client.System, 0, len(seedSystems))
 
 	for _, ss := range seedSystems {
+		if ss.Broken != "" {
+			rsp.Systems = append(rsp.Systems, client.System{
+				Label:  ss.Label,
+				Broken: ss.Broken,
+			})
+			continue
+		}
+
 		// untangle the model
 
 		actions := make([]client.SystemAction, 0, len(ss.Actions))
@@ -137,6 +145,7 @@ func storageEncryption(encInfo *install.EncryptionSupportInfo) *client.StorageEn
 
 var (
 	devicestateInstallFinish                 = devicestate.InstallFinish
+	devicestateCreateRecoverySystem          = devicestate.CreateRecoverySystem
 	devicestateInstallSetupStorageEncryption = devicestate.InstallSetupStorageEncryption
 )
 
@@ -199,6 +208,8 @@ func postSystemsAction(c *Command, r *http.Request, user *auth.UserState) Respon
 		return postSystemActionReboot(c, systemLabel, &req)
 	case "install":
 		return postSystemActionInstall(c, systemLabel, &req)
+	case "create":
+		return postSystemActionCreate(c, systemLabel)
 	default:
 		return BadRequest("unsupported action %q", req.Action)
 	}
@@ -271,3 +282,20 @@ func postSystemActionInstall(c *Command, systemLabel string, req *systemActionRe
 		return BadRequest("unsupported install step %q", req.Step)
 	}
 }
+
+func postSystemActionCreate(c *Command, systemLabel string) Response {
+	if systemLabel == "" {
+		return BadRequest("cannot create a recovery system without a label")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	chg, err := devicestateCreateRecoverySystem(st, systemLabel)
+	if err != nil {
+		return BadRequest("cannot create recovery system %q: %v", systemLabel, err)
+	}
+	ensureStateSoon(st)
+	return AsyncResponse(nil, chg.ID())
+}
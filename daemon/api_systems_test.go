@@ -260,6 +260,42 @@ func (s *systemsSuite) TestSystemsGetSome(c *check.C) {
 		}})
 }
 
+func (s *systemsSuite) TestSystemsGetBroken(c *check.C) {
+	m := boot.Modeenv{
+		Mode: "run",
+	}
+	err := m.WriteTo("")
+	c.Assert(err, check.IsNil)
+
+	d := s.daemonWithOverlordMockAndStore()
+	hookMgr, err := hookstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, check.IsNil)
+	mgr, err := devicestate.Manager(d.Overlord().State(), hookMgr, d.Overlord().TaskRunner(), nil)
+	c.Assert(err, check.IsNil)
+	d.Overlord().AddManager(mgr)
+
+	s.expectAuthenticatedAccess()
+
+	restore := s.mockSystemSeeds(c)
+	defer restore()
+
+	err = os.Remove(filepath.Join(dirs.SnapSeedDir, "systems", "20191119", "model"))
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("GET", "/v2/systems", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	c.Assert(rsp.Status, check.Equals, 200)
+	sys := rsp.Result.(*daemon.SystemsResponse)
+	c.Assert(sys.Systems, check.HasLen, 2)
+	c.Check(sys.Systems[0].Label, check.Equals, "20191119")
+	c.Check(sys.Systems[0].Broken, check.Matches, `cannot load assertions for label "20191119": .*`)
+	c.Check(sys.Systems[0].Actions, check.HasLen, 0)
+	c.Check(sys.Systems[1].Label, check.Equals, "20200318")
+	c.Check(sys.Systems[1].Broken, check.Equals, "")
+}
+
 func (s *systemsSuite) TestSystemsGetNone(c *check.C) {
 	m := boot.Modeenv{
 		Mode: "run",
@@ -1217,3 +1253,50 @@ func (s *systemsSuite) TestSystemInstallActionError(c *check.C) {
 	rspe := s.errorReq(c, req, nil)
 	c.Check(rspe.Error(), check.Equals, `unsupported install step "unknown-install-step" (api)`)
 }
+
+func (s *systemsSuite) TestSystemCreateActionCallsDevicestate(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	soon := 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	var gotLabel string
+	r := daemon.MockDevicestateCreateRecoverySystem(func(st *state.State, label string) (*state.Change, error) {
+		gotLabel = label
+		return st.NewChange("create-recovery-system", "..."), nil
+	})
+	defer r()
+
+	buf := bytes.NewBufferString(`{"action": "create"}`)
+	req, err := http.NewRequest("POST", "/v2/systems/1234", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.asyncReq(c, req, nil)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	st.Unlock()
+	c.Check(chg, check.NotNil)
+	c.Check(gotLabel, check.Equals, "1234")
+	c.Check(soon, check.Equals, 1)
+}
+
+func (s *systemsSuite) TestSystemCreateActionError(c *check.C) {
+	s.daemon(c)
+
+	r := daemon.MockDevicestateCreateRecoverySystem(func(st *state.State, label string) (*state.Change, error) {
+		return nil, fmt.Errorf("cannot create new recovery systems until fully seeded")
+	})
+	defer r()
+
+	buf := bytes.NewBufferString(`{"action": "create"}`)
+	req, err := http.NewRequest("POST", "/v2/systems/1234", buf)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Error(), check.Equals, `cannot create recovery system "1234": cannot create new recovery systems until fully seeded (api)`)
+}
@@ -49,6 +49,39 @@ func (s *apiSuite) TestListIncludesAll(c *check.C) {
 		check.Commentf(`At a glance it looks like you've not added all the Commands defined in api to the api list.`))
 }
 
+func (s *apiSuite) TestCommandsDeclareAccess(c *check.C) {
+	for _, cmd := range daemon.APICommands() {
+		if cmd.GET != nil {
+			c.Check(cmd.ReadAccess, check.NotNil, check.Commentf("%s has GET but no ReadAccess", cmd.Path))
+		}
+		if cmd.POST != nil || cmd.PUT != nil {
+			c.Check(cmd.WriteAccess, check.NotNil, check.Commentf("%s has POST or PUT but no WriteAccess", cmd.Path))
+		}
+	}
+}
+
+func (s *apiSuite) TestAdminCommandsRequirePolkitAuthorization(c *check.C) {
+	// changes to the system can be authorized by an administrator
+	// through polkit, while reading is open or needs authentication
+	expected := map[string]string{
+		"/v2/snaps":             "io.snapcraft.snapd.manage",
+		"/v2/snaps/{name}":      "io.snapcraft.snapd.manage",
+		"/v2/interfaces":        "io.snapcraft.snapd.manage-interfaces",
+		"/v2/snaps/{name}/conf": "io.snapcraft.snapd.manage-configuration",
+		"/v2/login":             "io.snapcraft.snapd.login",
+	}
+	found := 0
+	for _, cmd := range daemon.APICommands() {
+		action, ok := expected[cmd.Path]
+		if !ok {
+			continue
+		}
+		found++
+		c.Check(cmd.WriteAccess, check.DeepEquals, daemon.AuthenticatedAccess{Polkit: action}, check.Commentf("%s", cmd.Path))
+	}
+	c.Check(found, check.Equals, len(expected))
+}
+
 func (s *apiSuite) TestserFromRequestNoHeader(c *check.C) {
 	req, _ := http.NewRequest("GET", "http://example.com", nil)
 
@@ -21,6 +21,7 @@ package daemon
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"regexp"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/store"
@@ -88,6 +90,11 @@ func loginUser(c *Command, r *http.Request, user *auth.UserState) Response {
 		Email    string `json:"email"`
 		Password string `json:"password"`
 		Otp      string `json:"otp"`
+
+		// store credentials obtained elsewhere, to log in without
+		// a password
+		Macaroon   string   `json:"macaroon"`
+		Discharges []string `json:"discharges"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -95,70 +102,66 @@ func loginUser(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("cannot decode login data from request body: %v", err)
 	}
 
-	if loginData.Email == "" && isEmailish(loginData.Username) {
-		// for backwards compatibility, if no email is provided assume username is the email
-		loginData.Email = loginData.Username
-		loginData.Username = ""
-	}
+	// store credentials obtained elsewhere do not come with an email
+	// address the store vouched for
+	withMacaroon := loginData.Macaroon != ""
+	if withMacaroon {
+		loginData.Email = ""
+	} else {
+		if loginData.Email == "" && isEmailish(loginData.Username) {
+			// for backwards compatibility, if no email is provided assume username is the email
+			loginData.Email = loginData.Username
+			loginData.Username = ""
+		}
 
-	if loginData.Email == "" && user != nil && user.Email != "" {
-		loginData.Email = user.Email
-	}
+		if loginData.Email == "" && user != nil && user.Email != "" {
+			loginData.Email = user.Email
+		}
 
-	// the "username" needs to look a lot like an email address
-	if !isEmailish(loginData.Email) {
-		return &apiError{
-			Status:  400,
-			Message: "please use a valid email address.",
-			Kind:    client.ErrorKindInvalidAuthData,
-			Value:   map[string][]string{"email": {"invalid"}},
+		// the "username" needs to look a lot like an email address
+		if !isEmailish(loginData.Email) {
+			return &apiError{
+				Status:  400,
+				Message: "please use a valid email address.",
+				Kind:    client.ErrorKindInvalidAuthData,
+				Value:   map[string][]string{"email": {"invalid"}},
+			}
 		}
 	}
 
 	overlord := c.d.overlord
 	st := overlord.State()
-	theStore := storeFrom(c.d)
-	macaroon, discharge, err := theStore.LoginUser(loginData.Email, loginData.Password, loginData.Otp)
-	switch err {
-	case store.ErrAuthenticationNeeds2fa:
-		return &apiError{
-			Status:  401,
-			Message: err.Error(),
-			Kind:    client.ErrorKindTwoFactorRequired,
-		}
-	case store.Err2faFailed:
-		return &apiError{
-			Status:  401,
-			Message: err.Error(),
-			Kind:    client.ErrorKindTwoFactorFailed,
-		}
-	default:
-		switch err := err.(type) {
-		case store.InvalidAuthDataError:
+	var macaroon string
+	var discharges []string
+	if withMacaroon {
+		if err := validateStoreMacaroons(loginData.Macaroon, loginData.Discharges); err != nil {
 			return &apiError{
 				Status:  400,
 				Message: err.Error(),
 				Kind:    client.ErrorKindInvalidAuthData,
-				Value:   err,
-			}
-		case store.PasswordPolicyError:
-			return &apiError{
-				Status:  401,
-				Message: err.Error(),
-				Kind:    client.ErrorKindPasswordPolicy,
-				Value:   err,
 			}
 		}
-		return Unauthorized(err.Error())
-	case nil:
-		// continue
+		macaroon, discharges = loginData.Macaroon, loginData.Discharges
+	} else {
+		theStore := storeFrom(c.d)
+		var discharge string
+		var rsp Response
+		macaroon, discharge, rsp = storeLoginUser(theStore, loginData.Email, loginData.Password, loginData.Otp)
+		if rsp != nil {
+			return rsp
+		}
+		discharges = []string{discharge}
 	}
+
 	st.Lock()
+	var err error
 	if user != nil {
 		// local user logged-in, set its store macaroons
 		user.StoreMacaroon = macaroon
-		user.StoreDischarges = []string{discharge}
-		// user's email address authenticated by the store
+		user.StoreDischarges = discharges
+		// user's email address authenticated by the store, none
+		// if the credentials were obtained elsewhere as they may be
+		// for another account
 		user.Email = loginData.Email
 		err = auth.UpdateUser(st, user)
 	} else {
@@ -166,7 +169,7 @@ func loginUser(c *Command, r *http.Request, user *auth.UserState) Response {
 			Username:   loginData.Username,
 			Email:      loginData.Email,
 			Macaroon:   macaroon,
-			Discharges: []string{discharge},
+			Discharges: discharges,
 		})
 	}
 	st.Unlock()
@@ -184,6 +187,60 @@ func loginUser(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(result)
 }
 
+func validateStoreMacaroons(macaroon string, discharges []string) error {
+	if _, err := auth.MacaroonDeserialize(macaroon); err != nil {
+		return fmt.Errorf("cannot use store macaroon: %v", err)
+	}
+	if len(discharges) == 0 {
+		return fmt.Errorf("cannot use store macaroon without discharges")
+	}
+	for _, d := range discharges {
+		if _, err := auth.MacaroonDeserialize(d); err != nil {
+			return fmt.Errorf("cannot use store discharge: %v", err)
+		}
+	}
+	return nil
+}
+
+func storeLoginUser(theStore snapstate.StoreService, email, password, otp string) (macaroon, discharge string, rsp Response) {
+	macaroon, discharge, err := theStore.LoginUser(email, password, otp)
+	switch err {
+	case store.ErrAuthenticationNeeds2fa:
+		return "", "", &apiError{
+			Status:  401,
+			Message: err.Error(),
+			Kind:    client.ErrorKindTwoFactorRequired,
+		}
+	case store.Err2faFailed:
+		return "", "", &apiError{
+			Status:  401,
+			Message: err.Error(),
+			Kind:    client.ErrorKindTwoFactorFailed,
+		}
+	default:
+		switch err := err.(type) {
+		case store.InvalidAuthDataError:
+			return "", "", &apiError{
+				Status:  400,
+				Message: err.Error(),
+				Kind:    client.ErrorKindInvalidAuthData,
+				Value:   err,
+			}
+		case store.PasswordPolicyError:
+			return "", "", &apiError{
+				Status:  401,
+				Message: err.Error(),
+				Kind:    client.ErrorKindPasswordPolicy,
+				Value:   err,
+			}
+		}
+		return "", "", Unauthorized(err.Error())
+	case nil:
+		// continue
+	}
+	return macaroon, discharge, nil
+}
+
 func logoutUser(c *Command, r *http.Request, user *auth.UserState) Response {
 	state := c.d.overlord.State()
 	state.Lock()
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"gopkg.in/check.v1"
+	"gopkg.in/macaroon.v1"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/assertstest"
@@ -365,6 +366,68 @@ func (s *userSuite) TestLogoutUser(c *check.C) {
 	c.Check(err, check.Equals, auth.ErrInvalidUser)
 }
 
+func mustSerializeMacaroon(c *check.C, id, location string) string {
+	m, err := macaroon.New([]byte("secret"), id, location)
+	c.Assert(err, check.IsNil)
+	serialized, err := auth.MacaroonSerialize(m)
+	c.Assert(err, check.IsNil)
+	return serialized
+}
+
+func (s *userSuite) TestLoginUserWithStoreMacaroon(c *check.C) {
+	st := s.d.Overlord().State()
+
+	s.expectLoginAccess()
+
+	// the store is not asked to log in
+	s.err = fmt.Errorf("unexpected store login")
+
+	storeMacaroon := mustSerializeMacaroon(c, "root-id", "store")
+	discharge := mustSerializeMacaroon(c, "discharge-id", "sso")
+	buf := bytes.NewBufferString(fmt.Sprintf(`{"email": "email@.com", "macaroon": %q, "discharges": [%q]}`, storeMacaroon, discharge))
+	req, err := http.NewRequest("POST", "/v2/login", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+
+	st.Lock()
+	user, err := auth.User(st, 1)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	// the email address is not vouched for by the store
+	c.Check(user.Email, check.Equals, "")
+	c.Check(user.StoreMacaroon, check.Equals, storeMacaroon)
+	c.Check(user.StoreDischarges, check.DeepEquals, []string{discharge})
+	c.Check(rsp.Result, check.DeepEquals, daemon.UserResponseData{
+		ID:         1,
+		Macaroon:   user.Macaroon,
+		Discharges: user.Discharges,
+	})
+}
+
+func (s *userSuite) TestLoginUserWithBadStoreMacaroon(c *check.C) {
+	s.expectLoginAccess()
+
+	storeMacaroon := mustSerializeMacaroon(c, "root-id", "store")
+	for _, tc := range []struct {
+		data string
+		err  string
+	}{
+		{`{"email": "email@.com", "macaroon": "junk", "discharges": ["junk"]}`, "cannot use store macaroon: .*"},
+		{fmt.Sprintf(`{"email": "email@.com", "macaroon": %q}`, storeMacaroon), "cannot use store macaroon without discharges"},
+		{fmt.Sprintf(`{"email": "email@.com", "macaroon": %q, "discharges": ["junk"]}`, storeMacaroon), "cannot use store discharge: .*"},
+	} {
+		req, err := http.NewRequest("POST", "/v2/login", bytes.NewBufferString(tc.data))
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Kind, check.Equals, client.ErrorKindInvalidAuthData)
+		c.Check(rspe.Message, check.Matches, tc.err)
+	}
+}
+
 func (s *userSuite) TestLoginUserBadRequest(c *check.C) {
 	s.expectLoginAccess()
 
@@ -0,0 +1,215 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+var (
+	// maxAuditLogSize is the size the audit log can reach before it is
+	// rotated.
+	maxAuditLogSize int64 = 4 * 1024 * 1024
+	// auditLogBackups is how many rotated audit logs are kept.
+	auditLogBackups = 3
+)
+
+// auditEntry records a mutating API request.
+type auditEntry struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	// UID and PID are those of the client, if known.
+	UID  *uint32 `json:"uid,omitempty"`
+	PID  int32   `json:"pid,omitempty"`
+	User string  `json:"user,omitempty"`
+	// Status is the HTTP status of the response.
+	Status int `json:"status"`
+	// Change is the ID of the change the request started, if any.
+	Change string `json:"change,omitempty"`
+	// Kind and Snaps are the kind of that change and the snaps it
+	// operates on, telling what the request did.
+	Kind  string   `json:"kind,omitempty"`
+	Snaps []string `json:"snaps,omitempty"`
+}
+
+// auditLog is an append-only log of the mutating API requests, with one
+// JSON entry per line, that is rotated once it grows too big.
+type auditLog struct {
+	mu sync.Mutex
+}
+
+func auditLogBackup(n int) string {
+	return fmt.Sprintf("%s.%d", dirs.SnapdAuditLogFile, n)
+}
+
+// rotate shifts the rotated logs by one, dropping the oldest one, and
+// makes the current log the first of them.
+func (al *auditLog) rotate() error {
+	for n := auditLogBackups; n > 1; n-- {
+		if err := os.Rename(auditLogBackup(n-1), auditLogBackup(n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if auditLogBackups < 1 {
+		return os.Remove(dirs.SnapdAuditLogFile)
+	}
+	return os.Rename(dirs.SnapdAuditLogFile, auditLogBackup(1))
+}
+
+// record appends the entry to the log, rotating it first if needed.
+func (al *auditLog) record(entry *auditEntry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	if fi, err := os.Stat(dirs.SnapdAuditLogFile); err == nil {
+		if fi.Size()+int64(len(buf)) > maxAuditLogSize {
+			if err := al.rotate(); err != nil {
+				return fmt.Errorf("cannot rotate audit log: %v", err)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dirs.SnapdAuditLogFile), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dirs.SnapdAuditLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recordAudit records the mutating request in the audit log, failing to
+// do so does not fail the request.
+func (d *Daemon) recordAudit(r *http.Request, ucred *ucrednet, user *auth.UserState, status int, chgID string) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	entry := &auditEntry{
+		Time:   timeNow().UTC(),
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Status: status,
+		Change: chgID,
+	}
+	if ucred != nil {
+		uid := ucred.Uid
+		entry.UID = &uid
+		entry.PID = ucred.Pid
+	}
+	if user != nil {
+		entry.User = user.Username
+	}
+	if chgID != "" {
+		st := d.overlord.State()
+		st.Lock()
+		if chg := st.Change(chgID); chg != nil {
+			entry.Kind = chg.Kind()
+			// not all changes operate on snaps
+			chg.Get("snap-names", &entry.Snaps)
+		}
+		st.Unlock()
+	}
+	if err := d.auditLog.record(entry); err != nil {
+		logger.Noticef("cannot record %s %s in the audit log: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// auditFilter allows filtering audit entries.
+type auditFilter struct {
+	// Since, if set, includes only entries recorded after this time.
+	Since time.Time
+	// UID, if set, includes only entries of requests by this uid.
+	UID *uint32
+}
+
+func (f *auditFilter) matches(entry *auditEntry) bool {
+	if !f.Since.IsZero() && !entry.Time.After(f.Since) {
+		return false
+	}
+	if f.UID != nil && (entry.UID == nil || *entry.UID != *f.UID) {
+		return false
+	}
+	return true
+}
+
+// entries returns the recorded entries matching the filter, oldest first,
+// including those of the rotated logs.
+func (al *auditLog) entries(filter *auditFilter) ([]*auditEntry, error) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	paths := make([]string, 0, auditLogBackups+1)
+	for n := auditLogBackups; n > 0; n-- {
+		paths = append(paths, auditLogBackup(n))
+	}
+	paths = append(paths, dirs.SnapdAuditLogFile)
+
+	entries := []*auditEntry{}
+	for _, path := range paths {
+		if !osutil.FileExists(path) {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry auditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("cannot decode audit log entry in %s: %v", path, err)
+			}
+			if filter.matches(&entry) {
+				entries = append(entries, &entry)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
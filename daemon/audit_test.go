@@ -0,0 +1,209 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *daemonSuite) TestAuditLogRecordsMutatingRequests(c *check.C) {
+	t0 := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(testutil.Backup(&timeNow))
+	timeNow = func() time.Time { return t0 }
+
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+	cmd.GET = func(*Command, *http.Request, *auth.UserState) Response {
+		return SyncResponse(nil)
+	}
+	cmd.ReadAccess = openAccess{}
+
+	rec := serveAs(cmd, 42, "")
+	c.Assert(rec.Code, check.Equals, 202)
+	var rsp map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+
+	// reads are not recorded
+	req, err := http.NewRequest("GET", "/v2/foo", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=42;socket=%s;", dirs.SnapdSocket)
+	cmd.ServeHTTP(httptest.NewRecorder(), req)
+
+	// refused requests are recorded too
+	cmd.WriteAccess = rootAccess{}
+	rec = serveAs(cmd, 42, "")
+	c.Assert(rec.Code, check.Equals, 403)
+
+	entries, err := d.auditLog.entries(&auditFilter{})
+	c.Assert(err, check.IsNil)
+	uid := uint32(42)
+	c.Check(entries, check.DeepEquals, []*auditEntry{
+		{Time: t0, Method: "POST", UID: &uid, PID: 100, Status: 202, Change: rsp["change"].(string), Kind: "foo"},
+		{Time: t0, Method: "POST", UID: &uid, PID: 100, Status: 403},
+	})
+}
+
+func (s *daemonSuite) TestAuditLogRecordsChangeKindAndSnaps(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
+		st := d.overlord.State()
+		st.Lock()
+		defer st.Unlock()
+		chg := st.NewChange("remove-snap", "...")
+		chg.Set("snap-names", []string{"foo", "bar"})
+		return AsyncResponse(nil, chg.ID())
+	}
+
+	rec := serveAs(cmd, 42, `{"action":"remove","snaps":["foo","bar"]}`)
+	c.Assert(rec.Code, check.Equals, 202)
+
+	entries, err := d.auditLog.entries(&auditFilter{})
+	c.Assert(err, check.IsNil)
+	c.Assert(entries, check.HasLen, 1)
+	c.Check(entries[0].Kind, check.Equals, "remove-snap")
+	c.Check(entries[0].Snaps, check.DeepEquals, []string{"foo", "bar"})
+}
+
+func (s *daemonSuite) TestAuditLogSkipsNoAuditCommands(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+	cmd.NoAudit = true
+
+	rec := serveAs(cmd, 42, "")
+	c.Assert(rec.Code, check.Equals, 202)
+
+	entries, err := d.auditLog.entries(&auditFilter{})
+	c.Assert(err, check.IsNil)
+	c.Check(entries, check.HasLen, 0)
+
+	// snapctl is called by hooks and apps, it is not audited
+	c.Check(snapctlCmd.NoAudit, check.Equals, true)
+}
+
+func (s *daemonSuite) TestAuditLogRotation(c *check.C) {
+	s.AddCleanup(testutil.Backup(&maxAuditLogSize, &auditLogBackups))
+	maxAuditLogSize = 200
+	auditLogBackups = 2
+
+	var al auditLog
+	t0 := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		err := al.record(&auditEntry{
+			Time:   t0.Add(time.Duration(i) * time.Second),
+			Method: "POST",
+			Path:   "/v2/snaps",
+			Status: 202,
+			Change: fmt.Sprint(i),
+		})
+		c.Assert(err, check.IsNil)
+	}
+
+	c.Check(dirs.SnapdAuditLogFile, testutil.FilePresent)
+	c.Check(dirs.SnapdAuditLogFile+".1", testutil.FilePresent)
+	c.Check(dirs.SnapdAuditLogFile+".2", testutil.FilePresent)
+	c.Check(dirs.SnapdAuditLogFile+".3", testutil.FileAbsent)
+
+	// the oldest entries were dropped, the others are in order
+	entries, err := al.entries(&auditFilter{})
+	c.Assert(err, check.IsNil)
+	c.Assert(len(entries) > 0 && len(entries) < 10, check.Equals, true)
+	for i, entry := range entries {
+		c.Check(entry.Change, check.Equals, fmt.Sprint(10-len(entries)+i))
+	}
+}
+
+func (s *daemonSuite) TestGetAuditLog(c *check.C) {
+	d := s.newTestDaemon(c)
+	t0 := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, uid := range []uint32{0, 1000, 1000, 0} {
+		uid := uid
+		err := d.auditLog.record(&auditEntry{
+			Time:   t0.Add(time.Duration(i) * time.Minute),
+			Method: "POST",
+			Path:   "/v2/snaps",
+			UID:    &uid,
+			Status: 202,
+			Change: fmt.Sprint(i + 1),
+		})
+		c.Assert(err, check.IsNil)
+	}
+
+	cmd := *debugAuditLogCmd
+	cmd.d = d
+	get := func(uid int, query url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/v2/debug/audit-log?"+query.Encode(), nil)
+		c.Assert(err, check.IsNil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", uid, dirs.SnapdSocket)
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		return rec
+	}
+	changes := func(rec *httptest.ResponseRecorder) []string {
+		var rsp struct {
+			Result []*auditEntry `json:"result"`
+		}
+		c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+		ids := []string{}
+		for _, entry := range rsp.Result {
+			ids = append(ids, entry.Change)
+		}
+		return ids
+	}
+
+	// only root can read the audit log
+	rec := get(1000, nil)
+	c.Check(rec.Code, check.Equals, 403)
+
+	for _, t := range []struct {
+		query   url.Values
+		changes []string
+	}{
+		{nil, []string{"1", "2", "3", "4"}},
+		{url.Values{"uid": {"1000"}}, []string{"2", "3"}},
+		{url.Values{"since": {t0.Add(time.Minute).Format(time.RFC3339)}}, []string{"3", "4"}},
+		{url.Values{"limit": {"3"}}, []string{"2", "3", "4"}},
+		{url.Values{"uid": {"0"}, "limit": {"1"}}, []string{"4"}},
+	} {
+		rec := get(0, t.query)
+		c.Assert(rec.Code, check.Equals, 200, check.Commentf("%v", t.query))
+		c.Check(changes(rec), check.DeepEquals, t.changes, check.Commentf("%v", t.query))
+	}
+
+	for _, query := range []url.Values{
+		{"uid": {"foo"}},
+		{"since": {"yesterday"}},
+		{"limit": {"-1"}},
+	} {
+		rec := get(0, query)
+		c.Check(rec.Code, check.Equals, 400, check.Commentf("%v", query))
+	}
+}
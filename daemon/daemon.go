@@ -88,6 +88,11 @@ type Daemon struct {
 
 	expectedRebootDidNotHappen bool
 
+	rateLimiter     rateLimiter
+	opsLimiter      opsLimiter
+	auditLog        auditLog
+	idempotencyKeys idempotencyKeys
+
 	mu sync.Mutex
 }
 
@@ -107,14 +112,40 @@ type Command struct {
 	ReadAccess  accessChecker
 	WriteAccess accessChecker
 
+	// NoPendingChangesLimit exempts the command from the limit on
+	// pending changes per client uid, it must be set on commands that
+	// are needed to recover from reaching the limit.
+	NoPendingChangesLimit bool
+
+	// Expensive marks commands whose operations are expensive, like
+	// downloads or regenerating security profiles, these count
+	// against the limit of concurrent operations. Only the PUT and
+	// POST operations are counted unless the command has none.
+	// Commands with only some expensive actions use acquireOperation
+	// instead.
+	Expensive bool
+
+	// NoAudit exempts the command from the audit log, it is set on
+	// commands issued on behalf of snaps rather than by the admin, so
+	// that their volume does not rotate out the relevant entries.
+	NoAudit bool
+
 	d *Daemon
 }
 
 func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ww := &wrappedWriter{w: w}
+	t0 := time.Now()
+	defer func() {
+		c.observeAPIRequest(r.Method, ww.s, time.Since(t0))
+	}()
+	w = ww
+
 	st := c.d.state
 	st.Lock()
 	// TODO Look at the error and fail if there's an attempt to authenticate with invalid data.
 	user, _ := userFromRequest(st, r)
+	limits := configuredAPILimits(st)
 	st.Unlock()
 
 	// check if we are in degradedMode
@@ -130,6 +161,13 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var chgID string
+	if r.Method != "GET" && !c.NoAudit {
+		defer func() {
+			c.d.recordAudit(r, ucred, user, ww.s, chgID)
+		}()
+	}
+
 	ctx := store.WithClientUserAgent(r.Context(), r)
 	r = r.WithContext(ctx)
 
@@ -153,15 +191,90 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// root and snapctl, which hooks and services of snaps rely on, are
+	// not rate limited
+	if ucred != nil && ucred.Uid != 0 && ucred.Socket != dirs.SnapSocket {
+		if rspe := c.d.rateLimiter.check(ucred, limits.rateLimit); rspe != nil {
+			rspe.ServeHTTP(w, r)
+			return
+		}
+	}
+
 	if rspe := access.CheckAccess(c.d, r, ucred, user); rspe != nil {
 		rspe.ServeHTTP(w, r)
 		return
 	}
 
-	rsp := rspf(c, r, user)
+	idempotencyKey, rspe := idempotencyKeyFromRequest(r)
+	if rspe != nil {
+		rspe.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method != "GET" {
+		if rspe := limitRequestBody(w, r); rspe != nil {
+			rspe.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	var idempotentChgID, fingerprint string
+	if idempotencyKey != "" {
+		fingerprint, rspe = requestFingerprint(r)
+		if rspe != nil {
+			rspe.ServeHTTP(w, r)
+			return
+		}
+		var release func()
+		idempotentChgID, release, rspe = c.d.idempotencyKeys.reserve(st, idempotencyKey, fingerprint, ucred)
+		if rspe != nil {
+			rspe.ServeHTTP(w, r)
+			return
+		}
+		if release != nil {
+			// the key is recorded on the change below
+			defer release()
+		}
+	}
+
+	var rsp Response
+	var expensive bool
+	if idempotentChgID != "" {
+		// this is a retry of a request that was already handled,
+		// return the change it created instead of making a new one
+		rsp = AsyncResponse(nil, idempotentChgID)
+	} else {
+		if r.Method != "GET" {
+			if !c.NoPendingChangesLimit {
+				if rspe := checkPendingChanges(st, ucred); rspe != nil {
+					rspe.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+
+		expensive = c.Expensive && (r.Method != "GET" || (c.PUT == nil && c.POST == nil))
+		if expensive {
+			release, rspe := c.d.opsLimiter.acquire(st, limits.maxConcurrentOps)
+			if rspe != nil {
+				rspe.ServeHTTP(w, r)
+				return
+			}
+			defer release()
+		}
+
+		rsp = rspf(c, r, user)
+	}
 
 	if srsp, ok := rsp.(StructuredResponse); ok {
 		rjson := srsp.JSON()
+		chgID = rjson.Change
+
+		recordChangeRequester(st, rjson.Change, ucred)
+		recordIdempotencyKey(st, rjson.Change, idempotencyKey, fingerprint)
+		if expensive {
+			markExpensiveChange(st, rjson.Change)
+		}
 
 		st.Lock()
 		_, rst := restart.Pending(st)
@@ -437,6 +550,22 @@ var (
 	rebootMaxTentatives    = 3
 )
 
+// daemonRestartExpectedDuration is how long snapd is expected to be
+// unavailable while restarting itself.
+var daemonRestartExpectedDuration = 1 * time.Minute
+
+// maintenanceExpectedDuration returns how long the maintenance for the
+// given restart type is expected to last at most.
+func maintenanceExpectedDuration(rst restart.RestartType) time.Duration {
+	switch rst {
+	case restart.RestartDaemon, restart.RestartSocket:
+		return daemonRestartExpectedDuration
+	}
+	// a fallback reboot is scheduled after rebootWaitTimeout in
+	// case the system does not go down by itself
+	return rebootWaitTimeout
+}
+
 func (d *Daemon) updateMaintenanceFile(rst restart.RestartType) error {
 	// for unset restart, just remove the maintenance.json file
 	if rst == restart.RestartUnset {
@@ -449,8 +578,16 @@ func (d *Daemon) updateMaintenanceFile(rst restart.RestartType) error {
 		return nil
 	}
 
-	// otherwise marshal and write it out appropriately
-	b, err := json.Marshal(maintenanceForRestartType(rst))
+	// otherwise marshal and write it out appropriately, including how
+	// long clients should expect snapd to be unavailable
+	maintenance := maintenanceForRestartType(rst)
+	value, _ := maintenance.Value.(map[string]interface{})
+	if value == nil {
+		value = make(map[string]interface{})
+	}
+	value["expected-duration"] = maintenanceExpectedDuration(rst).String()
+	maintenance.Value = value
+	b, err := json.Marshal(maintenance)
 	if err != nil {
 		return err
 	}
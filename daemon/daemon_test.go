@@ -179,6 +179,48 @@ func (s *daemonSuite) TestCommandMethodDispatch(c *check.C) {
 	c.Check(rec.Code, check.Equals, 405)
 }
 
+func (s *daemonSuite) TestCommandRecordsMetrics(c *check.C) {
+	cmd := &Command{
+		Path: "/v2/test-metrics",
+		GET: func(*Command, *http.Request, *auth.UserState) Response {
+			return SyncResponse(nil)
+		},
+		ReadAccess: openAccess{},
+		POST: func(*Command, *http.Request, *auth.UserState) Response {
+			return BadRequest("nope")
+		},
+		WriteAccess: authenticatedAccess{},
+		d:           s.newTestDaemon(c),
+	}
+
+	okBefore := apiRequests.Value("GET", "/v2/test-metrics", "200")
+	unauthorizedBefore := apiRequests.Value("POST", "/v2/test-metrics", "401")
+	badBefore := apiRequests.Value("POST", "/v2/test-metrics", "400")
+	durationsBefore := apiRequestDuration.Count("POST", "/v2/test-metrics")
+
+	for _, t := range []struct {
+		method string
+		uid    int
+		code   int
+	}{
+		{"GET", 1001, 200},
+		{"POST", 1001, 401},
+		{"POST", 0, 400},
+	} {
+		req, err := http.NewRequest(t.method, "/v2/test-metrics", nil)
+		c.Assert(err, check.IsNil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", t.uid, dirs.SnapdSocket)
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		c.Check(rec.Code, check.Equals, t.code)
+	}
+
+	c.Check(apiRequests.Value("GET", "/v2/test-metrics", "200")-okBefore, check.Equals, 1.0)
+	c.Check(apiRequests.Value("POST", "/v2/test-metrics", "401")-unauthorizedBefore, check.Equals, 1.0)
+	c.Check(apiRequests.Value("POST", "/v2/test-metrics", "400")-badBefore, check.Equals, 1.0)
+	c.Check(apiRequestDuration.Count("POST", "/v2/test-metrics")-durationsBefore, check.Equals, uint64(2))
+}
+
 func (s *daemonSuite) TestCommandMethodDispatchRoot(c *check.C) {
 	fakeUserAgent := "some-agent-talking-to-snapd/1.0"
 
@@ -724,6 +766,19 @@ func (s *daemonSuite) TestRestartWiring(c *check.C) {
 	stoppedYet = true
 
 	c.Assert(s.notified, check.DeepEquals, []string{"EXTEND_TIMEOUT_USEC=30000000", "READY=1", "STOPPING=1"})
+
+	// the maintenance.json file describes the restart for clients
+	b, err := ioutil.ReadFile(dirs.SnapdMaintenanceFile)
+	c.Assert(err, check.IsNil)
+	maintErr := &errorResult{}
+	c.Assert(json.Unmarshal(b, maintErr), check.IsNil)
+	c.Check(maintErr, check.DeepEquals, &errorResult{
+		Kind:    client.ErrorKindDaemonRestart,
+		Message: daemonRestartMsg,
+		Value: map[string]interface{}{
+			"expected-duration": "1m0s",
+		},
+	})
 }
 
 func (s *daemonSuite) TestGracefulStop(c *check.C) {
@@ -1050,11 +1105,12 @@ func (s *daemonSuite) testRestartSystemWiring(c *check.C, prep func(d *Daemon),
 	c.Assert(json.Unmarshal(b, maintErr), check.IsNil)
 	c.Check(maintErr.Kind, check.Equals, client.ErrorKindSystemRestart)
 	c.Check(maintErr.Value, check.DeepEquals, map[string]interface{}{
-		"op": expectedOp,
+		"op":                expectedOp,
+		"expected-duration": rebootWaitTimeout.String(),
 	})
 
 	exp := maintenanceForRestartType(restartKind)
-	c.Assert(maintErr, check.DeepEquals, exp)
+	c.Check(maintErr.Message, check.Equals, exp.Message)
 }
 
 func (s *daemonSuite) TestRestartSystemGracefulWiring(c *check.C) {
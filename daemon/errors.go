@@ -22,8 +22,11 @@ package daemon
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/snapcore/snapd/arch"
 	"github.com/snapcore/snapd/client"
@@ -42,6 +45,9 @@ type apiError struct {
 	// Kind is the error kind. See client/errors.go
 	Kind  client.ErrorKind
 	Value errorValue
+	// RetryAfter, if set, is sent to the client as the Retry-After
+	// header.
+	RetryAfter time.Duration
 }
 
 func (ae *apiError) Error() string {
@@ -67,6 +73,10 @@ func (ae *apiError) JSON() *respJSON {
 }
 
 func (ae *apiError) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ae.RetryAfter > 0 {
+		secs := int64(math.Ceil(ae.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	}
 	ae.JSON().ServeHTTP(w, r)
 }
 
@@ -275,6 +285,52 @@ func InterfacesUnchanged(format string, v ...interface{}) *apiError {
 	}
 }
 
+// TooManyChanges is an error responder used when a client has reached
+// the limit of pending changes.
+func TooManyChanges(limit int) *apiError {
+	return &apiError{
+		Status:  429,
+		Message: fmt.Sprintf("cannot request more changes: too many changes pending (limit %d)", limit),
+		Kind:    client.ErrorKindTooManyChanges,
+		Value:   map[string]interface{}{"limit": limit},
+	}
+}
+
+// RateLimited is an error responder used when a client has made more
+// requests than allowed by the rate limit.
+func RateLimited(limit int, retryAfter time.Duration) *apiError {
+	return &apiError{
+		Status:     429,
+		Message:    fmt.Sprintf("too many requests (limit %d per minute)", limit),
+		Kind:       client.ErrorKindRateLimited,
+		Value:      map[string]interface{}{"limit": limit},
+		RetryAfter: retryAfter,
+	}
+}
+
+// TooManyOperations is an error responder used when the limit of
+// expensive operations in progress at the same time has been reached.
+func TooManyOperations(limit int, retryAfter time.Duration) *apiError {
+	return &apiError{
+		Status:     429,
+		Message:    fmt.Sprintf("cannot start operation: too many operations in progress (limit %d)", limit),
+		Kind:       client.ErrorKindTooManyOperations,
+		Value:      map[string]interface{}{"limit": limit},
+		RetryAfter: retryAfter,
+	}
+}
+
+// RequestTooLarge is an error responder used when the request body is
+// larger than allowed.
+func RequestTooLarge(limit int64) *apiError {
+	return &apiError{
+		Status:  413,
+		Message: fmt.Sprintf("request body is too large (limit %d bytes)", limit),
+		Kind:    client.ErrorKindRequestTooLarge,
+		Value:   map[string]interface{}{"limit": limit},
+	}
+}
+
 func errToResponse(err error, snaps []string, fallback errorResponder, format string, v ...interface{}) *apiError {
 	var kind client.ErrorKind
 	var snapName string
@@ -20,5 +20,6 @@
 package daemon
 
 type (
-	SeedingInfo = seedingInfo
+	SeedingInfo   = seedingInfo
+	SeedTaskError = seedTaskError
 )
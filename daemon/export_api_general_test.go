@@ -56,3 +56,11 @@ func MockWarningsAccessors(okay func(*state.State, time.Time) int, all func(*sta
 type (
 	ChangeInfo = changeInfo
 )
+
+func MockCgroupVersion(version int, err error) (restore func()) {
+	old := cgroupVersion
+	cgroupVersion = func() (int, error) {
+		return version, err
+	}
+	return func() { cgroupVersion = old }
+}
@@ -72,6 +72,14 @@ func MockSnapshotRestore(newRestore func(*state.State, uint64, []string, []strin
 	}
 }
 
+func MockSnapshotRevertData(newRevertData func(*state.State, string, snap.Revision, []string) (uint64, *state.TaskSet, error)) (restore func()) {
+	oldRevertData := snapshotRevertData
+	snapshotRevertData = newRevertData
+	return func() {
+		snapshotRevertData = oldRevertData
+	}
+}
+
 func MockSnapshotForget(newForget func(*state.State, uint64, []string) ([]string, *state.TaskSet, error)) (restore func()) {
 	oldForget := snapshotForget
 	snapshotForget = newForget
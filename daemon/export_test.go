@@ -27,6 +27,7 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/snapcore/snapd/aspects"
+	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/overlord"
@@ -356,3 +357,17 @@ func MockRebootNoticeWait(d time.Duration) (restore func()) {
 	rebootNoticeWait = d
 	return restore
 }
+
+func MockConfigstateApplyConfigProfile(f func(st *state.State, profile *asserts.ConfigProfile) (*state.Change, error)) (restore func()) {
+	restore = testutil.Backup(&configstateApplyConfigProfile)
+	configstateApplyConfigProfile = f
+	return restore
+}
+
+func MockOsutilDirSize(f func(path string) (uint64, error)) (restore func()) {
+	old := osutilDirSize
+	osutilDirSize = f
+	return func() {
+		osutilDirSize = old
+	}
+}
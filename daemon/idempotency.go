@@ -0,0 +1,193 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"unicode"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+const (
+	// idempotencyKeyHeader is the header clients use to mark retries of
+	// the same POST request.
+	idempotencyKeyHeader = "Idempotency-Key"
+	// changeIdempotencyKey is the change data key recording the
+	// idempotency key of the request that created the change.
+	changeIdempotencyKey = "idempotency-key"
+	// changeIdempotencyRequest is the change data key recording the
+	// fingerprint of the request that created the change.
+	changeIdempotencyRequest = "idempotency-request"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyKeyFromRequest returns the idempotency key of a POST
+// request, if any.
+func idempotencyKeyFromRequest(r *http.Request) (string, *apiError) {
+	if r.Method != "POST" {
+		return "", nil
+	}
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key == "" {
+		return "", nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return "", BadRequest("invalid %s header: longer than %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+	}
+	for _, r := range key {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return "", BadRequest("invalid %s header: only printable ASCII characters are allowed", idempotencyKeyHeader)
+		}
+	}
+	return key, nil
+}
+
+// requestFingerprint returns a digest of the method, path and body of the
+// request, binding an idempotency key to the request it was first used
+// with. The body of JSON requests is read and then put back, its size is
+// limited already. Uploads are streamed to disk by their endpoints, so
+// only their media type is part of the digest, without parameters such
+// as the multipart boundary which changes with every attempt.
+func requestFingerprint(r *http.Request) (string, *apiError) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", r.Method, r.URL.Path)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case r.Body == nil:
+	case mediaType == "" || mediaType == "application/json":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return "", BadRequest("cannot read request body: %v", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	default:
+		fmt.Fprintf(h, "%s", mediaType)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type idempotencyKeyOwner struct {
+	key    string
+	uid    uint32
+	hasUID bool
+}
+
+// idempotencyKeys tracks the idempotency keys of the requests being
+// handled, so that a retry sent while the first request is still being
+// handled does not create another change.
+type idempotencyKeys struct {
+	mu sync.Mutex
+	// pending maps the keys being handled to the fingerprint of
+	// their request
+	pending map[idempotencyKeyOwner]string
+}
+
+// reserve returns the id of the change created by an earlier request with
+// the given idempotency key and fingerprint, if any. Otherwise it reserves
+// the key until the returned release function is called, which must happen
+// once the key was recorded on the change created by the request.
+func (k *idempotencyKeys) reserve(st *state.State, key, fingerprint string, ucred *ucrednet) (changeID string, release func(), rspe *apiError) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	chgID, chgFingerprint := changeForIdempotencyKey(st, key, ucred)
+	if chgID != "" {
+		if chgFingerprint != fingerprint {
+			return "", nil, idempotencyKeyReused()
+		}
+		return chgID, nil, nil
+	}
+
+	owner := idempotencyKeyOwner{key: key}
+	if ucred != nil {
+		owner.uid = ucred.Uid
+		owner.hasUID = true
+	}
+	if pendingFingerprint, ok := k.pending[owner]; ok {
+		if pendingFingerprint != fingerprint {
+			return "", nil, idempotencyKeyReused()
+		}
+		return "", nil, Conflict("a request with the same %s header is being handled, retry later", idempotencyKeyHeader)
+	}
+	if k.pending == nil {
+		k.pending = make(map[idempotencyKeyOwner]string)
+	}
+	k.pending[owner] = fingerprint
+	release = func() {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		delete(k.pending, owner)
+	}
+	return "", release, nil
+}
+
+func idempotencyKeyReused() *apiError {
+	return BadRequest("%s header already used for a different request", idempotencyKeyHeader)
+}
+
+// changeForIdempotencyKey returns the id of the change created by an
+// earlier request of the same client with the given idempotency key and
+// the fingerprint of that request, or "" if there is none. Keys are only
+// remembered as long as their change.
+func changeForIdempotencyKey(st *state.State, key string, ucred *ucrednet) (changeID, fingerprint string) {
+	st.Lock()
+	defer st.Unlock()
+	for _, chg := range st.Changes() {
+		var chgKey string
+		if err := chg.Get(changeIdempotencyKey, &chgKey); err != nil || chgKey != key {
+			continue
+		}
+		var requestedBy uint32
+		err := chg.Get(changeRequestedByUIDKey, &requestedBy)
+		switch {
+		case ucred == nil && err == nil:
+			continue
+		case ucred != nil && (err != nil || requestedBy != ucred.Uid):
+			continue
+		}
+		chg.Get(changeIdempotencyRequest, &fingerprint)
+		return chg.ID(), fingerprint
+	}
+	return "", ""
+}
+
+// recordIdempotencyKey records the idempotency key and the fingerprint of
+// the request that created the change with the given id.
+func recordIdempotencyKey(st *state.State, changeID string, key, fingerprint string) {
+	if changeID == "" || key == "" {
+		return
+	}
+	st.Lock()
+	defer st.Unlock()
+	if chg := st.Change(changeID); chg != nil {
+		chg.Set(changeIdempotencyKey, key)
+		chg.Set(changeIdempotencyRequest, fingerprint)
+	}
+}
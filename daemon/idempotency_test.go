@@ -0,0 +1,180 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+func serveWithIdempotencyKey(cmd *Command, uid int, key string) *httptest.ResponseRecorder {
+	return serveWithIdempotencyKeyAndBody(cmd, uid, key, "")
+}
+
+func serveWithIdempotencyKeyAndBody(cmd *Command, uid int, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "", strings.NewReader(body))
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", uid, dirs.SnapdSocket)
+	req.Header.Set("Idempotency-Key", key)
+	rec := httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	return rec
+}
+
+func changeIDFromRecorder(c *check.C, rec *httptest.ResponseRecorder) string {
+	c.Assert(rec.Code, check.Equals, 202)
+	var rsp map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	return rsp["change"].(string)
+}
+
+func (s *daemonSuite) TestIdempotencyKey(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+
+	chgID := changeIDFromRecorder(c, serveWithIdempotencyKey(cmd, 42, "key-1"))
+
+	// a retry returns the same change
+	c.Check(changeIDFromRecorder(c, serveWithIdempotencyKey(cmd, 42, "key-1")), check.Equals, chgID)
+
+	// but not for a different key, or for another user
+	c.Check(changeIDFromRecorder(c, serveWithIdempotencyKey(cmd, 42, "key-2")), check.Not(check.Equals), chgID)
+	c.Check(changeIDFromRecorder(c, serveWithIdempotencyKey(cmd, 1000, "key-1")), check.Not(check.Equals), chgID)
+
+	// nor without a key
+	c.Check(changeIDFromRecorder(c, serveAs(cmd, 42, "")), check.Not(check.Equals), chgID)
+
+	st := d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 4)
+	var key string
+	c.Assert(st.Change(chgID).Get("idempotency-key", &key), check.IsNil)
+	c.Check(key, check.Equals, "key-1")
+}
+
+func errorMessageFromRecorder(c *check.C, rec *httptest.ResponseRecorder) string {
+	var rsp map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	return rsp["result"].(map[string]interface{})["message"].(string)
+}
+
+func (s *daemonSuite) TestIdempotencyKeyBoundToRequest(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+
+	chgID := changeIDFromRecorder(c, serveWithIdempotencyKeyAndBody(cmd, 42, "key-1", `{"action":"install"}`))
+	c.Check(changeIDFromRecorder(c, serveWithIdempotencyKeyAndBody(cmd, 42, "key-1", `{"action":"install"}`)), check.Equals, chgID)
+
+	// the body is still available to the handler
+	var body []byte
+	cmd.POST = func(_ *Command, r *http.Request, _ *auth.UserState) Response {
+		body, _ = io.ReadAll(r.Body)
+		return SyncResponse(nil)
+	}
+	rec := serveWithIdempotencyKeyAndBody(cmd, 42, "key-2", `{"action":"remove"}`)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(string(body), check.Equals, `{"action":"remove"}`)
+
+	rec = serveWithIdempotencyKeyAndBody(cmd, 42, "key-1", `{"action":"remove"}`)
+	c.Check(rec.Code, check.Equals, 400)
+	c.Check(errorMessageFromRecorder(c, rec), check.Equals, "Idempotency-Key header already used for a different request")
+}
+
+func (s *daemonSuite) TestIdempotencyKeyUploadRetry(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+
+	upload := func(key, boundary string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf("--%s\r\nContent-Disposition: form-data; name=\"snap\"; filename=\"x\"\r\n\r\nxyzzy\r\n--%s--\r\n", boundary, boundary)
+		req, _ := http.NewRequest("POST", "", strings.NewReader(body))
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=42;socket=%s;", dirs.SnapdSocket)
+		req.Header.Set("Idempotency-Key", key)
+		req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// a retried upload has a different boundary
+	chgID := changeIDFromRecorder(c, upload("key-1", "foo"))
+	c.Check(changeIDFromRecorder(c, upload("key-1", "quux")), check.Equals, chgID)
+
+	st := d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 1)
+}
+
+func (s *daemonSuite) TestIdempotencyKeyReservedWhileHandled(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+	handle := cmd.POST
+
+	var retry *httptest.ResponseRecorder
+	cmd.POST = func(cmd *Command, r *http.Request, user *auth.UserState) Response {
+		// the client retries before the first request is answered
+		retry = serveWithIdempotencyKey(cmd, 42, "key-1")
+		return handle(cmd, r, user)
+	}
+	changeIDFromRecorder(c, serveWithIdempotencyKey(cmd, 42, "key-1"))
+
+	c.Assert(retry, check.NotNil)
+	c.Check(retry.Code, check.Equals, 409)
+	c.Check(errorMessageFromRecorder(c, retry), check.Equals, "a request with the same Idempotency-Key header is being handled, retry later")
+
+	// once answered a retry gets the change
+	cmd.POST = handle
+	st := d.overlord.State()
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 1)
+	st.Unlock()
+	changeIDFromRecorder(c, serveWithIdempotencyKey(cmd, 42, "key-1"))
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 1)
+}
+
+func (s *daemonSuite) TestIdempotencyKeyInvalid(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+
+	for _, t := range []struct {
+		key string
+		err string
+	}{
+		{strings.Repeat("x", 256), "invalid Idempotency-Key header: longer than 255 characters"},
+		{"ключ", "invalid Idempotency-Key header: only printable ASCII characters are allowed"},
+	} {
+		rec := serveWithIdempotencyKey(cmd, 42, t.key)
+		c.Check(rec.Code, check.Equals, 400)
+		var rsp map[string]interface{}
+		c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+		c.Check(rsp["result"].(map[string]interface{})["message"], check.Equals, t.err)
+	}
+}
@@ -0,0 +1,275 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"math"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var (
+	// maxPendingChangesPerUID is the maximum number of changes that can
+	// be pending at the same time on behalf of a single client uid.
+	maxPendingChangesPerUID = 50
+	// maxRequestBodySize is the maximum size of a JSON request body,
+	// uploads like snaps or snapshots are not subject to it.
+	maxRequestBodySize int64 = 4 * 1024 * 1024
+	// defaultMaxConcurrentOperations is the maximum number of expensive
+	// operations that can be in progress at the same time when
+	// api.max-concurrent-operations is not set, 0 means no limit.
+	defaultMaxConcurrentOperations = 0
+	// operationsRetryAfter is how long clients are told to wait before
+	// retrying when too many expensive operations are in progress.
+	operationsRetryAfter = 5 * time.Second
+
+	timeNow = time.Now
+)
+
+const (
+	// changeRequestedByUIDKey is the change data key recording the uid of
+	// the client that requested the change.
+	changeRequestedByUIDKey = "requested-by-uid"
+	// expensiveChangeKey is the change data key marking changes that
+	// were requested through an expensive command.
+	expensiveChangeKey = "expensive-operation"
+)
+
+// limitRequestBody rejects JSON requests whose declared body is larger than
+// maxRequestBodySize, and otherwise makes sure that no more than that is read
+// from the body.
+func limitRequestBody(w http.ResponseWriter, r *http.Request) *apiError {
+	if r.Body == nil {
+		return nil
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			// uploads are streamed to disk and handled by their
+			// own endpoints
+			return nil
+		}
+	}
+	if r.ContentLength > maxRequestBodySize {
+		return RequestTooLarge(maxRequestBodySize)
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+	return nil
+}
+
+// pendingChangesForUID returns the number of changes not yet ready that were
+// requested by the given uid.
+func pendingChangesForUID(st *state.State, uid uint32) int {
+	n := 0
+	for _, chg := range st.Changes() {
+		if chg.IsReady() {
+			continue
+		}
+		var requestedBy uint32
+		if err := chg.Get(changeRequestedByUIDKey, &requestedBy); err != nil {
+			continue
+		}
+		if requestedBy == uid {
+			n++
+		}
+	}
+	return n
+}
+
+// checkPendingChanges returns an error if the client with the given uid has
+// reached the limit of pending changes.
+func checkPendingChanges(st *state.State, ucred *ucrednet) *apiError {
+	if ucred == nil {
+		return nil
+	}
+	st.Lock()
+	defer st.Unlock()
+	if pending := pendingChangesForUID(st, ucred.Uid); pending >= maxPendingChangesPerUID {
+		return TooManyChanges(maxPendingChangesPerUID)
+	}
+	return nil
+}
+
+// recordChangeRequester records the uid of the client that requested the
+// change with the given id.
+func recordChangeRequester(st *state.State, changeID string, ucred *ucrednet) {
+	if ucred == nil || changeID == "" {
+		return
+	}
+	st.Lock()
+	defer st.Unlock()
+	if chg := st.Change(changeID); chg != nil {
+		chg.Set(changeRequestedByUIDKey, ucred.Uid)
+	}
+}
+
+// markExpensiveChange marks the change with the given id as an expensive
+// operation that counts against the limit of concurrent operations until it
+// is ready.
+func markExpensiveChange(st *state.State, changeID string) {
+	if changeID == "" {
+		return
+	}
+	st.Lock()
+	defer st.Unlock()
+	if chg := st.Change(changeID); chg != nil {
+		chg.Set(expensiveChangeKey, true)
+	}
+}
+
+// acquireOperation reserves a slot for an expensive operation on behalf of a
+// command that only knows once the request is decoded whether it is
+// expensive, see Command.Expensive. It must be called without holding the
+// state lock. The returned release function must be called once the request
+// has been served, and the change started marked with expensiveChangeKey.
+func (c *Command) acquireOperation() (release func(), rspe *apiError) {
+	st := c.d.overlord.State()
+	st.Lock()
+	limits := configuredAPILimits(st)
+	st.Unlock()
+	return c.d.opsLimiter.acquire(st, limits.maxConcurrentOps)
+}
+
+// apiLimits holds the limits on API requests that can be configured with
+// the api.* system options.
+type apiLimits struct {
+	// rateLimit is the number of requests per minute allowed to each
+	// client uid, 0 means no limit.
+	rateLimit int
+	// maxConcurrentOps is the maximum number of expensive operations
+	// that can be in progress at the same time, 0 means no limit.
+	maxConcurrentOps int
+}
+
+// configuredAPILimits returns the API limits as currently configured,
+// falling back to the defaults for unset options.
+func configuredAPILimits(st *state.State) apiLimits {
+	limits := apiLimits{
+		maxConcurrentOps: defaultMaxConcurrentOperations,
+	}
+	tr := config.NewTransaction(st)
+	if err := tr.GetMaybe("core", "api.rate-limit", &limits.rateLimit); err != nil {
+		logger.Noticef("cannot get api.rate-limit option: %v", err)
+	}
+	if err := tr.GetMaybe("core", "api.max-concurrent-operations", &limits.maxConcurrentOps); err != nil {
+		logger.Noticef("cannot get api.max-concurrent-operations option: %v", err)
+	}
+	return limits
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter limits the rate of requests of each client uid, using a
+// token bucket per uid that holds up to a minute worth of requests and is
+// refilled continuously.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[uint32]*tokenBucket
+}
+
+// check consumes a request from the bucket of the client with the given
+// uid, it returns an error if the client has exceeded the given number of
+// requests per minute.
+func (rl *rateLimiter) check(ucred *ucrednet, perMinute int) *apiError {
+	if ucred == nil || perMinute <= 0 {
+		return nil
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := timeNow()
+	if rl.buckets == nil {
+		rl.buckets = make(map[uint32]*tokenBucket)
+	}
+	b := rl.buckets[ucred.Uid]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(perMinute), last: now}
+		rl.buckets[ucred.Uid] = b
+	}
+
+	rate := float64(perMinute) / time.Minute.Seconds()
+	b.tokens = math.Min(float64(perMinute), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		retryAfter := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return RateLimited(perMinute, retryAfter)
+	}
+	b.tokens--
+	return nil
+}
+
+// pendingExpensiveChanges returns the number of changes not yet ready that
+// were requested through an expensive command.
+func pendingExpensiveChanges(st *state.State) int {
+	n := 0
+	for _, chg := range st.Changes() {
+		if chg.IsReady() {
+			continue
+		}
+		var expensive bool
+		if err := chg.Get(expensiveChangeKey, &expensive); err == nil && expensive {
+			n++
+		}
+	}
+	return n
+}
+
+// opsLimiter caps the number of expensive operations in progress at the
+// same time, counting both the requests being served and the changes they
+// started that are not yet ready.
+type opsLimiter struct {
+	mu         sync.Mutex
+	inProgress int
+}
+
+// acquire reserves a slot for an expensive operation, the returned release
+// function must be called once the request has been served. A max of 0
+// means no limit.
+func (ol *opsLimiter) acquire(st *state.State, max int) (release func(), rspe *apiError) {
+	if max <= 0 {
+		return func() {}, nil
+	}
+
+	st.Lock()
+	pending := pendingExpensiveChanges(st)
+	st.Unlock()
+
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+	if ol.inProgress+pending >= max {
+		return nil, TooManyOperations(max, operationsRetryAfter)
+	}
+	ol.inProgress++
+	return func() {
+		ol.mu.Lock()
+		defer ol.mu.Unlock()
+		ol.inProgress--
+	}, nil
+}
@@ -0,0 +1,322 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *daemonSuite) changeCommand(c *check.C, d *Daemon) *Command {
+	cmd := &Command{d: d}
+	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
+		st := d.overlord.State()
+		st.Lock()
+		defer st.Unlock()
+		chg := st.NewChange("foo", "...")
+		return AsyncResponse(nil, chg.ID())
+	}
+	cmd.WriteAccess = openAccess{}
+	return cmd
+}
+
+func serveAs(cmd *Command, uid int, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "", strings.NewReader(body))
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", uid, dirs.SnapdSocket)
+	rec := httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *daemonSuite) TestPendingChangesLimitPerUID(c *check.C) {
+	s.AddCleanup(testutil.Backup(&maxPendingChangesPerUID))
+	maxPendingChangesPerUID = 2
+
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+
+	for i := 0; i < 2; i++ {
+		rec := serveAs(cmd, 42, "")
+		c.Check(rec.Code, check.Equals, 202)
+	}
+
+	// the third one is refused
+	rec := serveAs(cmd, 42, "")
+	c.Check(rec.Code, check.Equals, 429)
+	var rsp map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	c.Check(rsp["result"], check.DeepEquals, map[string]interface{}{
+		"message": "cannot request more changes: too many changes pending (limit 2)",
+		"kind":    "too-many-changes",
+		"value":   map[string]interface{}{"limit": 2.0},
+	})
+
+	// other uids are not affected
+	rec = serveAs(cmd, 1000, "")
+	c.Check(rec.Code, check.Equals, 202)
+
+	st := d.overlord.State()
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 3)
+	c.Check(pendingChangesForUID(st, 42), check.Equals, 2)
+	c.Check(pendingChangesForUID(st, 1000), check.Equals, 1)
+	// once a change is ready another one can be requested
+	for _, chg := range st.Changes() {
+		var uid uint32
+		c.Assert(chg.Get("requested-by-uid", &uid), check.IsNil)
+		if uid == 42 {
+			chg.SetStatus(state.DoneStatus)
+			break
+		}
+	}
+	st.Unlock()
+
+	rec = serveAs(cmd, 42, "")
+	c.Check(rec.Code, check.Equals, 202)
+}
+
+func (s *daemonSuite) TestPendingChangesLimitExemptCommand(c *check.C) {
+	s.AddCleanup(testutil.Backup(&maxPendingChangesPerUID))
+	maxPendingChangesPerUID = 0
+
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+
+	rec := serveAs(cmd, 42, "")
+	c.Check(rec.Code, check.Equals, 429)
+
+	cmd.NoPendingChangesLimit = true
+	rec = serveAs(cmd, 42, "")
+	c.Check(rec.Code, check.Equals, 202)
+}
+
+func (s *daemonSuite) TestRequestBodyLimit(c *check.C) {
+	s.AddCleanup(testutil.Backup(&maxRequestBodySize))
+	maxRequestBodySize = 10
+
+	d := s.newTestDaemon(c)
+	var readErr error
+	cmd := &Command{d: d}
+	cmd.POST = func(_ *Command, r *http.Request, _ *auth.UserState) Response {
+		_, readErr = ioutil.ReadAll(r.Body)
+		return SyncResponse(nil)
+	}
+	cmd.WriteAccess = openAccess{}
+
+	// within the limit
+	rec := serveAs(cmd, 42, `{"a": 1}`)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(readErr, check.IsNil)
+
+	// the declared length is too large
+	rec = serveAs(cmd, 42, `{"action": "install"}`)
+	c.Check(rec.Code, check.Equals, 413)
+	var rsp map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	c.Check(rsp["result"], check.DeepEquals, map[string]interface{}{
+		"message": "request body is too large (limit 10 bytes)",
+		"kind":    "request-too-large",
+		"value":   map[string]interface{}{"limit": 10.0},
+	})
+
+	// no declared length, reading stops at the limit
+	req, err := http.NewRequest("POST", "", ioutil.NopCloser(bytes.NewBufferString(`{"action": "install"}`)))
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=42;socket=%s;", dirs.SnapdSocket)
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(readErr, check.ErrorMatches, "http: request body too large")
+
+	// uploads are not limited
+	req, err = http.NewRequest("POST", "", strings.NewReader(strings.Repeat("x", 100)))
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=42;socket=%s;", dirs.SnapdSocket)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=foo")
+	rec = httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(readErr, check.IsNil)
+}
+
+func (s *daemonSuite) setAPIOption(c *check.C, d *Daemon, key string, value interface{}) {
+	st := d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", key, value), check.IsNil)
+	tr.Commit()
+}
+
+func (s *daemonSuite) TestRateLimitPerUID(c *check.C) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.AddCleanup(testutil.Backup(&timeNow))
+	timeNow = func() time.Time { return now }
+
+	d := s.newTestDaemon(c)
+	cmd := &Command{d: d}
+	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
+		return SyncResponse(nil)
+	}
+	cmd.WriteAccess = openAccess{}
+
+	// not limited by default
+	for i := 0; i < 10; i++ {
+		rec := serveAs(cmd, 42, "")
+		c.Check(rec.Code, check.Equals, 200)
+	}
+
+	s.setAPIOption(c, d, "api.rate-limit", 2)
+
+	for i := 0; i < 2; i++ {
+		rec := serveAs(cmd, 1000, "")
+		c.Check(rec.Code, check.Equals, 200)
+	}
+	rec := serveAs(cmd, 1000, "")
+	c.Check(rec.Code, check.Equals, 429)
+	c.Check(rec.Header().Get("Retry-After"), check.Equals, "30")
+	var rsp map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	c.Check(rsp["result"], check.DeepEquals, map[string]interface{}{
+		"message": "too many requests (limit 2 per minute)",
+		"kind":    "rate-limited",
+		"value":   map[string]interface{}{"limit": 2.0},
+	})
+
+	// other uids are not affected
+	rec = serveAs(cmd, 1001, "")
+	c.Check(rec.Code, check.Equals, 200)
+
+	// the bucket refills over time
+	now = now.Add(20 * time.Second)
+	rec = serveAs(cmd, 1000, "")
+	c.Check(rec.Code, check.Equals, 429)
+	c.Check(rec.Header().Get("Retry-After"), check.Equals, "10")
+	now = now.Add(10 * time.Second)
+	rec = serveAs(cmd, 1000, "")
+	c.Check(rec.Code, check.Equals, 200)
+
+	// root is not limited
+	for i := 0; i < 3; i++ {
+		rec := serveAs(cmd, 0, "")
+		c.Check(rec.Code, check.Equals, 200)
+	}
+
+	// nor is snapctl
+	cmd.WriteAccess = snapAccess{}
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("POST", "", nil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=1002;socket=%s;", dirs.SnapSocket)
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		c.Check(rec.Code, check.Equals, 200)
+	}
+	cmd.WriteAccess = openAccess{}
+
+	// disabling the limit lets everything through again
+	s.setAPIOption(c, d, "api.rate-limit", 0)
+	rec = serveAs(cmd, 1000, "")
+	c.Check(rec.Code, check.Equals, 200)
+}
+
+func (s *daemonSuite) TestConcurrentOperationsLimit(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+	cmd.Expensive = true
+
+	s.setAPIOption(c, d, "api.max-concurrent-operations", 1)
+
+	rec := serveAs(cmd, 42, "")
+	c.Check(rec.Code, check.Equals, 202)
+
+	// the change started by the first request is still in progress
+	rec = serveAs(cmd, 1000, "")
+	c.Check(rec.Code, check.Equals, 429)
+	c.Check(rec.Header().Get("Retry-After"), check.Equals, "5")
+	var rsp map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	c.Check(rsp["result"], check.DeepEquals, map[string]interface{}{
+		"message": "cannot start operation: too many operations in progress (limit 1)",
+		"kind":    "too-many-operations",
+		"value":   map[string]interface{}{"limit": 1.0},
+	})
+
+	// commands that are not expensive are not affected
+	cheap := s.changeCommand(c, d)
+	rec = serveAs(cheap, 1000, "")
+	c.Check(rec.Code, check.Equals, 202)
+
+	st := d.overlord.State()
+	st.Lock()
+	c.Check(pendingExpensiveChanges(st), check.Equals, 1)
+	for _, chg := range st.Changes() {
+		chg.SetStatus(state.DoneStatus)
+	}
+	c.Check(pendingExpensiveChanges(st), check.Equals, 0)
+	st.Unlock()
+
+	rec = serveAs(cmd, 1000, "")
+	c.Check(rec.Code, check.Equals, 202)
+}
+
+func (s *daemonSuite) TestConcurrentOperationsNoLimitByDefault(c *check.C) {
+	d := s.newTestDaemon(c)
+	cmd := s.changeCommand(c, d)
+	cmd.Expensive = true
+
+	for i := 0; i < 10; i++ {
+		rec := serveAs(cmd, 1000, "")
+		c.Check(rec.Code, check.Equals, 202)
+	}
+}
+
+func (s *daemonSuite) TestConcurrentOperationsInProgress(c *check.C) {
+	d := s.newTestDaemon(c)
+	st := d.overlord.State()
+
+	release1, rspe := d.opsLimiter.acquire(st, 2)
+	c.Assert(rspe, check.IsNil)
+	release2, rspe := d.opsLimiter.acquire(st, 2)
+	c.Assert(rspe, check.IsNil)
+
+	_, rspe = d.opsLimiter.acquire(st, 2)
+	c.Check(rspe, check.NotNil)
+
+	release1()
+	release3, rspe := d.opsLimiter.acquire(st, 2)
+	c.Check(rspe, check.IsNil)
+	release2()
+	release3()
+	c.Check(d.opsLimiter.inProgress, check.Equals, 0)
+}
@@ -0,0 +1,124 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"github.com/snapcore/snapd/strutil"
+)
+
+// pageOptions holds the pagination and field selection requested by a
+// client listing snaps, changes or store search results.
+type pageOptions struct {
+	offset int
+	// limit of 0 means no limit
+	limit int
+	// fields to keep in each result, all if empty
+	fields []string
+}
+
+// pageInfo describes the page of results returned to the client.
+type pageInfo struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit,omitempty"`
+	// Total is the number of results before pagination.
+	Total int `json:"total"`
+}
+
+// pageOptionsFromQuery reads the offset, limit and fields query
+// parameters.
+func pageOptionsFromQuery(query url.Values) (*pageOptions, *apiError) {
+	opts := &pageOptions{}
+	for _, p := range []struct {
+		name string
+		dest *int
+	}{
+		{"offset", &opts.offset},
+		{"limit", &opts.limit},
+	} {
+		s := query.Get(p.name)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, BadRequest("invalid %s parameter: %q", p.name, s)
+		}
+		*p.dest = n
+	}
+	opts.fields = strutil.CommaSeparatedList(query.Get("fields"))
+	return opts, nil
+}
+
+// paginated returns whether the client asked for a page of the results
+// rather than all of them.
+func (opts *pageOptions) paginated() bool {
+	return opts.offset > 0 || opts.limit > 0
+}
+
+// bounds returns the range of the n results that makes up the
+// requested page, together with its description.
+func (opts *pageOptions) bounds(n int) (start, end int, info *pageInfo) {
+	start = opts.offset
+	if start > n {
+		start = n
+	}
+	end = n
+	if opts.limit > 0 && start+opts.limit < n {
+		end = start + opts.limit
+	}
+	if opts.paginated() {
+		info = &pageInfo{
+			Offset: opts.offset,
+			Limit:  opts.limit,
+			Total:  n,
+		}
+	}
+	return start, end, info
+}
+
+// selectFields reduces each of the given results, which must serialize
+// to a list of JSON objects, to the requested fields.
+func (opts *pageOptions) selectFields(results interface{}) (interface{}, error) {
+	if len(opts.fields) == 0 {
+		return results, nil
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+	var objs []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &objs); err != nil {
+		return nil, err
+	}
+	selected := make([]map[string]json.RawMessage, len(objs))
+	for i, obj := range objs {
+		selected[i] = make(map[string]json.RawMessage, len(opts.fields))
+		for _, field := range opts.fields {
+			if v, ok := obj[field]; ok {
+				selected[i][field] = v
+			}
+		}
+	}
+	return selected, nil
+}
@@ -79,6 +79,8 @@ type respJSON struct {
 	Sources []string `json:"sources,omitempty"`
 	// XXX SuggestedCurrency is part of unsupported paid snap code.
 	SuggestedCurrency string `json:"suggested-currency,omitempty"`
+	// Page describes the returned page of a paginated result.
+	Page *pageInfo `json:"page,omitempty"`
 	// Maintenance...  are filled as needed by the serving pipeline.
 	WarningTimestamp *time.Time   `json:"warning-timestamp,omitempty"`
 	WarningCount     int          `json:"warning-count,omitempty"`
@@ -309,6 +311,7 @@ func (rr *journalLineReaderSeqResponse) ServeHTTP(w http.ResponseWriter, r *http
 			Message:   log.Message(),
 			SID:       log.SID(),
 			PID:       log.PID(),
+			Cursor:    log.Cursor(),
 		}); err != nil {
 			break
 		}
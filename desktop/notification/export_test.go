@@ -19,7 +19,11 @@
 
 package notification
 
-import "github.com/godbus/dbus"
+import (
+	"io"
+
+	"github.com/godbus/dbus"
+)
 
 var (
 	NewFdoBackend = newFdoBackend
@@ -28,6 +32,7 @@ var (
 
 type FdoBackend = fdoBackend
 type GtkBackend = gtkBackend
+type StdoutBackend = stdoutBackend
 
 func (srv *fdoBackend) ProcessSignal(sig *dbus.Signal, observer Observer) error {
 	return srv.processSignal(sig, observer)
@@ -48,3 +53,11 @@ func MockNewGtkBackend(f func(conn *dbus.Conn, desktopID string) (NotificationMa
 		newGtkBackend = old
 	}
 }
+
+func MockStdout(w io.Writer) (restore func()) {
+	old := stdout
+	stdout = w
+	return func() {
+		stdout = old
+	}
+}
@@ -24,6 +24,8 @@ import (
 	"time"
 
 	"github.com/godbus/dbus"
+
+	"github.com/snapcore/snapd/logger"
 )
 
 type NotificationManager interface {
@@ -34,12 +36,34 @@ type NotificationManager interface {
 	HandleNotifications(ctx context.Context) error
 }
 
+// capabilitiesProber is implemented by backends able to query the
+// capabilities of the notification server.
+type capabilitiesProber interface {
+	ServerCapabilities() ([]ServerCapability, error)
+}
+
+// NewNotificationManager returns a notification manager sending
+// notifications over the given session bus connection, which may be nil.
+// When no notification server is available the notifications are written
+// to the standard output.
 func NewNotificationManager(conn *dbus.Conn, desktopID string) NotificationManager {
+	if conn == nil {
+		return newStdoutBackend(desktopID)
+	}
+
 	// first try the GTK backend
 	if manager, err := newGtkBackend(conn, desktopID); err == nil {
 		return manager
 	}
 
-	// fallback to the older FDO API
-	return newFdoBackend(conn, desktopID)
+	// fallback to the older FDO API, if a server answers (possibly after
+	// being activated)
+	manager := newFdoBackend(conn, desktopID)
+	if prober, ok := manager.(capabilitiesProber); ok {
+		if _, err := prober.ServerCapabilities(); err != nil {
+			logger.Debugf("cannot find a notification server, notifications will be written to stdout: %v", err)
+			return newStdoutBackend(desktopID)
+		}
+	}
+	return manager
 }
@@ -20,12 +20,18 @@
 package notification_test
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/godbus/dbus"
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/desktop/notification"
+	"github.com/snapcore/snapd/desktop/notification/notificationtest"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -67,15 +73,54 @@ func (s *managerSuite) TestFdoFallback(c *C) {
 	})
 	defer restoreGtk()
 
-	fdoBackend := &notification.FdoBackend{}
-	restoreFdo := notification.MockNewFdoBackend(func(conn *dbus.Conn, desktopID string) notification.NotificationManager {
-		c.Check(conn, Equals, s.SessionBus)
-		c.Check(desktopID, Equals, "desktop-id")
-		return fdoBackend
-	})
-	defer restoreFdo()
+	server, err := notificationtest.NewFdoServer()
+	c.Assert(err, IsNil)
+	defer server.Stop()
 
 	mgr := notification.NewNotificationManager(s.SessionBus, "desktop-id")
 	c.Check(mgr, NotNil)
-	c.Check(mgr, Equals, fdoBackend)
+	c.Check(mgr, FitsTypeOf, &notification.FdoBackend{})
+}
+
+func (s *managerSuite) TestStdoutFallbackNoServer(c *C) {
+	os.Setenv("SNAPD_DEBUG", "1")
+	defer os.Unsetenv("SNAPD_DEBUG")
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	restoreGtk := notification.MockNewGtkBackend(func(conn *dbus.Conn, desktopID string) (notification.NotificationManager, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	defer restoreGtk()
+
+	var stdout bytes.Buffer
+	defer notification.MockStdout(&stdout)()
+
+	// no notification server is running on the bus
+	mgr := notification.NewNotificationManager(s.SessionBus, "desktop-id")
+	c.Check(mgr, FitsTypeOf, &notification.StdoutBackend{})
+	c.Check(logbuf.String(), testutil.Contains, "cannot find a notification server, notifications will be written to stdout")
+
+	err := mgr.SendNotification("some-id", &notification.Message{
+		AppName: "some-app",
+		Title:   "Update available",
+		Body:    "Close the app\nto update it.",
+	})
+	c.Assert(err, IsNil)
+	c.Check(stdout.String(), Equals, "some-app: Update available: Close the app to update it.\n")
+}
+
+func (s *managerSuite) TestStdoutNoBus(c *C) {
+	var stdout bytes.Buffer
+	defer notification.MockStdout(&stdout)()
+
+	mgr := notification.NewNotificationManager(nil, "desktop-id")
+	c.Check(mgr, FitsTypeOf, &notification.StdoutBackend{})
+
+	err := mgr.SendNotification("some-id", &notification.Message{Title: "Reboot required"})
+	c.Assert(err, IsNil)
+	c.Check(mgr.CloseNotification("some-id"), IsNil)
+	c.Check(mgr.HandleNotifications(context.Background()), IsNil)
+	c.Check(stdout.String(), Equals, "desktop-id: Reboot required\n")
+	c.Check(mgr.IdleDuration() > time.Hour, Equals, true)
 }
@@ -0,0 +1,82 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package notification
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stdoutBackend is used when no notification server is available on the
+// session bus. Notifications are written to the standard output, which for
+// the session agent ends up in the user journal.
+type stdoutBackend struct {
+	out       io.Writer
+	desktopID string
+
+	mu sync.Mutex
+}
+
+var stdout io.Writer = os.Stdout
+
+func newStdoutBackend(desktopID string) NotificationManager {
+	return &stdoutBackend{
+		out:       stdout,
+		desktopID: desktopID,
+	}
+}
+
+func (srv *stdoutBackend) SendNotification(id ID, msg *Message) error {
+	appName := msg.AppName
+	if appName == "" {
+		appName = srv.desktopID
+	}
+	line := fmt.Sprintf("%s: %s", appName, msg.Title)
+	if msg.Body != "" {
+		// keep one notification per line
+		line += ": " + strings.Join(strings.Fields(msg.Body), " ")
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	_, err := fmt.Fprintln(srv.out, line)
+	return err
+}
+
+func (srv *stdoutBackend) CloseNotification(id ID) error {
+	// nothing to close
+	return nil
+}
+
+func (srv *stdoutBackend) HandleNotifications(context.Context) error {
+	// there are no actions or signals to handle
+	return nil
+}
+
+func (srv *stdoutBackend) IdleDuration() time.Duration {
+	// notifications are never pending
+	return time.Duration(math.MaxInt64)
+}
@@ -41,27 +41,31 @@ var (
 
 	HiddenSnapDataHomeGlob string
 
-	SnapBlobDir          string
-	SnapDataDir          string
-	SnapDataHomeGlob     string
-	SnapDownloadCacheDir string
-	SnapAppArmorDir      string
-	SnapSeccompBase      string
-	SnapSeccompDir       string
-	SnapMountPolicyDir   string
-	SnapUdevRulesDir     string
-	SnapKModModulesDir   string
-	SnapKModModprobeDir  string
-	LocaleDir            string
-	SnapdSocket          string
-	SnapSocket           string
-	SnapRunDir           string
-	SnapRunNsDir         string
-	SnapRunLockDir       string
-	SnapBootstrapRunDir  string
-	SnapVoidDir          string
+	SnapBlobDir               string
+	SnapDataDir               string
+	SnapDataHomeGlob          string
+	SnapDownloadCacheDir      string
+	SnapDownloadQuarantineDir string
+	SnapAuthDataFallbackDir   string
+	SnapAppArmorDir           string
+	SnapSeccompBase           string
+	SnapSeccompDir            string
+	SnapMountPolicyDir        string
+	SnapUdevRulesDir          string
+	SnapKModModulesDir        string
+	SnapKModModprobeDir       string
+	LocaleDir                 string
+	SnapdSocket               string
+	SnapSocket                string
+	SnapRunDir                string
+	SnapRunNsDir              string
+	SnapRunLockDir            string
+	SnapBootstrapRunDir       string
+	SnapStatusFile            string
+	SnapVoidDir               string
 
 	SnapdMaintenanceFile string
+	SnapdAuditLogFile    string
 
 	SnapdStoreSSLCertsDir string
 
@@ -73,6 +77,7 @@ var (
 	SnapTrustedAccountKey string
 	SnapAssertsSpoolDir   string
 	SnapSeqDir            string
+	SnapEnvironmentDir    string
 
 	SnapStateFile     string
 	SnapStateLockFile string
@@ -371,10 +376,13 @@ func SetRootDir(rootdir string) {
 	HiddenSnapDataHomeGlob = filepath.Join(rootdir, "/home/*/", HiddenSnapDataHomeDir)
 	SnapAppArmorDir = filepath.Join(rootdir, snappyDir, "apparmor", "profiles")
 	SnapDownloadCacheDir = filepath.Join(rootdir, snappyDir, "cache")
+	SnapDownloadQuarantineDir = filepath.Join(rootdir, snappyDir, "quarantine")
+	SnapAuthDataFallbackDir = filepath.Join(rootdir, snappyDir, "auth")
 	SnapSeccompBase = filepath.Join(rootdir, snappyDir, "seccomp")
 	SnapSeccompDir = filepath.Join(SnapSeccompBase, "bpf")
 	SnapMountPolicyDir = filepath.Join(rootdir, snappyDir, "mount")
 	SnapdMaintenanceFile = filepath.Join(rootdir, snappyDir, "maintenance.json")
+	SnapdAuditLogFile = filepath.Join(rootdir, snappyDir, "audit.log")
 	SnapBlobDir = SnapBlobDirUnder(rootdir)
 	SnapVoidDir = filepath.Join(rootdir, snappyDir, "void")
 	// ${snappyDir}/desktop is added to $XDG_DATA_DIRS.
@@ -389,6 +397,10 @@ func SetRootDir(rootdir string) {
 
 	SnapBootstrapRunDir = filepath.Join(SnapRunDir, "snap-bootstrap")
 
+	// summary of pending refreshes, failed changes etc. suitable for
+	// inclusion via update-motd or issue.d
+	SnapStatusFile = filepath.Join(SnapRunDir, "status")
+
 	SnapdStoreSSLCertsDir = filepath.Join(rootdir, snappyDir, "ssl/store-certs")
 
 	// keep in sync with the debian/snapd.socket file:
@@ -399,6 +411,7 @@ func SetRootDir(rootdir string) {
 	SnapCookieDir = filepath.Join(rootdir, snappyDir, "cookie")
 	SnapAssertsSpoolDir = filepath.Join(rootdir, "run/snapd/auto-import")
 	SnapSeqDir = filepath.Join(rootdir, snappyDir, "sequence")
+	SnapEnvironmentDir = filepath.Join(rootdir, snappyDir, "environment")
 
 	SnapStateFile = SnapStateFileUnder(rootdir)
 	SnapStateLockFile = SnapStateLockFileUnder(rootdir)
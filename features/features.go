@@ -68,6 +68,9 @@ const (
 	//  * journal quotas are still experimental
 	// while guota groups creation and management and memory, cpu, quotas are no longer experimental.
 	QuotaGroups
+	// SnapIntegrity enables mounting snaps with dm-verity protection, using the
+	// integrity data they carry or generating them alongside the snaps.
+	SnapIntegrity
 
 	// lastFeature is the final known feature, it is only used for testing.
 	lastFeature
@@ -108,6 +111,8 @@ var featureNames = map[SnapdFeature]string{
 	GateAutoRefreshHook: "gate-auto-refresh-hook",
 
 	QuotaGroups: "quota-groups",
+
+	SnapIntegrity: "snap-integrity",
 }
 
 // featuresEnabledWhenUnset contains a set of features that are enabled when not explicitly configured.
@@ -19,6 +19,18 @@
 
 package httputil
 
+import (
+	"github.com/snapcore/snapd/timeutil"
+)
+
 var (
 	GetFlags = (*LoggedTransport).getFlags
 )
+
+func MockRetryTimeSource(ts timeutil.TimeSource) (restore func()) {
+	old := retryTimeSource
+	retryTimeSource = ts
+	return func() {
+		retryTimeSource = old
+	}
+}
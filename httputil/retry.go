@@ -32,10 +32,10 @@ import (
 	"syscall"
 	"time"
 
-	"gopkg.in/retry.v1"
-
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/retryutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 type PersistentNetworkError struct {
@@ -46,13 +46,13 @@ func (e *PersistentNetworkError) Error() string {
 	return fmt.Sprintf("persistent network error: %v", e.Err)
 }
 
-func MaybeLogRetryAttempt(url string, attempt *retry.Attempt, startTime time.Time) {
+func MaybeLogRetryAttempt(url string, attempt *retryutil.Attempt, startTime time.Time) {
 	if osutil.GetenvBool("SNAPD_DEBUG") || attempt.Count() > 1 {
 		logger.Debugf("Retrying %s, attempt %d, elapsed time=%v", url, attempt.Count(), time.Since(startTime))
 	}
 }
 
-func maybeLogRetrySummary(startTime time.Time, url string, attempt *retry.Attempt, resp *http.Response, err error) {
+func maybeLogRetrySummary(startTime time.Time, url string, attempt *retryutil.Attempt, resp *http.Response, err error) {
 	if osutil.GetenvBool("SNAPD_DEBUG") || attempt.Count() > 1 {
 		var status string
 		if err != nil {
@@ -64,7 +64,7 @@ func maybeLogRetrySummary(startTime time.Time, url string, attempt *retry.Attemp
 	}
 }
 
-func ShouldRetryHttpResponse(attempt *retry.Attempt, resp *http.Response) bool {
+func ShouldRetryHttpResponse(attempt *retryutil.Attempt, resp *http.Response) bool {
 	if !attempt.More() {
 		return false
 	}
@@ -93,7 +93,7 @@ func isHttp2ProtocolError(err error) bool {
 	return false
 }
 
-func ShouldRetryAttempt(attempt *retry.Attempt, err error) bool {
+func ShouldRetryAttempt(attempt *retryutil.Attempt, err error) bool {
 	if !attempt.More() {
 		return false
 	}
@@ -252,11 +252,14 @@ func isDnsUnavailable(err error) bool {
 	return strings.Contains(dnsErr.Err, "Temporary failure in name resolution")
 }
 
-// RetryRequest calls doRequest and read the response body in a retry loop using the given retryStrategy.
-func RetryRequest(endpoint string, doRequest func() (*http.Response, error), readResponseBody func(resp *http.Response) error, retryStrategy retry.Strategy) (resp *http.Response, err error) {
-	var attempt *retry.Attempt
+// retryTimeSource is used to wait between attempts of RetryRequest.
+var retryTimeSource timeutil.TimeSource = timeutil.RealTimeSource
+
+// RetryRequest calls doRequest and read the response body in a retry loop using the given retryPolicy.
+func RetryRequest(endpoint string, doRequest func() (*http.Response, error), readResponseBody func(resp *http.Response) error, retryPolicy retryutil.Policy) (resp *http.Response, err error) {
+	var attempt *retryutil.Attempt
 	startTime := time.Now()
-	for attempt = retry.Start(retryStrategy, nil); attempt.Next(); {
+	for attempt = retryutil.Start(retryPolicy, retryTimeSource); attempt.Next(); {
 		MaybeLogRetryAttempt(endpoint, attempt, startTime)
 
 		resp, err = doRequest()
@@ -27,13 +27,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
 	. "gopkg.in/check.v1"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/retryutil"
+	"github.com/snapcore/snapd/timeutil/clocktest"
 )
 
 type retrySuite struct{}
@@ -46,12 +48,13 @@ func (s *retrySuite) SetUpTest(c *C) {
 func (s *retrySuite) TearDownTest(c *C) {
 }
 
-var testRetryStrategy = retry.LimitCount(5, retry.LimitTime(5*time.Second,
-	retry.Exponential{
+var testRetryPolicy = retryutil.Policy{
+	Strategy: retryutil.Exponential{
 		Initial: 1 * time.Millisecond,
 		Factor:  1,
 	},
-))
+	Limits: retryutil.Limits{MaxAttempts: 5, MaxElapsed: 5 * time.Second},
+}
 
 type counter struct {
 	n  int
@@ -104,7 +107,7 @@ func (s *retrySuite) TestRetryRequestOnEOF(c *C) {
 		return json.NewDecoder(resp.Body).Decode(&got)
 	}
 
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, IsNil)
 
 	c.Assert(failure, Equals, false)
@@ -112,6 +115,43 @@ func (s *retrySuite) TestRetryRequestOnEOF(c *C) {
 	c.Assert(n, Equals, 4)
 }
 
+func (s *retrySuite) TestRetryRequestWaitsOnTimeSource(c *C) {
+	clock := clocktest.New(time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC))
+	restore := httputil.MockRetryTimeSource(clock)
+	defer restore()
+
+	policy := retryutil.Policy{
+		Strategy: retryutil.Constant{Interval: time.Hour},
+		Limits:   retryutil.Limits{MaxAttempts: 3},
+	}
+
+	n := new(counter)
+	doRequest := func() (*http.Response, error) {
+		status := 500
+		if n.Inc() > 1 {
+			status = 200
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	}
+	readResponseBody := func(resp *http.Response) error { return nil }
+
+	done := make(chan error)
+	go func() {
+		resp, err := httputil.RetryRequest("endp", doRequest, readResponseBody, policy)
+		if err == nil && resp.StatusCode != 200 {
+			err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		done <- err
+	}()
+
+	// the second attempt waits for an hour to elapse on the time source
+	clock.BlockUntil(1)
+	c.Check(n.Count(), Equals, 1)
+	clock.Advance(time.Hour)
+	c.Assert(<-done, IsNil)
+	c.Check(n.Count(), Equals, 2)
+}
+
 func (s *retrySuite) TestRetryRequestFailWithEOF(c *C) {
 	n := new(counter)
 	var mockServer *httptest.Server
@@ -142,7 +182,7 @@ func (s *retrySuite) TestRetryRequestFailWithEOF(c *C) {
 		return json.NewDecoder(resp.Body).Decode(&got)
 	}
 
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, NotNil)
 	c.Check(err, ErrorMatches, `^Get \"?http://127.0.0.1:.*?\"?: EOF$`)
 
@@ -182,7 +222,7 @@ func (s *retrySuite) TestRetryRequestOn500(c *C) {
 		return json.NewDecoder(resp.Body).Decode(&got)
 	}
 
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, IsNil)
 
 	c.Assert(failure, Equals, false)
@@ -219,7 +259,7 @@ func (s *retrySuite) TestRetryRequestFailOn500(c *C) {
 		return json.NewDecoder(resp.Body).Decode(&got)
 	}
 
-	resp, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	resp, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, IsNil)
 	c.Assert(resp.StatusCode, Equals, 500)
 
@@ -269,7 +309,7 @@ func (s *retrySuite) TestRetryRequestUnexpectedEOFHandling(c *C) {
 
 	// Check that we really recognize unexpected EOF error by failing on all retries
 	url = mockPermanentlyBrokenServer.URL
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, NotNil)
 	c.Assert(err, Equals, io.ErrUnexpectedEOF)
 	c.Assert(err, ErrorMatches, "unexpected EOF")
@@ -282,7 +322,7 @@ func (s *retrySuite) TestRetryRequestUnexpectedEOFHandling(c *C) {
 	failure = false
 	got = nil
 	// Check that we retry on unexpected EOF and eventually succeed
-	_, err = httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err = httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, IsNil)
 	// check that we retried 4 times
 	c.Check(failure, Equals, false)
@@ -317,7 +357,7 @@ func (s *retrySuite) TestRetryRequestFailOnReadResponseBody(c *C) {
 		return json.NewDecoder(resp.Body).Decode(&got)
 	}
 
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, ErrorMatches, `invalid character '<' looking for beginning of value`)
 	c.Check(failure, Equals, false)
 }
@@ -350,7 +390,7 @@ func (s *retrySuite) TestRetryRequestReadResponseBodyFailure(c *C) {
 		return json.NewDecoder(resp.Body).Decode(&got)
 	}
 
-	resp, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	resp, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, IsNil)
 	c.Check(failure, Equals, true)
 	c.Check(resp.StatusCode, Equals, 404)
@@ -404,7 +444,7 @@ func (s *retrySuite) TestRetryRequestTimeoutHandling(c *C) {
 
 	// Check that we really recognize unexpected EOF error by failing on all retries
 	url = mockPermanentlyBrokenServer.URL
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, NotNil)
 	// context deadline detection when the response body was not received
 	// yet is racy and context.DeadlineExceeded errors are not necessarily
@@ -419,7 +459,7 @@ func (s *retrySuite) TestRetryRequestTimeoutHandling(c *C) {
 	failure = false
 	got = nil
 	// Check that we retry on unexpected EOF and eventually succeed
-	_, err = httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err = httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, IsNil)
 	// check that we retried 4 times
 	c.Check(failure, Equals, false)
@@ -445,7 +485,7 @@ func (s *retrySuite) TestRetryDoesNotFailForPermanentDNSErrors(c *C) {
 		return nil
 	}
 
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, NotNil)
 	// we try exactly once, a non-existing server is a permanent error
 	c.Assert(n, Equals, 1)
@@ -464,7 +504,7 @@ func (s *retrySuite) TestRetryOnTemporaryDNSfailure(c *C) {
 	readResponseBody := func(resp *http.Response) error {
 		return nil
 	}
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, NotNil)
 	c.Assert(n > 1, Equals, true, Commentf("%v not > 1", n))
 }
@@ -484,7 +524,7 @@ func (s *retrySuite) TestRetryOnTemporaryDNSfailureNotGo19(c *C) {
 	readResponseBody := func(resp *http.Response) error {
 		return nil
 	}
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, NotNil)
 	c.Assert(n > 1, Equals, true, Commentf("%v not > 1", n))
 }
@@ -502,7 +542,7 @@ func (s *retrySuite) TestRetryOnHttp2ProtocolErrors(c *C) {
 	readResponseBody := func(resp *http.Response) error {
 		return nil
 	}
-	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryStrategy)
+	_, err := httputil.RetryRequest("endp", doRequest, readResponseBody, testRetryPolicy)
 	c.Assert(err, NotNil)
 	c.Assert(n > 1, Equals, true, Commentf("%v not > 1", n))
 }
@@ -0,0 +1,167 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+)
+
+// checkpointFile is the name of the file, under the prepare directory,
+// recording the progress of an image build, such that an interrupted
+// build can be resumed by running it again.
+const checkpointFile = ".prepare-image-checkpoint.json"
+
+// checkpoint records which snaps an image build already downloaded.
+type checkpoint struct {
+	path string
+
+	// Model identifies the model the image is built for as
+	// <brand-id>/<model>.
+	Model string `json:"model"`
+	// Options records the options affecting which snaps and revisions
+	// the build picks.
+	Options json.RawMessage            `json:"options,omitempty"`
+	Snaps   map[string]*checkpointSnap `json:"snaps"`
+}
+
+// checkpointSnap records a downloaded snap; the blob is verified again by
+// digest before being reused.
+type checkpointSnap struct {
+	Revision snap.Revision `json:"revision"`
+	Channel  string        `json:"channel,omitempty"`
+	Sha3_384 string        `json:"sha3-384"`
+	Size     int64         `json:"size"`
+}
+
+// checkpointOptions are the build options a checkpoint is only valid for.
+type checkpointOptions struct {
+	Channel       string            `json:"channel,omitempty"`
+	Snaps         []string          `json:"snaps,omitempty"`
+	SnapChannels  map[string]string `json:"snap-channels,omitempty"`
+	WideCohortKey string            `json:"wide-cohort-key,omitempty"`
+	Architecture  string            `json:"architecture,omitempty"`
+	Validation    string            `json:"validation,omitempty"`
+}
+
+func modelKey(model *asserts.Model) string {
+	return fmt.Sprintf("%s/%s", model.BrandID(), model.Model())
+}
+
+func optionsKey(opts *Options) (json.RawMessage, error) {
+	snaps := append([]string(nil), opts.Snaps...)
+	sort.Strings(snaps)
+	return json.Marshal(&checkpointOptions{
+		Channel:       opts.Channel,
+		Snaps:         snaps,
+		SnapChannels:  opts.SnapChannels,
+		WideCohortKey: opts.WideCohortKey,
+		Architecture:  opts.Architecture,
+		Validation:    opts.Customizations.Validation,
+	})
+}
+
+// readCheckpoint reads the checkpoint left in prepareDir by an
+// interrupted build of an image for the same model and with the same
+// options, or returns an empty one if there is none.
+func readCheckpoint(prepareDir string, model *asserts.Model, opts *Options) (*checkpoint, error) {
+	optsKey, err := optionsKey(opts)
+	if err != nil {
+		return nil, err
+	}
+	cp := &checkpoint{
+		path:    filepath.Join(prepareDir, checkpointFile),
+		Model:   modelKey(model),
+		Options: optsKey,
+		Snaps:   make(map[string]*checkpointSnap),
+	}
+	data, err := os.ReadFile(cp.path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var prev checkpoint
+	if err := json.Unmarshal(data, &prev); err != nil {
+		return nil, fmt.Errorf("cannot decode checkpoint %s: %v", cp.path, err)
+	}
+	if prev.Model != cp.Model {
+		fmt.Fprintf(Stderr, "WARNING: ignoring checkpoint %s of a build for model %q\n", cp.path, prev.Model)
+		return cp, nil
+	}
+	if !bytes.Equal(prev.Options, cp.Options) {
+		fmt.Fprintf(Stderr, "WARNING: ignoring checkpoint %s of a build with different options\n", cp.path)
+		return cp, nil
+	}
+	if len(prev.Snaps) != 0 {
+		fmt.Fprintf(Stdout, "Resuming interrupted build, %d snaps already fetched\n", len(prev.Snaps))
+		cp.Snaps = prev.Snaps
+	}
+	return cp, nil
+}
+
+// revision returns the revision of snapName fetched from channel by the
+// interrupted build, if any.
+func (cp *checkpoint) revision(snapName, channel string) snap.Revision {
+	if cs := cp.Snaps[snapName]; cs != nil && cs.Channel == channel {
+		return cs.Revision
+	}
+	return snap.Revision{}
+}
+
+// downloaded records that the given snap was downloaded from channel.
+func (cp *checkpoint) downloaded(info *snap.Info, channel string) error {
+	cs := cp.Snaps[info.SnapName()]
+	if cs != nil && cs.Revision == info.Revision && cs.Channel == channel && cs.Sha3_384 == info.Sha3_384 {
+		return nil
+	}
+	cp.Snaps[info.SnapName()] = &checkpointSnap{
+		Revision: info.Revision,
+		Channel:  channel,
+		Sha3_384: info.Sha3_384,
+		Size:     info.Size,
+	}
+	return cp.write()
+}
+
+func (cp *checkpoint) write() error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(cp.path, data, 0644, 0)
+}
+
+// remove removes the checkpoint once the build is complete.
+func (cp *checkpoint) remove() error {
+	if err := os.Remove(cp.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2014-2020 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type checkpointSnap struct {
+	Revision snap.Revision `json:"revision"`
+	Channel  string        `json:"channel,omitempty"`
+	Sha3_384 string        `json:"sha3-384"`
+	Size     int64         `json:"size"`
+}
+
+type checkpoint struct {
+	Model   string                     `json:"model"`
+	Options json.RawMessage            `json:"options,omitempty"`
+	Snaps   map[string]*checkpointSnap `json:"snaps"`
+}
+
+func (s *imageSuite) writeCheckpoint(c *C, preparedir string, cp *checkpoint) {
+	data, err := json.Marshal(cp)
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(filepath.Join(preparedir, ".prepare-image-checkpoint.json"), data, 0644), IsNil)
+}
+
+func (s *imageSuite) readCheckpoint(c *C, preparedir string) *checkpoint {
+	data, err := os.ReadFile(filepath.Join(preparedir, ".prepare-image-checkpoint.json"))
+	c.Assert(err, IsNil)
+	var cp checkpoint
+	c.Assert(json.Unmarshal(data, &cp), IsNil)
+	return &cp
+}
+
+func (s *imageSuite) TestSetupSeedCheckpointLeftOnFailure(c *C) {
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	preparedir := c.MkDir()
+	s.setupSnaps(c, map[string]string{
+		"pc":        "canonical",
+		"pc-kernel": "canonical",
+	}, "")
+
+	restore = image.MockWriteResolvedContent(func(_ string, _ *gadget.Info, _, _ string) error {
+		return fmt.Errorf("boom")
+	})
+	defer restore()
+
+	opts := &image.Options{
+		PrepareDir: preparedir,
+		Customizations: image.Customizations{
+			Validation: "ignore",
+		},
+	}
+	err := image.SetupSeed(s.tsto, s.model, opts)
+	c.Assert(err, ErrorMatches, "boom")
+
+	cp := s.readCheckpoint(c, preparedir)
+	c.Check(cp.Model, Equals, "my-brand/my-model")
+	c.Check(string(cp.Options), Equals, `{"validation":"ignore"}`)
+	c.Check(cp.Snaps, HasLen, 4)
+	for _, name := range []string{"core", "pc-kernel", "pc", "required-snap1"} {
+		info := s.AssertedSnapInfo(name)
+		c.Check(cp.Snaps[name], DeepEquals, &checkpointSnap{
+			Revision: info.Revision,
+			Channel:  "stable",
+			Sha3_384: info.Sha3_384,
+			Size:     info.Size,
+		}, Commentf(name))
+	}
+}
+
+func (s *imageSuite) TestSetupSeedResumesFromCheckpoint(c *C) {
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	preparedir := c.MkDir()
+	s.setupSnaps(c, map[string]string{
+		"pc":        "canonical",
+		"pc-kernel": "canonical",
+	}, "")
+
+	info := s.AssertedSnapInfo("pc-kernel")
+	s.writeCheckpoint(c, preparedir, &checkpoint{
+		Model:   "my-brand/my-model",
+		Options: json.RawMessage(`{"validation":"ignore"}`),
+		Snaps: map[string]*checkpointSnap{
+			"pc-kernel": {
+				Revision: info.Revision,
+				Channel:  "stable",
+				Sha3_384: info.Sha3_384,
+				Size:     info.Size,
+			},
+		},
+	})
+
+	opts := &image.Options{
+		PrepareDir: preparedir,
+		Customizations: image.Customizations{
+			Validation: "ignore",
+		},
+	}
+	err := image.SetupSeed(s.tsto, s.model, opts)
+	c.Assert(err, IsNil)
+	c.Check(s.stdout.String(), testutil.Contains, "Resuming interrupted build, 1 snaps already fetched\n")
+
+	// the revision fetched by the interrupted build is asked for
+	for _, a := range s.storeActions {
+		if a.InstanceName == "pc-kernel" {
+			c.Check(a.Revision, Equals, info.Revision)
+			c.Check(a.Channel, Equals, "")
+		} else {
+			c.Check(a.Revision.Unset(), Equals, true, Commentf(a.InstanceName))
+		}
+	}
+
+	// the checkpoint is gone once the build is complete
+	c.Check(filepath.Join(preparedir, ".prepare-image-checkpoint.json"), testutil.FileAbsent)
+}
+
+func (s *imageSuite) TestSetupSeedIgnoresCheckpointOfOtherModel(c *C) {
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	preparedir := c.MkDir()
+	s.setupSnaps(c, map[string]string{
+		"pc":        "canonical",
+		"pc-kernel": "canonical",
+	}, "")
+
+	s.writeCheckpoint(c, preparedir, &checkpoint{
+		Model: "my-brand/other-model",
+		Snaps: map[string]*checkpointSnap{
+			"pc-kernel": {Revision: snap.R(1)},
+		},
+	})
+
+	opts := &image.Options{
+		PrepareDir: preparedir,
+		Customizations: image.Customizations{
+			Validation: "ignore",
+		},
+	}
+	err := image.SetupSeed(s.tsto, s.model, opts)
+	c.Assert(err, IsNil)
+	c.Check(s.stderr.String(), Matches, `(?s).*WARNING: ignoring checkpoint .*/\.prepare-image-checkpoint\.json of a build for model "my-brand/other-model"\n.*`)
+	for _, a := range s.storeActions {
+		c.Check(a.Revision.Unset(), Equals, true, Commentf(a.InstanceName))
+	}
+}
+
+func (s *imageSuite) TestSetupSeedIgnoresCheckpointWithOtherOptions(c *C) {
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	preparedir := c.MkDir()
+	s.setupSnaps(c, map[string]string{
+		"pc":        "canonical",
+		"pc-kernel": "canonical",
+	}, "")
+
+	s.writeCheckpoint(c, preparedir, &checkpoint{
+		Model:   "my-brand/my-model",
+		Options: json.RawMessage(`{"snap-channels":{"pc-kernel":"edge"},"validation":"ignore"}`),
+		Snaps: map[string]*checkpointSnap{
+			"pc-kernel": {Revision: snap.R(1), Channel: "edge"},
+		},
+	})
+
+	opts := &image.Options{
+		PrepareDir: preparedir,
+		Customizations: image.Customizations{
+			Validation: "ignore",
+		},
+	}
+	err := image.SetupSeed(s.tsto, s.model, opts)
+	c.Assert(err, IsNil)
+	c.Check(s.stderr.String(), Matches, `(?s).*WARNING: ignoring checkpoint .*/\.prepare-image-checkpoint\.json of a build with different options\n.*`)
+	for _, a := range s.storeActions {
+		c.Check(a.Revision.Unset(), Equals, true, Commentf(a.InstanceName))
+	}
+}
@@ -21,8 +21,13 @@ package image
 
 import (
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/store/tooling"
 )
 
 func Prepare(opts *Options) error {
 	return osutil.ErrDarwin
 }
+
+func Mirror(tsto *tooling.ToolingStore, snaps []MirrorSnap, targetDir string) (*MirrorIndex, error) {
+	return nil, osutil.ErrDarwin
+}
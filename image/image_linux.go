@@ -145,6 +145,16 @@ func Prepare(opts *Options) error {
 	}
 	tsto.Stdout = Stdout
 
+	if len(opts.AssertionFiles) != 0 {
+		localAsserts, err := decodeAssertionFiles(opts.AssertionFiles)
+		if err != nil {
+			return err
+		}
+		if err := tsto.AddLocalAssertions(localAsserts); err != nil {
+			return err
+		}
+	}
+
 	// FIXME: limitation until we can pass series parametrized much more
 	if model.Series() != release.Series {
 		return fmt.Errorf("model with series %q != %q unsupported", model.Series(), release.Series)
@@ -213,6 +223,30 @@ func decodeModelAssertion(opts *Options) (*asserts.Model, error) {
 	return modela, nil
 }
 
+func decodeAssertionFiles(fns []string) ([]asserts.Assertion, error) {
+	var as []asserts.Assertion
+	for _, fn := range fns {
+		f, err := os.Open(fn)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read assertions: %v", err)
+		}
+		dec := asserts.NewDecoder(f)
+		for {
+			a, err := dec.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("cannot decode assertions in %q: %v", fn, err)
+			}
+			as = append(as, a)
+		}
+		f.Close()
+	}
+	return as, nil
+}
+
 func unpackSnap(gadgetFname, gadgetUnpackDir string) error {
 	// FIXME: jumping through layers here, we need to make
 	//        unpack part of the container interface (again)
@@ -299,6 +333,8 @@ type imageSeeder struct {
 	db          *asserts.Database
 	w           *seedwriter.Writer
 	f           seedwriter.SeedAssertionFetcher
+
+	checkpoint *checkpoint
 }
 
 func newImageSeeder(tsto *tooling.ToolingStore, model *asserts.Model, opts *Options) (*imageSeeder, error) {
@@ -343,6 +379,12 @@ func newImageSeeder(tsto *tooling.ToolingStore, model *asserts.Model, opts *Opti
 		}
 	}
 
+	cp, err := readCheckpoint(s.prepareDir, model, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.checkpoint = cp
+
 	wOpts := &seedwriter.Options{
 		SeedDir:        s.seedDir,
 		Label:          s.label,
@@ -548,6 +590,11 @@ func (s *imageSeeder) downloadSnaps(snapsToDownload []*seedwriter.SeedSnap, curS
 			return nil, err
 		}
 
+		if rev.Unset() && len(vss) == 0 {
+			// stick to what an interrupted build already fetched
+			rev = s.checkpoint.revision(sn.SnapName(), sn.Channel)
+		}
+
 		byName[sn.SnapName()] = sn
 		revisions[sn.SnapName()] = rev
 		snapToDownloadOptions[i].Snap = sn
@@ -563,7 +610,11 @@ func (s *imageSeeder) downloadSnaps(snapsToDownload []*seedwriter.SeedSnap, curS
 	})
 	downloadedSnaps, err = s.tsto.DownloadMany(snapToDownloadOptions, curSnaps, tooling.DownloadManyOptions{
 		BeforeDownloadFunc: beforeDownload,
-		EnforceValidation:  s.customizations.Validation == "enforce",
+		AfterDownloadFunc: func(dlSnap *tooling.DownloadedSnap) error {
+			return s.checkpoint.downloaded(dlSnap.Info, byName[dlSnap.Info.SnapName()].Channel)
+		},
+		EnforceValidation:   s.customizations.Validation == "enforce",
+		LeavePartialOnError: true,
 	})
 	if err != nil {
 		return nil, err
@@ -926,5 +977,8 @@ var setupSeed = func(tsto *tooling.ToolingStore, model *asserts.Model, opts *Opt
 	if err := s.downloadAllSnaps(localSnaps, fetchAsserts); err != nil {
 		return err
 	}
-	return s.finish()
+	if err := s.finish(); err != nil {
+		return err
+	}
+	return s.checkpoint.remove()
 }
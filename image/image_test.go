@@ -2128,6 +2128,28 @@ func (s *imageSuite) TestPrepareInvalidChannel(c *C) {
 	c.Assert(err, ErrorMatches, `cannot use global default option channel: channel name has too many components: x/x/x/x`)
 }
 
+func (s *imageSuite) TestPrepareInvalidAssertionFile(c *C) {
+	fn := filepath.Join(c.MkDir(), "model.assertion")
+	err := os.WriteFile(fn, asserts.Encode(s.model), 0644)
+	c.Assert(err, IsNil)
+
+	assertsFn := filepath.Join(c.MkDir(), "local.assert")
+	err = os.WriteFile(assertsFn, []byte("not an assertion"), 0644)
+	c.Assert(err, IsNil)
+
+	err = image.Prepare(&image.Options{
+		ModelFile:      fn,
+		AssertionFiles: []string{assertsFn},
+	})
+	c.Assert(err, ErrorMatches, `cannot decode assertions in ".*/local.assert": .*`)
+
+	err = image.Prepare(&image.Options{
+		ModelFile:      fn,
+		AssertionFiles: []string{filepath.Join(c.MkDir(), "missing.assert")},
+	})
+	c.Assert(err, ErrorMatches, `cannot read assertions: open .*/missing.assert: no such file or directory`)
+}
+
 func (s *imageSuite) TestPrepareClassicModeNoClassicModel(c *C) {
 	fn := filepath.Join(c.MkDir(), "model.assertion")
 	err := os.WriteFile(fn, asserts.Encode(s.model), 0644)
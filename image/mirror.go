@@ -0,0 +1,59 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image
+
+import (
+	"github.com/snapcore/snapd/snap"
+)
+
+const (
+	mirrorBlobsDir      = "blobs"
+	mirrorAssertionsDir = "assertions"
+	// MirrorIndexFile is the name of the index of a static mirror,
+	// relative to its top directory.
+	MirrorIndexFile = "index.json"
+)
+
+// MirrorSnap identifies a snap to publish to a static mirror.
+type MirrorSnap struct {
+	Name string
+	// Channel is the channel to take the snap from, the default one
+	// of the store if not set.
+	Channel string
+}
+
+// MirrorIndex is the content of the index of a static mirror.
+type MirrorIndex struct {
+	Snaps []*MirrorIndexEntry `json:"snaps"`
+}
+
+// MirrorIndexEntry describes a snap published to a static mirror. Paths
+// are relative to the top directory of the mirror.
+type MirrorIndexEntry struct {
+	Name       string        `json:"name"`
+	SnapID     string        `json:"snap-id"`
+	Revision   snap.Revision `json:"revision"`
+	Version    string        `json:"version"`
+	Channel    string        `json:"channel,omitempty"`
+	Sha3_384   string        `json:"sha3-384"`
+	Size       uint64        `json:"size"`
+	Blob       string        `json:"blob"`
+	Assertions string        `json:"assertions"`
+}
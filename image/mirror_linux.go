@@ -0,0 +1,125 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/store/tooling"
+)
+
+// Mirror downloads the given snaps together with their supporting
+// assertions into targetDir and writes an index of them, in a layout that
+// can be served as is by any static HTTP server or object storage:
+//
+//	<targetDir>/index.json
+//	<targetDir>/blobs/<name>_<revision>.snap
+//	<targetDir>/assertions/<name>_<revision>.assert
+//
+// The assertions of each snap are stored in a file of their own, which
+// is self-contained up to the trusted roots, such that they can be
+// acked independently.
+func Mirror(tsto *tooling.ToolingStore, snaps []MirrorSnap, targetDir string) (*MirrorIndex, error) {
+	blobsDir := filepath.Join(targetDir, mirrorBlobsDir)
+	assertsDir := filepath.Join(targetDir, mirrorAssertionsDir)
+	for _, dir := range []string{blobsDir, assertsDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	index := &MirrorIndex{Snaps: make([]*MirrorIndexEntry, 0, len(snaps))}
+	for _, sn := range snaps {
+		entry, err := mirrorSnap(tsto, sn, blobsDir, assertsDir)
+		if err != nil {
+			return nil, fmt.Errorf("cannot mirror snap %q: %v", sn.Name, err)
+		}
+		index.Snaps = append(index.Snaps, entry)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := osutil.AtomicWriteFile(filepath.Join(targetDir, MirrorIndexFile), data, 0644, 0); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func mirrorSnap(tsto *tooling.ToolingStore, sn MirrorSnap, blobsDir, assertsDir string) (*MirrorIndexEntry, error) {
+	dlSnap, err := tsto.DownloadSnap(sn.Name, tooling.DownloadSnapOptions{
+		TargetDir: blobsDir,
+		Channel:   sn.Channel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	info := dlSnap.Info
+	blob := filepath.Base(dlSnap.Path)
+	assertFile := fmt.Sprintf("%s_%s.assert", info.SnapName(), info.Revision)
+
+	// a database per snap so that each assertions file carries all the
+	// prerequisites of the snap assertions
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   trusted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	w, err := osutil.NewAtomicFile(filepath.Join(assertsDir, assertFile), 0644, 0, osutil.NoChown, osutil.NoChown)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Cancel()
+	enc := asserts.NewEncoder(w)
+	save := func(a asserts.Assertion) error {
+		return enc.Encode(a)
+	}
+	f := tsto.AssertionFetcher(db, save)
+	if _, err := FetchAndCheckSnapAssertions(dlSnap.Path, info, nil, f, db); err != nil {
+		return nil, err
+	}
+	if err := w.Commit(); err != nil {
+		return nil, err
+	}
+
+	sha3_384, size, err := asserts.SnapFileSHA3_384(dlSnap.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &MirrorIndexEntry{
+		Name:       info.SnapName(),
+		SnapID:     info.SnapID,
+		Revision:   info.Revision,
+		Version:    info.Version,
+		Channel:    info.Channel,
+		Sha3_384:   sha3_384,
+		Size:       size,
+		Blob:       filepath.Join(mirrorBlobsDir, blob),
+		Assertions: filepath.Join(mirrorAssertionsDir, assertFile),
+	}, nil
+}
@@ -0,0 +1,100 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2014-2020 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *imageSuite) TestMirror(c *C) {
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	s.MakeAssertedSnap(c, requiredSnap1, nil, snap.R(3), "other")
+	s.MakeAssertedSnap(c, requiredSnap18, nil, snap.R(6), "other")
+
+	targetDir := c.MkDir()
+	index, err := image.Mirror(s.tsto, []image.MirrorSnap{
+		{Name: "required-snap1", Channel: "edge"},
+		{Name: "required-snap18"},
+	}, targetDir)
+	c.Assert(err, IsNil)
+	c.Assert(index.Snaps, HasLen, 2)
+
+	sha3_384, size, err := asserts.SnapFileSHA3_384(s.AssertedSnap("required-snap1"))
+	c.Assert(err, IsNil)
+	c.Check(index.Snaps[0], DeepEquals, &image.MirrorIndexEntry{
+		Name:       "required-snap1",
+		SnapID:     s.AssertedSnapID("required-snap1"),
+		Revision:   snap.R(3),
+		Version:    "1.0",
+		Channel:    "edge",
+		Sha3_384:   sha3_384,
+		Size:       size,
+		Blob:       "blobs/required-snap1_3.snap",
+		Assertions: "assertions/required-snap1_3.assert",
+	})
+	c.Check(index.Snaps[1].Blob, Equals, "blobs/required-snap18_6.snap")
+	c.Check(index.Snaps[1].Assertions, Equals, "assertions/required-snap18_6.assert")
+
+	// the index on disk matches
+	data, err := os.ReadFile(filepath.Join(targetDir, "index.json"))
+	c.Assert(err, IsNil)
+	var onDisk image.MirrorIndex
+	c.Assert(json.Unmarshal(data, &onDisk), IsNil)
+	c.Check(onDisk, DeepEquals, *index)
+
+	for _, entry := range index.Snaps {
+		c.Check(filepath.Join(targetDir, entry.Blob), testutil.FilePresent)
+
+		// each assertions file is self-contained
+		f, err := os.Open(filepath.Join(targetDir, entry.Assertions))
+		c.Assert(err, IsNil)
+		defer f.Close()
+		dec := asserts.NewDecoder(f)
+		var types []string
+		for {
+			a, err := dec.Decode()
+			if err != nil {
+				break
+			}
+			types = append(types, a.Type().Name)
+		}
+		c.Check(types, testutil.Contains, "account-key")
+		c.Check(types, testutil.Contains, "snap-declaration")
+		c.Check(types, testutil.Contains, "snap-revision")
+	}
+}
+
+func (s *imageSuite) TestMirrorError(c *C) {
+	_, err := image.Mirror(s.tsto, []image.MirrorSnap{
+		{Name: "not-in-store"},
+	}, c.MkDir())
+	c.Check(err, ErrorMatches, `cannot mirror snap "not-in-store": .*no "not-in-store" in the fake store`)
+}
@@ -48,6 +48,11 @@ type Options struct {
 	Snaps        []string
 	SnapChannels map[string]string
 
+	// AssertionFiles are files with assertions, for example the
+	// snap-declaration and snap-revision assertions of local snaps,
+	// to use in preference to fetching them from the store.
+	AssertionFiles []string
+
 	// SeedManifest is a pre-provided seed manifest, to allow for
 	// creating reproducible seeds. If provided, the snap revisions and
 	// validation-sets specified in the seed manifest will be used to
@@ -343,6 +343,10 @@ func (s *preseedSuite) TestReset(c *C) {
 			symlinkTarget string
 		}{
 			{dirs.SnapStateFile, ""},
+			{dirs.SnapStateFile + ".sha3-384", ""},
+			{dirs.SnapStateFile + ".bak", ""},
+			{dirs.SnapStateFile + ".bak.sha3-384", ""},
+			{dirs.SnapStateFile + ".corrupt", ""},
 			{dirs.SnapSystemKeyFile, ""},
 			{filepath.Join(dirs.SnapDesktopFilesDir, "foo.desktop"), ""},
 			{filepath.Join(dirs.SnapDesktopIconsDir, "foo.png"), ""},
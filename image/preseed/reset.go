@@ -98,6 +98,11 @@ var ResetPreseededChroot = func(preseedChroot string) error {
 	// globs that yield individual files
 	globs := []string{
 		dirs.SnapStateFile,
+		// the state checksum, backup and corrupted state kept aside
+		dirs.SnapStateFile + ".sha3-384",
+		dirs.SnapStateFile + ".bak",
+		dirs.SnapStateFile + ".bak.sha3-384",
+		dirs.SnapStateFile + ".corrupt",
 		dirs.SnapSystemKeyFile,
 		filepath.Join(dirs.SnapBlobDir, "*.snap"),
 		filepath.Join(dirs.SnapUdevRulesDir, "*-snap.*.rules"),
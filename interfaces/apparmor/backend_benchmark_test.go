@@ -0,0 +1,127 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package apparmor_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/release"
+	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timings"
+)
+
+const benchCoreYaml = `name: core
+version: 1
+type: os
+slots:
+  network:
+  network-bind:
+  home:
+  x11:
+  opengl:
+  audio-playback:
+  desktop:
+  removable-media:
+`
+
+// benchSnapYaml returns the yaml of a snap with the given number of apps,
+// all of them plugging the interfaces that core provides above.
+func benchSnapYaml(apps int) string {
+	var buf strings.Builder
+	buf.WriteString("name: bench-snap\nversion: 1\napps:\n")
+	for i := 0; i < apps; i++ {
+		fmt.Fprintf(&buf, "  app-%d:\n    command: bin/app\n    plugs: [network, network-bind, home, x11, opengl, audio-playback, desktop, removable-media]\n", i)
+	}
+	buf.WriteString("hooks:\n  configure:\n")
+	return buf.String()
+}
+
+func mockInfo(b *testing.B, yaml string, rev int) *snap.Info {
+	info, err := snap.InfoFromSnapYaml([]byte(yaml))
+	if err != nil {
+		b.Fatal(err)
+	}
+	info.SideInfo = snap.SideInfo{Revision: snap.R(rev)}
+	return info
+}
+
+func benchmarkSetupProfiles(b *testing.B, apps int) {
+	dirs.SetRootDir(b.TempDir())
+	defer dirs.SetRootDir("")
+	defer release.MockReleaseInfo(&release.OS{ID: "ubuntu"})()
+	defer apparmor_sandbox.MockFeatures(nil, nil, nil, nil)()
+	defer osutil.MockMountInfo("")()
+	defer apparmor.MockLoadProfiles(func([]string, string, apparmor_sandbox.AaParserFlags) error { return nil })()
+	defer apparmor.MockRemoveCachedProfiles(func([]string, string) error { return nil })()
+
+	repo := interfaces.NewRepository()
+	for _, iface := range builtin.Interfaces() {
+		if err := repo.AddInterface(iface); err != nil {
+			b.Fatal(err)
+		}
+	}
+	backend := &apparmor.Backend{}
+	if err := repo.AddBackend(backend); err != nil {
+		b.Fatal(err)
+	}
+
+	coreInfo := mockInfo(b, benchCoreYaml, 123)
+	snapInfo := mockInfo(b, benchSnapYaml(apps), 1)
+	for _, info := range []*snap.Info{coreInfo, snapInfo} {
+		if err := repo.AddSnap(info); err != nil {
+			b.Fatal(err)
+		}
+	}
+	for _, plug := range snapInfo.Plugs {
+		connRef := interfaces.NewConnRef(plug, coreInfo.Slots[plug.Name])
+		if _, err := repo.Connect(connRef, nil, nil, nil, nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	opts := &interfaces.SecurityBackendOptions{
+		CoreSnapInfo:  coreInfo,
+		SnapdSnapInfo: snap.MinimalPlaceInfo("snapd", snap.R(321)).(*snap.Info),
+	}
+	if err := backend.Initialize(opts); err != nil {
+		b.Fatal(err)
+	}
+
+	meas := timings.New(nil).StartSpan("", "")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// alternate confinement options so that the profiles are
+		// regenerated and rewritten at each iteration
+		confinement := interfaces.ConfinementOptions{DevMode: i%2 == 1}
+		if err := backend.Setup(snapInfo, confinement, repo, meas); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSetupProfiles1(b *testing.B)  { benchmarkSetupProfiles(b, 1) }
+func BenchmarkSetupProfiles50(b *testing.B) { benchmarkSetupProfiles(b, 50) }
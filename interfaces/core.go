@@ -315,3 +315,21 @@ func ValidateDBusBusName(busName string) error {
 	}
 	return nil
 }
+
+// ParseInterfaceNames parses a comma separated list of interface names, as
+// used by the interfaces.forbidden system option, into a set. Empty
+// elements are ignored.
+func ParseInterfaceNames(value string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if err := snap.ValidateInterfaceName(name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	return names, nil
+}
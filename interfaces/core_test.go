@@ -105,6 +105,19 @@ func (s *CoreSuite) TestValidateDBusBusName(c *C) {
 	c.Assert(err, ErrorMatches, `DBus bus name is too long \(must be <= 255\)`)
 }
 
+func (s *CoreSuite) TestParseInterfaceNames(c *C) {
+	names, err := interfaces.ParseInterfaceNames("")
+	c.Assert(err, IsNil)
+	c.Check(names, HasLen, 0)
+
+	names, err = interfaces.ParseInterfaceNames(" camera,, home ,camera")
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, map[string]bool{"camera": true, "home": true})
+
+	_, err = interfaces.ParseInterfaceNames("camera,Home")
+	c.Assert(err, ErrorMatches, `invalid interface name: "Home"`)
+}
+
 // PlugRef.String works as expected
 func (s *CoreSuite) TestPlugRefString(c *C) {
 	ref := interfaces.PlugRef{Snap: "snap", Name: "plug"}
@@ -0,0 +1,147 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/metrics"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type metricsSuite struct {
+	restore func()
+}
+
+var _ = Suite(&metricsSuite{})
+
+func (s *metricsSuite) SetUpTest(c *C) {
+	s.restore = metrics.MockRegistry()
+}
+
+func (s *metricsSuite) TearDownTest(c *C) {
+	s.restore()
+}
+
+func (s *metricsSuite) write(c *C) string {
+	var buf bytes.Buffer
+	c.Assert(metrics.WriteTo(&buf), IsNil)
+	return buf.String()
+}
+
+func (s *metricsSuite) TestCounter(c *C) {
+	ctr := metrics.NewCounter("test_requests_total", "Number of requests.", "method", "code")
+	ctr.Inc("GET", "200")
+	ctr.Inc("GET", "200")
+	ctr.Add(3, "POST", "202")
+
+	c.Check(ctr.Value("GET", "200"), Equals, 2.0)
+	c.Check(ctr.Value("POST", "202"), Equals, 3.0)
+	c.Check(ctr.Value("PUT", "400"), Equals, 0.0)
+
+	c.Check(s.write(c), Equals, `# HELP test_requests_total Number of requests.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",code="200"} 2
+test_requests_total{method="POST",code="202"} 3
+`)
+}
+
+func (s *metricsSuite) TestCounterNoLabels(c *C) {
+	ctr := metrics.NewCounter("test_bytes_total", "Bytes\\downloaded\nin total.")
+	ctr.Add(1024)
+	ctr.Add(0.5)
+
+	c.Check(s.write(c), Equals, `# HELP test_bytes_total Bytes\\downloaded\nin total.
+# TYPE test_bytes_total counter
+test_bytes_total 1024.5
+`)
+}
+
+func (s *metricsSuite) TestCounterEscapesLabelValues(c *C) {
+	ctr := metrics.NewCounter("test_total", "Test.", "path")
+	ctr.Inc("a\"b\\c\nd")
+
+	c.Check(s.write(c), Equals, `# HELP test_total Test.
+# TYPE test_total counter
+test_total{path="a\"b\\c\nd"} 1
+`)
+}
+
+func (s *metricsSuite) TestCounterErrors(c *C) {
+	ctr := metrics.NewCounter("test_total", "Test.", "path")
+	c.Check(func() { ctr.Add(-1, "foo") }, PanicMatches, `internal error: counter "test_total" cannot decrease`)
+	c.Check(func() { ctr.Inc() }, PanicMatches, `internal error: metric "test_total" expects 1 label values, got 0`)
+	c.Check(func() { metrics.NewCounter("test_total", "Again.") }, PanicMatches, `internal error: metric "test_total" registered twice`)
+}
+
+func (s *metricsSuite) TestHistogram(c *C) {
+	h := metrics.NewHistogram("test_duration_seconds", "Duration.", []float64{0.1, 1, 10}, "kind")
+	h.Observe(0.05, "install")
+	h.Observe(1, "install")
+	h.Observe(100, "install")
+	h.Observe(2, "remove")
+
+	c.Check(h.Count("install"), Equals, uint64(3))
+	c.Check(h.Count("remove"), Equals, uint64(1))
+	c.Check(h.Count("refresh"), Equals, uint64(0))
+
+	c.Check(s.write(c), Equals, `# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{kind="install",le="0.1"} 1
+test_duration_seconds_bucket{kind="install",le="1"} 2
+test_duration_seconds_bucket{kind="install",le="10"} 2
+test_duration_seconds_bucket{kind="install",le="+Inf"} 3
+test_duration_seconds_sum{kind="install"} 101.05
+test_duration_seconds_count{kind="install"} 3
+test_duration_seconds_bucket{kind="remove",le="0.1"} 0
+test_duration_seconds_bucket{kind="remove",le="1"} 0
+test_duration_seconds_bucket{kind="remove",le="10"} 1
+test_duration_seconds_bucket{kind="remove",le="+Inf"} 1
+test_duration_seconds_sum{kind="remove"} 2
+test_duration_seconds_count{kind="remove"} 1
+`)
+}
+
+func (s *metricsSuite) TestHistogramUnsortedBuckets(c *C) {
+	c.Check(func() { metrics.NewHistogram("test_seconds", "Test.", []float64{1, 0.1}) }, PanicMatches, `internal error: buckets of histogram "test_seconds" are not sorted`)
+}
+
+func (s *metricsSuite) TestWriteToSortsByName(c *C) {
+	metrics.NewCounter("b_total", "B.").Inc()
+	metrics.NewHistogram("a_seconds", "A.", []float64{1}).Observe(0.5)
+	metrics.NewCounter("c_total", "C.")
+
+	c.Check(s.write(c), Equals, `# HELP a_seconds A.
+# TYPE a_seconds histogram
+a_seconds_bucket{le="1"} 1
+a_seconds_bucket{le="+Inf"} 1
+a_seconds_sum 0.5
+a_seconds_count 1
+# HELP b_total B.
+# TYPE b_total counter
+b_total 1
+# HELP c_total C.
+# TYPE c_total counter
+`)
+}
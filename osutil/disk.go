@@ -21,6 +21,8 @@ package osutil
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"syscall"
 
 	"github.com/snapcore/snapd/strutil"
@@ -59,3 +61,30 @@ func CheckFreeSpace(path string, minSize uint64) error {
 	}
 	return nil
 }
+
+// DirSize returns the total size of the regular files found under the
+// given directory, recursively. A missing directory has a size of zero.
+func DirSize(dir string) (uint64, error) {
+	exists, isDir, err := DirExists(dir)
+	if err != nil {
+		return 0, err
+	}
+	if !exists || !isDir {
+		return 0, nil
+	}
+
+	var total uint64
+	err = filepath.Walk(dir, func(path string, finfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if finfo.Mode().IsRegular() {
+			total += uint64(finfo.Size())
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
@@ -21,6 +21,7 @@ package osutil_test
 
 import (
 	"os"
+	"path/filepath"
 	"syscall"
 
 	. "gopkg.in/check.v1"
@@ -68,3 +69,22 @@ func (s *diskSuite) TestCheckFreeSpacePathError(c *C) {
 	err := osutil.CheckFreeSpace("/does/not/exist/path", 8193)
 	c.Assert(os.IsNotExist(err), Equals, true)
 }
+
+func (s *diskSuite) TestDirSize(c *C) {
+	dir := c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(dir, "sub"), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dir, "a"), make([]byte, 10), 0644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 32), 0644), IsNil)
+	// symlinks are not followed nor counted
+	c.Assert(os.Symlink("a", filepath.Join(dir, "link")), IsNil)
+
+	sz, err := osutil.DirSize(dir)
+	c.Assert(err, IsNil)
+	c.Check(sz, Equals, uint64(42))
+}
+
+func (s *diskSuite) TestDirSizeMissing(c *C) {
+	sz, err := osutil.DirSize(filepath.Join(c.MkDir(), "missing"))
+	c.Assert(err, IsNil)
+	c.Check(sz, Equals, uint64(0))
+}
@@ -0,0 +1,94 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package kcmdline_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/snapcore/snapd/osutil/kcmdline"
+)
+
+// hugeCmdline builds a kernel command line of n arguments mixing the
+// different forms of arguments that can be found in the wild.
+func hugeCmdline(n int) string {
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			args = append(args, fmt.Sprintf("param%d", i))
+		case 1:
+			args = append(args, fmt.Sprintf("param%d=value%d", i, i))
+		case 2:
+			args = append(args, fmt.Sprintf(`param%d="quoted value %d"`, i, i))
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+func writeCmdline(b *testing.B, cmdline string) string {
+	p := filepath.Join(b.TempDir(), "cmdline")
+	if err := os.WriteFile(p, []byte(cmdline), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return p
+}
+
+func benchmarkSplit(b *testing.B, n int) {
+	cmdline := hugeCmdline(n)
+	b.SetBytes(int64(len(cmdline)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := kcmdline.Split(cmdline); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSplit100(b *testing.B)   { benchmarkSplit(b, 100) }
+func BenchmarkSplit10000(b *testing.B) { benchmarkSplit(b, 10000) }
+
+func benchmarkParse(b *testing.B, n int) {
+	cmdline := hugeCmdline(n)
+	b.SetBytes(int64(len(cmdline)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		kcmdline.Parse(cmdline)
+	}
+}
+
+func BenchmarkParse100(b *testing.B)   { benchmarkParse(b, 100) }
+func BenchmarkParse10000(b *testing.B) { benchmarkParse(b, 10000) }
+
+func BenchmarkKeyValues10000(b *testing.B) {
+	cmdline := hugeCmdline(10000)
+	restore := kcmdline.MockProcCmdline(writeCmdline(b, cmdline))
+	defer restore()
+	b.SetBytes(int64(len(cmdline)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := kcmdline.KeyValues("param1", "param9997", "snapd_recovery_mode"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -19,6 +19,11 @@
 
 package osutil
 
+import (
+	"errors"
+	"syscall"
+)
+
 func IsHomeUsingNFS() (bool, error) {
 	return isHomeUsingNFS()
 }
@@ -33,3 +38,19 @@ func MockIsHomeUsingNFS(new func() (bool, error)) (restore func()) {
 		isHomeUsingNFS = old
 	}
 }
+
+// HomeNotWritableReason returns why writing to a home directory failed with
+// err, when that is because of the filesystem the home directory is on:
+// either it is read-only, or it is on NFS which refused the write, e.g.
+// because of root squashing. The empty string is returned otherwise.
+func HomeNotWritableReason(err error) string {
+	if errors.Is(err, syscall.EROFS) {
+		return "is on a read-only filesystem"
+	}
+	if errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM) {
+		if nfs, _ := IsHomeUsingNFS(); nfs {
+			return "is on NFS and does not allow writing to it"
+		}
+	}
+	return ""
+}
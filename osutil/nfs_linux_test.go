@@ -20,6 +20,9 @@
 package osutil_test
 
 import (
+	"os"
+	"syscall"
+
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/osutil"
@@ -96,3 +99,21 @@ func (s *nfsSuite) TestIsHomeUsingNFS(c *C) {
 		c.Assert(nfs, Equals, tc.nfs)
 	}
 }
+
+func (s *nfsSuite) TestHomeNotWritableReason(c *C) {
+	nfs := false
+	restore := osutil.MockIsHomeUsingNFS(func() (bool, error) { return nfs, nil })
+	defer restore()
+
+	rofsErr := &os.PathError{Op: "mkdir", Path: "/home/user/snap", Err: syscall.EROFS}
+	accessErr := &os.PathError{Op: "mkdir", Path: "/home/user/snap", Err: syscall.EACCES}
+
+	c.Check(osutil.HomeNotWritableReason(rofsErr), Equals, "is on a read-only filesystem")
+	c.Check(osutil.HomeNotWritableReason(accessErr), Equals, "")
+	c.Check(osutil.HomeNotWritableReason(syscall.ENOSPC), Equals, "")
+
+	nfs = true
+	c.Check(osutil.HomeNotWritableReason(rofsErr), Equals, "is on a read-only filesystem")
+	c.Check(osutil.HomeNotWritableReason(accessErr), Equals, "is on NFS and does not allow writing to it")
+	c.Check(osutil.HomeNotWritableReason(syscall.ENOSPC), Equals, "")
+}
@@ -24,8 +24,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+
+	"github.com/snapcore/snapd/dirs"
 )
 
 // ExeRuntime is the runtime of an individual executable
@@ -35,12 +39,46 @@ type ExeRuntime struct {
 	TotalSec float64
 }
 
+// The stages of snap run, in order.
+const (
+	StageSnapRun      = "snap run"
+	StageSnapConfine  = "snap-confine"
+	StageSnapUpdateNs = "snap-update-ns"
+	StageSnapExec     = "snap-exec"
+	StageCommandChain = "command-chain"
+	StageApp          = "app"
+)
+
+var stageOrder = []string{StageSnapRun, StageSnapConfine, StageSnapUpdateNs, StageSnapExec, StageCommandChain, StageApp}
+
+var stageDescriptions = map[string]string{
+	StageSnapConfine:  "sandbox and security profile setup",
+	StageSnapUpdateNs: "mount namespace setup",
+	StageSnapExec:     "environment setup",
+}
+
+// StageRuntime is the time spent in a stage of snap run
+type StageRuntime struct {
+	Stage    string
+	TotalSec float64
+}
+
 // ExecveTiming measures the execve calls timings under strace. This is
 // useful for performance analysis. It keeps the N slowest samples.
 type ExecveTiming struct {
 	TotalTime   float64
 	exeRuntimes []ExeRuntime
 
+	// AppStageName is how the stage running the app itself is
+	// displayed, e.g. "hook" when running a hook.
+	AppStageName string
+	mainPid      string
+	stages       map[string]float64
+	// appRuntimes are the runtimes of the executables run in turn by
+	// the initial process after snap-exec, the last one being the app
+	// and the others its command-chain
+	appRuntimes []float64
+
 	nSlowestSamples int
 }
 
@@ -73,13 +111,94 @@ func (stt *ExecveTiming) prune() {
 	}
 }
 
+// stageOf returns the stage of snap run the given executable run by the
+// given pid belongs to. Only executables run by the initial process are
+// accounted as part of the app, not their children.
+func (stt *ExecveTiming) stageOf(pid, exe string) string {
+	switch filepath.Base(exe) {
+	case "snap":
+		return StageSnapRun
+	case "snap-confine":
+		return StageSnapConfine
+	case "snap-update-ns":
+		return StageSnapUpdateNs
+	case "snap-exec":
+		return StageSnapExec
+	}
+	if strings.HasPrefix(exe, dirs.SnapBinariesDir+"/") {
+		// the /snap/bin symlinks to snap
+		return StageSnapRun
+	}
+	if pid == stt.mainPid {
+		return StageApp
+	}
+	return ""
+}
+
+func (stt *ExecveTiming) addStageRuntime(pid, exe string, totalSec float64) {
+	stage := stt.stageOf(pid, exe)
+	switch stage {
+	case "":
+		return
+	case StageApp:
+		stt.appRuntimes = append(stt.appRuntimes, totalSec)
+		return
+	}
+	if stt.stages == nil {
+		stt.stages = make(map[string]float64)
+	}
+	stt.stages[stage] += totalSec
+}
+
+// StageRuntimes returns the time spent in each stage of snap run, in
+// order. Note that the snap-update-ns stage happens during snap-confine.
+func (stt *ExecveTiming) StageRuntimes() []StageRuntime {
+	var stages []StageRuntime
+	for _, stage := range stageOrder {
+		switch stage {
+		case StageCommandChain:
+			if len(stt.appRuntimes) > 1 {
+				var totalSec float64
+				for _, sec := range stt.appRuntimes[:len(stt.appRuntimes)-1] {
+					totalSec += sec
+				}
+				stages = append(stages, StageRuntime{Stage: stage, TotalSec: totalSec})
+			}
+		case StageApp:
+			if len(stt.appRuntimes) > 0 {
+				stages = append(stages, StageRuntime{Stage: stage, TotalSec: stt.appRuntimes[len(stt.appRuntimes)-1]})
+			}
+		default:
+			if totalSec, ok := stt.stages[stage]; ok {
+				stages = append(stages, StageRuntime{Stage: stage, TotalSec: totalSec})
+			}
+		}
+	}
+	return stages
+}
+
 func (stt *ExecveTiming) Display(w io.Writer) {
-	if len(stt.exeRuntimes) == 0 {
+	if len(stt.exeRuntimes) == 0 && len(stt.stages) == 0 && len(stt.appRuntimes) == 0 {
 		return
 	}
-	fmt.Fprintf(w, "Slowest %d exec calls during snap run:\n", len(stt.exeRuntimes))
-	for _, rt := range stt.exeRuntimes {
-		fmt.Fprintf(w, "  %2.3fs %s\n", rt.TotalSec, rt.Exe)
+	if len(stt.exeRuntimes) > 0 {
+		fmt.Fprintf(w, "Slowest %d exec calls during snap run:\n", len(stt.exeRuntimes))
+		for _, rt := range stt.exeRuntimes {
+			fmt.Fprintf(w, "  %2.3fs %s\n", rt.TotalSec, rt.Exe)
+		}
+	}
+	if stages := stt.StageRuntimes(); len(stages) > 0 {
+		fmt.Fprintf(w, "Time spent in each stage of snap run:\n")
+		for _, st := range stages {
+			name := st.Stage
+			if name == StageApp && stt.AppStageName != "" {
+				name = stt.AppStageName
+			}
+			if desc := stageDescriptions[st.Stage]; desc != "" {
+				name = fmt.Sprintf("%s (%s)", name, desc)
+			}
+			fmt.Fprintf(w, "  %2.3fs %s\n", st.TotalSec, name)
+		}
 	}
 	fmt.Fprintf(w, "Total time: %2.3fs\n", stt.TotalTime)
 }
@@ -141,9 +260,13 @@ func handleExecMatch(trace *ExecveTiming, pt *pidTracker, match []string) error
 		return err
 	}
 	exe := match[3]
+	if trace.mainPid == "" {
+		trace.mainPid = pid
+	}
 	// deal with subsequent execve()
 	if start, exe := pt.Get(pid); exe != "" {
 		trace.addExeRuntime(exe, execStart-start)
+		trace.addStageRuntime(pid, exe, execStart-start)
 	}
 	pt.Add(pid, execStart, exe)
 	return nil
@@ -160,6 +283,7 @@ func handleSignalMatch(trace *ExecveTiming, pt *pidTracker, match []string) erro
 	sigPid := match[3]
 	if start, exe := pt.Get(sigPid); exe != "" {
 		trace.addExeRuntime(exe, sigTime-start)
+		trace.addStageRuntime(sigPid, exe, sigTime-start)
 		pt.Del(sigPid)
 	}
 	return nil
@@ -219,6 +343,11 @@ func TraceExecveTimings(straceLog string, nSlowest int) (*ExecveTiming, error) {
 		return nil, fmt.Errorf("cannot parse end of exec profile: %s", err)
 	}
 	trace.TotalTime = end - start
+	// the processes still running at the end, typically the app itself,
+	// only count towards their stage
+	for pid, es := range pidTracker.pidToExeStart {
+		trace.addStageRuntime(pid, es.exe, end-es.start)
+	}
 
 	if r.Err() != nil {
 		return nil, r.Err()
@@ -136,3 +136,80 @@ func (s *timingSuite) TestTraceExecveTimings(c *C) {
 		{Exe: "/usr/lib/snapd/snap-exec", TotalSec: 0.006349086761474609},
 	})
 }
+
+func (s *timingSuite) TestTraceExecveTimingsStages(c *C) {
+	f, err := ioutil.TempFile("", "strace-extract-test-")
+	c.Assert(err, IsNil)
+	defer os.Remove(f.Name())
+	_, err = f.Write(sampleStraceSimple)
+	c.Assert(err, IsNil)
+	f.Sync()
+
+	st, err := strace.TraceExecveTimings(f.Name(), 10)
+	c.Assert(err, IsNil)
+	// the time snap-update-ns runs is part of the snap-confine stage
+	c.Check(st.StageRuntimes(), DeepEquals, []strace.StageRuntime{
+		{Stage: "snap run", TotalSec: 0.021938085556030273},
+		{Stage: "snap-confine", TotalSec: 0.15650391578674316},
+		{Stage: "snap-update-ns", TotalSec: 0.0042438507080078125},
+		{Stage: "snap-exec", TotalSec: 0.006349086761474609},
+		{Stage: "app", TotalSec: 0.0012760162353515625},
+	})
+
+	buf := bytes.NewBuffer(nil)
+	st.AppStageName = "hook"
+	st.Display(buf)
+	c.Check(buf.String(), Equals, `Slowest 5 exec calls during snap run:
+  0.006s /snap/bin/test-snapd-tools.echo
+  0.016s /snap/core/current/usr/bin/snap
+  0.004s snap-update-ns
+  0.157s /snap/core/5976/usr/lib/snapd/snap-confine
+  0.006s /usr/lib/snapd/snap-exec
+Time spent in each stage of snap run:
+  0.022s snap run
+  0.157s snap-confine (sandbox and security profile setup)
+  0.004s snap-update-ns (mount namespace setup)
+  0.006s snap-exec (environment setup)
+  0.001s hook
+Total time: 0.186s
+`)
+}
+
+var sampleStraceCommandChain = []byte(`21616 1542882400.198907 execve("/snap/bin/test-snapd-tools.echo", ["test-snapd-tools.echo", "foo"], 0x7fff7f275f48 /* 27 vars */) = 0
+21616 1542882400.204710 execve("/snap/core/current/usr/bin/snap", ["test-snapd-tools.echo", "foo"], 0xc42011c8c0 /* 27 vars */) = 0
+21616 1542882400.220845 execve("/snap/core/5976/usr/lib/snapd/snap-confine", ["/snap/core/5976/usr/lib/snapd/sn"..., "snap.test-snapd-tools.echo", "/usr/lib/snapd/snap-exec", "test-snapd-tools.echo", "foo"], 0xc8200a3600 /* 41 vars */) = 0
+21616 1542882400.377349 execve("/usr/lib/snapd/snap-exec", ["/usr/lib/snapd/snap-exec", "test-snapd-tools.echo", "foo"], 0x23ebc80 /* 45 vars */) = 0
+21616 1542882400.383698 execve("/snap/test-snapd-tools/6/bin/chain1", ["/snap/test-snapd-tools/6/bin/cha"..., "foo"], 0xc420072f00 /* 47 vars */) = 0
+21616 1542882400.393698 execve("/snap/test-snapd-tools/6/bin/chain2", ["/snap/test-snapd-tools/6/bin/cha"..., "foo"], 0xc420072f00 /* 47 vars */) = 0
+21616 1542882400.413698 execve("/snap/test-snapd-tools/6/bin/echo", ["/snap/test-snapd-tools/6/bin/ech"..., "foo"], 0xc420072f00 /* 47 vars */) = 0
+21616 1542882400.418698 +++ exited with 0 +++
+`)
+
+func (s *timingSuite) TestTraceExecveTimingsCommandChain(c *C) {
+	f, err := ioutil.TempFile("", "strace-extract-test-")
+	c.Assert(err, IsNil)
+	defer os.Remove(f.Name())
+	_, err = f.Write(sampleStraceCommandChain)
+	c.Assert(err, IsNil)
+	f.Sync()
+
+	st, err := strace.TraceExecveTimings(f.Name(), 10)
+	c.Assert(err, IsNil)
+	stages := st.StageRuntimes()
+	c.Assert(stages, HasLen, 5)
+	for i, stage := range []string{"snap run", "snap-confine", "snap-exec", "command-chain", "app"} {
+		c.Check(stages[i].Stage, Equals, stage)
+	}
+
+	// both command-chain entries are accounted together
+	buf := bytes.NewBuffer(nil)
+	st.Display(buf)
+	c.Check(buf.String(), Matches, `(?s).*Time spent in each stage of snap run:
+  0.022s snap run
+  0.157s snap-confine \(sandbox and security profile setup\)
+  0.006s snap-exec \(environment setup\)
+  0.030s command-chain
+  0.005s app
+Total time: 0.220s
+`)
+}
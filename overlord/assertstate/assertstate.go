@@ -377,6 +377,31 @@ func delayedCrossMgrInit() {
 	snapstate.EnforceValidationSets = ApplyEnforcedValidationSets
 	// hook helper for enforcing already existing validation set assertions
 	snapstate.EnforceLocalValidationSets = ApplyLocalEnforcedValidationSets
+	// hook helper for getting the integrity data declared by snap-revisions
+	snapstate.SnapRevisionIntegrity = SnapRevisionIntegrity
+}
+
+// SnapRevisionIntegrity returns the integrity data declared by the
+// snap-revision assertion of the given snap revision, or nil if there are
+// none or the assertion is not known.
+func SnapRevisionIntegrity(st *state.State, snapID string, rev snap.Revision) (*asserts.SnapIntegrity, error) {
+	db := DB(st)
+	as, err := db.FindMany(asserts.SnapRevisionType, map[string]string{
+		"snap-id":       snapID,
+		"snap-revision": rev.String(),
+	})
+	if errors.Is(err, &asserts.NotFoundError{}) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range as {
+		if snapIntegrity := a.(*asserts.SnapRevision).SnapIntegrity(); snapIntegrity != nil {
+			return snapIntegrity, nil
+		}
+	}
+	return nil, nil
 }
 
 // AutoRefreshAssertions tries to refresh all assertions
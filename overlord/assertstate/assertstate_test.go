@@ -485,6 +485,52 @@ func fakeHash(rev int) []byte {
 	return h[:]
 }
 
+func (s *assertMgrSuite) TestSnapRevisionIntegrity(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(assertstate.Add(s.state, s.storeSigning.StoreAccountKey("")), IsNil)
+	c.Assert(assertstate.Add(s.state, s.dev1Acct), IsNil)
+	c.Assert(assertstate.Add(s.state, s.snapDecl(c, "foo", nil)), IsNil)
+
+	// no snap-revision
+	snapIntegrity, err := assertstate.SnapRevisionIntegrity(s.state, "foo-id", snap.R(1))
+	c.Assert(err, IsNil)
+	c.Check(snapIntegrity, IsNil)
+
+	for _, rev := range []int{1, 2} {
+		headers := map[string]interface{}{
+			"snap-id":       "foo-id",
+			"snap-sha3-384": makeDigest(rev),
+			"snap-size":     fmt.Sprintf("%d", len(fakeSnap(rev))),
+			"snap-revision": fmt.Sprintf("%d", rev),
+			"developer-id":  s.dev1Acct.AccountID(),
+			"timestamp":     time.Now().Format(time.RFC3339),
+		}
+		if rev == 2 {
+			headers["integrity"] = map[string]interface{}{
+				"sha3-384": makeDigest(20),
+				"size":     "8192",
+			}
+		}
+		snapRev, err := s.storeSigning.Sign(asserts.SnapRevisionType, headers, nil, "")
+		c.Assert(err, IsNil)
+		c.Assert(assertstate.Add(s.state, snapRev), IsNil)
+	}
+
+	// the snap-revision declares no integrity data
+	snapIntegrity, err = assertstate.SnapRevisionIntegrity(s.state, "foo-id", snap.R(1))
+	c.Assert(err, IsNil)
+	c.Check(snapIntegrity, IsNil)
+
+	snapIntegrity, err = assertstate.SnapRevisionIntegrity(s.state, "foo-id", snap.R(2))
+	c.Assert(err, IsNil)
+	c.Check(snapIntegrity, DeepEquals, &asserts.SnapIntegrity{
+		SHA3_384: makeDigest(20),
+		Size:     8192,
+	})
+}
+
 func makeDigest(rev int) string {
 	d, err := asserts.EncodeDigest(crypto.SHA3_384, fakeHash(rev))
 	if err != nil {
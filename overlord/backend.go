@@ -20,9 +20,30 @@
 package overlord
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
 	"time"
 
+	"golang.org/x/crypto/sha3"
+
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+const (
+	// stateChecksumSuffix is appended to the path of a state file
+	// to get the path of the file holding its checksum.
+	stateChecksumSuffix = ".sha3-384"
+	// stateBackupSuffix is appended to the path of the state file to
+	// get the path of the copy of the previously written state.
+	stateBackupSuffix = ".bak"
+	// stateCorruptSuffix is appended to the path of the state file
+	// to get the path a corrupted state file is moved to on recovery.
+	stateCorruptSuffix = ".corrupt"
 )
 
 type overlordStateBackend struct {
@@ -31,9 +52,134 @@ type overlordStateBackend struct {
 }
 
 func (osb *overlordStateBackend) Checkpoint(data []byte) error {
-	return osutil.AtomicWriteFile(osb.path, data, 0600, 0)
+	if err := backupStateFile(osb.path); err != nil {
+		return fmt.Errorf("cannot backup the state file: %v", err)
+	}
+	if err := osutil.AtomicWriteFile(osb.path, data, 0600, 0); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(osb.path+stateChecksumSuffix, stateChecksum(data), 0600, 0)
 }
 
 func (osb *overlordStateBackend) EnsureBefore(d time.Duration) {
 	osb.ensureBefore(d)
 }
+
+func stateChecksum(data []byte) []byte {
+	sum := sha3.Sum384(data)
+	return []byte(hex.EncodeToString(sum[:]))
+}
+
+// backupStateFile keeps the state file at path, which is the last one
+// that was written completely, together with its checksum as the backup
+// copy. The state file is hard linked so this is cheap.
+func backupStateFile(path string) error {
+	backup := path + stateBackupSuffix
+	if err := replaceWithLink(path, backup); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	// the checksum of the state file is moved along, until the new
+	// state is written the state file has no checksum and is trusted
+	// as before checksums were introduced
+	err := os.Rename(path+stateChecksumSuffix, backup+stateChecksumSuffix)
+	if os.IsNotExist(err) {
+		err = os.Remove(backup + stateChecksumSuffix)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// replaceWithLink atomically replaces newname with a hard link to
+// oldname.
+func replaceWithLink(oldname, newname string) error {
+	tmp := newname + "~"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(oldname, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, newname)
+}
+
+// corruptStateError is returned when a state file does not match its
+// checksum or cannot be decoded.
+type corruptStateError struct {
+	err error
+}
+
+func (e *corruptStateError) Error() string {
+	return e.err.Error()
+}
+
+// readStateFile reads the state from the file at path, verifying it
+// against its checksum if there is one. A state file not matching its
+// checksum is considered corrupted, a state file modified on purpose while
+// snapd was not running needs its checksum file to be removed.
+func readStateFile(backend state.Backend, path string) (*state.State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the state file: %s", err)
+	}
+	sum, err := os.ReadFile(path + stateChecksumSuffix)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read the state file checksum: %s", err)
+	}
+	if err == nil && !bytes.Equal(bytes.TrimSpace(sum), stateChecksum(data)) {
+		return nil, &corruptStateError{fmt.Errorf("state file %s does not match its checksum", path)}
+	}
+	st, err := state.ReadState(backend, bytes.NewReader(data))
+	if err != nil {
+		return nil, &corruptStateError{err}
+	}
+	return st, nil
+}
+
+// readState reads the state from the state file at path. If the state
+// file is corrupted, that is it does not match its checksum or cannot be
+// decoded, the state is restored from the backup copy of the previously
+// written state, if that is intact. The corrupted state file is kept
+// aside for inspection.
+func readState(backend state.Backend, path string) (*state.State, error) {
+	s, err := readStateFile(backend, path)
+	if err == nil {
+		return s, nil
+	}
+	var cerr *corruptStateError
+	if !errors.As(err, &cerr) {
+		return nil, err
+	}
+
+	backup := path + stateBackupSuffix
+	if !osutil.FileExists(backup) {
+		return nil, err
+	}
+	s, backupErr := readStateFile(backend, backup)
+	if backupErr != nil {
+		logger.Noticef("cannot restore the state from its backup: %v", backupErr)
+		return nil, err
+	}
+	logger.Noticef("WARNING: restoring the state from its backup, the state file is corrupted: %v", err)
+	if err := os.Remove(path + stateCorruptSuffix); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := os.Link(path, path+stateCorruptSuffix); err != nil {
+		return nil, err
+	}
+	if err := replaceWithLink(backup, path); err != nil {
+		return nil, fmt.Errorf("cannot restore the state file from its backup: %v", err)
+	}
+	err = replaceWithLink(backup+stateChecksumSuffix, path+stateChecksumSuffix)
+	if os.IsNotExist(err) {
+		err = os.Remove(path + stateChecksumSuffix)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot restore the state file checksum from its backup: %v", err)
+	}
+	return s, nil
+}
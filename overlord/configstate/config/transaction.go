@@ -69,6 +69,59 @@ func (t *Transaction) State() *state.State {
 	return t.state
 }
 
+// Clone returns a new transaction with the same view of the configuration
+// as t, including the changes made so far. Changes made to the clone do
+// not affect t until they are moved back with ReplaceChanges.
+func (t *Transaction) Clone() *Transaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pristine := make(map[string]map[string]*json.RawMessage, len(t.pristine))
+	for instanceName, config := range t.pristine {
+		pristine[instanceName] = make(map[string]*json.RawMessage, len(config))
+		for k, v := range config {
+			pristine[instanceName][k] = v
+		}
+	}
+	changes := make(map[string]map[string]interface{}, len(t.changes))
+	for instanceName, snapChanges := range t.changes {
+		changes[instanceName] = copyChanges(snapChanges)
+	}
+	return &Transaction{
+		state:    t.state,
+		pristine: pristine,
+		changes:  changes,
+	}
+}
+
+// ReplaceChanges replaces the changes made in t with the ones made in
+// other, which is expected to be a clone of t.
+func (t *Transaction) ReplaceChanges(other *Transaction) {
+	other.mu.Lock()
+	changes := make(map[string]map[string]interface{}, len(other.changes))
+	for instanceName, snapChanges := range other.changes {
+		changes[instanceName] = copyChanges(snapChanges)
+	}
+	other.mu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.changes = changes
+}
+
+// copyChanges copies the given tree of changes, raw values are never
+// modified in place so they can be shared.
+func copyChanges(changes map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(changes))
+	for k, v := range changes {
+		if m, ok := v.(map[string]interface{}); ok {
+			v = copyChanges(m)
+		}
+		out[k] = v
+	}
+	return out
+}
+
 func changes(cfgStr string, cfg map[string]interface{}) []string {
 	var out []string
 	for k := range cfg {
@@ -505,6 +505,40 @@ func (s *transactionSuite) TestCommitOverNilSnapConfig(c *C) {
 	c.Assert(v, Equals, "bar")
 }
 
+func (s *transactionSuite) TestCloneAndReplaceChanges(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Assert(s.transaction.Set("test-snap", "a", map[string]interface{}{"b": 1, "c": 2}), IsNil)
+	s.transaction.Commit()
+
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "a.b", 10), IsNil)
+
+	clone := tr.Clone()
+	c.Assert(clone.Set("test-snap", "a.c", 20), IsNil)
+	c.Assert(clone.Set("test-snap", "d", "x"), IsNil)
+
+	var v int
+	// the clone sees the changes made before it was created
+	c.Assert(clone.Get("test-snap", "a.b", &v), IsNil)
+	c.Check(v, Equals, 10)
+	// the original does not see the changes made to the clone
+	c.Assert(tr.Get("test-snap", "a.c", &v), IsNil)
+	c.Check(v, Equals, 2)
+	c.Check(tr.Changes(), DeepEquals, []string{"test-snap.a.b"})
+
+	tr.ReplaceChanges(clone)
+	c.Check(tr.Changes(), DeepEquals, []string{"test-snap.a.b", "test-snap.a.c", "test-snap.d"})
+	// further changes to the clone are not seen
+	c.Assert(clone.Set("test-snap", "e", "y"), IsNil)
+	c.Check(tr.Changes(), DeepEquals, []string{"test-snap.a.b", "test-snap.a.c", "test-snap.d"})
+
+	tr.Commit()
+	var a map[string]int
+	c.Assert(config.NewTransaction(s.state).Get("test-snap", "a", &a), IsNil)
+	c.Check(a, DeepEquals, map[string]int{"b": 10, "c": 20})
+}
+
 func (s *transactionSuite) TestGetUnmarshalError(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -0,0 +1,56 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package configcore
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.api.rate-limit"] = true
+	supportedConfigurations["core.api.max-concurrent-operations"] = true
+}
+
+func validateAPILimits(tr RunTransaction) error {
+	rateLimit, err := coreCfg(tr, "api.rate-limit")
+	if err != nil {
+		return err
+	}
+	if rateLimit != "" {
+		// 0 disables rate limiting
+		if n, err := strconv.Atoi(rateLimit); err != nil || n < 0 {
+			return fmt.Errorf("api.rate-limit must be a number of requests per minute, or 0 to disable, not %q", rateLimit)
+		}
+	}
+
+	maxOps, err := coreCfg(tr, "api.max-concurrent-operations")
+	if err != nil {
+		return err
+	}
+	if maxOps != "" {
+		// 0 means no limit
+		if n, err := strconv.Atoi(maxOps); err != nil || n < 0 {
+			return fmt.Errorf("api.max-concurrent-operations must be a number of operations, or 0 for no limit, not %q", maxOps)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type apiSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&apiSuite{})
+
+func (s *apiSuite) TestConfigureAPILimitsHappy(c *C) {
+	for _, conf := range []map[string]interface{}{
+		{"api.rate-limit": 0},
+		{"api.rate-limit": 600},
+		{"api.rate-limit": "600"},
+		{"api.max-concurrent-operations": 0},
+		{"api.max-concurrent-operations": 1},
+		{"api.max-concurrent-operations": "8"},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  conf,
+		})
+		c.Check(err, IsNil, Commentf("%v", conf))
+	}
+}
+
+func (s *apiSuite) TestConfigureAPILimitsErrors(c *C) {
+	for _, t := range []struct {
+		conf map[string]interface{}
+		err  string
+	}{
+		{map[string]interface{}{"api.rate-limit": -1}, `api.rate-limit must be a number of requests per minute, or 0 to disable, not "-1"`},
+		{map[string]interface{}{"api.rate-limit": "lots"}, `api.rate-limit must be a number of requests per minute, or 0 to disable, not "lots"`},
+		{map[string]interface{}{"api.max-concurrent-operations": -1}, `api.max-concurrent-operations must be a number of operations, or 0 for no limit, not "-1"`},
+		{map[string]interface{}{"api.max-concurrent-operations": "2.5"}, `api.max-concurrent-operations must be a number of operations, or 0 for no limit, not "2.5"`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  t.conf,
+		})
+		c.Check(err, ErrorMatches, t.err, Commentf("%v", t.conf))
+	}
+}
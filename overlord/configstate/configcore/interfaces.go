@@ -0,0 +1,100 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+const forbiddenInterfacesOpt = "interfaces.forbidden"
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core."+forbiddenInterfacesOpt] = true
+}
+
+// ForbiddenConnections returns the existing connections of the given
+// forbidden interfaces. It is set by configstate to avoid a dependency on
+// the interface manager.
+var ForbiddenConnections func(st *state.State, forbidden map[string]bool) ([]*interfaces.ConnRef, error)
+
+func parseForbiddenInterfaces(value string) (map[string]bool, error) {
+	forbidden, err := interfaces.ParseInterfaceNames(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set %s: %v", forbiddenInterfacesOpt, err)
+	}
+	return forbidden, nil
+}
+
+func validateForbiddenInterfaces(tr RunTransaction) error {
+	value, err := coreCfg(tr, forbiddenInterfacesOpt)
+	if err != nil {
+		return err
+	}
+	_, err = parseForbiddenInterfaces(value)
+	return err
+}
+
+// handleForbiddenInterfaces reports the existing connections violating a
+// new interfaces.forbidden policy. They are not disconnected automatically
+// as that could break running applications, instead a warning is recorded
+// for the administrator.
+func handleForbiddenInterfaces(tr RunTransaction, opts *fsOnlyContext) error {
+	var pristine, value string
+	if err := tr.GetPristine("core", forbiddenInterfacesOpt, &pristine); err != nil && !config.IsNoOption(err) {
+		return err
+	}
+	if err := tr.Get("core", forbiddenInterfacesOpt, &value); err != nil && !config.IsNoOption(err) {
+		return err
+	}
+	if pristine == value || ForbiddenConnections == nil {
+		return nil
+	}
+	forbidden, err := parseForbiddenInterfaces(value)
+	if err != nil {
+		return err
+	}
+
+	st := tr.State()
+	st.Lock()
+	defer st.Unlock()
+
+	connRefs, err := ForbiddenConnections(st, forbidden)
+	if err != nil {
+		return err
+	}
+	if len(connRefs) == 0 {
+		return nil
+	}
+	conns := make([]string, len(connRefs))
+	for i, connRef := range connRefs {
+		conns[i] = fmt.Sprintf("%s:%s %s:%s", connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
+	}
+	logger.Noticef("existing connections violate the %s system option: %s", forbiddenInterfacesOpt, strings.Join(conns, ", "))
+	st.Warnf("the following connections use interfaces forbidden by the %s system option and should be disconnected: %s",
+		forbiddenInterfacesOpt, strings.Join(conns, ", "))
+	return nil
+}
@@ -0,0 +1,99 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type forbiddenInterfacesSuite struct {
+	configcoreSuite
+
+	forbidden map[string]bool
+}
+
+var _ = Suite(&forbiddenInterfacesSuite{})
+
+func (s *forbiddenInterfacesSuite) SetUpTest(c *C) {
+	s.configcoreSuite.SetUpTest(c)
+
+	s.forbidden = nil
+	s.AddCleanup(testutil.Backup(&configcore.ForbiddenConnections))
+	configcore.ForbiddenConnections = func(st *state.State, forbidden map[string]bool) ([]*interfaces.ConnRef, error) {
+		s.forbidden = forbidden
+		if !forbidden["system-observe"] {
+			return nil, nil
+		}
+		return []*interfaces.ConnRef{
+			{PlugRef: interfaces.PlugRef{Snap: "app", Name: "system-observe"}, SlotRef: interfaces.SlotRef{Snap: "core", Name: "system-observe"}},
+			{PlugRef: interfaces.PlugRef{Snap: "other", Name: "observe"}, SlotRef: interfaces.SlotRef{Snap: "core", Name: "system-observe"}},
+		}, nil
+	}
+}
+
+func (s *forbiddenInterfacesSuite) TestForbiddenInterfacesInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf:  map[string]interface{}{"interfaces.forbidden": "camera,Not_Valid"},
+	})
+	c.Check(err, ErrorMatches, `cannot set interfaces.forbidden: invalid interface name: "Not_Valid"`)
+}
+
+func (s *forbiddenInterfacesSuite) TestForbiddenInterfacesReportsExistingConnections(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state:   s.state,
+		changes: map[string]interface{}{"interfaces.forbidden": "camera, system-observe"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.forbidden, DeepEquals, map[string]bool{"camera": true, "system-observe": true})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `the following connections use interfaces forbidden by the interfaces.forbidden system option and should be disconnected: app:system-observe core:system-observe, other:observe core:system-observe`)
+}
+
+func (s *forbiddenInterfacesSuite) TestForbiddenInterfacesNoViolations(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state:   s.state,
+		changes: map[string]interface{}{"interfaces.forbidden": "camera"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.forbidden, DeepEquals, map[string]bool{"camera": true})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.AllWarnings(), HasLen, 0)
+}
+
+func (s *forbiddenInterfacesSuite) TestForbiddenInterfacesUnchanged(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf:  map[string]interface{}{"interfaces.forbidden": "system-observe"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.forbidden, IsNil)
+}
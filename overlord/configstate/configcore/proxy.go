@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/dirs"
@@ -52,6 +53,8 @@ func init() {
 	supportedConfigurations["core.proxy.ftp"] = true
 	supportedConfigurations["core.proxy.no-proxy"] = true
 	supportedConfigurations["core.proxy.store"] = true
+	supportedConfigurations["core.proxy.store-backup"] = true
+	supportedConfigurations["core.proxy.store-failover-timeout"] = true
 }
 
 func etcEnvironment() string {
@@ -103,7 +106,30 @@ func handleProxyConfiguration(tr RunTransaction, opts *fsOnlyContext) error {
 }
 
 func validateProxyStore(tr RunTransaction) error {
-	proxyStore, err := coreCfg(tr, "proxy.store")
+	for _, key := range []string{"proxy.store", "proxy.store-backup"} {
+		if err := validateProxyStoreOption(tr, key); err != nil {
+			return err
+		}
+	}
+
+	failoverTimeout, err := coreCfg(tr, "proxy.store-failover-timeout")
+	if err != nil {
+		return err
+	}
+	if failoverTimeout != "" {
+		dur, err := time.ParseDuration(failoverTimeout)
+		if err != nil {
+			return fmt.Errorf("cannot parse proxy.store-failover-timeout: %v", err)
+		}
+		if dur <= 0 {
+			return fmt.Errorf("proxy.store-failover-timeout must be positive, not %q", failoverTimeout)
+		}
+	}
+	return nil
+}
+
+func validateProxyStoreOption(tr RunTransaction, key string) error {
+	proxyStore, err := coreCfg(tr, key)
 	if err != nil {
 		return err
 	}
@@ -118,10 +144,10 @@ func validateProxyStore(tr RunTransaction) error {
 
 	store, err := assertstate.Store(st, proxyStore)
 	if errors.Is(err, &asserts.NotFoundError{}) {
-		return fmt.Errorf("cannot set proxy.store to %q without a matching store assertion", proxyStore)
+		return fmt.Errorf("cannot set %s to %q without a matching store assertion", key, proxyStore)
 	}
 	if err == nil && store.URL() == nil {
-		return fmt.Errorf("cannot set proxy.store to %q with a matching store assertion with url unset", proxyStore)
+		return fmt.Errorf("cannot set %s to %q with a matching store assertion with url unset", key, proxyStore)
 	}
 	return err
 }
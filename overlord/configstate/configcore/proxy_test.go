@@ -233,3 +233,57 @@ func (s *proxySuite) TestConfigureProxyStoreNoURL(c *C) {
 	err = configcore.Run(coreDev, conf)
 	c.Check(err, ErrorMatches, `cannot set proxy.store to "foo" with a matching store assertion with url unset`)
 }
+
+func (s *proxySuite) TestConfigureProxyStoreBackup(c *C) {
+	conf := &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"proxy.store-backup": "bar",
+		},
+	}
+	err := configcore.Run(classicDev, conf)
+	c.Check(err, ErrorMatches, `cannot set proxy.store-backup to "bar" without a matching store assertion`)
+
+	operatorAcct := assertstest.NewAccount(s.storeSigning, "bar-operator", nil, "")
+	stoAs, err := s.storeSigning.Sign(asserts.StoreType, map[string]interface{}{
+		"store":       "bar",
+		"operator-id": operatorAcct.AccountID(),
+		"url":         "http://backup.internal:9943",
+		"timestamp":   time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	func() {
+		s.state.Lock()
+		defer s.state.Unlock()
+		assertstatetest.AddMany(s.state, operatorAcct, stoAs)
+	}()
+
+	err = configcore.Run(classicDev, conf)
+	c.Check(err, IsNil)
+}
+
+func (s *proxySuite) TestConfigureProxyStoreFailoverTimeout(c *C) {
+	for _, t := range []struct {
+		value string
+		err   string
+	}{
+		{"", ""},
+		{"30s", ""},
+		{"10m", ""},
+		{"foo", `cannot parse proxy.store-failover-timeout: time: invalid duration "foo"`},
+		{"0s", `proxy.store-failover-timeout must be positive, not "0s"`},
+		{"-1m", `proxy.store-failover-timeout must be positive, not "-1m"`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				"proxy.store-failover-timeout": t.value,
+			},
+		})
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf(t.value))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf(t.value))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.system.reboot-required.notify"] = true
+	supportedConfigurations["core.system.reboot-required.webhook-url"] = true
+}
+
+var rebootRequiredNotifyMethods = map[string]bool{
+	"wall":    true,
+	"desktop": true,
+	"motd":    true,
+	"webhook": true,
+}
+
+func validateRebootRequiredNotify(tr RunTransaction) error {
+	methods, err := coreCfg(tr, "system.reboot-required.notify")
+	if err != nil {
+		return err
+	}
+	webhook := false
+	if methods != "" {
+		for _, m := range strings.Split(methods, ",") {
+			m = strings.TrimSpace(m)
+			if !rebootRequiredNotifyMethods[m] {
+				return fmt.Errorf("cannot set system.reboot-required.notify: unsupported method %q", m)
+			}
+			if m == "webhook" {
+				webhook = true
+			}
+		}
+	}
+
+	webhookURL, err := coreCfg(tr, "system.reboot-required.webhook-url")
+	if err != nil {
+		return err
+	}
+	if webhookURL != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil {
+			return fmt.Errorf("cannot parse system.reboot-required.webhook-url: %v", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("system.reboot-required.webhook-url must be an http or https URL")
+		}
+	} else if webhook {
+		return fmt.Errorf("cannot use webhook reboot required notification without system.reboot-required.webhook-url")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type rebootNotifySuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&rebootNotifySuite{})
+
+func (s *rebootNotifySuite) TestConfigureRebootRequiredNotifyHappy(c *C) {
+	for _, conf := range []map[string]interface{}{
+		{"system.reboot-required.notify": "wall"},
+		{"system.reboot-required.notify": "wall,desktop, motd"},
+		{
+			"system.reboot-required.notify":      "motd,webhook",
+			"system.reboot-required.webhook-url": "https://example.com/hook",
+		},
+		{"system.reboot-required.webhook-url": "http://example.com/hook"},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  conf,
+		})
+		c.Check(err, IsNil, Commentf("%v", conf))
+	}
+}
+
+func (s *rebootNotifySuite) TestConfigureRebootRequiredNotifyErrors(c *C) {
+	for _, tc := range []struct {
+		conf   map[string]interface{}
+		errMsg string
+	}{
+		{
+			map[string]interface{}{"system.reboot-required.notify": "wall,carrier-pigeon"},
+			`cannot set system.reboot-required.notify: unsupported method "carrier-pigeon"`,
+		}, {
+			map[string]interface{}{"system.reboot-required.notify": "webhook"},
+			`cannot use webhook reboot required notification without system.reboot-required.webhook-url`,
+		}, {
+			map[string]interface{}{"system.reboot-required.webhook-url": "ftp://example.com"},
+			`system.reboot-required.webhook-url must be an http or https URL`,
+		}, {
+			map[string]interface{}{"system.reboot-required.webhook-url": ":foo"},
+			`cannot parse system.reboot-required.webhook-url: .*`,
+		},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.errMsg)
+	}
+}
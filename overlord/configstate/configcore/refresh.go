@@ -37,6 +37,11 @@ func init() {
 	supportedConfigurations["core.refresh.metered"] = true
 	supportedConfigurations["core.refresh.retain"] = true
 	supportedConfigurations["core.refresh.rate-limit"] = true
+	supportedConfigurations["core.refresh.download-window"] = true
+	supportedConfigurations["core.refresh.splay"] = true
+	supportedConfigurations["core.refresh.withdrawn"] = true
+	supportedConfigurations["core.refresh.unhealthy"] = true
+	supportedConfigurations["core.refresh.rollback-window"] = true
 }
 
 func reportOrIgnoreInvalidManageRefreshes(tr RunTransaction, optName string) error {
@@ -84,6 +89,66 @@ func validateRefreshSchedule(tr RunTransaction) error {
 		return fmt.Errorf("refresh.metered value %q is invalid", refreshOnMeteredStr)
 	}
 
+	refreshWithdrawnStr, err := coreCfg(tr, "refresh.withdrawn")
+	if err != nil {
+		return err
+	}
+	switch refreshWithdrawnStr {
+	case "", "warn", "disable-services":
+		// noop
+	default:
+		return fmt.Errorf("refresh.withdrawn value %q is invalid", refreshWithdrawnStr)
+	}
+
+	refreshUnhealthyStr, err := coreCfg(tr, "refresh.unhealthy")
+	if err != nil {
+		return err
+	}
+	switch refreshUnhealthyStr {
+	case "", "hold":
+		// noop
+	default:
+		return fmt.Errorf("refresh.unhealthy value %q is invalid", refreshUnhealthyStr)
+	}
+
+	refreshRollbackWindowStr, err := coreCfg(tr, "refresh.rollback-window")
+	if err != nil {
+		return err
+	}
+	if refreshRollbackWindowStr != "" {
+		window, err := time.ParseDuration(refreshRollbackWindowStr)
+		if err != nil {
+			return fmt.Errorf("refresh.rollback-window cannot be parsed: %v", err)
+		}
+		if window < 0 {
+			return fmt.Errorf("refresh.rollback-window cannot be negative")
+		}
+	}
+
+	refreshDownloadWindowStr, err := coreCfg(tr, "refresh.download-window")
+	if err != nil {
+		return err
+	}
+	if refreshDownloadWindowStr != "" {
+		if _, err := timeutil.ParseSchedule(refreshDownloadWindowStr); err != nil {
+			return fmt.Errorf("refresh.download-window cannot be parsed: %v", err)
+		}
+	}
+
+	refreshSplayStr, err := coreCfg(tr, "refresh.splay")
+	if err != nil {
+		return err
+	}
+	if refreshSplayStr != "" {
+		splay, err := time.ParseDuration(refreshSplayStr)
+		if err != nil {
+			return fmt.Errorf("refresh.splay cannot be parsed: %v", err)
+		}
+		if splay < 0 {
+			return fmt.Errorf("refresh.splay cannot be negative")
+		}
+	}
+
 	// check (new) refresh.timer
 	refreshTimerStr, err := coreCfg(tr, "refresh.timer")
 	if err != nil {
@@ -163,6 +163,82 @@ func (s *refreshSuite) TestConfigureRefreshHoldOnMeteredInvalid(c *C) {
 	c.Assert(err, ErrorMatches, `refresh\.metered value "invalid" is invalid`)
 }
 
+func (s *refreshSuite) TestConfigureRefreshDownloadWindowHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.download-window": "mon-fri,1:00-5:00,,sat-sun,23:00-6:00",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *refreshSuite) TestConfigureRefreshDownloadWindowInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.download-window": "1:00-25:00",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.download-window cannot be parsed: cannot parse "1:00-25:00": not a valid time`)
+}
+
+func (s *refreshSuite) TestConfigureRefreshSplayHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.splay": "2h30m",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *refreshSuite) TestConfigureRefreshSplayInvalid(c *C) {
+	for _, tc := range []struct {
+		splay string
+		err   string
+	}{
+		{"foo", `refresh\.splay cannot be parsed: time: invalid duration "?foo"?`},
+		{"-1h", `refresh\.splay cannot be negative`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.splay": tc.splay,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err)
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshRollbackWindowHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.rollback-window": "15m",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *refreshSuite) TestConfigureRefreshRollbackWindowInvalid(c *C) {
+	for _, tc := range []struct {
+		window string
+		err    string
+	}{
+		{"foo", `refresh\.rollback-window cannot be parsed: time: invalid duration "?foo"?`},
+		{"-1h", `refresh\.rollback-window cannot be negative`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.rollback-window": tc.window,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err)
+	}
+}
+
 func (s *refreshSuite) TestConfigureRefreshHoldOnMeteredHappy(c *C) {
 	err := configcore.Run(classicDev, &mockConf{
 		state: s.state,
@@ -181,6 +257,46 @@ func (s *refreshSuite) TestConfigureRefreshHoldOnMeteredHappy(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *refreshSuite) TestConfigureRefreshWithdrawn(c *C) {
+	for _, v := range []string{"", "warn", "disable-services"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.withdrawn": v,
+			},
+		})
+		c.Check(err, IsNil)
+	}
+
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.withdrawn": "remove",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.withdrawn value "remove" is invalid`)
+}
+
+func (s *refreshSuite) TestConfigureRefreshUnhealthy(c *C) {
+	for _, v := range []string{"", "hold"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.unhealthy": v,
+			},
+		})
+		c.Check(err, IsNil)
+	}
+
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.unhealthy": "skip",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.unhealthy value "skip" is invalid`)
+}
+
 func (s *refreshSuite) TestConfigureRefreshRetainHappy(c *C) {
 	err := configcore.Run(classicDev, &mockConf{
 		state: s.state,
@@ -48,11 +48,17 @@ func init() {
 	// resilience.vitality-hint
 	addWithStateHandler(validateVitalitySettings, handleVitalityConfiguration, nil)
 
+	// service-overrides.<snap>.<app>
+	addWithStateHandler(validateServiceOverrides, handleServiceOverrides, nil)
+
 	// XXX: this should become a FSOnlyHandler. We need to
 	// add/implement Changes() to the ConfGetter interface
 	// store-certs.*
 	addWithStateHandler(validateCertSettings, handleCertConfiguration, nil)
 
+	// interfaces.forbidden
+	addWithStateHandler(validateForbiddenInterfaces, handleForbiddenInterfaces, nil)
+
 	// users.create.automatic
 	addWithStateHandler(validateUsersSettings, handleUserSettings, &flags{earlyConfigFilter: earlyUsersSettingsFilter})
 
@@ -60,6 +66,12 @@ func init() {
 	addWithStateHandler(validateRefreshSchedule, nil, validateOnly)
 	addWithStateHandler(validateRefreshRateLimit, nil, validateOnly)
 	addWithStateHandler(validateAutomaticSnapshotsExpiration, nil, validateOnly)
+	addWithStateHandler(validateRebootRequiredNotify, nil, validateOnly)
+	addWithStateHandler(validateWarningsNotifyDesktop, nil, validateOnly)
+	addWithStateHandler(validateStoreDownloadDir, nil, validateOnly)
+	addWithStateHandler(validateAPILimits, nil, validateOnly)
+	// tasks.timeout.<kind>
+	addWithStateHandler(validateTaskTimeouts, nil, validateOnly)
 
 	// netplan.*
 	addWithStateHandler(validateNetplanSettings, handleNetplanConfiguration, coreOnly)
@@ -148,14 +160,53 @@ func Run(dev sysconfig.Device, cfg RunTransaction) error {
 	return applyHandlers(dev, cfg, handlers)
 }
 
+// Validate checks the system options as they would be after committing
+// the given transaction, without applying them. Changes to the options of
+// other snaps in the transaction are ignored.
+func Validate(cfg RunTransaction) error {
+	var changes []string
+	for _, k := range cfg.Changes() {
+		if strings.HasPrefix(k, "core.") {
+			changes = append(changes, k)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return validateHandlers(cfg, changes, handlers)
+}
+
 func applyHandlers(dev sysconfig.Device, cfg RunTransaction, handlers []configHandler) error {
+	if err := validateHandlers(cfg, cfg.Changes(), handlers); err != nil {
+		return err
+	}
+
+	for _, h := range handlers {
+		if h.flags().coreOnlyConfig && dev.Classic() {
+			continue
+		}
+		if h.flags().modeenvOnlyConfig && !dev.HasModeenv() {
+			continue
+		}
+		if err := h.handle(dev, cfg, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateHandlers(cfg RunTransaction, changes []string, handlers []configHandler) error {
 	// check if the changes
-	for _, k := range cfg.Changes() {
+	for _, k := range changes {
 		switch {
 		case strings.HasPrefix(k, "core.store-certs."):
 			if !validCertOption(k) {
 				return fmt.Errorf("cannot set store ssl certificate under name %q: name must only contain word characters or a dash", k)
 			}
+		case isServiceOverridesChange(k):
+			// validated by validateServiceOverrides
+		case isTaskTimeoutChange(k):
+			// validated by validateTaskTimeouts
 		case isNetplanChange(k):
 			if release.OnClassic {
 				return fmt.Errorf("cannot set netplan configuration on classic")
@@ -170,18 +221,6 @@ func applyHandlers(dev sysconfig.Device, cfg RunTransaction, handlers []configHa
 			return err
 		}
 	}
-
-	for _, h := range handlers {
-		if h.flags().coreOnlyConfig && dev.Classic() {
-			continue
-		}
-		if h.flags().modeenvOnlyConfig && !dev.HasModeenv() {
-			continue
-		}
-		if err := h.handle(dev, cfg, nil); err != nil {
-			return err
-		}
-	}
 	return nil
 }
 
@@ -41,3 +41,21 @@ func (r *configcoreSuite) TestConfigureUnknownOption(c *C) {
 	err := configcore.Run(coreDev, conf)
 	c.Check(err, ErrorMatches, `cannot set "core.unknown.option": unsupported system option`)
 }
+
+func (r *configcoreSuite) TestValidateUnknownOption(c *C) {
+	conf := &mockConf{
+		state: r.state,
+		changes: map[string]interface{}{
+			"unknown.option": "1",
+		},
+	}
+
+	err := configcore.Validate(conf)
+	c.Check(err, ErrorMatches, `cannot set "core.unknown.option": unsupported system option`)
+}
+
+func (r *configcoreSuite) TestValidateNoSystemOptions(c *C) {
+	conf := &mockConf{state: r.state}
+
+	c.Check(configcore.Validate(conf), IsNil)
+}
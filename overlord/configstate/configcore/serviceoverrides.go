@@ -0,0 +1,170 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/wrappers"
+)
+
+const serviceOverridesOpt = servicestate.ServiceOverridesOption
+
+func init() {
+	// add supported configuration of this module, the options under
+	// it are checked with isServiceOverridesChange
+	supportedConfigurations["core."+serviceOverridesOpt] = true
+}
+
+// isServiceOverridesChange returns whether the changed key is one of
+// service-overrides.<snap>.<app>.<setting>, which are validated by
+// validateServiceOverrides.
+func isServiceOverridesChange(key string) bool {
+	return strings.HasPrefix(key, "core."+serviceOverridesOpt+".")
+}
+
+func getServiceOverrides(getter func(snapName, key string, result interface{}) error) (map[string]json.RawMessage, error) {
+	var all map[string]json.RawMessage
+	if err := getter("core", serviceOverridesOpt, &all); err != nil && !config.IsNoOption(err) {
+		return nil, fmt.Errorf("cannot set %q: %v", serviceOverridesOpt, err)
+	}
+	return all, nil
+}
+
+func validateServiceOverrides(tr RunTransaction) error {
+	all, err := getServiceOverrides(tr.Get)
+	if err != nil {
+		return err
+	}
+	for instanceName := range all {
+		if err := naming.ValidateInstance(instanceName); err != nil {
+			return fmt.Errorf("cannot set %q: %v", serviceOverridesOpt, err)
+		}
+		if instanceName == "snapd" {
+			return fmt.Errorf("cannot set %q: snapd snap services cannot be overridden", serviceOverridesOpt)
+		}
+		if _, err := servicestate.SnapServiceOverrides(tr, instanceName); err != nil {
+			return fmt.Errorf("cannot set %q: %v", serviceOverridesOpt, err)
+		}
+	}
+	return nil
+}
+
+// handleServiceOverrides regenerates the units of the services of the
+// snaps whose overrides changed. The new settings take effect when the
+// services are next restarted.
+func handleServiceOverrides(tr RunTransaction, opts *fsOnlyContext) error {
+	pristine, err := getServiceOverrides(tr.GetPristine)
+	if err != nil {
+		return err
+	}
+	all, err := getServiceOverrides(tr.Get)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for instanceName, overrides := range all {
+		if !bytes.Equal(overrides, pristine[instanceName]) {
+			changed = append(changed, instanceName)
+		}
+	}
+	for instanceName := range pristine {
+		if _, ok := all[instanceName]; !ok {
+			changed = append(changed, instanceName)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+
+	st := tr.State()
+	st.Lock()
+	defer st.Unlock()
+
+	grps, err := servicestate.AllQuotas(st)
+	if err != nil {
+		return err
+	}
+
+	for _, instanceName := range changed {
+		var snapst snapstate.SnapState
+		err := snapstate.Get(st, instanceName, &snapst)
+		// not installed or not active, the overrides will be applied
+		// when the snap services are written
+		if errors.Is(err, state.ErrNoState) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !snapst.Active {
+			continue
+		}
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			return err
+		}
+		if len(info.Services()) == 0 {
+			continue
+		}
+
+		deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+		if err != nil {
+			return err
+		}
+		ensureOpts := &wrappers.EnsureSnapServicesOptions{}
+		if !deviceCtx.Classic() && deviceCtx.Model().Base() != "" {
+			ensureOpts.RequireMountedSnapdSnap = true
+		}
+
+		snapSvcOpts, err := servicestate.SnapServiceOptions(st, info, grps)
+		if err != nil {
+			return err
+		}
+		// use the overrides from this transaction, not yet committed
+		snapSvcOpts.ServiceOverrides, err = servicestate.SnapServiceOverrides(tr, instanceName)
+		if err != nil {
+			return err
+		}
+
+		m := map[*snap.Info]*wrappers.SnapServiceOptions{
+			info: snapSvcOpts,
+		}
+		if err := wrappers.EnsureSnapServices(m, ensureOpts, nil, progress.Null); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type serviceOverridesSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&serviceOverridesSuite{})
+
+func (s *serviceOverridesSuite) SetUpTest(c *C) {
+	s.configcoreSuite.SetUpTest(c)
+
+	model := assertstest.FakeAssertion(map[string]interface{}{
+		"type":         "model",
+		"authority-id": "canonical",
+		"series":       "16",
+		"brand-id":     "canonical",
+		"model":        "pc",
+		"gadget":       "pc",
+		"kernel":       "kernel",
+		"architecture": "amd64",
+	}).(*asserts.Model)
+	s.AddCleanup(snapstatetest.MockDeviceModel(model))
+}
+
+func (s *serviceOverridesSuite) run(c *C, settings map[string]interface{}) error {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	s.state.Unlock()
+	for k, v := range settings {
+		c.Assert(tr.Set("core", k, v), IsNil)
+	}
+	err := configcore.Run(classicDev, configcore.NewRunTransaction(tr, nil))
+	if err == nil {
+		s.state.Lock()
+		tr.Commit()
+		s.state.Unlock()
+	}
+	return err
+}
+
+func (s *serviceOverridesSuite) TestConfigureServiceOverridesInvalid(c *C) {
+	for _, t := range []struct {
+		key   string
+		value interface{}
+		err   string
+	}{
+		{"service-overrides.test-snap.foo.nice", 20, `cannot set "service-overrides": invalid service-overrides.test-snap.foo: nice must be between -20 and 19, not 20`},
+		{"service-overrides.test-snap.foo.oom-score-adjust", -1000, `cannot set "service-overrides": invalid service-overrides.test-snap.foo: oom-score-adjust must be between -899 and 1000, not -1000`},
+		{"service-overrides.test-snap.foo.exec-start", "/bin/sh", `cannot set "service-overrides": invalid service-overrides.test-snap.foo: json: unknown field "exec-start"`},
+		{"service-overrides.test-snap.foo.environment", map[string]string{"A": "b\nc"}, `cannot set "service-overrides": invalid service-overrides.test-snap.foo: invalid value of environment variable "A": cannot contain newlines or NUL characters`},
+		{"service-overrides.test-snap.foo", "nice", `cannot set "service-overrides": invalid service-overrides.test-snap.foo: json: cannot unmarshal string .*`},
+		{"service-overrides.test-snap", "nice", `cannot set "service-overrides": invalid service-overrides.test-snap: json: cannot unmarshal string .*`},
+		{"service-overrides.snapd.foo.nice", 1, `cannot set "service-overrides": snapd snap services cannot be overridden`},
+	} {
+		err := s.run(c, map[string]interface{}{t.key: t.value})
+		c.Check(err, ErrorMatches, t.err, Commentf("%s", t.key))
+	}
+}
+
+func (s *serviceOverridesSuite) TestConfigureServiceOverrides(c *C) {
+	si := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1)}
+	snaptest.MockSnap(c, mockSnapWithService, si)
+	s.state.Lock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{si},
+		Current:  snap.R(1),
+		Active:   true,
+		SnapType: "app",
+	})
+	s.state.Unlock()
+
+	err := s.run(c, map[string]interface{}{
+		"service-overrides.test-snap.foo.nice":        5,
+		"service-overrides.test-snap.foo.environment": map[string]string{"FOO": "bar"},
+		// not installed, applied when the services are written
+		"service-overrides.other-snap.bar.nice": 1,
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.systemctlArgs, DeepEquals, [][]string{
+		{"daemon-reload"},
+	})
+	dropInFile := filepath.Join(dirs.SnapServicesDir, "snap.test-snap.foo.service.d/50-snap-overrides.conf")
+	c.Check(dropInFile, testutil.FileEquals, `[Service]
+# Overrides of the settings of the test-snap.foo service configured with
+# snap set system service-overrides.test-snap.foo
+Nice=5
+Environment="FOO=bar"
+`)
+
+	// unchanged overrides do not touch the units
+	s.systemctlArgs = nil
+	err = s.run(c, map[string]interface{}{
+		"service-overrides.other-snap.bar.nice": 2,
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.systemctlArgs, HasLen, 0)
+
+	// unsetting the overrides removes the drop-in
+	err = s.run(c, map[string]interface{}{
+		"service-overrides.test-snap": nil,
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.systemctlArgs, DeepEquals, [][]string{
+		{"daemon-reload"},
+	})
+	c.Check(dropInFile, testutil.FileAbsent)
+}
@@ -22,6 +22,7 @@ package configcore
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -32,6 +33,7 @@ import (
 
 func init() {
 	supportedConfigurations["core.store.access"] = true
+	supportedConfigurations["core.store.download-dir"] = true
 }
 
 func validateStoreAccess(cfg ConfGetter) error {
@@ -48,6 +50,24 @@ func validateStoreAccess(cfg ConfGetter) error {
 	}
 }
 
+func validateStoreDownloadDir(tr RunTransaction) error {
+	downloadDir, err := coreCfg(tr, "store.download-dir")
+	if err != nil {
+		return err
+	}
+	if downloadDir == "" {
+		return nil
+	}
+
+	if !filepath.IsAbs(downloadDir) {
+		return fmt.Errorf("store download directory must be an absolute path: %q", downloadDir)
+	}
+	if !osutil.IsDirectory(downloadDir) {
+		return fmt.Errorf("store download directory %q does not exist or is not a directory", downloadDir)
+	}
+	return nil
+}
+
 // repairConfig is a set of configuration data that is consumed by the
 // snap-repair command. This struct is duplicated in cmd/snap-repair.
 type repairConfig struct {
@@ -96,3 +96,38 @@ func (s *storeSuite) TestFilesystemOnlyApply(c *C) {
 
 	c.Check(repairConfig.StoreOffline, Equals, true)
 }
+
+func (s *storeSuite) TestStoreDownloadDirHappy(c *C) {
+	downloadDir := c.MkDir()
+	for _, v := range []string{"", downloadDir} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				"store.download-dir": v,
+			},
+		})
+		c.Check(err, IsNil)
+	}
+}
+
+func (s *storeSuite) TestStoreDownloadDirUnhappy(c *C) {
+	someFile := filepath.Join(c.MkDir(), "file")
+	c.Assert(os.WriteFile(someFile, nil, 0644), IsNil)
+
+	for _, tc := range []struct {
+		value string
+		err   string
+	}{
+		{"relative/dir", `store download directory must be an absolute path: "relative/dir"`},
+		{"/does/not/exist", `store download directory "/does/not/exist" does not exist or is not a directory`},
+		{someFile, `store download directory ".*/file" does not exist or is not a directory`},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				"store.download-dir": tc.value,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err)
+	}
+}
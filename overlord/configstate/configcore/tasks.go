@@ -0,0 +1,60 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+)
+
+func init() {
+	// add supported configuration of this module, the options under
+	// it are checked with isTaskTimeoutChange
+	supportedConfigurations["core.tasks.timeout"] = true
+}
+
+// isTaskTimeoutChange returns whether the changed key is one of
+// tasks.timeout.<kind>, which are validated by validateTaskTimeouts.
+func isTaskTimeoutChange(key string) bool {
+	return strings.HasPrefix(key, "core.tasks.timeout.")
+}
+
+func validateTaskTimeouts(tr RunTransaction) error {
+	var timeouts map[string]string
+	if err := tr.Get("core", "tasks.timeout", &timeouts); err != nil && !config.IsNoOption(err) {
+		return fmt.Errorf("cannot set %q: %v", "tasks.timeout", err)
+	}
+	for kind, timeoutStr := range timeouts {
+		if timeoutStr == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return fmt.Errorf("tasks.timeout.%s cannot be parsed: %v", kind, err)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("tasks.timeout.%s cannot be negative", kind)
+		}
+	}
+	return nil
+}
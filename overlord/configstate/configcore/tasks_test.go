@@ -0,0 +1,68 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type tasksSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&tasksSuite{})
+
+func (s *tasksSuite) run(c *C, settings map[string]interface{}) error {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	s.state.Unlock()
+	for k, v := range settings {
+		c.Assert(tr.Set("core", k, v), IsNil)
+	}
+	return configcore.Run(classicDev, configcore.NewRunTransaction(tr, nil))
+}
+
+func (s *tasksSuite) TestConfigureTaskTimeoutsHappy(c *C) {
+	err := s.run(c, map[string]interface{}{
+		"tasks.timeout.run-hook":      "30m",
+		"tasks.timeout.download-snap": "2h",
+		"tasks.timeout.link-snap":     "",
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *tasksSuite) TestConfigureTaskTimeoutsInvalid(c *C) {
+	for _, tc := range []struct {
+		value interface{}
+		err   string
+	}{
+		{"foo", `tasks\.timeout\.run-hook cannot be parsed: time: invalid duration "?foo"?`},
+		{"-1h", `tasks\.timeout\.run-hook cannot be negative`},
+		{10, `cannot set "tasks.timeout": .*`},
+	} {
+		err := s.run(c, map[string]interface{}{
+			"tasks.timeout.run-hook": tc.value,
+		})
+		c.Check(err, ErrorMatches, tc.err)
+	}
+}
@@ -0,0 +1,45 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package configcore
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.warnings.notify-desktop"] = true
+}
+
+func validateWarningsNotifyDesktop(tr RunTransaction) error {
+	severity, err := coreCfg(tr, "warnings.notify-desktop")
+	if err != nil {
+		return err
+	}
+	if severity == "" || severity == "none" {
+		return nil
+	}
+	if err := state.ValidateWarningSeverity(state.WarningSeverity(severity)); err != nil {
+		return fmt.Errorf("cannot set warnings.notify-desktop: %v", err)
+	}
+	return nil
+}
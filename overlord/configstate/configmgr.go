@@ -70,5 +70,10 @@ func Init(st *state.State, hookManager *hookstate.HookManager) error {
 		return configcoreRun(dev, tr)
 	})
 
+	st.Lock()
+	defer st.Unlock()
+	// record the results of applying config-profiles
+	st.AddChangeStatusChangedHandler(recordConfigProfileResults)
+
 	return nil
 }
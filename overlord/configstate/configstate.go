@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
@@ -118,12 +119,24 @@ func Configure(st *state.State, snapName string, patch map[string]interface{}, f
 		// all configure hooks must finish within this timeout
 		Timeout: ConfigureHookTimeout(),
 	}
+	if isEnvOnlyPatch(patch) {
+		// the env.* configuration is handled by snapd, so the hook is
+		// not required but the handler must run anyway
+		hooksup.Optional = true
+		hooksup.Always = true
+	}
 	var contextData map[string]interface{}
 	if flags&snapstate.UseConfigDefaults != 0 {
 		contextData = map[string]interface{}{"use-defaults": true}
 	} else if len(patch) > 0 {
 		contextData = map[string]interface{}{"patch": patch}
 	}
+	if flags&snapstate.RevertConfigOnFailure != 0 {
+		if contextData == nil {
+			contextData = make(map[string]interface{})
+		}
+		contextData["revert-on-failure"] = true
+	}
 
 	if hooksup.Optional {
 		summary = fmt.Sprintf(i18n.G("Run configure hook of %q snap if present"), snapName)
@@ -167,6 +180,7 @@ func RemapSnapToResponse(snapName string) string {
 
 func delayedCrossMgrInit() {
 	devicestate.EarlyConfig = EarlyConfig
+	configcore.ForbiddenConnections = ifacestate.ForbiddenConnections
 }
 
 var (
@@ -0,0 +1,191 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snapenv"
+)
+
+// envConfigKey is the reserved configuration namespace of every snap holding
+// the environment variables exported into its apps and services.
+const envConfigKey = "env"
+
+// isEnvOnlyPatch returns whether the given patch only touches the env.*
+// configuration of a snap, which is handled by snapd itself.
+func isEnvOnlyPatch(patch map[string]interface{}) bool {
+	if len(patch) == 0 {
+		return false
+	}
+	for k := range patch {
+		if k != envConfigKey && !strings.HasPrefix(k, envConfigKey+".") {
+			return false
+		}
+	}
+	return true
+}
+
+// envName returns the name of the environment variable set by the given
+// option under env, configuration options being lowercase the dashes are
+// mapped to underscores, e.g. env.log-level sets LOG_LEVEL.
+func envName(key string) string {
+	return strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+// envFromConfig validates the given env configuration value and converts it
+// to a set of environment variables.
+func envFromConfig(value interface{}) (map[string]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot use %q configuration: expected a document of environment variables", envConfigKey)
+	}
+	env := make(map[string]string, len(doc))
+	for key, v := range doc {
+		name := envName(key)
+		if err := snapenv.ValidateConfigEnvName(name); err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case string:
+			env[name] = v
+		case json.Number:
+			env[name] = v.String()
+		case bool:
+			env[name] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("cannot set environment variable %q: value must be a string, a number or a boolean", name)
+		}
+	}
+	return env, nil
+}
+
+// handleEnvConfig validates the env.* configuration set in the transaction
+// of the given configure hook context, exports it for "snap run" once the
+// transaction is committed and, if it changed, restarts the running services
+// of the snap unless their refresh-mode is endure.
+func handleEnvConfig(context *hookstate.Context) error {
+	instanceName := context.InstanceName()
+	tr := ContextTransaction(context)
+
+	var value, oldValue interface{}
+	if err := tr.GetMaybe(instanceName, envConfigKey, &value); err != nil {
+		return err
+	}
+	env, err := envFromConfig(value)
+	if err != nil {
+		return err
+	}
+	if err := tr.GetPristineMaybe(instanceName, envConfigKey, &oldValue); err != nil {
+		return err
+	}
+	// the old value was validated already
+	oldEnv, _ := envFromConfig(oldValue)
+
+	// the transaction commits in an earlier OnDone callback, as it
+	// was cached in the context before the hook ran
+	context.OnDone(func() error {
+		if err := snapenv.WriteConfigEnv(instanceName, env); err != nil {
+			return fmt.Errorf("cannot write environment of snap %q: %v", instanceName, err)
+		}
+		return nil
+	})
+
+	unchanged := reflect.DeepEqual(env, oldEnv) || (len(env) == 0 && len(oldEnv) == 0)
+	if unchanged {
+		return nil
+	}
+	return restartServicesForEnvChange(context)
+}
+
+// restoreEnvConfig exports again the env.* configuration of the given snap
+// after its configuration was restored.
+func restoreEnvConfig(st *state.State, instanceName string) error {
+	tr := config.NewTransaction(st)
+	var value interface{}
+	if err := tr.GetMaybe(instanceName, envConfigKey, &value); err != nil {
+		return err
+	}
+	// the restored value was validated already
+	env, _ := envFromConfig(value)
+	if err := snapenv.WriteConfigEnv(instanceName, env); err != nil {
+		return fmt.Errorf("cannot write environment of snap %q: %v", instanceName, err)
+	}
+	return nil
+}
+
+func restartServicesForEnvChange(context *hookstate.Context) error {
+	hookTask, ok := context.Task()
+	if !ok {
+		// nothing to queue the restart into
+		return nil
+	}
+	st := context.State()
+	info, err := snapstate.CurrentInfo(st, context.InstanceName())
+	if err != nil {
+		return err
+	}
+
+	var svcs []*snap.AppInfo
+	for _, app := range info.Services() {
+		if app.RefreshMode == "endure" {
+			continue
+		}
+		svcs = append(svcs, app)
+	}
+	if len(svcs) == 0 {
+		return nil
+	}
+
+	// only the running services are restarted
+	inst := &servicestate.Instruction{Action: "restart"}
+	tts, err := servicestate.Control(st, svcs, inst, nil, context)
+	if err != nil {
+		return err
+	}
+
+	chg := hookTask.Change()
+	lanes := hookTask.Lanes()
+	if len(lanes) == 1 && lanes[0] == 0 {
+		lanes = nil
+	}
+	for _, ts := range tts {
+		for _, l := range lanes {
+			ts.JoinLane(l)
+		}
+		ts.WaitFor(hookTask)
+		chg.AddAll(ts)
+	}
+	st.EnsureBefore(0)
+	return nil
+}
@@ -0,0 +1,255 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configstate_test
+
+import (
+	"encoding/json"
+	"os"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snapenv"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type envConfigSuite struct {
+	testutil.BaseTest
+
+	state    *state.State
+	hookTask *state.Task
+	context  *hookstate.Context
+	handler  hookstate.Handler
+}
+
+var _ = Suite(&envConfigSuite{})
+
+const envTestSnapYaml = `name: test-snap
+version: 1.0
+apps:
+  svc:
+    command: bin/svc
+    daemon: simple
+  endure-svc:
+    command: bin/svc
+    daemon: simple
+    refresh-mode: endure
+  app:
+    command: bin/app
+`
+
+func (s *envConfigSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("/") })
+	s.AddCleanup(snap.MockSanitizePlugsSlots(func(snapInfo *snap.Info) {}))
+
+	s.state = state.New(nil)
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	si := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1)}
+	snaptest.MockSnap(c, envTestSnapYaml, si)
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{si},
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+
+	s.newHandler(c)
+}
+
+func (s *envConfigSuite) newHandler(c *C) {
+	chg := s.state.NewChange("configure-snap", "...")
+	s.hookTask = s.state.NewTask("run-hook", "configure hook")
+	chg.AddTask(s.hookTask)
+	setup := &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: "configure"}
+
+	var err error
+	s.context, err = hookstate.NewContext(s.hookTask, s.state, setup, hooktest.NewMockHandler(), "")
+	c.Assert(err, IsNil)
+	s.handler = configstate.NewConfigureHandler(s.context)
+}
+
+func (s *envConfigSuite) runHandler(c *C, patch map[string]interface{}) error {
+	s.context.Lock()
+	s.context.Set("patch", patch)
+	s.context.Unlock()
+
+	c.Assert(s.handler.Before(), IsNil)
+	if err := s.handler.Done(); err != nil {
+		return err
+	}
+	s.context.Lock()
+	defer s.context.Unlock()
+	return s.context.Done()
+}
+
+func (s *envConfigSuite) TestEnvConfigExportedAndServicesRestarted(c *C) {
+	err := s.runHandler(c, map[string]interface{}{
+		"env.log-level": "debug",
+		"env.port":      json.Number("8080"),
+		"env.verbose":   true,
+	})
+	c.Assert(err, IsNil)
+
+	env, err := snapenv.ConfigEnv("test-snap")
+	c.Assert(err, IsNil)
+	c.Check(env, DeepEquals, map[string]string{
+		"LOG_LEVEL": "debug",
+		"PORT":      "8080",
+		"VERBOSE":   "true",
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// the configuration was committed
+	tr := config.NewTransaction(s.state)
+	var level string
+	c.Check(tr.Get("test-snap", "env.log-level", &level), IsNil)
+	c.Check(level, Equals, "debug")
+
+	// the service which does not endure refreshes is restarted after the
+	// hook
+	tasks := s.hookTask.Change().Tasks()
+	c.Assert(tasks, HasLen, 2)
+	restart := tasks[1]
+	c.Check(restart.Kind(), Equals, "service-control")
+	c.Check(restart.WaitTasks(), DeepEquals, []*state.Task{s.hookTask})
+	var action servicestate.ServiceAction
+	c.Assert(restart.Get("service-action", &action), IsNil)
+	c.Check(action.Action, Equals, "restart")
+	c.Check(action.Services, DeepEquals, []string{"svc"})
+}
+
+func (s *envConfigSuite) TestEnvConfigExportedAfterCommit(c *C) {
+	s.context.Lock()
+	s.context.Set("patch", map[string]interface{}{"env.token": "secret"})
+	s.context.Unlock()
+
+	c.Assert(s.handler.Before(), IsNil)
+	c.Assert(s.handler.Done(), IsNil)
+	c.Check(snapenv.ConfigEnvFile("test-snap"), testutil.FileAbsent)
+
+	s.context.Lock()
+	c.Assert(s.context.Done(), IsNil)
+	s.context.Unlock()
+
+	c.Check(snapenv.ConfigEnvFile("test-snap"), testutil.FileEquals, `{"TOKEN":"secret"}`)
+	// apps run by any user pick up the environment
+	fi, err := os.Stat(snapenv.ConfigEnvFile("test-snap"))
+	c.Assert(err, IsNil)
+	c.Check(fi.Mode().Perm(), Equals, os.FileMode(0644))
+}
+
+func (s *envConfigSuite) TestEnvConfigUnchangedNoRestart(c *C) {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "env.foo", "bar"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	err := s.runHandler(c, map[string]interface{}{
+		"env.foo": "bar",
+		"other":   "value",
+	})
+	c.Assert(err, IsNil)
+
+	c.Check(snapenv.ConfigEnvFile("test-snap"), testutil.FileEquals, `{"FOO":"bar"}`)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.hookTask.Change().Tasks(), HasLen, 1)
+}
+
+func (s *envConfigSuite) TestEnvConfigUnset(c *C) {
+	c.Assert(snapenv.WriteConfigEnv("test-snap", map[string]string{"FOO": "bar"}), IsNil)
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "env.foo", "bar"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	err := s.runHandler(c, map[string]interface{}{"env": nil})
+	c.Assert(err, IsNil)
+
+	c.Check(snapenv.ConfigEnvFile("test-snap"), testutil.FileAbsent)
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.hookTask.Change().Tasks(), HasLen, 2)
+}
+
+func (s *envConfigSuite) TestEnvConfigInvalid(c *C) {
+	for _, t := range []struct {
+		patch map[string]interface{}
+		err   string
+	}{
+		{map[string]interface{}{"env.snap-data": "/tmp"}, `cannot set environment variable "SNAP_DATA": reserved for snapd`},
+		{map[string]interface{}{"env.home": "/tmp"}, `cannot set environment variable "HOME": controlled by snapd`},
+		{map[string]interface{}{"env.foo": map[string]interface{}{"a": "b"}}, `cannot set environment variable "FOO": value must be a string, a number or a boolean`},
+		{map[string]interface{}{"env": "foo"}, `cannot use "env" configuration: expected a document of environment variables`},
+	} {
+		s.state.Lock()
+		s.newHandler(c)
+		s.state.Unlock()
+
+		err := s.runHandler(c, t.patch)
+		c.Check(err, ErrorMatches, t.err)
+		c.Check(snapenv.ConfigEnvFile("test-snap"), testutil.FileAbsent)
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	var env interface{}
+	tr := config.NewTransaction(s.state)
+	c.Check(config.IsNoOption(tr.Get("test-snap", "env", &env)), Equals, true)
+}
+
+func (s *envConfigSuite) TestConfigureEnvOnlyPatchDoesNotRequireHook(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, t := range []struct {
+		patch    map[string]interface{}
+		optional bool
+	}{
+		{map[string]interface{}{"env.foo": "bar"}, true},
+		{map[string]interface{}{"env": map[string]interface{}{"foo": "bar"}}, true},
+		{map[string]interface{}{"env.foo": "bar", "foo": "bar"}, false},
+		{map[string]interface{}{"environment": "bar"}, false},
+	} {
+		ts := configstate.Configure(s.state, "test-snap", t.patch, 0)
+		var hooksup hookstate.HookSetup
+		c.Assert(ts.Tasks()[0].Get("hook-setup", &hooksup), IsNil)
+		c.Check(hooksup.Optional, Equals, t.optional)
+		c.Check(hooksup.Always, Equals, t.optional)
+	}
+}
@@ -43,3 +43,11 @@ func MockConfigcoreEarly(mock func(dev sysconfig.Device, cfg configcore.RunTrans
 		configcoreEarly = old
 	}
 }
+
+func MockTransactionValidators(validators []TransactionValidator) (restore func()) {
+	old := transactionValidators
+	transactionValidators = validators
+	return func() {
+		transactionValidators = old
+	}
+}
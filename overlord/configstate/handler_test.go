@@ -24,6 +24,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -603,3 +604,241 @@ volumes:
 	c.Assert(err, IsNil)
 	c.Check(foo, Equals, "bar")
 }
+
+func (s *configureHandlerSuite) TestRevertOnFailureUndoRestoresConfig(c *C) {
+	s.context.Lock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "foo", "old"), IsNil)
+	tr.Commit()
+	s.context.Set("patch", map[string]interface{}{"foo": "new", "bar": "baz"})
+	s.context.Set("revert-on-failure", true)
+	s.context.Unlock()
+
+	c.Assert(s.handler.Before(), IsNil)
+	c.Assert(s.handler.Done(), IsNil)
+	s.context.Lock()
+	c.Assert(s.context.Done(), IsNil)
+	s.context.Unlock()
+
+	s.state.Lock()
+	tr = config.NewTransaction(s.state)
+	var value string
+	c.Check(tr.Get("test-snap", "foo", &value), IsNil)
+	c.Check(value, Equals, "new")
+	s.state.Unlock()
+
+	// the change failed later on
+	undoer, ok := s.handler.(hookstate.UndoHandler)
+	c.Assert(ok, Equals, true)
+	c.Assert(undoer.Undo(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr = config.NewTransaction(s.state)
+	c.Check(tr.Get("test-snap", "foo", &value), IsNil)
+	c.Check(value, Equals, "old")
+	c.Check(config.IsNoOption(tr.Get("test-snap", "bar", &value)), Equals, true)
+
+	task, _ := s.context.Task()
+	c.Check(strings.Join(task.Log(), "\n"), Matches, `(?s).*Restored the previous configuration of snap "test-snap"`)
+}
+
+func (s *configureHandlerSuite) TestRevertOnFailureUndoKeepsLaterChanges(c *C) {
+	s.context.Lock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "foo", "old"), IsNil)
+	c.Assert(tr.Set("test-snap", "bar", "old"), IsNil)
+	tr.Commit()
+	s.context.Set("patch", map[string]interface{}{"foo": "new", "bar": "new"})
+	s.context.Set("revert-on-failure", true)
+	s.context.Unlock()
+
+	c.Assert(s.handler.Before(), IsNil)
+	s.context.Lock()
+	c.Assert(s.context.Done(), IsNil)
+	s.context.Unlock()
+
+	// another change modifies the configuration in the meantime
+	s.state.Lock()
+	tr = config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "bar", "other"), IsNil)
+	c.Assert(tr.Set("test-snap", "baz", "other"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	c.Assert(s.handler.(hookstate.UndoHandler).Undo(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr = config.NewTransaction(s.state)
+	var value string
+	c.Check(tr.Get("test-snap", "foo", &value), IsNil)
+	c.Check(value, Equals, "old")
+	c.Check(tr.Get("test-snap", "bar", &value), IsNil)
+	c.Check(value, Equals, "other")
+	c.Check(tr.Get("test-snap", "baz", &value), IsNil)
+	c.Check(value, Equals, "other")
+
+	task, _ := s.context.Task()
+	c.Check(strings.Join(task.Log(), "\n"), Matches, `(?s).*Kept option "bar" of snap "test-snap", modified again since.*`)
+}
+
+func (s *configureHandlerSuite) TestRevertOnFailureUndoRemovesNewConfig(c *C) {
+	s.context.Lock()
+	s.context.Set("patch", map[string]interface{}{"foo": "new"})
+	s.context.Set("revert-on-failure", true)
+	s.context.Unlock()
+
+	c.Assert(s.handler.Before(), IsNil)
+	s.context.Lock()
+	c.Assert(s.context.Done(), IsNil)
+	s.context.Unlock()
+
+	c.Assert(s.handler.(hookstate.UndoHandler).Undo(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	snapcfg, err := config.GetSnapConfig(s.state, "test-snap")
+	c.Assert(err, IsNil)
+	c.Check(snapcfg, IsNil)
+}
+
+func (s *configureHandlerSuite) TestUndoWithoutRevertOnFailure(c *C) {
+	s.context.Lock()
+	s.context.Set("patch", map[string]interface{}{"foo": "new"})
+	s.context.Unlock()
+
+	c.Assert(s.handler.Before(), IsNil)
+	s.context.Lock()
+	c.Assert(s.context.Done(), IsNil)
+	s.context.Unlock()
+
+	c.Assert(s.handler.(hookstate.UndoHandler).Undo(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr := config.NewTransaction(s.state)
+	var value string
+	c.Check(tr.Get("test-snap", "foo", &value), IsNil)
+	c.Check(value, Equals, "new")
+}
+
+func (s *configureHandlerSuite) TestRevertOnFailureHookError(c *C) {
+	s.context.Lock()
+	s.context.Set("patch", map[string]interface{}{"foo": "new"})
+	s.context.Set("revert-on-failure", true)
+	s.context.Unlock()
+
+	c.Assert(s.handler.Before(), IsNil)
+	ignore, err := s.handler.Error(errors.New("boom"))
+	c.Assert(err, IsNil)
+	c.Check(ignore, Equals, false)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	// nothing was committed
+	snapcfg, err := config.GetSnapConfig(s.state, "test-snap")
+	c.Assert(err, IsNil)
+	c.Check(snapcfg, IsNil)
+
+	task, _ := s.context.Task()
+	c.Check(strings.Join(task.Log(), "\n"), Matches, `(?s).*Configure hook of snap "test-snap" failed: boom.*Kept the previous configuration of snap "test-snap"`)
+}
+
+func (s *configureHandlerSuite) TestStagedTransaction(c *C) {
+	s.context.Lock()
+	defer s.context.Unlock()
+
+	hookTr := configstate.ContextTransaction(s.context)
+	c.Assert(hookTr.Set("test-snap", "a", 1), IsNil)
+
+	_, err := configstate.StagedTransaction(s.context)
+	c.Check(err, Equals, configstate.ErrNoTransaction)
+	c.Check(configstate.HookTransaction(s.context), Equals, hookTr)
+
+	c.Assert(configstate.StartTransaction(s.context), IsNil)
+	c.Check(configstate.StartTransaction(s.context), ErrorMatches, "configuration transaction already started")
+
+	tr := configstate.HookTransaction(s.context)
+	c.Assert(tr, Not(Equals), hookTr)
+	staged, err := configstate.StagedTransaction(s.context)
+	c.Assert(err, IsNil)
+	c.Check(staged, Equals, tr)
+
+	var v int
+	c.Assert(tr.Get("test-snap", "a", &v), IsNil)
+	c.Check(v, Equals, 1)
+	c.Assert(tr.Set("test-snap", "b", 2), IsNil)
+	// not visible until committed
+	c.Check(hookTr.Changes(), DeepEquals, []string{"test-snap.a"})
+
+	c.Assert(configstate.CommitTransaction(s.context), IsNil)
+	c.Check(hookTr.Changes(), DeepEquals, []string{"test-snap.a", "test-snap.b"})
+	c.Check(configstate.HookTransaction(s.context), Equals, hookTr)
+	c.Check(configstate.CommitTransaction(s.context), Equals, configstate.ErrNoTransaction)
+
+	// aborting discards the changes
+	c.Assert(configstate.StartTransaction(s.context), IsNil)
+	c.Assert(configstate.HookTransaction(s.context).Set("test-snap", "c", 3), IsNil)
+	c.Assert(configstate.AbortTransaction(s.context), IsNil)
+	c.Check(hookTr.Changes(), DeepEquals, []string{"test-snap.a", "test-snap.b"})
+	c.Check(configstate.AbortTransaction(s.context), Equals, configstate.ErrNoTransaction)
+}
+
+func (s *configureHandlerSuite) TestStagedTransactionValidators(c *C) {
+	var validated []string
+	restore := configstate.MockTransactionValidators([]configstate.TransactionValidator{
+		func(tr *config.Transaction, instanceName string) error {
+			validated = append(validated, instanceName)
+			var min, max int
+			if err := tr.GetMaybe(instanceName, "min", &min); err != nil {
+				return err
+			}
+			if err := tr.GetMaybe(instanceName, "max", &max); err != nil {
+				return err
+			}
+			if min > max {
+				return errors.New("min cannot be larger than max")
+			}
+			return nil
+		},
+	})
+	defer restore()
+
+	s.context.Lock()
+	defer s.context.Unlock()
+
+	c.Assert(configstate.StartTransaction(s.context), IsNil)
+	tr := configstate.HookTransaction(s.context)
+	c.Assert(tr.Set("test-snap", "min", 10), IsNil)
+	c.Assert(tr.Set("test-snap", "max", 5), IsNil)
+
+	err := configstate.CommitTransaction(s.context)
+	c.Check(err, ErrorMatches, "cannot commit configuration transaction: min cannot be larger than max")
+	c.Check(validated, DeepEquals, []string{"test-snap"})
+	// nothing was applied and the transaction can still be fixed
+	c.Check(configstate.ContextTransaction(s.context).Changes(), HasLen, 0)
+	c.Check(configstate.HookTransaction(s.context), Equals, tr)
+
+	c.Assert(tr.Set("test-snap", "max", 20), IsNil)
+	c.Assert(configstate.CommitTransaction(s.context), IsNil)
+	c.Check(configstate.ContextTransaction(s.context).Changes(), DeepEquals, []string{"test-snap.max", "test-snap.min"})
+}
+
+func (s *configureHandlerSuite) TestStagedTransactionValidatesSystemOptions(c *C) {
+	s.context.Lock()
+	defer s.context.Unlock()
+
+	c.Assert(configstate.StartTransaction(s.context), IsNil)
+	tr := configstate.HookTransaction(s.context)
+	c.Assert(tr.Set("test-snap", "a", 1), IsNil)
+	c.Assert(tr.Set("core", "refresh.timer", "garbage"), IsNil)
+
+	err := configstate.CommitTransaction(s.context)
+	c.Check(err, ErrorMatches, `cannot commit configuration transaction: cannot parse "garbage": .*`)
+	c.Check(configstate.ContextTransaction(s.context).Changes(), HasLen, 0)
+
+	c.Assert(tr.Set("core", "refresh.timer", "4:00-6:00"), IsNil)
+	c.Assert(configstate.CommitTransaction(s.context), IsNil)
+	c.Check(configstate.ContextTransaction(s.context).Changes(), DeepEquals, []string{"core.refresh.timer", "test-snap.a"})
+}
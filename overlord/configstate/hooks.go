@@ -20,10 +20,15 @@
 package configstate
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -90,6 +95,30 @@ func (h *configureHandler) Before() error {
 		}
 	}
 
+	revert, err := h.revertOnFailure()
+	if err != nil {
+		return err
+	}
+	if revert {
+		// keep the configuration as it was before the change, so
+		// that it can be restored if the change fails later on
+		snapcfg, err := config.GetSnapConfig(h.context.State(), instanceName)
+		if err != nil {
+			return err
+		}
+		h.context.Set("config-snapshot", &configSnapshot{Config: snapcfg})
+		// and the one this change applied once the transaction is
+		// committed, so that only what it changed is restored
+		h.context.OnDone(func() error {
+			applied, err := config.GetSnapConfig(h.context.State(), instanceName)
+			if err != nil {
+				return err
+			}
+			h.context.Set("config-applied", &configSnapshot{Config: applied})
+			return nil
+		})
+	}
+
 	if err := config.Patch(tr, instanceName, patch); err != nil {
 		return err
 	}
@@ -97,18 +126,182 @@ func (h *configureHandler) Before() error {
 	return nil
 }
 
+// configSnapshot holds the configuration of a snap before it was changed.
+type configSnapshot struct {
+	Config *json.RawMessage `json:"config,omitempty"`
+}
+
+func (h *configureHandler) revertOnFailure() (bool, error) {
+	var revert bool
+	if err := h.context.Get("revert-on-failure", &revert); err != nil && !errors.Is(err, state.ErrNoState) {
+		return false, err
+	}
+	return revert, nil
+}
+
 // Done is called by the HookManager after the configure hook has exited
 // successfully.
 func (h *configureHandler) Done() error {
-	return nil
+	h.context.Lock()
+	defer h.context.Unlock()
+
+	// the environment of core is not configurable
+	if h.context.InstanceName() == "core" {
+		return nil
+	}
+	return handleEnvConfig(h.context)
 }
 
 // Error is called by the HookManager after the configure hook has exited
 // non-zero, and includes the error.
 func (h *configureHandler) Error(err error) (bool, error) {
+	h.context.Lock()
+	defer h.context.Unlock()
+
+	revert, rerr := h.revertOnFailure()
+	if rerr != nil {
+		return false, rerr
+	}
+	if revert {
+		// the transaction is only committed on success
+		h.context.Logf("Configure hook of snap %q failed: %v", h.context.InstanceName(), err)
+		h.context.Logf("Kept the previous configuration of snap %q", h.context.InstanceName())
+	}
 	return false, nil
 }
 
+// Undo is called by the HookManager when the change fails after the configure
+// hook succeeded, e.g. if restarting the services of the snap failed. The
+// previous value of the options the change modified is restored if that was
+// requested, unless they were modified again since.
+func (h *configureHandler) Undo() error {
+	h.context.Lock()
+	defer h.context.Unlock()
+
+	revert, err := h.revertOnFailure()
+	if err != nil || !revert {
+		return err
+	}
+	var snapshot, applied configSnapshot
+	if err := h.context.Get("config-snapshot", &snapshot); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			// the hook did not run, nothing was changed
+			return nil
+		}
+		return err
+	}
+	if err := h.context.Get("config-applied", &applied); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			// the transaction was not committed
+			return nil
+		}
+		return err
+	}
+
+	st := h.context.State()
+	instanceName := h.context.InstanceName()
+	current, err := config.GetSnapConfig(st, instanceName)
+	if err != nil {
+		return err
+	}
+	restored, kept, err := undoConfig(snapshot.Config, applied.Config, current)
+	if err != nil {
+		return fmt.Errorf("cannot restore the previous configuration of snap %q: %v", instanceName, err)
+	}
+	for _, opt := range kept {
+		h.context.Logf("Kept option %q of snap %q, modified again since", opt, instanceName)
+	}
+	if err := config.SetSnapConfig(st, instanceName, restored); err != nil {
+		return fmt.Errorf("cannot restore the previous configuration of snap %q: %v", instanceName, err)
+	}
+	if instanceName != "core" {
+		if err := restoreEnvConfig(st, instanceName); err != nil {
+			return err
+		}
+	}
+	h.context.Logf("Restored the previous configuration of snap %q", instanceName)
+	return nil
+}
+
+// undoConfig returns the current configuration with the top-level options
+// that were modified going from the snapshot to the applied configuration
+// restored to their value in the snapshot. Options whose current value is
+// not the applied one anymore are kept as they are and returned.
+func undoConfig(snapshot, applied, current *json.RawMessage) (restored *json.RawMessage, kept []string, err error) {
+	snapshotOpts, err := configOptions(snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	appliedOpts, err := configOptions(applied)
+	if err != nil {
+		return nil, nil, err
+	}
+	currentOpts, err := configOptions(current)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modified := make(map[string]bool)
+	for opt := range snapshotOpts {
+		modified[opt] = true
+	}
+	for opt := range appliedOpts {
+		modified[opt] = true
+	}
+	for opt := range modified {
+		if sameOption(snapshotOpts[opt], appliedOpts[opt]) {
+			continue
+		}
+		if !sameOption(currentOpts[opt], appliedOpts[opt]) {
+			kept = append(kept, opt)
+			continue
+		}
+		if value, ok := snapshotOpts[opt]; ok {
+			currentOpts[opt] = value
+		} else {
+			delete(currentOpts, opt)
+		}
+	}
+	sort.Strings(kept)
+
+	if len(currentOpts) == 0 {
+		return nil, kept, nil
+	}
+	data, err := json.Marshal(currentOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw := json.RawMessage(data)
+	return &raw, kept, nil
+}
+
+// configOptions returns the top-level options of the given configuration.
+func configOptions(cfg *json.RawMessage) (map[string]*json.RawMessage, error) {
+	var opts map[string]*json.RawMessage
+	if cfg != nil {
+		if err := json.Unmarshal(*cfg, &opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts == nil {
+		opts = make(map[string]*json.RawMessage)
+	}
+	return opts, nil
+}
+
+// sameOption returns whether the given option values, nil if unset, are
+// the same.
+func sameOption(a, b *json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	var va, vb interface{}
+	if json.Unmarshal(*a, &va) != nil || json.Unmarshal(*b, &vb) != nil {
+		return bytes.Equal(*a, *b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
 // defaultConfigureHandler is the handler for the default-configure hook.
 type defaultConfigureHandler struct {
 	context *hookstate.Context
@@ -202,3 +395,94 @@ func ContextTransaction(context *hookstate.Context) *config.Transaction {
 	context.Cache(cachedTransaction{}, tr)
 	return tr
 }
+
+// cachedStagedTransaction is the index into the context cache where the
+// transaction started by the hook with StartTransaction is stored.
+type cachedStagedTransaction struct{}
+
+// ErrNoTransaction is returned when there is no transaction started by
+// the hook to show, commit or abort.
+var ErrNoTransaction = errors.New("no configuration transaction started")
+
+// A TransactionValidator validates the configuration of a snap as it would
+// be after committing a transaction started by one of its hooks. Returning
+// an error rejects the changes of the transaction.
+type TransactionValidator func(tr *config.Transaction, instanceName string) error
+
+var transactionValidators = []TransactionValidator{validateSystemOptions}
+
+// validateSystemOptions validates the system options changed by a
+// transaction the same way as when they are set with snap set.
+func validateSystemOptions(tr *config.Transaction, instanceName string) error {
+	return configcore.Validate(configcore.NewRunTransaction(tr, nil))
+}
+
+// AddTransactionValidator adds a validator to be called whenever a
+// transaction started by a hook is committed.
+func AddTransactionValidator(v TransactionValidator) {
+	transactionValidators = append(transactionValidators, v)
+}
+
+// HookTransaction returns the transaction that configuration changes made
+// by the hook go to: the one started with StartTransaction if any,
+// otherwise the one cached within the context.
+func HookTransaction(context *hookstate.Context) *config.Transaction {
+	if tr, ok := context.Cached(cachedStagedTransaction{}).(*config.Transaction); ok {
+		return tr
+	}
+	return ContextTransaction(context)
+}
+
+// StagedTransaction returns the transaction started by the hook with
+// StartTransaction, or ErrNoTransaction if there is none.
+func StagedTransaction(context *hookstate.Context) (*config.Transaction, error) {
+	tr, ok := context.Cached(cachedStagedTransaction{}).(*config.Transaction)
+	if !ok {
+		return nil, ErrNoTransaction
+	}
+	return tr, nil
+}
+
+// StartTransaction starts a transaction on top of the one of the context,
+// so that the hook can make several configuration changes and have them
+// validated together before they are applied by CommitTransaction. Changes
+// of a transaction that is not committed are discarded when the hook
+// finishes.
+func StartTransaction(context *hookstate.Context) error {
+	if _, err := StagedTransaction(context); err == nil {
+		return errors.New("configuration transaction already started")
+	}
+	tr := ContextTransaction(context).Clone()
+	context.Cache(cachedStagedTransaction{}, tr)
+	return nil
+}
+
+// CommitTransaction validates the changes of the transaction started by
+// the hook and applies them to the transaction of the context, which is
+// committed to the state as usual once the hook finishes successfully. If
+// the validation fails the transaction is kept so that the hook can fix
+// its changes or abort it.
+func CommitTransaction(context *hookstate.Context) error {
+	tr, err := StagedTransaction(context)
+	if err != nil {
+		return err
+	}
+	for _, validate := range transactionValidators {
+		if err := validate(tr, context.InstanceName()); err != nil {
+			return fmt.Errorf("cannot commit configuration transaction: %v", err)
+		}
+	}
+	ContextTransaction(context).ReplaceChanges(tr)
+	context.Cache(cachedStagedTransaction{}, nil)
+	return nil
+}
+
+// AbortTransaction discards the changes of the transaction started by the
+// hook.
+func AbortTransaction(context *hookstate.Context) error {
+	if _, err := StagedTransaction(context); err != nil {
+		return err
+	}
+	context.Cache(cachedStagedTransaction{}, nil)
+	return nil
+}
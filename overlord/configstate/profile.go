@@ -0,0 +1,226 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+const applyConfigProfileKind = "apply-config-profile"
+
+// The results of applying the configuration of a snap from a config-profile.
+const (
+	ConfigProfileApplied    = "applied"
+	ConfigProfileFailed     = "failed"
+	ConfigProfileReverted   = "reverted"
+	ConfigProfileNotApplied = "not-applied"
+	ConfigProfileSkipped    = "skipped"
+)
+
+// ConfigProfileResult is the result of applying the configuration of a
+// snap from a config-profile. The keys of a snap are applied together by
+// its configure hook, so they all share the same result.
+type ConfigProfileResult struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message,omitempty"`
+	Keys    []string `json:"keys"`
+}
+
+// configProfileSetup is stored in the change applying a config-profile.
+type configProfileSetup struct {
+	BrandID  string `json:"brand-id"`
+	Name     string `json:"name"`
+	Revision int    `json:"revision"`
+	// Keys holds the configuration keys applied for each snap
+	Keys map[string][]string `json:"keys"`
+	// Skipped holds the reason the configuration of some snaps
+	// was not applied at all
+	Skipped map[string]string `json:"skipped,omitempty"`
+}
+
+func checkConfigProfileApplies(st *state.State, profile *asserts.ConfigProfile) error {
+	deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+	if err != nil {
+		return err
+	}
+	model := deviceCtx.Model()
+	if profile.BrandID() != model.BrandID() {
+		return fmt.Errorf("cannot apply config-profile %q of brand %q to a device of brand %q", profile.Name(), profile.BrandID(), model.BrandID())
+	}
+	if len(profile.Models()) != 0 && !strutil.ListContains(profile.Models(), model.Model()) {
+		return fmt.Errorf("cannot apply config-profile %q to model %q", profile.Name(), model.Model())
+	}
+	return nil
+}
+
+// ApplyConfigProfile returns a change applying the configuration values
+// carried by the given config-profile assertion. The system configuration
+// is applied first, then the configuration of each snap is applied
+// independently of the others and restored if its configure hook fails.
+// Snaps that are not installed are skipped. Once the change is ready the
+// result for each snap is available in its "api-data".
+func ApplyConfigProfile(st *state.State, profile *asserts.ConfigProfile) (*state.Change, error) {
+	if err := checkConfigProfileApplies(st, profile); err != nil {
+		return nil, err
+	}
+
+	setup := configProfileSetup{
+		BrandID:  profile.BrandID(),
+		Name:     profile.Name(),
+		Revision: profile.Revision(),
+		Keys:     make(map[string][]string),
+	}
+
+	config := profile.Config()
+	snapNames := make([]string, 0, len(config))
+	for snapName := range config {
+		snapNames = append(snapNames, RemapSnapFromRequest(snapName))
+	}
+	// the system configuration goes first
+	sort.Slice(snapNames, func(i, j int) bool {
+		if snapNames[i] == "core" || snapNames[j] == "core" {
+			return snapNames[i] == "core"
+		}
+		return snapNames[i] < snapNames[j]
+	})
+
+	chg := st.NewChange(applyConfigProfileKind, fmt.Sprintf(i18n.G("Apply config-profile %q"), profile.Name()))
+	var systemTs *state.TaskSet
+	for _, snapName := range snapNames {
+		values := config[RemapSnapToResponse(snapName)]
+		keys := make([]string, 0, len(values))
+		patch := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			keys = append(keys, key)
+			patch[key] = value
+		}
+		sort.Strings(keys)
+		setup.Keys[snapName] = keys
+
+		flags := 0
+		if snapName != "core" {
+			flags |= snapstate.RevertConfigOnFailure
+		}
+		ts, err := ConfigureInstalled(st, snapName, patch, flags)
+		if err != nil {
+			var notInstalled *snap.NotInstalledError
+			if !errors.As(err, &notInstalled) {
+				return nil, err
+			}
+			if setup.Skipped == nil {
+				setup.Skipped = make(map[string]string)
+			}
+			setup.Skipped[snapName] = "snap is not installed"
+			continue
+		}
+		// a failure to configure a snap does not affect the others
+		ts.JoinLane(st.NewLane())
+		if snapName == "core" {
+			systemTs = ts
+		} else if systemTs != nil {
+			ts.WaitAll(systemTs)
+		}
+		chg.AddAll(ts)
+	}
+	chg.Set("config-profile", &setup)
+
+	if len(chg.Tasks()) == 0 {
+		// nothing to do, still record the results in a ready change
+		chg.SetStatus(state.DoneStatus)
+	}
+	return chg, nil
+}
+
+// taskErrorMessage returns the last error logged by the task, if any.
+func taskErrorMessage(t *state.Task) string {
+	log := t.Log()
+	for i := len(log) - 1; i >= 0; i-- {
+		if idx := strings.Index(log[i], " "+state.LogError+" "); idx >= 0 {
+			return log[i][idx+len(state.LogError)+2:]
+		}
+	}
+	return ""
+}
+
+func configProfileResults(chg *state.Change) (map[string]*ConfigProfileResult, error) {
+	var setup configProfileSetup
+	if err := chg.Get("config-profile", &setup); err != nil {
+		return nil, err
+	}
+
+	snapResults := make(map[string]ConfigProfileResult, len(setup.Keys))
+	for snapName, reason := range setup.Skipped {
+		snapResults[snapName] = ConfigProfileResult{Status: ConfigProfileSkipped, Message: reason}
+	}
+	for _, t := range chg.Tasks() {
+		var hooksup hookstate.HookSetup
+		if err := t.Get("hook-setup", &hooksup); err != nil {
+			return nil, err
+		}
+		var res ConfigProfileResult
+		switch t.Status() {
+		case state.DoneStatus:
+			res.Status = ConfigProfileApplied
+		case state.ErrorStatus:
+			res.Status = ConfigProfileFailed
+			res.Message = taskErrorMessage(t)
+		case state.UndoneStatus:
+			res.Status = ConfigProfileReverted
+		default:
+			res.Status = ConfigProfileNotApplied
+		}
+		snapResults[hooksup.Snap] = res
+	}
+
+	results := make(map[string]*ConfigProfileResult, len(setup.Keys))
+	for snapName, keys := range setup.Keys {
+		res := snapResults[snapName]
+		res.Keys = keys
+		results[RemapSnapToResponse(snapName)] = &res
+	}
+	return results, nil
+}
+
+// recordConfigProfileResults stores the results of applying a
+// config-profile in the change once it is ready.
+func recordConfigProfileResults(chg *state.Change, old, new state.Status) {
+	if chg.Kind() != applyConfigProfileKind || !new.Ready() {
+		return
+	}
+	results, err := configProfileResults(chg)
+	if err != nil {
+		chg.State().Warnf("cannot record the results of applying a config-profile: %v", err)
+		return
+	}
+	chg.Set("api-data", map[string]interface{}{
+		"config-profile": results,
+	})
+}
@@ -0,0 +1,215 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configstate_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/sysconfig"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type configProfileSuite struct {
+	testutil.BaseTest
+
+	o     *overlord.Overlord
+	state *state.State
+}
+
+var _ = Suite(&configProfileSuite{})
+
+func (s *configProfileSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("/") })
+	s.AddCleanup(snap.MockSanitizePlugsSlots(func(snapInfo *snap.Info) {}))
+
+	s.o = overlord.Mock()
+	s.state = s.o.State()
+	hookMgr, err := hookstate.Manager(s.state, s.o.TaskRunner())
+	c.Assert(err, IsNil)
+	s.o.AddManager(hookMgr)
+	s.AddCleanup(configstate.MockConfigcoreExportExperimentalFlags(func(_ configcore.ConfGetter) error {
+		return nil
+	}))
+	c.Assert(configstate.Init(s.state, hookMgr), IsNil)
+	s.o.AddManager(s.o.TaskRunner())
+
+	s.AddCleanup(snapstatetest.MockDeviceModel(makeModel(nil)))
+	s.AddCleanup(configstate.MockConfigcoreRun(func(sysconfig.Device, configcore.RunTransaction) error {
+		return nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	for _, name := range []string{"foo", "bar"} {
+		si := &snap.SideInfo{RealName: name, Revision: snap.R(1)}
+		snaptest.MockSnap(c, fmt.Sprintf("name: %s\nversion: 1\nhooks:\n  configure:\n", name), si)
+		snapstate.Set(s.state, name, &snapstate.SnapState{
+			Active:   true,
+			Sequence: []*snap.SideInfo{si},
+			Current:  snap.R(1),
+			SnapType: "app",
+		})
+	}
+}
+
+func (s *configProfileSuite) mockProfile(c *C, headers map[string]interface{}, body string) *asserts.ConfigProfile {
+	profile := map[string]interface{}{
+		"type":         "config-profile",
+		"authority-id": "brand",
+		"brand-id":     "brand",
+		"name":         "fleet",
+		"revision":     "3",
+		"body-length":  fmt.Sprint(len(body)),
+	}
+	return assertstest.FakeAssertionWithBody([]byte(body), profile, headers).(*asserts.ConfigProfile)
+}
+
+func (s *configProfileSuite) settle(c *C) {
+	s.state.Unlock()
+	defer s.state.Lock()
+	c.Assert(s.o.Settle(5*time.Second), IsNil)
+}
+
+func (s *configProfileSuite) TestApplyConfigProfile(c *C) {
+	s.AddCleanup(hookstate.MockRunHook(func(ctx *hookstate.Context, _ *tomb.Tomb) ([]byte, error) {
+		if ctx.InstanceName() == "bar" {
+			return nil, errors.New("bar is unhappy")
+		}
+		return nil, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	profile := s.mockProfile(c, nil, `{"system": {"refresh.timer": "4:00-6:00"}, "foo": {"a": 1, "b.c": "d"}, "bar": {"x": true}, "missing": {"y": "z"}}`)
+	chg, err := configstate.ApplyConfigProfile(s.state, profile)
+	c.Assert(err, IsNil)
+	c.Check(chg.Kind(), Equals, "apply-config-profile")
+	c.Check(chg.Summary(), Equals, `Apply config-profile "fleet"`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 3)
+	c.Check(tasks[0].Summary(), Equals, `Run configure hook of "core" snap`)
+	c.Check(tasks[1].WaitTasks(), DeepEquals, []*state.Task{tasks[0]})
+	c.Check(tasks[2].WaitTasks(), DeepEquals, []*state.Task{tasks[0]})
+
+	s.settle(c)
+
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*bar is unhappy.*`)
+
+	var data map[string]map[string]*configstate.ConfigProfileResult
+	c.Assert(chg.Get("api-data", &data), IsNil)
+	c.Check(data["config-profile"], DeepEquals, map[string]*configstate.ConfigProfileResult{
+		"system":  {Status: "applied", Keys: []string{"refresh.timer"}},
+		"foo":     {Status: "applied", Keys: []string{"a", "b.c"}},
+		"bar":     {Status: "failed", Message: `run hook "configure": bar is unhappy`, Keys: []string{"x"}},
+		"missing": {Status: "skipped", Message: "snap is not installed", Keys: []string{"y"}},
+	})
+
+	// the configuration of the other snaps was kept, the one of the
+	// failed snap was not
+	tr := config.NewTransaction(s.state)
+	var value interface{}
+	c.Check(tr.Get("foo", "b.c", &value), IsNil)
+	c.Check(value, Equals, "d")
+	c.Check(config.IsNoOption(tr.Get("bar", "x", &value)), Equals, true)
+}
+
+func (s *configProfileSuite) TestApplyConfigProfileSystemFailure(c *C) {
+	s.AddCleanup(configstate.MockConfigcoreRun(func(sysconfig.Device, configcore.RunTransaction) error {
+		return errors.New("invalid system option")
+	}))
+	s.AddCleanup(hookstate.MockRunHook(func(ctx *hookstate.Context, _ *tomb.Tomb) ([]byte, error) {
+		return nil, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	profile := s.mockProfile(c, nil, `{"system": {"foo": "bar"}, "foo": {"a": 1}}`)
+	chg, err := configstate.ApplyConfigProfile(s.state, profile)
+	c.Assert(err, IsNil)
+
+	s.settle(c)
+
+	// the snaps are not configured if the system configuration fails
+	var data map[string]map[string]*configstate.ConfigProfileResult
+	c.Assert(chg.Get("api-data", &data), IsNil)
+	c.Check(data["config-profile"], DeepEquals, map[string]*configstate.ConfigProfileResult{
+		"system": {Status: "failed", Message: `run hook "configure": invalid system option`, Keys: []string{"foo"}},
+		"foo":    {Status: "not-applied", Keys: []string{"a"}},
+	})
+}
+
+func (s *configProfileSuite) TestApplyConfigProfileNothingInstalled(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	profile := s.mockProfile(c, nil, `{"missing": {"y": "z"}}`)
+	chg, err := configstate.ApplyConfigProfile(s.state, profile)
+	c.Assert(err, IsNil)
+	c.Check(chg.Tasks(), HasLen, 0)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+
+	var data map[string]map[string]*configstate.ConfigProfileResult
+	c.Assert(chg.Get("api-data", &data), IsNil)
+	c.Check(data["config-profile"], DeepEquals, map[string]*configstate.ConfigProfileResult{
+		"missing": {Status: "skipped", Message: "snap is not installed", Keys: []string{"y"}},
+	})
+}
+
+func (s *configProfileSuite) TestApplyConfigProfileWrongDevice(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	profile := s.mockProfile(c, map[string]interface{}{"authority-id": "other", "brand-id": "other"}, `{"foo": {"a": 1}}`)
+	_, err := configstate.ApplyConfigProfile(s.state, profile)
+	c.Check(err, ErrorMatches, `cannot apply config-profile "fleet" of brand "other" to a device of brand "brand"`)
+
+	profile = s.mockProfile(c, map[string]interface{}{"models": []interface{}{"other-model"}}, `{"foo": {"a": 1}}`)
+	_, err = configstate.ApplyConfigProfile(s.state, profile)
+	c.Check(err, ErrorMatches, `cannot apply config-profile "fleet" to model "baz-3000"`)
+
+	profile = s.mockProfile(c, map[string]interface{}{"models": []interface{}{"other-model", "baz-3000"}}, `{"foo": {"a": 1}}`)
+	_, err = configstate.ApplyConfigProfile(s.state, profile)
+	c.Check(err, IsNil)
+
+	c.Check(s.state.Changes(), HasLen, 1)
+}
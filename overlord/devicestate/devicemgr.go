@@ -52,6 +52,7 @@ import (
 	"github.com/snapcore/snapd/overlord/storecontext"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/secboot"
 	"github.com/snapcore/snapd/secboot/keys"
 	"github.com/snapcore/snapd/seed"
@@ -118,11 +119,11 @@ type DeviceManager struct {
 	cloudInitErrorAttemptStart           *time.Time
 	cloudInitEnabledInactiveAttemptStart *time.Time
 
-	lastBecomeOperationalAttempt time.Time
-	becomeOperationalBackoff     time.Duration
-	registered                   bool
-	reg                          chan struct{}
-	noRegister                   bool
+	becomeOperationalBackoff retryutil.Backoff
+
+	registered bool
+	reg        chan struct{}
+	noRegister bool
 
 	preseed            bool
 	preseedSystemLabel string
@@ -140,6 +141,8 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 		newStore: newStore,
 		reg:      make(chan struct{}),
 		preseed:  snapdenv.Preseeding(),
+
+		becomeOperationalBackoff: retryutil.Backoff{Strategy: becomeOperationalStrategy},
 	}
 	m.populateStateFromSeed = m.populateStateFromSeedImpl
 
@@ -508,21 +511,21 @@ func ensureOperationalAttempts(st *state.State) int {
 // further become-operational tentatives while its backoff interval is
 // not expired.
 func (m *DeviceManager) ensureOperationalShouldBackoff(now time.Time) bool {
-	if !m.lastBecomeOperationalAttempt.IsZero() && m.lastBecomeOperationalAttempt.Add(m.becomeOperationalBackoff).After(now) {
-		return true
+	return m.becomeOperationalBackoff.ShouldWait(now)
+}
+
+// becomeOperationalStrategy starts with 5 minutes between become-operational
+// tentatives and doubles that, going straight to a day once over 12 hours.
+var becomeOperationalStrategy = retryutil.StrategyFunc(func(n int, prev time.Duration) time.Duration {
+	if prev == 0 {
+		return 5 * time.Minute
 	}
-	if m.becomeOperationalBackoff == 0 {
-		m.becomeOperationalBackoff = 5 * time.Minute
-	} else {
-		newBackoff := m.becomeOperationalBackoff * 2
-		if newBackoff > (12 * time.Hour) {
-			newBackoff = 24 * time.Hour
-		}
-		m.becomeOperationalBackoff = newBackoff
+	next := prev * 2
+	if next > 12*time.Hour {
+		next = 24 * time.Hour
 	}
-	m.lastBecomeOperationalAttempt = now
-	return false
-}
+	return next
+})
 
 func setClassicFallbackModel(st *state.State, device *auth.DeviceState) error {
 	err := assertstate.Add(st, sysdb.GenericClassicModel())
@@ -989,6 +992,66 @@ func (m *DeviceManager) ensureSeeded() error {
 	return nil
 }
 
+var seedLoadExtraSnaps = seed.LoadExtraSnaps
+
+// ensureExtraSeedSnaps makes sure that the snaps placed in the extra
+// snaps drop-in directory of the seed get installed, once the snaps
+// from the seed itself are.
+func (m *DeviceManager) ensureExtraSeedSnaps() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	var seeded bool
+	err := m.state.Get("seeded", &seeded)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	var processed []*extraSeedSnap
+	err = m.state.Get("seed-extra-snaps", &processed)
+	if err == nil {
+		// already processed
+		return nil
+	}
+	if !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+
+	if !osutil.IsDirectory(filepath.Join(dirs.SnapSeedDir, seed.ExtraSnapsDir)) {
+		return nil
+	}
+
+	deviceCtx, err := DeviceCtx(m.state, nil, nil)
+	if err != nil {
+		return err
+	}
+	model := deviceCtx.Model()
+
+	commitTo := func(batch *asserts.Batch) error {
+		return assertstate.AddBatch(m.state, batch, nil)
+	}
+	extraSnaps, err := seedLoadExtraSnaps(dirs.SnapSeedDir, model, assertstate.DB(m.state), commitTo)
+	if err != nil {
+		return err
+	}
+
+	outcomes, tss := extraSeedSnapsTasks(m.state, model, extraSnaps)
+	m.state.Set("seed-extra-snaps", outcomes)
+	if len(tss) == 0 {
+		return nil
+	}
+
+	chg := m.state.NewChange("install-extra-seed-snaps", i18n.G("Install extra snaps from the seed"))
+	for _, ts := range tss {
+		chg.AddAll(ts)
+	}
+	m.state.EnsureBefore(0)
+	return nil
+}
+
 var processAutoImportAssertionsImpl = processAutoImportAssertions
 
 // ensureAutoImportAssertions makes sure that auto import assertions
@@ -1700,7 +1763,7 @@ func (m *DeviceManager) Ensure() error {
 
 	if err := m.ensureSeeded(); err != nil {
 		m.state.Lock()
-		m.state.Warnf(seedFailureFmt, err)
+		m.state.WarnfWithSeverity(state.WarningSeverityHigh, seedFailureFmt, err)
 		m.state.Unlock()
 		errs = append(errs, fmt.Errorf("cannot seed: %v", err))
 	}
@@ -1731,6 +1794,10 @@ func (m *DeviceManager) Ensure() error {
 			errs = append(errs, err)
 		}
 
+		if err := m.ensureExtraSeedSnaps(); err != nil {
+			errs = append(errs, err)
+		}
+
 		if err := m.ensureInstalled(); err != nil {
 			errs = append(errs, err)
 		}
@@ -1924,8 +1991,7 @@ func (m *DeviceManager) Unregister(opts *UnregisterOptions) error {
 		return nil
 	})
 
-	m.lastBecomeOperationalAttempt = time.Time{}
-	m.becomeOperationalBackoff = 0
+	m.becomeOperationalBackoff.Reset()
 	return err
 }
 
@@ -2012,6 +2078,10 @@ type System struct {
 	Brand *asserts.Account
 	// Actions available for this system
 	Actions []SystemAction
+	// Broken is set to the reason the seed of the system cannot be
+	// loaded, in which case only the label is known and no action is
+	// available
+	Broken string
 }
 
 var defaultSystemActions = []SystemAction{
@@ -2052,10 +2122,11 @@ func (m *DeviceManager) Systems() ([]*System, error) {
 		label := filepath.Base(fpLabel)
 		system, err := systemFromSeed(label, currentSys)
 		if err != nil {
-			// TODO:UC20 add a Broken field to the seed system like
-			// we do for snap.Info
 			logger.Noticef("cannot load system %q seed: %v", label, err)
-			continue
+			system = &System{
+				Label:  label,
+				Broken: err.Error(),
+			}
 		}
 		systems = append(systems, system)
 	}
@@ -2317,6 +2388,26 @@ func (scb storeContextBackend) ProxyStore() (*asserts.Store, error) {
 	return proxyStore(st, config.NewTransaction(st))
 }
 
+func (scb storeContextBackend) BackupProxyStore() (*asserts.Store, error) {
+	st := scb.DeviceManager.state
+	return backupProxyStore(st, config.NewTransaction(st))
+}
+
+func (scb storeContextBackend) StoreFailoverTimeout() (time.Duration, error) {
+	tr := config.NewTransaction(scb.state)
+
+	var timeout string
+	if err := tr.GetMaybe("core", "proxy.store-failover-timeout", &timeout); err != nil {
+		return 0, err
+	}
+
+	if timeout == "" {
+		return 0, state.ErrNoState
+	}
+
+	return time.ParseDuration(timeout)
+}
+
 func (scb storeContextBackend) StoreOffline() (bool, error) {
 	tr := config.NewTransaction(scb.state)
 
@@ -116,6 +116,15 @@ func findSerial(st *state.State, device *auth.DeviceState) (*asserts.Serial, err
 }
 
 // auto-refresh
+// deviceSerial returns the serial of the device, if it has one yet.
+func deviceSerial(st *state.State) (string, error) {
+	device, err := internal.Device(st)
+	if err != nil {
+		return "", err
+	}
+	return device.Serial, nil
+}
+
 func canAutoRefresh(st *state.State) (bool, error) {
 	// we need to be seeded first
 	var seeded bool
@@ -249,6 +258,7 @@ func delayedCrossMgrInit() {
 	})
 	snapstate.CanAutoRefresh = canAutoRefresh
 	snapstate.CanManageRefreshes = CanManageRefreshes
+	snapstate.DeviceSerial = deviceSerial
 	snapstate.IsOnMeteredConnection = netutil.IsOnMeteredConnection
 	snapstate.DeviceCtx = DeviceCtx
 	snapstate.RemodelingChange = RemodelingChange
@@ -256,8 +266,18 @@ func delayedCrossMgrInit() {
 
 // proxyStore returns the store assertion for the proxy store if one is set.
 func proxyStore(st *state.State, tr *config.Transaction) (*asserts.Store, error) {
+	return storeFromConfig(st, tr, "proxy.store")
+}
+
+// backupProxyStore returns the store assertion for the backup proxy
+// store if one is set.
+func backupProxyStore(st *state.State, tr *config.Transaction) (*asserts.Store, error) {
+	return storeFromConfig(st, tr, "proxy.store-backup")
+}
+
+func storeFromConfig(st *state.State, tr *config.Transaction, key string) (*asserts.Store, error) {
 	var proxyStore string
-	err := tr.GetMaybe("core", "proxy.store", &proxyStore)
+	err := tr.GetMaybe("core", key, &proxyStore)
 	if err != nil {
 		return nil, err
 	}
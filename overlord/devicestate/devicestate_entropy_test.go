@@ -0,0 +1,183 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type deviceMgrEntropySuite struct {
+	deviceMgrBaseSuite
+
+	urandom string
+}
+
+var _ = Suite(&deviceMgrEntropySuite{})
+
+func (s *deviceMgrEntropySuite) SetUpTest(c *C) {
+	classic := false
+	s.deviceMgrBaseSuite.setupBaseTest(c, classic)
+
+	s.urandom = filepath.Join(c.MkDir(), "urandom")
+	c.Assert(os.WriteFile(s.urandom, nil, 0644), IsNil)
+	s.AddCleanup(devicestate.MockURandomPath(s.urandom))
+	s.AddCleanup(devicestate.MockEntropyWait(50*time.Millisecond, time.Millisecond))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.makeModelAssertionInState(c, "canonical", "pc", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+	})
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand: "canonical",
+		Model: "pc",
+	})
+}
+
+func (s *deviceMgrEntropySuite) mockGadgetRandomSeed(c *C, seed string) {
+	devicestatetest.MockGadget(c, s.state, "pc", snap.R(2), nil)
+	info, err := snap.ReadInfo("pc", &snap.SideInfo{RealName: "pc", Revision: snap.R(2)})
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.MountDir(), "meta/random-seed"), []byte(seed), 0644), IsNil)
+}
+
+func taskLog(t *state.Task) string {
+	return strings.Join(t.Log(), "\n")
+}
+
+func (s *deviceMgrEntropySuite) TestWaitRandomReadyAlreadyReady(c *C) {
+	s.AddCleanup(devicestate.MockRandomReady(func() (bool, error) {
+		return true, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockGadgetRandomSeed(c, "seed")
+	t := s.state.NewTask("generate-device-key", "...")
+
+	err := devicestate.WaitRandomReady(t, &tomb.Tomb{})
+	c.Assert(err, IsNil)
+	c.Check(t.Log(), HasLen, 0)
+	// the pool is not touched if not needed
+	c.Check(s.urandom, testutil.FileEquals, "")
+}
+
+func (s *deviceMgrEntropySuite) TestWaitRandomReadySeedsFromGadget(c *C) {
+	calls := 0
+	s.AddCleanup(devicestate.MockRandomReady(func() (bool, error) {
+		calls++
+		return calls > 3, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockGadgetRandomSeed(c, "gadget random seed")
+	t := s.state.NewTask("generate-device-key", "...")
+
+	err := devicestate.WaitRandomReady(t, &tomb.Tomb{})
+	c.Assert(err, IsNil)
+	c.Check(calls, Equals, 4)
+	c.Check(s.urandom, testutil.FileEquals, "gadget random seed")
+	log := taskLog(t)
+	c.Check(log, Matches, `(?s).*Seeded random number generator from gadget.*`)
+	c.Check(log, Matches, `(?s).*Waiting for the random number generator to be initialized.*`)
+	c.Check(log, Matches, `(?s).*Random number generator initialized`)
+	label, done, total := t.Progress()
+	c.Check(label, Equals, "Waiting for the random number generator to be initialized")
+	c.Check(done, Equals, total)
+}
+
+func (s *deviceMgrEntropySuite) TestWaitRandomReadyNoGadgetSeed(c *C) {
+	calls := 0
+	s.AddCleanup(devicestate.MockRandomReady(func() (bool, error) {
+		calls++
+		return calls > 1, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	devicestatetest.MockGadget(c, s.state, "pc", snap.R(2), nil)
+	t := s.state.NewTask("generate-device-key", "...")
+
+	err := devicestate.WaitRandomReady(t, &tomb.Tomb{})
+	c.Assert(err, IsNil)
+	c.Check(s.urandom, testutil.FileEquals, "")
+	c.Check(taskLog(t), Not(Matches), `(?s).*Seeded random number generator.*`)
+}
+
+func (s *deviceMgrEntropySuite) TestWaitRandomReadyTimeout(c *C) {
+	s.AddCleanup(devicestate.MockRandomReady(func() (bool, error) {
+		return false, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	devicestatetest.MockGadget(c, s.state, "pc", snap.R(2), nil)
+	t := s.state.NewTask("generate-device-key", "...")
+
+	err := devicestate.WaitRandomReady(t, &tomb.Tomb{})
+	c.Assert(err, FitsTypeOf, &state.Retry{})
+	c.Check(err.(*state.Retry).Reason, Equals, "random number generator not initialized")
+	c.Check(taskLog(t), Matches, `(?s).*Random number generator not initialized after 50ms, will retry`)
+}
+
+func (s *deviceMgrEntropySuite) TestWaitRandomReadyStopped(c *C) {
+	s.AddCleanup(devicestate.MockRandomReady(func() (bool, error) {
+		return false, nil
+	}))
+	s.AddCleanup(devicestate.MockEntropyWait(time.Minute, time.Minute))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	devicestatetest.MockGadget(c, s.state, "pc", snap.R(2), nil)
+	t := s.state.NewTask("generate-device-key", "...")
+
+	tm := &tomb.Tomb{}
+	tm.Kill(nil)
+	err := devicestate.WaitRandomReady(t, tm)
+	c.Check(err, DeepEquals, &state.Retry{})
+}
+
+func (s *deviceMgrEntropySuite) TestWaitRandomReadyError(c *C) {
+	s.AddCleanup(devicestate.MockRandomReady(func() (bool, error) {
+		return false, os.ErrPermission
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	t := s.state.NewTask("generate-device-key", "...")
+
+	err := devicestate.WaitRandomReady(t, &tomb.Tomb{})
+	c.Check(err, Equals, os.ErrPermission)
+}
@@ -1676,6 +1676,43 @@ func (s *deviceMgrSerialSuite) TestStoreContextBackendStoreAccess(c *C) {
 	c.Check(offline, Equals, true)
 }
 
+func (s *deviceMgrSerialSuite) TestStoreContextBackendBackupProxyStore(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	scb := s.mgr.StoreContextBackend()
+
+	// nothing in the state
+	_, err := scb.BackupProxyStore()
+	c.Check(err, testutil.ErrorIs, state.ErrNoState)
+	_, err = scb.StoreFailoverTimeout()
+	c.Check(err, testutil.ErrorIs, state.ErrNoState)
+
+	operatorAcct := assertstest.NewAccount(s.storeSigning, "bar-operator", nil, "")
+	stoAs, err := s.storeSigning.Sign(asserts.StoreType, map[string]interface{}{
+		"store":       "bar",
+		"operator-id": operatorAcct.AccountID(),
+		"url":         "http://bar.internal",
+		"timestamp":   time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	assertstatetest.AddMany(s.state, operatorAcct, stoAs)
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "proxy.store-backup", "bar")
+	tr.Set("core", "proxy.store-failover-timeout", "2m")
+	tr.Commit()
+
+	sto, err := scb.BackupProxyStore()
+	c.Assert(err, IsNil)
+	c.Check(sto.Store(), Equals, "bar")
+	c.Check(sto.URL().String(), Equals, "http://bar.internal")
+
+	timeout, err := scb.StoreFailoverTimeout()
+	c.Assert(err, IsNil)
+	c.Check(timeout, Equals, 2*time.Minute)
+}
+
 func (s *deviceMgrSerialSuite) TestInitialRegistrationContext(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -457,8 +457,12 @@ func (s *deviceMgrSystemsSuite) TestBrokenSeedSystems(c *C) {
 
 	systems, err := s.mgr.Systems()
 	c.Assert(err, IsNil)
-	c.Assert(systems, HasLen, 2)
-	c.Check(systems, DeepEquals, []*devicestate.System{{
+	c.Assert(systems, HasLen, 3)
+	c.Check(systems[0].Label, Equals, s.mockedSystemSeeds[0].label)
+	c.Check(systems[0].Broken, Matches, `cannot load assertions for label .*: cannot read model assertion: .*`)
+	c.Check(systems[0].Model, IsNil)
+	c.Check(systems[0].Actions, HasLen, 0)
+	c.Check(systems[1:], DeepEquals, []*devicestate.System{{
 		Current: false,
 		Label:   s.mockedSystemSeeds[1].label,
 		Model:   s.mockedSystemSeeds[1].model,
@@ -508,6 +508,78 @@ func (s *deviceMgrSuite) TestDeviceManagerEnsureSeededHappy(c *C) {
 	c.Check(seedStartTime.Equal(devicestate.StartTime()), Equals, true)
 }
 
+func (s *deviceMgrSuite) TestDeviceManagerEnsureExtraSeedSnaps(c *C) {
+	s.setPCModelInState(c)
+	extraDir := filepath.Join(dirs.SnapSeedDir, seed.ExtraSnapsDir)
+	c.Assert(os.MkdirAll(extraDir, 0755), IsNil)
+
+	fooPath := snaptest.MakeTestSnapWithFiles(c, "name: foo\nversion: 1.0", nil)
+	barSi := &snap.SideInfo{RealName: "bar", SnapID: "bar-id", Revision: snap.R(1)}
+
+	calls := 0
+	restore := devicestate.MockSeedLoadExtraSnaps(func(seedDir string, model *asserts.Model, db asserts.RODatabase, commitTo func(*asserts.Batch) error) ([]*seed.ExtraSnap, error) {
+		calls++
+		c.Check(seedDir, Equals, dirs.SnapSeedDir)
+		c.Check(model.Model(), Equals, "pc")
+		return []*seed.ExtraSnap{
+			{Path: filepath.Join(extraDir, "bar.snap"), SideInfo: barSi},
+			{Path: filepath.Join(extraDir, "baz.snap"), Err: errors.New("cannot find assertions file baz.assert")},
+			{Path: fooPath, SideInfo: &snap.SideInfo{RealName: "foo", SnapID: "foo-id", Revision: snap.R(3)}},
+		}, nil
+	})
+	defer restore()
+
+	s.state.Lock()
+	snapstate.Set(s.state, "bar", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{barSi},
+		Current:  barSi.Revision,
+	})
+	s.state.Unlock()
+
+	// nothing happens before seeding
+	c.Assert(devicestate.EnsureExtraSeedSnaps(s.mgr), IsNil)
+	c.Check(calls, Equals, 0)
+
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	s.state.Unlock()
+
+	c.Assert(devicestate.EnsureExtraSeedSnaps(s.mgr), IsNil)
+	c.Check(calls, Equals, 1)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var outcomes []map[string]interface{}
+	c.Assert(s.state.Get("seed-extra-snaps", &outcomes), IsNil)
+	c.Check(outcomes, DeepEquals, []map[string]interface{}{
+		{"file": "bar.snap", "name": "bar", "revision": "1", "error": `snap "bar" is already installed`},
+		{"file": "baz.snap", "error": "cannot find assertions file baz.assert"},
+		{"file": filepath.Base(fooPath), "name": "foo", "revision": "3"},
+	})
+	c.Check(s.state.AllWarnings(), HasLen, 2)
+
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	chg := chgs[0]
+	c.Check(chg.Kind(), Equals, "install-extra-seed-snaps")
+	var snapsup snapstate.SnapSetup
+	c.Assert(chg.Tasks()[0].Get("snap-setup", &snapsup), IsNil)
+	c.Check(snapsup.InstanceName(), Equals, "foo")
+	c.Check(snapsup.SnapPath, Equals, fooPath)
+	for _, t := range chg.Tasks() {
+		c.Check(t.Lanes(), HasLen, 1)
+	}
+
+	// the extra snaps are processed only once
+	s.state.Unlock()
+	c.Assert(devicestate.EnsureExtraSeedSnaps(s.mgr), IsNil)
+	s.state.Lock()
+	c.Check(calls, Equals, 1)
+	c.Check(s.state.Changes(), HasLen, 1)
+}
+
 func (s *deviceMgrSuite) TestDeviceManagerEnsureBootOkSkippedOnClassic(c *C) {
 	s.bootloader.GetErr = fmt.Errorf("should not be called")
 	release.OnClassic = true
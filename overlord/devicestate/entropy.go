@@ -0,0 +1,172 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// gadgetRandomSeedFile is the optional file in the gadget snap with random
+// data that is mixed into the kernel random pool when it is not yet
+// initialized. As the file is the same on all the devices using the gadget
+// it is not credited as entropy, it is only useful as an extra input to
+// the pool on boards without other sources of randomness at first boot.
+const gadgetRandomSeedFile = "meta/random-seed"
+
+// maxRandomSeedSize is the maximum amount of data used from the gadget
+// random seed file, matching the size of the kernel pool.
+const maxRandomSeedSize = 512
+
+var (
+	urandomPath = "/dev/urandom"
+
+	entropyWaitTimeout  = 2 * time.Minute
+	entropyPollInterval = time.Second
+)
+
+// randomReady returns whether the kernel random number generator is
+// initialized, i.e. whether reading from it would not block.
+var randomReady = func() (bool, error) {
+	var buf [1]byte
+	_, err := unix.Getrandom(buf[:], unix.GRND_NONBLOCK)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, unix.EAGAIN):
+		return false, nil
+	case errors.Is(err, unix.ENOSYS):
+		// getrandom is not available, there is no way to tell
+		return true, nil
+	}
+	return false, fmt.Errorf("cannot check random number generator: %v", err)
+}
+
+// seedRandomFromGadget mixes the random seed file of the gadget, if there is
+// one, into the kernel random pool. It returns whether the pool was seeded.
+func seedRandomFromGadget(st *state.State, t *state.Task) (bool, error) {
+	deviceCtx, err := DeviceCtx(st, t, nil)
+	if err != nil {
+		return false, err
+	}
+	gadgetInfo, err := snapstate.GadgetInfo(st, deviceCtx)
+	if errors.Is(err, state.ErrNoState) {
+		// no gadget on classic
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	f, err := os.Open(filepath.Join(gadgetInfo.MountDir(), gadgetRandomSeedFile))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	seed, err := ioutil.ReadAll(io.LimitReader(f, maxRandomSeedSize))
+	if err != nil {
+		return false, err
+	}
+	if len(seed) == 0 {
+		return false, nil
+	}
+
+	// data written to urandom is mixed into the pool without being
+	// credited, which is what we want for data shared by many devices
+	urandom, err := os.OpenFile(urandomPath, os.O_WRONLY, 0)
+	if err != nil {
+		return false, err
+	}
+	defer urandom.Close()
+	if _, err := urandom.Write(seed); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// waitRandomReady waits for the kernel random number generator to be
+// initialized before keys are generated, to avoid generating weak keys or
+// blocking without feedback early at first boot on boards without a
+// hardware source of randomness. The random seed of the gadget is mixed
+// into the pool first if available. Progress is reported through the task.
+// If the generator is not initialized within entropyWaitTimeout the task is
+// retried later. It must be called with the state locked.
+func waitRandomReady(t *state.Task, tm *tomb.Tomb) error {
+	st := t.State()
+
+	ready, err := randomReady()
+	if err != nil {
+		return err
+	}
+	if ready {
+		return nil
+	}
+
+	seeded, err := seedRandomFromGadget(st, t)
+	if err != nil {
+		// not fatal, the kernel might still gather enough entropy
+		logger.Noticef("cannot seed random number generator from gadget: %v", err)
+	}
+	if seeded {
+		t.Logf("Seeded random number generator from gadget")
+	}
+	t.Logf("Waiting for the random number generator to be initialized")
+
+	const label = "Waiting for the random number generator to be initialized"
+	total := int(entropyWaitTimeout / entropyPollInterval)
+	for done := 0; done < total; done++ {
+		t.SetProgress(label, done, total)
+
+		st.Unlock()
+		select {
+		case <-time.After(entropyPollInterval):
+		case <-tm.Dying():
+			st.Lock()
+			return &state.Retry{}
+		}
+		ready, err = randomReady()
+		st.Lock()
+		if err != nil {
+			return err
+		}
+		if ready {
+			t.SetProgress(label, total, total)
+			t.Logf("Random number generator initialized")
+			return nil
+		}
+	}
+
+	t.Logf("Random number generator not initialized after %v, will retry", entropyWaitTimeout)
+	return &state.Retry{After: retryInterval, Reason: "random number generator not initialized"}
+}
@@ -119,11 +119,14 @@ func EnsureOperationalShouldBackoff(m *DeviceManager, now time.Time) bool {
 }
 
 func BecomeOperationalBackoff(m *DeviceManager) time.Duration {
-	return m.becomeOperationalBackoff
+	return m.becomeOperationalBackoff.Delay()
 }
 
+// SetLastBecomeOperationalAttempt forgets about the previous attempts but
+// one at t.
 func SetLastBecomeOperationalAttempt(m *DeviceManager, t time.Time) {
-	m.lastBecomeOperationalAttempt = t
+	m.becomeOperationalBackoff.Reset()
+	m.becomeOperationalBackoff.ShouldWait(t)
 }
 
 func SetSystemMode(m *DeviceManager, mode string) {
@@ -188,6 +191,16 @@ func EnsureSeeded(m *DeviceManager) error {
 	return m.ensureSeeded()
 }
 
+func EnsureExtraSeedSnaps(m *DeviceManager) error {
+	return m.ensureExtraSeedSnaps()
+}
+
+func MockSeedLoadExtraSnaps(f func(seedDir string, model *asserts.Model, db asserts.RODatabase, commitTo func(*asserts.Batch) error) ([]*seed.ExtraSnap, error)) (restore func()) {
+	r := testutil.Backup(&seedLoadExtraSnaps)
+	seedLoadExtraSnaps = f
+	return r
+}
+
 func EnsureCloudInitRestricted(m *DeviceManager) error {
 	return m.ensureCloudInitRestricted()
 }
@@ -580,3 +593,32 @@ func CleanUpEncryptionSetupDataInCache(st *state.State, label string) {
 	key := encryptionSetupDataKey{label}
 	st.Cache(key, nil)
 }
+
+var WaitRandomReady = waitRandomReady
+
+func MockRandomReady(f func() (bool, error)) (restore func()) {
+	old := randomReady
+	randomReady = f
+	return func() {
+		randomReady = old
+	}
+}
+
+func MockEntropyWait(timeout, interval time.Duration) (restore func()) {
+	oldTimeout := entropyWaitTimeout
+	oldInterval := entropyPollInterval
+	entropyWaitTimeout = timeout
+	entropyPollInterval = interval
+	return func() {
+		entropyWaitTimeout = oldTimeout
+		entropyPollInterval = oldInterval
+	}
+}
+
+func MockURandomPath(path string) (restore func()) {
+	old := urandomPath
+	urandomPath = path
+	return func() {
+		urandomPath = old
+	}
+}
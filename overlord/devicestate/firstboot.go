@@ -22,12 +22,14 @@ package devicestate
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"sort"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/devicestate/internal"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -375,6 +377,64 @@ func (m *DeviceManager) populateStateFromSeedImpl(tm timings.Measurer) ([]*state
 	return tsAll, nil
 }
 
+// extraSeedSnap records the outcome of processing a snap from the
+// extra snaps drop-in directory of the seed.
+type extraSeedSnap struct {
+	File     string `json:"file"`
+	Name     string `json:"name,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	// Error is set if the snap was rejected.
+	Error string `json:"error,omitempty"`
+}
+
+// extraSeedSnapsTasks returns a taskset, each in its own lane so that a
+// failure does not affect the others, for installing each of the valid
+// extra snaps from the seed. It also returns the outcome for all of
+// them, rejected snaps are reported with warnings.
+func extraSeedSnapsTasks(st *state.State, model *asserts.Model, extraSnaps []*seed.ExtraSnap) ([]*extraSeedSnap, []*state.TaskSet) {
+	outcomes := make([]*extraSeedSnap, 0, len(extraSnaps))
+	var tss []*state.TaskSet
+	for _, es := range extraSnaps {
+		outcome := &extraSeedSnap{File: filepath.Base(es.Path)}
+		outcomes = append(outcomes, outcome)
+
+		err := es.Err
+		if err == nil {
+			outcome.Name = es.SideInfo.RealName
+			outcome.Revision = es.SideInfo.Revision.String()
+			var ts *state.TaskSet
+			ts, err = installExtraSeedSnap(st, model, es)
+			if err == nil {
+				ts.JoinLane(st.NewLane())
+				tss = append(tss, ts)
+				continue
+			}
+		}
+		outcome.Error = err.Error()
+		logger.Noticef("cannot install extra snap %s from the seed: %v", outcome.File, err)
+		st.Warnf("cannot install extra snap %s from the seed: %v", outcome.File, err)
+	}
+	return outcomes, tss
+}
+
+func installExtraSeedSnap(st *state.State, model *asserts.Model, es *seed.ExtraSnap) (*state.TaskSet, error) {
+	var snapst snapstate.SnapState
+	err := snapstate.Get(st, es.SideInfo.RealName, &snapst)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if snapst.IsInstalled() {
+		return nil, fmt.Errorf("snap %q is already installed", es.SideInfo.RealName)
+	}
+
+	flags := snapstate.Flags{
+		// same as for the snaps listed in the seed
+		ApplySnapDevMode: model.Grade() == asserts.ModelDangerous,
+	}
+	ts, _, err := snapstate.InstallPath(st, es.SideInfo, es.Path, "", "", flags)
+	return ts, err
+}
+
 func (m *DeviceManager) importAssertionsFromSeed(isCoreBoot bool) (seed.Seed, error) {
 	st := m.state
 
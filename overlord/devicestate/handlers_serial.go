@@ -78,7 +78,7 @@ var (
 	registrationCapabilities = []string{"serial-stream"}
 )
 
-func (m *DeviceManager) doGenerateDeviceKey(t *state.Task, _ *tomb.Tomb) error {
+func (m *DeviceManager) doGenerateDeviceKey(t *state.Task, tm *tomb.Tomb) error {
 	st := t.State()
 	st.Lock()
 	defer st.Unlock()
@@ -96,6 +96,11 @@ func (m *DeviceManager) doGenerateDeviceKey(t *state.Task, _ *tomb.Tomb) error {
 		return nil
 	}
 
+	// do not generate weak keys or block silently early at first boot
+	if err := waitRandomReady(t, tm); err != nil {
+		return err
+	}
+
 	st.Unlock()
 	var keyPair *rsa.PrivateKey
 	timings.Run(perfTimings, "generate-rsa-key", "generating device key pair", func(tm timings.Measurer) {
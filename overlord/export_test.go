@@ -116,3 +116,11 @@ func MockSystemdSdNotify(f func(notifyState string) error) (restore func()) {
 		systemdSdNotify = old
 	}
 }
+
+var (
+	ObserveChangeReady = observeChangeReady
+	ChangeDuration     = changeDuration
+	RefreshOutcomes    = refreshOutcomes
+	TaskRetries        = taskRetries
+	TaskTimeout        = taskTimeout
+)
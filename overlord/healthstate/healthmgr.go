@@ -0,0 +1,242 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package healthstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/systemd"
+)
+
+var (
+	// checkInterval is the interval between periodic runs of the
+	// check-health hooks
+	checkInterval = 24 * time.Hour
+
+	// serviceCheckInterval is the interval between checks of the
+	// services of snaps refreshed within refresh.rollback-window
+	serviceCheckInterval = time.Minute
+
+	timeNow = time.Now
+)
+
+// HealthManager periodically runs the check-health hook of the snaps
+// that have one, and reverts snaps whose services failed within
+// refresh.rollback-window of being refreshed.
+type HealthManager struct {
+	state *state.State
+
+	nextCheck        time.Time
+	nextServiceCheck time.Time
+}
+
+// Manager returns a new HealthManager.
+func Manager(st *state.State) *HealthManager {
+	return &HealthManager{state: st}
+}
+
+// Ensure is part of the overlord.StateManager interface.
+func (m *HealthManager) Ensure() error {
+	if err := m.ensureServicesHealthy(); err != nil {
+		return err
+	}
+
+	now := timeNow()
+	if now.Before(m.nextCheck) {
+		return nil
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	var seeded bool
+	err := m.state.Get("seeded", &seeded)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	var lastCheck time.Time
+	err = m.state.Get("last-health-check", &lastCheck)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if next := lastCheck.Add(checkInterval); now.Before(next) {
+		m.nextCheck = next
+		return nil
+	}
+
+	for _, chg := range m.state.Changes() {
+		if chg.Kind() == "check-health" && !chg.IsReady() {
+			// try again on the next ensure
+			return nil
+		}
+	}
+
+	tasks, err := periodicCheckTasks(m.state)
+	if err != nil {
+		return err
+	}
+	if len(tasks) > 0 {
+		chg := m.state.NewChange("check-health", i18n.G("Run periodic health checks"))
+		chg.AddAll(state.NewTaskSet(tasks...))
+		m.state.EnsureBefore(0)
+	}
+
+	m.state.Set("last-health-check", now)
+	m.nextCheck = now.Add(checkInterval)
+	return nil
+}
+
+// periodicCheckTasks returns check-health hook tasks for the active snaps
+// that have the hook and are not being operated on.
+func periodicCheckTasks(st *state.State) ([]*state.Task, error) {
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(snapStates))
+	for name := range snapStates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tasks []*state.Task
+	for _, name := range names {
+		snapst := snapStates[name]
+		if !snapst.Active {
+			continue
+		}
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			return nil, fmt.Errorf("cannot get info of snap %q: %v", name, err)
+		}
+		if info.Hooks["check-health"] == nil {
+			continue
+		}
+		if err := snapstate.CheckChangeConflict(st, name, nil); err != nil {
+			logger.Noticef("cannot check health of snap %q: %v", name, err)
+			continue
+		}
+		tasks = append(tasks, Hook(st, name, snapst.Current))
+	}
+	return tasks, nil
+}
+
+// ensureServicesHealthy reverts the snaps refreshed within
+// refresh.rollback-window whose services have failed since.
+func (m *HealthManager) ensureServicesHealthy() error {
+	now := timeNow()
+	if now.Before(m.nextServiceCheck) {
+		return nil
+	}
+	m.nextServiceCheck = now.Add(serviceCheckInterval)
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	window, err := rollbackWindow(m.state)
+	if err != nil || window <= 0 {
+		return err
+	}
+	snapStates, err := snapstate.All(m.state)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(snapStates))
+	for name := range snapStates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		snapst := snapStates[name]
+		if !snapst.Active || !inRollbackWindow(snapst, window) {
+			continue
+		}
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			return fmt.Errorf("cannot get info of snap %q: %v", name, err)
+		}
+		// the refresh itself undoes on services failing to start
+		if snapstate.CheckChangeConflict(m.state, name, nil) != nil {
+			continue
+		}
+
+		m.state.Unlock()
+		failed, err := failedServices(info)
+		m.state.Lock()
+		if err != nil {
+			logger.Noticef("cannot check services of snap %q: %v", name, err)
+			continue
+		}
+		if len(failed) == 0 || snapstate.CheckChangeConflict(m.state, name, nil) != nil {
+			continue
+		}
+
+		if revertUnhealthy(m.state, name, "") {
+			m.state.Warnf("services %s of snap %q failed after being refreshed, reverting", strutil.Quoted(failed), name)
+		}
+	}
+	return nil
+}
+
+// failedServices returns the names of the system services of the snap
+// that are in the failed state.
+func failedServices(info *snap.Info) ([]string, error) {
+	var apps []*snap.AppInfo
+	var units []string
+	for _, app := range info.Services() {
+		if app.DaemonScope != snap.SystemDaemon {
+			continue
+		}
+		apps = append(apps, app)
+		units = append(units, app.ServiceName())
+	}
+	if len(units) == 0 {
+		return nil, nil
+	}
+
+	sysd := systemd.New(systemd.SystemMode, nil)
+	sts, err := sysd.Status(units)
+	if err != nil {
+		return nil, err
+	}
+	var failed []string
+	for i, st := range sts {
+		if st.Failed {
+			failed = append(failed, apps[i].Name)
+		}
+	}
+	return failed, nil
+}
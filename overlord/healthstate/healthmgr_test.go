@@ -0,0 +1,210 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package healthstate_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/healthstate"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/systemd"
+)
+
+func (s *healthSuite) mockCheckHealthHook(c *check.C) {
+	hookFn := filepath.Join(s.info.MountDir(), "meta", "hooks", "check-health")
+	c.Assert(os.MkdirAll(filepath.Dir(hookFn), 0755), check.IsNil)
+	c.Assert(os.WriteFile(hookFn, nil, 0755), check.IsNil)
+}
+
+func (s *healthSuite) checkHealthChanges() []*state.Change {
+	var chgs []*state.Change
+	for _, chg := range s.state.Changes() {
+		if chg.Kind() == "check-health" {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
+func (s *healthSuite) TestManagerPeriodicCheck(c *check.C) {
+	s.mockCheckHealthHook(c)
+	now := time.Now()
+	defer healthstate.MockTimeNow(func() time.Time { return now })()
+
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	s.state.Unlock()
+
+	mgr := healthstate.Manager(s.state)
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	s.state.Lock()
+	chgs := s.checkHealthChanges()
+	c.Assert(chgs, check.HasLen, 1)
+	tasks := chgs[0].Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "run-hook")
+	var hooksup hookstate.HookSetup
+	c.Assert(tasks[0].Get("hook-setup", &hooksup), check.IsNil)
+	c.Check(hooksup.Snap, check.Equals, "test-snap")
+	c.Check(hooksup.Hook, check.Equals, "check-health")
+	var last time.Time
+	c.Assert(s.state.Get("last-health-check", &last), check.IsNil)
+	c.Check(last.Equal(now), check.Equals, true)
+	tasks[0].SetStatus(state.DoneStatus)
+	s.state.Unlock()
+
+	// nothing to do until the interval elapsed, also across restarts
+	now = now.Add(time.Hour)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Assert(healthstate.Manager(s.state).Ensure(), check.IsNil)
+	s.state.Lock()
+	c.Check(s.checkHealthChanges(), check.HasLen, 1)
+	s.state.Unlock()
+
+	now = now.Add(24 * time.Hour)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	s.state.Lock()
+	c.Check(s.checkHealthChanges(), check.HasLen, 2)
+	s.state.Unlock()
+}
+
+func (s *healthSuite) TestManagerNoCheckUnlessSeeded(c *check.C) {
+	s.mockCheckHealthHook(c)
+
+	mgr := healthstate.Manager(s.state)
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.checkHealthChanges(), check.HasLen, 0)
+}
+
+func (s *healthSuite) TestManagerSkipsSnapsWithoutHook(c *check.C) {
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	s.state.Unlock()
+
+	mgr := healthstate.Manager(s.state)
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.checkHealthChanges(), check.HasLen, 0)
+	var last time.Time
+	c.Check(s.state.Get("last-health-check", &last), check.IsNil)
+}
+
+func (s *healthSuite) mockRefreshedSnapWithService(c *check.C, refreshed time.Time, activeState string) (systemctlCalls *int) {
+	s.mockRefreshedSnap(c, refreshed)
+	snaptest.MockSnap(c, "{name: test-snap, version: v1, apps: {svc: {daemon: simple}}}", &snap.SideInfo{RealName: "test-snap", Revision: snap.R(42)})
+
+	calls := 0
+	s.AddCleanup(systemd.MockSystemctl(func(args ...string) ([]byte, error) {
+		calls++
+		c.Check(args, check.DeepEquals, []string{"show", "--property=Id,ActiveState,UnitFileState,Type,Names,NeedDaemonReload", "snap.test-snap.svc.service"})
+		return []byte(fmt.Sprintf(`Type=simple
+Id=snap.test-snap.svc.service
+Names=snap.test-snap.svc.service
+ActiveState=%s
+UnitFileState=enabled
+NeedDaemonReload=no
+`, activeState)), nil
+	}))
+	return &calls
+}
+
+func (s *healthSuite) TestManagerRevertsSnapWithFailedServices(c *check.C) {
+	calls := s.mockRefreshedSnapWithService(c, time.Now(), "failed")
+
+	mgr := healthstate.Manager(s.state)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(*calls, check.Equals, 1)
+
+	s.state.Lock()
+	reverts := s.revertChanges()
+	c.Assert(reverts, check.HasLen, 1)
+	c.Check(reverts[0].Summary(), check.Equals, `Revert unhealthy "test-snap" snap`)
+	c.Assert(s.state.AllWarnings(), check.HasLen, 1)
+	c.Check(s.state.AllWarnings()[0].String(), check.Equals, `services "svc" of snap "test-snap" failed after being refreshed, reverting`)
+	s.state.Unlock()
+
+	// not checked again while the revert is in progress
+	defer healthstate.MockTimeNow(func() time.Time { return time.Now().Add(time.Minute) })()
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(*calls, check.Equals, 1)
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.revertChanges(), check.HasLen, 1)
+}
+
+func (s *healthSuite) TestManagerKeepsSnapWithActiveServices(c *check.C) {
+	calls := s.mockRefreshedSnapWithService(c, time.Now(), "active")
+
+	c.Assert(healthstate.Manager(s.state).Ensure(), check.IsNil)
+	c.Check(*calls, check.Equals, 1)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.revertChanges(), check.HasLen, 0)
+	c.Check(s.state.AllWarnings(), check.HasLen, 0)
+}
+
+func (s *healthSuite) TestManagerIgnoresFailedServicesOutsideWindow(c *check.C) {
+	calls := s.mockRefreshedSnapWithService(c, time.Now().Add(-time.Hour), "failed")
+
+	c.Assert(healthstate.Manager(s.state).Ensure(), check.IsNil)
+	c.Check(*calls, check.Equals, 0)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.revertChanges(), check.HasLen, 0)
+}
+
+func (s *healthSuite) TestIsUnhealthy(c *check.C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	unhealthy, err := snapstate.IsUnhealthy(s.state, "test-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(unhealthy, check.Equals, false)
+
+	for status, expected := range map[healthstate.HealthStatus]bool{
+		healthstate.OkayStatus:    false,
+		healthstate.WaitingStatus: false,
+		healthstate.ErrorStatus:   true,
+	} {
+		s.state.Set("health", map[string]*healthstate.HealthState{
+			"test-snap": {Status: status},
+		})
+		unhealthy, err := snapstate.IsUnhealthy(s.state, "test-snap")
+		c.Assert(err, check.IsNil)
+		c.Check(unhealthy, check.Equals, expected, check.Commentf("%s", status))
+	}
+}
@@ -27,7 +27,9 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -47,6 +49,7 @@ func init() {
 	}
 
 	snapstate.CheckHealthHook = Hook
+	snapstate.IsUnhealthy = isUnhealthy
 }
 
 func Hook(st *state.State, snapName string, snapRev snap.Revision) *state.Task {
@@ -143,7 +146,11 @@ func (h *healthHandler) Done() error {
 		}
 	}
 
-	return h.appendHealth(&health)
+	if err := h.appendHealth(&health); err != nil {
+		return err
+	}
+
+	return h.maybeRollback(&health)
 }
 
 func (h *healthHandler) Error(err error) (bool, error) {
@@ -164,6 +171,106 @@ func (h *healthHandler) appendHealth(health *HealthState) error {
 	return appendHealth(h.context, health)
 }
 
+// maybeRollback reverts the snap to its previous revision if it reported
+// an error within refresh.rollback-window of being refreshed. When the hook
+// runs as part of the refresh itself, failing it undoes the refresh;
+// otherwise a revert change is created.
+func (h *healthHandler) maybeRollback(health *HealthState) error {
+	st := h.context.State()
+	st.Lock()
+	defer st.Unlock()
+
+	snapName := h.context.InstanceName()
+	rollback, err := shouldRollback(st, snapName, health)
+	if err != nil || !rollback {
+		return err
+	}
+
+	var fromChange string
+	if task, ok := h.context.Task(); ok && task.Change() != nil {
+		chg := task.Change()
+		if chg.Kind() != "check-health" {
+			st.Warnf("snap %q reported an error after being refreshed, reverting: %s", snapName, health.Message)
+			return fmt.Errorf("snap %q reported an error after being refreshed: %s", snapName, health.Message)
+		}
+		fromChange = chg.ID()
+	}
+
+	if revertUnhealthy(st, snapName, fromChange) {
+		st.Warnf("snap %q reported an error after being refreshed, reverting: %s", snapName, health.Message)
+	}
+	return nil
+}
+
+// revertUnhealthy creates a change reverting the snap to its previous
+// revision and returns whether it could.
+func revertUnhealthy(st *state.State, snapName, fromChange string) bool {
+	ts, err := snapstate.Revert(st, snapName, snapstate.Flags{}, fromChange)
+	if err != nil {
+		logger.Noticef("cannot revert unhealthy snap %q: %v", snapName, err)
+		return false
+	}
+	chg := st.NewChange("revert-snap", fmt.Sprintf(i18n.G("Revert unhealthy %q snap"), snapName))
+	chg.AddAll(ts)
+	st.EnsureBefore(0)
+	return true
+}
+
+// rollbackWindow returns for how long after a refresh an error reported by
+// the check-health hook, or a service failing, reverts the snap, as set via
+// refresh.rollback-window.
+func rollbackWindow(st *state.State) (time.Duration, error) {
+	tr := config.NewTransaction(st)
+	var windowStr string
+	if err := tr.Get("core", "refresh.rollback-window", &windowStr); err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if windowStr == "" {
+		return 0, nil
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse refresh.rollback-window: %v", err)
+	}
+	return window, nil
+}
+
+// shouldRollback returns whether the snap reported an error for the
+// revision it was last refreshed to, within the rollback window.
+func shouldRollback(st *state.State, snapName string, health *HealthState) (bool, error) {
+	if health.Status != ErrorStatus {
+		return false, nil
+	}
+	window, err := rollbackWindow(st)
+	if err != nil || window <= 0 {
+		return false, err
+	}
+
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, snapName, &snapst); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return false, nil
+		}
+		return false, err
+	}
+	if snapst.Current != health.Revision {
+		return false, nil
+	}
+	return inRollbackWindow(&snapst, window), nil
+}
+
+// inRollbackWindow returns whether the snap was refreshed to its current
+// revision within the rollback window and can be reverted from it.
+func inRollbackWindow(snapst *snapstate.SnapState, window time.Duration) bool {
+	if snapst.LastRefreshTime == nil || timeNow().Sub(*snapst.LastRefreshTime) > window {
+		return false
+	}
+	// only go back from the latest revision, and not further from one
+	// the snap was already reverted to
+	idx := snapst.LastIndex(snapst.Current)
+	return idx > 0 && idx == len(snapst.Sequence)-1
+}
+
 func appendHealth(ctx *hookstate.Context, health *HealthState) error {
 	st := ctx.State()
 
@@ -225,3 +332,11 @@ func Get(st *state.State, snap string) (*HealthState, error) {
 
 	return &health, nil
 }
+
+func isUnhealthy(st *state.State, snap string) (bool, error) {
+	health, err := Get(st, snap)
+	if err != nil || health == nil {
+		return false, err
+	}
+	return health.Status == ErrorStatus, nil
+}
@@ -26,12 +26,15 @@ import (
 	"time"
 
 	"gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/healthstate"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
@@ -251,3 +254,134 @@ func (s *healthSuite) TestSetFromHookContextEmpty(c *check.C) {
 	// no health in the context -> no health in state
 	c.Check(s.state.Get("health", &hs), testutil.ErrorIs, state.ErrNoState)
 }
+
+func (s *healthSuite) mockRefreshedSnap(c *check.C, refreshed time.Time) {
+	s.AddCleanup(snapstatetest.MockDeviceModel(nil))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	si41 := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(41)}
+	si42 := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(42)}
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence:        []*snap.SideInfo{si41, si42},
+		Current:         snap.R(42),
+		Active:          true,
+		SnapType:        "app",
+		LastRefreshTime: &refreshed,
+	})
+	snaptest.MockSnap(c, "{name: test-snap, version: v0}", si41)
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.rollback-window", "10m")
+	tr.Commit()
+}
+
+func (s *healthSuite) runErrorHealthHook(c *check.C, chgKind string) *state.Change {
+	s.mockCheckHealthHook(c)
+	restore := hookstate.MockRunHook(func(ctx *hookstate.Context, _ *tomb.Tomb) ([]byte, error) {
+		ctx.Lock()
+		defer ctx.Unlock()
+		ctx.Set("health", &healthstate.HealthState{
+			Revision:  snap.R(42),
+			Timestamp: time.Now(),
+			Status:    healthstate.ErrorStatus,
+			Message:   "broken",
+		})
+		return nil, nil
+	})
+	defer restore()
+
+	s.state.Lock()
+	chg := s.state.NewChange(chgKind, "...")
+	chg.AddTask(healthstate.Hook(s.state, "test-snap", snap.R(42)))
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	return chg
+}
+
+func (s *healthSuite) revertChanges() []*state.Change {
+	var chgs []*state.Change
+	for _, chg := range s.state.Changes() {
+		if chg.Kind() == "revert-snap" {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
+func (s *healthSuite) TestRollbackFailsRefreshChange(c *check.C) {
+	s.mockRefreshedSnap(c, time.Now())
+
+	chg := s.runErrorHealthHook(c, "refresh-snap")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(chg.Status(), check.Equals, state.ErrorStatus)
+	c.Check(chg.Err(), check.ErrorMatches, `(?s).*snap "test-snap" reported an error after being refreshed: broken.*`)
+	c.Check(s.revertChanges(), check.HasLen, 0)
+	c.Assert(s.state.AllWarnings(), check.HasLen, 1)
+	c.Check(s.state.AllWarnings()[0].String(), check.Equals, `snap "test-snap" reported an error after being refreshed, reverting: broken`)
+}
+
+func (s *healthSuite) TestRollbackAfterPeriodicCheck(c *check.C) {
+	s.mockRefreshedSnap(c, time.Now())
+
+	chg := s.runErrorHealthHook(c, "check-health")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(chg.Status(), check.Equals, state.DoneStatus)
+	reverts := s.revertChanges()
+	c.Assert(reverts, check.HasLen, 1)
+	c.Check(reverts[0].Summary(), check.Equals, `Revert unhealthy "test-snap" snap`)
+	c.Check(s.state.AllWarnings(), check.HasLen, 1)
+}
+
+func (s *healthSuite) TestNoRollbackOutsideWindow(c *check.C) {
+	s.mockRefreshedSnap(c, time.Now().Add(-time.Hour))
+
+	chg := s.runErrorHealthHook(c, "refresh-snap")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(chg.Status(), check.Equals, state.DoneStatus)
+	c.Check(s.revertChanges(), check.HasLen, 0)
+	c.Check(s.state.AllWarnings(), check.HasLen, 0)
+}
+
+func (s *healthSuite) TestNoRollbackUnlessConfigured(c *check.C) {
+	s.mockRefreshedSnap(c, time.Now())
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.rollback-window", "")
+	tr.Commit()
+	s.state.Unlock()
+
+	chg := s.runErrorHealthHook(c, "refresh-snap")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(chg.Status(), check.Equals, state.DoneStatus)
+	c.Check(s.state.AllWarnings(), check.HasLen, 0)
+}
+
+func (s *healthSuite) TestNoRollbackWithoutPreviousRevision(c *check.C) {
+	s.mockRefreshedSnap(c, time.Now())
+	s.state.Lock()
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "test-snap", &snapst), check.IsNil)
+	snapst.Sequence = snapst.Sequence[1:]
+	snapstate.Set(s.state, "test-snap", &snapst)
+	s.state.Unlock()
+
+	chg := s.runErrorHealthHook(c, "check-health")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(chg.Status(), check.Equals, state.DoneStatus)
+	c.Check(s.revertChanges(), check.HasLen, 0)
+}
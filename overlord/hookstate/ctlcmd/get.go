@@ -175,7 +175,7 @@ func (c *getCommand) getConfigSetting(context *hookstate.Context) error {
 	}
 
 	context.Lock()
-	transaction := configstate.ContextTransaction(context)
+	transaction := configstate.HookTransaction(context)
 	context.Unlock()
 
 	return c.printValues(func(key string) (interface{}, bool, error) {
@@ -79,6 +79,10 @@ updated and/or if a restart will occur, both of which are disruptive. A base
 snap update can temporarily disrupt the starting of applications or hooks from
 the snap.
 
+If the pending refresh moves the snap to a different base snap, the new base
+is shown as well:
+    new-base: core22
+
 To tell snapd to proceed with pending refreshes:
     $ snapctl refresh --pending --proceed
 
@@ -152,6 +156,8 @@ type updateDetails struct {
 	// TODO: epoch
 	Base    bool `yaml:"base"`
 	Restart bool `yaml:"restart"`
+	// NewBase is set when the pending refresh changes the base of the snap
+	NewBase string `yaml:"new-base,omitempty"`
 }
 
 type holdDetails struct {
@@ -163,6 +169,7 @@ type holdDetails struct {
 type refreshCandidate struct {
 	Channel     string         `json:"channel,omitempty"`
 	Version     string         `json:"version,omitempty"`
+	Base        string         `json:"base,omitempty"`
 	SideInfo    *snap.SideInfo `json:"side-info,omitempty"`
 	InstanceKey string         `json:"instance-key,omitempty"`
 }
@@ -226,6 +233,9 @@ func getUpdateDetails(context *hookstate.Context) (*updateDetails, error) {
 		up.Channel = cand.Channel
 		up.Revision = cand.SideInfo.Revision.N
 		up.Version = cand.Version
+		if curInfo, err := snapst.CurrentInfo(); err == nil && cand.Base != "" && curInfo.Base != cand.Base {
+			up.NewBase = cand.Base
+		}
 		return &up, nil
 	}
 
@@ -60,6 +60,19 @@ func mockRefreshCandidate(snapName, channel, version string, revision snap.Revis
 	return snapstate.MockRefreshCandidate(sup)
 }
 
+func mockRefreshCandidateWithBase(snapName, channel, version, base string, revision snap.Revision) interface{} {
+	sup := &snapstate.SnapSetup{
+		Channel: channel,
+		Base:    base,
+		SideInfo: &snap.SideInfo{
+			Revision: revision,
+			RealName: snapName,
+		},
+		Version: version,
+	}
+	return snapstate.MockRefreshCandidate(sup)
+}
+
 func (s *refreshSuite) SetUpTest(c *C) {
 	s.BaseTest.SetUpTest(c)
 	dirs.SetRootDir(c.MkDir())
@@ -97,6 +110,18 @@ var refreshFromHookTests = []struct {
 		"snap1": mockRefreshCandidate("snap1", "edge", "v1", snap.Revision{N: 3}),
 	},
 	stdout: "pending: ready\nchannel: edge\nversion: v1\nrevision: 3\nbase: false\nrestart: false\n",
+}, {
+	args: []string{"refresh", "--pending"},
+	refreshCandidates: map[string]interface{}{
+		"snap1": mockRefreshCandidateWithBase("snap1", "edge", "v2", "core22", snap.Revision{N: 4}),
+	},
+	stdout: "pending: ready\nchannel: edge\nversion: v2\nrevision: 4\nbase: false\nrestart: false\nnew-base: core22\n",
+}, {
+	args: []string{"refresh", "--pending"},
+	refreshCandidates: map[string]interface{}{
+		"snap1": mockRefreshCandidateWithBase("snap1", "edge", "v2", "snap1-base", snap.Revision{N: 4}),
+	},
+	stdout: "pending: ready\nchannel: edge\nversion: v2\nrevision: 4\nbase: false\nrestart: false\n",
 }, {
 	args:   []string{"refresh", "--pending"},
 	stdout: "pending: none\nchannel: stable\nbase: false\nrestart: false\n",
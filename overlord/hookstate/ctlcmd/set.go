@@ -104,7 +104,7 @@ func (s *setCommand) Execute(args []string) error {
 
 func (s *setCommand) setConfigSetting(context *hookstate.Context) error {
 	context.Lock()
-	tr := configstate.ContextTransaction(context)
+	tr := configstate.HookTransaction(context)
 	context.Unlock()
 
 	for _, patchValue := range s.Positional.ConfValues {
@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/configstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+)
+
+type transactionCommand struct {
+	baseCommand
+
+	Positional struct {
+		Action string `positional-arg-name:"<start|show|commit|abort>" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+var shortTransactionHelp = i18n.G("Group configuration changes in a transaction")
+var longTransactionHelp = i18n.G(`
+The transaction command lets a hook group several configuration changes and
+have them validated together before they are applied.
+
+    $ snapctl transaction start
+    $ snapctl set min=10 max=20
+    $ snapctl transaction show
+    $ snapctl transaction commit
+
+Once a transaction is started, the changes made with set and unset are only
+seen by get until the transaction is committed or aborted. Committing
+validates the resulting configuration and, if it is valid, applies the
+changes so that they are persisted with the others once the hook returns
+successfully. If the validation fails the transaction stays open so that
+the changes can be fixed or aborted.
+
+Changes of a transaction that is not committed are discarded when the hook
+returns.
+`)
+
+func init() {
+	addCommand("transaction", shortTransactionHelp, longTransactionHelp, func() command { return &transactionCommand{} })
+}
+
+func (c *transactionCommand) Execute(args []string) error {
+	context, err := c.ensureContext()
+	if err != nil {
+		return err
+	}
+
+	context.Lock()
+	defer context.Unlock()
+
+	switch c.Positional.Action {
+	case "start":
+		return configstate.StartTransaction(context)
+	case "show":
+		tr, err := configstate.StagedTransaction(context)
+		if err != nil {
+			return err
+		}
+		return c.showChanges(tr, context.InstanceName())
+	case "commit":
+		return configstate.CommitTransaction(context)
+	case "abort":
+		return configstate.AbortTransaction(context)
+	default:
+		return fmt.Errorf(i18n.G("unknown transaction action %q"), c.Positional.Action)
+	}
+}
+
+// showChanges prints the pending changes of the snap configuration, with
+// null values for the options that are unset.
+func (c *transactionCommand) showChanges(tr *config.Transaction, instanceName string) error {
+	changes := make(map[string]interface{})
+	prefix := instanceName + "."
+	for _, change := range tr.Changes() {
+		if !strings.HasPrefix(change, prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(change, prefix)
+		var value interface{}
+		if err := tr.Get(instanceName, key, &value); err != nil && !config.IsNoOption(err) {
+			return err
+		}
+		changes[key] = value
+	}
+
+	bytes, err := json.MarshalIndent(changes, "", "\t")
+	if err != nil {
+		return err
+	}
+	c.printf("%s\n", string(bytes))
+	return nil
+}
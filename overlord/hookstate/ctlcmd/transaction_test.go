@@ -0,0 +1,136 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+type transactionSuite struct {
+	mockContext *hookstate.Context
+}
+
+var _ = Suite(&transactionSuite{})
+
+func (s *transactionSuite) SetUpTest(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	tr := config.NewTransaction(st)
+	tr.Set("test-snap", "min", 1)
+	tr.Set("test-snap", "max", 2)
+	tr.Commit()
+
+	task := st.NewTask("test-task", "my test task")
+	setup := &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: "configure"}
+
+	var err error
+	s.mockContext, err = hookstate.NewContext(task, task.State(), setup, hooktest.NewMockHandler(), "")
+	c.Assert(err, IsNil)
+}
+
+func (s *transactionSuite) run(c *C, args ...string) string {
+	stdout, stderr, err := ctlcmd.Run(s.mockContext, args, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stderr), Equals, "")
+	return string(stdout)
+}
+
+func (s *transactionSuite) committed(c *C, key string) int {
+	s.mockContext.Lock()
+	defer s.mockContext.Unlock()
+	s.mockContext.Done()
+
+	var value int
+	tr := config.NewTransaction(s.mockContext.State())
+	c.Assert(tr.Get("test-snap", key, &value), IsNil)
+	return value
+}
+
+func (s *transactionSuite) TestTransactionCommit(c *C) {
+	s.run(c, "transaction", "start")
+	s.run(c, "set", "min=10", "max=20")
+	s.run(c, "unset", "other")
+
+	c.Check(s.run(c, "get", "min"), Equals, "10\n")
+	c.Check(s.run(c, "transaction", "show"), Equals, `{
+	"max": 20,
+	"min": 10,
+	"other": null
+}
+`)
+
+	s.run(c, "transaction", "commit")
+	c.Check(s.run(c, "get", "max"), Equals, "20\n")
+
+	c.Check(s.committed(c, "min"), Equals, 10)
+	c.Check(s.committed(c, "max"), Equals, 20)
+}
+
+func (s *transactionSuite) TestTransactionAbort(c *C) {
+	s.run(c, "set", "min=0")
+	s.run(c, "transaction", "start")
+	s.run(c, "set", "max=20")
+	c.Check(s.run(c, "transaction", "show"), Equals, `{
+	"max": 20,
+	"min": 0
+}
+`)
+	s.run(c, "transaction", "abort")
+
+	c.Check(s.run(c, "get", "max"), Equals, "2\n")
+	c.Check(s.committed(c, "min"), Equals, 0)
+	c.Check(s.committed(c, "max"), Equals, 2)
+}
+
+func (s *transactionSuite) TestTransactionNotCommitted(c *C) {
+	s.run(c, "transaction", "start")
+	s.run(c, "set", "max=20")
+
+	c.Check(s.committed(c, "max"), Equals, 2)
+}
+
+func (s *transactionSuite) TestTransactionErrors(c *C) {
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"transaction"}, "the required argument `<start|show|commit|abort>` was not provided"},
+		{[]string{"transaction", "rollback"}, `unknown transaction action "rollback"`},
+		{[]string{"transaction", "show"}, "no configuration transaction started"},
+		{[]string{"transaction", "commit"}, "no configuration transaction started"},
+		{[]string{"transaction", "abort"}, "no configuration transaction started"},
+	} {
+		_, _, err := ctlcmd.Run(s.mockContext, t.args, 0)
+		c.Check(err, ErrorMatches, t.err, Commentf("%v", t.args))
+	}
+
+	s.run(c, "transaction", "start")
+	_, _, err := ctlcmd.Run(s.mockContext, []string{"transaction", "start"}, 0)
+	c.Check(err, ErrorMatches, "configuration transaction already started")
+}
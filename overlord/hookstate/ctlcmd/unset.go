@@ -63,7 +63,7 @@ func (s *unsetCommand) Execute(args []string) error {
 	}
 
 	context.Lock()
-	tr := configstate.ContextTransaction(context)
+	tr := configstate.HookTransaction(context)
 	context.Unlock()
 
 	for _, confKey := range s.Positional.ConfKeys {
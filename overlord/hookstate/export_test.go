@@ -39,6 +39,14 @@ func MockDefaultHookTimeout(timeout time.Duration) func() {
 	}
 }
 
+func MockMaxHookTimeout(timeout time.Duration) func() {
+	oldMaxTimeout := maxHookTimeout
+	maxHookTimeout = timeout
+	return func() {
+		maxHookTimeout = oldMaxTimeout
+	}
+}
+
 func MockErrtrackerReport(mock func(string, string, string, map[string]string) (string, error)) (restore func()) {
 	prev := errtrackerReport
 	errtrackerReport = mock
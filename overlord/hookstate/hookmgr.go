@@ -24,11 +24,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"gopkg.in/tomb.v2"
@@ -77,6 +79,14 @@ type Handler interface {
 	Error(hookErr error) (ignoreHookErr bool, err error)
 }
 
+// UndoHandler can be implemented by handlers that need to revert the effects
+// of a hook that ran successfully when its change fails later on.
+type UndoHandler interface {
+	// Undo is called when the task of the hook is undone, unless an
+	// undo hook was requested for it.
+	Undo() error
+}
+
 // HandlerGenerator is the function signature required to register for hooks.
 type HandlerGenerator func(*Context) Handler
 
@@ -279,7 +289,7 @@ func (m *HookManager) undoRunHook(task *state.Task, tomb *tomb.Tomb) error {
 	if err != nil {
 		if errors.Is(err, state.ErrNoState) {
 			// no undo hook setup
-			return nil
+			return m.undoHandler(task)
 		}
 		return fmt.Errorf("cannot extract undo hook setup from task: %s", err)
 	}
@@ -287,6 +297,38 @@ func (m *HookManager) undoRunHook(task *state.Task, tomb *tomb.Tomb) error {
 	return m.runHookForTask(task, tomb, snapst, hooksup)
 }
 
+// undoHandler lets the handler of the hook run by the given task revert its
+// effects if it implements UndoHandler.
+func (m *HookManager) undoHandler(task *state.Task) error {
+	st := task.State()
+	st.Lock()
+	var hooksup HookSetup
+	err := task.Get("hook-setup", &hooksup)
+	st.Unlock()
+	if errors.Is(err, state.ErrNoState) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot extract hook setup from task: %s", err)
+	}
+
+	context, err := NewContext(task, m.state, &hooksup, nil, "")
+	if err != nil {
+		return err
+	}
+	handlers := m.repository.generateHandlers(context)
+	if len(handlers) != 1 {
+		// nothing to undo, errors were reported when running the hook
+		return nil
+	}
+	undoer, ok := handlers[0].(UndoHandler)
+	if !ok {
+		return nil
+	}
+	context.handler = handlers[0]
+	return undoer.Undo()
+}
+
 func (m *HookManager) EphemeralRunHook(ctx context.Context, hooksup *HookSetup, contextData map[string]interface{}) (*Context, error) {
 	var snapst snapstate.SnapState
 	m.state.Lock()
@@ -344,10 +386,21 @@ func (m *HookManager) runHook(context *Context, snapst *snapstate.SnapState, hoo
 			return fmt.Errorf("cannot read %q snap details: %v", hooksup.Snap, err)
 		}
 
-		hookExists = info.Hooks[hooksup.Hook] != nil
+		hookInfo := info.Hooks[hooksup.Hook]
+		hookExists = hookInfo != nil
 		if !hookExists && !hooksup.Optional {
 			return fmt.Errorf("snap %q has no %q hook", hooksup.Snap, hooksup.Hook)
 		}
+		// a timeout declared by the hook, which is capped, takes
+		// precedence over the one requested by snapd; the CPU and
+		// memory limits of hooks are set by snap run
+		if hookExists && hookInfo.Timeout > 0 {
+			hooksup.Timeout = time.Duration(hookInfo.Timeout)
+			if hooksup.Timeout > maxHookTimeout {
+				logger.Noticef("Capping the timeout of hook %q of snap %q to %v", hooksup.Hook, hooksup.Snap, maxHookTimeout)
+				hooksup.Timeout = maxHookTimeout
+			}
+		}
 	}
 
 	if hookExists || mustHijack {
@@ -402,6 +455,11 @@ func (m *HookManager) runHook(context *Context, snapst *snapstate.SnapState, hoo
 		err = f(context)
 	} else if hookExists {
 		output, err = runHook(context, tomb)
+		if hookKilled(err) {
+			// keep the reason along with the output, as
+			// osutil.OutputErr prefers the latter
+			output = append(output, "\n<killed, possibly for exceeding its memory limit>"...)
+		}
 	}
 	if err != nil {
 		if hooksup.TrackError {
@@ -478,6 +536,9 @@ func snapCmd() string {
 
 var defaultHookTimeout = 10 * time.Minute
 
+// maxHookTimeout caps the timeout a hook can declare in snap.yaml.
+var maxHookTimeout = time.Hour
+
 func runHookAndWait(snapName string, revision snap.Revision, hookName, hookContext string, timeout time.Duration, tomb *tomb.Tomb) ([]byte, error) {
 	argv := []string{snapCmd(), "run", "--hook", hookName, "-r", revision.String(), snapName}
 	if timeout == 0 {
@@ -497,6 +558,17 @@ func runHookAndWait(snapName string, revision snap.Revision, hookName, hookConte
 	return osutil.RunAndWait(argv, env, timeout, tomb)
 }
 
+// hookKilled returns whether the hook was killed with SIGKILL, which is how
+// the kernel enforces the memory limit of its scope.
+func hookKilled(err error) bool {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return false
+	}
+	ws, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && ws.Signaled() && ws.Signal() == syscall.SIGKILL
+}
+
 var errtrackerReport = errtracker.Report
 
 func trackHookError(context *Context, output []byte, err error) {
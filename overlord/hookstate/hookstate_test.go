@@ -529,6 +529,119 @@ func (s *hookManagerSuite) TestHookTaskEnforcesDefaultTimeout(c *C) {
 	checkTaskLogContains(c, s.task, `.*exceeded maximum runtime of 150ms`)
 }
 
+func (s *hookManagerSuite) TestHookTaskEnforcesTimeoutFromSnapYaml(c *C) {
+	s.state.Lock()
+	var hooksup hookstate.HookSetup
+	c.Assert(s.task.Get("hook-setup", &hooksup), IsNil)
+	s.state.Unlock()
+
+	// the hook declares its own timeout
+	sideInfo := &snap.SideInfo{RealName: "test-snap", SnapID: "some-snap-id", Revision: snap.R(1)}
+	snaptest.MockSnap(c, fmt.Sprintf(`
+name: test-snap
+version: 1.0
+hooks:
+    %s:
+        timeout: 150ms
+`, hooksup.Hook), sideInfo)
+
+	// Force the snap command to hang
+	cmd := testutil.MockCommand(c, "snap", "while true; do sleep 1; done")
+	defer cmd.Restore()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.mockHandler.ErrorCalled, Equals, true)
+	c.Check(s.mockHandler.Err, ErrorMatches, `.*exceeded maximum runtime of 150ms.*`)
+	c.Check(s.task.Status(), Equals, state.ErrorStatus)
+	checkTaskLogContains(c, s.task, `.*exceeded maximum runtime of 150ms`)
+}
+
+func (s *hookManagerSuite) TestHookTaskTimeoutFromSnapYamlIsCapped(c *C) {
+	restore := hookstate.MockMaxHookTimeout(150 * time.Millisecond)
+	defer restore()
+
+	s.state.Lock()
+	var hooksup hookstate.HookSetup
+	c.Assert(s.task.Get("hook-setup", &hooksup), IsNil)
+	s.state.Unlock()
+
+	sideInfo := &snap.SideInfo{RealName: "test-snap", SnapID: "some-snap-id", Revision: snap.R(1)}
+	snaptest.MockSnap(c, fmt.Sprintf(`
+name: test-snap
+version: 1.0
+hooks:
+    %s:
+        timeout: 1h
+`, hooksup.Hook), sideInfo)
+
+	// Force the snap command to hang
+	cmd := testutil.MockCommand(c, "snap", "while true; do sleep 1; done")
+	defer cmd.Restore()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.mockHandler.Err, ErrorMatches, `.*exceeded maximum runtime of 150ms.*`)
+	c.Check(s.task.Status(), Equals, state.ErrorStatus)
+}
+
+func (s *hookManagerSuite) TestHookTaskTimeoutFromSnapYamlOverridesSnapd(c *C) {
+	var hooksup hookstate.HookSetup
+
+	s.state.Lock()
+	c.Assert(s.task.Get("hook-setup", &hooksup), IsNil)
+	hooksup.Timeout = time.Hour
+	s.task.Set("hook-setup", &hooksup)
+	s.state.Unlock()
+
+	sideInfo := &snap.SideInfo{RealName: "test-snap", SnapID: "some-snap-id", Revision: snap.R(1)}
+	snaptest.MockSnap(c, fmt.Sprintf(`
+name: test-snap
+version: 1.0
+hooks:
+    %s:
+        timeout: 200ms
+`, hooksup.Hook), sideInfo)
+
+	// Force the snap command to hang
+	cmd := testutil.MockCommand(c, "snap", "while true; do sleep 1; done")
+	defer cmd.Restore()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.mockHandler.Err, ErrorMatches, `.*exceeded maximum runtime of 200ms.*`)
+	c.Check(s.task.Status(), Equals, state.ErrorStatus)
+}
+
+func (s *hookManagerSuite) TestHookTaskKilledKeepsOutput(c *C) {
+	// the hook gets killed as when going over its memory limit
+	cmd := testutil.MockCommand(c, "snap", "echo some output; kill -9 $$")
+	defer cmd.Restore()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.mockHandler.ErrorCalled, Equals, true)
+	c.Check(s.mockHandler.Err, ErrorMatches, `(?s).*some output.*<killed, possibly for exceeding its memory limit>.*`)
+	c.Check(s.task.Status(), Equals, state.ErrorStatus)
+	checkTaskLogContains(c, s.task, `(?s).*some output.*<killed, possibly for exceeding its memory limit>.*`)
+}
+
 func (s *hookManagerSuite) TestHookTaskEnforcedTimeoutWithIgnoreError(c *C) {
 	var hooksup hookstate.HookSetup
 
@@ -736,6 +849,55 @@ func (s *hookManagerSuite) TestHookUndoRunsOnError(c *C) {
 	c.Check(s.manager.NumRunningHooks(), Equals, 0)
 }
 
+type undoingHandler struct {
+	*hooktest.MockHandler
+	undoCalled bool
+}
+
+func (h *undoingHandler) Undo() error {
+	h.undoCalled = true
+	return nil
+}
+
+func (s *hookManagerSuite) TestHookHandlerUndoRunsOnError(c *C) {
+	handler := &undoingHandler{MockHandler: hooktest.NewMockHandler()}
+	s.manager.Register(regexp.MustCompile("^do-something$"), func(context *hookstate.Context) hookstate.Handler {
+		return handler
+	})
+
+	hooksup := &hookstate.HookSetup{
+		Snap:     "test-snap",
+		Hook:     "do-something",
+		Revision: snap.R(1),
+	}
+	// use unknown hook to fail the change
+	failinghooksup := &hookstate.HookSetup{
+		Snap:     "test-snap",
+		Hook:     "unknown-hook",
+		Revision: snap.R(1),
+	}
+
+	s.state.Lock()
+	task := hookstate.HookTask(s.state, "test summary", hooksup, nil)
+	failtask := hookstate.HookTask(s.state, "test summary", failinghooksup, nil)
+	failtask.WaitFor(task)
+
+	change := s.state.NewChange("kind", "summary")
+	change.AddTask(task)
+	change.AddTask(failtask)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(handler.DoneCalled, Equals, true)
+	c.Check(handler.undoCalled, Equals, true)
+	c.Check(task.Status(), Equals, state.UndoneStatus)
+	c.Check(change.Status(), Equals, state.ErrorStatus)
+}
+
 func (s *hookManagerSuite) TestHookWithoutHandlerIsError(c *C) {
 	hooksup := &hookstate.HookSetup{
 		Snap:     "test-snap",
@@ -0,0 +1,100 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// ForbiddenInterfacesOption is the system option holding the comma
+// separated list of interfaces that cannot be connected on the device.
+const ForbiddenInterfacesOption = "interfaces.forbidden"
+
+// ErrInterfaceForbidden is returned when connecting an interface that is
+// forbidden by the system configuration.
+type ErrInterfaceForbidden struct {
+	Connection interfaces.ConnRef
+	Interface  string
+}
+
+func (e *ErrInterfaceForbidden) Error() string {
+	return fmt.Sprintf("cannot connect %s:%s to %s:%s: interface %q is forbidden by the %q system option",
+		e.Connection.PlugRef.Snap, e.Connection.PlugRef.Name, e.Connection.SlotRef.Snap, e.Connection.SlotRef.Name,
+		e.Interface, ForbiddenInterfacesOption)
+}
+
+// forbiddenInterfaces returns the set of interfaces forbidden by the system
+// configuration.
+func forbiddenInterfaces(st *state.State) (map[string]bool, error) {
+	tr := config.NewTransaction(st)
+	var value string
+	if err := tr.GetMaybe("core", ForbiddenInterfacesOption, &value); err != nil {
+		return nil, err
+	}
+	return interfaces.ParseInterfaceNames(value)
+}
+
+// checkInterfaceAllowed returns an ErrInterfaceForbidden error if the
+// interface of the given connection is forbidden by the system
+// configuration.
+func checkInterfaceAllowed(st *state.State, connRef *interfaces.ConnRef, ifaceName string) error {
+	forbidden, err := forbiddenInterfaces(st)
+	if err != nil {
+		return err
+	}
+	if forbidden[ifaceName] {
+		return &ErrInterfaceForbidden{Connection: *connRef, Interface: ifaceName}
+	}
+	return nil
+}
+
+// ForbiddenConnections returns the existing connections, sorted, whose
+// interface is one of the given forbidden interfaces. Such connections
+// are left in place when the policy changes and need to be disconnected
+// by the administrator.
+func ForbiddenConnections(st *state.State, forbidden map[string]bool) ([]*interfaces.ConnRef, error) {
+	if len(forbidden) == 0 {
+		return nil, nil
+	}
+	conns, err := getConns(st)
+	if err != nil {
+		return nil, err
+	}
+	var refs []*interfaces.ConnRef
+	for id, conn := range conns {
+		if conn.Undesired || conn.HotplugGone || !forbidden[conn.Interface] {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, connRef)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].SortsBefore(refs[j])
+	})
+	return refs, nil
+}
@@ -0,0 +1,128 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (s *interfaceManagerSuite) forbidInterfaces(c *C, value string) {
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "interfaces.forbidden", value), IsNil)
+	tr.Commit()
+}
+
+func (s *interfaceManagerSuite) TestConnectForbiddenInterface(c *C) {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+	_ = s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// other interfaces can still be connected
+	s.forbidInterfaces(c, "test2")
+	_, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Check(err, IsNil)
+
+	s.forbidInterfaces(c, "other, test")
+	ts, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Assert(err, ErrorMatches, `cannot connect consumer:plug to producer:slot: interface "test" is forbidden by the "interfaces.forbidden" system option`)
+	c.Check(ts, IsNil)
+	forbidden, ok := err.(*ifacestate.ErrInterfaceForbidden)
+	c.Assert(ok, Equals, true)
+	c.Check(forbidden.Interface, Equals, "test")
+}
+
+func (s *interfaceManagerSuite) TestConnectTaskForbiddenAfterCreation(c *C) {
+	s.MockModel(c, nil)
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+	_ = s.manager(c)
+
+	s.state.Lock()
+	change := s.state.NewChange("kind", "summary")
+	ts, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Assert(err, IsNil)
+	ts.Tasks()[0].Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "consumer",
+		},
+	})
+	change.AddAll(ts)
+	// the policy changes before the connection is made
+	s.forbidInterfaces(c, "test")
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(change.Err(), ErrorMatches, `(?s).*interface "test" is forbidden by the "interfaces.forbidden" system option.*`)
+	c.Check(change.Status(), Equals, state.ErrorStatus)
+	c.Check(s.manager(c).Repository().Interfaces().Connections, HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestAutoConnectSkipsForbiddenInterface(c *C) {
+	s.state.Lock()
+	s.forbidInterfaces(c, "test")
+	s.state.Unlock()
+
+	s.testDoSetupSnapSecurityAutoConnectsDeclBased(c, true, func(conns map[string]interface{}, repoConns []*interfaces.ConnRef) {
+		// nothing was auto-connected
+		c.Check(conns, HasLen, 0)
+		c.Check(repoConns, HasLen, 0)
+	})
+}
+
+func (s *interfaceManagerSuite) TestForbiddenConnections(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot":       map[string]interface{}{"interface": "test"},
+		"consumer:otherplug producer:slot2": map[string]interface{}{"interface": "test2"},
+		"app:plug producer:slot":            map[string]interface{}{"interface": "test", "auto": true},
+		"other:plug producer:slot":          map[string]interface{}{"interface": "test", "undesired": true},
+		"other:plug2 producer:slot":         map[string]interface{}{"interface": "test", "hotplug-gone": true},
+	})
+
+	connRefs, err := ifacestate.ForbiddenConnections(s.state, nil)
+	c.Assert(err, IsNil)
+	c.Check(connRefs, HasLen, 0)
+
+	connRefs, err = ifacestate.ForbiddenConnections(s.state, map[string]bool{"test": true})
+	c.Assert(err, IsNil)
+	c.Check(connRefs, DeepEquals, []*interfaces.ConnRef{
+		{PlugRef: interfaces.PlugRef{Snap: "app", Name: "plug"}, SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"}},
+		{PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"}, SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"}},
+	})
+}
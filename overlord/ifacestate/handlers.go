@@ -549,6 +549,16 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 		return fmt.Errorf("snap %q has no %q slot", connRef.SlotRef.Snap, connRef.SlotRef.Name)
 	}
 
+	// the system configuration may have changed since the task was created
+	if err := checkInterfaceAllowed(st, connRef, plug.Interface); err != nil {
+		if _, ok := err.(*ErrInterfaceForbidden); ok && autoConnect {
+			// auto-connections are skipped, like when denied by the policy
+			task.Logf("%s", err)
+			return nil
+		}
+		return err
+	}
+
 	// attributes are always present, even if there are no hooks (they're initialized by Connect).
 	plugDynamicAttrs, slotDynamicAttrs, err := getDynamicHookAttributes(task)
 	if err != nil {
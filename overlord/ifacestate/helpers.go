@@ -651,6 +651,14 @@ func addNewConnection(st *state.State, task *state.Task, newconns map[string]*in
 		return nil
 	}
 
+	if err := checkInterfaceAllowed(st, connRef, plug.Interface); err != nil {
+		if _, ok := err.(*ErrInterfaceForbidden); ok {
+			task.Logf("%s", err)
+			return nil
+		}
+		return err
+	}
+
 	if task.Kind() == "auto-connect" {
 		ignore, err := findSymmetricAutoconnectTask(st, plug.Snap.InstanceName(), slot.Snap.InstanceName(), task)
 		if err != nil {
@@ -842,7 +850,9 @@ func (c *autoConnectChecker) addAutoConnections(newconns map[string]*interfaces.
 			for i, candidate := range candSlots {
 				crefs[i] = candidate.String()
 			}
-			c.task.Logf(cannotAutoConnectLog(plug, crefs))
+			msg := cannotAutoConnectLog(plug, crefs)
+			c.task.Logf(msg)
+			c.st.Warnf("%s", msg)
 			continue
 		}
 
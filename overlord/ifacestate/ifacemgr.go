@@ -175,7 +175,7 @@ func (m *InterfaceManager) StartUp() error {
 		}
 	}
 	if snapdAppArmorServiceIsDisabled() {
-		s.Warnf(`the snapd.apparmor service is disabled; snap applications will likely not start.
+		s.WarnfWithSeverity(state.WarningSeverityHigh, `the snapd.apparmor service is disabled; snap applications will likely not start.
 Run "systemctl enable --now snapd.apparmor" to correct this.`)
 	}
 
@@ -312,6 +312,101 @@ func (m *InterfaceManager) ConnectionStates() (connStateByRef map[string]Connect
 	return ConnectionStates(m.state)
 }
 
+// AutoConnectProblem describes why a plug is not connected although it
+// would be a candidate for auto-connection.
+type AutoConnectProblem struct {
+	Plug      interfaces.PlugRef
+	Interface string
+	// Candidates are the slots the plug could be connected to, if any.
+	Candidates []interfaces.SlotRef
+	Reason     string
+}
+
+// AutoConnectProblems returns the plugs of the given snap, or of all the
+// snaps if snapName is empty, that are not connected, together with the
+// reason why they were not connected automatically. Plugs that were
+// disconnected manually are not reported.
+func (m *InterfaceManager) AutoConnectProblems(snapName string) ([]*AutoConnectProblem, error) {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	deviceCtx, err := snapstate.DeviceCtxFromState(m.state, nil)
+	if err != nil {
+		return nil, err
+	}
+	checker, err := newAutoConnectChecker(m.state, nil, m.repo, deviceCtx)
+	if err != nil {
+		return nil, err
+	}
+	conns, err := getConns(m.state)
+	if err != nil {
+		return nil, err
+	}
+	undesired := make(map[interfaces.PlugRef]bool)
+	for id, cstate := range conns {
+		if !cstate.Undesired {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return nil, err
+		}
+		undesired[connRef.PlugRef] = true
+	}
+
+	var plugs []*snap.PlugInfo
+	if snapName != "" {
+		plugs = m.repo.Plugs(snapName)
+	} else {
+		plugs = m.repo.AllPlugs("")
+	}
+
+	anySlot := func(*interfaces.ConnectedPlug, *interfaces.ConnectedSlot) (bool, interfaces.SideArity, error) {
+		return true, nil, nil
+	}
+	var problems []*AutoConnectProblem
+	for _, plug := range plugs {
+		plugRef := interfaces.PlugRef{Snap: plug.Snap.InstanceName(), Name: plug.Name}
+		if undesired[plugRef] {
+			continue
+		}
+		connected, err := m.repo.Connected(plugRef.Snap, plugRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(connected) > 0 {
+			continue
+		}
+
+		problem := &AutoConnectProblem{Plug: plugRef, Interface: plug.Interface}
+		candidates, arities := m.repo.AutoConnectCandidateSlots(plugRef.Snap, plugRef.Name, checker.check)
+		candidates, arities = filterUbuntuCoreSlots(candidates, arities)
+		switch {
+		case len(m.repo.AllSlots(plug.Interface)) == 0:
+			problem.Reason = fmt.Sprintf("no slots of the %q interface are available", plug.Interface)
+		case len(candidates) == 0:
+			if slots, _ := m.repo.AutoConnectCandidateSlots(plugRef.Snap, plugRef.Name, anySlot); len(slots) == 0 {
+				problem.Reason = fmt.Sprintf("the %q interface does not connect automatically to the available slots", plug.Interface)
+			} else {
+				problem.Reason = "auto-connection is not allowed by the snap declarations"
+			}
+		default:
+			for _, slot := range candidates {
+				problem.Candidates = append(problem.Candidates, interfaces.SlotRef{Snap: slot.Snap.InstanceName(), Name: slot.Name})
+			}
+			problem.Reason = "auto-connection is allowed but did not happen"
+			for _, arity := range arities {
+				if !arity.SlotsPerPlugAny() && len(candidates) != 1 {
+					problem.Reason = "more than one slot is a candidate for auto-connection"
+					break
+				}
+			}
+		}
+		problems = append(problems, problem)
+	}
+	return problems, nil
+}
+
 // ResolveDisconnect resolves potentially missing plug or slot names and
 // returns a list of fully populated connection references that can be
 // disconnected.
@@ -139,6 +139,11 @@ func connect(st *state.State, plugSnap, plugName, slotSnap, slotName string, fla
 	if err != nil {
 		return nil, err
 	}
+	if plug, ok := plugSnapInfo.Plugs[plugName]; ok {
+		if err := checkInterfaceAllowed(st, &connRef, plug.Interface); err != nil {
+			return nil, err
+		}
+	}
 
 	plugStatic, slotStatic, err := initialConnectAttributes(st, plugSnapInfo, plugSnap, plugName, slotSnapInfo, slotSnap, slotName)
 	if err != nil {
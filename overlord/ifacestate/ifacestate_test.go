@@ -9304,3 +9304,75 @@ func (s *interfaceManagerSuite) TestOnSnapLinkageChanged(c *C) {
 		Sequence: []*snap.SideInfo{&info.SideInfo},
 	})
 }
+
+func (s *interfaceManagerSuite) testAutoConnectProblems(c *C, consumerPublisher string) []*ifacestate.AutoConnectProblem {
+	s.MockModel(c, nil)
+
+	restore := assertstest.MockBuiltinBaseDeclaration([]byte(`
+type: base-declaration
+authority-id: canonical
+series: 16
+slots:
+  test:
+    allow-auto-connection:
+      plug-publisher-id:
+        - $SLOT_PUBLISHER_ID
+`))
+	defer restore()
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.MockSnapDecl(c, "producer", "one-publisher", nil)
+	s.mockSnap(c, producerYaml)
+	s.MockSnapDecl(c, "consumer", consumerPublisher, nil)
+	s.mockSnap(c, `
+name: consumer
+version: 1
+plugs:
+ plug:
+  interface: test
+ lonely:
+  interface: test2
+ unwanted:
+  interface: test
+`)
+
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:unwanted producer:slot": map[string]interface{}{
+			"interface": "test", "auto": true, "undesired": true,
+		},
+	})
+	s.state.Unlock()
+
+	mgr := s.manager(c)
+
+	problems, err := mgr.AutoConnectProblems("consumer")
+	c.Assert(err, IsNil)
+	return problems
+}
+
+func (s *interfaceManagerSuite) TestAutoConnectProblemsDeniedByPolicy(c *C) {
+	problems := s.testAutoConnectProblems(c, "other-publisher")
+	c.Check(problems, DeepEquals, []*ifacestate.AutoConnectProblem{{
+		Plug:      interfaces.PlugRef{Snap: "consumer", Name: "lonely"},
+		Interface: "test2",
+		Reason:    `no slots of the "test2" interface are available`,
+	}, {
+		Plug:      interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		Interface: "test",
+		Reason:    "auto-connection is not allowed by the snap declarations",
+	}})
+}
+
+func (s *interfaceManagerSuite) TestAutoConnectProblemsAllowed(c *C) {
+	problems := s.testAutoConnectProblems(c, "one-publisher")
+	c.Check(problems, DeepEquals, []*ifacestate.AutoConnectProblem{{
+		Plug:      interfaces.PlugRef{Snap: "consumer", Name: "lonely"},
+		Interface: "test2",
+		Reason:    `no slots of the "test2" interface are available`,
+	}, {
+		Plug:       interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		Interface:  "test",
+		Candidates: []interfaces.SlotRef{{Snap: "producer", Name: "slot"}},
+		Reason:     "auto-connection is allowed but did not happen",
+	}})
+}
@@ -0,0 +1,65 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package overlord
+
+import (
+	"github.com/snapcore/snapd/metrics"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var (
+	changeDuration = metrics.NewHistogram("snapd_change_duration_seconds",
+		"Time taken by changes to become ready by change kind and final status.",
+		metrics.DefaultDurationBuckets, "kind", "status")
+	refreshOutcomes = metrics.NewCounter("snapd_refreshes_total",
+		"Number of completed refresh changes by change kind and outcome.",
+		"kind", "outcome")
+	taskRetries = metrics.NewCounter("snapd_task_retries_total",
+		"Number of times tasks asked to be retried by task kind.", "kind")
+)
+
+// refreshChangeKinds are the kinds of the changes refreshing snaps.
+var refreshChangeKinds = map[string]bool{
+	"refresh-snap": true,
+	"auto-refresh": true,
+}
+
+// observeChangeReady records the metrics of changes that just became ready.
+func observeChangeReady(chg *state.Change, old, new state.Status) {
+	if old.Ready() || !new.Ready() {
+		return
+	}
+	kind := chg.Kind()
+	duration := chg.ReadyTime().Sub(chg.SpawnTime())
+	changeDuration.Observe(duration.Seconds(), kind, new.String())
+
+	if refreshChangeKinds[kind] {
+		outcome := "failure"
+		if new == state.DoneStatus {
+			outcome = "success"
+		}
+		refreshOutcomes.Inc(kind, outcome)
+	}
+}
+
+// observeTaskRetry records a task asking to be retried.
+func observeTaskRetry(t *state.Task) {
+	taskRetries.Inc(t.Kind())
+}
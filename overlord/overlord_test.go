@@ -20,6 +20,7 @@
 package overlord_test
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -33,13 +34,16 @@ import (
 	"testing"
 	"time"
 
+	"golang.org/x/crypto/sha3"
 	. "gopkg.in/check.v1"
 	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate"
@@ -149,6 +153,100 @@ func (ovs *overlordSuite) TestNew(c *C) {
 	c.Check(sto.(*store.Store).CacheDownloads(), Equals, 5)
 }
 
+func (ovs *overlordSuite) TestChangeMetrics(c *C) {
+	restore := patch.Mock(42, 2, nil)
+	defer restore()
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+	o.InterfaceManager().DisableUDevMonitor()
+
+	st := o.State()
+	st.Lock()
+	defer st.Unlock()
+
+	durationsBefore := overlord.ChangeDuration.Count("auto-refresh", "Done")
+	successesBefore := overlord.RefreshOutcomes.Value("auto-refresh", "success")
+	failuresBefore := overlord.RefreshOutcomes.Value("auto-refresh", "failure")
+	installsBefore := overlord.ChangeDuration.Count("install-snap", "Error")
+
+	// the overlord observes changes becoming ready
+	chg := st.NewChange("auto-refresh", "...")
+	chg.SetStatus(state.DoneStatus)
+	c.Check(overlord.ChangeDuration.Count("auto-refresh", "Done")-durationsBefore, Equals, uint64(1))
+	c.Check(overlord.RefreshOutcomes.Value("auto-refresh", "success")-successesBefore, Equals, 1.0)
+
+	// changes that were already ready or are not ready are not counted
+	overlord.ObserveChangeReady(chg, state.DoneStatus, state.ErrorStatus)
+	overlord.ObserveChangeReady(chg, state.DefaultStatus, state.DoingStatus)
+	c.Check(overlord.ChangeDuration.Count("auto-refresh", "Done")-durationsBefore, Equals, uint64(1))
+	c.Check(overlord.RefreshOutcomes.Value("auto-refresh", "failure"), Equals, failuresBefore)
+
+	chg = st.NewChange("auto-refresh", "...")
+	overlord.ObserveChangeReady(chg, state.DoingStatus, state.UndoneStatus)
+	c.Check(overlord.RefreshOutcomes.Value("auto-refresh", "failure")-failuresBefore, Equals, 1.0)
+
+	// only refreshes have outcomes
+	chg = st.NewChange("install-snap", "...")
+	overlord.ObserveChangeReady(chg, state.DoingStatus, state.ErrorStatus)
+	c.Check(overlord.ChangeDuration.Count("install-snap", "Error")-installsBefore, Equals, uint64(1))
+	c.Check(overlord.RefreshOutcomes.Value("install-snap", "failure"), Equals, 0.0)
+}
+
+func (ovs *overlordSuite) TestTaskRetryMetrics(c *C) {
+	restore := patch.Mock(42, 2, nil)
+	defer restore()
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+	o.InterfaceManager().DisableUDevMonitor()
+
+	calls := 0
+	o.TaskRunner().AddHandler("retry-once", func(t *state.Task, _ *tomb.Tomb) error {
+		calls++
+		if calls == 1 {
+			return &state.Retry{}
+		}
+		return nil
+	}, nil)
+	retriesBefore := overlord.TaskRetries.Value("retry-once")
+
+	st := o.State()
+	st.Lock()
+	chg := st.NewChange("retry", "...")
+	chg.AddTask(st.NewTask("retry-once", "..."))
+	st.Unlock()
+
+	for i := 0; i < 2; i++ {
+		c.Assert(o.TaskRunner().Ensure(), IsNil)
+		o.TaskRunner().Wait()
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(calls, Equals, 2)
+	c.Check(overlord.TaskRetries.Value("retry-once")-retriesBefore, Equals, 1.0)
+}
+
+func (ovs *overlordSuite) TestTaskTimeout(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t := st.NewTask("run-hook", "...")
+	c.Check(overlord.TaskTimeout(t), Equals, time.Duration(0))
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "tasks.timeout.run-hook", "90m")
+	tr.Set("core", "tasks.timeout.link-snap", "bogus")
+	tr.Commit()
+
+	c.Check(overlord.TaskTimeout(t), Equals, 90*time.Minute)
+	c.Check(overlord.TaskTimeout(st.NewTask("link-snap", "...")), Equals, time.Duration(0))
+	c.Check(overlord.TaskTimeout(st.NewTask("mount-snap", "...")), Equals, time.Duration(0))
+}
+
 func (ovs *overlordSuite) TestNewStore(c *C) {
 	// this is a shallow test, the deep testing happens in the
 	// remodeling tests in managers_test.go
@@ -862,6 +960,134 @@ func (ovs *overlordSuite) TestCheckpoint(c *C) {
 	c.Check(dirs.SnapStateFile, testutil.FileContains, `"mark":1`)
 }
 
+func (ovs *overlordSuite) TestCheckpointKeepsChecksumAndBackup(c *C) {
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	s := o.State()
+	s.Lock()
+	s.Set("mark", 1)
+	s.Unlock()
+
+	data, err := os.ReadFile(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	sum := sha3.Sum384(data)
+	c.Check(dirs.SnapStateFile+".sha3-384", testutil.FileEquals, hex.EncodeToString(sum[:]))
+
+	s.Lock()
+	s.Set("mark", 2)
+	s.Unlock()
+
+	// the previous state is kept as backup along with its checksum
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"mark":2`)
+	c.Check(dirs.SnapStateFile+".bak", testutil.FileEquals, string(data))
+	c.Check(dirs.SnapStateFile+".bak.sha3-384", testutil.FileEquals, hex.EncodeToString(sum[:]))
+	data, err = os.ReadFile(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	sum = sha3.Sum384(data)
+	c.Check(dirs.SnapStateFile+".sha3-384", testutil.FileEquals, hex.EncodeToString(sum[:]))
+}
+
+func (ovs *overlordSuite) writeStateWithChecksum(c *C, path string, data []byte) {
+	err := os.WriteFile(path, data, 0600)
+	c.Assert(err, IsNil)
+	sum := sha3.Sum384(data)
+	err = os.WriteFile(path+".sha3-384", []byte(hex.EncodeToString(sum[:])), 0600)
+	c.Assert(err, IsNil)
+}
+
+func stateData(some string) []byte {
+	return []byte(fmt.Sprintf(`{"data":{"patch-level":%d,"patch-sublevel":%d,"some":%q},"changes":null,"tasks":null,"last-change-id":0,"last-task-id":0,"last-lane-id":0}`, patch.Level, patch.Sublevel, some))
+}
+
+func (ovs *overlordSuite) TestNewWithChecksumMismatchRestoresBackup(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	ovs.writeStateWithChecksum(c, dirs.SnapStateFile+".bak", stateData("data"))
+	ovs.writeStateWithChecksum(c, dirs.SnapStateFile, stateData("other"))
+	// modify the content under the checksum
+	err := os.WriteFile(dirs.SnapStateFile, stateData("flipped"), 0600)
+	c.Assert(err, IsNil)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	st := o.State()
+	st.Lock()
+	defer st.Unlock()
+	var some string
+	c.Assert(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "data")
+
+	c.Check(logbuf.String(), testutil.Contains, fmt.Sprintf("WARNING: restoring the state from its backup, the state file is corrupted: state file %s does not match its checksum", dirs.SnapStateFile))
+	c.Check(dirs.SnapStateFile+".corrupt", testutil.FileEquals, string(stateData("flipped")))
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"some":"data"`)
+}
+
+func (ovs *overlordSuite) TestNewWithChecksumMismatchAndBackup(c *C) {
+	ovs.writeStateWithChecksum(c, dirs.SnapStateFile+".bak", stateData("data"))
+	err := os.WriteFile(dirs.SnapStateFile+".bak", stateData("flipped"), 0600)
+	c.Assert(err, IsNil)
+	ovs.writeStateWithChecksum(c, dirs.SnapStateFile, stateData("other"))
+	err = os.WriteFile(dirs.SnapStateFile, stateData("flipped"), 0600)
+	c.Assert(err, IsNil)
+
+	_, err = overlord.New(nil)
+	c.Assert(err, ErrorMatches, fmt.Sprintf("state file %s does not match its checksum", dirs.SnapStateFile))
+	c.Check(dirs.SnapStateFile, testutil.FileEquals, string(stateData("flipped")))
+}
+
+func (ovs *overlordSuite) TestNewWithChecksumRemovedUsesStateFile(c *C) {
+	ovs.writeStateWithChecksum(c, dirs.SnapStateFile+".bak", stateData("data"))
+	// edited by hand, dropping the checksum
+	err := os.WriteFile(dirs.SnapStateFile, stateData("edited"), 0600)
+	c.Assert(err, IsNil)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	st := o.State()
+	st.Lock()
+	defer st.Unlock()
+	var some string
+	c.Assert(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "edited")
+	c.Check(dirs.SnapStateFile+".corrupt", testutil.FileAbsent)
+}
+
+func (ovs *overlordSuite) TestNewWithUndecodableStateRestoresBackup(c *C) {
+	good := []byte(fmt.Sprintf(`{"data":{"patch-level":%d,"patch-sublevel":%d,"some":"data"},"changes":null,"tasks":null,"last-change-id":0,"last-task-id":0,"last-lane-id":0}`, patch.Level, patch.Sublevel))
+	ovs.writeStateWithChecksum(c, dirs.SnapStateFile+".bak", good)
+	// no checksum, as when written by an older snapd
+	err := os.WriteFile(dirs.SnapStateFile, []byte(`{"data":`), 0600)
+	c.Assert(err, IsNil)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	st := o.State()
+	st.Lock()
+	defer st.Unlock()
+	var some string
+	c.Assert(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "data")
+
+	c.Check(dirs.SnapStateFile+".corrupt", testutil.FileEquals, `{"data":`)
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"some":"data"`)
+}
+
+func (ovs *overlordSuite) TestNewWithCorruptedStateAndBackup(c *C) {
+	err := os.WriteFile(dirs.SnapStateFile, []byte(`{"data":{"x":1}`), 0600)
+	c.Assert(err, IsNil)
+	err = os.WriteFile(dirs.SnapStateFile+".bak", []byte(`{"data":{"y":1}`), 0600)
+	c.Assert(err, IsNil)
+
+	_, err = overlord.New(nil)
+	c.Assert(err, ErrorMatches, "cannot read state: unexpected EOF")
+	c.Check(dirs.SnapStateFile, testutil.FileEquals, `{"data":{"x":1}`)
+}
+
 type sampleManager struct {
 	ensureCallback func()
 }
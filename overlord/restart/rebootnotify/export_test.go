@@ -0,0 +1,70 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package rebootnotify
+
+import (
+	"time"
+
+	"github.com/snapcore/snapd/testutil"
+)
+
+var (
+	NotifyRebootRequired = notifyRebootRequired
+	MotdFile             = motdFile
+)
+
+type BackendConfig = backendConfig
+
+func MockBackends(m map[string]func(ev *Event, cfg *BackendConfig) error) (restore func()) {
+	r := testutil.Backup(&backends)
+	backends = make(map[string]backend, len(m))
+	for name, f := range m {
+		backends[name] = f
+	}
+	return r
+}
+
+func MockDispatchSync() (restore func()) {
+	r := testutil.Backup(&dispatch)
+	dispatch = func(f func()) { f() }
+	return r
+}
+
+func MockTimeNow(t time.Time) (restore func()) {
+	r := testutil.Backup(&timeNow)
+	timeNow = func() time.Time { return t }
+	return r
+}
+
+func MockWallCmd(cmd string) (restore func()) {
+	r := testutil.Backup(&wallCmd)
+	wallCmd = cmd
+	return r
+}
+
+var (
+	NotifyWall    = notifyWall
+	NotifyMotd    = notifyMotd
+	NotifyWebhook = notifyWebhook
+)
+
+func (cfg *BackendConfig) SetWebhookURL(u string) {
+	cfg.webhookURL = u
+}
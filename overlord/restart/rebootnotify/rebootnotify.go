@@ -0,0 +1,186 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package rebootnotify dispatches notifications about required system
+// restarts to the backends configured via system.reboot-required.notify.
+package rebootnotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/restart"
+	"github.com/snapcore/snapd/overlord/state"
+	userclient "github.com/snapcore/snapd/usersession/client"
+)
+
+func init() {
+	restart.AddRebootRequiredParticipant(restart.RebootRequiredParticipantFunc(notifyRebootRequired))
+}
+
+// Event describes a change that requires a system restart to continue.
+type Event struct {
+	Event      string    `json:"event"`
+	ChangeID   string    `json:"change-id"`
+	ChangeKind string    `json:"change-kind"`
+	SnapName   string    `json:"snap-name,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+func (ev *Event) message() string {
+	if ev.SnapName == "" || ev.SnapName == "snapd" {
+		return fmt.Sprintf("A system restart is required to complete the %s change %s.", ev.ChangeKind, ev.ChangeID)
+	}
+	return fmt.Sprintf("A system restart is required to complete the %s change %s of %q.", ev.ChangeKind, ev.ChangeID, ev.SnapName)
+}
+
+// backendConfig is the configuration passed to the backends.
+type backendConfig struct {
+	webhookURL string
+}
+
+type backend func(ev *Event, cfg *backendConfig) error
+
+var backends = map[string]backend{
+	"wall":    notifyWall,
+	"desktop": notifyDesktop,
+	"motd":    notifyMotd,
+	"webhook": notifyWebhook,
+}
+
+var timeNow = time.Now
+
+// dispatch runs the backends; it is done in a go-routine as some of
+// them are potentially slow and the state is locked when called.
+var dispatch = func(f func()) {
+	go f()
+}
+
+func notifyRebootRequired(chg *state.Change, snapName string) {
+	tr := config.NewTransaction(chg.State())
+	var methods, webhookURL string
+	if err := tr.Get("core", "system.reboot-required.notify", &methods); err != nil && !config.IsNoOption(err) {
+		logger.Noticef("cannot get system.reboot-required.notify: %v", err)
+		return
+	}
+	if methods == "" {
+		return
+	}
+	if err := tr.Get("core", "system.reboot-required.webhook-url", &webhookURL); err != nil && !config.IsNoOption(err) {
+		logger.Noticef("cannot get system.reboot-required.webhook-url: %v", err)
+		return
+	}
+
+	ev := &Event{
+		Event:      "reboot-required",
+		ChangeID:   chg.ID(),
+		ChangeKind: chg.Kind(),
+		SnapName:   snapName,
+		Time:       timeNow(),
+	}
+	cfg := &backendConfig{webhookURL: webhookURL}
+
+	var enabled []string
+	for _, name := range strings.Split(methods, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := backends[name]; !ok {
+			// validated by configcore, but be robust
+			logger.Noticef("unknown reboot required notification method %q", name)
+			continue
+		}
+		enabled = append(enabled, name)
+	}
+
+	dispatch(func() {
+		for _, name := range enabled {
+			if err := backends[name](ev, cfg); err != nil {
+				logger.Noticef("cannot notify about required reboot via %s: %v", name, err)
+			}
+		}
+	})
+}
+
+var wallCmd = "wall"
+
+func notifyWall(ev *Event, cfg *backendConfig) error {
+	output, err := exec.Command(wallCmd, ev.message()).CombinedOutput()
+	if err != nil {
+		return osutil.OutputErr(output, err)
+	}
+	return nil
+}
+
+func notifyDesktop(ev *Event, cfg *backendConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	info := &userclient.RebootRequiredInfo{InstanceName: ev.SnapName}
+	return userclient.New().RebootRequiredNotification(ctx, info)
+}
+
+// motdFile returns the path of the message of the day fragment. It lives
+// under /run so that it goes away once the system has been restarted.
+func motdFile() string {
+	return filepath.Join(dirs.GlobalRootDir, "/run/motd.d/90-snapd-reboot-required")
+}
+
+func notifyMotd(ev *Event, cfg *backendConfig) error {
+	if err := os.MkdirAll(filepath.Dir(motdFile()), 0755); err != nil {
+		return err
+	}
+	content := fmt.Sprintf("*** System restart required ***\n%s\n", ev.message())
+	return osutil.AtomicWriteFile(motdFile(), []byte(content), 0644, 0)
+}
+
+var webhookTimeout = 10 * time.Second
+
+func notifyWebhook(ev *Event, cfg *backendConfig) error {
+	if cfg.webhookURL == "" {
+		return fmt.Errorf("system.reboot-required.webhook-url is not set")
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	cli := httputil.NewHTTPClient(&httputil.ClientOptions{
+		Timeout: webhookTimeout,
+		Proxy:   http.ProxyFromEnvironment,
+	})
+	resp, err := cli.Post(cfg.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status from webhook: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package rebootnotify_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/restart/rebootnotify"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func TestRebootNotify(t *testing.T) { TestingT(t) }
+
+type rebootNotifySuite struct {
+	testutil.BaseTest
+
+	st  *state.State
+	now time.Time
+}
+
+var _ = Suite(&rebootNotifySuite{})
+
+func (s *rebootNotifySuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.st = state.New(nil)
+	s.now = time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(rebootnotify.MockTimeNow(s.now))
+	s.AddCleanup(rebootnotify.MockDispatchSync())
+}
+
+func (s *rebootNotifySuite) setConfig(c *C, key string, value interface{}) {
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", key, value), IsNil)
+	tr.Commit()
+}
+
+func (s *rebootNotifySuite) TestNotifyRebootRequiredNotConfigured(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	called := false
+	restore := rebootnotify.MockBackends(map[string]func(*rebootnotify.Event, *rebootnotify.BackendConfig) error{
+		"wall": func(*rebootnotify.Event, *rebootnotify.BackendConfig) error {
+			called = true
+			return nil
+		},
+	})
+	defer restore()
+
+	chg := s.st.NewChange("refresh-snap", "...")
+	rebootnotify.NotifyRebootRequired(chg, "pc-kernel")
+	c.Check(called, Equals, false)
+}
+
+func (s *rebootNotifySuite) TestNotifyRebootRequiredDispatches(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	var calls []string
+	var events []*rebootnotify.Event
+	mockBackend := func(name string) func(*rebootnotify.Event, *rebootnotify.BackendConfig) error {
+		return func(ev *rebootnotify.Event, cfg *rebootnotify.BackendConfig) error {
+			calls = append(calls, name)
+			events = append(events, ev)
+			return nil
+		}
+	}
+	restore := rebootnotify.MockBackends(map[string]func(*rebootnotify.Event, *rebootnotify.BackendConfig) error{
+		"wall":    mockBackend("wall"),
+		"motd":    mockBackend("motd"),
+		"desktop": mockBackend("desktop"),
+	})
+	defer restore()
+
+	s.setConfig(c, "system.reboot-required.notify", "motd, wall")
+
+	chg := s.st.NewChange("refresh-snap", "...")
+	rebootnotify.NotifyRebootRequired(chg, "pc-kernel")
+	c.Check(calls, DeepEquals, []string{"motd", "wall"})
+	c.Assert(events, HasLen, 2)
+	c.Check(events[0], DeepEquals, &rebootnotify.Event{
+		Event:      "reboot-required",
+		ChangeID:   chg.ID(),
+		ChangeKind: "refresh-snap",
+		SnapName:   "pc-kernel",
+		Time:       s.now,
+	})
+}
+
+func (s *rebootNotifySuite) TestNotifyWall(c *C) {
+	cmd := testutil.MockCommand(c, "wall", "")
+	defer cmd.Restore()
+	restore := rebootnotify.MockWallCmd(cmd.Exe())
+	defer restore()
+
+	ev := &rebootnotify.Event{ChangeID: "1", ChangeKind: "refresh-snap", SnapName: "pc-kernel"}
+	c.Assert(rebootnotify.NotifyWall(ev, &rebootnotify.BackendConfig{}), IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"wall", `A system restart is required to complete the refresh-snap change 1 of "pc-kernel".`},
+	})
+}
+
+func (s *rebootNotifySuite) TestNotifyMotd(c *C) {
+	ev := &rebootnotify.Event{ChangeID: "1", ChangeKind: "refresh-snap", SnapName: "snapd"}
+	c.Assert(rebootnotify.NotifyMotd(ev, &rebootnotify.BackendConfig{}), IsNil)
+	c.Check(rebootnotify.MotdFile(), testutil.FileEquals, `*** System restart required ***
+A system restart is required to complete the refresh-snap change 1.
+`)
+}
+
+func (s *rebootNotifySuite) TestNotifyWebhook(c *C) {
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.Header.Get("Content-Type"), Equals, "application/json")
+		body, err := ioutil.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		var ev rebootnotify.Event
+		c.Assert(json.Unmarshal(body, &ev), IsNil)
+		c.Check(ev.Event, Equals, "reboot-required")
+		c.Check(ev.SnapName, Equals, "pc-kernel")
+	}))
+	defer srv.Close()
+
+	ev := &rebootnotify.Event{Event: "reboot-required", ChangeID: "1", ChangeKind: "refresh-snap", SnapName: "pc-kernel"}
+	cfg := &rebootnotify.BackendConfig{}
+	c.Check(rebootnotify.NotifyWebhook(ev, cfg), ErrorMatches, "system.reboot-required.webhook-url is not set")
+
+	cfg.SetWebhookURL(srv.URL)
+	c.Assert(rebootnotify.NotifyWebhook(ev, cfg), IsNil)
+	c.Check(n, Equals, 1)
+}
+
+func (s *rebootNotifySuite) TestNotifyWebhookError(c *C) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer srv.Close()
+
+	cfg := &rebootnotify.BackendConfig{}
+	cfg.SetWebhookURL(srv.URL)
+	err := rebootnotify.NotifyWebhook(&rebootnotify.Event{}, cfg)
+	c.Check(err, ErrorMatches, "unexpected status from webhook: 500 Internal Server Error")
+}
@@ -333,9 +333,27 @@ func Request(st *state.State, t RestartType, rebootInfo *boot.RebootInfo) {
 		st.Set("system-restart-from-boot-id", rm.bootID)
 	}
 	rm.restarting = t
+	addMaintenanceNotice(st, t)
 	rm.handleRestart(t, rebootInfo)
 }
 
+// addMaintenanceNotice records a maintenance notice for clients waiting
+// for notices, before snapd goes down for the requested restart.
+func addMaintenanceNotice(st *state.State, t RestartType) {
+	var key string
+	switch t {
+	case RestartSystem, RestartSystemNow, RestartSystemHaltNow, RestartSystemPoweroffNow:
+		key = "system-restart"
+	case RestartDaemon, RestartSocket:
+		key = "daemon-restart"
+	default:
+		return
+	}
+	if _, err := st.AddNotice(state.MaintenanceNotice, key, nil); err != nil {
+		logger.Noticef("cannot record maintenance notice: %v", err)
+	}
+}
+
 func setWaitForSystemRestart(chg *state.Change) {
 	if chg == nil {
 		// nothing to do
@@ -520,6 +538,24 @@ func PendingForChange(st *state.State, chg *state.Change) bool {
 	return rm.pendingForSystemRestart(chg)
 }
 
+// ChangesPendingSystemRestart returns the changes that have tasks waiting
+// for a manual system restart. It returns nil if the RestartManager was not
+// initialized.
+func ChangesPendingSystemRestart(st *state.State) []*state.Change {
+	cached := st.Cached(restartManagerKey{})
+	if cached == nil {
+		return nil
+	}
+	rm := cached.(*RestartManager)
+	var chgs []*state.Change
+	for _, chg := range st.Changes() {
+		if rm.pendingForSystemRestart(chg) {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
 // TaskWaitForRestart can be used for tasks that need to wait for a pending
 // restart to occur, meant to be used in conjunction with PendingForChange.
 // The task will then be re-run after the restart has occurred.
@@ -550,6 +586,40 @@ func isStatusThatCanNeedRestart(status state.Status) bool {
 	}
 }
 
+// RebootRequiredParticipant is an interface for being notified when a
+// change requires a system restart to continue.
+type RebootRequiredParticipant interface {
+	// RebootRequired is called with the state locked when the given
+	// change, operating on the given snap, requires a system restart.
+	RebootRequired(chg *state.Change, snapName string)
+}
+
+// RebootRequiredParticipantFunc is an adapter from function to
+// RebootRequiredParticipant.
+type RebootRequiredParticipantFunc func(chg *state.Change, snapName string)
+
+func (f RebootRequiredParticipantFunc) RebootRequired(chg *state.Change, snapName string) {
+	f(chg, snapName)
+}
+
+var rebootRequiredParticipants []RebootRequiredParticipant
+
+// AddRebootRequiredParticipant adds a participant to be notified when a
+// change requires a system restart.
+func AddRebootRequiredParticipant(p RebootRequiredParticipant) {
+	rebootRequiredParticipants = append(rebootRequiredParticipants, p)
+}
+
+// MockRebootRequiredParticipants replaces the list of reboot required
+// participants for testing.
+func MockRebootRequiredParticipants(ps []RebootRequiredParticipant) (restore func()) {
+	old := rebootRequiredParticipants
+	rebootRequiredParticipants = ps
+	return func() {
+		rebootRequiredParticipants = old
+	}
+}
+
 // processRestartForChange must only be called from the change status changed event
 // hook.
 func processRestartForChange(chg *state.Change, old, new state.Status) {
@@ -570,6 +640,10 @@ func processRestartForChange(chg *state.Change, old, new state.Status) {
 	// clear out the restart context for this change before restarting
 	chg.Set("pending-system-restart", nil)
 
+	for _, p := range rebootRequiredParticipants {
+		p.RebootRequired(chg, rp.SnapName)
+	}
+
 	// perform the restart
 	if release.OnClassic {
 		// Notify the system that a reboot is required.
@@ -107,6 +107,11 @@ func (s *restartSuite) TestRequestRestartDaemon(c *C) {
 	ok, t = restart.Pending(st)
 	c.Check(ok, Equals, true)
 	c.Check(t, Equals, restart.RestartDaemon)
+
+	notices := st.Notices(nil)
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Type(), Equals, state.MaintenanceNotice)
+	c.Check(notices[0].Key(), Equals, "daemon-restart")
 }
 
 func (s *restartSuite) TestRequestRestartDaemonNoHandler(c *C) {
@@ -147,6 +152,11 @@ func (s *restartSuite) TestRequestRestartSystemAndVerifyReboot(c *C) {
 	c.Check(ok, Equals, true)
 	c.Check(t, Equals, restart.RestartSystem)
 
+	notices := st.Notices(nil)
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Type(), Equals, state.MaintenanceNotice)
+	c.Check(notices[0].Key(), Equals, "system-restart")
+
 	var fromBootID string
 	c.Check(st.Get("system-restart-from-boot-id", &fromBootID), IsNil)
 	c.Check(fromBootID, Equals, "boot-id-1")
@@ -357,6 +367,44 @@ func (s *restartSuite) TestProcessRestartForChangeClassic(c *C) {
 	c.Assert(buf.String(), testutil.Contains, `Postponing restart until a manual system restart allows to continue`)
 }
 
+func (s *restartSuite) TestProcessRestartForChangeRebootRequiredParticipants(c *C) {
+	restore := release.MockOnClassic(true)
+	defer restore()
+
+	type call struct {
+		chgID    string
+		snapName string
+	}
+	var calls []call
+	restore = restart.MockRebootRequiredParticipants([]restart.RebootRequiredParticipant{
+		restart.RebootRequiredParticipantFunc(func(chg *state.Change, snapName string) {
+			calls = append(calls, call{chg.ID(), snapName})
+		}),
+	})
+	defer restore()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, err := restart.Manager(st, "boot-id-1", nil)
+	c.Assert(err, IsNil)
+
+	chg := st.NewChange("test", "...")
+	t := st.NewTask("waiting", "...")
+	chg.AddTask(t)
+
+	// no pending restart, no notification
+	restart.ProcessRestartForChange(chg, state.DefaultStatus, state.DoneStatus)
+	c.Check(calls, HasLen, 0)
+
+	err = restart.FinishTaskWithRestart(t, state.DoneStatus, restart.RestartSystem, "some-snap", nil)
+	c.Assert(err, IsNil)
+
+	restart.ProcessRestartForChange(chg, state.DefaultStatus, state.DoneStatus)
+	c.Check(calls, DeepEquals, []call{{chg.ID(), "some-snap"}})
+}
+
 func (s *restartSuite) TestProcessRestartForChangeCore(c *C) {
 	restore := release.MockOnClassic(false)
 	defer restore()
@@ -571,6 +619,32 @@ func (s *restartSuite) TestPendingForChange(c *C) {
 	c.Check(restart.PendingForChange(st, chg2), Equals, true)
 }
 
+func (s *restartSuite) TestChangesPendingSystemRestart(c *C) {
+	st := state.New(nil)
+
+	st.Lock()
+	defer st.Unlock()
+
+	// no manager yet
+	c.Check(restart.ChangesPendingSystemRestart(st), HasLen, 0)
+
+	_, err := restart.Manager(st, "boot-id-1", nil)
+	c.Assert(err, IsNil)
+
+	chg1 := st.NewChange("pending", "...")
+	chg1.Set("wait-for-system-restart", true)
+	t1 := st.NewTask("task", "...")
+	chg1.AddTask(t1)
+	t1.SetToWait(state.DoneStatus)
+	t1.Set("wait-for-system-restart-from-boot-id", "boot-id-1")
+
+	chg2 := st.NewChange("not-pending", "...")
+	t2 := st.NewTask("task", "...")
+	chg2.AddTask(t2)
+
+	c.Check(restart.ChangesPendingSystemRestart(st), DeepEquals, []*state.Change{chg1})
+}
+
 func (s *restartSuite) TestPendingForChangeWaitTasks(c *C) {
 	st := state.New(nil)
 
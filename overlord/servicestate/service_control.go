@@ -59,6 +59,10 @@ type ServiceAction struct {
 	// "reload-or-restart" actions, and when set it restarts also enabled
 	// non-running services, otherwise these services are left inactive.
 	RestartEnabledNonActive bool `json:"restart-enabled-non-active,omitempty"`
+	// RestartFailedOnly is only for "restart" and "reload-or-restart"
+	// actions, and when set only the services in the failed state are
+	// restarted.
+	RestartFailedOnly bool `json:"restart-failed-only,omitempty"`
 }
 
 func (m *ServiceManager) doServiceControl(t *state.Task, _ *tomb.Tomb) error {
@@ -114,6 +118,16 @@ func (m *ServiceManager) doServiceControl(t *state.Task, _ *tomb.Tomb) error {
 		if err != nil {
 			return err
 		}
+	} else {
+		// stop the services in the reverse order of their startup, so
+		// that services are stopped before the ones they depend on;
+		// stopping must not fail because of a broken ordering though
+		if ordered, err := snap.SortServices(services); err == nil {
+			services = make([]*snap.AppInfo, 0, len(ordered))
+			for i := len(ordered) - 1; i >= 0; i-- {
+				services = append(services, ordered[i])
+			}
+		}
 	}
 
 	// ExplicitServices are snap app names; obtain names of systemd units
@@ -177,12 +191,12 @@ func (m *ServiceManager) doServiceControl(t *state.Task, _ *tomb.Tomb) error {
 		}
 	case "restart":
 		st.Unlock()
-		err := wrappers.RestartServices(startupOrdered, explicitServicesSystemdUnits, &wrappers.RestartServicesFlags{AlsoEnabledNonActive: sc.RestartEnabledNonActive}, meter, perfTimings)
+		err := wrappers.RestartServices(startupOrdered, explicitServicesSystemdUnits, &wrappers.RestartServicesFlags{AlsoEnabledNonActive: sc.RestartEnabledNonActive, OnlyFailed: sc.RestartFailedOnly}, meter, perfTimings)
 		st.Lock()
 		return err
 	case "reload-or-restart":
 		st.Unlock()
-		err := wrappers.RestartServices(startupOrdered, explicitServicesSystemdUnits, &wrappers.RestartServicesFlags{Reload: true, AlsoEnabledNonActive: sc.RestartEnabledNonActive}, meter, perfTimings)
+		err := wrappers.RestartServices(startupOrdered, explicitServicesSystemdUnits, &wrappers.RestartServicesFlags{Reload: true, AlsoEnabledNonActive: sc.RestartEnabledNonActive, OnlyFailed: sc.RestartFailedOnly}, meter, perfTimings)
 		st.Lock()
 		return err
 	default:
@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/snapcore/snapd/wrappers"
+)
+
+// ServiceOverridesOption is the system option under which the overrides
+// of the unit settings of snap services are configured, keyed by snap
+// instance and app name, e.g. service-overrides.<snap>.<app>.nice.
+const ServiceOverridesOption = "service-overrides"
+
+// ConfGetter is the subset of a configuration transaction needed to
+// read the service overrides.
+type ConfGetter interface {
+	GetMaybe(snapName, key string, result interface{}) error
+}
+
+// SnapServiceOverrides returns the validated overrides of the unit
+// settings of the services of the given snap, keyed by app name.
+func SnapServiceOverrides(tr ConfGetter, instanceName string) (map[string]*wrappers.ServiceOverrides, error) {
+	// the whole document is read as the instance key separator of
+	// parallel instances is not valid in option names
+	var all map[string]json.RawMessage
+	if err := tr.GetMaybe("core", ServiceOverridesOption, &all); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", ServiceOverridesOption, err)
+	}
+	data, ok := all[instanceName]
+	if !ok {
+		return nil, nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid %s.%s: %v", ServiceOverridesOption, instanceName, err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	overrides := make(map[string]*wrappers.ServiceOverrides, len(raw))
+	for appName, data := range raw {
+		var o wrappers.ServiceOverrides
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&o); err != nil {
+			return nil, fmt.Errorf("invalid %s.%s.%s: %v", ServiceOverridesOption, instanceName, appName, err)
+		}
+		if err := o.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s.%s.%s: %v", ServiceOverridesOption, instanceName, appName, err)
+		}
+		overrides[appName] = &o
+	}
+	return overrides, nil
+}
@@ -28,6 +28,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
@@ -212,6 +213,22 @@ func delayedCrossMgrInit() {
 	snapstate.RegisterAffectedSnapsByAttr("service-action", serviceControlAffectedSnaps)
 	snapstate.SnapServiceOptions = SnapServiceOptions
 	snapstate.EnsureSnapAbsentFromQuotaGroup = EnsureSnapAbsentFromQuota
+	snapstate.DisableSnapServices = disableSnapServices
+}
+
+// disableSnapServices stops and disables the given services, for use by
+// snapstate, e.g. when the store withdrew the snap providing them.
+func disableSnapServices(st *state.State, svcs []*snap.AppInfo) ([]*state.TaskSet, error) {
+	names := make([]string, len(svcs))
+	for i, app := range svcs {
+		names[i] = app.String()
+	}
+	inst := &Instruction{
+		Action:      "stop",
+		Names:       names,
+		StopOptions: client.StopOptions{Disable: true},
+	}
+	return Control(st, svcs, inst, nil, nil)
 }
 
 func serviceControlAffectedSnaps(t *state.Task) ([]string, error) {
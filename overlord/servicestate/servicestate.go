@@ -20,6 +20,7 @@
 package servicestate
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -29,6 +30,7 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/cmdstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
@@ -115,6 +117,7 @@ func serviceControlTs(st *state.State, appInfos []*snap.AppInfo, inst *Instructi
 			}
 		case inst.Action == "restart":
 			cmd.RestartEnabledNonActive = true
+			cmd.RestartFailedOnly = inst.Failed
 			if inst.Reload {
 				cmd.Action = "reload-or-restart"
 			} else {
@@ -139,7 +142,11 @@ func serviceControlTs(st *state.State, appInfos []*snap.AppInfo, inst *Instructi
 		} else if inst.Action == "restart" {
 			// Use a generic message, since we cannot know the exact list of
 			// services affected
-			summary = fmt.Sprintf("Run service command %q for running services of snap %q", cmd.Action, cmd.SnapName)
+			if inst.Failed {
+				summary = fmt.Sprintf("Run service command %q for failed services of snap %q", cmd.Action, cmd.SnapName)
+			} else {
+				summary = fmt.Sprintf("Run service command %q for running services of snap %q", cmd.Action, cmd.SnapName)
+			}
 		}
 
 		if summary == "" {
@@ -333,6 +340,7 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 		case ".service":
 			appInfo.Enabled = st.Enabled
 			appInfo.Active = st.Active
+			appInfo.Failed = st.Failed
 		case ".timer":
 			appInfo.Activators = append(appInfo.Activators, client.AppActivator{
 				Name:    snapApp.Name,
@@ -406,6 +414,11 @@ func SnapServiceOptions(st *state.State, snapInfo *snap.Info, quotaGroups map[st
 		}
 	}
 
+	opts.ServiceOverrides, err = SnapServiceOverrides(tr, snapInfo.InstanceName())
+	if err != nil {
+		return nil, err
+	}
+
 	// also check for quota group for this instance name
 	for _, grp := range quotaGroups {
 		if strutil.ListContains(grp.Snaps, snapInfo.InstanceName()) {
@@ -417,10 +430,69 @@ func SnapServiceOptions(st *state.State, snapInfo *snap.Info, quotaGroups map[st
 	return opts, nil
 }
 
+// serviceFailureLogLines is how many of the last lines logged by a
+// failed service are reported with its failure.
+var serviceFailureLogLines = 5
+
+// DecorateWithFailure sets the Failure of the given client.AppInfo if the
+// service of the given snap.AppInfo failed the last time it exited or was
+// restarted automatically. Only system services of active snaps are
+// considered. The failure is queried live from systemd and the journal,
+// it is not tracked in the state. If the result of the service cannot be
+// queried an error is returned and Failure is left unset; if only its log
+// cannot be read, the failure is reported without it.
+func (sd *StatusDecorator) DecorateWithFailure(appInfo *client.AppInfo, snapApp *snap.AppInfo) error {
+	if !snapApp.Snap.IsActive() || !snapApp.IsService() || snapApp.DaemonScope != snap.SystemDaemon {
+		return nil
+	}
+	res, err := sd.sysd.ServiceResult(snapApp.ServiceName())
+	if err != nil {
+		return fmt.Errorf("cannot get the result of service %q: %v", appInfo.Name, err)
+	}
+	if !res.Failed() {
+		return nil
+	}
+	failure := &client.AppFailure{
+		Result:   res.Result,
+		ExitCode: res.ExitCode,
+		Restarts: res.Restarts,
+	}
+	if res.Result != "success" {
+		failure.Log, err = lastLogLines(snapApp, serviceFailureLogLines)
+		if err != nil {
+			logger.Noticef("%v", err)
+		}
+	}
+	appInfo.Failure = failure
+	return nil
+}
+
+func lastLogLines(snapApp *snap.AppInfo, n int) ([]string, error) {
+	reader, err := LogReader([]*snap.AppInfo{snapApp}, &systemd.LogOptions{N: n})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the log of service %q: %v", snapApp.Name, err)
+	}
+	defer reader.Close()
+
+	var lines []string
+	dec := json.NewDecoder(reader)
+	for {
+		var log systemd.Log
+		if err := dec.Decode(&log); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("cannot read the log of service %q: %v", snapApp.Name, err)
+		}
+		lines = append(lines, log.Message())
+	}
+	return lines, nil
+}
+
 // LogReader returns an io.ReadCloser which produce logs for the provided
 // snap AppInfo's. It is a convenience wrapper around the systemd.LogReader
-// implementation.
-func LogReader(appInfos []*snap.AppInfo, n int, follow bool) (io.ReadCloser, error) {
+// implementation, the Namespaces option is set as needed.
+func LogReader(appInfos []*snap.AppInfo, opts *systemd.LogOptions) (io.ReadCloser, error) {
 	serviceNames := make([]string, len(appInfos))
 	for i, appInfo := range appInfos {
 		if !appInfo.IsService() {
@@ -439,6 +511,8 @@ func LogReader(appInfos []*snap.AppInfo, n int, follow bool) (io.ReadCloser, err
 		return nil, fmt.Errorf("cannot get systemd version: %v", err)
 	}
 
+	logOpts := *opts
+	logOpts.Namespaces = includeNamespaces
 	sysd := systemd.New(systemd.SystemMode, progress.Null)
-	return sysd.LogReader(serviceNames, n, follow, includeNamespaces)
+	return sysd.LogReader(serviceNames, &logOpts)
 }
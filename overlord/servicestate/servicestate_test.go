@@ -33,6 +33,7 @@ import (
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
@@ -50,6 +51,112 @@ type statusDecoratorSuite struct{}
 
 var _ = Suite(&statusDecoratorSuite{})
 
+func (s *statusDecoratorSuite) TestDecorateWithFailure(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+	snp := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(1),
+		},
+	}
+	c.Assert(os.MkdirAll(snp.MountDir(), 0755), IsNil)
+	c.Assert(os.Symlink(snp.Revision.String(), filepath.Join(filepath.Dir(snp.MountDir()), "current")), IsNil)
+
+	results := map[string]string{
+		"snap.foo.ok.service":        "Result=success\nExecMainStatus=0\nNRestarts=0\n",
+		"snap.foo.restarted.service": "Result=success\nExecMainStatus=0\nNRestarts=2\n",
+		"snap.foo.failed.service":    "Result=exit-code\nExecMainStatus=1\nNRestarts=5\n",
+	}
+	r := systemd.MockSystemctl(func(args ...string) (buf []byte, err error) {
+		c.Assert(args, HasLen, 3)
+		c.Check(args[:2], DeepEquals, []string{"show", "--property=Result,ExecMainStatus,NRestarts"})
+		return []byte(results[args[2]]), nil
+	})
+	defer r()
+	r = systemd.MockSystemdVersion(245, nil)
+	defer r()
+	var jctlCalls int
+	r = systemd.MockJournalctl(func(svcs []string, opts *systemd.LogOptions) (io.ReadCloser, error) {
+		jctlCalls++
+		c.Check(svcs, DeepEquals, []string{"snap.foo.failed.service"})
+		c.Check(opts, DeepEquals, &systemd.LogOptions{N: 5, Namespaces: true})
+		return ioutil.NopCloser(strings.NewReader(`{"MESSAGE": "starting"}
+{"MESSAGE": "cannot open config"}
+`)), nil
+	})
+	defer r()
+
+	sd := servicestate.NewStatusDecorator(nil)
+
+	for _, t := range []struct {
+		name    string
+		failure *client.AppFailure
+	}{
+		{"ok", nil},
+		{"restarted", &client.AppFailure{Result: "success", Restarts: 2}},
+		{"failed", &client.AppFailure{
+			Result:   "exit-code",
+			ExitCode: 1,
+			Restarts: 5,
+			Log:      []string{"starting", "cannot open config"},
+		}},
+	} {
+		app := &client.AppInfo{Snap: "foo", Name: t.name}
+		snapApp := &snap.AppInfo{Snap: snp, Name: t.name, Daemon: "simple", DaemonScope: snap.SystemDaemon}
+		c.Assert(sd.DecorateWithFailure(app, snapApp), IsNil)
+		c.Check(app.Failure, DeepEquals, t.failure, Commentf(t.name))
+	}
+	c.Check(jctlCalls, Equals, 1)
+
+	// user services and apps are not considered
+	app := &client.AppInfo{Snap: "foo", Name: "user"}
+	snapApp := &snap.AppInfo{Snap: snp, Name: "user", Daemon: "simple", DaemonScope: snap.UserDaemon}
+	c.Assert(sd.DecorateWithFailure(app, snapApp), IsNil)
+	c.Check(app.Failure, IsNil)
+	app = &client.AppInfo{Snap: "foo", Name: "app"}
+	c.Assert(sd.DecorateWithFailure(app, &snap.AppInfo{Snap: snp, Name: "app"}), IsNil)
+	c.Check(app.Failure, IsNil)
+}
+
+func (s *statusDecoratorSuite) TestDecorateWithFailureLogError(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+	snp := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(1),
+		},
+	}
+	c.Assert(os.MkdirAll(snp.MountDir(), 0755), IsNil)
+	c.Assert(os.Symlink(snp.Revision.String(), filepath.Join(filepath.Dir(snp.MountDir()), "current")), IsNil)
+
+	r := systemd.MockSystemctl(func(args ...string) (buf []byte, err error) {
+		return []byte("Result=exit-code\nExecMainStatus=1\nNRestarts=5\n"), nil
+	})
+	defer r()
+	r = systemd.MockSystemdVersion(245, nil)
+	defer r()
+	r = systemd.MockJournalctl(func(svcs []string, opts *systemd.LogOptions) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("no journal")
+	})
+	defer r()
+
+	sd := servicestate.NewStatusDecorator(nil)
+	app := &client.AppInfo{Snap: "foo", Name: "failed"}
+	snapApp := &snap.AppInfo{Snap: snp, Name: "failed", Daemon: "simple", DaemonScope: snap.SystemDaemon}
+	c.Assert(sd.DecorateWithFailure(app, snapApp), IsNil)
+	// the failure is still reported, without the log
+	c.Check(app.Failure, DeepEquals, &client.AppFailure{
+		Result:   "exit-code",
+		ExitCode: 1,
+		Restarts: 5,
+	})
+	c.Check(logbuf.String(), testutil.Contains, `cannot read the log of service "failed": no journal`)
+}
+
 func (s *statusDecoratorSuite) TestDecorateWithStatus(c *C) {
 	dirs.SetRootDir(c.MkDir())
 	defer dirs.SetRootDir("")
@@ -315,6 +422,61 @@ func (s *snapServiceOptionsSuite) TestSnapServiceOptionsVitalityRank(c *C) {
 	})
 }
 
+func (s *snapServiceOptionsSuite) TestSnapServiceOptionsServiceOverrides(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+	t := config.NewTransaction(st)
+	c.Assert(t.Set("core", "service-overrides.foo.svc1.nice", 5), IsNil)
+	c.Assert(t.Set("core", "service-overrides.foo.svc2", map[string]interface{}{
+		"oom-score-adjust": 100,
+		"environment":      map[string]string{"FOO": "bar"},
+	}), IsNil)
+	t.Commit()
+
+	fooInfo := snaptest.MockInfo(c, "name: foo\nversion: 0", nil)
+	barInfo := snaptest.MockInfo(c, "name: bar\nversion: 0", nil)
+
+	nice := 5
+	oomScoreAdjust := 100
+	opts, err := servicestate.SnapServiceOptions(st, fooInfo, nil)
+	c.Assert(err, IsNil)
+	c.Check(opts, DeepEquals, &wrappers.SnapServiceOptions{
+		ServiceOverrides: map[string]*wrappers.ServiceOverrides{
+			"svc1": {Nice: &nice},
+			"svc2": {OOMScoreAdjust: &oomScoreAdjust, Environment: map[string]string{"FOO": "bar"}},
+		},
+	})
+	opts, err = servicestate.SnapServiceOptions(st, barInfo, nil)
+	c.Assert(err, IsNil)
+	c.Check(opts, DeepEquals, &wrappers.SnapServiceOptions{})
+}
+
+func (s *snapServiceOptionsSuite) TestSnapServiceOverridesInvalid(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	for _, t := range []struct {
+		value interface{}
+		err   string
+	}{
+		{map[string]interface{}{"nice": 100}, `invalid service-overrides.foo.svc1: nice must be between -20 and 19, not 100`},
+		{map[string]interface{}{"nice": "high"}, `invalid service-overrides.foo.svc1: json: cannot unmarshal string .*`},
+		{map[string]interface{}{"cpu-weight": 1}, `invalid service-overrides.foo.svc1: json: unknown field "cpu-weight"`},
+	} {
+		tr := config.NewTransaction(st)
+		c.Assert(tr.Set("core", "service-overrides.foo.svc1", t.value), IsNil)
+		_, err := servicestate.SnapServiceOverrides(tr, "foo")
+		c.Check(err, ErrorMatches, t.err)
+	}
+
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "service-overrides.foo", "nice"), IsNil)
+	_, err := servicestate.SnapServiceOverrides(tr, "foo")
+	c.Check(err, ErrorMatches, `invalid service-overrides.foo: .*`)
+}
+
 func (s *snapServiceOptionsSuite) TestSnapServiceOptionsQuotaGroups(c *C) {
 	st := s.state
 	st.Lock()
@@ -449,6 +611,13 @@ func (s *snapServiceOptionsSuite) TestServiceControlTaskSummaries(c *C) {
 			},
 			`Run service command "reload-or-restart" for services ["svc1" "svc2"] of snap "foo"`,
 		},
+		{
+			&servicestate.Instruction{
+				Action:         "restart",
+				RestartOptions: client.RestartOptions{Failed: true},
+			},
+			`Run service command "restart" for failed services of snap "foo"`,
+		},
 		{
 			&servicestate.Instruction{
 				Action: "stop",
@@ -498,17 +667,20 @@ func (s *snapServiceOptionsSuite) TestLogReader(c *C) {
 	defer restore()
 
 	var jctlCalls int
-	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+	restore = systemd.MockJournalctl(func(svcs []string, opts *systemd.LogOptions) (rc io.ReadCloser, err error) {
 		jctlCalls++
 		c.Check(svcs, DeepEquals, []string{"snap.foo.svc1.service", "snap.foo.svc2.service"})
-		c.Check(n, Equals, 100)
-		c.Check(follow, Equals, false)
-		c.Check(namespaces, Equals, false)
+		c.Check(opts, DeepEquals, &systemd.LogOptions{
+			N:           100,
+			Namespaces:  false,
+			Priority:    "err",
+			AfterCursor: "s=abc",
+		})
 		return ioutil.NopCloser(strings.NewReader("")), nil
 	})
 	defer restore()
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	_, err := servicestate.LogReader(appInfos, &systemd.LogOptions{N: 100, Priority: "err", AfterCursor: "s=abc"})
 	c.Assert(err, IsNil)
 	c.Check(jctlCalls, Equals, 1)
 }
@@ -540,7 +712,7 @@ func (s *snapServiceOptionsSuite) TestLogReaderFailsWithNonServices(c *C) {
 		},
 	}
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	_, err := servicestate.LogReader(appInfos, &systemd.LogOptions{N: 100})
 	c.Assert(err.Error(), Equals, `cannot read logs for app "app1": not a service`)
 }
 
@@ -576,17 +748,20 @@ func (s *snapServiceOptionsSuite) TestLogReaderNamespaces(c *C) {
 
 	restore := systemd.MockSystemdVersion(245, nil)
 	defer restore()
-	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+	restore = systemd.MockJournalctl(func(svcs []string, opts *systemd.LogOptions) (rc io.ReadCloser, err error) {
 		jctlCalls++
 		c.Check(svcs, DeepEquals, []string{"snap.foo.svc1.service", "snap.foo.svc2.service"})
-		c.Check(n, Equals, 100)
-		c.Check(follow, Equals, false)
-		c.Check(namespaces, Equals, true)
+		c.Check(opts, DeepEquals, &systemd.LogOptions{
+			N:           100,
+			Namespaces:  true,
+			Priority:    "err",
+			AfterCursor: "s=abc",
+		})
 		return ioutil.NopCloser(strings.NewReader("")), nil
 	})
 	defer restore()
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	_, err := servicestate.LogReader(appInfos, &systemd.LogOptions{N: 100, Priority: "err", AfterCursor: "s=abc"})
 	c.Assert(err, IsNil)
 	c.Check(jctlCalls, Equals, 1)
 }
@@ -260,22 +260,10 @@ func isSnapshotFilename(filePath string) (ok bool, setID uint64) {
 // EstimateSnapshotSize calculates estimated size of the snapshot.
 func EstimateSnapshotSize(si *snap.Info, usernames []string, dirOpts *dirs.SnapDirOptions) (uint64, error) {
 	var total uint64
-	calculateSize := func(path string, finfo os.FileInfo, err error) error {
-		if finfo.Mode().IsRegular() {
-			total += uint64(finfo.Size())
-		}
-		return err
-	}
-
 	visitDir := func(dir string) error {
-		exists, isDir, err := osutil.DirExists(dir)
-		if err != nil {
-			return err
-		}
-		if !(exists && isDir) {
-			return nil
-		}
-		return filepath.Walk(dir, calculateSize)
+		sz, err := osutil.DirSize(dir)
+		total += sz
+		return err
 	}
 
 	for _, dir := range []string{si.DataDir(), si.CommonDataDir()} {
@@ -467,6 +467,41 @@ func AutomaticSnapshot(st *state.State, snapName string) (ts *state.TaskSet, err
 	return ts, nil
 }
 
+// ForgetSnapshots creates a taskset for deleting the data of the given
+// snap from all the snapshot sets holding some, as done when removing
+// the snap with the purge flag. It returns snapstate.ErrNothingToDo if
+// there are no snapshots of the snap.
+// Note that the state must be locked by the caller.
+func ForgetSnapshots(st *state.State, instanceName string) (ts *state.TaskSet, err error) {
+	var setIDs []uint64
+	err = backendIter(context.TODO(), func(r *backend.Reader) error {
+		if r.Snap == instanceName {
+			setIDs = append(setIDs, r.SetID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(setIDs) == 0 {
+		return nil, snapstate.ErrNothingToDo
+	}
+	sort.Slice(setIDs, func(i, j int) bool { return setIDs[i] < setIDs[j] })
+
+	ts = state.NewTaskSet()
+	for i, setID := range setIDs {
+		if i > 0 && setID == setIDs[i-1] {
+			continue
+		}
+		_, forgetTs, err := Forget(st, setID, []string{instanceName})
+		if err != nil {
+			return nil, err
+		}
+		ts.AddAll(forgetTs)
+	}
+	return ts, nil
+}
+
 // Restore creates a taskset for restoring a snapshot's data.
 // Note that the state must be locked by the caller.
 func Restore(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
@@ -538,6 +573,55 @@ func Restore(st *state.State, setID uint64, snapNames []string, users []string)
 	return snapsFound, ts, nil
 }
 
+// RevertData creates a taskset for restoring the data of an older
+// revision of the snap from the most recent snapshot taken of that
+// revision, while keeping the currently installed revision of the snap
+// itself. If rev is unset the previous revision of the snap is used.
+// Note that the state must be locked by the caller.
+func RevertData(st *state.State, instanceName string, rev snap.Revision, users []string) (setID uint64, ts *state.TaskSet, err error) {
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, instanceName, &snapst); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return 0, nil, &snap.NotInstalledError{Snap: instanceName}
+		}
+		return 0, nil, err
+	}
+	if !snapst.Active {
+		return 0, nil, fmt.Errorf("cannot revert data of inactive snap %q", instanceName)
+	}
+	if rev.Unset() {
+		i := snapst.LastIndex(snapst.Current)
+		if i <= 0 {
+			return 0, nil, fmt.Errorf("no revision to revert data to")
+		}
+		rev = snapst.Sequence[i-1].Revision
+	}
+	if rev == snapst.Current {
+		return 0, nil, fmt.Errorf("already on requested revision")
+	}
+
+	err = backendIter(context.TODO(), func(r *backend.Reader) error {
+		if r.Snap == instanceName && r.Revision == rev && r.SetID > setID {
+			setID = r.SetID
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if setID == 0 {
+		return 0, nil, fmt.Errorf("cannot revert data of snap %q: no snapshot of revision %s found", instanceName, rev)
+	}
+
+	// Restore checks that the current revision can read the data
+	// of the snapshot (epochs) and that it belongs to the same snap
+	_, ts, err = Restore(st, setID, []string{instanceName}, users)
+	if err != nil {
+		return 0, nil, err
+	}
+	return setID, ts, nil
+}
+
 // Check creates a taskset for checking a snapshot's data.
 // Note that the state must be locked by the caller.
 func Check(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
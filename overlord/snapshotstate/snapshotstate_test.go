@@ -1199,6 +1199,120 @@ func (snapshotSuite) TestRestore(c *check.C) {
 	})
 }
 
+func (snapshotSuite) setupRevertData(c *check.C, st *state.State, snapYaml string) *os.File {
+	si1 := &snap.SideInfo{RealName: "a-snap", Revision: snap.R(1)}
+	si2 := &snap.SideInfo{RealName: "a-snap", Revision: snap.R(2)}
+	snapstate.Set(st, "a-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{si1, si2},
+		Current:  si2.Revision,
+	})
+	snaptest.MockSnap(c, snapYaml, si2)
+
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "yadda.zip"))
+	c.Assert(err, check.IsNil)
+	return shotfile
+}
+
+func (s snapshotSuite) TestRevertDataPicksLatestSnapshotOfRevision(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	shotfile := s.setupRevertData(c, st, "{name: a-snap, version: v2, epoch: 1}")
+	defer shotfile.Close()
+
+	fakeIter := func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, shot := range []client.Snapshot{
+			{SetID: 10, Snap: "a-snap", Revision: snap.R(1), Epoch: snap.E("1")},
+			{SetID: 12, Snap: "a-snap", Revision: snap.R(1), Epoch: snap.E("1")},
+			{SetID: 13, Snap: "b-snap", Revision: snap.R(1)},
+			{SetID: 15, Snap: "a-snap", Revision: snap.R(2), Epoch: snap.E("1")},
+		} {
+			c.Assert(f(&backend.Reader{Snapshot: shot, File: shotfile}), check.IsNil)
+		}
+		return nil
+	}
+	defer snapshotstate.MockBackendIter(fakeIter)()
+
+	setID, ts, err := snapshotstate.RevertData(st, "a-snap", snap.R(0), nil)
+	c.Assert(err, check.IsNil)
+	c.Check(setID, check.Equals, uint64(12))
+	tasks := ts.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	c.Check(tasks[0].Kind(), check.Equals, "restore-snapshot")
+	var snapshot map[string]interface{}
+	c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+		"set-id":   12.,
+		"snap":     "a-snap",
+		"filename": shotfile.Name(),
+		"current":  "2",
+	})
+
+	// an explicit revision works too
+	setID, _, err = snapshotstate.RevertData(st, "a-snap", snap.R(1), nil)
+	c.Assert(err, check.IsNil)
+	c.Check(setID, check.Equals, uint64(12))
+}
+
+func (s snapshotSuite) TestRevertDataChecksEpoch(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	shotfile := s.setupRevertData(c, st, "{name: a-snap, version: v2, epoch: 2}")
+	defer shotfile.Close()
+
+	fakeIter := func(_ context.Context, f func(*backend.Reader) error) error {
+		c.Assert(f(&backend.Reader{
+			Snapshot: client.Snapshot{SetID: 10, Snap: "a-snap", Revision: snap.R(1), Epoch: snap.E("1")},
+			File:     shotfile,
+		}), check.IsNil)
+		return nil
+	}
+	defer snapshotstate.MockBackendIter(fakeIter)()
+
+	_, _, err := snapshotstate.RevertData(st, "a-snap", snap.R(1), nil)
+	c.Assert(err, check.ErrorMatches, `cannot restore snapshot for "a-snap": current snap \(epoch 2\) cannot read snapshot data \(epoch 1\)`)
+}
+
+func (s snapshotSuite) TestRevertDataErrors(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	shotfile := s.setupRevertData(c, st, "{name: a-snap, version: v2}")
+	defer shotfile.Close()
+
+	fakeIter := func(_ context.Context, f func(*backend.Reader) error) error {
+		c.Assert(f(&backend.Reader{
+			Snapshot: client.Snapshot{SetID: 10, Snap: "a-snap", Revision: snap.R(2)},
+			File:     shotfile,
+		}), check.IsNil)
+		return nil
+	}
+	defer snapshotstate.MockBackendIter(fakeIter)()
+
+	_, _, err := snapshotstate.RevertData(st, "b-snap", snap.R(0), nil)
+	c.Check(err, check.ErrorMatches, `snap "b-snap" is not installed`)
+
+	_, _, err = snapshotstate.RevertData(st, "a-snap", snap.R(2), nil)
+	c.Check(err, check.ErrorMatches, `already on requested revision`)
+
+	_, _, err = snapshotstate.RevertData(st, "a-snap", snap.R(0), nil)
+	c.Check(err, check.ErrorMatches, `cannot revert data of snap "a-snap": no snapshot of revision 1 found`)
+
+	si2 := &snap.SideInfo{RealName: "a-snap", Revision: snap.R(2)}
+	snapstate.Set(st, "a-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{si2},
+		Current:  si2.Revision,
+	})
+	_, _, err = snapshotstate.RevertData(st, "a-snap", snap.R(0), nil)
+	c.Check(err, check.ErrorMatches, `no revision to revert data to`)
+}
+
 func (snapshotSuite) TestRestoreIntegration(c *check.C) {
 	testRestoreIntegration(c, dirs.UserHomeSnapDir, nil)
 }
@@ -1619,6 +1733,62 @@ func (snapshotSuite) TestForget(c *check.C) {
 	})
 }
 
+func (snapshotSuite) TestForgetSnapshots(c *check.C) {
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "yadda.zip"))
+	c.Assert(err, check.IsNil)
+	defer shotfile.Close()
+	fakeIter := func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, shot := range []client.Snapshot{
+			{SetID: 12, Snap: "a-snap"},
+			{SetID: 10, Snap: "a-snap"},
+			{SetID: 10, Snap: "b-snap"},
+			{SetID: 11, Snap: "b-snap"},
+		} {
+			c.Assert(f(&backend.Reader{Snapshot: shot, File: shotfile}), check.IsNil)
+		}
+		return nil
+	}
+	defer snapshotstate.MockBackendIter(fakeIter)()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	ts, err := snapshotstate.ForgetSnapshots(st, "a-snap")
+	c.Assert(err, check.IsNil)
+	tasks := ts.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	for i, setID := range []uint64{10, 12} {
+		c.Check(tasks[i].Kind(), check.Equals, "forget-snapshot")
+		c.Check(tasks[i].Summary(), check.Equals, fmt.Sprintf(`Drop data of snap "a-snap" from snapshot set #%d`, setID))
+	}
+
+	_, err = snapshotstate.ForgetSnapshots(st, "c-snap")
+	c.Check(err, check.Equals, snapstate.ErrNothingToDo)
+}
+
+func (snapshotSuite) TestForgetSnapshotsConflict(c *check.C) {
+	fakeIter := func(_ context.Context, f func(*backend.Reader) error) error {
+		c.Assert(f(&backend.Reader{
+			Snapshot: client.Snapshot{SetID: 42, Snap: "a-snap"},
+		}), check.IsNil)
+		return nil
+	}
+	defer snapshotstate.MockBackendIter(fakeIter)()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("restore-snapshot", "...")
+	tsk := st.NewTask("restore-snapshot", "...")
+	tsk.Set("snapshot-setup", map[string]int{"set-id": 42})
+	chg.AddTask(tsk)
+
+	_, err := snapshotstate.ForgetSnapshots(st, "a-snap")
+	c.Check(err, check.ErrorMatches, `cannot operate on snapshot set #42 while change "1" is in progress`)
+}
+
 func (snapshotSuite) TestSaveExpiration(c *check.C) {
 	st := state.New(nil)
 	st.Lock()
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/snapcore/snapd/i18n"
@@ -529,22 +530,26 @@ func (m *SnapManager) ensureAliasesV2() error {
 	return nil
 }
 
-// Alias sets up a manual alias from alias to app in snapName.
-func Alias(st *state.State, instanceName, app, alias string) (*state.TaskSet, error) {
-	if err := snap.ValidateAlias(alias); err != nil {
-		return nil, err
-	}
+// AliasFlags holds the options for setting up manual aliases.
+type AliasFlags struct {
+	// Force takes over the aliases that are enabled for other snaps,
+	// disabling them there.
+	Force bool
+}
 
+// aliasSnapSetup returns the SnapSetup for an alias operation on the
+// installed instanceName snap.
+func aliasSnapSetup(st *state.State, instanceName string) (*SnapSetup, *SnapState, error) {
 	var snapst SnapState
 	err := Get(st, instanceName, &snapst)
 	if errors.Is(err, state.ErrNoState) {
-		return nil, &snap.NotInstalledError{Snap: instanceName}
+		return nil, nil, &snap.NotInstalledError{Snap: instanceName}
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if err := CheckChangeConflict(st, instanceName, nil); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	snapName, instanceKey := snap.SplitInstanceName(instanceName)
@@ -552,15 +557,136 @@ func Alias(st *state.State, instanceName, app, alias string) (*state.TaskSet, er
 		SideInfo:    &snap.SideInfo{RealName: snapName},
 		InstanceKey: instanceKey,
 	}
+	return snapsup, &snapst, nil
+}
+
+// Alias sets up a manual alias from alias to app in snapName.
+func Alias(st *state.State, instanceName, app, alias string) (*state.TaskSet, error) {
+	return AliasWithFlags(st, instanceName, app, alias, nil)
+}
+
+// AliasWithFlags sets up a manual alias from alias to app in snapName
+// according to flags.
+func AliasWithFlags(st *state.State, instanceName, app, alias string, flags *AliasFlags) (*state.TaskSet, error) {
+	if err := snap.ValidateAlias(alias); err != nil {
+		return nil, err
+	}
+
+	snapsup, snapst, err := aliasSnapSetup(st, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var otherSnaps []string
+	if flags != nil && flags.Force {
+		otherSnaps, err = checkAliasForceConflicts(st, instanceName, snapst, func(info *snap.Info) (map[string]*AliasTarget, error) {
+			return manualAlias(info, snapst.Aliases, app, alias)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	manualAlias := st.NewTask("alias", fmt.Sprintf(i18n.G("Setup manual alias %q => %q for snap %q"), alias, app, snapsup.InstanceName()))
 	manualAlias.Set("alias", alias)
 	manualAlias.Set("target", app)
 	manualAlias.Set("snap-setup", &snapsup)
+	setAliasForce(manualAlias, flags, otherSnaps)
 
 	return state.NewTaskSet(manualAlias), nil
 }
 
+// checkAliasForceConflicts returns the other snaps a forced alias
+// operation setting up the aliases computed by newAliases would take
+// aliases from, after checking that they have no change in progress.
+func checkAliasForceConflicts(st *state.State, instanceName string, snapst *SnapState, newAliases func(info *snap.Info) (map[string]*AliasTarget, error)) ([]string, error) {
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := newAliases(info)
+	if err != nil {
+		// reported when the alias task runs
+		return nil, nil
+	}
+	conflicts, err := checkAliasesConflicts(st, instanceName, snapst.AutoAliasesDisabled, aliases, nil)
+	if _, ok := err.(*AliasConflictError); err != nil && !ok {
+		return nil, err
+	}
+	otherSnaps := make([]string, 0, len(conflicts))
+	for otherSnap := range conflicts {
+		otherSnaps = append(otherSnaps, otherSnap)
+	}
+	sort.Strings(otherSnaps)
+	if err := CheckChangeConflictMany(st, otherSnaps, ""); err != nil {
+		return nil, err
+	}
+	return otherSnaps, nil
+}
+
+func setAliasForce(t *state.Task, flags *AliasFlags, otherSnaps []string) {
+	if flags == nil || !flags.Force {
+		return
+	}
+	t.Set("force", true)
+	if len(otherSnaps) != 0 {
+		// the snaps aliases are taken from are affected as well
+		t.Set("other-snaps", otherSnaps)
+	}
+}
+
+// AliasAllApps sets up manual aliases for all the applications of
+// snapName that are not daemons, each aliased under its own name, according
+// to flags.
+func AliasAllApps(st *state.State, instanceName string, flags *AliasFlags) (*state.TaskSet, error) {
+	snapsup, snapst, err := aliasSnapSetup(st, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return nil, err
+	}
+	if len(allAppsAliases(info)) == 0 {
+		return nil, fmt.Errorf("cannot alias the applications of snap %q: no application can be aliased", instanceName)
+	}
+
+	var otherSnaps []string
+	if flags != nil && flags.Force {
+		otherSnaps, err = checkAliasForceConflicts(st, instanceName, snapst, func(info *snap.Info) (map[string]*AliasTarget, error) {
+			return manualAliasAllApps(info, snapst.Aliases)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manualAlias := st.NewTask("alias", fmt.Sprintf(i18n.G("Setup manual aliases for all applications of snap %q"), snapsup.InstanceName()))
+	manualAlias.Set("all-apps", true)
+	manualAlias.Set("snap-setup", &snapsup)
+	setAliasForce(manualAlias, flags, otherSnaps)
+
+	return state.NewTaskSet(manualAlias), nil
+}
+
+// allAppsAliases returns the names of the applications of the snap that
+// can be aliased under their own name. Daemons cannot be aliased and the
+// application named after the snap is already invoked by that name.
+func allAppsAliases(info *snap.Info) []string {
+	var apps []string
+	for _, app := range info.Apps {
+		if app.IsService() || app.Name == info.InstanceName() {
+			continue
+		}
+		if snap.ValidateAlias(app.Name) != nil {
+			continue
+		}
+		apps = append(apps, app.Name)
+	}
+	sort.Strings(apps)
+	return apps
+}
+
 // manualAliases returns newAliases with a manual alias to target setup over
 // curAliases.
 func manualAlias(info *snap.Info, curAliases map[string]*AliasTarget, target, alias string) (newAliases map[string]*AliasTarget, err error) {
@@ -590,6 +716,52 @@ func manualAlias(info *snap.Info, curAliases map[string]*AliasTarget, target, al
 	return newAliases, nil
 }
 
+// manualAliasAllApps returns newAliases with manual aliases for all
+// applications of the snap setup over curAliases.
+func manualAliasAllApps(info *snap.Info, curAliases map[string]*AliasTarget) (newAliases map[string]*AliasTarget, err error) {
+	newAliases = curAliases
+	for _, app := range allAppsAliases(info) {
+		newAliases, err = manualAlias(info, newAliases, app, app)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newAliases, nil
+}
+
+// stealAliases returns otherAliases and otherAutoDisabled corresponding to
+// disabling the given conflicting aliases of another snap so that they can
+// be enabled for a different one. Conflicting manual aliases are removed,
+// recorded in disabledManual, while automatic aliases can only be disabled
+// all together.
+func stealAliases(otherAliases map[string]*AliasTarget, otherAutoDisabled bool, conflicting []string) (newAliases map[string]*AliasTarget, newAutoDisabled bool, disabledManual map[string]string) {
+	newAliases = make(map[string]*AliasTarget, len(otherAliases))
+	for alias, aliasTarget := range otherAliases {
+		newAliases[alias] = aliasTarget
+	}
+	newAutoDisabled = otherAutoDisabled
+	for _, alias := range conflicting {
+		target := otherAliases[alias]
+		if target == nil {
+			continue
+		}
+		if target.Manual != "" {
+			if disabledManual == nil {
+				disabledManual = make(map[string]string)
+			}
+			disabledManual[alias] = target.Manual
+			if target.Auto == "" {
+				delete(newAliases, alias)
+				continue
+			}
+			newAliases[alias] = &AliasTarget{Auto: target.Auto}
+		}
+		// the remaining automatic alias needs disabling
+		newAutoDisabled = true
+	}
+	return newAliases, newAutoDisabled, disabledManual
+}
+
 // DisableAllAliases disables all aliases of a snap, removing all manual ones.
 func DisableAllAliases(st *state.State, instanceName string) (*state.TaskSet, error) {
 	var snapst SnapState
@@ -641,6 +813,45 @@ func RemoveManualAlias(st *state.State, alias string) (ts *state.TaskSet, instan
 	return state.NewTaskSet(unalias), instanceName, nil
 }
 
+// RemoveAllManualAliases removes all the manual aliases of a snap, its
+// automatic aliases are left as they are.
+func RemoveAllManualAliases(st *state.State, instanceName string) (*state.TaskSet, error) {
+	snapsup, snapst, err := aliasSnapSetup(st, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	hasManual := false
+	for _, target := range snapst.Aliases {
+		if target.Manual != "" {
+			hasManual = true
+			break
+		}
+	}
+	if !hasManual {
+		return nil, fmt.Errorf("cannot find any manual alias for snap %q", instanceName)
+	}
+
+	unalias := st.NewTask("unalias", fmt.Sprintf(i18n.G("Remove manual aliases for snap %q"), instanceName))
+	unalias.Set("all-manual", true)
+	unalias.Set("snap-setup", &snapsup)
+
+	return state.NewTaskSet(unalias), nil
+}
+
+// manualUnaliasAll returns newAliases with all the manual aliases removed
+// from curAliases.
+func manualUnaliasAll(curAliases map[string]*AliasTarget) (newAliases map[string]*AliasTarget) {
+	newAliases = make(map[string]*AliasTarget, len(curAliases))
+	for alias, aliasTarget := range curAliases {
+		if aliasTarget.Manual == "" {
+			newAliases[alias] = aliasTarget
+		} else if aliasTarget.Auto != "" {
+			newAliases[alias] = &AliasTarget{Auto: aliasTarget.Auto}
+		}
+	}
+	return newAliases
+}
+
 func findSnapOfManualAlias(st *state.State, alias string) (snapName string, err error) {
 	snapStates, err := All(st)
 	if err != nil {
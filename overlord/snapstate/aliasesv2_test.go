@@ -871,6 +871,274 @@ func (s *snapmgrTestSuite) TestAliasAliasConflict(c *C) {
 	c.Check(chg.Err(), ErrorMatches, `(?s).*cannot enable alias "alias1" for "alias-snap", already enabled for "other-snap".*`)
 }
 
+func (s *snapmgrTestSuite) TestAliasAllAppsRunThrough(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "alias-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+		Aliases: map[string]*snapstate.AliasTarget{
+			"alias1": {Auto: "cmd1"},
+		},
+	})
+
+	chg := s.state.NewChange("alias", "manual alias")
+	ts, err := snapstate.AliasAllApps(s.state, "alias-snap", nil)
+	c.Assert(err, IsNil)
+	c.Assert(taskKinds(ts.Tasks()), DeepEquals, []string{"alias"})
+	chg.AddAll(ts)
+
+	defer s.se.Stop()
+	s.settle(c)
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("%v", chg.Err()))
+	expected := fakeOps{
+		{
+			op: "update-aliases",
+			aliases: []*backend.Alias{
+				{Name: "cmd1", Target: "alias-snap.cmd1"},
+				{Name: "cmd2", Target: "alias-snap.cmd2"},
+				{Name: "cmd3", Target: "alias-snap.cmd3"},
+				{Name: "cmd4", Target: "alias-snap.cmd4"},
+				{Name: "cmd5", Target: "alias-snap.cmd5"},
+			},
+		},
+	}
+	// start with an easier-to-read error if this fails:
+	c.Assert(s.fakeBackend.ops.Ops(), DeepEquals, expected.Ops())
+	c.Assert(s.fakeBackend.ops, DeepEquals, expected)
+
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "alias-snap", &snapst)
+	c.Assert(err, IsNil)
+	// daemons are not aliased
+	c.Check(snapst.Aliases, DeepEquals, map[string]*snapstate.AliasTarget{
+		"alias1": {Auto: "cmd1"},
+		"cmd1":   {Manual: "cmd1"},
+		"cmd2":   {Manual: "cmd2"},
+		"cmd3":   {Manual: "cmd3"},
+		"cmd4":   {Manual: "cmd4"},
+		"cmd5":   {Manual: "cmd5"},
+	})
+}
+
+func (s *snapmgrTestSuite) TestAliasAllAppsNothingToAlias(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "some-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+	})
+
+	_, err := snapstate.AliasAllApps(s.state, "some-snap", nil)
+	c.Assert(err, ErrorMatches, `cannot alias the applications of snap "some-snap": no application can be aliased`)
+}
+
+func (s *snapmgrTestSuite) TestAliasForceStealsAliasAndUndo(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "alias-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+	})
+	otherAliases := map[string]*snapstate.AliasTarget{
+		"alias1": {Manual: "cmd1"},
+		"alias2": {Auto: "cmd2"},
+		"alias3": {Auto: "cmd3"},
+	}
+	snapstate.Set(s.state, "alias-snap_instance", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current:     snap.R(11),
+		Active:      true,
+		InstanceKey: "instance",
+		Aliases:     otherAliases,
+	})
+
+	chg := s.state.NewChange("alias", "manual alias")
+	ts, err := snapstate.AliasWithFlags(s.state, "alias-snap", "cmd5", "alias2", &snapstate.AliasFlags{Force: true})
+	c.Assert(err, IsNil)
+	c.Check(ts.Tasks()[0].Has("force"), Equals, true)
+	chg.AddAll(ts)
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.WaitAll(ts)
+	chg.AddTask(terr)
+
+	defer s.se.Stop()
+	s.settle(c)
+
+	c.Assert(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(ts.Tasks()[0].Status(), Equals, state.UndoneStatus)
+	expected := fakeOps{
+		{
+			// automatic aliases of the other snap are disabled
+			op: "update-aliases",
+			rmAliases: []*backend.Alias{
+				{Name: "alias2", Target: "alias-snap_instance.cmd2"},
+				{Name: "alias3", Target: "alias-snap_instance.cmd3"},
+			},
+		},
+		{
+			op:      "update-aliases",
+			aliases: []*backend.Alias{{Name: "alias2", Target: "alias-snap.cmd5"}},
+		},
+		{
+			op:        "update-aliases",
+			rmAliases: []*backend.Alias{{Name: "alias2", Target: "alias-snap.cmd5"}},
+		},
+		{
+			op: "update-aliases",
+			aliases: []*backend.Alias{
+				{Name: "alias2", Target: "alias-snap_instance.cmd2"},
+				{Name: "alias3", Target: "alias-snap_instance.cmd3"},
+			},
+		},
+	}
+	// start with an easier-to-read error if this fails:
+	c.Assert(s.fakeBackend.ops.Ops(), DeepEquals, expected.Ops())
+	c.Assert(s.fakeBackend.ops, DeepEquals, expected)
+
+	var trace traceData
+	err = chg.Get("api-data", &trace)
+	c.Assert(err, IsNil)
+	c.Check(trace.Added, DeepEquals, []*changedAlias{{Snap: "alias-snap", App: "cmd5", Alias: "alias2"}})
+	sort.Slice(trace.Removed, func(i, j int) bool { return trace.Removed[i].Alias < trace.Removed[j].Alias })
+	c.Check(trace.Removed, DeepEquals, []*changedAlias{
+		{Snap: "alias-snap_instance", App: "cmd2", Alias: "alias2"},
+		{Snap: "alias-snap_instance", App: "cmd3", Alias: "alias3"},
+	})
+
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "alias-snap", &snapst)
+	c.Assert(err, IsNil)
+	c.Check(snapst.Aliases, HasLen, 0)
+	err = snapstate.Get(s.state, "alias-snap_instance", &snapst)
+	c.Assert(err, IsNil)
+	c.Check(snapst.AutoAliasesDisabled, Equals, false)
+	c.Check(snapst.Aliases, DeepEquals, otherAliases)
+}
+
+func (s *snapmgrTestSuite) TestAliasForceStealsManualAlias(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "alias-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+	})
+	snapstate.Set(s.state, "alias-snap_instance", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current:     snap.R(11),
+		Active:      true,
+		InstanceKey: "instance",
+		Aliases: map[string]*snapstate.AliasTarget{
+			"cmd1":   {Manual: "cmd1"},
+			"alias2": {Auto: "cmd2"},
+		},
+	})
+
+	chg := s.state.NewChange("alias", "manual alias")
+	ts, err := snapstate.AliasAllApps(s.state, "alias-snap", &snapstate.AliasFlags{Force: true})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	defer s.se.Stop()
+	s.settle(c)
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("%v", chg.Err()))
+
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "alias-snap", &snapst)
+	c.Assert(err, IsNil)
+	c.Check(snapst.Aliases, HasLen, 5)
+	c.Check(snapst.Aliases["cmd1"], DeepEquals, &snapstate.AliasTarget{Manual: "cmd1"})
+
+	// only the conflicting manual alias was removed
+	err = snapstate.Get(s.state, "alias-snap_instance", &snapst)
+	c.Assert(err, IsNil)
+	c.Check(snapst.AutoAliasesDisabled, Equals, false)
+	c.Check(snapst.Aliases, DeepEquals, map[string]*snapstate.AliasTarget{
+		"alias2": {Auto: "cmd2"},
+	})
+
+	var otherDisabled map[string]map[string]interface{}
+	c.Assert(ts.Tasks()[0].Get("other-disabled-aliases", &otherDisabled), IsNil)
+	c.Check(otherDisabled, DeepEquals, map[string]map[string]interface{}{
+		"alias-snap_instance": {"manual": map[string]interface{}{"cmd1": "cmd1"}},
+	})
+}
+
+func (s *snapmgrTestSuite) TestAliasForceChangeConflict(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "alias-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+	})
+	snapstate.Set(s.state, "alias-snap_instance", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current:     snap.R(11),
+		Active:      true,
+		InstanceKey: "instance",
+		Aliases: map[string]*snapstate.AliasTarget{
+			"cmd1":   {Manual: "cmd1"},
+			"alias2": {Auto: "cmd2"},
+		},
+	})
+
+	// the snap the alias would be taken from is busy
+	ts, err := snapstate.DisableAllAliases(s.state, "alias-snap_instance")
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("unalias", "...")
+	chg.AddAll(ts)
+
+	_, err = snapstate.AliasWithFlags(s.state, "alias-snap", "cmd5", "alias2", &snapstate.AliasFlags{Force: true})
+	c.Assert(err, ErrorMatches, `snap "alias-snap_instance" has "unalias" change in progress`)
+	_, err = snapstate.AliasAllApps(s.state, "alias-snap", &snapstate.AliasFlags{Force: true})
+	c.Assert(err, ErrorMatches, `snap "alias-snap_instance" has "unalias" change in progress`)
+	// not without force, or if no alias is taken from it
+	_, err = snapstate.AliasWithFlags(s.state, "alias-snap", "cmd5", "alias2", nil)
+	c.Assert(err, IsNil)
+	_, err = snapstate.AliasWithFlags(s.state, "alias-snap", "cmd5", "alias5", &snapstate.AliasFlags{Force: true})
+	c.Assert(err, IsNil)
+	chg.SetStatus(state.DoneStatus)
+
+	// while the alias is taken, the other snap is busy too
+	ts, err = snapstate.AliasWithFlags(s.state, "alias-snap", "cmd5", "alias2", &snapstate.AliasFlags{Force: true})
+	c.Assert(err, IsNil)
+	var otherSnaps []string
+	c.Assert(ts.Tasks()[0].Get("other-snaps", &otherSnaps), IsNil)
+	c.Check(otherSnaps, DeepEquals, []string{"alias-snap_instance"})
+	s.state.NewChange("alias", "...").AddAll(ts)
+
+	_, err = snapstate.DisableAllAliases(s.state, "alias-snap_instance")
+	c.Assert(err, ErrorMatches, `snap "alias-snap_instance" has "alias" change in progress`)
+}
+
 func (s *snapmgrTestSuite) TestParallelInstanceAliasConflict(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1164,6 +1432,72 @@ func (s *snapmgrTestSuite) TestRemoveManualAliasRunThrough(c *C) {
 	})
 }
 
+func (s *snapmgrTestSuite) TestRemoveAllManualAliasesRunThrough(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "alias-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+		Aliases: map[string]*snapstate.AliasTarget{
+			"alias1": {Manual: "cmd5"},
+			"alias2": {Manual: "cmd2", Auto: "cmd1"},
+			"alias3": {Auto: "cmd3"},
+		},
+	})
+
+	chg := s.state.NewChange("unalias", "remove manual aliases")
+	ts, err := snapstate.RemoveAllManualAliases(s.state, "alias-snap")
+	c.Assert(err, IsNil)
+	c.Assert(taskKinds(ts.Tasks()), DeepEquals, []string{"unalias"})
+	chg.AddAll(ts)
+
+	defer s.se.Stop()
+	s.settle(c)
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("%v", chg.Err()))
+
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "alias-snap", &snapst)
+	c.Assert(err, IsNil)
+	c.Check(snapst.Aliases, DeepEquals, map[string]*snapstate.AliasTarget{
+		"alias2": {Auto: "cmd1"},
+		"alias3": {Auto: "cmd3"},
+	})
+
+	var trace traceData
+	err = chg.Get("api-data", &trace)
+	c.Assert(err, IsNil)
+	c.Check(trace.Added, DeepEquals, []*changedAlias{{Snap: "alias-snap", App: "cmd1", Alias: "alias2"}})
+	sort.Slice(trace.Removed, func(i, j int) bool { return trace.Removed[i].Alias < trace.Removed[j].Alias })
+	c.Check(trace.Removed, DeepEquals, []*changedAlias{
+		{Snap: "alias-snap", App: "cmd5", Alias: "alias1"},
+		{Snap: "alias-snap", App: "cmd2", Alias: "alias2"},
+	})
+}
+
+func (s *snapmgrTestSuite) TestRemoveAllManualAliasesNone(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "alias-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+		Aliases: map[string]*snapstate.AliasTarget{
+			"alias3": {Auto: "cmd3"},
+		},
+	})
+
+	_, err := snapstate.RemoveAllManualAliases(s.state, "alias-snap")
+	c.Assert(err, ErrorMatches, `cannot find any manual alias for snap "alias-snap"`)
+}
+
 func (s *snapmgrTestSuite) TestRemoveManualAliasOverAutoRunThrough(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
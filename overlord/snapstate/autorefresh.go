@@ -23,6 +23,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"os"
 	"time"
 
@@ -63,6 +64,7 @@ var (
 	CanAutoRefresh        func(st *state.State) (bool, error)
 	CanManageRefreshes    func(st *state.State) bool
 	IsOnMeteredConnection func() (bool, error)
+	DeviceSerial          func(st *state.State) (string, error)
 
 	defaultRefreshSchedule = func() []*timeutil.Schedule {
 		refreshSchedule, err := timeutil.ParseSchedule(defaultRefreshScheduleStr)
@@ -129,16 +131,21 @@ type autoRefresh struct {
 	state *state.State
 
 	lastRefreshSchedule string
+	lastRefreshSplay    time.Duration
 	nextRefresh         time.Time
 	lastRefreshAttempt  time.Time
 	managedDeniedLogged bool
 
 	restoredMonitoring bool
+
+	// timeSource is used to schedule the refreshes
+	timeSource timeutil.TimeSource
 }
 
 func newAutoRefresh(st *state.State) *autoRefresh {
 	return &autoRefresh{
-		state: st,
+		state:      st,
+		timeSource: timeutil.RealTimeSource,
 	}
 }
 
@@ -190,7 +197,7 @@ func effectiveRefreshHold(st *state.State) (time.Time, error) {
 }
 
 func (m *autoRefresh) ensureRefreshHoldAtLeast(duration time.Duration) error {
-	now := time.Now()
+	now := m.timeSource.Now()
 
 	// get the effective refresh hold and check if it is sooner than the
 	// specified duration in the future
@@ -234,7 +241,7 @@ func (m *autoRefresh) AtSeed() error {
 		}
 		// TODO: have a policy that if the snapd exe itself
 		// is older than X weeks/months we skip the holding?
-		now := time.Now().UTC()
+		now := m.timeSource.Now().UTC()
 		tr.Set("core", "refresh.hold", now.Add(2*time.Hour))
 		tr.Commit()
 		m.nextRefresh = now
@@ -327,29 +334,34 @@ func (m *autoRefresh) Ensure() (err error) {
 		m.nextRefresh = time.Time{}
 		return nil
 	}
+	splay, err := refreshSplay(m.state)
+	if err != nil {
+		return err
+	}
 	// we already have a refresh time, check if we got a new config
 	if !m.nextRefresh.IsZero() {
-		if m.lastRefreshSchedule != refreshScheduleStr {
+		if m.lastRefreshSchedule != refreshScheduleStr || m.lastRefreshSplay != splay {
 			// the refresh schedule has changed
 			logger.Debugf("Refresh timer changed.")
 			m.nextRefresh = time.Time{}
 		}
 	}
 	m.lastRefreshSchedule = refreshScheduleStr
+	m.lastRefreshSplay = splay
 
 	// ensure nothing is in flight already
 	if autoRefreshInFlight(m.state) {
 		return nil
 	}
 
-	now := time.Now()
+	now := m.timeSource.Now()
 	// compute next refresh attempt time (if needed)
 	if m.nextRefresh.IsZero() {
 		// store attempts in memory so that we can backoff
 		if !lastRefresh.IsZero() {
 			delta := timeutil.Next(refreshSchedule, lastRefresh, maxPostponement)
-			now = time.Now()
-			m.nextRefresh = now.Add(delta)
+			now = m.timeSource.Now()
+			m.nextRefresh = splayRefreshTime(now, now.Add(delta), refreshSchedule, lastRefresh, lastRefresh, splay)
 		} else {
 			// make sure either seed-time or last-refresh
 			// are set for hold code below
@@ -373,8 +385,8 @@ func (m *autoRefresh) Ensure() (err error) {
 			if m.nextRefresh.Before(holdTime) {
 				// next refresh is obsolete, compute the next one
 				delta := timeutil.Next(refreshSchedule, holdTime, maxPostponement)
-				now = time.Now()
-				m.nextRefresh = now.Add(delta)
+				now = m.timeSource.Now()
+				m.nextRefresh = splayRefreshTime(now, now.Add(delta), refreshSchedule, holdTime, lastRefresh, splay)
 			}
 		}
 
@@ -459,7 +471,7 @@ func (m *autoRefresh) restoreMonitoring() error {
 // isRefreshHeld returns whether an auto-refresh is currently held back or not,
 // as indicated by m.EffectiveRefreshHold().
 func (m *autoRefresh) isRefreshHeld() (bool, time.Time, error) {
-	now := time.Now()
+	now := m.timeSource.Now()
 	// should we hold back refreshes?
 	holdTime, err := m.EffectiveRefreshHold()
 	if err != nil {
@@ -472,6 +484,69 @@ func (m *autoRefresh) isRefreshHeld() (bool, time.Time, error) {
 	return false, holdTime, nil
 }
 
+// Statuses of the automatic refreshes as returned by AutoRefreshStatus.
+const (
+	// AutoRefreshScheduled: refreshes happen according to the schedule.
+	AutoRefreshScheduled = "scheduled"
+	// AutoRefreshInProgress: an automatic refresh is in progress.
+	AutoRefreshInProgress = "in-progress"
+	// AutoRefreshHeld: refreshes are held back, either until the
+	// time of the refresh hold or while on a metered connection.
+	AutoRefreshHeld = "held"
+	// AutoRefreshManaged: refreshes are managed by a snap through
+	// the snapd-control interface.
+	AutoRefreshManaged = "managed"
+	// AutoRefreshDisabled: refreshes cannot happen, e.g. because
+	// the store is offline or the system is not seeded yet.
+	AutoRefreshDisabled = "disabled"
+)
+
+// Status returns the status of the automatic refreshes.
+func (m *autoRefresh) Status() (string, error) {
+	online, err := isStoreOnline(m.state)
+	if err != nil {
+		return "", err
+	}
+	if !online || CanAutoRefresh == nil {
+		return AutoRefreshDisabled, nil
+	}
+	if ok, err := CanAutoRefresh(m.state); err != nil || !ok {
+		return AutoRefreshDisabled, err
+	}
+
+	if autoRefreshInFlight(m.state) {
+		return AutoRefreshInProgress, nil
+	}
+
+	scheduleConf, _, err := getRefreshScheduleConf(m.state)
+	if err != nil {
+		return "", err
+	}
+	if scheduleConf == "managed" && CanManageRefreshes != nil && CanManageRefreshes(m.state) {
+		return AutoRefreshManaged, nil
+	}
+
+	held, _, err := m.isRefreshHeld()
+	if err != nil {
+		return "", err
+	}
+	if held {
+		return AutoRefreshHeld, nil
+	}
+	canOnMetered, err := canRefreshOnMeteredConnection(m.state)
+	if err != nil {
+		return "", err
+	}
+	if !canOnMetered {
+		// ignore errors like canRefreshRespectingMetered does
+		if metered, _ := IsOnMeteredConnection(); metered {
+			return AutoRefreshHeld, nil
+		}
+	}
+
+	return AutoRefreshScheduled, nil
+}
+
 func (m *autoRefresh) ensureLastRefreshAnchor() {
 	seedTime, _ := getTime(m.state, "seed-time")
 	if !seedTime.IsZero() {
@@ -512,6 +587,65 @@ func getRefreshScheduleConf(st *state.State) (confStr string, legacy bool, err e
 	return confStr, legacy, nil
 }
 
+// splayRefreshTime pushes back by splay the next refresh time computed from
+// the schedule windows after the given time, but neither past the end of
+// that window nor past maxPostponement since lastRefresh.
+func splayRefreshTime(now, next time.Time, schedule []*timeutil.Schedule, after, lastRefresh time.Time, splay time.Duration) time.Time {
+	if splay == 0 {
+		return next
+	}
+	limit := timeutil.NextWindow(schedule, after, maxPostponement).End
+	if maxTime := lastRefresh.Add(maxPostponement); maxTime.Before(limit) {
+		limit = maxTime
+	}
+	next = next.Add(splay)
+	if next.After(limit) {
+		next = limit
+	}
+	if next.Before(now) {
+		next = now
+	}
+	return next
+}
+
+// refreshSplay returns the offset of this device within the interval
+// configured with refresh.splay, which is added to the times computed from
+// the refresh schedule so that devices sharing a schedule do not all hit
+// the store at the same time. The offset is derived from the device serial,
+// so it is stable across restarts; it is zero when no splay is configured
+// or the device has no serial yet.
+func refreshSplay(st *state.State) (time.Duration, error) {
+	tr := config.NewTransaction(st)
+	var splayStr string
+	if err := tr.Get("core", "refresh.splay", &splayStr); err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if splayStr == "" {
+		return 0, nil
+	}
+	splay, err := time.ParseDuration(splayStr)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse refresh.splay: %v", err)
+	}
+	if splay <= 0 {
+		return 0, nil
+	}
+
+	if DeviceSerial == nil {
+		return 0, nil
+	}
+	serial, err := DeviceSerial(st)
+	if err != nil {
+		return 0, err
+	}
+	if serial == "" {
+		return 0, nil
+	}
+	h := fnv.New64a()
+	h.Write([]byte(serial))
+	return time.Duration(h.Sum64() % uint64(splay)), nil
+}
+
 // refreshScheduleWithDefaultsFallback returns the current refresh schedule
 // and refresh string.
 func (m *autoRefresh) refreshScheduleWithDefaultsFallback() (sched []*timeutil.Schedule, scheduleConf string, legacy bool, err error) {
@@ -612,8 +746,12 @@ func (m *autoRefresh) launchAutoRefresh() error {
 		perfTimings.Save(m.state)
 	}()
 
+	// forget withdrawn snaps seen by earlier queries, only act on the
+	// ones reported for this auto-refresh
+	takeWithdrawnSnaps(m.state)
 	// NOTE: this will unlock and re-lock state for network ops
 	updated, updateTss, err := AutoRefresh(auth.EnsureContextTODO(), m.state)
+	handleWithdrawnSnaps(m.state, takeWithdrawnSnaps(m.state))
 
 	// TODO: we should have some way to lock just creating and starting changes,
 	//       as that would alleviate this race condition we are guarding against
@@ -47,6 +47,7 @@ import (
 	"github.com/snapcore/snapd/store/storetest"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/timeutil"
+	"github.com/snapcore/snapd/timeutil/clocktest"
 	userclient "github.com/snapcore/snapd/usersession/client"
 )
 
@@ -300,6 +301,30 @@ func (s *autoRefreshTestSuite) TestLastRefreshNoRefreshNeeded(c *C) {
 	c.Check(s.store.ops, HasLen, 0)
 }
 
+func (s *autoRefreshTestSuite) TestAutoRefreshHandlesWithdrawnSnaps(c *C) {
+	s.state.Lock()
+	// withdrawn snaps seen by earlier queries are not acted upon
+	snapstate.RecordWithdrawnSnaps(s.state, map[string]error{
+		"other-snap": &store.WithdrawnError{Code: "revision-withdrawn"},
+	})
+	s.state.Unlock()
+
+	s.store.err = &store.SnapActionError{Refresh: map[string]error{
+		"some-snap": &store.WithdrawnError{Code: "revision-withdrawn", Message: "bad revision"},
+	}}
+
+	af := snapstate.NewAutoRefresh(s.state)
+	err := af.Ensure()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `snap "some-snap" was withdrawn by the store: bad revision`)
+}
+
 func (s *autoRefreshTestSuite) TestRefreshBackoff(c *C) {
 	s.store.err = fmt.Errorf("random store error")
 	af := snapstate.NewAutoRefresh(s.state)
@@ -404,6 +429,119 @@ func (s *autoRefreshTestSuite) TestDefaultScheduleIsRandomized(c *C) {
 	}
 }
 
+func (s *autoRefreshTestSuite) TestRefreshSplay(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// no splay configured
+	splay, err := snapstate.RefreshSplay(s.state)
+	c.Assert(err, IsNil)
+	c.Check(splay, Equals, time.Duration(0))
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.splay", "1h")
+	tr.Commit()
+
+	// no hook set up
+	splay, err = snapstate.RefreshSplay(s.state)
+	c.Assert(err, IsNil)
+	c.Check(splay, Equals, time.Duration(0))
+
+	serial := ""
+	snapstate.DeviceSerial = func(*state.State) (string, error) { return serial, nil }
+	defer func() { snapstate.DeviceSerial = nil }()
+
+	// no serial yet
+	splay, err = snapstate.RefreshSplay(s.state)
+	c.Assert(err, IsNil)
+	c.Check(splay, Equals, time.Duration(0))
+
+	serial = "serial-1"
+	splay1, err := snapstate.RefreshSplay(s.state)
+	c.Assert(err, IsNil)
+	c.Check(splay1 >= 0 && splay1 < time.Hour, Equals, true)
+
+	// stable
+	splay, err = snapstate.RefreshSplay(s.state)
+	c.Assert(err, IsNil)
+	c.Check(splay, Equals, splay1)
+
+	// but different for another device
+	serial = "serial-2"
+	splay2, err := snapstate.RefreshSplay(s.state)
+	c.Assert(err, IsNil)
+	c.Check(splay2 >= 0 && splay2 < time.Hour, Equals, true)
+	c.Check(splay2, Not(Equals), splay1)
+}
+
+func (s *autoRefreshTestSuite) TestRefreshSplayAppliedToNextRefresh(c *C) {
+	s.state.Lock()
+	lastRefresh := time.Now().Add(-time.Hour)
+	s.state.Set("last-refresh", lastRefresh)
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.timer", "0:00-24:00")
+	tr.Set("core", "refresh.splay", "6h")
+	tr.Commit()
+	snapstate.DeviceSerial = func(*state.State) (string, error) { return "serial-1", nil }
+	defer func() { snapstate.DeviceSerial = nil }()
+	splay, err := snapstate.RefreshSplay(s.state)
+	c.Assert(err, IsNil)
+	s.state.Unlock()
+	c.Assert(splay > 0, Equals, true)
+
+	sched, err := timeutil.ParseSchedule("0:00-24:00")
+	c.Assert(err, IsNil)
+	delta := timeutil.Next(sched, lastRefresh, 95*24*time.Hour)
+	window := timeutil.NextWindow(sched, lastRefresh, 95*24*time.Hour)
+
+	af := snapstate.NewAutoRefresh(s.state)
+	err = af.Ensure()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+	// the next refresh is pushed back by the splay, within the window
+	expected := time.Now().Add(delta + splay)
+	if expected.After(window.End) {
+		expected = window.End
+	}
+	next := af.NextRefresh()
+	c.Check(!next.After(expected), Equals, true, Commentf("%v %v", next, expected))
+	c.Check(next.After(expected.Add(-time.Minute)), Equals, true, Commentf("%v %v", next, expected))
+}
+
+func (s *autoRefreshTestSuite) TestRefreshSplayClampedToWindow(c *C) {
+	s.state.Lock()
+	lastRefresh := time.Now().Add(-time.Hour)
+	s.state.Set("last-refresh", lastRefresh)
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.timer", "0:00-24:00")
+	tr.Set("core", "refresh.splay", "1000h")
+	tr.Commit()
+	snapstate.DeviceSerial = func(*state.State) (string, error) { return "serial-1", nil }
+	defer func() { snapstate.DeviceSerial = nil }()
+	splay, err := snapstate.RefreshSplay(s.state)
+	c.Assert(err, IsNil)
+	s.state.Unlock()
+	// the splay goes past the end of the window
+	c.Assert(splay > 48*time.Hour, Equals, true)
+
+	sched, err := timeutil.ParseSchedule("0:00-24:00")
+	c.Assert(err, IsNil)
+	window := timeutil.NextWindow(sched, lastRefresh, 95*24*time.Hour)
+
+	af := snapstate.NewAutoRefresh(s.state)
+	now := time.Now()
+	err = af.Ensure()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+	next := af.NextRefresh()
+	if window.End.After(now) {
+		c.Check(next.Equal(window.End), Equals, true, Commentf("%v %v", next, window.End))
+	} else {
+		// the window is already over
+		c.Check(next.Sub(now) < time.Minute, Equals, true, Commentf("%v %v", next, now))
+	}
+}
+
 func (s *autoRefreshTestSuite) TestRefreshHoldForever(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -705,6 +843,42 @@ func (s *autoRefreshTestSuite) TestEnsureRefreshHoldAtLeast(c *C) {
 	c.Assert(t1.Format(time.RFC3339), Equals, t2.Format(time.RFC3339))
 }
 
+func (s *autoRefreshTestSuite) TestEnsureRefreshHoldAtLeastTimeSource(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	t0 := time.Now().Truncate(time.Second)
+	s.state.Set("last-refresh", t0.Add(-12*time.Hour))
+	clock := clocktest.New(t0)
+
+	af := snapstate.NewAutoRefresh(s.state)
+	snapstate.MockAutoRefreshTimeSource(af, clock)
+
+	getHold := func() time.Time {
+		tr := config.NewTransaction(s.state)
+		var hold time.Time
+		c.Assert(tr.Get("core", "refresh.hold", &hold), IsNil)
+		return hold
+	}
+
+	err := af.EnsureRefreshHoldAtLeast(time.Hour)
+	c.Assert(err, IsNil)
+	c.Check(getHold().Equal(t0.Add(time.Hour)), Equals, true)
+
+	s.state.Unlock()
+	err = af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	// refresh is held
+	c.Check(s.store.ops, HasLen, 0)
+
+	// 10 minutes are left of the hold, extend it
+	clock.Advance(50 * time.Minute)
+	err = af.EnsureRefreshHoldAtLeast(30 * time.Minute)
+	c.Assert(err, IsNil)
+	c.Check(getHold().Equal(t0.Add(80*time.Minute)), Equals, true)
+}
+
 func (s *autoRefreshTestSuite) TestEffectiveRefreshHold(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -738,6 +912,68 @@ func (s *autoRefreshTestSuite) TestEffectiveRefreshHold(c *C) {
 	c.Check(t2.Equal(longTime), Equals, true)
 }
 
+func (s *autoRefreshTestSuite) TestStatus(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	af := snapstate.NewAutoRefresh(s.state)
+	checkStatus := func(expected string) {
+		status, err := af.Status()
+		c.Assert(err, IsNil)
+		c.Check(status, Equals, expected)
+	}
+
+	checkStatus(snapstate.AutoRefreshScheduled)
+
+	// held
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.hold", time.Now().Add(time.Hour))
+	tr.Commit()
+	checkStatus(snapstate.AutoRefreshHeld)
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.hold", nil)
+	tr.Commit()
+	checkStatus(snapstate.AutoRefreshScheduled)
+
+	// held while on a metered connection
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.metered", "hold")
+	tr.Commit()
+	checkStatus(snapstate.AutoRefreshScheduled)
+	snapstate.IsOnMeteredConnection = func() (bool, error) { return true, nil }
+	defer func() { snapstate.IsOnMeteredConnection = func() (bool, error) { return false, nil } }()
+	checkStatus(snapstate.AutoRefreshHeld)
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.metered", nil)
+	tr.Commit()
+
+	// managed
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.timer", "managed")
+	tr.Commit()
+	checkStatus(snapstate.AutoRefreshScheduled)
+	snapstate.CanManageRefreshes = func(st *state.State) bool { return true }
+	defer func() { snapstate.CanManageRefreshes = nil }()
+	checkStatus(snapstate.AutoRefreshManaged)
+
+	// in progress
+	chg := s.state.NewChange("auto-refresh", "...")
+	checkStatus(snapstate.AutoRefreshInProgress)
+	chg.SetStatus(state.DoneStatus)
+	checkStatus(snapstate.AutoRefreshManaged)
+
+	// the system cannot refresh
+	snapstate.CanAutoRefresh = func(*state.State) (bool, error) { return false, nil }
+	checkStatus(snapstate.AutoRefreshDisabled)
+	snapstate.CanAutoRefresh = func(*state.State) (bool, error) { return true, nil }
+
+	// the store is offline
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "store.access", "offline")
+	tr.Commit()
+	checkStatus(snapstate.AutoRefreshDisabled)
+}
+
 func (s *autoRefreshTestSuite) TestEnsureLastRefreshAnchor(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -141,6 +141,36 @@ func (s *copydataSuite) TestCopyDataBails(c *C) {
 	c.Check(err, ErrorMatches, "cannot copy .*")
 }
 
+func (s *copydataSuite) TestCopyDataReadOnlyHome(c *C) {
+	homedir := filepath.Join(s.tempdir, "home", "user1", "snap")
+	homeData := filepath.Join(homedir, "hello/10")
+	c.Assert(os.MkdirAll(homeData, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(homeData, "canary.home"), nil, 0644), IsNil)
+
+	restore := backend.MockHomeNotWritableReason(func(dir string) string {
+		if dir == filepath.Join(homedir, "hello") {
+			return "is on a read-only filesystem"
+		}
+		return ""
+	})
+	defer restore()
+
+	v1 := snaptest.MockSnap(c, helloYaml1, &snap.SideInfo{Revision: snap.R(10)})
+	c.Assert(s.be.CopySnapData(v1, nil, nil, progress.Null), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(v1.DataDir(), "canary.txt"), nil, 0644), IsNil)
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	// the refresh is not blocked by the read-only home
+	v2 := snaptest.MockSnap(c, helloYaml2, &snap.SideInfo{Revision: snap.R(20)})
+	c.Assert(s.be.CopySnapData(v2, v1, nil, progress.Null), IsNil)
+
+	c.Check(filepath.Join(dirs.SnapDataDir, "hello/20", "canary.txt"), testutil.FilePresent)
+	c.Check(filepath.Join(homedir, "hello/20"), testutil.FileAbsent)
+	c.Check(logbuf.String(), testutil.Contains, fmt.Sprintf(`cannot copy snap data in "%s/hello": directory is on a read-only filesystem, skipping`, homedir))
+}
+
 // ensure that even with no home dir there is no error and the
 // system data gets copied
 func (s *copydataSuite) TestCopyDataNoUserHomes(c *C) {
@@ -73,3 +73,27 @@ func MockMkdirAllChown(f func(string, os.FileMode, sys.UserID, sys.GroupID) erro
 		mkdirAllChown = old
 	}
 }
+
+func MockHomeNotWritableReason(f func(dir string) string) (restore func()) {
+	old := homeNotWritableReason
+	homeNotWritableReason = f
+	return func() {
+		homeNotWritableReason = old
+	}
+}
+
+func MockDmverityMountSupported(supported bool) (restore func()) {
+	old := dmverityMountSupported
+	dmverityMountSupported = func() bool { return supported }
+	return func() {
+		dmverityMountSupported = old
+	}
+}
+
+func MockIntegrityGenerateAlongside(f func(snapPath string) error) (restore func()) {
+	old := integrityGenerateAlongside
+	integrityGenerateAlongside = f
+	return func() {
+		integrityGenerateAlongside = old
+	}
+}
@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap/integrity"
+	"github.com/snapcore/snapd/snap/integrity/dmverity"
+	"github.com/snapcore/snapd/snap/squashfs"
+)
+
+// IntegrityOptions controls the use of dm-verity protection when mounting
+// a snap.
+type IntegrityOptions struct {
+	// Generate requests generating integrity data alongside the snap
+	// when it carries none.
+	Generate bool
+	// Expected are the integrity data expected as declared by the
+	// snap-revision assertion of the snap, if any.
+	Expected *integrity.ExpectedData
+}
+
+var (
+	dmverityMountSupported     = dmverity.MountSupported
+	integrityGenerateAlongside = integrity.GenerateAlongside
+)
+
+// IntegrityMountOptions returns the mount options protecting the snap at
+// snapPath with dm-verity, or nil if the snap has no integrity data, is not
+// a squashfs file or the kernel does not support dm-verity.
+func IntegrityMountOptions(snapPath string, opts *IntegrityOptions) ([]string, error) {
+	if opts == nil || !dmverityMountSupported() {
+		return nil, nil
+	}
+	// tried snaps are mounted from a directory, and only squashfs
+	// snaps carry integrity data
+	if osutil.IsDirectory(snapPath) || !squashfs.FileHasSquashfsHeader(snapPath) {
+		return nil, nil
+	}
+	data, err := integrity.Lookup(snapPath, opts.Expected)
+	if err == integrity.ErrNoIntegrityDataFound && opts.Expected == nil && opts.Generate {
+		if genErr := integrityGenerateAlongside(snapPath); genErr != nil {
+			// not fatal, the snap is mounted without protection
+			logger.Noticef("cannot generate integrity data for %q: %v", snapPath, genErr)
+			return nil, nil
+		}
+		data, err = integrity.Lookup(snapPath, nil)
+	}
+	if err == integrity.ErrNoIntegrityDataFound {
+		if opts.Expected != nil {
+			return nil, fmt.Errorf("cannot find integrity data of %q declared by its snap-revision assertion", snapPath)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dmverity.MountOptions(&data.Header.DmVerity, dirs.StripRootDir(data.SourceFilePath), data.HashOffset()), nil
+}
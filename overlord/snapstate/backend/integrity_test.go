@@ -0,0 +1,175 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/snapstate/backend"
+	"github.com/snapcore/snapd/snap/integrity"
+	"github.com/snapcore/snapd/snap/integrity/dmverity"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type integritySuite struct {
+	testutil.BaseTest
+
+	snapPath string
+}
+
+var _ = Suite(&integritySuite{})
+
+func (s *integritySuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+	s.AddCleanup(backend.MockDmverityMountSupported(true))
+
+	s.snapPath = filepath.Join(dirs.SnapBlobDir, "foo_1.snap")
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	// a minimal fake squashfs
+	fs := make([]byte, 4096)
+	copy(fs, "hsqs")
+	binary.LittleEndian.PutUint64(fs[40:], 4096)
+	c.Assert(os.WriteFile(s.snapPath, fs, 0644), IsNil)
+}
+
+func (s *integritySuite) writeIntegrityData(c *C, path string) *integrity.IntegrityData {
+	header := integrity.IntegrityDataHeader{
+		Type:     "integrity",
+		Size:     integrity.HeaderSize + 4096,
+		DmVerity: dmverity.Info{RootHash: "1234"},
+	}
+	encoded, err := header.Encode()
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(path, append(encoded, make([]byte, 4096)...), 0644), IsNil)
+	data, err := integrity.Lookup(s.snapPath, nil)
+	c.Assert(err, IsNil)
+	return data
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsNoOptions(c *C) {
+	opts, err := backend.IntegrityMountOptions(s.snapPath, nil)
+	c.Assert(err, IsNil)
+	c.Check(opts, IsNil)
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsUnsupported(c *C) {
+	s.writeIntegrityData(c, s.snapPath+".verity")
+	restore := backend.MockDmverityMountSupported(false)
+	defer restore()
+
+	opts, err := backend.IntegrityMountOptions(s.snapPath, &backend.IntegrityOptions{})
+	c.Assert(err, IsNil)
+	c.Check(opts, IsNil)
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsTriedSnapDir(c *C) {
+	tryDir := c.MkDir()
+	opts, err := backend.IntegrityMountOptions(tryDir, &backend.IntegrityOptions{Generate: true})
+	c.Assert(err, IsNil)
+	c.Check(opts, IsNil)
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsNotSquashfs(c *C) {
+	c.Assert(os.WriteFile(s.snapPath, []byte("not a squashfs"), 0644), IsNil)
+	opts, err := backend.IntegrityMountOptions(s.snapPath, &backend.IntegrityOptions{Generate: true})
+	c.Assert(err, IsNil)
+	c.Check(opts, IsNil)
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsAlongside(c *C) {
+	data := s.writeIntegrityData(c, s.snapPath+".verity")
+
+	opts, err := backend.IntegrityMountOptions(s.snapPath, &backend.IntegrityOptions{
+		Expected: &integrity.ExpectedData{SHA3_384: data.SHA3_384, Size: data.Header.Size},
+	})
+	c.Assert(err, IsNil)
+	c.Check(opts, DeepEquals, []string{
+		"verity.hashdevice=/var/lib/snapd/snaps/foo_1.snap.verity",
+		"verity.roothash=1234",
+		"verity.hashoffset=4096",
+	})
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsMismatch(c *C) {
+	s.writeIntegrityData(c, s.snapPath+".verity")
+
+	_, err := backend.IntegrityMountOptions(s.snapPath, &backend.IntegrityOptions{
+		Expected: &integrity.ExpectedData{SHA3_384: "other", Size: 8192},
+	})
+	c.Check(err, ErrorMatches, `integrity data of ".*/foo_1.snap" do not match the expected ones .*`)
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsExpectedMissing(c *C) {
+	restore := backend.MockIntegrityGenerateAlongside(func(string) error {
+		c.Fatal("unexpected generation")
+		return nil
+	})
+	defer restore()
+
+	_, err := backend.IntegrityMountOptions(s.snapPath, &backend.IntegrityOptions{
+		Generate: true,
+		Expected: &integrity.ExpectedData{SHA3_384: "digest", Size: 8192},
+	})
+	c.Check(err, ErrorMatches, `cannot find integrity data of ".*/foo_1.snap" declared by its snap-revision assertion`)
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsGenerate(c *C) {
+	// nothing is generated unless requested
+	opts, err := backend.IntegrityMountOptions(s.snapPath, &backend.IntegrityOptions{})
+	c.Assert(err, IsNil)
+	c.Check(opts, IsNil)
+
+	var generated []string
+	restore := backend.MockIntegrityGenerateAlongside(func(snapPath string) error {
+		generated = append(generated, snapPath)
+		s.writeIntegrityData(c, snapPath+".verity")
+		return nil
+	})
+	defer restore()
+
+	opts, err = backend.IntegrityMountOptions(s.snapPath, &backend.IntegrityOptions{Generate: true})
+	c.Assert(err, IsNil)
+	c.Check(generated, DeepEquals, []string{s.snapPath})
+	c.Check(opts, DeepEquals, []string{
+		"verity.hashdevice=/var/lib/snapd/snaps/foo_1.snap.verity",
+		"verity.roothash=1234",
+		"verity.hashoffset=4096",
+	})
+}
+
+func (s *integritySuite) TestIntegrityMountOptionsGenerateError(c *C) {
+	restore := backend.MockIntegrityGenerateAlongside(func(snapPath string) error {
+		return errors.New("boom")
+	})
+	defer restore()
+
+	// the snap is mounted without protection
+	opts, err := backend.IntegrityMountOptions(s.snapPath, &backend.IntegrityOptions{Generate: true})
+	c.Assert(err, IsNil)
+	c.Check(opts, IsNil)
+}
@@ -26,17 +26,38 @@ import (
 	"github.com/snapcore/snapd/systemd"
 )
 
-func addMountUnit(s *snap.Info, preseed bool, meter progress.Meter) error {
-	squashfsPath := dirs.StripRootDir(s.MountFile())
-	whereDir := dirs.StripRootDir(s.MountDir())
-
+func addMountUnit(s *snap.Info, verityOptions []string, preseed bool, meter progress.Meter) error {
 	var sysd systemd.Systemd
 	if preseed {
 		sysd = systemd.NewEmulationMode(dirs.GlobalRootDir)
+		// the image being preseeded is mounted without dm-verity
+		verityOptions = nil
 	} else {
 		sysd = systemd.New(systemd.SystemMode, meter)
 	}
-	_, err := sysd.EnsureMountUnitFile(s.InstanceName(), s.Revision.String(), squashfsPath, whereDir, "squashfs")
+	return EnsureSnapMountUnit(sysd, s, verityOptions)
+}
+
+// EnsureSnapMountUnit ensures the mount unit of the given snap is in place.
+// If verityOptions are given the snap is mounted with dm-verity protection.
+func EnsureSnapMountUnit(sysd systemd.Systemd, s *snap.Info, verityOptions []string) error {
+	squashfsPath := dirs.StripRootDir(s.MountFile())
+	whereDir := dirs.StripRootDir(s.MountDir())
+
+	if len(verityOptions) == 0 {
+		_, err := sysd.EnsureMountUnitFile(s.InstanceName(), s.Revision.String(), squashfsPath, whereDir, "squashfs")
+		return err
+	}
+	// dm-verity requires the kernel squashfs implementation
+	_, err := sysd.EnsureMountUnitFileWithOptions(&systemd.MountUnitOptions{
+		Lifetime: systemd.Persistent,
+		SnapName: s.InstanceName(),
+		Revision: s.Revision.String(),
+		What:     squashfsPath,
+		Where:    whereDir,
+		Fstype:   "squashfs",
+		Options:  append(systemd.KernelSquashfsMountOptions(), verityOptions...),
+	})
 	return err
 }
 
@@ -28,6 +28,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/snapstate/backend"
 	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/sandbox/selinux"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/testutil"
@@ -48,6 +49,8 @@ type FakeSystemd struct {
 	EnsureMountUnitFileCalls  []ParamsForEnsureMountUnitFile
 	EnsureMountUnitFileResult ResultForEnsureMountUnitFile
 
+	EnsureMountUnitFileWithOptionsCalls []*systemd.MountUnitOptions
+
 	RemoveMountUnitFileCalls  []string
 	RemoveMountUnitFileResult error
 
@@ -61,6 +64,11 @@ func (s *FakeSystemd) EnsureMountUnitFile(name, revision, what, where, fstype st
 	return s.EnsureMountUnitFileResult.path, s.EnsureMountUnitFileResult.err
 }
 
+func (s *FakeSystemd) EnsureMountUnitFileWithOptions(opts *systemd.MountUnitOptions) (string, error) {
+	s.EnsureMountUnitFileWithOptionsCalls = append(s.EnsureMountUnitFileWithOptionsCalls, opts)
+	return s.EnsureMountUnitFileResult.path, s.EnsureMountUnitFileResult.err
+}
+
 func (s *FakeSystemd) RemoveMountUnitFile(mountDir string) error {
 	s.RemoveMountUnitFileCalls = append(s.RemoveMountUnitFileCalls, mountDir)
 	return s.RemoveMountUnitFileResult
@@ -114,7 +122,7 @@ func (s *mountunitSuite) TestAddMountUnit(c *C) {
 		Version:       "1.1",
 		Architectures: []string{"all"},
 	}
-	err := backend.AddMountUnit(info, false, progress.Null)
+	err := backend.AddMountUnit(info, nil, false, progress.Null)
 	c.Check(err, Equals, expectedErr)
 
 	// ensure correct parameters
@@ -130,6 +138,39 @@ func (s *mountunitSuite) TestAddMountUnit(c *C) {
 	})
 }
 
+func (s *mountunitSuite) TestAddMountUnitWithVerity(c *C) {
+	restore := selinux.MockIsEnabled(func() (bool, error) { return false, nil })
+	defer restore()
+
+	var sysd *FakeSystemd
+	restore = systemd.MockNewSystemd(func(be systemd.Backend, roodDir string, mode systemd.InstanceMode, meter systemd.Reporter) systemd.Systemd {
+		sysd = &FakeSystemd{}
+		return sysd
+	})
+	defer restore()
+
+	info := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(13),
+		},
+	}
+	verityOptions := []string{"verity.hashdevice=/var/lib/snapd/snaps/foo_13.snap", "verity.roothash=1234", "verity.hashoffset=8192"}
+	err := backend.AddMountUnit(info, verityOptions, false, progress.Null)
+	c.Assert(err, IsNil)
+
+	c.Check(sysd.EnsureMountUnitFileCalls, HasLen, 0)
+	c.Assert(sysd.EnsureMountUnitFileWithOptionsCalls, HasLen, 1)
+	opts := sysd.EnsureMountUnitFileWithOptionsCalls[0]
+	c.Check(opts.SnapName, Equals, "foo")
+	c.Check(opts.Revision, Equals, "13")
+	c.Check(opts.What, Equals, "/var/lib/snapd/snaps/foo_13.snap")
+	c.Check(opts.Where, Equals, fmt.Sprintf("%s/foo/13", dirs.StripRootDir(dirs.SnapMountDir)))
+	c.Check(opts.Fstype, Equals, "squashfs")
+	c.Check(opts.Lifetime, Equals, systemd.Persistent)
+	c.Check(opts.Options, DeepEquals, append(systemd.KernelSquashfsMountOptions(), verityOptions...))
+}
+
 func (s *mountunitSuite) TestRemoveMountUnit(c *C) {
 	expectedErr := errors.New("removal error")
 
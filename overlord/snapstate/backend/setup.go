@@ -29,6 +29,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 )
 
 // InstallRecord keeps a record of what installation effectively did as hints
@@ -41,6 +42,9 @@ type InstallRecord struct {
 
 type SetupSnapOptions struct {
 	SkipKernelExtraction bool
+	// Integrity enables mounting the snap with dm-verity protection if
+	// possible.
+	Integrity *IntegrityOptions
 }
 
 // SetupSnap does prepare and mount the snap for further processing.
@@ -95,8 +99,13 @@ func (b Backend) SetupSnap(snapFilePath, instanceName string, sideInfo *snap.Sid
 		return snapType, nil, err
 	}
 
+	verityOptions, err := IntegrityMountOptions(s.MountFile(), setupOpts.Integrity)
+	if err != nil {
+		return snapType, nil, err
+	}
+
 	// generate the mount unit for the squashfs
-	if err := addMountUnit(s, b.preseed, meter); err != nil {
+	if err := addMountUnit(s, verityOptions, b.preseed, meter); err != nil {
 		return snapType, nil, err
 	}
 
@@ -141,6 +150,10 @@ func (b Backend) RemoveSnapFiles(s snap.PlaceInfo, typ snap.Type, installRecord
 				return err
 			}
 		}
+		// and the integrity data generated alongside it, if any
+		if err := os.Remove(snapPath + integrity.AlongsideSuffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
 	}
 
 	return nil
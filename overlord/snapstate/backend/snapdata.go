@@ -24,7 +24,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	unix "syscall"
+
+	"golang.org/x/sys/unix"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
@@ -153,6 +154,17 @@ func snapCommonDataDirs(snap *snap.Info, opts *dirs.SnapDirOptions) ([]string, e
 	return found, nil
 }
 
+// homeNotWritableReason returns why the per-user data directory dir cannot
+// be written to, if that is because its filesystem is read-only or on NFS
+// refusing writes from root, or the empty string otherwise.
+var homeNotWritableReason = func(dir string) string {
+	err := unix.Access(dir, unix.W_OK)
+	if err == nil {
+		return ""
+	}
+	return osutil.HomeNotWritableReason(err)
+}
+
 // Copy all data for oldSnap to newSnap
 // (but never overwrite)
 func copySnapData(oldSnap, newSnap *snap.Info, opts *dirs.SnapDirOptions) (err error) {
@@ -178,6 +190,15 @@ func copySnapData(oldSnap, newSnap *snap.Info, opts *dirs.SnapDirOptions) (err e
 
 	newSuffix := filepath.Base(newSnap.DataDir())
 	for _, oldDir := range oldDataDirs {
+		// home directories on a read-only filesystem or on NFS with
+		// root squashing cannot be updated, the data the snap had
+		// there is left as is
+		if oldDir != oldSnap.DataDir() {
+			if reason := homeNotWritableReason(filepath.Dir(oldDir)); reason != "" {
+				logger.Noticef("cannot copy snap data in %q: directory %s, skipping", filepath.Dir(oldDir), reason)
+				continue
+			}
+		}
 		// replace the trailing "../$old-suffix" with the "../$new-suffix"
 		newDir := filepath.Join(filepath.Dir(oldDir), newSuffix)
 		if err := copySnapDataDirectory(oldDir, newDir); err != nil {
@@ -26,6 +26,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -72,6 +73,7 @@ type fakeOp struct {
 	otherInstances         bool
 	unlinkFirstInstallUndo bool
 	skipKernelExtraction   bool
+	integrity              *backend.IntegrityOptions
 
 	services         []string
 	disabledServices []string
@@ -167,9 +169,11 @@ type fakeStore struct {
 	fakeCurrentProgress int
 	fakeTotalProgress   int
 	// snap -> error map for simulating download errors
-	downloadError   map[string]error
-	state           *state.State
-	seenPrivacyKeys map[string]bool
+	downloadError map[string]error
+	// snap -> content that failed digest verification during download
+	downloadMismatch map[string]*store.DownloadMismatch
+	state            *state.State
+	seenPrivacyKeys  map[string]bool
 
 	downloadCallback func()
 }
@@ -707,8 +711,16 @@ func (f *fakeStore) Download(ctx context.Context, name, targetFn string, snapInf
 	if user != nil {
 		macaroon = user.StoreMacaroon
 	}
+	var onHashMismatch func(*store.DownloadMismatch)
+	if dlOpts != nil {
+		// the hash mismatch callback is not comparable
+		opts := *dlOpts
+		onHashMismatch = opts.OnHashMismatch
+		opts.OnHashMismatch = nil
+		dlOpts = &opts
+	}
 	// only add the options if they contain anything interesting
-	if dlOpts != nil && *dlOpts == (store.DownloadOptions{}) {
+	if dlOpts != nil && reflect.DeepEqual(*dlOpts, store.DownloadOptions{}) {
 		dlOpts = nil
 	}
 	f.downloads = append(f.downloads, fakeDownload{
@@ -724,6 +736,10 @@ func (f *fakeStore) Download(ctx context.Context, name, targetFn string, snapInf
 		pb.Set(float64(f.fakeCurrentProgress))
 	}
 
+	if mismatch, ok := f.downloadMismatch[name]; ok && onHashMismatch != nil {
+		onHashMismatch(mismatch)
+	}
+
 	if e, ok := f.downloadError[name]; ok {
 		return e
 	}
@@ -862,14 +878,18 @@ func (f *fakeSnappyBackend) SetupSnap(snapFilePath, instanceName string, si *sna
 	if si != nil {
 		revno = si.Revision
 	}
-	f.appendOp(&fakeOp{
+	op := &fakeOp{
 		op:    "setup-snap",
 		name:  instanceName,
 		path:  snapFilePath,
 		revno: revno,
 
 		skipKernelExtraction: opts != nil && opts.SkipKernelExtraction,
-	})
+	}
+	if opts != nil {
+		op.integrity = opts.Integrity
+	}
+	f.appendOp(op)
 	snapType := snap.TypeApp
 	switch si.RealName {
 	case "core":
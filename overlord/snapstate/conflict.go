@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/strutil"
 )
@@ -76,6 +77,88 @@ func RegisterAffectedSnapsByKind(kind string, f AffectedSnapsFunc) {
 	affectedSnapsByKind[kind] = f
 }
 
+// A ConflictQueuePredicate returns whether an operation on the given snap
+// that conflicts with the given change in progress can be queued behind
+// it instead of failing.
+type ConflictQueuePredicate func(conflicting *state.Change, instanceName string) bool
+
+func alwaysQueue(*state.Change, string) bool { return true }
+
+var conflictQueuePredicates = map[string]ConflictQueuePredicate{
+	"install-snap": alwaysQueue,
+	"refresh-snap": alwaysQueue,
+	"remove-snap":  alwaysQueue,
+	"revert-snap":  alwaysQueue,
+	"enable-snap":  alwaysQueue,
+	"disable-snap": alwaysQueue,
+	"switch-snap":  alwaysQueue,
+	"auto-refresh": alwaysQueue,
+}
+
+// RegisterConflictQueuePredicate registers a ConflictQueuePredicate for
+// changes of the given kind. Operations requested with QueueOnConflict
+// that conflict with changes of kinds without a predicate still fail.
+func RegisterConflictQueuePredicate(changeKind string, f ConflictQueuePredicate) {
+	conflictQueuePredicates[changeKind] = f
+}
+
+// queueableConflict returns the change in progress err is a conflict
+// with, if an operation on the given snap can be queued behind it.
+func queueableConflict(st *state.State, err error, instanceName string) *state.Change {
+	var conflErr *ChangeConflictError
+	if !errors.As(err, &conflErr) || conflErr.ChangeID == "" {
+		return nil
+	}
+	chg := st.Change(conflErr.ChangeID)
+	if chg == nil || chg.IsReady() {
+		return nil
+	}
+	f := conflictQueuePredicates[chg.Kind()]
+	if f == nil || !f(chg, instanceName) {
+		return nil
+	}
+	return chg
+}
+
+// queuedSnapOp describes an operation on a snap that was queued behind
+// a conflicting change and is planned only once that change is ready.
+type queuedSnapOp struct {
+	Action  string           `json:"action"`
+	Name    string           `json:"name"`
+	RevOpts *RevisionOptions `json:"rev-opts,omitempty"`
+	UserID  int              `json:"user-id,omitempty"`
+	Flags   Flags            `json:"flags"`
+}
+
+// queueOnConflict calls op to create the tasks of the given operation. If
+// they conflict with a change in progress that the operation can be queued
+// behind, a single task waiting for that change is returned instead, which
+// plans the operation against the state left behind by the change once it
+// is ready. Only one conflicting change can be waited for, conflicting with
+// others then fails when the operation is planned.
+func queueOnConflict(st *state.State, qop *queuedSnapOp, op func() (*state.TaskSet, error)) (*state.TaskSet, error) {
+	ts, err := op()
+	chg := queueableConflict(st, err, qop.Name)
+	if chg == nil {
+		return ts, err
+	}
+	if ts != nil {
+		st.DiscardTasks(ts.Tasks())
+	}
+	t := st.NewTask("plan-queued-snap-op", fmt.Sprintf(i18n.G("Plan %s of snap %q queued behind change %s"), qop.Action, qop.Name, chg.ID()))
+	t.Set("queued-snap-op", qop)
+	t.WaitForChange(chg)
+	return state.NewTaskSet(t), nil
+}
+
+func queuedSnapOpAffectedSnaps(t *state.Task) ([]string, error) {
+	var qop queuedSnapOp
+	if err := t.Get("queued-snap-op", &qop); err != nil {
+		return nil, fmt.Errorf("internal error: cannot get queued operation for %s task %s: %v", t.Kind(), t.ID(), err)
+	}
+	return []string{qop.Name}, nil
+}
+
 func affectedSnaps(t *state.Task) ([]string, error) {
 	// snapstate's own styled tasks
 	if t.Has("snap-setup") || t.Has("snap-setup-task") {
@@ -83,7 +166,13 @@ func affectedSnaps(t *state.Task) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("internal error: cannot obtain snap setup from task: %s", t.Summary())
 		}
-		return []string{snapsup.InstanceName()}, nil
+		snaps := []string{snapsup.InstanceName()}
+		// forced aliases are taken from other snaps
+		var otherSnaps []string
+		if err := t.Get("other-snaps", &otherSnaps); err != nil && !errors.Is(err, state.ErrNoState) {
+			return nil, err
+		}
+		return append(snaps, otherSnaps...), nil
 	}
 
 	if f := affectedSnapsByKind[t.Kind()]; f != nil {
@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeutil"
 	userclient "github.com/snapcore/snapd/usersession/client"
 )
 
@@ -173,6 +174,8 @@ var (
 	NewAutoRefresh                = newAutoRefresh
 	NewRefreshHints               = newRefreshHints
 	CanRefreshOnMeteredConnection = canRefreshOnMeteredConnection
+	HandleWithdrawnSnaps          = handleWithdrawnSnaps
+	RecordWithdrawnSnaps          = recordWithdrawnSnaps
 
 	NewCatalogRefresh            = newCatalogRefresh
 	CatalogRefreshDelayBase      = catalogRefreshDelayBase
@@ -193,6 +196,12 @@ func MockLastRefreshSchedule(ar *autoRefresh, schedule string) {
 	ar.lastRefreshSchedule = schedule
 }
 
+var RefreshSplay = refreshSplay
+
+func MockAutoRefreshTimeSource(ar *autoRefresh, ts timeutil.TimeSource) {
+	ar.timeSource = ts
+}
+
 func MockCatalogRefreshNextRefresh(cr *catalogRefresh, when time.Time) {
 	cr.nextCatalogRefresh = when
 }
@@ -487,6 +496,18 @@ func MockCgroupMonitorSnapEnded(f func(string, chan<- string) error) func() {
 	}
 }
 
+func MockCgroupKillSnapProcesses(f func(string) error) func() {
+	old := cgroupKillSnapProcesses
+	cgroupKillSnapProcesses = f
+	return func() {
+		cgroupKillSnapProcesses = old
+	}
+}
+
 func SetRestoredMonitoring(snapmgr *SnapManager, value bool) {
 	snapmgr.autoRefresh.restoredMonitoring = value
 }
+
+func (m *SnapManager) EnsureStatusFile() error {
+	return m.ensureStatusFile()
+}
@@ -113,6 +113,11 @@ type Flags struct {
 
 	// Lane is the lane that tasks should join if Transaction is set to "all-snaps".
 	Lane int `json:"lane,omitempty"`
+
+	// QueueOnConflict is set to request that the operation is queued
+	// behind a conflicting change in progress, if possible, instead of
+	// failing.
+	QueueOnConflict bool `json:"queue-on-conflict,omitempty"`
 }
 
 // DevModeAllowed returns whether a snap can be installed with devmode
@@ -130,5 +135,6 @@ func (f Flags) ForSnapSetup() Flags {
 	f.RequireTypeBase = false
 	f.ApplySnapDevMode = false
 	f.Lane = 0
+	f.QueueOnConflict = false
 	return f
 }
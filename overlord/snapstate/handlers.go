@@ -57,11 +57,14 @@ import (
 	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
 	"github.com/snapcore/snapd/sandbox/cgroup"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/snap/snapenv"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/snapdtool"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 	"github.com/snapcore/snapd/timings"
 	userclient "github.com/snapcore/snapd/usersession/client"
 	"github.com/snapcore/snapd/wrappers"
@@ -82,6 +85,8 @@ var SecurityProfilesRemoveLate = func(snapName string, rev snap.Revision, typ sn
 
 var cgroupMonitorSnapEnded = cgroup.MonitorSnapEnded
 
+var cgroupKillSnapProcesses = cgroup.KillSnapProcesses
+
 // TaskSnapSetup returns the SnapSetup with task params hold by or referred to by the task.
 func TaskSnapSetup(t *state.Task) (*SnapSetup, error) {
 	var snapsup SnapSetup
@@ -708,6 +713,42 @@ func autoRefreshRateLimited(st *state.State) (rate int64) {
 	return val
 }
 
+// downloadStagingDir returns the directory set with store.download-dir in
+// which snap downloads are staged before being moved into place, or "" if
+// they are staged next to their target.
+func downloadStagingDir(st *state.State) string {
+	tr := config.NewTransaction(st)
+
+	var dir string
+	if err := tr.Get("core", "store.download-dir", &dir); err != nil {
+		return ""
+	}
+	return dir
+}
+
+// autoRefreshDownloadDelay returns how long auto-refresh downloads need to
+// wait for the window set with refresh.download-window to open, or 0 if they
+// can proceed now.
+func autoRefreshDownloadDelay(st *state.State) time.Duration {
+	tr := config.NewTransaction(st)
+
+	var window string
+	if err := tr.Get("core", "refresh.download-window", &window); err != nil || window == "" {
+		return 0
+	}
+	// the window was validated when it was set
+	schedule, err := timeutil.ParseSchedule(window)
+	if err != nil {
+		return 0
+	}
+	now := timeNow()
+	if timeutil.Includes(schedule, now) {
+		return 0
+	}
+	// windows can be as far apart as a month, eg. mon1,1:00-5:00
+	return timeutil.Next(schedule, now, 31*24*time.Hour)
+}
+
 func downloadSnapParams(st *state.State, t *state.Task) (*SnapSetup, StoreService, *auth.UserState, error) {
 	snapsup, err := TaskSnapSetup(t)
 	if err != nil {
@@ -732,18 +773,29 @@ func downloadSnapParams(st *state.State, t *state.Task) (*SnapSetup, StoreServic
 func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	st := t.State()
 	var rate int64
+	var delay time.Duration
 
 	st.Lock()
 	perfTimings := state.TimingsForTask(t)
+	stagingDir := downloadStagingDir(st)
 	snapsup, theStore, user, err := downloadSnapParams(st, t)
 	if snapsup != nil && snapsup.IsAutoRefresh {
 		// NOTE rate is never negative
 		rate = autoRefreshRateLimited(st)
+		// manual installs and refreshes are not subject to the
+		// download window
+		delay = autoRefreshDownloadDelay(st)
+		if delay > 0 {
+			t.Logf("Download postponed until the download window opens at %s", timeNow().Add(delay).Format(time.RFC3339))
+		}
 	}
 	st.Unlock()
 	if err != nil {
 		return err
 	}
+	if delay > 0 {
+		return &state.Retry{After: delay, Reason: "outside of the download window"}
+	}
 
 	if err := waitForPreDownload(t, snapsup); err != nil {
 		return err
@@ -752,9 +804,14 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	meter := NewTaskProgressAdapterUnlocked(t)
 	targetFn := snapsup.MountFile()
 
+	var mismatches []*store.DownloadMismatch
 	dlOpts := &store.DownloadOptions{
-		Scheduled: snapsup.IsAutoRefresh,
-		RateLimit: rate,
+		Scheduled:  snapsup.IsAutoRefresh,
+		RateLimit:  rate,
+		StagingDir: stagingDir,
+		OnHashMismatch: func(mismatch *store.DownloadMismatch) {
+			mismatches = append(mismatches, mismatch)
+		},
 	}
 	if snapsup.DownloadInfo == nil {
 		var storeInfo store.SnapActionResult
@@ -774,6 +831,11 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 			err = theStore.Download(tomb.Context(nil), snapsup.SnapName(), targetFn, snapsup.DownloadInfo, meter, user, dlOpts)
 		})
 	}
+	if len(mismatches) != 0 {
+		st.Lock()
+		warnDownloadMismatches(st, mismatches)
+		st.Unlock()
+	}
 	if err != nil {
 		return err
 	}
@@ -789,6 +851,23 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	return nil
 }
 
+// warnDownloadMismatches warns about downloads that failed digest
+// verification, as they can point to a corrupted mirror or CDN or to the
+// content being tampered with on its way.
+func warnDownloadMismatches(st *state.State, mismatches []*store.DownloadMismatch) {
+	for _, mismatch := range mismatches {
+		msg := fmt.Sprintf("download of snap %q from %s failed digest verification", mismatch.Name, mismatch.URL)
+		if mismatch.RequestID != "" {
+			msg += fmt.Sprintf(" (request ID %s)", mismatch.RequestID)
+		}
+		msg += ", this could indicate a corrupted mirror or tampering with the download"
+		if mismatch.QuarantinePath != "" {
+			msg += fmt.Sprintf("; the downloaded content was saved as %s", mismatch.QuarantinePath)
+		}
+		st.Warnf("%s", msg)
+	}
+}
+
 func waitForPreDownload(task *state.Task, snapsup *SnapSetup) error {
 	st := task.State()
 	st.Lock()
@@ -832,12 +911,21 @@ func (m *SnapManager) doPreDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	if err != nil {
 		return err
 	}
+	if delay := autoRefreshDownloadDelay(st); delay > 0 {
+		t.Logf("Download postponed until the download window opens at %s", timeNow().Add(delay).Format(time.RFC3339))
+		return &state.Retry{After: delay, Reason: "outside of the download window"}
+	}
 
 	targetFn := snapsup.MountFile()
+	var mismatches []*store.DownloadMismatch
 	dlOpts := &store.DownloadOptions{
 		// pre-downloads are only triggered in auto-refreshes
-		Scheduled: true,
-		RateLimit: autoRefreshRateLimited(st),
+		Scheduled:  true,
+		RateLimit:  autoRefreshRateLimited(st),
+		StagingDir: downloadStagingDir(st),
+		OnHashMismatch: func(mismatch *store.DownloadMismatch) {
+			mismatches = append(mismatches, mismatch)
+		},
 	}
 
 	perfTimings := state.TimingsForTask(t)
@@ -846,6 +934,7 @@ func (m *SnapManager) doPreDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 		err = theStore.Download(tomb.Context(nil), snapsup.SnapName(), targetFn, snapsup.DownloadInfo, nil, user, dlOpts)
 	})
 	st.Lock()
+	warnDownloadMismatches(st, mismatches)
 	if err != nil {
 		return err
 	}
@@ -1200,8 +1289,17 @@ func (m *SnapManager) doMountSnap(t *state.Task, _ *tomb.Tomb) error {
 
 	}
 
+	st.Lock()
+	integrityOpts, err := integrityOptions(st, snapsup)
+	st.Unlock()
+	if err != nil {
+		cleanup()
+		return err
+	}
+
 	setupOpts := &backend.SetupSnapOptions{
 		SkipKernelExtraction: snapsup.SkipKernelExtraction,
+		Integrity:            integrityOpts,
 	}
 	pb := NewTaskProgressAdapterUnlocked(t)
 	// TODO Use snapsup.Revision() to obtain the right info to mount
@@ -1270,6 +1368,41 @@ func (m *SnapManager) doMountSnap(t *state.Task, _ *tomb.Tomb) error {
 	return nil
 }
 
+// integrityOptions returns the options to mount the snap with dm-verity
+// protection, or nil if the snap-integrity feature is disabled.
+func integrityOptions(st *state.State, snapsup *SnapSetup) (*backend.IntegrityOptions, error) {
+	tr := config.NewTransaction(st)
+	enabled, err := features.Flag(tr, features.SnapIntegrity)
+	if err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	expected, err := expectedIntegrity(st, snapsup.SideInfo.SnapID, snapsup.Revision())
+	if err != nil {
+		return nil, err
+	}
+	return &backend.IntegrityOptions{Generate: true, Expected: expected}, nil
+}
+
+// expectedIntegrity returns the integrity data declared by the
+// snap-revision assertion of the given snap revision, if any.
+func expectedIntegrity(st *state.State, snapID string, rev snap.Revision) (*integrity.ExpectedData, error) {
+	if snapID == "" || rev.Local() || SnapRevisionIntegrity == nil {
+		return nil, nil
+	}
+	snapIntegrity, err := SnapRevisionIntegrity(st, snapID, rev)
+	if err != nil || snapIntegrity == nil {
+		return nil, err
+	}
+	return &integrity.ExpectedData{
+		SHA3_384: snapIntegrity.SHA3_384,
+		Size:     snapIntegrity.Size,
+	}, nil
+}
+
 func (m *SnapManager) undoMountSnap(t *state.Task, _ *tomb.Tomb) error {
 	st := t.State()
 	st.Lock()
@@ -3105,6 +3238,23 @@ func (m *SnapManager) undoStopSnapServices(t *state.Task, _ *tomb.Tomb) error {
 	return nil
 }
 
+// doKillSnapApps terminates the processes of the snap still running
+// after its services were stopped, so that it can be removed.
+func (m *SnapManager) doKillSnapApps(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	snapsup, err := TaskSnapSetup(t)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := cgroupKillSnapProcesses(snapsup.InstanceName()); err != nil {
+		return fmt.Errorf("cannot terminate running apps of snap %q: %v", snapsup.InstanceName(), err)
+	}
+	return nil
+}
+
 func (m *SnapManager) doUnlinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	// invoked only if snap has a current active revision, during remove or
 	// disable
@@ -3341,6 +3491,10 @@ func (m *SnapManager) doDiscardSnap(t *state.Task, _ *tomb.Tomb) error {
 		if err != nil {
 			return err
 		}
+		// and the environment exported from its env.* configuration
+		if err := snapenv.WriteConfigEnv(snapsup.InstanceName(), nil); err != nil {
+			return err
+		}
 		err = m.backend.DiscardSnapNamespace(snapsup.InstanceName())
 		if err != nil {
 			t.Errorf("cannot discard snap namespace %q, will retry in 3 mins: %s", snapsup.InstanceName(), err)
@@ -3740,13 +3894,11 @@ func (m *SnapManager) doAlias(t *state.Task, _ *tomb.Tomb) error {
 	if err != nil {
 		return err
 	}
-	var target, alias string
-	err = t.Get("target", &target)
-	if err != nil {
+	var allApps, force bool
+	if err := t.Get("all-apps", &allApps); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
 	}
-	err = t.Get("alias", &alias)
-	if err != nil {
+	if err := t.Get("force", &force); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
 	}
 
@@ -3758,15 +3910,61 @@ func (m *SnapManager) doAlias(t *state.Task, _ *tomb.Tomb) error {
 
 	autoDisabled := snapst.AutoAliasesDisabled
 	curAliases := snapst.Aliases
-	newAliases, err := manualAlias(curInfo, curAliases, target, alias)
+	var newAliases map[string]*AliasTarget
+	if allApps {
+		newAliases, err = manualAliasAllApps(curInfo, curAliases)
+	} else {
+		var target, alias string
+		if err := t.Get("target", &target); err != nil {
+			return err
+		}
+		if err := t.Get("alias", &alias); err != nil {
+			return err
+		}
+		newAliases, err = manualAlias(curInfo, curAliases, target, alias)
+	}
 	if err != nil {
 		return err
 	}
-	_, err = checkAliasesConflicts(st, snapName, autoDisabled, newAliases, nil)
-	if err != nil {
+	aliasConflicts, err := checkAliasesConflicts(st, snapName, autoDisabled, newAliases, nil)
+	conflErr, isConflErr := err.(*AliasConflictError)
+	if err != nil && (!force || !isConflErr || conflErr.Conflicts == nil) {
+		// without --force, or for a snap command namespace
+		// conflict, we cannot remedy it
 		return err
 	}
 
+	// with force, take over the conflicting aliases from the other
+	// snaps before setting up ours
+	otherSnapStates := make(map[string]*SnapState, len(aliasConflicts))
+	otherSnapDisabled := make(map[string]*otherDisabledAliases, len(aliasConflicts))
+	for otherSnap, conflicting := range aliasConflicts {
+		var otherSnapState SnapState
+		if err := Get(st, otherSnap, &otherSnapState); err != nil {
+			return err
+		}
+
+		otherAliases, otherAutoDisabled, disabledManual := stealAliases(otherSnapState.Aliases, otherSnapState.AutoAliasesDisabled, conflicting)
+
+		added, removed, err := applyAliasesChange(otherSnap, otherSnapState.AutoAliasesDisabled, otherSnapState.Aliases, otherAutoDisabled, otherAliases, m.backend, otherSnapState.AliasesPending)
+		if err != nil {
+			return err
+		}
+		if err := aliasesTrace(t, added, removed); err != nil {
+			return err
+		}
+
+		otherDisabled := &otherDisabledAliases{
+			Auto:   otherAutoDisabled && !otherSnapState.AutoAliasesDisabled,
+			Manual: disabledManual,
+		}
+		logger.Noticef("Disabled aliases %s of snap %q to enable them for snap %q", strutil.Quoted(conflicting), otherSnap, snapName)
+		otherSnapState.Aliases = otherAliases
+		otherSnapState.AutoAliasesDisabled = otherAutoDisabled
+		otherSnapDisabled[otherSnap] = otherDisabled
+		otherSnapStates[otherSnap] = &otherSnapState
+	}
+
 	added, removed, err := applyAliasesChange(snapName, autoDisabled, curAliases, autoDisabled, newAliases, m.backend, snapst.AliasesPending)
 	if err != nil {
 		return err
@@ -3775,6 +3973,12 @@ func (m *SnapManager) doAlias(t *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
+	for otherSnap, otherSnapState := range otherSnapStates {
+		Set(st, otherSnap, otherSnapState)
+	}
+	if len(otherSnapDisabled) != 0 {
+		t.Set("other-disabled-aliases", otherSnapDisabled)
+	}
 	t.Set("old-aliases-v2", curAliases)
 	snapst.Aliases = newAliases
 	Set(st, snapName, snapst)
@@ -3819,18 +4023,26 @@ func (m *SnapManager) doUnalias(t *state.Task, _ *tomb.Tomb) error {
 	if err != nil {
 		return err
 	}
-	var alias string
-	err = t.Get("alias", &alias)
-	if err != nil {
+	var allManual bool
+	if err := t.Get("all-manual", &allManual); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
 	}
 	snapName := snapsup.InstanceName()
 
 	autoDisabled := snapst.AutoAliasesDisabled
 	oldAliases := snapst.Aliases
-	newAliases, err := manualUnalias(oldAliases, alias)
-	if err != nil {
-		return err
+	var newAliases map[string]*AliasTarget
+	if allManual {
+		newAliases = manualUnaliasAll(oldAliases)
+	} else {
+		var alias string
+		if err := t.Get("alias", &alias); err != nil {
+			return err
+		}
+		newAliases, err = manualUnalias(oldAliases, alias)
+		if err != nil {
+			return err
+		}
 	}
 
 	added, removed, err := applyAliasesChange(snapName, autoDisabled, oldAliases, autoDisabled, newAliases, m.backend, snapst.AliasesPending)
@@ -4141,6 +4353,49 @@ func (m *SnapManager) doCheckReRefresh(t *state.Task, tomb *tomb.Tomb) error {
 	return nil
 }
 
+// doPlanQueuedSnapOp plans an operation that was queued behind a
+// conflicting change, now that the change is ready, and adds its tasks to
+// the task's change.
+func (m *SnapManager) doPlanQueuedSnapOp(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var qop queuedSnapOp
+	if err := t.Get("queued-snap-op", &qop); err != nil {
+		return err
+	}
+
+	chg := t.Change()
+	var ts *state.TaskSet
+	var err error
+	switch qop.Action {
+	case "install":
+		ts, err = InstallWithDeviceContext(context.TODO(), st, qop.Name, qop.RevOpts, qop.UserID, qop.Flags, nil, chg.ID())
+	case "refresh":
+		ts, err = UpdateWithDeviceContext(st, qop.Name, qop.RevOpts, qop.UserID, qop.Flags, nil, chg.ID())
+		if errors.Is(err, store.ErrNoUpdateAvailable) {
+			t.Logf("no update available for snap %q", qop.Name)
+			return nil
+		}
+	default:
+		return fmt.Errorf("internal error: unknown queued operation %q", qop.Action)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, lane := range t.Lanes() {
+		ts.JoinLane(lane)
+	}
+	ts.WaitFor(t)
+	chg.AddAll(ts)
+
+	t.SetStatus(state.DoneStatus)
+	st.EnsureBefore(0)
+	return nil
+}
+
 func (m *SnapManager) doConditionalAutoRefresh(t *state.Task, tomb *tomb.Tomb) error {
 	st := t.State()
 	st.Lock()
@@ -21,6 +21,7 @@ package snapstate_test
 
 import (
 	"path/filepath"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -175,6 +176,50 @@ func (s *downloadSnapSuite) TestDoDownloadSnapNormal(c *C) {
 	})
 }
 
+func (s *downloadSnapSuite) TestDoDownloadSnapWarnsOnHashMismatch(c *C) {
+	s.state.Lock()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "mySnapID",
+		Revision: snap.R(11),
+	}
+	s.fakeStore.downloadMismatch = map[string]*store.DownloadMismatch{
+		"foo": {
+			Name:           "foo",
+			URL:            "http://some-cdn.com/snap",
+			RequestID:      "req-id",
+			QuarantinePath: "/var/lib/snapd/quarantine/foo.blob",
+		},
+	}
+
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+	})
+	chg := s.state.NewChange("sample", "...")
+	chg.AddTask(t)
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// the retried download succeeded
+	c.Assert(chg.Err(), IsNil)
+	c.Check(t.Status(), Equals, state.DoneStatus)
+
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `download of snap "foo" from http://some-cdn.com/snap failed digest verification (request ID req-id), this could indicate a corrupted mirror or tampering with the download; the downloaded content was saved as /var/lib/snapd/quarantine/foo.blob`)
+}
+
 func (s *downloadSnapSuite) TestDoDownloadSnapWithDeviceContext(c *C) {
 	s.state.Lock()
 
@@ -259,6 +304,85 @@ func (s *downloadSnapSuite) TestDoUndoDownloadSnap(c *C) {
 
 }
 
+func (s *downloadSnapSuite) TestDoDownloadOutsideDownloadWindow(c *C) {
+	now := time.Now()
+	// the window opens in two hours
+	start := now.Add(2 * time.Hour)
+	end := start.Add(time.Hour)
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.download-window", start.Format("15:04")+"-"+end.Format("15:04"))
+	tr.Commit()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+		Flags: snapstate.Flags{
+			IsAutoRefresh: true,
+		},
+	})
+	s.state.NewChange("sample", "...").AddTask(t)
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// nothing was downloaded, the task is retried when the window opens
+	c.Check(s.fakeStore.downloads, HasLen, 0)
+	c.Check(t.Status(), Equals, state.DoingStatus)
+	c.Check(t.AtTime().After(start.Add(-time.Minute)), Equals, true)
+	c.Check(t.AtTime().Before(start), Equals, true)
+	c.Assert(t.Log(), HasLen, 1)
+	c.Check(t.Log()[0], Matches, `.* Download postponed until the download window opens at .*`)
+}
+
+func (s *downloadSnapSuite) TestDoDownloadManualIgnoresDownloadWindow(c *C) {
+	now := time.Now()
+	start := now.Add(2 * time.Hour)
+	end := start.Add(time.Hour)
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.download-window", start.Format("15:04")+"-"+end.Format("15:04"))
+	tr.Commit()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+	})
+	s.state.NewChange("sample", "...").AddTask(t)
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.fakeStore.downloads, HasLen, 1)
+	c.Check(t.Status(), Equals, state.DoneStatus)
+}
+
 func (s *downloadSnapSuite) TestDoDownloadRateLimitedIntegration(c *C) {
 	s.state.Lock()
 
@@ -303,3 +427,41 @@ func (s *downloadSnapSuite) TestDoDownloadRateLimitedIntegration(c *C) {
 	})
 
 }
+
+func (s *downloadSnapSuite) TestDoDownloadStagingDir(c *C) {
+	s.state.Lock()
+
+	stagingDir := c.MkDir()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "store.download-dir", stagingDir)
+	tr.Commit()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+	})
+	s.state.NewChange("sample", "...").AddTask(t)
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	c.Assert(s.fakeStore.downloads, DeepEquals, []fakeDownload{
+		{
+			name:   "foo",
+			target: filepath.Join(dirs.SnapBlobDir, "foo_11.snap"),
+			opts: &store.DownloadOptions{
+				StagingDir: stagingDir,
+			},
+		},
+	})
+}
@@ -33,9 +33,11 @@ import (
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/errtracker"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapstate/backend"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/randutil"
@@ -375,6 +377,9 @@ var AutomaticSnapshot func(st *state.State, instanceName string) (ts *state.Task
 var AutomaticSnapshotExpiration func(st *state.State) (time.Duration, error)
 var EstimateSnapshotSize func(st *state.State, instanceName string, users []string) (uint64, error)
 
+// ForgetSnapshots allows to hook snapshot manager's ForgetSnapshots.
+var ForgetSnapshots func(st *state.State, instanceName string) (ts *state.TaskSet, err error)
+
 func readInfo(name string, si *snap.SideInfo, flags int) (*snap.Info, error) {
 	info, err := snapReadInfo(name, si)
 	if err != nil && flags&errorOnBroken != 0 {
@@ -524,6 +529,7 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 	runner.AddHandler("toggle-snap-flags", m.doToggleSnapFlags, nil)
 	runner.AddHandler("check-rerefresh", m.doCheckReRefresh, nil)
 	runner.AddHandler("conditional-auto-refresh", m.doConditionalAutoRefresh, nil)
+	runner.AddHandler("plan-queued-snap-op", m.doPlanQueuedSnapOp, nil)
 
 	// FIXME: drop the task entirely after a while
 	// (having this wart here avoids yet-another-patch)
@@ -531,6 +537,7 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 
 	// remove related
 	runner.AddHandler("stop-snap-services", m.stopSnapServices, m.undoStopSnapServices)
+	runner.AddHandler("kill-snap-apps", m.doKillSnapApps, nil)
 	runner.AddHandler("unlink-snap", m.doUnlinkSnap, m.undoUnlinkSnap)
 	runner.AddHandler("clear-snap", m.doClearSnapData, nil)
 	runner.AddHandler("discard-snap", m.doDiscardSnap, nil)
@@ -559,6 +566,7 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 	runner.AddBlocked(m.blockedTask)
 
 	RegisterAffectedSnapsByKind("conditional-auto-refresh", conditionalAutoRefreshAffectedSnaps)
+	RegisterAffectedSnapsByKind("plan-queued-snap-op", queuedSnapOpAffectedSnaps)
 
 	return m, nil
 }
@@ -631,6 +639,13 @@ func (m *SnapManager) EffectiveRefreshHold() (time.Time, error) {
 	return m.autoRefresh.EffectiveRefreshHold()
 }
 
+// AutoRefreshStatus returns the status of the automatic refreshes, one of
+// the AutoRefresh* constants.
+// The caller should be holding the state lock.
+func (m *SnapManager) AutoRefreshStatus() (string, error) {
+	return m.autoRefresh.Status()
+}
+
 // LastRefresh returns the time the last snap update.
 // The caller should be holding the state lock.
 func (m *SnapManager) LastRefresh() (time.Time, error) {
@@ -1118,14 +1133,35 @@ func (m *SnapManager) ensureMountsUpdated() error {
 	if len(allStates) != 0 {
 		sysd := getSystemD()
 
+		tr := config.NewTransaction(m.state)
+		integrityEnabled, err := features.Flag(tr, features.SnapIntegrity)
+		if err != nil && !config.IsNoOption(err) {
+			return err
+		}
+
 		for _, snapSt := range allStates {
 			info, err := snapSt.CurrentInfo()
 			if err != nil {
 				return err
 			}
-			squashfsPath := dirs.StripRootDir(info.MountFile())
-			whereDir := dirs.StripRootDir(info.MountDir())
-			if _, err = sysd.EnsureMountUnitFile(info.InstanceName(), info.Revision.String(), squashfsPath, whereDir, "squashfs"); err != nil {
+			var verityOptions []string
+			if integrityEnabled {
+				// cross-check the integrity data again, the
+				// ones on disk could have been replaced
+				expected, err := expectedIntegrity(m.state, info.SnapID, info.Revision)
+				if err != nil {
+					return err
+				}
+				verityOptions, err = backend.IntegrityMountOptions(info.MountFile(), &backend.IntegrityOptions{Expected: expected})
+				if err != nil {
+					// keep the existing mount unit, if the
+					// integrity data were tampered with
+					// mounting fails
+					logger.Noticef("cannot update mount unit of snap %q: %v", info.InstanceName(), err)
+					continue
+				}
+			}
+			if err := backend.EnsureSnapMountUnit(sysd, info, verityOptions); err != nil {
 				return err
 			}
 		}
@@ -1157,6 +1193,7 @@ func (m *SnapManager) Ensure() error {
 		m.localInstallCleanup(),
 		m.ensureVulnerableSnapConfineVersionsRemovedOnClassic(),
 		m.ensureMountsUpdated(),
+		m.ensureStatusFile(),
 	}
 
 	//FIXME: use firstErr helper
@@ -67,6 +67,7 @@ const (
 	IgnoreHookError = 1 << iota
 	TrackHookError
 	UseConfigDefaults
+	RevertConfigOnFailure
 )
 
 const (
@@ -531,6 +532,24 @@ func doInstall(st *state.State, snapst *SnapState, snapsup *SnapSetup, flags int
 		addTask(stop)
 		prev = stop
 
+		// save the data of the current revision before going across
+		// a major version, in either direction, as the new revision
+		// might convert it in ways the old one cannot read back
+		if snapsup.Type == snap.TypeApp && AutomaticSnapshot != nil && crossesMajorVersion(snapst, snapsup) {
+			ts, err := AutomaticSnapshot(st, snapsup.InstanceName())
+			switch err {
+			case nil:
+				if err := checkRefreshSnapshotDiskSpace(st, snapsup.InstanceName()); err != nil {
+					return nil, err
+				}
+				addTasksFromTaskSet(ts)
+			case ErrNothingToDo:
+				// automatic snapshots are disabled
+			default:
+				return nil, err
+			}
+		}
+
 		removeAliases := st.NewTask("remove-aliases", fmt.Sprintf(i18n.G("Remove aliases for snap %q"), snapsup.InstanceName()))
 		addTask(removeAliases)
 		prev = removeAliases
@@ -747,6 +766,58 @@ func doInstall(st *state.State, snapst *SnapState, snapsup *SnapSetup, flags int
 	return installSet, nil
 }
 
+// checkRefreshSnapshotDiskSpace checks if there is enough space for the
+// automatic snapshot saved when refreshing the given snap.
+func checkRefreshSnapshotDiskSpace(st *state.State, instanceName string) error {
+	tr := config.NewTransaction(st)
+	enabled, err := features.Flag(tr, features.CheckDiskSpaceRefresh)
+	if err != nil && !config.IsNoOption(err) {
+		return err
+	}
+	if !enabled || EstimateSnapshotSize == nil {
+		return nil
+	}
+
+	snapshotSize, err := EstimateSnapshotSize(st, instanceName, nil)
+	if err != nil {
+		return err
+	}
+	requiredSpace := safetyMarginDiskSpace(snapshotSize)
+	path := dirs.SnapdStateDir(dirs.GlobalRootDir)
+	if err := osutilCheckFreeSpace(path, requiredSpace); err != nil {
+		if _, ok := err.(*osutil.NotEnoughDiskSpaceError); ok {
+			return &InsufficientSpaceError{
+				Path:       path,
+				Snaps:      []string{instanceName},
+				ChangeKind: "refresh",
+				Message:    fmt.Sprintf("cannot create automatic snapshot when refreshing across major versions: %v", err)}
+		}
+		return err
+	}
+	return nil
+}
+
+// crossesMajorVersion returns whether the revision described by snapsup
+// has a different major version, i.e. the part of the version up to the
+// first separator, than the current revision of the snap.
+func crossesMajorVersion(snapst *SnapState, snapsup *SnapSetup) bool {
+	if snapsup.Version == "" {
+		return false
+	}
+	cur, err := snapst.CurrentInfo()
+	if err != nil || cur.Version == "" {
+		return false
+	}
+	return majorVersion(cur.Version) != majorVersion(snapsup.Version)
+}
+
+func majorVersion(version string) string {
+	if i := strings.IndexAny(version, ".-+~"); i >= 0 {
+		return version[:i]
+	}
+	return version
+}
+
 func findTasksMatchingKindAndSnap(st *state.State, kind string, snapName string, revision snap.Revision) ([]*state.Task, error) {
 	var tasks []*state.Task
 	for _, t := range st.Tasks() {
@@ -807,6 +878,12 @@ var CheckHealthHook = func(st *state.State, snapName string, rev snap.Revision)
 	panic("internal error: snapstate.CheckHealthHook is unset")
 }
 
+// IsUnhealthy returns whether the last health check of the snap reported
+// an error. It is set by healthstate.
+var IsUnhealthy = func(st *state.State, instanceName string) (bool, error) {
+	return false, nil
+}
+
 var SetupGateAutoRefreshHook = func(st *state.State, snapName string) *state.Task {
 	panic("internal error: snapstate.SetupAutoRefreshGatingHook is unset")
 }
@@ -1239,6 +1316,13 @@ type snapInfoForInstall func(DeviceContext, *RevisionOptions) (si *snap.Info, sn
 // identifying the last task before the first task that introduces system
 // modifications.
 func InstallWithDeviceContext(ctx context.Context, st *state.State, name string, opts *RevisionOptions, userID int, flags Flags, deviceCtx DeviceContext, fromChange string) (*state.TaskSet, error) {
+	if flags.QueueOnConflict && fromChange == "" && deviceCtx == nil {
+		flags.QueueOnConflict = false
+		qop := &queuedSnapOp{Action: "install", Name: name, RevOpts: opts, UserID: userID, Flags: flags}
+		return queueOnConflict(st, qop, func() (*state.TaskSet, error) {
+			return InstallWithDeviceContext(ctx, st, name, opts, userID, flags, nil, "")
+		})
+	}
 	logger.Debugf("installing with device context %s", name)
 	snapInstallInfo := func(dc DeviceContext, ro *RevisionOptions) (si *snap.Info, snapPath, redirectChannel string, e error) {
 		sar, err := installInfo(ctx, st, name, ro, userID, flags, dc)
@@ -1829,6 +1913,13 @@ func updateManyFiltered(ctx context.Context, st *state.State, names []string, re
 		}
 	}
 
+	if flags.IsAutoRefresh {
+		toUpdate, err = filterUnhealthySnaps(st, toUpdate)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	if err = checkDiskSpace(st, "refresh", toUpdate, userID); err != nil {
 		return nil, nil, err
 	}
@@ -1867,6 +1958,43 @@ func filterHeldSnaps(st *state.State, updates []minimalInstallInfo, flags *Flags
 	return filteredUpdates, nil
 }
 
+// holdUnhealthySnaps returns whether auto-refreshes of snaps whose last
+// health check reported an error are held, as set via refresh.unhealthy.
+func holdUnhealthySnaps(st *state.State) (bool, error) {
+	tr := config.NewTransaction(st)
+	var unhealthy string
+	err := tr.GetMaybe("core", "refresh.unhealthy", &unhealthy)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return false, err
+	}
+	return unhealthy == "hold", nil
+}
+
+// filterUnhealthySnaps filters out of an auto-refresh the snaps whose
+// last health check reported an error, if refresh.unhealthy is set to
+// "hold".
+func filterUnhealthySnaps(st *state.State, updates []minimalInstallInfo) ([]minimalInstallInfo, error) {
+	hold, err := holdUnhealthySnaps(st)
+	if err != nil || !hold {
+		return updates, err
+	}
+
+	filteredUpdates := make([]minimalInstallInfo, 0, len(updates))
+	for _, update := range updates {
+		unhealthy, err := IsUnhealthy(st, update.InstanceName())
+		if err != nil {
+			return nil, err
+		}
+		if unhealthy {
+			logger.Noticef("not auto-refreshing unhealthy snap %q", update.InstanceName())
+			continue
+		}
+		filteredUpdates = append(filteredUpdates, update)
+	}
+
+	return filteredUpdates, nil
+}
+
 // UpdateTaskSets distinguishes tasksets for refreshes and pre-downloads since an
 // auto-refresh can return both (even simultaneously).
 type UpdateTaskSets struct {
@@ -2411,6 +2539,13 @@ type snapInfoForUpdate func(dc DeviceContext, ro *RevisionOptions, fl Flags, sna
 // modifications. If no such edge is set, then none of the tasks introduce
 // system modifications.
 func UpdateWithDeviceContext(st *state.State, name string, opts *RevisionOptions, userID int, flags Flags, deviceCtx DeviceContext, fromChange string) (*state.TaskSet, error) {
+	if flags.QueueOnConflict && fromChange == "" && deviceCtx == nil {
+		flags.QueueOnConflict = false
+		qop := &queuedSnapOp{Action: "refresh", Name: name, RevOpts: opts, UserID: userID, Flags: flags}
+		return queueOnConflict(st, qop, func() (*state.TaskSet, error) {
+			return UpdateWithDeviceContext(st, name, opts, userID, flags, nil, "")
+		})
+	}
 	snapUpdateInfo := func(dc DeviceContext, ro *RevisionOptions, fl Flags, snapst *SnapState) ([]minimalInstallInfo, error) {
 		toUpdate := []minimalInstallInfo{}
 		info, infoErr := infoForUpdate(st, snapst, name, ro, userID, fl, dc)
@@ -2710,6 +2845,11 @@ func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap strin
 
 	updates := make([]string, 0, len(hints))
 
+	holdUnhealthy, err := holdUnhealthySnaps(st)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// check conflicts
 	fromChange := ""
 	for _, up := range candidates {
@@ -2718,6 +2858,17 @@ func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap strin
 			continue
 		}
 
+		if holdUnhealthy {
+			unhealthy, err := IsUnhealthy(st, up.InstanceName())
+			if err != nil {
+				return nil, nil, err
+			}
+			if unhealthy {
+				logger.Noticef("not auto-refreshing unhealthy snap %q", up.InstanceName())
+				continue
+			}
+		}
+
 		snapst := snapstateByInstance[up.InstanceName()]
 		if err := checkChangeConflictIgnoringOneChange(st, up.InstanceName(), snapst, fromChange); err != nil {
 			logger.Noticef("cannot refresh snap %q: %v", up.InstanceName(), err)
@@ -3362,8 +3513,11 @@ func canRemove(st *state.State, si *snap.Info, snapst *SnapState, removeAll bool
 
 // RemoveFlags are used to pass additional flags to the Remove operation.
 type RemoveFlags struct {
-	// Remove the snap without creating snapshot data
+	// Remove the snap without creating snapshot data, and forget the
+	// existing snapshots of the snap when removing it completely
 	Purge bool
+	// Terminate the running apps of the snap before removing it
+	Terminate bool
 }
 
 // Remove returns a set of tasks for removing snap.
@@ -3479,6 +3633,13 @@ func removeTasks(st *state.State, name string, revision snap.Revision, flags *Re
 		stopSnapServices.Set("stop-reason", snap.StopReasonRemove)
 		addNext(state.NewTaskSet(stopSnapServices))
 		prev = stopSnapServices
+
+		if flags != nil && flags.Terminate {
+			killSnapApps := st.NewTask("kill-snap-apps", fmt.Sprintf(i18n.G("Terminate running apps of snap %q"), name))
+			killSnapApps.Set("snap-setup-task", stopSnapServices.ID())
+			addNext(state.NewTaskSet(killSnapApps))
+			prev = killSnapApps
+		}
 	}
 
 	// only run remove hook if uninstalling the snap completely
@@ -3559,6 +3720,19 @@ func removeTasks(st *state.State, name string, revision snap.Revision, flags *Re
 		addNext(removeInactiveRevision(st, name, info.SnapID, revision, snapsup.Type))
 	}
 
+	// forgetting the snapshots cannot be undone, so it comes last
+	if removeAll && flags != nil && flags.Purge {
+		ts, err := ForgetSnapshots(st, name)
+		switch err {
+		case nil:
+			addNext(ts)
+		case ErrNothingToDo:
+			// no snapshots to forget
+		default:
+			return nil, 0, err
+		}
+	}
+
 	return removeTs, snapshotSize, nil
 }
 
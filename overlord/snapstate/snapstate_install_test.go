@@ -57,6 +57,7 @@ import (
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/integrity"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
@@ -735,6 +736,59 @@ func (s *snapmgrTestSuite) TestInstallConflict(c *C) {
 	c.Assert(err, ErrorMatches, `snap "some-snap" has "install" change in progress`)
 }
 
+func (s *snapmgrTestSuite) TestInstallQueueOnConflict(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	ts, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, 0, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("install-snap", "...")
+	chg.AddAll(ts)
+
+	ts, err = snapstate.Install(context.Background(), s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{QueueOnConflict: true})
+	c.Assert(err, IsNil)
+	// the operation is only planned once the conflicting change is ready
+	c.Assert(ts.Tasks(), HasLen, 1)
+	t := ts.Tasks()[0]
+	c.Check(t.Kind(), Equals, "plan-queued-snap-op")
+	c.Check(t.WaitChanges(), DeepEquals, []*state.Change{chg})
+	var qop map[string]interface{}
+	c.Assert(t.Get("queued-snap-op", &qop), IsNil)
+	c.Check(qop["action"], Equals, "install")
+	c.Check(qop["name"], Equals, "some-snap")
+	c.Check(qop["user-id"], Equals, float64(s.user.ID))
+	// the flag is not kept with the queued operation
+	c.Check(qop["flags"], DeepEquals, map[string]interface{}{})
+
+	// the queued operation conflicts with further operations on the snap
+	s.state.NewChange("install-snap", "...").AddAll(ts)
+	chg.Abort()
+	chg.SetStatus(state.HoldStatus)
+	_, err = snapstate.Install(context.Background(), s.state, "some-snap", nil, 0, snapstate.Flags{})
+	c.Check(err, ErrorMatches, `snap "some-snap" has "install-snap" change in progress`)
+}
+
+func (s *snapmgrTestSuite) TestInstallQueueOnConflictNotQueueable(c *C) {
+	snapstate.RegisterConflictQueuePredicate("test-queue-kind", func(chg *state.Change, instanceName string) bool {
+		return instanceName != "some-snap"
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, kind := range []string{"install", "test-queue-kind"} {
+		ts, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, 0, snapstate.Flags{})
+		c.Assert(err, IsNil)
+		chg := s.state.NewChange(kind, "...")
+		chg.AddAll(ts)
+
+		_, err = snapstate.Install(context.Background(), s.state, "some-snap", nil, 0, snapstate.Flags{QueueOnConflict: true})
+		c.Check(err, ErrorMatches, fmt.Sprintf(`snap "some-snap" has %q change in progress`, kind))
+		chg.Abort()
+		chg.SetStatus(state.HoldStatus)
+	}
+}
+
 func (s *snapmgrTestSuite) TestGadgetInstallConflict(c *C) {
 	restore := release.MockOnClassic(false)
 	defer restore()
@@ -1142,6 +1196,50 @@ func (s *snapmgrTestSuite) TestInstallPathFailsEarlyOnEpochMismatch(c *C) {
 	c.Assert(err, ErrorMatches, `cannot refresh "some-snap" to local snap with epoch 42, because it can't read the current epoch of 1\*`)
 }
 
+func (s *snapmgrTestSuite) TestInstallSnapIntegrity(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var integrityQueries []string
+	s.AddCleanup(testutil.Backup(&snapstate.SnapRevisionIntegrity))
+	snapstate.SnapRevisionIntegrity = func(st *state.State, snapID string, rev snap.Revision) (*asserts.SnapIntegrity, error) {
+		integrityQueries = append(integrityQueries, fmt.Sprintf("%s:%s", snapID, rev))
+		return &asserts.SnapIntegrity{SHA3_384: "digest", Size: 8192}, nil
+	}
+
+	// disabled by default
+	chg := s.state.NewChange("install", "install a snap")
+	ts, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	defer s.se.Stop()
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(s.fakeBackend.ops.MustFindOp(c, "setup-snap").integrity, IsNil)
+	c.Check(integrityQueries, HasLen, 0)
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "experimental.snap-integrity", true)
+	tr.Commit()
+
+	s.fakeBackend.ops = nil
+	chg = s.state.NewChange("install", "install a snap")
+	ts, err = snapstate.Install(context.Background(), s.state, "some-other-snap", nil, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(s.fakeBackend.ops.MustFindOp(c, "setup-snap").integrity, DeepEquals, &backend.IntegrityOptions{
+		Generate: true,
+		Expected: &integrity.ExpectedData{SHA3_384: "digest", Size: 8192},
+	})
+	c.Check(integrityQueries, DeepEquals, []string{"some-other-snap-id:11"})
+}
+
 func (s *snapmgrTestSuite) TestInstallRunThrough(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -119,6 +119,193 @@ func (s *snapmgrTestSuite) TestRemoveTasksAutoSnapshotDisabledByPurgeFlag(c *C)
 	})
 }
 
+func (s *snapmgrTestSuite) TestRemoveTasksPurgeForgetsSnapshots(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.ForgetSnapshots = func(st *state.State, instanceName string) (ts *state.TaskSet, err error) {
+		c.Check(instanceName, Equals, "foo")
+		return state.NewTaskSet(st.NewTask("forget-snapshot", "...")), nil
+	}
+
+	snapstate.Set(s.state, "foo", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "foo", Revision: snap.R(11)},
+		},
+		Current:  snap.R(11),
+		SnapType: "app",
+	})
+
+	ts, err := snapstate.Remove(s.state, "foo", snap.R(0), &snapstate.RemoveFlags{Purge: true})
+	c.Assert(err, IsNil)
+
+	c.Assert(taskKinds(ts.Tasks()), DeepEquals, []string{
+		"stop-snap-services",
+		"run-hook[remove]",
+		"auto-disconnect",
+		"remove-aliases",
+		"unlink-snap",
+		"remove-profiles",
+		"clear-snap",
+		"discard-snap",
+		"forget-snapshot",
+	})
+	// the snapshots are only forgotten once the snap is gone
+	tasks := ts.Tasks()
+	forget := tasks[len(tasks)-1]
+	c.Check(forget.WaitTasks(), testutil.DeepContains, tasks[len(tasks)-2])
+}
+
+func (s *snapmgrTestSuite) TestRemoveTasksPurgeForgetSnapshotsError(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.ForgetSnapshots = func(st *state.State, instanceName string) (ts *state.TaskSet, err error) {
+		return nil, fmt.Errorf("boom")
+	}
+
+	snapstate.Set(s.state, "foo", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "foo", Revision: snap.R(11)},
+		},
+		Current:  snap.R(11),
+		SnapType: "app",
+	})
+
+	_, err := snapstate.Remove(s.state, "foo", snap.R(0), &snapstate.RemoveFlags{Purge: true})
+	c.Assert(err, ErrorMatches, "boom")
+}
+
+func (s *snapmgrTestSuite) TestRemoveTasksPurgeSingleRevisionKeepsSnapshots(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.ForgetSnapshots = func(st *state.State, instanceName string) (ts *state.TaskSet, err error) {
+		c.Fatalf("unexpected call")
+		return nil, nil
+	}
+
+	snapstate.Set(s.state, "foo", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "foo", Revision: snap.R(11)},
+			{RealName: "foo", Revision: snap.R(12)},
+		},
+		Current:  snap.R(12),
+		SnapType: "app",
+	})
+
+	ts, err := snapstate.Remove(s.state, "foo", snap.R(11), &snapstate.RemoveFlags{Purge: true})
+	c.Assert(err, IsNil)
+	c.Assert(taskKinds(ts.Tasks()), DeepEquals, []string{
+		"clear-snap",
+		"discard-snap",
+	})
+}
+
+func (s *snapmgrTestSuite) TestRemoveTasksTerminate(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "foo", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "foo", Revision: snap.R(11)},
+		},
+		Current:  snap.R(11),
+		SnapType: "app",
+	})
+
+	ts, err := snapstate.Remove(s.state, "foo", snap.R(0), &snapstate.RemoveFlags{Terminate: true})
+	c.Assert(err, IsNil)
+
+	c.Assert(taskKinds(ts.Tasks()), DeepEquals, []string{
+		"stop-snap-services",
+		"kill-snap-apps",
+		"run-hook[remove]",
+		"auto-disconnect",
+		"save-snapshot",
+		"remove-aliases",
+		"unlink-snap",
+		"remove-profiles",
+		"clear-snap",
+		"discard-snap",
+	})
+	stop := ts.Tasks()[0]
+	kill := ts.Tasks()[1]
+	c.Check(kill.WaitTasks(), DeepEquals, []*state.Task{stop})
+	var stopID string
+	c.Assert(kill.Get("snap-setup-task", &stopID), IsNil)
+	c.Check(stopID, Equals, stop.ID())
+}
+
+func (s *snapmgrTestSuite) TestRemoveTerminateRunThrough(c *C) {
+	var killed []string
+	restore := snapstate.MockCgroupKillSnapProcesses(func(name string) error {
+		killed = append(killed, name)
+		return nil
+	})
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{SnapID: "some-snap-id", RealName: "some-snap", Revision: snap.R(7)},
+		},
+		Current:  snap.R(7),
+		SnapType: "app",
+	})
+
+	chg := s.state.NewChange("remove", "remove a snap")
+	ts, err := snapstate.Remove(s.state, "some-snap", snap.R(0), &snapstate.RemoveFlags{Terminate: true})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	defer s.se.Stop()
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(killed, DeepEquals, []string{"some-snap"})
+}
+
+func (s *snapmgrTestSuite) TestRemoveTerminateError(c *C) {
+	restore := snapstate.MockCgroupKillSnapProcesses(func(name string) error {
+		return fmt.Errorf("boom")
+	})
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{SnapID: "some-snap-id", RealName: "some-snap", Revision: snap.R(7)},
+		},
+		Current:  snap.R(7),
+		SnapType: "app",
+	})
+
+	chg := s.state.NewChange("remove", "remove a snap")
+	ts, err := snapstate.Remove(s.state, "some-snap", snap.R(0), &snapstate.RemoveFlags{Terminate: true})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	defer s.se.Stop()
+	s.settle(c)
+
+	c.Check(chg.Err(), ErrorMatches, `(?s).*cannot terminate running apps of snap "some-snap": boom.*`)
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Active, Equals, true)
+}
+
 func (s *snapmgrTestSuite) TestRemoveHookNotExecutedIfNotLastRevison(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -250,10 +250,15 @@ func (s *snapmgrBaseTest) SetUpTest(c *C) {
 
 	oldAutomaticSnapshotExpiration := snapstate.AutomaticSnapshotExpiration
 	snapstate.AutomaticSnapshotExpiration = func(st *state.State) (time.Duration, error) { return 1, nil }
+	oldForgetSnapshots := snapstate.ForgetSnapshots
+	snapstate.ForgetSnapshots = func(st *state.State, instanceName string) (ts *state.TaskSet, err error) {
+		return nil, snapstate.ErrNothingToDo
+	}
 	s.BaseTest.AddCleanup(func() {
 		snapstate.EstimateSnapshotSize = oldEstimateSnapshotSize
 		snapstate.AutomaticSnapshot = oldAutomaticSnapshot
 		snapstate.AutomaticSnapshotExpiration = oldAutomaticSnapshotExpiration
+		snapstate.ForgetSnapshots = oldForgetSnapshots
 	})
 
 	s.state.Lock()
@@ -9013,6 +9018,41 @@ func (s *snapmgrTestSuite) TestSaveRefreshCandidatesOnAutoRefresh(c *C) {
 	c.Check(cands["some-other-snap"], NotNil)
 }
 
+func (s *snapmgrTestSuite) TestAutoRefreshHoldsUnhealthySnaps(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, name := range []string{"some-snap", "some-other-snap"} {
+		snapstate.Set(s.state, name, &snapstate.SnapState{
+			Active: true,
+			Sequence: []*snap.SideInfo{
+				{RealName: name, SnapID: name + "-id", Revision: snap.R(1)},
+			},
+			Current:  snap.R(1),
+			SnapType: "app",
+		})
+	}
+
+	oldIsUnhealthy := snapstate.IsUnhealthy
+	defer func() { snapstate.IsUnhealthy = oldIsUnhealthy }()
+	snapstate.IsUnhealthy = func(st *state.State, instanceName string) (bool, error) {
+		return instanceName == "some-snap", nil
+	}
+
+	// unhealthy snaps are refreshed by default
+	names, _, err := snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"some-other-snap", "some-snap"})
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.unhealthy", "hold")
+	tr.Commit()
+
+	names, _, err = snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"some-other-snap"})
+}
+
 func (s *snapmgrTestSuite) TestRefreshCandidatesMergeFlags(c *C) {
 	si := &snap.SideInfo{
 		RealName: "some-snap",
@@ -60,6 +60,7 @@ import (
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -4623,6 +4624,75 @@ func (s *snapmgrTestSuite) TestUpdateConflict(c *C) {
 	c.Assert(err, ErrorMatches, `snap "some-snap" has "refresh" change in progress`)
 }
 
+func (s *snapmgrTestSuite) TestUpdateQueueOnConflict(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(7)}},
+		Current:  snap.R(7),
+		SnapType: "app",
+	})
+
+	ts, err := snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("refresh-snap", "...")
+	chg.AddAll(ts)
+
+	ts, err = snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{QueueOnConflict: true})
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+	t := ts.Tasks()[0]
+	c.Check(t.Kind(), Equals, "plan-queued-snap-op")
+	c.Check(t.WaitChanges(), DeepEquals, []*state.Change{chg})
+	var qop map[string]interface{}
+	c.Assert(t.Get("queued-snap-op", &qop), IsNil)
+	c.Check(qop["action"], Equals, "refresh")
+	c.Check(qop["name"], Equals, "some-snap")
+}
+
+func (s *snapmgrTestSuite) TestUpdateQueueOnConflictPlansAfterChange(c *C) {
+	si := &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(7)}
+	snaptest.MockSnap(c, `name: some-snap`, si)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:          true,
+		Sequence:        []*snap.SideInfo{si},
+		Current:         si.Revision,
+		SnapType:        "app",
+		TrackingChannel: "latest/stable",
+	})
+
+	ts, err := snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel/stable"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("refresh-snap", "...")
+	chg.AddAll(ts)
+
+	ts, err = snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel/stable"}, s.user.ID, snapstate.Flags{QueueOnConflict: true})
+	c.Assert(err, IsNil)
+	queued := s.state.NewChange("refresh-snap", "...")
+	queued.AddAll(ts)
+
+	defer s.se.Stop()
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Assert(queued.Err(), IsNil)
+	c.Check(queued.Status(), Equals, state.DoneStatus)
+
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Current, Equals, snap.R(11))
+
+	// planned against the refreshed snap, there was nothing left to do
+	c.Assert(queued.Tasks(), HasLen, 1)
+	c.Check(strings.Join(queued.Tasks()[0].Log(), ""), Matches, `.*no update available for snap "some-snap"`)
+}
+
 func (s *snapmgrTestSuite) TestUpdateCreatesGCTasks(c *C) {
 	restore := release.MockOnClassic(false)
 	defer restore()
@@ -10453,3 +10523,80 @@ func (s *snapmgrTestSuite) TestUpdateSetsRestartBoundaries(c *C) {
 	c.Assert(linkSnap2, NotNil)
 	c.Check(linkSnap2.Get("restart-boundary", &boundary), ErrorMatches, `no state entry for key "restart-boundary"`)
 }
+
+func (s *snapmgrTestSuite) testUpdateMaybeSavesSnapshot(c *C, version string) []string {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// the current revision has version "some-snapVer"
+	snapst := &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(1)}},
+		Current:  snap.R(1),
+		SnapType: "app",
+	}
+	snapstate.Set(s.state, "some-snap", snapst)
+	snapsup := &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(2)},
+		Type:     snap.TypeApp,
+		Version:  version,
+	}
+	ts, err := snapstate.DoInstall(s.state, snapst, snapsup, 0, "", inUseCheck)
+	c.Assert(err, IsNil)
+	return taskKinds(ts.Tasks())
+}
+
+func (s *snapmgrTestSuite) TestUpdateAcrossMajorVersionSavesSnapshot(c *C) {
+	kinds := s.testUpdateMaybeSavesSnapshot(c, "2.0")
+	c.Check(strings.Join(kinds, " "), Matches, ".* stop-snap-services save-snapshot remove-aliases unlink-current-snap .*")
+}
+
+func (s *snapmgrTestSuite) TestUpdateAcrossMajorVersionSnapshotDiskSpace(c *C) {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "experimental.check-disk-space-refresh", true)
+	tr.Commit()
+	s.state.Unlock()
+
+	// restored by TearDownTest
+	snapstate.EstimateSnapshotSize = func(st *state.State, instanceName string, users []string) (uint64, error) {
+		c.Check(instanceName, Equals, "some-snap")
+		return 100, nil
+	}
+	restore := snapstate.MockOsutilCheckFreeSpace(func(path string, required uint64) error {
+		c.Check(required, Equals, snapstate.SafetyMarginDiskSpace(100))
+		return &osutil.NotEnoughDiskSpaceError{}
+	})
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapst := &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(1)}},
+		Current:  snap.R(1),
+		SnapType: "app",
+	}
+	snapstate.Set(s.state, "some-snap", snapst)
+	snapsup := &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(2)},
+		Type:     snap.TypeApp,
+		Version:  "2.0",
+	}
+	_, err := snapstate.DoInstall(s.state, snapst, snapsup, 0, "", inUseCheck)
+	c.Assert(err, FitsTypeOf, &snapstate.InsufficientSpaceError{})
+	c.Check(err.(*snapstate.InsufficientSpaceError).ChangeKind, Equals, "refresh")
+}
+
+func (s *snapmgrTestSuite) TestUpdateAcrossMajorVersionNoAutomaticSnapshotHook(c *C) {
+	// restored by TearDownTest
+	snapstate.AutomaticSnapshot = nil
+	kinds := s.testUpdateMaybeSavesSnapshot(c, "2.0")
+	c.Check(strutil.ListContains(kinds, "save-snapshot"), Equals, false)
+}
+
+func (s *snapmgrTestSuite) TestUpdateWithinMajorVersionSavesNoSnapshot(c *C) {
+	kinds := s.testUpdateMaybeSavesSnapshot(c, "some.2")
+	c.Check(strutil.ListContains(kinds, "save-snapshot"), Equals, false)
+}
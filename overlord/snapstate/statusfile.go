@@ -0,0 +1,146 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// failedChangesWindow is how far back failed changes are reported in
+// the status file.
+var failedChangesWindow = 24 * time.Hour
+
+// statusSummary returns a short human readable summary of pending and
+// held refreshes, recently failed changes and pending system restarts,
+// or an empty string if there is nothing to report.
+func statusSummary(st *state.State) (string, error) {
+	var lines []string
+
+	var candidates map[string]*refreshCandidate
+	if err := st.Get("refresh-candidates", &candidates); err != nil && !errors.Is(err, state.ErrNoState) {
+		return "", err
+	}
+	held, err := HeldSnaps(st, HoldAutoRefresh)
+	if err != nil {
+		return "", err
+	}
+
+	var pending []string
+	for name := range candidates {
+		if _, ok := held[name]; ok {
+			continue
+		}
+		pending = append(pending, name)
+	}
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		lines = append(lines, fmt.Sprintf(i18n.NG("%d snap refresh is pending: %s", "%d snap refreshes are pending: %s", len(pending)),
+			len(pending), strings.Join(pending, ", ")))
+	}
+
+	if len(held) > 0 {
+		heldNames := make([]string, 0, len(held))
+		for name := range held {
+			heldNames = append(heldNames, name)
+		}
+		sort.Strings(heldNames)
+		lines = append(lines, fmt.Sprintf(i18n.NG("%d snap refresh is held: %s", "%d snap refreshes are held: %s", len(heldNames)),
+			len(heldNames), strings.Join(heldNames, ", ")))
+	}
+
+	cutoff := time.Now().Add(-failedChangesWindow)
+	failed := 0
+	restartPending := false
+	for _, chg := range st.Changes() {
+		status := chg.Status()
+		if status == state.ErrorStatus && chg.ReadyTime().After(cutoff) {
+			failed++
+		}
+		if chg.IsReady() {
+			continue
+		}
+		var waitForRestart bool
+		if err := chg.Get("wait-for-system-restart", &waitForRestart); err != nil && !errors.Is(err, state.ErrNoState) {
+			return "", err
+		}
+		if waitForRestart {
+			restartPending = true
+		}
+	}
+	if failed > 0 {
+		lines = append(lines, fmt.Sprintf(i18n.NG("%d change failed in the last day, see 'snap changes'", "%d changes failed in the last day, see 'snap changes'", failed), failed))
+	}
+	if restartPending {
+		lines = append(lines, i18n.G("a system restart is required to complete snap changes"))
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	for _, line := range lines {
+		fmt.Fprintf(&buf, "snapd: %s\n", line)
+	}
+	return buf.String(), nil
+}
+
+// ensureStatusFile keeps the status file, meant for inclusion via
+// update-motd or issue.d on servers, up to date. The file is removed when
+// there is nothing to report.
+func (m *SnapManager) ensureStatusFile() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	summary, err := statusSummary(m.state)
+	if err != nil {
+		return err
+	}
+
+	if summary == "" {
+		if err := os.Remove(dirs.SnapStatusFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dirs.SnapStatusFile), 0755); err != nil {
+		return err
+	}
+	err = osutil.EnsureFileState(dirs.SnapStatusFile, &osutil.MemoryFileState{
+		Content: []byte(summary),
+		Mode:    0644,
+	})
+	if err == osutil.ErrSameState {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) TestEnsureStatusFileNothingToReport(c *C) {
+	c.Assert(s.snapmgr.EnsureStatusFile(), IsNil)
+	c.Check(dirs.SnapStatusFile, testutil.FileAbsent)
+}
+
+func (s *snapmgrTestSuite) TestEnsureStatusFile(c *C) {
+	s.state.Lock()
+	mockInstalledSnap(c, s.state, snapAyaml, false)
+	mockInstalledSnap(c, s.state, snapByaml, false)
+	s.state.Set("refresh-candidates", map[string]interface{}{
+		"snap-a": map[string]interface{}{},
+		"snap-b": map[string]interface{}{},
+	})
+	c.Assert(snapstate.HoldRefreshesBySystem(s.state, snapstate.HoldAutoRefresh, "forever", []string{"snap-b"}), IsNil)
+
+	chg := s.state.NewChange("install", "...")
+	chg.SetStatus(state.ErrorStatus)
+
+	chg = s.state.NewChange("refresh", "...")
+	chg.AddTask(s.state.NewTask("foo", "..."))
+	chg.Set("wait-for-system-restart", true)
+	s.state.Unlock()
+
+	c.Assert(s.snapmgr.EnsureStatusFile(), IsNil)
+	c.Check(dirs.SnapStatusFile, testutil.FileEquals, `snapd: 1 snap refresh is pending: snap-a
+snapd: 1 snap refresh is held: snap-b
+snapd: 1 change failed in the last day, see 'snap changes'
+snapd: a system restart is required to complete snap changes
+`)
+
+	// once everything is resolved the file goes away
+	s.state.Lock()
+	s.state.Set("refresh-candidates", nil)
+	c.Assert(snapstate.ProceedWithRefresh(s.state, "system", nil), IsNil)
+	for _, chg := range s.state.Changes() {
+		chg.Set("wait-for-system-restart", nil)
+		chg.SetStatus(state.DoneStatus)
+	}
+	s.state.Prune(time.Now(), 0, 0, 0)
+	s.state.Unlock()
+
+	c.Assert(s.snapmgr.EnsureStatusFile(), IsNil)
+	c.Check(dirs.SnapStatusFile, testutil.FileAbsent)
+}
@@ -51,6 +51,11 @@ var EnforceLocalValidationSets func(*state.State, map[string][]string, map[strin
 // fetching them. It's hooked from assertstate.
 var EnforceValidationSets func(*state.State, map[string]*asserts.ValidationSet, map[string]int, []*snapasserts.InstalledSnap, map[string]bool, int) error
 
+// SnapRevisionIntegrity allows to hook getting the integrity data declared
+// by the snap-revision assertion of a snap revision, if any. It's hooked
+// from assertstate.
+var SnapRevisionIntegrity func(st *state.State, snapID string, rev snap.Revision) (*asserts.SnapIntegrity, error)
+
 func userIDForSnap(st *state.State, snapst *SnapState, fallbackUserID int) (int, error) {
 	userID := snapst.UserID
 	_, err := auth.User(st, userID)
@@ -788,6 +793,7 @@ func refreshCandidates(ctx context.Context, st *state.State, names []string, rev
 			}
 			// TODO: use the warning infra here when we have it
 			logger.Noticef("%v", saErr)
+			recordWithdrawnSnaps(st, saErr.Refresh)
 		}
 
 		for _, sar := range sarsForUser {
@@ -0,0 +1,154 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+)
+
+// DisableSnapServices returns the task sets that stop and disable the
+// given services. It is set by servicestate.
+var DisableSnapServices = func(st *state.State, svcs []*snap.AppInfo) ([]*state.TaskSet, error) {
+	panic("internal error: snapstate.DisableSnapServices is unset")
+}
+
+func disableServicesOfWithdrawnSnaps(st *state.State) (bool, error) {
+	tr := config.NewTransaction(st)
+	var onWithdrawn string
+	err := tr.GetMaybe("core", "refresh.withdrawn", &onWithdrawn)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return false, err
+	}
+
+	return onWithdrawn == "disable-services", nil
+}
+
+type withdrawnSnapsKey struct{}
+
+// recordWithdrawnSnaps remembers the refresh errors of the installed snaps
+// that the store reported as withdrawn. They are only acted upon by the
+// auto-refresh, so that querying for refresh candidates, e.g. with snap
+// refresh --list, has no side effects.
+func recordWithdrawnSnaps(st *state.State, refreshErrors map[string]error) {
+	withdrawn, _ := st.Cached(withdrawnSnapsKey{}).(map[string]error)
+	for name, err := range refreshErrors {
+		if _, ok := err.(*store.WithdrawnError); !ok {
+			continue
+		}
+		if withdrawn == nil {
+			withdrawn = make(map[string]error)
+		}
+		withdrawn[name] = err
+	}
+	if withdrawn != nil {
+		st.Cache(withdrawnSnapsKey{}, withdrawn)
+	}
+}
+
+// takeWithdrawnSnaps returns and forgets the refresh errors recorded by
+// recordWithdrawnSnaps.
+func takeWithdrawnSnaps(st *state.State) map[string]error {
+	withdrawn, _ := st.Cached(withdrawnSnapsKey{}).(map[string]error)
+	st.Cache(withdrawnSnapsKey{}, nil)
+	return withdrawn
+}
+
+// handleWithdrawnSnaps looks for installed snaps that the store reported
+// as withdrawn among the given refresh errors. A warning is raised for
+// each of them and, if refresh.withdrawn is set to "disable-services",
+// their services are stopped and disabled.
+func handleWithdrawnSnaps(st *state.State, refreshErrors map[string]error) {
+	var withdrawn []string
+	for name, err := range refreshErrors {
+		if _, ok := err.(*store.WithdrawnError); ok {
+			withdrawn = append(withdrawn, name)
+		}
+	}
+	if len(withdrawn) == 0 {
+		return
+	}
+	sort.Strings(withdrawn)
+
+	disable, err := disableServicesOfWithdrawnSnaps(st)
+	if err != nil {
+		logger.Noticef("cannot get refresh.withdrawn configuration: %v", err)
+	}
+
+	for _, name := range withdrawn {
+		st.WarnfWithSeverity(state.WarningSeverityHigh, "snap %q was withdrawn by the store: %v", name, refreshErrors[name])
+		if !disable {
+			continue
+		}
+		if err := disableWithdrawnSnapServices(st, name); err != nil {
+			logger.Noticef("cannot disable services of withdrawn snap %q: %v", name, err)
+		}
+	}
+}
+
+func disableWithdrawnSnapServices(st *state.State, instanceName string) error {
+	var snapst SnapState
+	if err := Get(st, instanceName, &snapst); err != nil {
+		return err
+	}
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return err
+	}
+	svcs := info.Services()
+	if len(svcs) == 0 {
+		return nil
+	}
+
+	// only act once per revision, so that services re-enabled by the
+	// user stay enabled
+	var disabled map[string]snap.Revision
+	if err := st.Get("withdrawn-snaps-disabled", &disabled); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if rev, ok := disabled[instanceName]; ok && rev == snapst.Current {
+		return nil
+	}
+
+	tss, err := DisableSnapServices(st, svcs)
+	if err != nil {
+		return err
+	}
+	chg := st.NewChange("disable-withdrawn-services", fmt.Sprintf(i18n.G("Disable services of withdrawn snap %q"), instanceName))
+	for _, ts := range tss {
+		chg.AddAll(ts)
+	}
+
+	if disabled == nil {
+		disabled = make(map[string]snap.Revision)
+	}
+	disabled[instanceName] = snapst.Current
+	st.Set("withdrawn-snaps-disabled", disabled)
+	st.EnsureBefore(0)
+	return nil
+}
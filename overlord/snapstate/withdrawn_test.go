@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"sort"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+)
+
+func (s *snapmgrTestSuite) mockWithdrawnServicesSnap(c *C) *[][]string {
+	snapstate.Set(s.state, "services-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "services-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+	})
+
+	var disabled [][]string
+	oldDisableSnapServices := snapstate.DisableSnapServices
+	snapstate.DisableSnapServices = func(st *state.State, svcs []*snap.AppInfo) ([]*state.TaskSet, error) {
+		var names []string
+		for _, app := range svcs {
+			names = append(names, app.Name)
+		}
+		sort.Strings(names)
+		disabled = append(disabled, names)
+		return []*state.TaskSet{state.NewTaskSet(st.NewTask("service-control", "..."))}, nil
+	}
+	s.AddCleanup(func() { snapstate.DisableSnapServices = oldDisableSnapServices })
+	return &disabled
+}
+
+func (s *snapmgrTestSuite) TestHandleWithdrawnSnapsWarns(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	disabled := s.mockWithdrawnServicesSnap(c)
+
+	snapstate.HandleWithdrawnSnaps(s.state, map[string]error{
+		"services-snap": &store.WithdrawnError{Code: "revision-withdrawn", Message: "bad revision"},
+		"some-snap":     store.ErrNoUpdateAvailable,
+	})
+
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `snap "services-snap" was withdrawn by the store: bad revision`)
+	c.Check(warns[0].Severity(), Equals, state.WarningSeverityHigh)
+
+	// services are left alone by default
+	c.Check(*disabled, HasLen, 0)
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestHandleWithdrawnSnapsDisableServices(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	disabled := s.mockWithdrawnServicesSnap(c)
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.withdrawn", "disable-services")
+	tr.Commit()
+
+	refreshErrors := map[string]error{
+		"services-snap": &store.WithdrawnError{Code: "publisher-terminated"},
+	}
+	snapstate.HandleWithdrawnSnaps(s.state, refreshErrors)
+
+	c.Check(*disabled, DeepEquals, [][]string{{"svc1", "svc2", "svc3"}})
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Kind(), Equals, "disable-withdrawn-services")
+	c.Check(chgs[0].Summary(), Equals, `Disable services of withdrawn snap "services-snap"`)
+	c.Check(chgs[0].Tasks(), HasLen, 1)
+
+	// the services are disabled only once for the same revision
+	snapstate.HandleWithdrawnSnaps(s.state, refreshErrors)
+	c.Check(*disabled, HasLen, 1)
+	c.Check(s.state.Changes(), HasLen, 1)
+
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `snap "services-snap" was withdrawn by the store: snap publisher account was terminated`)
+	c.Check(warns[0].Count(), Equals, 2)
+}
+
+func (s *snapmgrTestSuite) TestRecordWithdrawnSnapsHasNoSideEffects(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	disabled := s.mockWithdrawnServicesSnap(c)
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.withdrawn", "disable-services")
+	tr.Commit()
+
+	snapstate.RecordWithdrawnSnaps(s.state, map[string]error{
+		"services-snap": &store.WithdrawnError{Code: "revision-withdrawn"},
+	})
+
+	c.Check(*disabled, HasLen, 0)
+	c.Check(s.state.Changes(), HasLen, 0)
+	c.Check(s.state.AllWarnings(), HasLen, 0)
+}
@@ -438,7 +438,9 @@ func (c *Change) SetStatus(s Status) {
 	if s.Ready() {
 		c.markReady()
 	}
-	c.notifyStatusChange(c.Status())
+	cs := c.Status()
+	c.addNotice(cs)
+	c.notifyStatusChange(cs)
 }
 
 func (c *Change) markReady() {
@@ -478,6 +480,7 @@ func (c *Change) detectChangeReady(excludeTask *Task) {
 // notify observers of Change changes.
 func (c *Change) taskStatusChanged(t *Task, old, new Status) {
 	cs := c.Status()
+	c.addNotice(cs)
 	// If the task changes from ready => unready or unready => ready,
 	// update the ready status for the change.
 	if old.Ready() == new.Ready() {
@@ -488,6 +491,20 @@ func (c *Change) taskStatusChanged(t *Task, old, new Status) {
 	c.notifyStatusChange(cs)
 }
 
+// addNotice records a change-update notice for the change, so that
+// clients waiting for notices learn about the progress of the change.
+func (c *Change) addNotice(status Status) {
+	opts := &AddNoticeOptions{
+		Data: map[string]string{
+			"kind":   c.kind,
+			"status": status.String(),
+		},
+	}
+	if _, err := c.state.AddNotice(ChangeUpdateNotice, c.id, opts); err != nil {
+		logger.Panicf("%v", err)
+	}
+}
+
 // IsClean returns whether all tasks in the change have been cleaned. See SetClean.
 func (c *Change) IsClean() bool {
 	c.state.reading()
@@ -51,6 +51,7 @@ func (s *State) AddWarning(message string, lastAdded, lastShown time.Time, expir
 		lastShown:   lastShown,
 		expireAfter: expireAfter,
 		repeatAfter: repeatAfter,
+		severity:    WarningSeverityNormal,
 	}, lastAdded)
 }
 
@@ -73,3 +74,11 @@ var (
 	ErrNoWarningExpireAfter = errNoWarningExpireAfter
 	ErrNoWarningRepeatAfter = errNoWarningRepeatAfter
 )
+
+func MockTimeoutGrace(grace time.Duration) (restore func()) {
+	old := timeoutGrace
+	timeoutGrace = grace
+	return func() {
+		timeoutGrace = old
+	}
+}
@@ -0,0 +1,433 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultNoticeExpireAfter is how long notices are kept after they last
+// occurred.
+var DefaultNoticeExpireAfter = 7 * 24 * time.Hour
+
+// NoticeType is the type of a notice.
+type NoticeType string
+
+const (
+	// ChangeUpdateNotice is recorded when a change or one of its tasks
+	// changes status. The key is the change ID.
+	ChangeUpdateNotice NoticeType = "change-update"
+
+	// WarningNotice is recorded when a warning is added, or added again
+	// once it would be repeated to the user. The key is the warning
+	// message.
+	WarningNotice NoticeType = "warning"
+
+	// MaintenanceNotice is recorded when snapd is about to go down for
+	// maintenance. The key is the kind of the maintenance, either
+	// "daemon-restart" or "system-restart".
+	MaintenanceNotice NoticeType = "maintenance"
+)
+
+// Valid returns whether the notice type is a known one.
+func (t NoticeType) Valid() bool {
+	switch t {
+	case ChangeUpdateNotice, WarningNotice, MaintenanceNotice:
+		return true
+	}
+	return false
+}
+
+// Notice records an occurrence of an event of interest to clients, such
+// as a change being updated. Occurrences of the same type and key are
+// recorded in the same notice.
+type Notice struct {
+	// the unique ID of the notice, assigned in order of creation
+	id string
+	// the type of the event
+	noticeType NoticeType
+	// what the event is about, e.g. a change ID
+	key string
+	// the first time the event occurred
+	firstOccurred time.Time
+	// the last time the event occurred
+	lastOccurred time.Time
+	// the last time the notice was repeated to clients, clients
+	// waiting for notices are woken up when it changes
+	lastRepeated time.Time
+	// how many times the event occurred
+	occurrences int
+	// the data recorded with the last occurrence
+	lastData map[string]string
+	// how much time since the last repeat should pass before
+	// another occurrence is repeated to clients
+	repeatAfter time.Duration
+	// how much time since the last occurrence should the notice be
+	// dropped
+	expireAfter time.Duration
+}
+
+type jsonNotice struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Key           string            `json:"key"`
+	FirstOccurred time.Time         `json:"first-occurred"`
+	LastOccurred  time.Time         `json:"last-occurred"`
+	LastRepeated  time.Time         `json:"last-repeated"`
+	Occurrences   int               `json:"occurrences"`
+	LastData      map[string]string `json:"last-data,omitempty"`
+	RepeatAfter   string            `json:"repeat-after,omitempty"`
+	ExpireAfter   string            `json:"expire-after,omitempty"`
+}
+
+func (n *Notice) String() string {
+	return fmt.Sprintf("Notice %s (%s:%s)", n.id, n.noticeType, n.key)
+}
+
+// ID returns the unique ID of the notice.
+func (n *Notice) ID() string {
+	return n.id
+}
+
+// Type returns the type of the notice.
+func (n *Notice) Type() NoticeType {
+	return n.noticeType
+}
+
+// Key returns what the notice is about.
+func (n *Notice) Key() string {
+	return n.key
+}
+
+// LastRepeated returns the last time the notice was repeated to clients.
+func (n *Notice) LastRepeated() time.Time {
+	return n.lastRepeated
+}
+
+// Occurrences returns how many times the event of the notice occurred.
+func (n *Notice) Occurrences() int {
+	return n.occurrences
+}
+
+// LastData returns the data recorded with the last occurrence.
+func (n *Notice) LastData() map[string]string {
+	return n.lastData
+}
+
+func (n *Notice) MarshalJSON() ([]byte, error) {
+	jn := jsonNotice{
+		ID:            n.id,
+		Type:          string(n.noticeType),
+		Key:           n.key,
+		FirstOccurred: n.firstOccurred,
+		LastOccurred:  n.lastOccurred,
+		LastRepeated:  n.lastRepeated,
+		Occurrences:   n.occurrences,
+		LastData:      n.lastData,
+	}
+	if n.repeatAfter != 0 {
+		jn.RepeatAfter = n.repeatAfter.String()
+	}
+	if n.expireAfter != 0 {
+		jn.ExpireAfter = n.expireAfter.String()
+	}
+	return json.Marshal(jn)
+}
+
+func (n *Notice) UnmarshalJSON(data []byte) error {
+	var jn jsonNotice
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return err
+	}
+	n.id = jn.ID
+	n.noticeType = NoticeType(jn.Type)
+	n.key = jn.Key
+	n.firstOccurred = jn.FirstOccurred
+	n.lastOccurred = jn.LastOccurred
+	n.lastRepeated = jn.LastRepeated
+	n.occurrences = jn.Occurrences
+	n.lastData = jn.LastData
+	var err error
+	if jn.RepeatAfter != "" {
+		n.repeatAfter, err = time.ParseDuration(jn.RepeatAfter)
+		if err != nil {
+			return err
+		}
+	}
+	if jn.ExpireAfter != "" {
+		n.expireAfter, err = time.ParseDuration(jn.ExpireAfter)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Notice) expiredBefore(now time.Time) bool {
+	return n.lastOccurred.Add(n.expireAfter).Before(now)
+}
+
+type noticeKey struct {
+	noticeType NoticeType
+	key        string
+}
+
+// AddNoticeOptions holds optional parameters for AddNotice.
+type AddNoticeOptions struct {
+	// Data is recorded as the data of the occurrence.
+	Data map[string]string
+
+	// RepeatAfter defines how much time since the notice was last
+	// repeated should pass before an occurrence is repeated to clients.
+	// Zero means occurrences are always repeated.
+	RepeatAfter time.Duration
+
+	// Time, if set, is used as the time of the occurrence instead of
+	// the current time.
+	Time time.Time
+}
+
+// AddNotice records an occurrence of the event with the given type and
+// key, creating a notice for it if there is none yet, and wakes up
+// clients waiting for notices if the occurrence is repeated to them. It
+// returns the ID of the notice.
+func (s *State) AddNotice(noticeType NoticeType, key string, options *AddNoticeOptions) (string, error) {
+	if options == nil {
+		options = &AddNoticeOptions{}
+	}
+	if !noticeType.Valid() {
+		return "", fmt.Errorf("internal error: attempted to add notice with invalid type %q", noticeType)
+	}
+	if key == "" {
+		return "", fmt.Errorf("internal error: attempted to add %s notice with empty key", noticeType)
+	}
+
+	s.writing()
+
+	now := options.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	// keep the timestamps strictly increasing so that clients can ask
+	// for the notices after the last one they have seen
+	if !now.After(s.lastNoticeTimestamp) {
+		now = s.lastNoticeTimestamp.Add(time.Nanosecond)
+	}
+	s.lastNoticeTimestamp = now
+
+	nk := noticeKey{noticeType, key}
+	notice, ok := s.notices[nk]
+	newOrRepeated := false
+	if !ok {
+		s.lastNoticeId++
+		notice = &Notice{
+			id:            strconv.Itoa(s.lastNoticeId),
+			noticeType:    noticeType,
+			key:           key,
+			firstOccurred: now,
+			lastRepeated:  now,
+			expireAfter:   DefaultNoticeExpireAfter,
+		}
+		s.notices[nk] = notice
+		newOrRepeated = true
+	} else if !now.Before(notice.lastRepeated.Add(options.RepeatAfter)) {
+		notice.lastRepeated = now
+		newOrRepeated = true
+	}
+	notice.occurrences++
+	notice.lastOccurred = now
+	notice.lastData = options.Data
+	notice.repeatAfter = options.RepeatAfter
+
+	if newOrRepeated {
+		s.noticeCond.Broadcast()
+	}
+	return notice.id, nil
+}
+
+// NoticeFilter allows filtering notices by various fields.
+type NoticeFilter struct {
+	// Types, if not empty, includes only notices of one of these types.
+	Types []NoticeType
+	// Keys, if not empty, includes only notices with one of these keys.
+	Keys []string
+	// After, if set, includes only notices repeated after this time.
+	After time.Time
+}
+
+func (f *NoticeFilter) matches(n *Notice) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Types) > 0 && !noticeTypeIn(n.noticeType, f.Types) {
+		return false
+	}
+	if len(f.Keys) > 0 && !stringIn(n.key, f.Keys) {
+		return false
+	}
+	if !f.After.IsZero() && !n.lastRepeated.After(f.After) {
+		return false
+	}
+	return true
+}
+
+func noticeTypeIn(t NoticeType, types []NoticeType) bool {
+	for _, tt := range types {
+		if t == tt {
+			return true
+		}
+	}
+	return false
+}
+
+func stringIn(s string, strs []string) bool {
+	for _, ss := range strs {
+		if s == ss {
+			return true
+		}
+	}
+	return false
+}
+
+// Notices returns the unexpired notices matching the filter, if any,
+// ordered by the time they were last repeated.
+func (s *State) Notices(filter *NoticeFilter) []*Notice {
+	s.reading()
+
+	now := time.Now()
+	var notices []*Notice
+	for _, n := range s.notices {
+		if n.expiredBefore(now) || !filter.matches(n) {
+			continue
+		}
+		notices = append(notices, n)
+	}
+	sort.Slice(notices, func(i, j int) bool {
+		return notices[i].lastRepeated.Before(notices[j].lastRepeated)
+	})
+	return notices
+}
+
+// Notice returns the unexpired notice with the given ID, or nil.
+func (s *State) Notice(id string) *Notice {
+	s.reading()
+
+	now := time.Now()
+	for _, n := range s.notices {
+		if n.id == id && !n.expiredBefore(now) {
+			return n
+		}
+	}
+	return nil
+}
+
+// WaitNotices returns the notices matching the filter, waiting until
+// there are some or the context is done, in which case it returns the
+// error of the context. The state must be locked, it is unlocked while
+// waiting.
+func (s *State) WaitNotices(ctx context.Context, filter *NoticeFilter) ([]*Notice, error) {
+	if notices := s.Notices(filter); len(notices) > 0 {
+		return notices, nil
+	}
+
+	// wake up the waiters once the context is done so that they notice
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// hold the lock so that the broadcast cannot happen
+			// between the check of the context and the wait
+			s.mu.Lock()
+			s.noticeCond.Broadcast()
+			s.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		s.waitNotice()
+		if notices := s.Notices(filter); len(notices) > 0 {
+			return notices, nil
+		}
+	}
+}
+
+// waitNotice waits for a notice to be added or repeated, releasing the
+// lock meanwhile without checkpointing.
+func (s *State) waitNotice() {
+	s.reading()
+	atomic.AddInt32(&s.muC, -1)
+	s.noticeCond.Wait()
+	atomic.AddInt32(&s.muC, 1)
+}
+
+// flattenNotices returns the unexpired notices as a flat list, for
+// serialising.
+func (s *State) flattenNotices() []*Notice {
+	now := time.Now()
+	flat := make([]*Notice, 0, len(s.notices))
+	for _, n := range s.notices {
+		if n.expiredBefore(now) {
+			continue
+		}
+		flat = append(flat, n)
+	}
+	// keep the serialisation stable
+	sort.Slice(flat, func(i, j int) bool {
+		return flat[i].lastRepeated.Before(flat[j].lastRepeated)
+	})
+	return flat
+}
+
+// unflattenNotices replaces the notices with the given unexpired ones.
+func (s *State) unflattenNotices(flat []*Notice) {
+	now := time.Now()
+	s.notices = make(map[noticeKey]*Notice, len(flat))
+	for _, n := range flat {
+		if n.expiredBefore(now) {
+			continue
+		}
+		s.notices[noticeKey{n.noticeType, n.key}] = n
+		if n.lastRepeated.After(s.lastNoticeTimestamp) {
+			s.lastNoticeTimestamp = n.lastRepeated
+		}
+	}
+}
+
+// pruneNotices removes the expired notices.
+func (s *State) pruneNotices(now time.Time) {
+	for k, n := range s.notices {
+		if n.expiredBefore(now) {
+			s.writing()
+			delete(s.notices, k)
+		}
+	}
+}
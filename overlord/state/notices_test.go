@@ -0,0 +1,330 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+func noticeKeys(notices []*state.Notice) []string {
+	keys := make([]string, len(notices))
+	for i, n := range notices {
+		keys[i] = string(n.Type()) + ":" + n.Key()
+	}
+	return keys
+}
+
+func (stateSuite) TestAddNotice(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t0 := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	id1, err := st.AddNotice(state.MaintenanceNotice, "daemon-restart", &state.AddNoticeOptions{Time: t0})
+	c.Assert(err, check.IsNil)
+	id2, err := st.AddNotice(state.WarningNotice, "be careful", &state.AddNoticeOptions{Time: t0.Add(time.Second)})
+	c.Assert(err, check.IsNil)
+	c.Check(id1, check.Equals, "1")
+	c.Check(id2, check.Equals, "2")
+
+	// occurring again keeps the notice, but repeats it
+	id, err := st.AddNotice(state.MaintenanceNotice, "daemon-restart", &state.AddNoticeOptions{
+		Time: t0.Add(2 * time.Second),
+		Data: map[string]string{"k": "v"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, id1)
+
+	notices := st.Notices(nil)
+	c.Check(noticeKeys(notices), check.DeepEquals, []string{"warning:be careful", "maintenance:daemon-restart"})
+	n := st.Notice(id1)
+	c.Assert(n, check.NotNil)
+	c.Check(n.Occurrences(), check.Equals, 2)
+	c.Check(n.LastRepeated().Equal(t0.Add(2*time.Second)), check.Equals, true)
+	c.Check(n.LastData(), check.DeepEquals, map[string]string{"k": "v"})
+	c.Check(n.String(), check.Equals, "Notice 1 (maintenance:daemon-restart)")
+
+	c.Check(st.Notice("99"), check.IsNil)
+
+	_, err = st.AddNotice(state.NoticeType("foo"), "bar", nil)
+	c.Check(err, check.ErrorMatches, `internal error: attempted to add notice with invalid type "foo"`)
+	_, err = st.AddNotice(state.WarningNotice, "", nil)
+	c.Check(err, check.ErrorMatches, `internal error: attempted to add warning notice with empty key`)
+}
+
+func (stateSuite) TestAddNoticeRepeatAfter(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t0 := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	opts := &state.AddNoticeOptions{Time: t0, RepeatAfter: time.Hour}
+	id, err := st.AddNotice(state.MaintenanceNotice, "system-restart", opts)
+	c.Assert(err, check.IsNil)
+
+	// not repeated before an hour passed
+	opts.Time = t0.Add(time.Minute)
+	_, err = st.AddNotice(state.MaintenanceNotice, "system-restart", opts)
+	c.Assert(err, check.IsNil)
+	n := st.Notice(id)
+	c.Check(n.Occurrences(), check.Equals, 2)
+	c.Check(n.LastRepeated().Equal(t0), check.Equals, true)
+
+	opts.Time = t0.Add(time.Hour)
+	_, err = st.AddNotice(state.MaintenanceNotice, "system-restart", opts)
+	c.Assert(err, check.IsNil)
+	c.Check(n.Occurrences(), check.Equals, 3)
+	c.Check(n.LastRepeated().Equal(t0.Add(time.Hour)), check.Equals, true)
+}
+
+func (stateSuite) TestAddNoticeTimestampsIncrease(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t0 := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	id1, err := st.AddNotice(state.WarningNotice, "a", &state.AddNoticeOptions{Time: t0})
+	c.Assert(err, check.IsNil)
+	id2, err := st.AddNotice(state.WarningNotice, "b", &state.AddNoticeOptions{Time: t0})
+	c.Assert(err, check.IsNil)
+
+	c.Check(st.Notice(id2).LastRepeated().After(st.Notice(id1).LastRepeated()), check.Equals, true)
+	notices := st.Notices(&state.NoticeFilter{After: st.Notice(id1).LastRepeated()})
+	c.Check(noticeKeys(notices), check.DeepEquals, []string{"warning:b"})
+}
+
+func (stateSuite) TestNoticesFilter(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t0 := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	for i, nk := range []struct {
+		noticeType state.NoticeType
+		key        string
+	}{
+		{state.ChangeUpdateNotice, "123"},
+		{state.WarningNotice, "danger"},
+		{state.ChangeUpdateNotice, "124"},
+		{state.MaintenanceNotice, "daemon-restart"},
+	} {
+		_, err := st.AddNotice(nk.noticeType, nk.key, &state.AddNoticeOptions{Time: t0.Add(time.Duration(i) * time.Second)})
+		c.Assert(err, check.IsNil)
+	}
+
+	for _, t := range []struct {
+		filter *state.NoticeFilter
+		keys   []string
+	}{
+		{nil, []string{"change-update:123", "warning:danger", "change-update:124", "maintenance:daemon-restart"}},
+		{&state.NoticeFilter{Types: []state.NoticeType{state.ChangeUpdateNotice}}, []string{"change-update:123", "change-update:124"}},
+		{&state.NoticeFilter{Types: []state.NoticeType{state.WarningNotice, state.MaintenanceNotice}}, []string{"warning:danger", "maintenance:daemon-restart"}},
+		{&state.NoticeFilter{Keys: []string{"124", "danger"}}, []string{"warning:danger", "change-update:124"}},
+		{&state.NoticeFilter{Types: []state.NoticeType{state.ChangeUpdateNotice}, Keys: []string{"danger"}}, []string{}},
+		{&state.NoticeFilter{After: t0.Add(time.Second)}, []string{"change-update:124", "maintenance:daemon-restart"}},
+	} {
+		c.Check(noticeKeys(st.Notices(t.filter)), check.DeepEquals, t.keys, check.Commentf("%+v", t.filter))
+	}
+}
+
+func (stateSuite) TestNoticesExpire(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	old := time.Now().Add(-state.DefaultNoticeExpireAfter - time.Hour)
+	_, err := st.AddNotice(state.WarningNotice, "old", &state.AddNoticeOptions{Time: old})
+	c.Assert(err, check.IsNil)
+	_, err = st.AddNotice(state.WarningNotice, "new", nil)
+	c.Assert(err, check.IsNil)
+
+	c.Check(noticeKeys(st.Notices(nil)), check.DeepEquals, []string{"warning:new"})
+	c.Check(st.Notice("1"), check.IsNil)
+
+	st.Prune(time.Now(), time.Hour, time.Hour, 100)
+	buf, err := json.Marshal(st)
+	c.Assert(err, check.IsNil)
+	c.Check(bytes.Contains(buf, []byte(`"key":"old"`)), check.Equals, false)
+}
+
+func (stateSuite) TestNoticesCheckpointAndRead(c *check.C) {
+	b := new(fakeStateBackend)
+	st := state.New(b)
+	st.Lock()
+
+	t0 := time.Now().UTC().Add(-time.Hour)
+	_, err := st.AddNotice(state.MaintenanceNotice, "daemon-restart", &state.AddNoticeOptions{
+		Time:        t0,
+		Data:        map[string]string{"op": "restart"},
+		RepeatAfter: time.Minute,
+	})
+	c.Assert(err, check.IsNil)
+	_, err = st.AddNotice(state.WarningNotice, "be careful", &state.AddNoticeOptions{Time: t0.Add(time.Second)})
+	c.Assert(err, check.IsNil)
+
+	// implicit checkpoint
+	st.Unlock()
+
+	st2, err := state.ReadState(nil, bytes.NewReader(b.checkpoints[len(b.checkpoints)-1]))
+	c.Assert(err, check.IsNil)
+	st2.Lock()
+	defer st2.Unlock()
+
+	notices := st2.Notices(nil)
+	c.Assert(noticeKeys(notices), check.DeepEquals, []string{"maintenance:daemon-restart", "warning:be careful"})
+	c.Check(notices[0].ID(), check.Equals, "1")
+	c.Check(notices[0].LastData(), check.DeepEquals, map[string]string{"op": "restart"})
+	c.Check(notices[0].LastRepeated().Equal(t0), check.Equals, true)
+
+	// IDs and timestamps carry on
+	id, err := st2.AddNotice(state.ChangeUpdateNotice, "1", &state.AddNoticeOptions{Time: t0})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "3")
+	c.Check(st2.Notice(id).LastRepeated().After(notices[1].LastRepeated()), check.Equals, true)
+}
+
+func (stateSuite) TestNoticeMarshalJSON(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t0 := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	id, err := st.AddNotice(state.ChangeUpdateNotice, "42", &state.AddNoticeOptions{
+		Time: t0,
+		Data: map[string]string{"kind": "install-snap"},
+	})
+	c.Assert(err, check.IsNil)
+
+	buf, err := json.Marshal(st.Notice(id))
+	c.Assert(err, check.IsNil)
+	var v map[string]interface{}
+	c.Assert(json.Unmarshal(buf, &v), check.IsNil)
+	ts := t0.Format(time.RFC3339)
+	c.Check(v, check.DeepEquals, map[string]interface{}{
+		"id":             "1",
+		"type":           "change-update",
+		"key":            "42",
+		"first-occurred": ts,
+		"last-occurred":  ts,
+		"last-repeated":  ts,
+		"occurrences":    1.0,
+		"last-data":      map[string]interface{}{"kind": "install-snap"},
+		"expire-after":   "168h0m0s",
+	})
+}
+
+func (stateSuite) TestWaitNoticesExisting(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, err := st.AddNotice(state.WarningNotice, "hello", nil)
+	c.Assert(err, check.IsNil)
+
+	notices, err := st.WaitNotices(context.Background(), nil)
+	c.Assert(err, check.IsNil)
+	c.Check(noticeKeys(notices), check.DeepEquals, []string{"warning:hello"})
+}
+
+func (stateSuite) TestWaitNoticesNew(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		st.Lock()
+		defer st.Unlock()
+		// not matching the filter
+		st.AddNotice(state.WarningNotice, "hello", nil)
+		st.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		st.Lock()
+		st.AddNotice(state.MaintenanceNotice, "daemon-restart", nil)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	notices, err := st.WaitNotices(ctx, &state.NoticeFilter{Types: []state.NoticeType{state.MaintenanceNotice}})
+	c.Assert(err, check.IsNil)
+	c.Check(noticeKeys(notices), check.DeepEquals, []string{"maintenance:daemon-restart"})
+}
+
+func (stateSuite) TestWaitNoticesTimeout(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	notices, err := st.WaitNotices(ctx, nil)
+	c.Check(err, check.Equals, context.DeadlineExceeded)
+	c.Check(notices, check.HasLen, 0)
+
+	// the state is still usable
+	_, err = st.AddNotice(state.WarningNotice, "hello", nil)
+	c.Check(err, check.IsNil)
+}
+
+func (stateSuite) TestChangeUpdateNotices(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("install-snap", "...")
+	t1 := st.NewTask("download", "...")
+	chg.AddTask(t1)
+	c.Check(st.Notices(nil), check.HasLen, 0)
+
+	t1.SetStatus(state.DoingStatus)
+	notices := st.Notices(nil)
+	c.Assert(noticeKeys(notices), check.DeepEquals, []string{"change-update:" + chg.ID()})
+	c.Check(notices[0].LastData(), check.DeepEquals, map[string]string{"kind": "install-snap", "status": "Doing"})
+	c.Check(notices[0].Occurrences(), check.Equals, 1)
+
+	t1.SetStatus(state.DoneStatus)
+	c.Check(notices[0].LastData(), check.DeepEquals, map[string]string{"kind": "install-snap", "status": "Done"})
+	c.Check(notices[0].Occurrences(), check.Equals, 2)
+
+	chg.SetStatus(state.ErrorStatus)
+	c.Check(notices[0].LastData(), check.DeepEquals, map[string]string{"kind": "install-snap", "status": "Error"})
+	c.Check(notices[0].Occurrences(), check.Equals, 3)
+}
+
+func (stateSuite) TestWarningNotices(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	st.Warnf("hello %s", "world")
+	notices := st.Notices(nil)
+	c.Assert(noticeKeys(notices), check.DeepEquals, []string{"warning:hello world"})
+
+	// not repeated to the user yet
+	st.Warnf("hello %s", "world")
+	c.Check(notices[0].Occurrences(), check.Equals, 1)
+}
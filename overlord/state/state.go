@@ -21,6 +21,7 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,6 +33,7 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/retryutil"
 )
 
 // A Backend is used by State to checkpoint on every unlock operation
@@ -96,6 +98,15 @@ type State struct {
 	changes  map[string]*Change
 	tasks    map[string]*Task
 	warnings map[string]*Warning
+	notices  map[noticeKey]*Notice
+
+	lastNoticeId int
+	// lastNoticeTimestamp is not serialized, it's restored from the
+	// notices when reading the state
+	lastNoticeTimestamp time.Time
+	// noticeCond is used to wake up the waiters for notices, with the
+	// state mutex as its lock
+	noticeCond *sync.Cond
 
 	modified bool
 
@@ -106,22 +117,27 @@ type State struct {
 	// task/changes observing
 	taskHandlers   map[int]func(t *Task, old, new Status)
 	changeHandlers map[int]func(chg *Change, old, new Status)
+	warnHandlers   map[int]func(w *Warning)
 }
 
 // New returns a new empty state.
 func New(backend Backend) *State {
-	return &State{
+	s := &State{
 		backend:             backend,
 		data:                make(customData),
 		changes:             make(map[string]*Change),
 		tasks:               make(map[string]*Task),
 		warnings:            make(map[string]*Warning),
+		notices:             make(map[noticeKey]*Notice),
 		modified:            true,
 		cache:               make(map[interface{}]interface{}),
 		pendingChangeByAttr: make(map[string]func(*Change) bool),
 		taskHandlers:        make(map[int]func(t *Task, old Status, new Status)),
 		changeHandlers:      make(map[int]func(chg *Change, old Status, new Status)),
+		warnHandlers:        make(map[int]func(w *Warning)),
 	}
+	s.noticeCond = sync.NewCond(&s.mu)
+	return s
 }
 
 // Modified returns whether the state was modified since the last checkpoint.
@@ -158,10 +174,12 @@ type marshalledState struct {
 	Changes  map[string]*Change          `json:"changes"`
 	Tasks    map[string]*Task            `json:"tasks"`
 	Warnings []*Warning                  `json:"warnings,omitempty"`
+	Notices  []*Notice                   `json:"notices,omitempty"`
 
 	LastChangeId int `json:"last-change-id"`
 	LastTaskId   int `json:"last-task-id"`
 	LastLaneId   int `json:"last-lane-id"`
+	LastNoticeId int `json:"last-notice-id,omitempty"`
 }
 
 // MarshalJSON makes State a json.Marshaller
@@ -172,10 +190,12 @@ func (s *State) MarshalJSON() ([]byte, error) {
 		Changes:  s.changes,
 		Tasks:    s.tasks,
 		Warnings: s.flattenWarnings(),
+		Notices:  s.flattenNotices(),
 
 		LastTaskId:   s.lastTaskId,
 		LastChangeId: s.lastChangeId,
 		LastLaneId:   s.lastLaneId,
+		LastNoticeId: s.lastNoticeId,
 	})
 }
 
@@ -191,9 +211,11 @@ func (s *State) UnmarshalJSON(data []byte) error {
 	s.changes = unmarshalled.Changes
 	s.tasks = unmarshalled.Tasks
 	s.unflattenWarnings(unmarshalled.Warnings)
+	s.unflattenNotices(unmarshalled.Notices)
 	s.lastChangeId = unmarshalled.LastChangeId
 	s.lastTaskId = unmarshalled.LastTaskId
 	s.lastLaneId = unmarshalled.LastLaneId
+	s.lastNoticeId = unmarshalled.LastNoticeId
 	// backlink state again
 	for _, t := range s.tasks {
 		t.state = s
@@ -240,14 +262,14 @@ func (s *State) Unlock() {
 	}
 
 	data := s.checkpointData()
-	var err error
-	start := time.Now()
-	for time.Since(start) <= unlockCheckpointRetryMaxTime {
-		if err = s.backend.Checkpoint(data); err == nil {
-			s.modified = false
-			return
-		}
-		time.Sleep(unlockCheckpointRetryInterval)
+	strategy := retryutil.Constant{Interval: unlockCheckpointRetryInterval}
+	limits := retryutil.Limits{MaxElapsed: unlockCheckpointRetryMaxTime}
+	err := retryutil.Do(context.Background(), strategy, limits, func() error {
+		return s.backend.Checkpoint(data)
+	})
+	if err == nil {
+		s.modified = false
+		return
 	}
 	logger.Panicf("cannot checkpoint even after %v of retries every %v: %v", unlockCheckpointRetryMaxTime, unlockCheckpointRetryInterval, err)
 }
@@ -398,6 +420,20 @@ func (s *State) TaskCount() int {
 	return len(s.tasks)
 }
 
+// DiscardTasks removes from the state the given tasks that were never
+// linked to a change, for instance because they were only created to
+// find out what an operation would do. Tasks linked to a change are
+// left alone.
+func (s *State) DiscardTasks(tasks []*Task) {
+	for _, t := range tasks {
+		if t.Change() != nil || s.tasks[t.ID()] != t {
+			continue
+		}
+		s.writing()
+		delete(s.tasks, t.ID())
+	}
+}
+
 func (s *State) tasksIn(tids []string) []*Task {
 	res := make([]*Task, len(tids))
 	for i, tid := range tids {
@@ -423,7 +459,7 @@ func (s *State) RegisterPendingChangeByAttr(attr string, f func(*Change) bool) {
 //     changes than the limit set via "maxReadyChanges" those changes in ready
 //     state will also removed even if they are below the pruneWait duration.
 //
-//   - it removes expired warnings.
+//   - it removes expired warnings and notices.
 func (s *State) Prune(startOfOperation time.Time, pruneWait, abortWait time.Duration, maxReadyChanges int) {
 	now := time.Now()
 	pruneLimit := now.Add(-pruneWait)
@@ -450,6 +486,7 @@ func (s *State) Prune(startOfOperation time.Time, pruneWait, abortWait time.Dura
 			delete(s.warnings, k)
 		}
 	}
+	s.pruneNotices(now)
 
 NextChange:
 	for _, chg := range changes {
@@ -556,6 +593,31 @@ func (s *State) notifyChangeStatusChangedHandlers(chg *Change, old, new Status)
 	}
 }
 
+// AddWarningAddedHandler adds a callback function that will be invoked
+// whenever a warning is added for the first time, or added again once
+// it would be repeated to the user.
+// NOTE: Callbacks registered this way are invoked with the state locked,
+// so they should be as simple as possible, and return as quickly as
+// possible, and should avoid the use of i/o code or blocking.
+func (s *State) AddWarningAddedHandler(f func(w *Warning)) (id int) {
+	s.reading()
+	id = s.lastHandlerId
+	s.lastHandlerId++
+	s.warnHandlers[id] = f
+	return id
+}
+
+func (s *State) RemoveWarningAddedHandler(id int) {
+	s.reading()
+	delete(s.warnHandlers, id)
+}
+
+func (s *State) notifyWarningAddedHandlers(w *Warning) {
+	for _, f := range s.warnHandlers {
+		f(w)
+	}
+}
+
 // SaveTimings implements timings.GetSaver
 func (s *State) SaveTimings(timings interface{}) {
 	s.Set("timings", timings)
@@ -577,5 +639,7 @@ func ReadState(backend Backend, r io.Reader) (*State, error) {
 	s.pendingChangeByAttr = make(map[string]func(*Change) bool)
 	s.changeHandlers = make(map[int]func(chg *Change, old Status, new Status))
 	s.taskHandlers = make(map[int]func(t *Task, old Status, new Status))
+	s.warnHandlers = make(map[int]func(w *Warning))
+	s.noticeCond = sync.NewCond(&s.mu)
 	return s, err
 }
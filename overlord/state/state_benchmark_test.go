@@ -0,0 +1,96 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package state_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type discardBackend struct{}
+
+func (discardBackend) Checkpoint(data []byte) error { return nil }
+func (discardBackend) EnsureBefore(d time.Duration) {}
+
+// populatedState returns a state with the given number of changes, each of
+// them with a handful of tasks carrying some data, similar to what is seen
+// on a system that refreshes many snaps.
+func populatedState(backend state.Backend, changes int) *state.State {
+	st := state.New(backend)
+	st.Lock()
+	defer st.Unlock()
+
+	for i := 0; i < changes; i++ {
+		chg := st.NewChange("refresh-snap", fmt.Sprintf("Refresh snap %d", i))
+		var prev *state.Task
+		for j := 0; j < 10; j++ {
+			t := st.NewTask(fmt.Sprintf("task-%d", j), fmt.Sprintf("Task %d of change %d", j, i))
+			t.Set("snap-setup", map[string]interface{}{
+				"snap-name": fmt.Sprintf("snap-%d", i),
+				"revision":  j,
+				"channel":   "latest/stable",
+			})
+			t.Logf("doing task %d", j)
+			if prev != nil {
+				t.WaitFor(prev)
+			}
+			chg.AddTask(t)
+			prev = t
+		}
+	}
+	for i := 0; i < changes; i++ {
+		st.Set(fmt.Sprintf("key-%d", i), map[string]interface{}{"value": i})
+	}
+	return st
+}
+
+func benchmarkCheckpoint(b *testing.B, changes int) {
+	st := populatedState(discardBackend{}, changes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Unlock checkpoints the state if it was modified
+		st.Lock()
+		st.Set("counter", i)
+		st.Unlock()
+	}
+}
+
+func BenchmarkCheckpoint10(b *testing.B)   { benchmarkCheckpoint(b, 10) }
+func BenchmarkCheckpoint1000(b *testing.B) { benchmarkCheckpoint(b, 1000) }
+
+func BenchmarkReadState1000(b *testing.B) {
+	st := populatedState(discardBackend{}, 1000)
+	st.Lock()
+	data, err := st.MarshalJSON()
+	st.Unlock()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := state.ReadState(nil, bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
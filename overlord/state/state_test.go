@@ -576,10 +576,12 @@ func (ss *stateSuite) TestEmptyStateDataAndCheckpointReadAndSet(c *C) {
 		"changes",
 		"tasks",
 		"warnings",
+		"notices",
 		"cache",
 		"pendingChangeByAttr",
 		"taskHandlers",
 		"changeHandlers",
+		"warnHandlers",
 	})
 }
 
@@ -742,6 +744,29 @@ func (ss *stateSuite) TestNewTaskHiddenUntilLinked(c *C) {
 	c.Check(st.Task(t1.ID()), IsNil)
 }
 
+func (ss *stateSuite) TestDiscardTasks(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("check", "...")
+	chg.AddTask(t1)
+	t2 := st.NewTask("download", "...")
+	t3 := st.NewTask("link", "...")
+	t3.WaitFor(t2)
+	c.Check(st.TaskCount(), Equals, 3)
+
+	// tasks linked to a change are kept
+	st.DiscardTasks([]*state.Task{t1, t2, t3})
+	c.Check(st.TaskCount(), Equals, 1)
+	c.Check(st.Task(t1.ID()), Equals, t1)
+
+	// discarding again is harmless
+	st.DiscardTasks([]*state.Task{t2})
+	c.Check(st.TaskCount(), Equals, 1)
+}
+
 func (ss *stateSuite) TestMethodEntrance(c *C) {
 	st := state.New(&fakeStateBackend{})
 
@@ -51,6 +51,7 @@ type Task struct {
 	data         customData
 	waitTasks    []string
 	haltTasks    []string
+	waitChanges  []string
 	lanes        []int
 	log          []string
 	change       string
@@ -90,6 +91,7 @@ type marshalledTask struct {
 	Data         map[string]*json.RawMessage `json:"data,omitempty"`
 	WaitTasks    []string                    `json:"wait-tasks,omitempty"`
 	HaltTasks    []string                    `json:"halt-tasks,omitempty"`
+	WaitChanges  []string                    `json:"wait-changes,omitempty"`
 	Lanes        []int                       `json:"lanes,omitempty"`
 	Log          []string                    `json:"log,omitempty"`
 	Change       string                      `json:"change"`
@@ -125,6 +127,7 @@ func (t *Task) MarshalJSON() ([]byte, error) {
 		Data:         t.data,
 		WaitTasks:    t.waitTasks,
 		HaltTasks:    t.haltTasks,
+		WaitChanges:  t.waitChanges,
 		Lanes:        t.lanes,
 		Log:          t.log,
 		Change:       t.change,
@@ -169,6 +172,7 @@ func (t *Task) UnmarshalJSON(data []byte) error {
 	t.data = custData
 	t.waitTasks = unmarshalled.WaitTasks
 	t.haltTasks = unmarshalled.HaltTasks
+	t.waitChanges = unmarshalled.WaitChanges
 	t.lanes = unmarshalled.Lanes
 	t.log = unmarshalled.Log
 	t.change = unmarshalled.Change
@@ -522,6 +526,28 @@ func (t *Task) HaltTasks() []*Task {
 	return t.state.tasksIn(t.haltTasks)
 }
 
+// WaitForChange registers the change as a requirement for t to make
+// progress: t is not run before the change is ready, whatever its
+// outcome. Unlike with WaitFor the change is not affected by t, which
+// allows queueing an operation behind an unrelated change in progress.
+func (t *Task) WaitForChange(chg *Change) {
+	t.state.writing()
+	t.waitChanges = addOnce(t.waitChanges, chg.id)
+}
+
+// WaitChanges returns the list of changes registered for t to wait for,
+// leaving out the ones that were pruned.
+func (t *Task) WaitChanges() []*Change {
+	t.state.reading()
+	chgs := make([]*Change, 0, len(t.waitChanges))
+	for _, id := range t.waitChanges {
+		if chg := t.state.changes[id]; chg != nil {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
 // NumHaltTasks returns the number of tasks registered to wait for t.
 func (t *Task) NumHaltTasks() int {
 	return len(t.haltTasks)
@@ -318,6 +318,23 @@ func (ts *taskSuite) TestTaskMarshalsWaitFor(c *C) {
 	c.Assert(string(d), testutil.Contains, needle)
 }
 
+func (ts *taskSuite) TestTaskMarshalsWaitForChange(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("install", "2...")
+	t.WaitForChange(chg)
+	t.WaitForChange(chg)
+
+	d, err := t.MarshalJSON()
+	c.Assert(err, IsNil)
+	needle := fmt.Sprintf(`"wait-changes":["%s"]`, chg.ID())
+	c.Assert(string(d), testutil.Contains, needle)
+
+}
+
 func (ts *taskSuite) TestTaskMarshalsDoingUndoingTime(c *C) {
 	st := state.New(nil)
 	st.Lock()
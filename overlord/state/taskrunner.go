@@ -20,6 +20,7 @@
 package state
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -63,8 +64,30 @@ func (r *Wait) Error() string {
 	return "task set to wait, manual action required"
 }
 
+// timeoutError is the error a task fails with when it runs for longer
+// than the timeout set for it.
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("task timed out after %v", e.timeout)
+}
+
+// timeoutGrace is for how long the handler of a timed out task is waited
+// for after its tomb was killed, before the task is abandoned.
+var timeoutGrace = time.Minute
+
 type blockedFunc func(t *Task, running []*Task) bool
 
+type taskDeadline struct {
+	at      time.Time
+	timeout time.Duration
+	// expired is set once the task's tomb was killed for running
+	// past the deadline
+	expired bool
+}
+
 // TaskRunner controls the running of goroutines to execute known task kinds.
 type TaskRunner struct {
 	state *State
@@ -82,8 +105,15 @@ type TaskRunner struct {
 	// optional callback executed on task errors
 	taskErrorCallback func(err error)
 
+	// optional callback executed when a task asks to be retried
+	taskRetryCallback func(t *Task)
+
+	// optional function returning for how long a task may run
+	timeout func(t *Task) time.Duration
+
 	// go-routines lifecycle
-	tombs map[string]*tomb.Tomb
+	tombs     map[string]*tomb.Tomb
+	deadlines map[string]taskDeadline
 }
 
 type handlerPair struct {
@@ -98,10 +128,11 @@ type optionalHandler struct {
 // NewTaskRunner creates a new TaskRunner
 func NewTaskRunner(s *State) *TaskRunner {
 	return &TaskRunner{
-		state:    s,
-		handlers: make(map[string]handlerPair),
-		cleanups: make(map[string]HandlerFunc),
-		tombs:    make(map[string]*tomb.Tomb),
+		state:     s,
+		handlers:  make(map[string]handlerPair),
+		cleanups:  make(map[string]HandlerFunc),
+		tombs:     make(map[string]*tomb.Tomb),
+		deadlines: make(map[string]taskDeadline),
 	}
 }
 
@@ -110,6 +141,13 @@ func (r *TaskRunner) OnTaskError(f func(err error)) {
 	r.taskErrorCallback = f
 }
 
+// OnTaskRetry sets a callback executed when any task asks to be retried,
+// other than because the runner is stopping. It is called with the state
+// lock held.
+func (r *TaskRunner) OnTaskRetry(f func(t *Task)) {
+	r.taskRetryCallback = f
+}
+
 // AddHandler registers the functions to concurrently call for doing and
 // undoing tasks of the given kind. The undo handler may be nil.
 func (r *TaskRunner) AddHandler(kind string, do, undo HandlerFunc) {
@@ -183,6 +221,21 @@ func (r *TaskRunner) AddBlocked(pred func(t *Task, running []*Task) bool) {
 	r.blocked = append(r.blocked, pred)
 }
 
+// SetTimeout sets a function returning for how long a task may run in a
+// single do or undo attempt, zero meaning without limit. When the time is
+// up the task's tomb is killed. If its handler then asks to be retried the
+// task errors out as timed out, aborting its lanes, otherwise the handler's
+// result is honoured. A handler ignoring its tomb for longer than a grace
+// period is abandoned: the task errors out as timed out and its lanes are
+// aborted, the handler's eventual result is ignored. The function is
+// called with the state lock held.
+func (r *TaskRunner) SetTimeout(timeout func(t *Task) time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timeout = timeout
+}
+
 // run must be called with the state lock in place
 func (r *TaskRunner) run(t *Task) {
 	var handler HandlerFunc
@@ -212,6 +265,12 @@ func (r *TaskRunner) run(t *Task) {
 	t.At(time.Time{}) // clear schedule
 	tomb := &tomb.Tomb{}
 	r.tombs[t.ID()] = tomb
+	if r.timeout != nil {
+		if timeout := r.timeout(t); timeout > 0 {
+			r.deadlines[t.ID()] = taskDeadline{at: timeNow().Add(timeout), timeout: timeout}
+			r.state.EnsureBefore(timeout)
+		}
+	}
 	tomb.Go(func() error {
 		// Capture the error result with tomb.Kill so we can
 		// use tomb.Err uniformly to consider both it or a
@@ -225,9 +284,17 @@ func (r *TaskRunner) run(t *Task) {
 		defer r.mu.Unlock()
 		r.state.Lock()
 		defer r.state.Unlock()
+		if r.tombs[t.ID()] != tomb {
+			// the task was abandoned, its outcome was decided
+			// already
+			logger.Noticef("Abandoned task %s returned: %v", t.ID(), tomb.Err())
+			return nil
+		}
 		accuRuntime(t1.Sub(t0))
 
+		deadline := r.deadlines[t.ID()]
 		delete(r.tombs, t.ID())
+		delete(r.deadlines, t.ID())
 
 		// some tasks were blocked, now there's chance the
 		// blocked predicate will change its value
@@ -248,10 +315,18 @@ func (r *TaskRunner) run(t *Task) {
 				err = &Retry{}
 			}
 		}
+		if _, ok := err.(*Retry); ok && deadline.expired && !r.stopped {
+			// the handler gave up because it ran out of time,
+			// errors and completions are kept as they are
+			err = &timeoutError{timeout: deadline.timeout}
+		}
 
 		switch x := err.(type) {
 		case *Retry:
 			// Handler asked to be called again later.
+			if !r.stopped && r.taskRetryCallback != nil {
+				r.taskRetryCallback(t)
+			}
 			if t.Status() == AbortStatus {
 				// Would work without it but might take two ensures.
 				r.tryUndo(t)
@@ -293,8 +368,10 @@ func (r *TaskRunner) run(t *Task) {
 			}
 		default:
 			r.abortLanes(t.Change(), t.Lanes())
-			t.SetStatus(ErrorStatus)
+			// log the error first so that it is available to the
+			// status change handlers
 			t.Errorf("%s", err)
+			t.SetStatus(ErrorStatus)
 			// ensure the error is available in the global log too
 			logger.Noticef("[change %s %q task] failed: %v", t.Change().ID(), t.Summary(), err)
 			if r.taskErrorCallback != nil {
@@ -306,6 +383,46 @@ func (r *TaskRunner) run(t *Task) {
 	})
 }
 
+// abandon stops waiting for the handler of the running task t, which is
+// put in ErrorStatus with the given error, aborting its lanes. The result
+// of the handler is ignored once it returns.
+func (r *TaskRunner) abandon(t *Task, err error) {
+	r.tombs[t.ID()].Kill(nil)
+	delete(r.tombs, t.ID())
+	delete(r.deadlines, t.ID())
+
+	logger.Noticef("Abandoning task %s on %s: %s", t.ID(), t.Status(), t.Summary())
+	r.abortLanes(t.Change(), t.Lanes())
+	t.Errorf("%s", err)
+	t.SetStatus(ErrorStatus)
+	if r.taskErrorCallback != nil {
+		r.taskErrorCallback(err)
+	}
+	r.state.EnsureBefore(0)
+}
+
+// ForceAbort flags the change for cancellation like Change.Abort, but
+// without waiting for the handlers of its tasks being run: those tasks are
+// abandoned and put in ErrorStatus right away, the completed ones are undone
+// as usual. It must be called without holding the state lock.
+func (r *TaskRunner) ForceAbort(chg *Change) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.Lock()
+	defer r.state.Unlock()
+
+	if chg.IsReady() {
+		return
+	}
+	chg.Abort()
+	for _, t := range chg.Tasks() {
+		if _, ok := r.tombs[t.ID()]; ok {
+			r.abandon(t, fmt.Errorf("task was forcibly aborted"))
+		}
+	}
+	r.state.EnsureBefore(0)
+}
+
 func (r *TaskRunner) clean(t *Task) {
 	if !t.Change().IsReady() {
 		// Whole Change is not ready so don't run cleanups yet.
@@ -418,7 +535,35 @@ ConsiderTasks:
 		}
 
 		if tb != nil {
-			// Already being handled.
+			// Already being handled, stop it if it ran out of time.
+			deadline, ok := r.deadlines[t.ID()]
+			if !ok {
+				continue
+			}
+			if deadline.expired {
+				// give up on handlers ignoring their tomb
+				abandonAt := deadline.at.Add(timeoutGrace)
+				if !ensureTime.Before(abandonAt) {
+					r.abandon(t, &timeoutError{timeout: deadline.timeout})
+				} else if nextTaskTime.IsZero() || nextTaskTime.After(abandonAt) {
+					nextTaskTime = abandonAt
+				}
+				continue
+			}
+			if ensureTime.Before(deadline.at) {
+				if nextTaskTime.IsZero() || nextTaskTime.After(deadline.at) {
+					nextTaskTime = deadline.at
+				}
+				continue
+			}
+			logger.Noticef("Task %s on %s timed out after %v: %s", t.ID(), t.Status(), deadline.timeout, t.Summary())
+			// the handler's result decides the outcome, see run
+			tb.Kill(nil)
+			deadline.expired = true
+			r.deadlines[t.ID()] = deadline
+			if abandonAt := deadline.at.Add(timeoutGrace); nextTaskTime.IsZero() || nextTaskTime.After(abandonAt) {
+				nextTaskTime = abandonAt
+			}
 			continue
 		}
 
@@ -439,6 +584,13 @@ ConsiderTasks:
 			continue
 		}
 
+		if mustWaitForChanges(t) {
+			// Queued behind changes still in progress, look
+			// again whenever a task finishes.
+			r.someBlocked = true
+			continue
+		}
+
 		if status == UndoStatus && handlers.undo == nil {
 			// Although this has no dependencies itself, it must have waited
 			// above too since follow up tasks may have handlers again.
@@ -474,7 +626,7 @@ ConsiderTasks:
 		running = append(running, t)
 	}
 
-	// schedule next Ensure no later than the next task time
+	// schedule next Ensure no later than the next task time or deadline
 	if !nextTaskTime.IsZero() {
 		r.state.EnsureBefore(nextTaskTime.Sub(ensureTime))
 	}
@@ -501,6 +653,20 @@ func mustWait(t *Task) bool {
 	return false
 }
 
+// mustWaitForChanges returns whether task t must wait for the changes it
+// was queued behind to be ready.
+func mustWaitForChanges(t *Task) bool {
+	if t.Status() != DoStatus {
+		return false
+	}
+	for _, chg := range t.WaitChanges() {
+		if !chg.IsReady() {
+			return true
+		}
+	}
+	return false
+}
+
 // wait expects to be called with th r.mu lock held
 func (r *TaskRunner) wait() {
 	for len(r.tombs) > 0 {
@@ -888,6 +888,10 @@ func (ts *taskRunnerSuite) TestRetryAfterDuration(c *C) {
 	tock := time.Now()
 	restore := state.MockTime(tock)
 	defer restore()
+	var retried []string
+	r.OnTaskRetry(func(t *state.Task) {
+		retried = append(retried, t.Kind())
+	})
 	r.Ensure() // will run and be rescheduled in a minute
 	select {
 	case <-ensureBeforeTick:
@@ -900,6 +904,7 @@ func (ts *taskRunnerSuite) TestRetryAfterDuration(c *C) {
 	c.Check(t.Status(), Equals, state.DoingStatus)
 
 	c.Check(ask, Equals, 1)
+	c.Check(retried, DeepEquals, []string{"ask-for-retry"})
 	c.Check(sb.ensureBefore, Equals, 1*time.Minute)
 	schedule := t.AtTime()
 	c.Check(schedule.IsZero(), Equals, false)
@@ -1046,6 +1051,56 @@ func (ts *taskRunnerSuite) TestTaskSerializationAddBlocked(c *C) {
 	})
 }
 
+func (ts *taskRunnerSuite) TestWaitForChange(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var seq []string
+	ch := make(chan bool)
+	r.AddHandler("block", func(t *state.Task, tb *tomb.Tomb) error {
+		<-ch
+		seq = append(seq, "block")
+		return errors.New("BAM")
+	}, nil)
+	r.AddHandler("queued", func(t *state.Task, tb *tomb.Tomb) error {
+		seq = append(seq, "queued")
+		return nil
+	}, nil)
+
+	st.Lock()
+	chg1 := st.NewChange("install", "...")
+	t1 := st.NewTask("block", "...")
+	chg1.AddTask(t1)
+	chg2 := st.NewChange("refresh", "...")
+	t2 := st.NewTask("queued", "...")
+	t2.WaitForChange(chg1)
+	chg2.AddTask(t2)
+	c.Check(t2.WaitChanges(), DeepEquals, []*state.Change{chg1})
+	// the changes do not depend on each other otherwise
+	c.Check(t2.WaitTasks(), HasLen, 0)
+	c.Check(t1.HaltTasks(), HasLen, 0)
+	st.Unlock()
+
+	r.Ensure()
+	st.Lock()
+	c.Check(t1.Status(), Equals, state.DoingStatus)
+	c.Check(t2.Status(), Equals, state.DoStatus)
+	st.Unlock()
+
+	// the queued task runs once the change is ready, even if it failed
+	ch <- true
+	r.Wait()
+	ensureChange(c, r, sb, chg2)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg1.Status(), Equals, state.ErrorStatus)
+	c.Check(chg2.Status(), Equals, state.DoneStatus)
+	c.Check(seq, DeepEquals, []string{"block", "queued"})
+}
+
 func (ts *taskRunnerSuite) TestPrematureChangeReady(c *C) {
 	sb := &stateBackend{}
 	st := state.New(sb)
@@ -1328,3 +1383,252 @@ func (ts *taskRunnerSuite) TestErrorCallbackNotCalled(c *C) {
 	c.Check(t1.Status(), Equals, state.DoneStatus)
 	c.Check(called, Equals, false)
 }
+
+func (ts *taskRunnerSuite) TestTimeout(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	r.AddHandler("just-finish", func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	}, func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	})
+	started := make(chan bool)
+	r.AddHandler("hang", func(t *state.Task, tb *tomb.Tomb) error {
+		started <- true
+		<-tb.Dying()
+		// giving up turns into the timeout error
+		return &state.Retry{}
+	}, nil)
+	r.SetTimeout(func(t *state.Task) time.Duration {
+		if t.Kind() == "hang" {
+			return time.Minute
+		}
+		return 0
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("just-finish", "...")
+	t2 := st.NewTask("hang", "...")
+	t2.WaitFor(t1)
+	lane := st.NewLane()
+	t1.JoinLane(lane)
+	t2.JoinLane(lane)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	st.Unlock()
+
+	now := time.Now()
+	restore := state.MockTime(now)
+	defer restore()
+
+	r.Ensure()
+	r.Wait()
+	sb.ensureBefore = time.Hour
+	r.Ensure()
+	<-started
+	c.Check(sb.ensureBefore, Equals, time.Minute)
+
+	state.MockTime(now.Add(30 * time.Second))
+	sb.ensureBefore = time.Hour
+	r.Ensure() // too soon
+	st.Lock()
+	c.Check(t2.Status(), Equals, state.DoingStatus)
+	st.Unlock()
+	c.Check(sb.ensureBefore, Equals, 30*time.Second)
+
+	state.MockTime(now.Add(time.Minute))
+	r.Ensure()
+	r.Wait()
+
+	st.Lock()
+	c.Check(t2.Status(), Equals, state.ErrorStatus)
+	c.Check(strings.Join(t2.Log(), ""), Matches, `.* ERROR task timed out after 1m0s`)
+	c.Check(t1.Status(), Equals, state.UndoStatus)
+	st.Unlock()
+
+	r.Ensure()
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(t1.Status(), Equals, state.UndoneStatus)
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+}
+
+func (ts *taskRunnerSuite) TestTimeoutHandlerCompletes(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	started := make(chan bool)
+	finish := make(chan bool)
+	r.AddHandler("slow", func(t *state.Task, tb *tomb.Tomb) error {
+		started <- true
+		// completes regardless of the kill
+		<-finish
+		return nil
+	}, nil)
+	r.SetTimeout(func(t *state.Task) time.Duration {
+		return time.Minute
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("slow", "...")
+	chg.AddTask(t1)
+	st.Unlock()
+
+	now := time.Now()
+	restore := state.MockTime(now)
+	defer restore()
+
+	r.Ensure()
+	<-started
+
+	state.MockTime(now.Add(time.Minute))
+	r.Ensure()
+	close(finish)
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(t1.Status(), Equals, state.DoneStatus)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+}
+
+func (ts *taskRunnerSuite) TestTimeoutAbandonsHungHandler(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	restore := state.MockTimeoutGrace(10 * time.Second)
+	defer restore()
+
+	r.AddHandler("just-finish", func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	}, func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	})
+	started := make(chan bool)
+	finish := make(chan bool)
+	returned := make(chan bool)
+	r.AddHandler("stuck", func(t *state.Task, tb *tomb.Tomb) error {
+		started <- true
+		// ignores the kill
+		<-finish
+		defer close(returned)
+		return nil
+	}, nil)
+	r.SetTimeout(func(t *state.Task) time.Duration {
+		if t.Kind() == "stuck" {
+			return time.Minute
+		}
+		return 0
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("just-finish", "...")
+	t2 := st.NewTask("stuck", "...")
+	t2.WaitFor(t1)
+	lane := st.NewLane()
+	t1.JoinLane(lane)
+	t2.JoinLane(lane)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	st.Unlock()
+
+	now := time.Now()
+	restoreTime := state.MockTime(now)
+	defer restoreTime()
+
+	r.Ensure()
+	r.Wait()
+	r.Ensure()
+	<-started
+
+	state.MockTime(now.Add(time.Minute))
+	sb.ensureBefore = time.Hour
+	r.Ensure() // tomb killed
+	c.Check(sb.ensureBefore, Equals, 10*time.Second)
+	st.Lock()
+	c.Check(t2.Status(), Equals, state.DoingStatus)
+	st.Unlock()
+
+	state.MockTime(now.Add(time.Minute + 10*time.Second))
+	r.Ensure() // abandoned
+	r.Wait()
+
+	st.Lock()
+	c.Check(t2.Status(), Equals, state.ErrorStatus)
+	c.Check(strings.Join(t2.Log(), ""), Matches, `.* ERROR task timed out after 1m0s`)
+	c.Check(t1.Status(), Equals, state.UndoStatus)
+	st.Unlock()
+
+	// the eventual result of the handler is ignored
+	close(finish)
+	<-returned
+	r.Ensure()
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(t2.Status(), Equals, state.ErrorStatus)
+	c.Check(t1.Status(), Equals, state.UndoneStatus)
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+}
+
+func (ts *taskRunnerSuite) TestForceAbort(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	r.AddHandler("just-finish", func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	}, func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	})
+	started := make(chan bool)
+	finish := make(chan bool)
+	r.AddHandler("stuck", func(t *state.Task, tb *tomb.Tomb) error {
+		started <- true
+		// ignores the kill
+		<-finish
+		return nil
+	}, nil)
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("just-finish", "...")
+	t2 := st.NewTask("stuck", "...")
+	t2.WaitFor(t1)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	st.Unlock()
+
+	r.Ensure()
+	r.Wait()
+	r.Ensure()
+	<-started
+	defer close(finish)
+
+	r.ForceAbort(chg)
+
+	st.Lock()
+	c.Check(t2.Status(), Equals, state.ErrorStatus)
+	c.Check(strings.Join(t2.Log(), ""), Matches, `.* ERROR task was forcibly aborted`)
+	c.Check(t1.Status(), Equals, state.UndoStatus)
+	st.Unlock()
+
+	r.Ensure()
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(t1.Status(), Equals, state.UndoneStatus)
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+}
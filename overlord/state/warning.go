@@ -41,6 +41,34 @@ var (
 	errNoWarningRepeatAfter = errors.New("warning has no repeat-after duration")
 )
 
+// WarningSeverity is how important a warning is.
+type WarningSeverity string
+
+const (
+	WarningSeverityLow    WarningSeverity = "low"
+	WarningSeverityNormal WarningSeverity = "normal"
+	WarningSeverityHigh   WarningSeverity = "high"
+)
+
+var warningSeverityRank = map[WarningSeverity]int{
+	WarningSeverityLow:    1,
+	WarningSeverityNormal: 2,
+	WarningSeverityHigh:   3,
+}
+
+// ValidateWarningSeverity returns an error if s is not a known severity.
+func ValidateWarningSeverity(s WarningSeverity) error {
+	if warningSeverityRank[s] == 0 {
+		return fmt.Errorf("invalid warning severity %q", s)
+	}
+	return nil
+}
+
+// AtLeast returns whether the severity is s or a higher one.
+func (sev WarningSeverity) AtLeast(s WarningSeverity) bool {
+	return warningSeverityRank[sev] >= warningSeverityRank[s]
+}
+
 type jsonWarning struct {
 	Message     string     `json:"message"`
 	FirstAdded  time.Time  `json:"first-added"`
@@ -48,6 +76,8 @@ type jsonWarning struct {
 	LastShown   *time.Time `json:"last-shown,omitempty"`
 	ExpireAfter string     `json:"expire-after,omitempty"`
 	RepeatAfter string     `json:"repeat-after,omitempty"`
+	Count       int        `json:"count,omitempty"`
+	Severity    string     `json:"severity,omitempty"`
 }
 
 type Warning struct {
@@ -63,6 +93,10 @@ type Warning struct {
 	expireAfter time.Duration
 	// how much time since one of these was last shown should we repeat it
 	repeatAfter time.Duration
+	// how many times one of these was created
+	count int
+	// how important the warning is
+	severity WarningSeverity
 }
 
 func (w *Warning) String() string {
@@ -76,6 +110,8 @@ func (w *Warning) MarshalJSON() ([]byte, error) {
 		LastAdded:   w.lastAdded,
 		ExpireAfter: w.expireAfter.String(),
 		RepeatAfter: w.repeatAfter.String(),
+		Count:       w.count,
+		Severity:    string(w.severity),
 	}
 	if !w.lastShown.IsZero() {
 		jw.LastShown = &w.lastShown
@@ -96,6 +132,16 @@ func (w *Warning) UnmarshalJSON(data []byte) error {
 	if jw.LastShown != nil {
 		w.lastShown = *jw.LastShown
 	}
+	w.count = jw.Count
+	if w.count == 0 {
+		// recorded before occurrences were counted
+		w.count = 1
+	}
+	w.severity = WarningSeverity(jw.Severity)
+	if w.severity == "" {
+		// recorded before warnings had a severity
+		w.severity = WarningSeverityNormal
+	}
 	if jw.ExpireAfter != "" {
 		w.expireAfter, err = time.ParseDuration(jw.ExpireAfter)
 		if err != nil {
@@ -128,7 +174,7 @@ func (w *Warning) validate() (e error) {
 	if w.repeatAfter == 0 {
 		return errNoWarningRepeatAfter
 	}
-	return nil
+	return ValidateWarningSeverity(w.severity)
 }
 
 func (w *Warning) ExpiredBefore(now time.Time) bool {
@@ -173,11 +219,19 @@ func (s *State) unflattenWarnings(flat []*Warning) {
 	}
 }
 
-// Warnf records a warning: if it's the first Warning with this
-// message it'll be added (with its firstAdded and lastAdded set to the
-// current time), otherwise the existing one will have its lastAdded
-// updated.
+// Warnf records a warning of normal severity: if it's the first
+// Warning with this message it'll be added (with its firstAdded and
+// lastAdded set to the current time), otherwise the existing one will
+// have its lastAdded updated. In both cases its count of occurrences is
+// incremented.
 func (s *State) Warnf(template string, args ...interface{}) {
+	s.WarnfWithSeverity(WarningSeverityNormal, template, args...)
+}
+
+// WarnfWithSeverity records a warning like Warnf but with the given
+// severity. If the warning was already recorded with another severity,
+// the highest one is kept.
+func (s *State) WarnfWithSeverity(severity WarningSeverity, template string, args ...interface{}) {
 	var message string
 	if len(args) > 0 {
 		message = fmt.Sprintf(template, args...)
@@ -188,13 +242,15 @@ func (s *State) Warnf(template string, args ...interface{}) {
 		message:     message,
 		expireAfter: DefaultExpireAfter,
 		repeatAfter: DefaultRepeatAfter,
+		severity:    severity,
 	}, time.Now().UTC())
 }
 
 func (s *State) addWarning(w Warning, t time.Time) {
 	s.writing()
 
-	if s.warnings[w.message] == nil {
+	prev := s.warnings[w.message]
+	if prev == nil {
 		w.firstAdded = t
 		if err := w.validate(); err != nil {
 			// programming error!
@@ -203,7 +259,32 @@ func (s *State) addWarning(w Warning, t time.Time) {
 		}
 		s.warnings[w.message] = &w
 	}
-	s.warnings[w.message].lastAdded = t
+	// only report repeated warnings again to the handlers once they
+	// would be repeated to the user, or if they became more severe
+	notify := prev == nil || !t.Before(prev.lastAdded.Add(prev.repeatAfter))
+	cur := s.warnings[w.message]
+	cur.lastAdded = t
+	cur.count++
+	if w.severity != cur.severity && w.severity.AtLeast(cur.severity) {
+		cur.severity = w.severity
+		notify = true
+	}
+	if notify {
+		if _, err := s.AddNotice(WarningNotice, cur.message, &AddNoticeOptions{Time: t}); err != nil {
+			logger.Panicf("%v", err)
+		}
+		s.notifyWarningAddedHandlers(cur)
+	}
+}
+
+// Count returns how many times the warning was added.
+func (w *Warning) Count() int {
+	return w.count
+}
+
+// Severity returns how important the warning is.
+func (w *Warning) Severity() WarningSeverity {
+	return w.severity
 }
 
 type byLastAdded []*Warning
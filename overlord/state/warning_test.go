@@ -40,7 +40,7 @@ func (stateSuite) testMarshalWarning(shown bool, c *check.C) {
 	st.Warnf("hello")
 	now := time.Now()
 
-	expectedNumKeys := 5
+	expectedNumKeys := 7
 	if shown {
 		expectedNumKeys++ // last-shown
 		st.OkayWarnings(now)
@@ -52,7 +52,7 @@ func (stateSuite) testMarshalWarning(shown bool, c *check.C) {
 	buf, err := json.Marshal(ws)
 	c.Assert(err, check.IsNil)
 
-	var v []map[string]string
+	var v []map[string]interface{}
 	c.Assert(json.Unmarshal(buf, &v), check.IsNil)
 	c.Assert(v, check.HasLen, 1)
 	c.Check(v[0], check.HasLen, expectedNumKeys)
@@ -60,14 +60,16 @@ func (stateSuite) testMarshalWarning(shown bool, c *check.C) {
 	c.Check(v[0]["expire-after"], check.Equals, state.DefaultExpireAfter.String())
 	c.Check(v[0]["repeat-after"], check.Equals, state.DefaultRepeatAfter.String())
 	c.Check(v[0]["first-added"], check.Equals, v[0]["last-added"])
-	t, err := time.Parse(time.RFC3339, v[0]["first-added"])
+	c.Check(v[0]["count"], check.Equals, 1.0)
+	c.Check(v[0]["severity"], check.Equals, "normal")
+	t, err := time.Parse(time.RFC3339, v[0]["first-added"].(string))
 	c.Assert(err, check.IsNil)
 	dt := t.Sub(now)
 	// 'now' was just *after* creating the warning
 	c.Check(dt <= 0, check.Equals, true)
 	c.Check(-time.Minute < dt, check.Equals, true)
 	if shown {
-		t, err := time.Parse(time.RFC3339, v[0]["last-shown"])
+		t, err := time.Parse(time.RFC3339, v[0]["last-shown"].(string))
 		c.Assert(err, check.IsNil)
 		dt := t.Sub(now)
 		// 'now' was just *before* marking the warning as shown
@@ -177,6 +179,98 @@ func (stateSuite) TestOldRepeatedWarning(c *check.C) {
 	c.Check(w.ShowAfter(now), check.Equals, true)
 }
 
+func (stateSuite) TestWarningCount(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	st.Warnf("hello")
+	st.Warnf("hello")
+	st.Warnf("hello again")
+
+	ws := st.AllWarnings()
+	c.Assert(ws, check.HasLen, 2)
+	c.Check(ws[0].String(), check.Equals, "hello")
+	c.Check(ws[0].Count(), check.Equals, 2)
+	c.Check(ws[1].String(), check.Equals, "hello again")
+	c.Check(ws[1].Count(), check.Equals, 1)
+
+	// warnings recorded before they were counted occurred at least once
+	var w state.Warning
+	err := json.Unmarshal([]byte(`{"message": "x", "first-added": "2006-01-02T15:04:05Z", "expire-after": "1h", "repeat-after": "1h"}`), &w)
+	c.Assert(err, check.IsNil)
+	c.Check(w.Count(), check.Equals, 1)
+}
+
+func (stateSuite) TestWarnfWithSeverity(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	st.Warnf("normal")
+	st.WarnfWithSeverity(state.WarningSeverityLow, "low %d", 1)
+	st.WarnfWithSeverity(state.WarningSeverityLow, "escalated")
+	st.WarnfWithSeverity(state.WarningSeverityHigh, "escalated")
+	st.WarnfWithSeverity(state.WarningSeverityNormal, "escalated")
+
+	ws := st.AllWarnings()
+	c.Assert(ws, check.HasLen, 3)
+	c.Check(ws[0].String(), check.Equals, "normal")
+	c.Check(ws[0].Severity(), check.Equals, state.WarningSeverityNormal)
+	c.Check(ws[1].String(), check.Equals, "low 1")
+	c.Check(ws[1].Severity(), check.Equals, state.WarningSeverityLow)
+	// the highest severity is kept
+	c.Check(ws[2].String(), check.Equals, "escalated")
+	c.Check(ws[2].Severity(), check.Equals, state.WarningSeverityHigh)
+	c.Check(ws[2].Count(), check.Equals, 3)
+
+	// warnings recorded before they had a severity are normal ones
+	var w state.Warning
+	err := json.Unmarshal([]byte(`{"message": "x", "first-added": "2006-01-02T15:04:05Z", "expire-after": "1h", "repeat-after": "1h"}`), &w)
+	c.Assert(err, check.IsNil)
+	c.Check(w.Severity(), check.Equals, state.WarningSeverityNormal)
+
+	err = json.Unmarshal([]byte(`{"message": "x", "first-added": "2006-01-02T15:04:05Z", "expire-after": "1h", "repeat-after": "1h", "severity": "extreme"}`), &w)
+	c.Assert(err, check.ErrorMatches, `invalid warning severity "extreme"`)
+}
+
+func (stateSuite) TestWarningSeverityAtLeast(c *check.C) {
+	c.Check(state.WarningSeverityHigh.AtLeast(state.WarningSeverityNormal), check.Equals, true)
+	c.Check(state.WarningSeverityNormal.AtLeast(state.WarningSeverityNormal), check.Equals, true)
+	c.Check(state.WarningSeverityLow.AtLeast(state.WarningSeverityNormal), check.Equals, false)
+	c.Check(state.ValidateWarningSeverity("low"), check.IsNil)
+	c.Check(state.ValidateWarningSeverity(""), check.ErrorMatches, `invalid warning severity ""`)
+}
+
+func (stateSuite) TestWarningAddedHandler(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	var added []string
+	id := st.AddWarningAddedHandler(func(w *state.Warning) {
+		added = append(added, fmt.Sprintf("%s/%d", w, w.Count()))
+	})
+
+	now := time.Now()
+	st.AddWarning("hello", now, never, time.Hour, time.Minute)
+	// repeated before it would be shown again
+	st.AddWarning("hello", now.Add(30*time.Second), never, time.Hour, time.Minute)
+	st.AddWarning("hello", now.Add(2*time.Minute), never, time.Hour, time.Minute)
+	st.AddWarning("other", now, never, time.Hour, time.Minute)
+	c.Check(added, check.DeepEquals, []string{"hello/1", "hello/3", "other/1"})
+
+	// becoming more severe is reported right away
+	st.WarnfWithSeverity(state.WarningSeverityHigh, "other")
+	st.WarnfWithSeverity(state.WarningSeverityHigh, "other")
+	st.WarnfWithSeverity(state.WarningSeverityLow, "other")
+	c.Check(added, check.DeepEquals, []string{"hello/1", "hello/3", "other/1", "other/2"})
+
+	st.RemoveWarningAddedHandler(id)
+	st.AddWarning("more", now, never, time.Hour, time.Minute)
+	c.Check(added, check.HasLen, 4)
+}
+
 func (stateSuite) TestCheckpoint(c *check.C) {
 	b := &fakeStateBackend{}
 	st := state.New(b)
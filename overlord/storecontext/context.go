@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"time"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/sysdb"
@@ -76,6 +77,14 @@ type StoreOptions interface {
 	// StoreOffline returns a string indicating whether the store should have
 	// network access or not
 	StoreOffline() (bool, error)
+
+	// BackupProxyStore returns the store assertion for the backup proxy
+	// store if one is set.
+	BackupProxyStore() (*asserts.Store, error)
+
+	// StoreFailoverTimeout returns for how long the store must be
+	// unreachable before failing over to the backup proxy store.
+	StoreFailoverTimeout() (time.Duration, error)
 }
 
 // storeContext implements store.DeviceAndAuthContext.
@@ -251,6 +260,37 @@ func (sc *storeContext) ProxyStoreParams(defaultURL *url.URL) (proxyStoreID stri
 	return "", defaultURL, nil
 }
 
+// BackupProxyStoreParams returns the URL of the backup proxy store, if
+// one is set, and for how long the store must be unreachable before
+// failing over to it, or 0 if unset.
+func (sc *storeContext) BackupProxyStoreParams() (backupURL *url.URL, failoverTimeout time.Duration, err error) {
+	sc.state.Lock()
+	defer sc.state.Unlock()
+
+	sto, err := sc.storeOptions.BackupProxyStore()
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, 0, err
+	}
+	if sto == nil {
+		return nil, 0, nil
+	}
+
+	failoverTimeout, err = sc.storeOptions.StoreFailoverTimeout()
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, 0, err
+	}
+
+	return sto.URL(), failoverTimeout, nil
+}
+
+// Warnf records a warning about the store.
+func (sc *storeContext) Warnf(format string, v ...interface{}) {
+	sc.state.Lock()
+	defer sc.state.Unlock()
+
+	sc.state.Warnf(format, v...)
+}
+
 func (sc *storeContext) StoreOffline() (bool, error) {
 	sc.state.Lock()
 	defer sc.state.Unlock()
@@ -300,14 +300,25 @@ url: http://foo.internal
 timestamp: 2017-11-01T10:00:00Z
 sign-key-sha3-384: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij
 
+AXNpZw=`
+
+	exBackupStore = `type: store
+authority-id: canonical
+store: bar
+operator-id: foo-operator
+url: http://bar.internal
+timestamp: 2017-11-01T10:00:00Z
+sign-key-sha3-384: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij
+
 AXNpZw=`
 )
 
 type testBackend struct {
-	nothing      bool
-	noSerial     bool
-	storeOffline bool
-	device       *auth.DeviceState
+	nothing         bool
+	noSerial        bool
+	storeOffline    bool
+	failoverTimeout time.Duration
+	device          *auth.DeviceState
 }
 
 func (b *testBackend) Device() (*auth.DeviceState, error) {
@@ -371,6 +382,24 @@ func (b *testBackend) ProxyStore() (*asserts.Store, error) {
 	return a.(*asserts.Store), nil
 }
 
+func (b *testBackend) BackupProxyStore() (*asserts.Store, error) {
+	if b.nothing {
+		return nil, state.ErrNoState
+	}
+	a, err := asserts.Decode([]byte(exBackupStore))
+	if err != nil {
+		return nil, err
+	}
+	return a.(*asserts.Store), nil
+}
+
+func (b *testBackend) StoreFailoverTimeout() (time.Duration, error) {
+	if b.failoverTimeout == 0 {
+		return 0, state.ErrNoState
+	}
+	return b.failoverTimeout, nil
+}
+
 func (b *testBackend) StoreOffline() (bool, error) {
 	if b.nothing {
 		return false, state.ErrNoState
@@ -432,6 +461,40 @@ func (s *storeCtxSuite) TestWithDeviceAssertions(c *C) {
 	c.Check(proxyStoreURL, DeepEquals, fooURL)
 }
 
+func (s *storeCtxSuite) TestBackupProxyStoreParams(c *C) {
+	storeCtx := storecontext.New(s.state, &testBackend{nothing: true})
+	backupURL, failoverTimeout, err := storeCtx.BackupProxyStoreParams()
+	c.Assert(err, IsNil)
+	c.Check(backupURL, IsNil)
+	c.Check(failoverTimeout, Equals, time.Duration(0))
+
+	barURL, err := url.Parse("http://bar.internal")
+	c.Assert(err, IsNil)
+
+	storeCtx = storecontext.New(s.state, &testBackend{})
+	backupURL, failoverTimeout, err = storeCtx.BackupProxyStoreParams()
+	c.Assert(err, IsNil)
+	c.Check(backupURL, DeepEquals, barURL)
+	c.Check(failoverTimeout, Equals, time.Duration(0))
+
+	storeCtx = storecontext.New(s.state, &testBackend{failoverTimeout: time.Minute})
+	backupURL, failoverTimeout, err = storeCtx.BackupProxyStoreParams()
+	c.Assert(err, IsNil)
+	c.Check(backupURL, DeepEquals, barURL)
+	c.Check(failoverTimeout, Equals, time.Minute)
+}
+
+func (s *storeCtxSuite) TestWarnf(c *C) {
+	storeCtx := storecontext.New(s.state, &testBackend{nothing: true})
+	storeCtx.Warnf("store %s is unreachable", "foo")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, "store foo is unreachable")
+}
+
 func (s *storeCtxSuite) TestWithDeviceAssertionsGenericClassicModel(c *C) {
 	model, err := asserts.Decode([]byte(exModel))
 	c.Assert(err, IsNil)
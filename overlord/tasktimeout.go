@@ -0,0 +1,50 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package overlord
+
+import (
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// taskTimeout returns for how long tasks of the kind of the given one may
+// run, as set via tasks.timeout.<kind>, or zero if there is no limit.
+func taskTimeout(task *state.Task) time.Duration {
+	tr := config.NewTransaction(task.State())
+	var timeoutStr string
+	if err := tr.Get("core", "tasks.timeout."+task.Kind(), &timeoutStr); err != nil {
+		if !config.IsNoOption(err) {
+			logger.Noticef("cannot get timeout of %q tasks: %v", task.Kind(), err)
+		}
+		return 0
+	}
+	if timeoutStr == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		logger.Noticef("cannot parse timeout of %q tasks: %v", task.Kind(), err)
+		return 0
+	}
+	return timeout
+}
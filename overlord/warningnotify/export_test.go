@@ -0,0 +1,60 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package warningnotify
+
+import (
+	"context"
+	"io"
+
+	"github.com/snapcore/snapd/testutil"
+	userclient "github.com/snapcore/snapd/usersession/client"
+)
+
+func MockJournalStream(f func() (io.WriteCloser, error)) (restore func()) {
+	r := testutil.Backup(&newJournalStream, &journal)
+	newJournalStream = f
+	journal = nil
+	return r
+}
+
+func MockDispatchSync() (restore func()) {
+	r := testutil.Backup(&dispatch, &queueJournalEntry)
+	dispatch = func(f func()) { f() }
+	queueJournalEntry = writeToJournal
+	return r
+}
+
+type JournalEntry = journalEntry
+
+func MockQueueJournalEntry(f func(e JournalEntry)) (restore func()) {
+	r := testutil.Backup(&queueJournalEntry)
+	queueJournalEntry = f
+	return r
+}
+
+func (e JournalEntry) Message() string {
+	return e.msg
+}
+
+func MockNotifyDesktopSessions(f func(ctx context.Context, info *userclient.WarningInfo) error) (restore func()) {
+	r := testutil.Backup(&notifyDesktopSessions)
+	notifyDesktopSessions = f
+	return r
+}
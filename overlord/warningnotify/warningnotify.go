@@ -0,0 +1,170 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package warningnotify mirrors warnings to the journal and to desktop
+// sessions according to their severity.
+package warningnotify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/syslog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/systemd"
+	userclient "github.com/snapcore/snapd/usersession/client"
+)
+
+// Init sets up mirroring the warnings added to the state.
+func Init(st *state.State) {
+	startJournalWriter.Do(func() {
+		go journalWriter()
+	})
+	st.AddWarningAddedHandler(func(w *state.Warning) {
+		notifyWarning(st, w)
+	})
+}
+
+// journalPriority is the priority high severity warnings are mirrored
+// to the journal with.
+const journalPriority = syslog.LOG_ERR
+
+var (
+	newJournalStream = func() (io.WriteCloser, error) {
+		// with level prefixes so that each message carries its
+		// priority
+		return systemd.NewJournalStreamFile("snapd", syslog.LOG_WARNING, true)
+	}
+
+	// journalMu guards journal, which is only written to by the
+	// journalWriter go-routine outside of tests
+	journalMu sync.Mutex
+	journal   io.WriteCloser
+)
+
+// journalEntry is a warning waiting to be written to the journal.
+type journalEntry struct {
+	msg string
+	// fallback is logged instead if the journal cannot be written to
+	fallback string
+}
+
+// journalQueueSize is how many warnings can be waiting to be written to
+// the journal; more are only logged.
+const journalQueueSize = 64
+
+var (
+	journalQueue       = make(chan journalEntry, journalQueueSize)
+	startJournalWriter sync.Once
+)
+
+// queueJournalEntry hands the entry over to the journalWriter go-routine
+// without blocking, as the state is locked when called.
+var queueJournalEntry = func(e journalEntry) {
+	select {
+	case journalQueue <- e:
+	default:
+		logger.Noticef("WARNING: %s", e.fallback)
+	}
+}
+
+func journalWriter() {
+	for e := range journalQueue {
+		writeToJournal(e)
+	}
+}
+
+// dispatch runs the desktop notifications; it is done in a go-routine as
+// the state is locked when called.
+var dispatch = func(f func()) {
+	go f()
+}
+
+var notifyDesktopSessions = func(ctx context.Context, info *userclient.WarningInfo) error {
+	return userclient.New().WarningNotification(ctx, info)
+}
+
+func notifyWarning(st *state.State, w *state.Warning) {
+	if w.Severity().AtLeast(state.WarningSeverityHigh) {
+		mirrorToJournal(w)
+	}
+
+	var notifyDesktop string
+	if err := config.NewTransaction(st).Get("core", "warnings.notify-desktop", &notifyDesktop); err != nil && !config.IsNoOption(err) {
+		logger.Noticef("cannot get warnings.notify-desktop: %v", err)
+		return
+	}
+	if notifyDesktop == "" || notifyDesktop == "none" || !w.Severity().AtLeast(state.WarningSeverity(notifyDesktop)) {
+		return
+	}
+	info := &userclient.WarningInfo{
+		Message:  w.String(),
+		Severity: string(w.Severity()),
+	}
+	dispatch(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := notifyDesktopSessions(ctx, info); err != nil {
+			logger.Noticef("cannot notify desktop sessions about warning: %v", err)
+		}
+	})
+}
+
+func journalMessage(w *state.Warning) string {
+	// the journal stream is line based
+	msg := strings.Join(strings.Fields(w.String()), " ")
+	if w.Count() > 1 {
+		msg = fmt.Sprintf("%s (repeated %d times)", msg, w.Count())
+	}
+	return fmt.Sprintf("<%d>warning: %s\n", journalPriority, msg)
+}
+
+func mirrorToJournal(w *state.Warning) {
+	queueJournalEntry(journalEntry{
+		msg:      journalMessage(w),
+		fallback: w.String(),
+	})
+}
+
+func writeToJournal(e journalEntry) {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	if journal == nil {
+		var err error
+		journal, err = newJournalStream()
+		if err != nil {
+			logger.Noticef("cannot open journal stream: %v", err)
+			logger.Noticef("WARNING: %s", e.fallback)
+			return
+		}
+	}
+	if _, err := io.WriteString(journal, e.msg); err != nil {
+		logger.Noticef("cannot write warning to the journal: %v", err)
+		logger.Noticef("WARNING: %s", e.fallback)
+		journal.Close()
+		journal = nil
+	}
+}
@@ -0,0 +1,163 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package warningnotify_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/warningnotify"
+	"github.com/snapcore/snapd/testutil"
+	userclient "github.com/snapcore/snapd/usersession/client"
+)
+
+func TestWarningNotify(t *testing.T) { TestingT(t) }
+
+type warningNotifySuite struct {
+	testutil.BaseTest
+
+	st      *state.State
+	journal bytes.Buffer
+	desktop []*userclient.WarningInfo
+}
+
+var _ = Suite(&warningNotifySuite{})
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func (s *warningNotifySuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	s.journal.Reset()
+	s.desktop = nil
+	s.AddCleanup(warningnotify.MockJournalStream(func() (io.WriteCloser, error) {
+		return nopCloser{&s.journal}, nil
+	}))
+	s.AddCleanup(warningnotify.MockDispatchSync())
+	s.AddCleanup(warningnotify.MockNotifyDesktopSessions(func(ctx context.Context, info *userclient.WarningInfo) error {
+		s.desktop = append(s.desktop, info)
+		return nil
+	}))
+
+	s.st = state.New(nil)
+	s.st.Lock()
+	warningnotify.Init(s.st)
+	s.st.Unlock()
+}
+
+func (s *warningNotifySuite) setConfig(c *C, key string, value interface{}) {
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", key, value), IsNil)
+	tr.Commit()
+}
+
+func (s *warningNotifySuite) TestHighSeverityMirroredToJournal(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.st.Warnf("just a warning")
+	s.st.WarnfWithSeverity(state.WarningSeverityLow, "not important")
+	s.st.WarnfWithSeverity(state.WarningSeverityHigh, "something is broken;\nfix it")
+	c.Check(s.journal.String(), Equals, "<3>warning: something is broken; fix it\n")
+
+	// nothing sent to the desktop by default
+	c.Check(s.desktop, HasLen, 0)
+}
+
+func (s *warningNotifySuite) TestJournalMessageCount(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.st.Warnf("escalated")
+	c.Check(s.journal.String(), Equals, "")
+	s.st.WarnfWithSeverity(state.WarningSeverityHigh, "escalated")
+	// repeated too soon to be mirrored again
+	s.st.WarnfWithSeverity(state.WarningSeverityHigh, "escalated")
+	c.Check(s.journal.String(), Equals, "<3>warning: escalated (repeated 2 times)\n")
+}
+
+func (s *warningNotifySuite) TestJournalUnavailable(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+	restore = warningnotify.MockJournalStream(func() (io.WriteCloser, error) {
+		return nil, errors.New("no journal")
+	})
+	defer restore()
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.st.WarnfWithSeverity(state.WarningSeverityHigh, "something is broken")
+	c.Check(logbuf.String(), Matches, `(?s).*cannot open journal stream: no journal\n.*WARNING: something is broken\n`)
+}
+
+func (s *warningNotifySuite) TestNotifyDesktop(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.setConfig(c, "warnings.notify-desktop", "normal")
+
+	s.st.WarnfWithSeverity(state.WarningSeverityLow, "not important")
+	s.st.Warnf("just a warning")
+	s.st.WarnfWithSeverity(state.WarningSeverityHigh, "something is broken")
+	c.Check(s.desktop, DeepEquals, []*userclient.WarningInfo{
+		{Message: "just a warning", Severity: "normal"},
+		{Message: "something is broken", Severity: "high"},
+	})
+}
+
+func (s *warningNotifySuite) TestNotifyDesktopNone(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.setConfig(c, "warnings.notify-desktop", "none")
+
+	s.st.WarnfWithSeverity(state.WarningSeverityHigh, "something is broken")
+	c.Check(s.desktop, HasLen, 0)
+}
+
+func (s *warningNotifySuite) TestJournalWrittenOutsideStateLock(c *C) {
+	var queued []warningnotify.JournalEntry
+	restore := warningnotify.MockQueueJournalEntry(func(e warningnotify.JournalEntry) {
+		queued = append(queued, e)
+	})
+	defer restore()
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.st.WarnfWithSeverity(state.WarningSeverityHigh, "something is broken")
+	// only queued for the journal writer while the state is locked
+	c.Check(s.journal.String(), Equals, "")
+	c.Assert(queued, HasLen, 1)
+	c.Check(queued[0].Message(), Equals, "<3>warning: something is broken\n")
+}
@@ -178,7 +178,6 @@ BuildRequires: golang(golang.org/x/xerrors/internal)
 BuildRequires: golang(gopkg.in/check.v1)
 BuildRequires: golang(gopkg.in/macaroon.v1)
 BuildRequires: golang(gopkg.in/mgo.v2/bson)
-BuildRequires: golang(gopkg.in/retry.v1)
 BuildRequires: golang(gopkg.in/tomb.v2)
 BuildRequires: golang(gopkg.in/yaml.v2)
 BuildRequires: golang(gopkg.in/yaml.v3)
@@ -273,7 +272,6 @@ Requires:      golang(golang.org/x/xerrors/internal)
 Requires:      golang(gopkg.in/check.v1)
 Requires:      golang(gopkg.in/macaroon.v1)
 Requires:      golang(gopkg.in/mgo.v2/bson)
-Requires:      golang(gopkg.in/retry.v1)
 Requires:      golang(gopkg.in/tomb.v2)
 Requires:      golang(gopkg.in/yaml.v2)
 Requires:      golang(gopkg.in/yaml.v3)
@@ -302,7 +300,6 @@ Provides:      bundled(golang(golang.org/x/xerrors/internal))
 Provides:      bundled(golang(gopkg.in/check.v1))
 Provides:      bundled(golang(gopkg.in/macaroon.v1))
 Provides:      bundled(golang(gopkg.in/mgo.v2/bson))
-Provides:      bundled(golang(gopkg.in/retry.v1))
 Provides:      bundled(golang(gopkg.in/tomb.v2))
 Provides:      bundled(golang(gopkg.in/yaml.v2))
 Provides:      bundled(golang(gopkg.in/yaml.v3))
@@ -0,0 +1,232 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package retryutil
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	timeNow   = time.Now
+	timeAfter = time.After
+)
+
+// Limits bound the attempts made by Do.
+type Limits struct {
+	// MaxAttempts is the maximum number of attempts, including the
+	// first one, if set.
+	MaxAttempts int
+	// MaxElapsed is the time since the first attempt after which no
+	// further attempt is started, if set.
+	MaxElapsed time.Duration
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so that Do returns it right away instead of
+// retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls f until it succeeds, returns an error wrapped with Permanent,
+// ctx is done or the limits are reached, waiting between attempts as
+// decided by strategy. It returns the error of the last attempt, or of ctx
+// if it was done while waiting.
+func Do(ctx context.Context, strategy Strategy, limits Limits, f func() error) error {
+	start := timeNow()
+	var delay time.Duration
+	for n := 1; ; n++ {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if limits.MaxAttempts > 0 && n >= limits.MaxAttempts {
+			return err
+		}
+		delay = strategy.Delay(n, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeAfter(delay):
+		}
+		if limits.MaxElapsed > 0 && timeNow().Sub(start) > limits.MaxElapsed {
+			return err
+		}
+	}
+}
+
+// Policy combines the strategy to wait between the attempts of an
+// operation with the limits bounding them.
+type Policy struct {
+	Strategy Strategy
+	Limits   Limits
+}
+
+// Clock gives the current time and waits, timeutil.TimeSource satisfies
+// it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time {
+	return timeNow()
+}
+
+func (wallClock) After(d time.Duration) <-chan time.Time {
+	return timeAfter(d)
+}
+
+// Attempt iterates over the attempts of an operation retried from a loop
+// that needs more control than Do gives, waiting between them as decided
+// by the strategy of the policy and stopping at its limits:
+//
+//	for a := retryutil.Start(policy, nil); a.Next(); {
+//		...
+//		if retryable(err) && a.More() {
+//			continue
+//		}
+//		break
+//	}
+type Attempt struct {
+	policy Policy
+	clock  Clock
+
+	start time.Time
+	count int
+	delay time.Duration
+	// known is set when more is up to date for the current attempt
+	known bool
+	more  bool
+}
+
+// Start starts iterating over the attempts of an operation with the given
+// policy, using clock to wait between them. The real time is used if
+// clock is nil.
+func Start(policy Policy, clock Clock) *Attempt {
+	if clock == nil {
+		clock = wallClock{}
+	}
+	return &Attempt{
+		policy: policy,
+		clock:  clock,
+		start:  clock.Now(),
+	}
+}
+
+// Next waits as needed before the next attempt and returns true, or
+// returns false right away if the limits do not allow another attempt.
+func (a *Attempt) Next() bool {
+	if !a.More() {
+		return false
+	}
+	if a.count > 0 {
+		<-a.clock.After(a.delay)
+	}
+	a.count++
+	a.known = false
+	return true
+}
+
+// More returns whether the limits allow another attempt after the current
+// one.
+func (a *Attempt) More() bool {
+	if a.known {
+		return a.more
+	}
+	a.known = true
+	a.more = a.count == 0 || a.allowsRetry()
+	return a.more
+}
+
+func (a *Attempt) allowsRetry() bool {
+	limits := a.policy.Limits
+	if limits.MaxAttempts > 0 && a.count >= limits.MaxAttempts {
+		return false
+	}
+	delay := a.policy.Strategy.Delay(a.count, a.delay)
+	if limits.MaxElapsed > 0 && a.clock.Now().Add(delay).Sub(a.start) > limits.MaxElapsed {
+		return false
+	}
+	a.delay = delay
+	return true
+}
+
+// Count returns the number of attempts so far, including the current one.
+func (a *Attempt) Count() int {
+	return a.count
+}
+
+// Backoff spaces out the attempts of an operation retried from a loop
+// that cannot wait, like the ensure loop of a state manager.
+type Backoff struct {
+	Strategy Strategy
+
+	retries int
+	delay   time.Duration
+	last    time.Time
+}
+
+// ShouldWait returns whether the delay since the last attempt has not
+// passed yet at now. Otherwise an attempt is recorded at now and the delay
+// before the next one is computed.
+func (b *Backoff) ShouldWait(now time.Time) bool {
+	if !b.last.IsZero() && b.last.Add(b.delay).After(now) {
+		return true
+	}
+	b.retries++
+	b.delay = b.Strategy.Delay(b.retries, b.delay)
+	b.last = now
+	return false
+}
+
+// Delay returns the current delay between attempts.
+func (b *Backoff) Delay() time.Duration {
+	return b.delay
+}
+
+// Reset forgets about past attempts.
+func (b *Backoff) Reset() {
+	b.retries = 0
+	b.delay = 0
+	b.last = time.Time{}
+}
@@ -0,0 +1,178 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package retryutil_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/retryutil"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type retrySuite struct {
+	testutil.BaseTest
+
+	now    time.Time
+	sleeps []time.Duration
+}
+
+var _ = Suite(&retrySuite{})
+
+func (s *retrySuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	s.now = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.sleeps = nil
+	s.AddCleanup(retryutil.MockTimeNow(func() time.Time { return s.now }))
+	s.AddCleanup(retryutil.MockTimeAfter(func(d time.Duration) <-chan time.Time {
+		s.sleeps = append(s.sleeps, d)
+		s.now = s.now.Add(d)
+		ch := make(chan time.Time, 1)
+		ch <- s.now
+		return ch
+	}))
+}
+
+func (s *retrySuite) TestDoSucceedsEventually(c *C) {
+	calls := 0
+	err := retryutil.Do(context.Background(), retryutil.Exponential{Initial: time.Second}, retryutil.Limits{}, func() error {
+		calls++
+		if calls < 4 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	c.Assert(err, IsNil)
+	c.Check(calls, Equals, 4)
+	c.Check(s.sleeps, DeepEquals, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second})
+}
+
+func (s *retrySuite) TestDoMaxAttempts(c *C) {
+	calls := 0
+	err := retryutil.Do(context.Background(), retryutil.Constant{Interval: time.Second}, retryutil.Limits{MaxAttempts: 3}, func() error {
+		calls++
+		return errors.New("boom")
+	})
+	c.Assert(err, ErrorMatches, "boom")
+	c.Check(calls, Equals, 3)
+	c.Check(s.sleeps, HasLen, 2)
+}
+
+func (s *retrySuite) TestDoMaxElapsed(c *C) {
+	calls := 0
+	err := retryutil.Do(context.Background(), retryutil.Constant{Interval: 4 * time.Second}, retryutil.Limits{MaxElapsed: 10 * time.Second}, func() error {
+		calls++
+		return errors.New("boom")
+	})
+	c.Assert(err, ErrorMatches, "boom")
+	// attempts at 0s, 4s and 8s, none started at 12s
+	c.Check(calls, Equals, 3)
+}
+
+func (s *retrySuite) TestDoPermanent(c *C) {
+	calls := 0
+	boom := errors.New("boom")
+	err := retryutil.Do(context.Background(), retryutil.Constant{Interval: time.Second}, retryutil.Limits{}, func() error {
+		calls++
+		return retryutil.Permanent(boom)
+	})
+	c.Assert(err, Equals, boom)
+	c.Check(calls, Equals, 1)
+	c.Check(s.sleeps, HasLen, 0)
+
+	c.Check(retryutil.Permanent(nil), IsNil)
+}
+
+func (s *retrySuite) TestDoContextDone(c *C) {
+	restore := retryutil.MockTimeAfter(func(d time.Duration) <-chan time.Time {
+		return nil
+	})
+	defer restore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := retryutil.Do(ctx, retryutil.Constant{Interval: time.Hour}, retryutil.Limits{}, func() error {
+		calls++
+		cancel()
+		return errors.New("boom")
+	})
+	c.Assert(err, Equals, context.Canceled)
+	c.Check(calls, Equals, 1)
+}
+
+func (s *retrySuite) TestAttempt(c *C) {
+	policy := retryutil.Policy{
+		Strategy: retryutil.Exponential{Initial: time.Second},
+		Limits:   retryutil.Limits{MaxAttempts: 4},
+	}
+	var counts []int
+	a := retryutil.Start(policy, nil)
+	for a.Next() {
+		counts = append(counts, a.Count())
+	}
+	c.Check(counts, DeepEquals, []int{1, 2, 3, 4})
+	c.Check(s.sleeps, DeepEquals, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second})
+	c.Check(a.More(), Equals, false)
+}
+
+func (s *retrySuite) TestAttemptMaxElapsed(c *C) {
+	policy := retryutil.Policy{
+		Strategy: retryutil.Constant{Interval: 4 * time.Second},
+		Limits:   retryutil.Limits{MaxElapsed: 10 * time.Second},
+	}
+	a := retryutil.Start(policy, nil)
+	c.Assert(a.Next(), Equals, true)
+	c.Check(a.More(), Equals, true)
+	c.Assert(a.Next(), Equals, true)
+	// the attempt takes a while
+	s.now = s.now.Add(3 * time.Second)
+	// the next one would start past the limit, so there is none and
+	// no waiting for it
+	c.Check(a.More(), Equals, false)
+	c.Check(a.Next(), Equals, false)
+	c.Check(a.Count(), Equals, 2)
+	c.Check(s.sleeps, DeepEquals, []time.Duration{4 * time.Second})
+}
+
+func (s *retrySuite) TestBackoff(c *C) {
+	b := retryutil.Backoff{Strategy: retryutil.Exponential{Initial: time.Minute}}
+	t0 := s.now
+
+	c.Check(b.ShouldWait(t0), Equals, false)
+	c.Check(b.Delay(), Equals, time.Minute)
+	c.Check(b.ShouldWait(t0.Add(30*time.Second)), Equals, true)
+
+	// the delay counts from the actual last attempt
+	t1 := t0.Add(90 * time.Second)
+	c.Check(b.ShouldWait(t1), Equals, false)
+	c.Check(b.Delay(), Equals, 2*time.Minute)
+	c.Check(b.ShouldWait(t1.Add(119*time.Second)), Equals, true)
+	c.Check(b.ShouldWait(t1.Add(2*time.Minute)), Equals, false)
+	c.Check(b.Delay(), Equals, 4*time.Minute)
+
+	b.Reset()
+	c.Check(b.Delay(), Equals, time.Duration(0))
+	c.Check(b.ShouldWait(t1), Equals, false)
+	c.Check(b.Delay(), Equals, time.Minute)
+}
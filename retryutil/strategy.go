@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package retryutil provides strategies to space out the retries of an
+// operation and helpers to retry operations following them.
+package retryutil
+
+import (
+	"time"
+
+	"github.com/snapcore/snapd/randutil"
+)
+
+// Strategy decides how long to wait before each retry of an operation.
+type Strategy interface {
+	// Delay returns how long to wait before retry number n, counting
+	// from 1, given the delay before the previous retry, which is 0 for
+	// the first one.
+	Delay(n int, prev time.Duration) time.Duration
+}
+
+// StrategyFunc adapts a function to a Strategy.
+type StrategyFunc func(n int, prev time.Duration) time.Duration
+
+// Delay implements Strategy.
+func (f StrategyFunc) Delay(n int, prev time.Duration) time.Duration {
+	return f(n, prev)
+}
+
+// Constant waits the same interval before every retry.
+type Constant struct {
+	Interval time.Duration
+}
+
+// Delay implements Strategy.
+func (s Constant) Delay(n int, prev time.Duration) time.Duration {
+	return s.Interval
+}
+
+// Exponential multiplies the delay by Factor on every retry, starting
+// from Initial.
+type Exponential struct {
+	Initial time.Duration
+	// Factor is the factor the delay is multiplied by on every retry, 2
+	// if not set.
+	Factor float64
+	// Max is the maximum delay, if set.
+	Max time.Duration
+}
+
+// Delay implements Strategy.
+func (s Exponential) Delay(n int, prev time.Duration) time.Duration {
+	factor := s.Factor
+	if factor <= 0 {
+		factor = 2
+	}
+	delay := s.Initial
+	if n > 1 && prev > 0 {
+		delay = time.Duration(float64(prev) * factor)
+	}
+	if s.Max > 0 && delay > s.Max {
+		delay = s.Max
+	}
+	return delay
+}
+
+// DecorrelatedJitter picks a random delay between Base and three times the
+// previous delay, which spreads out the retries of many clients failing at
+// the same time better than an exponential backoff. See "Decorrelated
+// Jitter" in
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+type DecorrelatedJitter struct {
+	Base time.Duration
+	// Max is the maximum delay, if set.
+	Max time.Duration
+}
+
+// Delay implements Strategy.
+func (s DecorrelatedJitter) Delay(n int, prev time.Duration) time.Duration {
+	if prev < s.Base {
+		prev = s.Base
+	}
+	delay := s.Base
+	if span := 3*prev - s.Base; span > 0 {
+		delay += randutil.RandomDuration(span)
+	}
+	if s.Max > 0 && delay > s.Max {
+		delay = s.Max
+	}
+	return delay
+}
@@ -0,0 +1,90 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package retryutil_test
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/retryutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type strategySuite struct{}
+
+var _ = Suite(&strategySuite{})
+
+func delays(s retryutil.Strategy, n int) []time.Duration {
+	var ds []time.Duration
+	var prev time.Duration
+	for i := 1; i <= n; i++ {
+		prev = s.Delay(i, prev)
+		ds = append(ds, prev)
+	}
+	return ds
+}
+
+func (s *strategySuite) TestConstant(c *C) {
+	c.Check(delays(retryutil.Constant{Interval: time.Second}, 3), DeepEquals, []time.Duration{
+		time.Second, time.Second, time.Second,
+	})
+}
+
+func (s *strategySuite) TestExponential(c *C) {
+	c.Check(delays(retryutil.Exponential{Initial: time.Second}, 4), DeepEquals, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+	})
+	c.Check(delays(retryutil.Exponential{Initial: time.Second, Factor: 3, Max: 10 * time.Second}, 4), DeepEquals, []time.Duration{
+		time.Second, 3 * time.Second, 9 * time.Second, 10 * time.Second,
+	})
+}
+
+func (s *strategySuite) TestDecorrelatedJitter(c *C) {
+	strategy := retryutil.DecorrelatedJitter{Base: time.Second, Max: time.Minute}
+	var prev time.Duration
+	for i := 1; i <= 100; i++ {
+		delay := strategy.Delay(i, prev)
+		c.Assert(delay >= time.Second, Equals, true, Commentf("delay %v", delay))
+		upper := 3 * prev
+		if upper < time.Second {
+			upper = 3 * time.Second
+		}
+		if upper > time.Minute {
+			upper = time.Minute
+		}
+		c.Assert(delay <= upper, Equals, true, Commentf("delay %v after %v", delay, prev))
+		prev = delay
+	}
+
+	// no base does not panic
+	c.Check(retryutil.DecorrelatedJitter{}.Delay(1, 0), Equals, time.Duration(0))
+}
+
+func (s *strategySuite) TestStrategyFunc(c *C) {
+	strategy := retryutil.StrategyFunc(func(n int, prev time.Duration) time.Duration {
+		return time.Duration(n) * time.Second
+	})
+	c.Check(delays(strategy, 3), DeepEquals, []time.Duration{
+		time.Second, 2 * time.Second, 3 * time.Second,
+	})
+}
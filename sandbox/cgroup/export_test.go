@@ -19,6 +19,7 @@
 package cgroup
 
 import (
+	"syscall"
 	"time"
 
 	"github.com/godbus/dbus"
@@ -86,7 +87,9 @@ func MockCgroupProcessPathInTrackingCgroup(fn func(pid int) (string, error)) fun
 	}
 }
 
-func MockDoCreateTransientScope(fn func(conn *dbus.Conn, unitName string, pid int) error) func() {
+type ScopeProperty = scopeProperty
+
+func MockDoCreateTransientScope(fn func(conn *dbus.Conn, unitName string, pid int, extraProps []ScopeProperty) error) func() {
 	old := doCreateTransientScope
 	doCreateTransientScope = fn
 	return func() {
@@ -113,3 +116,11 @@ func MockCgroupsFilePath(path string) (restore func()) {
 func MonitorDelete(folders []string, name string, channel chan string) error {
 	return currentWatcher.monitorDelete(folders, name, channel)
 }
+
+func MockSyscallKill(f func(pid int, sig syscall.Signal) error) (restore func()) {
+	old := syscallKill
+	syscallKill = f
+	return func() {
+		syscallKill = old
+	}
+}
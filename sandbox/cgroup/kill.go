@@ -0,0 +1,45 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cgroup
+
+import (
+	"fmt"
+	"syscall"
+)
+
+var syscallKill = syscall.Kill
+
+// KillSnapProcesses sends SIGKILL to all the processes found in the
+// cgroups of the apps, services and hooks of the given snap instance.
+// Processes that exited meanwhile are ignored.
+func KillSnapProcesses(snapInstanceName string) error {
+	pidsByTag, err := PidsOfSnap(snapInstanceName)
+	if err != nil {
+		return err
+	}
+	for tag, pids := range pidsByTag {
+		for _, pid := range pids {
+			if err := syscallKill(pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+				return fmt.Errorf("cannot kill process %d of %s: %v", pid, tag, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package cgroup_test
+
+import (
+	"sort"
+	"syscall"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/sandbox/cgroup"
+)
+
+func (s *scanningSuite) TestKillSnapProcesses(c *C) {
+	restore := cgroup.MockVersion(cgroup.V2, nil)
+	defer restore()
+
+	s.writePids(c, "user.slice/user-1000.slice/user@1000.service/snap.pkg.app-1234.scope", []int{1, 2})
+	s.writePids(c, "system.slice/snap.pkg.daemon.service", []int{3})
+	s.writePids(c, "system.slice/snap.other.daemon.service", []int{4})
+
+	var killed []int
+	restore = cgroup.MockSyscallKill(func(pid int, sig syscall.Signal) error {
+		c.Check(sig, Equals, syscall.SIGKILL)
+		killed = append(killed, pid)
+		if pid == 2 {
+			// exited meanwhile
+			return syscall.ESRCH
+		}
+		return nil
+	})
+	defer restore()
+
+	err := cgroup.KillSnapProcesses("pkg")
+	c.Assert(err, IsNil)
+	sort.Ints(killed)
+	c.Check(killed, DeepEquals, []int{1, 2, 3})
+}
+
+func (s *scanningSuite) TestKillSnapProcessesNothingRunning(c *C) {
+	restore := cgroup.MockVersion(cgroup.V2, nil)
+	defer restore()
+
+	restore = cgroup.MockSyscallKill(func(pid int, sig syscall.Signal) error {
+		c.Fatalf("unexpected kill of %d", pid)
+		return nil
+	})
+	defer restore()
+
+	c.Check(cgroup.KillSnapProcesses("pkg"), IsNil)
+}
+
+func (s *scanningSuite) TestKillSnapProcessesError(c *C) {
+	restore := cgroup.MockVersion(cgroup.V2, nil)
+	defer restore()
+
+	s.writePids(c, "system.slice/snap.pkg.daemon.service", []int{3})
+
+	restore = cgroup.MockSyscallKill(func(pid int, sig syscall.Signal) error {
+		return syscall.EPERM
+	})
+	defer restore()
+
+	err := cgroup.KillSnapProcesses("pkg")
+	c.Check(err, ErrorMatches, `cannot kill process 3 of snap.pkg.daemon: operation not permitted`)
+}
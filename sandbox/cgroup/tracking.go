@@ -26,6 +26,25 @@ type TrackingOptions struct {
 	// AllowSessionBus controls if CreateTransientScopeForTracking will
 	// consider using the session bus for making the request.
 	AllowSessionBus bool
+	// MemoryMax, when non-zero, is the amount of memory in bytes the
+	// processes of the scope can use before getting killed.
+	MemoryMax uint64
+	// CPUQuota, when non-zero, is the CPU time the processes of the scope
+	// can use per second of wall clock time.
+	CPUQuota time.Duration
+}
+
+// scopeProperties returns the properties of the transient scope expressing
+// the resource limits of the options.
+func (opts *TrackingOptions) scopeProperties() []scopeProperty {
+	var props []scopeProperty
+	if opts.MemoryMax != 0 {
+		props = append(props, scopeProperty{"MemoryMax", opts.MemoryMax})
+	}
+	if opts.CPUQuota != 0 {
+		props = append(props, scopeProperty{"CPUQuotaPerSecUSec", uint64(opts.CPUQuota / time.Microsecond)})
+	}
+	return props
 }
 
 // CreateTransientScopeForTracking puts the current process in a transient scope.
@@ -92,7 +111,7 @@ func CreateTransientScopeForTracking(securityTag string, opts *TrackingOptions)
 	start := time.Now()
 tryAgain:
 	// Create a transient scope by talking to systemd over DBus.
-	if err := doCreateTransientScope(conn, unitName, pid); err != nil {
+	if err := doCreateTransientScope(conn, unitName, pid, opts.scopeProperties()); err != nil {
 		switch err {
 		case errDBusUnknownMethod:
 			return ErrCannotTrackProcess
@@ -217,13 +236,20 @@ var (
 	createScopeJobTimeout = 10 * time.Second
 )
 
+// scopeProperty is a property of a transient scope, as passed to systemd.
+type scopeProperty struct {
+	Name  string
+	Value interface{}
+}
+
 // startTransientScope requests systemd to create a transient unit and returns
 // the associated systemd job path.
 //
 // The scope is created by asking systemd via the specified DBus connection.
-// The unit name and the PID to attach are provided as well. The DBus method
-// call is performed outside confinement established by snap-confine.
-func startTransientScope(conn *dbus.Conn, unitName string, pid int) (job dbus.ObjectPath, err error) {
+// The unit name, the PID to attach and any extra properties of the unit are
+// provided as well. The DBus method call is performed outside confinement
+// established by snap-confine.
+func startTransientScope(conn *dbus.Conn, unitName string, pid int, extraProps []scopeProperty) (job dbus.ObjectPath, err error) {
 	// Documentation of StartTransientUnit is available at
 	// https://www.freedesktop.org/wiki/Software/systemd/dbus/
 	//
@@ -249,13 +275,9 @@ func startTransientScope(conn *dbus.Conn, unitName string, pid int) (job dbus.Ob
 	//   	Value interface{}
 	//	 }
 	// } // auxUnits describe any additional units to define.
-	type property struct {
-		Name  string
-		Value interface{}
-	}
 	type auxUnit struct {
 		Name  string
-		Props []property
+		Props []scopeProperty
 	}
 
 	// The mode string decides how the job is interacting with other systemd
@@ -278,7 +300,7 @@ func startTransientScope(conn *dbus.Conn, unitName string, pid int) (job dbus.Ob
 	//
 	// Here we choose "fail" to match systemd-run.
 	mode := "fail"
-	properties := []property{{"PIDs", []uint{uint(pid)}}}
+	properties := append([]scopeProperty{{"PIDs", []uint{uint(pid)}}}, extraProps...)
 	aux := []auxUnit(nil)
 	systemd := conn.Object("org.freedesktop.systemd1", "/org/freedesktop/systemd1")
 	call := systemd.Call(
@@ -323,15 +345,15 @@ func startTransientScope(conn *dbus.Conn, unitName string, pid int) (job dbus.Ob
 // doCreateTransientScopeOpportunisticSync creates a transient scope with a
 // given unit name asking systemd to move the provided pid to that scope, does
 // not wait for the systemd job to complete
-func doCreateTransientScopeNoSync(conn *dbus.Conn, unitName string, pid int) error {
-	_, err := startTransientScope(conn, unitName, pid)
+func doCreateTransientScopeNoSync(conn *dbus.Conn, unitName string, pid int, extraProps []scopeProperty) error {
+	_, err := startTransientScope(conn, unitName, pid, extraProps)
 	return err
 }
 
 // doCreateTransientScopeOpportunisticSync creates a transient scope with a
 // given unit name asking systemd to move the provided pid to that scope, and
 // waits for the systemd job to finish
-func doCreateTransientScopeJobRemovedSync(conn *dbus.Conn, unitName string, pid int) error {
+func doCreateTransientScopeJobRemovedSync(conn *dbus.Conn, unitName string, pid int, extraProps []scopeProperty) error {
 	// set up a watch for JobRemoved signals, so that we'll know when our
 	// request has completed
 	jobRemoveMatch := []dbus.MatchOption{
@@ -409,7 +431,7 @@ func doCreateTransientScopeJobRemovedSync(conn *dbus.Conn, unitName string, pid
 			}
 		}
 	}()
-	job, err := startTransientScope(conn, unitName, pid)
+	job, err := startTransientScope(conn, unitName, pid, extraProps)
 	if err != nil {
 		return err
 	}
@@ -430,9 +452,10 @@ func doCreateTransientScopeJobRemovedSync(conn *dbus.Conn, unitName string, pid
 // doCreateTransientScope creates a systemd transient scope with specified properties.
 //
 // The scope is created by asking systemd via the specified DBus connection.
-// The unit name and the PID to attach are provided as well. The DBus method
-// call is performed outside confinement established by snap-confine.
-var doCreateTransientScope = func(conn *dbus.Conn, unitName string, pid int) error {
+// The unit name, the PID to attach and any extra properties of the unit are
+// provided as well. The DBus method call is performed outside confinement
+// established by snap-confine.
+var doCreateTransientScope = func(conn *dbus.Conn, unitName string, pid int, extraProps []scopeProperty) error {
 	// in theory we could use a single implementation that sync with job
 	// removed signal and inspects the result, however some older
 	// distributions sport an unpatched and broken version of systemd, which
@@ -446,9 +469,9 @@ var doCreateTransientScope = func(conn *dbus.Conn, unitName string, pid int) err
 		// when using cgroup v2, we absolutely must be sure that the
 		// tracking group has been created, otherwise we risk
 		// establishing a device cgroup filtering in the wrong group
-		return doCreateTransientScopeJobRemovedSync(conn, unitName, pid)
+		return doCreateTransientScopeJobRemovedSync(conn, unitName, pid, extraProps)
 	}
-	return doCreateTransientScopeNoSync(conn, unitName, pid)
+	return doCreateTransientScopeNoSync(conn, unitName, pid, extraProps)
 }
 
 // The source of the bytes generated here is the same as that of
@@ -165,7 +165,7 @@ func (s *trackingSuite) TestCreateTransientScopeForTrackingUnhappyNotRootGeneric
 	defer restore()
 
 	// Pretend that attempting to create a transient scope fails with a canned error.
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		return fmt.Errorf("cannot create transient scope for testing")
 	})
 	defer restore()
@@ -176,7 +176,7 @@ func (s *trackingSuite) TestCreateTransientScopeForTrackingUnhappyNotRootGeneric
 
 	// Calling StartTransientUnit fails with org.freedesktop.DBus.UnknownMethod error.
 	// This is possible on old systemd or on deputy systemd.
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		return cgroup.ErrDBusUnknownMethod
 	})
 	defer restore()
@@ -189,7 +189,7 @@ func (s *trackingSuite) TestCreateTransientScopeForTrackingUnhappyNotRootGeneric
 	// Calling StartTransientUnit fails with org.freedesktop.DBus.Spawn.ChildExited error.
 	// This is possible where we try to activate socket activate session bus
 	// but it's not available OR when we try to socket activate systemd --user.
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		return cgroup.ErrDBusSpawnChildExited
 	})
 	defer restore()
@@ -227,7 +227,7 @@ func (s *trackingSuite) TestCreateTransientScopeForTrackingUnhappyRootFallback(c
 	// Calling StartTransientUnit fails on the session and then works on the system bus.
 	// This test emulates a root user falling back from the session bus to the system bus.
 	n := 0
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		n++
 		switch n {
 		case 1:
@@ -281,7 +281,7 @@ func (s *trackingSuite) TestCreateTransientScopeForTrackingUnhappyRootFailedFall
 	defer restore()
 
 	// Calling StartTransientUnit fails so that we try to use the system bus as fallback.
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		return cgroup.ErrDBusSpawnChildExited
 	})
 	defer restore()
@@ -324,7 +324,7 @@ func (s *trackingSuite) TestCreateTransientScopeForTrackingUnhappyNoDBus(c *C) {
 	defer restore()
 
 	// Calling StartTransientUnit is not attempted without a DBus connection.
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		c.Error("test sequence violated")
 		return fmt.Errorf("test was not expected to create a transient scope")
 	})
@@ -371,7 +371,7 @@ func (s *trackingSuite) TestCreateTransientScopeForTrackingSilentlyFails(c *C) {
 	// version is < 238 and when the calling user is in a hierarchy that is
 	// owned by another user. One example is a user logging in remotely over
 	// ssh.
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		return nil
 	})
 	defer restore()
@@ -420,7 +420,7 @@ func (s *trackingSuite) TestCreateTransientScopeForRootOnSystemBus(c *C) {
 	// Pretend that attempting to create a transient scope succeeds.  Measure
 	// the bus used and the unit name provided by the caller.  Note that the
 	// call was made on the system bus, as requested by TrackingOptions below.
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		c.Assert(conn, Equals, systemBus)
 		c.Assert(unitName, Equals, "snap.pkg.app-"+uuid+".scope")
 		return nil
@@ -438,6 +438,46 @@ func (s *trackingSuite) TestCreateTransientScopeForRootOnSystemBus(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *trackingSuite) TestCreateTransientScopeResourceLimits(c *C) {
+	enableFeatures(c, features.RefreshAppAwareness)
+
+	systemBus, err := dbustest.StubConnection()
+	c.Assert(err, IsNil)
+	restore := dbusutil.MockConnections(func() (*dbus.Conn, error) { return systemBus, nil }, dbustest.StubConnection)
+	defer restore()
+
+	restore = cgroup.MockOsGetuid(0)
+	defer restore()
+	restore = cgroup.MockOsGetpid(312123)
+	defer restore()
+	uuid := "cc98cd01-6a25-46bd-b71b-82069b71b770"
+	restore = cgroup.MockRandomUUID(func() (string, error) {
+		return uuid, nil
+	})
+	defer restore()
+
+	// The limits are passed as properties of the scope.
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
+		c.Check(extraProps, DeepEquals, []cgroup.ScopeProperty{
+			{Name: "MemoryMax", Value: uint64(1 << 30)},
+			{Name: "CPUQuotaPerSecUSec", Value: uint64(500000)},
+		})
+		return nil
+	})
+	defer restore()
+
+	restore = cgroup.MockCgroupProcessPathInTrackingCgroup(func(pid int) (string, error) {
+		return "snap.pkg.hook.configure-" + uuid + ".scope", nil
+	})
+	defer restore()
+
+	err = cgroup.CreateTransientScopeForTracking("snap.pkg.hook.configure", &cgroup.TrackingOptions{
+		MemoryMax: 1 << 30,
+		CPUQuota:  500 * time.Millisecond,
+	})
+	c.Assert(err, IsNil)
+}
+
 type testTransientScopeConfirm struct {
 	uuid        string
 	confirmUnit string
@@ -468,7 +508,7 @@ func (s *trackingSuite) testCreateTransientScopeConfirm(c *C, tc testTransientSc
 	c.Assert(err, IsNil)
 	restore = dbusutil.MockOnlySessionBusAvailable(sessionBus)
 	defer restore()
-	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int, extraProps []cgroup.ScopeProperty) error {
 		c.Assert(conn, Equals, sessionBus)
 		c.Assert(unitName, Equals, "snap.pkg.app-"+tc.uuid+".scope")
 		return nil
@@ -805,7 +845,7 @@ func (s *trackingSuite) TestDoCreateTransientScopeHappyCgroupV2(c *C) {
 
 	c.Assert(err, IsNil)
 	defer conn.Close()
-	err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123)
+	err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123, nil)
 	c.Assert(err, IsNil)
 }
 
@@ -823,7 +863,7 @@ func (s *trackingSuite) TestDoCreateTransientScopeHappyCgroupV1(c *C) {
 	})
 	c.Assert(err, IsNil)
 	defer conn.Close()
-	err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123)
+	err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123, nil)
 	c.Assert(err, IsNil)
 }
 
@@ -850,7 +890,7 @@ func (s *trackingSuite) TestDoCreateTransientScopeForwardedErrors(c *C) {
 		})
 		c.Assert(err, IsNil)
 		defer conn.Close()
-		err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123)
+		err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123, nil)
 		c.Assert(strings.HasSuffix(err.Error(), fmt.Sprintf(" [%s]", t.dbusError)), Equals, true, Commentf("%q ~ %s", err, t.dbusError))
 		c.Check(err, ErrorMatches, t.msg+" .*")
 	}
@@ -873,7 +913,7 @@ func (s *trackingSuite) TestDoCreateTransientScopeClashingScopeName(c *C) {
 	})
 	c.Assert(err, IsNil)
 	defer conn.Close()
-	err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123)
+	err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123, nil)
 	c.Assert(err, ErrorMatches, "cannot create transient scope: scope .* clashed: .*")
 }
 
@@ -893,7 +933,7 @@ func (s *trackingSuite) TestDoCreateTransientScopeOtherDBusErrors(c *C) {
 	})
 	c.Assert(err, IsNil)
 	defer conn.Close()
-	err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123)
+	err = cgroup.DoCreateTransientScope(conn, "foo.scope", 312123, nil)
 	c.Assert(err, ErrorMatches, `cannot create transient scope: DBus error "org.example.BadHairDay": \[\]`)
 }
 
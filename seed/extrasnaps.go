@@ -0,0 +1,105 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/snap"
+)
+
+// ExtraSnapsDir is the name of the drop-in directory at the top of a
+// seed where integrators can place additional snaps after the image
+// was built. Each <name>.snap file must be accompanied by a
+// <name>.assert file carrying the assertions needed to verify it.
+const ExtraSnapsDir = "extra-snaps"
+
+// ExtraSnap holds the outcome of loading a snap from the extra snaps
+// drop-in directory of a seed.
+type ExtraSnap struct {
+	Path string
+
+	// SideInfo is set if the snap could be verified.
+	SideInfo *snap.SideInfo
+	// Err is set if the snap could not be verified.
+	Err error
+}
+
+// LoadExtraSnaps loads the snaps found in the extra snaps drop-in
+// directory of the seed at seedDir. The assertions accompanying each
+// snap are committed with commitTo and the snap is then verified
+// against them using db, cross checking with model. A snap that cannot
+// be verified does not prevent loading the others, instead the error
+// is reported in its ExtraSnap. Nothing is returned if there is no
+// drop-in directory.
+func LoadExtraSnaps(seedDir string, model *asserts.Model, db asserts.RODatabase, commitTo func(*asserts.Batch) error) ([]*ExtraSnap, error) {
+	snapPaths, err := filepath.Glob(filepath.Join(seedDir, ExtraSnapsDir, "*.snap"))
+	if err != nil {
+		return nil, err
+	}
+
+	extraSnaps := make([]*ExtraSnap, 0, len(snapPaths))
+	for _, snapPath := range snapPaths {
+		si, err := loadExtraSnap(snapPath, model, db, commitTo)
+		extraSnaps = append(extraSnaps, &ExtraSnap{
+			Path:     snapPath,
+			SideInfo: si,
+			Err:      err,
+		})
+	}
+	return extraSnaps, nil
+}
+
+func loadExtraSnap(snapPath string, model *asserts.Model, db asserts.RODatabase, commitTo func(*asserts.Batch) error) (*snap.SideInfo, error) {
+	assertPath := strings.TrimSuffix(snapPath, ".snap") + ".assert"
+	batch := asserts.NewBatch(nil)
+	if _, err := readAsserts(batch, assertPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("cannot find assertions file %s", filepath.Base(assertPath))
+		}
+		return nil, fmt.Errorf("cannot read assertions: %v", err)
+	}
+	if err := commitTo(batch); err != nil {
+		return nil, err
+	}
+
+	si, err := snapasserts.DeriveSideInfo(snapPath, model, db)
+	if errors.Is(err, &asserts.NotFoundError{}) {
+		return nil, fmt.Errorf("cannot find signatures with metadata for snap %s", filepath.Base(snapPath))
+	}
+	if err != nil {
+		return nil, err
+	}
+	// make sure the snap file agrees with its assertions
+	info, err := readInfo(snapPath, si)
+	if err != nil {
+		return nil, err
+	}
+	if info.SnapName() != si.RealName {
+		return nil, fmt.Errorf("cannot use snap %s: name %q does not match the asserted name %q", filepath.Base(snapPath), info.SnapName(), si.RealName)
+	}
+	return si, nil
+}
@@ -0,0 +1,139 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seed_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/seed"
+	"github.com/snapcore/snapd/seed/seedtest"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type extraSnapsSuite struct {
+	testutil.BaseTest
+
+	seedtest.SeedSnaps
+
+	seedDir string
+	model   *asserts.Model
+	db      *asserts.Database
+}
+
+var _ = Suite(&extraSnapsSuite{})
+
+func (s *extraSnapsSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.AddCleanup(snap.MockSanitizePlugsSlots(func(snapInfo *snap.Info) {}))
+
+	s.SetupAssertSigning("canonical")
+	s.Brands.Register("my-brand", brandPrivKey, map[string]interface{}{
+		"verification": "verified",
+	})
+	s.model = s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+	})
+
+	devAcct := assertstest.NewAccount(s.StoreSigning, "developer", map[string]interface{}{
+		"account-id": "developerid",
+	}, "")
+	assertstest.AddMany(s.StoreSigning, devAcct)
+
+	s.seedDir = c.MkDir()
+
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   s.StoreSigning.Trusted,
+	})
+	c.Assert(err, IsNil)
+	s.db = db
+}
+
+func (s *extraSnapsSuite) commitTo(b *asserts.Batch) error {
+	return b.CommitTo(s.db, nil)
+}
+
+func (s *extraSnapsSuite) makeExtraSnap(c *C, snapYaml string, withAsserts bool) string {
+	decl, rev := s.MakeAssertedSnap(c, snapYaml, nil, snap.R(3), "developerid")
+	name := decl.SnapName()
+
+	extraDir := filepath.Join(s.seedDir, seed.ExtraSnapsDir)
+	c.Assert(os.MkdirAll(extraDir, 0755), IsNil)
+	snapPath := filepath.Join(extraDir, name+".snap")
+	c.Assert(osutil.CopyFile(s.AssertedSnap(name), snapPath, osutil.CopyFlagOverwrite), IsNil)
+	if withAsserts {
+		devAcct, err := s.StoreSigning.Find(asserts.AccountType, map[string]string{
+			"account-id": "developerid",
+		})
+		c.Assert(err, IsNil)
+		seedtest.WriteAssertions(filepath.Join(extraDir, name+".assert"), s.StoreSigning.StoreAccountKey(""), devAcct, decl, rev)
+	}
+	return snapPath
+}
+
+func (s *extraSnapsSuite) TestLoadExtraSnapsNoDir(c *C) {
+	extraSnaps, err := seed.LoadExtraSnaps(s.seedDir, s.model, s.db, s.commitTo)
+	c.Assert(err, IsNil)
+	c.Check(extraSnaps, HasLen, 0)
+}
+
+func (s *extraSnapsSuite) TestLoadExtraSnaps(c *C) {
+	fooPath := s.makeExtraSnap(c, "name: foo\nversion: 1.0", true)
+	barPath := s.makeExtraSnap(c, "name: bar\nversion: 2.0", false)
+
+	extraSnaps, err := seed.LoadExtraSnaps(s.seedDir, s.model, s.db, s.commitTo)
+	c.Assert(err, IsNil)
+	c.Assert(extraSnaps, HasLen, 2)
+
+	// sorted by file name
+	c.Check(extraSnaps[0].Path, Equals, barPath)
+	c.Check(extraSnaps[0].SideInfo, IsNil)
+	c.Check(extraSnaps[0].Err, ErrorMatches, "cannot find assertions file bar.assert")
+
+	c.Check(extraSnaps[1].Path, Equals, fooPath)
+	c.Check(extraSnaps[1].Err, IsNil)
+	c.Check(extraSnaps[1].SideInfo, DeepEquals, &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   s.AssertedSnapID("foo"),
+		Revision: snap.R(3),
+	})
+}
+
+func (s *extraSnapsSuite) TestLoadExtraSnapsMismatchedAssertions(c *C) {
+	fooPath := s.makeExtraSnap(c, "name: foo\nversion: 1.0", true)
+	// replace the snap with a different one, the assertions no longer match
+	other := s.makeExtraSnap(c, "name: foo\nversion: 1.1", false)
+	c.Assert(other, Equals, fooPath)
+
+	extraSnaps, err := seed.LoadExtraSnaps(s.seedDir, s.model, s.db, s.commitTo)
+	c.Assert(err, IsNil)
+	c.Assert(extraSnaps, HasLen, 1)
+	c.Check(extraSnaps[0].SideInfo, IsNil)
+	c.Check(extraSnaps[0].Err, ErrorMatches, "cannot find signatures with metadata for snap foo.snap")
+}
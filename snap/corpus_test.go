@@ -0,0 +1,47 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snap_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type corpusSuite struct{}
+
+var _ = Suite(&corpusSuite{})
+
+// TestSnapYamlCorpus validates the snap.yaml samples in testdata/corpus,
+// a failing sample can be contributed there as a regression test along
+// with the expected error in a .error file.
+func (s *corpusSuite) TestSnapYamlCorpus(c *C) {
+	defer snap.MockSanitizePlugsSlots(func(snapInfo *snap.Info) {})()
+
+	fixtures := testutil.LoadCorpus(c, "testdata/corpus", "*.yaml")
+	testutil.CheckCorpus(c, fixtures, func(content []byte) error {
+		info, err := snap.InfoFromSnapYaml(content)
+		if err != nil {
+			return err
+		}
+		return snap.Validate(info)
+	})
+}
@@ -1110,6 +1110,10 @@ type HookInfo struct {
 	Environment  strutil.OrderedMap
 	CommandChain []string
 
+	// Timeout is how long the hook is allowed to run for before it is
+	// killed, if set. snapd caps it.
+	Timeout timeout.Timeout
+
 	Explicit bool
 }
 
@@ -1234,6 +1238,12 @@ func (app *AppInfo) ServiceFile() string {
 	return filepath.Join(app.serviceDir(), app.ServiceName())
 }
 
+// ServiceOverridesFile returns the systemd drop-in file holding the
+// overrides of the service unit settings configured for the app.
+func (app *AppInfo) ServiceOverridesFile() string {
+	return filepath.Join(app.serviceDir(), app.ServiceName()+".d", "50-snap-overrides.conf")
+}
+
 // IsService returns whether app represents a daemon/service.
 func (app *AppInfo) IsService() bool {
 	return app.Daemon != ""
@@ -1939,6 +1939,20 @@ hooks:
 	c.Check(hook.CommandChain, DeepEquals, []string{"hookchain1", "hookchain2"})
 }
 
+func (s *YamlSuite) TestSnapYamlHookTimeout(c *C) {
+	y := []byte(`name: wat
+version: 42
+hooks:
+ configure:
+  timeout: 30s
+ install:
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	c.Check(info.Hooks["configure"].Timeout, Equals, timeout.Timeout(30*time.Second))
+	c.Check(info.Hooks["install"].Timeout, Equals, timeout.Timeout(0))
+}
+
 func (s *YamlSuite) TestSnapYamlRestartDelay(c *C) {
 	yAutostart := []byte(`name: wat
 version: 42
@@ -1391,6 +1391,7 @@ apps:
 	c.Check(svc.DaemonScope, Equals, snap.SystemDaemon)
 	c.Check(svc.ServiceName(), Equals, "snap.pans.svc1.service")
 	c.Check(svc.ServiceFile(), Equals, dirs.GlobalRootDir+"/etc/systemd/system/snap.pans.svc1.service")
+	c.Check(svc.ServiceOverridesFile(), Equals, dirs.GlobalRootDir+"/etc/systemd/system/snap.pans.svc1.service.d/50-snap-overrides.conf")
 
 	c.Check(info.Apps["svc2"].IsService(), Equals, true)
 	userSvc := info.Apps["svc3"]
@@ -1398,6 +1399,7 @@ apps:
 	c.Check(userSvc.DaemonScope, Equals, snap.UserDaemon)
 	c.Check(userSvc.ServiceName(), Equals, "snap.pans.svc3.service")
 	c.Check(userSvc.ServiceFile(), Equals, dirs.GlobalRootDir+"/etc/systemd/user/snap.pans.svc3.service")
+	c.Check(userSvc.ServiceOverridesFile(), Equals, dirs.GlobalRootDir+"/etc/systemd/user/snap.pans.svc3.service.d/50-snap-overrides.conf")
 	c.Check(info.Apps["app1"].IsService(), Equals, false)
 	c.Check(info.Apps["app1"].IsService(), Equals, false)
 
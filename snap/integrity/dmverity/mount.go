@@ -0,0 +1,48 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package dmverity
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// MountSupported returns whether the running kernel supports dm-verity, in
+// which case snaps can be mounted with verity protection.
+var MountSupported = func() bool {
+	// the module directory exists when dm-verity is either built-in or
+	// loaded
+	return osutil.IsDirectory(filepath.Join(dirs.GlobalRootDir, "/sys/module/dm_verity"))
+}
+
+// MountOptions returns the mount options setting up dm-verity protection of
+// a filesystem image, with the hash tree stored in hashDevice at the given
+// offset. The options are interpreted by libmount when setting up the loop
+// device.
+func MountOptions(info *Info, hashDevice string, hashOffset uint64) []string {
+	return []string{
+		fmt.Sprintf("verity.hashdevice=%s", hashDevice),
+		fmt.Sprintf("verity.roothash=%s", info.RootHash),
+		fmt.Sprintf("verity.hashoffset=%d", hashOffset),
+	}
+}
@@ -21,11 +21,14 @@ package dmverity_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/snap/integrity/dmverity"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
@@ -205,3 +208,22 @@ func (s *VerityTestSuite) TestVerityVersionDetect(c *C) {
 		c.Check(deploy, Equals, t.deploy, Commentf("test failed for version: %s", t.ver))
 	}
 }
+
+func (s *VerityTestSuite) TestMountOptions(c *C) {
+	info := &dmverity.Info{RootHash: "1234"}
+	c.Check(dmverity.MountOptions(info, "/var/lib/snapd/snaps/foo_1.snap", 8192), DeepEquals, []string{
+		"verity.hashdevice=/var/lib/snapd/snaps/foo_1.snap",
+		"verity.roothash=1234",
+		"verity.hashoffset=8192",
+	})
+}
+
+func (s *VerityTestSuite) TestMountSupported(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+
+	c.Check(dmverity.MountSupported(), Equals, false)
+
+	c.Assert(os.MkdirAll(filepath.Join(dirs.GlobalRootDir, "/sys/module/dm_verity"), 0755), IsNil)
+	c.Check(dmverity.MountSupported(), Equals, true)
+}
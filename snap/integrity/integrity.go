@@ -21,11 +21,17 @@ package integrity
 
 import (
 	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 
+	_ "golang.org/x/crypto/sha3"
+
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/snap/integrity/dmverity"
 )
@@ -41,8 +47,16 @@ const (
 var (
 	// magic is the magic prefix of snap extension blocks.
 	magic = []byte{'s', 'n', 'a', 'p', 'e', 'x', 't'}
+
+	// ErrNoIntegrityDataFound is returned when a snap has integrity data
+	// neither appended to it nor stored alongside it.
+	ErrNoIntegrityDataFound = errors.New("no integrity data found")
 )
 
+// AlongsideSuffix is the suffix of the file storing the integrity data
+// of a snap alongside the snap itself.
+const AlongsideSuffix = ".verity"
+
 // align aligns input `size` to closest `blockSize` value
 func align(size uint64) uint64 {
 	return (size + blockSize - 1) / blockSize * blockSize
@@ -108,56 +122,250 @@ func (integrityDataHeader *IntegrityDataHeader) Decode(input []byte) error {
 	return nil
 }
 
+// generateHashTree runs veritysetup on the snap and returns the encoded
+// integrity data header together with the file holding the hash tree. The
+// caller is responsible for removing the file.
+func generateHashTree(snapPath, hashFileName string) (header []byte, hashFile *os.File, err error) {
+	dmVerityBlock, err := dmverity.Format(snapPath, hashFileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashFile, err = os.Open(hashFileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	fi, err := hashFile.Stat()
+	if err != nil {
+		hashFile.Close()
+		return nil, nil, err
+	}
+
+	header, err = newIntegrityDataHeader(dmVerityBlock, uint64(fi.Size())).Encode()
+	if err != nil {
+		hashFile.Close()
+		return nil, nil, err
+	}
+	return header, hashFile, nil
+}
+
 // GenerateAndAppend generates integrity data for a snap file and appends them
 // to it.
 // Integrity data are formed from a fixed-size header aligned to blockSize which
 // includes the root hash followed by the generated dm-verity hash data.
 func GenerateAndAppend(snapPath string) (err error) {
 	// Generate verity metadata
-	hashFileName := snapPath + ".verity"
-	dmVerityBlock, err := dmverity.Format(snapPath, hashFileName)
+	// not AlongsideSuffix, which is the name of integrity data
+	// stored next to the snap
+	hashFileName := snapPath + ".verity-append.tmp"
+	header, hashFile, err := generateHashTree(snapPath, hashFileName)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		hashFile.Close()
+		if e := os.Remove(hashFileName); e != nil {
+			err = e
+		}
+	}()
 
-	hashFile, err := os.OpenFile(hashFileName, os.O_RDONLY, 0644)
+	snapFile, err := os.OpenFile(snapPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer snapFile.Close()
+
+	// Append header to snap
+	if _, err = snapFile.Write(header); err != nil {
+		return err
+	}
+
+	// Append verity metadata to snap
+	if _, err := io.Copy(snapFile, hashFile); err != nil {
+		return err
+	}
+
+	return err
+}
+
+// GenerateAlongside generates integrity data for a snap file that carries
+// none and stores them in a file next to it, named after the snap with the
+// AlongsideSuffix. The layout of the file is the same as the one of
+// integrity data appended to a snap.
+func GenerateAlongside(snapPath string) (err error) {
+	hashFileName := snapPath + ".verity.tmp"
+	header, hashFile, err := generateHashTree(snapPath, hashFileName)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		hashFile.Close()
-		if e := os.Remove(hashFileName); e != nil {
+		if e := os.Remove(hashFileName); e != nil && !os.IsNotExist(e) && err == nil {
 			err = e
 		}
 	}()
 
-	fi, err := hashFile.Stat()
+	tmpName := snapPath + AlongsideSuffix + "~"
+	out, err := os.OpenFile(tmpName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(tmpName)
+		}
+	}()
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, hashFile); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, snapPath+AlongsideSuffix)
+}
 
-	integrityDataHeader := newIntegrityDataHeader(dmVerityBlock, uint64(fi.Size()))
+// IntegrityData describes the integrity data of a snap and where they are
+// stored.
+type IntegrityData struct {
+	Header IntegrityDataHeader
+	// SourceFilePath is the path of the file containing the integrity
+	// data, either the snap itself or the file stored alongside it.
+	SourceFilePath string
+	// Offset is the offset of the integrity data header in the source
+	// file.
+	Offset uint64
+	// SHA3_384 is the digest of the integrity data, header included, as
+	// carried by snap-revision assertions.
+	SHA3_384 string
+}
 
-	// Append header to snap
-	header, err := integrityDataHeader.Encode()
+// HashOffset returns the offset of the dm-verity hash tree in the source
+// file.
+func (d *IntegrityData) HashOffset() uint64 {
+	return d.Offset + HeaderSize
+}
+
+const (
+	squashfsMagic             = "hsqs"
+	squashfsBytesUsedOffset   = 40
+	squashfsSuperblockMinSize = squashfsBytesUsedOffset + 8
+)
+
+// squashfsSize returns the size of the squashfs filesystem at the start of
+// the given file, as recorded in its superblock.
+func squashfsSize(f *os.File) (uint64, error) {
+	superblock := make([]byte, squashfsSuperblockMinSize)
+	if _, err := io.ReadFull(f, superblock); err != nil {
+		return 0, fmt.Errorf("cannot read squashfs superblock: %v", err)
+	}
+	if string(superblock[:len(squashfsMagic)]) != squashfsMagic {
+		return 0, fmt.Errorf("cannot read squashfs superblock: invalid magic value")
+	}
+	return binary.LittleEndian.Uint64(superblock[squashfsBytesUsedOffset:]), nil
+}
+
+// readIntegrityData reads and checks the integrity data starting at the
+// given offset of the file.
+func readIntegrityData(f *os.File, offset uint64) (*IntegrityData, error) {
+	block := make([]byte, HeaderSize)
+	if _, err := f.ReadAt(block, int64(offset)); err != nil {
+		return nil, err
+	}
+	data := &IntegrityData{
+		SourceFilePath: f.Name(),
+		Offset:         offset,
+	}
+	if err := data.Header.Decode(block); err != nil {
+		return nil, err
+	}
+	if data.Header.Size < HeaderSize {
+		return nil, fmt.Errorf("invalid integrity data header: invalid size %d", data.Header.Size)
+	}
+
+	h := crypto.SHA3_384.New()
+	n, err := io.Copy(h, io.NewSectionReader(f, int64(offset), int64(data.Header.Size)))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if uint64(n) != data.Header.Size {
+		return nil, fmt.Errorf("invalid integrity data: expected %d bytes, found %d", data.Header.Size, n)
 	}
+	data.SHA3_384 = base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+	return data, nil
+}
 
-	snapFile, err := os.OpenFile(snapPath, os.O_APPEND|os.O_WRONLY, 0644)
+// lookupAppended looks for integrity data appended to the snap, they start
+// at the first block boundary after the squashfs filesystem.
+func lookupAppended(snapPath string) (*IntegrityData, error) {
+	f, err := os.Open(snapPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer snapFile.Close()
+	defer f.Close()
 
-	if _, err = snapFile.Write(header); err != nil {
-		return err
+	fsSize, err := squashfsSize(f)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
 	}
+	// snaps can be padded after the filesystem, look for the header
+	// on every block boundary
+	for offset := align(fsSize); offset+HeaderSize <= uint64(fi.Size()); offset += blockSize {
+		magicBuf := make([]byte, len(magic))
+		if _, err := f.ReadAt(magicBuf, int64(offset)); err != nil {
+			return nil, err
+		}
+		if bytes.Equal(magicBuf, magic) {
+			return readIntegrityData(f, offset)
+		}
+	}
+	return nil, ErrNoIntegrityDataFound
+}
 
-	// Append verity metadata to snap
-	if _, err := io.Copy(snapFile, hashFile); err != nil {
-		return err
+// lookupAlongside looks for integrity data stored next to the snap.
+func lookupAlongside(snapPath string) (*IntegrityData, error) {
+	f, err := os.Open(snapPath + AlongsideSuffix)
+	if os.IsNotExist(err) {
+		return nil, ErrNoIntegrityDataFound
 	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readIntegrityData(f, 0)
+}
 
-	return err
+// Lookup finds the integrity data of the given snap, either appended to it
+// or stored alongside it, the former taking precedence. If expected is not
+// nil the integrity data must match it, this is used to cross-check them
+// with the integrity information delivered by the snap-revision assertion.
+// ErrNoIntegrityDataFound is returned if the snap has no integrity data.
+func Lookup(snapPath string, expected *ExpectedData) (*IntegrityData, error) {
+	data, err := lookupAppended(snapPath)
+	if err == ErrNoIntegrityDataFound {
+		data, err = lookupAlongside(snapPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expected != nil {
+		if data.Header.Size != expected.Size || data.SHA3_384 != expected.SHA3_384 {
+			return nil, fmt.Errorf("integrity data of %q do not match the expected ones (size %d, sha3-384 %s)", snapPath, expected.Size, expected.SHA3_384)
+		}
+	}
+	return data, nil
+}
+
+// ExpectedData carries the expected size and digest of the integrity data
+// of a snap.
+type ExpectedData struct {
+	SHA3_384 string
+	Size     uint64
 }
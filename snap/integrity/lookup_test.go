@@ -0,0 +1,183 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package integrity_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/snap/integrity"
+	"github.com/snapcore/snapd/snap/integrity/dmverity"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const testRootHash = "e2926364a8b1242d92fb1b56081e1ddb86eba35411961252a103a1c083c2be6d"
+
+// makeFakeSquashfs returns the content of a fake squashfs filesystem of the
+// given size, padded to padTo bytes.
+func makeFakeSquashfs(size, padTo int) []byte {
+	fs := make([]byte, padTo)
+	copy(fs, "hsqs")
+	binary.LittleEndian.PutUint64(fs[40:], uint64(size))
+	return fs
+}
+
+// makeIntegrityData returns integrity data with a fake hash tree of the
+// given size.
+func makeIntegrityData(c *C, hashSize int) []byte {
+	header := integrity.IntegrityDataHeader{
+		Type:     "integrity",
+		Size:     uint64(integrity.HeaderSize + hashSize),
+		DmVerity: dmverity.Info{RootHash: testRootHash},
+	}
+	encoded, err := header.Encode()
+	c.Assert(err, IsNil)
+	return append(encoded, bytes.Repeat([]byte{'h'}, hashSize)...)
+}
+
+func (s *IntegrityTestSuite) TestLookupAppended(c *C) {
+	snapPath := filepath.Join(c.MkDir(), "foo_1.snap")
+	// the filesystem was padded after the first block
+	data := makeIntegrityData(c, 8192)
+	content := append(makeFakeSquashfs(3000, 2*4096), data...)
+	c.Assert(os.WriteFile(snapPath, content, 0644), IsNil)
+
+	found, err := integrity.Lookup(snapPath, nil)
+	c.Assert(err, IsNil)
+	c.Check(found.SourceFilePath, Equals, snapPath)
+	c.Check(found.Offset, Equals, uint64(2*4096))
+	c.Check(found.HashOffset(), Equals, uint64(3*4096))
+	c.Check(found.Header.Type, Equals, "integrity")
+	c.Check(found.Header.Size, Equals, uint64(len(data)))
+	c.Check(found.Header.DmVerity.RootHash, Equals, testRootHash)
+
+	dataPath := filepath.Join(c.MkDir(), "data")
+	c.Assert(os.WriteFile(dataPath, data, 0644), IsNil)
+	dataDigest, _, err := asserts.SnapFileSHA3_384(dataPath)
+	c.Assert(err, IsNil)
+	c.Check(found.SHA3_384, Equals, dataDigest)
+
+	// matching the expected data
+	_, err = integrity.Lookup(snapPath, &integrity.ExpectedData{SHA3_384: dataDigest, Size: uint64(len(data))})
+	c.Check(err, IsNil)
+
+	// mismatch
+	_, err = integrity.Lookup(snapPath, &integrity.ExpectedData{SHA3_384: dataDigest, Size: 4096})
+	c.Check(err, ErrorMatches, fmt.Sprintf(`integrity data of %q do not match the expected ones \(size 4096, sha3-384 %s\)`, snapPath, dataDigest))
+}
+
+func (s *IntegrityTestSuite) TestLookupAlongside(c *C) {
+	snapPath := filepath.Join(c.MkDir(), "foo_1.snap")
+	c.Assert(os.WriteFile(snapPath, makeFakeSquashfs(4096, 4096), 0644), IsNil)
+
+	_, err := integrity.Lookup(snapPath, nil)
+	c.Check(err, Equals, integrity.ErrNoIntegrityDataFound)
+
+	c.Assert(os.WriteFile(snapPath+".verity", makeIntegrityData(c, 4096), 0644), IsNil)
+	found, err := integrity.Lookup(snapPath, nil)
+	c.Assert(err, IsNil)
+	c.Check(found.SourceFilePath, Equals, snapPath+".verity")
+	c.Check(found.Offset, Equals, uint64(0))
+	c.Check(found.HashOffset(), Equals, uint64(4096))
+	c.Check(found.Header.DmVerity.RootHash, Equals, testRootHash)
+}
+
+func (s *IntegrityTestSuite) TestLookupErrors(c *C) {
+	snapPath := filepath.Join(c.MkDir(), "foo_1.snap")
+
+	c.Assert(os.WriteFile(snapPath, []byte("not a squashfs"), 0644), IsNil)
+	_, err := integrity.Lookup(snapPath, nil)
+	c.Check(err, ErrorMatches, "cannot read squashfs superblock: .*")
+
+	c.Assert(os.WriteFile(snapPath, bytes.Repeat([]byte{'x'}, 4096), 0644), IsNil)
+	_, err = integrity.Lookup(snapPath, nil)
+	c.Check(err, ErrorMatches, "cannot read squashfs superblock: invalid magic value")
+
+	// the hash tree is truncated
+	data := makeIntegrityData(c, 8192)
+	content := append(makeFakeSquashfs(4096, 4096), data[:len(data)-4096]...)
+	c.Assert(os.WriteFile(snapPath, content, 0644), IsNil)
+	_, err = integrity.Lookup(snapPath, nil)
+	c.Check(err, ErrorMatches, "invalid integrity data: expected 12288 bytes, found 8192")
+}
+
+func (s *IntegrityTestSuite) TestGenerateAlongside(c *C) {
+	snapPath := filepath.Join(c.MkDir(), "foo_1.snap")
+	squashfs := makeFakeSquashfs(4096, 4096)
+	c.Assert(os.WriteFile(snapPath, squashfs, 0644), IsNil)
+
+	vscmd := testutil.MockCommand(c, "veritysetup", fmt.Sprintf(`
+case "$1" in
+	--version)
+		echo "veritysetup 2.2.6"
+		exit 0
+		;;
+	format)
+		truncate -s 8192 "$3"
+		echo "Hash algorithm:  	sha256"
+		echo "Root hash:      	%s"
+		;;
+esac
+`, testRootHash))
+	defer vscmd.Restore()
+
+	err := integrity.GenerateAlongside(snapPath)
+	c.Assert(err, IsNil)
+
+	c.Check(vscmd.Calls(), DeepEquals, [][]string{
+		{"veritysetup", "--version"},
+		{"veritysetup", "format", snapPath, snapPath + ".verity.tmp"},
+	})
+	// the snap is untouched and the temporary files are gone
+	c.Check(snapPath, testutil.FileEquals, squashfs)
+	c.Check(snapPath+".verity.tmp", testutil.FileAbsent)
+	c.Check(snapPath+".verity~", testutil.FileAbsent)
+
+	found, err := integrity.Lookup(snapPath, nil)
+	c.Assert(err, IsNil)
+	c.Check(found.SourceFilePath, Equals, snapPath+".verity")
+	c.Check(found.Header.Size, Equals, uint64(integrity.HeaderSize+8192))
+	c.Check(found.Header.DmVerity.RootHash, Equals, testRootHash)
+}
+
+func (s *IntegrityTestSuite) TestGenerateAlongsideError(c *C) {
+	snapPath := filepath.Join(c.MkDir(), "foo_1.snap")
+	c.Assert(os.WriteFile(snapPath, makeFakeSquashfs(4096, 4096), 0644), IsNil)
+
+	vscmd := testutil.MockCommand(c, "veritysetup", `
+if [ "$1" = --version ]; then
+	echo "veritysetup 2.2.6"
+	exit 0
+fi
+echo "boom"
+exit 1
+`)
+	defer vscmd.Restore()
+
+	err := integrity.GenerateAlongside(snapPath)
+	c.Assert(err, ErrorMatches, "boom")
+	c.Check(snapPath+".verity", testutil.FileAbsent)
+}
@@ -208,10 +208,21 @@ type Options struct {
 	SnapName string
 	// Compression method to use
 	Compression string
+	// BlockSize is the size in bytes of the blocks of the snap, which
+	// must be a power of two between 4KiB and 1MiB, or 0 for the default
+	BlockSize int
 	// Integrity requests appending integrity data to the snap when set
 	Integrity bool
+	// IntegrityAlongside requests storing integrity data in a file next
+	// to the snap when set
+	IntegrityAlongside bool
 }
 
+const (
+	minBlockSize = 4 * 1024
+	maxBlockSize = 1024 * 1024
+)
+
 var Defaults *Options = nil
 
 // Snap the given sourceDirectory and return the generated
@@ -221,11 +232,19 @@ func Snap(sourceDir string, opts *Options) (string, error) {
 		opts = &Options{}
 	}
 	switch opts.Compression {
-	case "xz", "lzo", "":
+	case "xz", "lzo", "zstd", "":
 		// fine
 	default:
 		return "", fmt.Errorf("cannot use compression %q", opts.Compression)
 	}
+	if opts.BlockSize != 0 {
+		if opts.BlockSize < minBlockSize || opts.BlockSize > maxBlockSize || opts.BlockSize&(opts.BlockSize-1) != 0 {
+			return "", fmt.Errorf("cannot use block size %d: must be a power of two between %d and %d", opts.BlockSize, minBlockSize, maxBlockSize)
+		}
+	}
+	if opts.Integrity && opts.IntegrityAlongside {
+		return "", fmt.Errorf("cannot both append integrity data and store it alongside the snap")
+	}
 
 	info, err := prepare(sourceDir, opts.TargetDir)
 	if err != nil {
@@ -244,13 +263,18 @@ func Snap(sourceDir string, opts *Options) (string, error) {
 		SnapType:     string(info.Type()),
 		Compression:  opts.Compression,
 		ExcludeFiles: []string{excludes},
+		BlockSize:    opts.BlockSize,
 	}); err != nil {
 		return "", err
 	}
 
-	if opts.Integrity {
-		err := integrity.GenerateAndAppend(snapName)
-		if err != nil {
+	switch {
+	case opts.Integrity:
+		if err := integrity.GenerateAndAppend(snapName); err != nil {
+			return "", err
+		}
+	case opts.IntegrityAlongside:
+		if err := integrity.GenerateAlongside(snapName); err != nil {
 			return "", err
 		}
 	}
@@ -452,7 +452,7 @@ func (s *packSuite) TestPackWithCompressionHappy(c *C) {
 func (s *packSuite) TestPackWithCompressionUnhappy(c *C) {
 	sourceDir := makeExampleSnapSourceDir(c, "{name: hello, version: 0}")
 
-	for _, comp := range []string{"gzip", "zstd", "silly"} {
+	for _, comp := range []string{"gzip", "silly"} {
 		snapfile, err := pack.Snap(sourceDir, &pack.Options{
 			TargetDir:   c.MkDir(),
 			Compression: comp,
@@ -462,6 +462,31 @@ func (s *packSuite) TestPackWithCompressionUnhappy(c *C) {
 	}
 }
 
+func (s *packSuite) TestPackWithBlockSizeUnhappy(c *C) {
+	sourceDir := makeExampleSnapSourceDir(c, "{name: hello, version: 0}")
+
+	for _, size := range []int{-1, 1024, 3 * 4096, 2 * 1024 * 1024} {
+		snapfile, err := pack.Snap(sourceDir, &pack.Options{
+			TargetDir: c.MkDir(),
+			BlockSize: size,
+		})
+		c.Check(err, ErrorMatches, fmt.Sprintf("cannot use block size %d: must be a power of two between 4096 and 1048576", size))
+		c.Check(snapfile, Equals, "")
+	}
+}
+
+func (s *packSuite) TestPackWithIntegrityBothWaysUnhappy(c *C) {
+	sourceDir := makeExampleSnapSourceDir(c, "{name: hello, version: 0}")
+
+	snapfile, err := pack.Snap(sourceDir, &pack.Options{
+		TargetDir:          c.MkDir(),
+		Integrity:          true,
+		IntegrityAlongside: true,
+	})
+	c.Check(err, ErrorMatches, "cannot both append integrity data and store it alongside the snap")
+	c.Check(snapfile, Equals, "")
+}
+
 func (s *packSuite) TestPackWithIntegrity(c *C) {
 	sourceDir := makeExampleSnapSourceDir(c, "{name: hello, version: 0}")
 	targetDir := c.MkDir()
@@ -0,0 +1,95 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// ConfigEnvFile returns the path of the file holding the environment
+// variables set via the env.* configuration of the given snap instance.
+func ConfigEnvFile(instanceName string) string {
+	return filepath.Join(dirs.SnapEnvironmentDir, fmt.Sprintf("snap.%s.json", instanceName))
+}
+
+var validConfigEnvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateConfigEnvName checks that the given name can be used for an
+// environment variable set via the env.* configuration of a snap. Variables
+// controlled by snapd itself cannot be overridden.
+func ValidateConfigEnvName(name string) error {
+	if !validConfigEnvName.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	switch {
+	case name == "SNAP" || strings.HasPrefix(name, "SNAP_"):
+		return fmt.Errorf("cannot set environment variable %q: reserved for snapd", name)
+	case name == "HOME" || name == "XDG_RUNTIME_DIR":
+		return fmt.Errorf("cannot set environment variable %q: controlled by snapd", name)
+	}
+	return nil
+}
+
+// WriteConfigEnv stores the environment variables set via the env.*
+// configuration of the given snap instance, so that they are picked up by
+// "snap run" for all users. The file is removed if env is empty. Like the
+// configuration itself, the variables are not meant to hold secrets.
+func WriteConfigEnv(instanceName string, env map[string]string) error {
+	fname := ConfigEnvFile(instanceName)
+	if len(env) == 0 {
+		if err := os.Remove(fname); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dirs.SnapEnvironmentDir, 0755); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(fname, data, 0644, 0)
+}
+
+// ConfigEnv returns the environment variables set via the env.*
+// configuration of the given snap instance.
+func ConfigEnv(instanceName string) (map[string]string, error) {
+	data, err := os.ReadFile(ConfigEnvFile(instanceName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var env map[string]string
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("cannot parse environment of snap %q: %v", instanceName, err)
+	}
+	return env, nil
+}
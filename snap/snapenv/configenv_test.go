@@ -0,0 +1,112 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapenv
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type configEnvSuite struct {
+	testutil.BaseTest
+}
+
+var _ = Suite(&configEnvSuite{})
+
+func (s *configEnvSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+}
+
+func (s *configEnvSuite) TestValidateConfigEnvName(c *C) {
+	for _, name := range []string{"FOO", "foo_bar", "_X1", "SNAPPY", "LOG_LEVEL"} {
+		c.Check(ValidateConfigEnvName(name), IsNil, Commentf(name))
+	}
+	for _, t := range []struct {
+		name, err string
+	}{
+		{"", `invalid environment variable name ""`},
+		{"1FOO", `invalid environment variable name "1FOO"`},
+		{"FOO-BAR", `invalid environment variable name "FOO-BAR"`},
+		{"FOO=BAR", `invalid environment variable name "FOO=BAR"`},
+		{"SNAP", `cannot set environment variable "SNAP": reserved for snapd`},
+		{"SNAP_DATA", `cannot set environment variable "SNAP_DATA": reserved for snapd`},
+		{"HOME", `cannot set environment variable "HOME": controlled by snapd`},
+		{"XDG_RUNTIME_DIR", `cannot set environment variable "XDG_RUNTIME_DIR": controlled by snapd`},
+	} {
+		c.Check(ValidateConfigEnvName(t.name), ErrorMatches, t.err)
+	}
+}
+
+func (s *configEnvSuite) TestWriteReadConfigEnv(c *C) {
+	env, err := ConfigEnv("foo")
+	c.Assert(err, IsNil)
+	c.Check(env, HasLen, 0)
+
+	c.Assert(WriteConfigEnv("foo", map[string]string{"A": "1", "B": "with\nnewline"}), IsNil)
+	c.Check(ConfigEnvFile("foo"), Equals, filepath.Join(dirs.SnapEnvironmentDir, "snap.foo.json"))
+	c.Check(ConfigEnvFile("foo"), testutil.FileEquals, `{"A":"1","B":"with\nnewline"}`)
+	st, err := os.Stat(ConfigEnvFile("foo"))
+	c.Assert(err, IsNil)
+	c.Check(st.Mode().Perm(), Equals, os.FileMode(0644))
+
+	env, err = ConfigEnv("foo")
+	c.Assert(err, IsNil)
+	c.Check(env, DeepEquals, map[string]string{"A": "1", "B": "with\nnewline"})
+
+	// empty environment removes the file
+	c.Assert(WriteConfigEnv("foo", nil), IsNil)
+	c.Check(ConfigEnvFile("foo"), testutil.FileAbsent)
+	c.Assert(WriteConfigEnv("foo", nil), IsNil)
+}
+
+func (s *configEnvSuite) TestConfigEnvInvalid(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapEnvironmentDir, 0755), IsNil)
+	c.Assert(os.WriteFile(ConfigEnvFile("foo"), []byte("garbage"), 0644), IsNil)
+
+	_, err := ConfigEnv("foo")
+	c.Check(err, ErrorMatches, `cannot parse environment of snap "foo": .*`)
+}
+
+func (s *configEnvSuite) TestConfigEnvUnreadable(c *C) {
+	c.Assert(os.MkdirAll(ConfigEnvFile("foo"), 0755), IsNil)
+
+	_, err := ConfigEnv("foo")
+	c.Check(err, ErrorMatches, `read .*/snap.foo.json: is a directory`)
+}
+
+func (s *configEnvSuite) TestExtendEnvForRunWithConfigEnv(c *C) {
+	c.Assert(WriteConfigEnv("foo", map[string]string{"LOG_LEVEL": "debug", "TMPDIR": "/tmp/foo"}), IsNil)
+
+	env := osutil.Environment{"TMPDIR": "/var/tmp"}
+	ExtendEnvForRun(env, mockSnapInfo, nil)
+
+	c.Check(env["SNAP_NAME"], Equals, "foo")
+	c.Check(env["LOG_LEVEL"], Equals, "debug")
+	// the configuration takes precedence over the calling environment
+	c.Check(env["TMPDIR"], Equals, "/tmp/foo")
+}
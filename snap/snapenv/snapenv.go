@@ -46,8 +46,16 @@ var userCurrent = user.Current
 // etc are all set.
 //
 // It ensures all SNAP_* override any pre-existing environment
-// variables.
+// variables. Variables set via the env.* configuration of the snap are
+// added as well.
 func ExtendEnvForRun(env osutil.Environment, info *snap.Info, opts *dirs.SnapDirOptions) {
+	configEnv, err := ConfigEnv(info.InstanceName())
+	if err != nil {
+		logger.Noticef("cannot read environment configuration of snap %q: %v", info.InstanceName(), err)
+	}
+	for k, v := range configEnv {
+		env[k] = v
+	}
 	// Set various SNAP_ environment variables as well as some non-SNAP variables,
 	// depending on snap confinement mode. Note that this does not include environment
 	// set by snap-exec.
@@ -30,6 +30,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -503,6 +504,9 @@ type BuildOpts struct {
 	SnapType     string
 	Compression  string
 	ExcludeFiles []string
+	// BlockSize is the size in bytes of the blocks the data is
+	// compressed in, the default of mksquashfs is used if not set
+	BlockSize int
 }
 
 // MinimumSnapSize is the smallest size a snap can be. The kernel attempts to read a
@@ -543,6 +547,9 @@ func (s *Snap) Build(sourceDir string, opts *BuildOpts) error {
 		"-no-fragments",
 		"-no-progress",
 	)
+	if opts.BlockSize > 0 {
+		cmd.Args = append(cmd.Args, "-b", strconv.Itoa(opts.BlockSize))
+	}
 
 	if len(opts.ExcludeFiles) > 0 {
 		cmd.Args = append(cmd.Args, "-wildcards")
@@ -696,6 +696,30 @@ func (s *SquashfsTestSuite) TestBuildSupportsMultipleExcludesWithOnlyOneWildcard
 	})
 }
 
+func (s *SquashfsTestSuite) TestBuildWithBlockSize(c *C) {
+	defer squashfs.MockCommandFromSystemSnap(func(cmd string, args ...string) (*exec.Cmd, error) {
+		return nil, errors.New("bzzt")
+	})()
+	mksq := testutil.MockCommand(c, "mksquashfs", `touch "$2"`)
+	defer mksq.Restore()
+
+	snapPath := filepath.Join(c.MkDir(), "foo.snap")
+	sn := squashfs.New(snapPath)
+	err := sn.Build(c.MkDir(), &squashfs.BuildOpts{
+		SnapType:    "app",
+		Compression: "zstd",
+		BlockSize:   1024 * 1024,
+	})
+	c.Assert(err, IsNil)
+	calls := mksq.Calls()
+	c.Assert(calls, HasLen, 1)
+	c.Check(calls[0], DeepEquals, []string{
+		"mksquashfs", ".", snapPath, "-noappend", "-comp", "zstd", "-no-fragments", "-no-progress",
+		"-b", "1048576",
+		"-all-root", "-no-xattrs",
+	})
+}
+
 func (s *SquashfsTestSuite) TestBuildUsesMksquashfsFromCoreIfAvailable(c *C) {
 	usedFromCore := false
 	defer squashfs.MockCommandFromSystemSnap(func(cmd string, args ...string) (*exec.Cmd, error) {
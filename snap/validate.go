@@ -191,6 +191,10 @@ func ValidateHook(hook *HookInfo) error {
 		}
 	}
 
+	if hook.Timeout < 0 {
+		return fmt.Errorf("hook timeout cannot be negative")
+	}
+
 	return nil
 }
 
@@ -24,11 +24,13 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 
 	. "github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeout"
 )
 
 type ValidateSuite struct {
@@ -168,6 +170,10 @@ func (s *ValidateSuite) TestValidateHook(c *C) {
 		err := ValidateHook(hook)
 		c.Assert(err, ErrorMatches, `hook command-chain contains illegal.*`)
 	}
+
+	c.Check(ValidateHook(&HookInfo{Name: "valid", Timeout: timeout.Timeout(time.Minute)}), IsNil)
+	err := ValidateHook(&HookInfo{Name: "valid", Timeout: timeout.Timeout(-time.Minute)})
+	c.Check(err, ErrorMatches, "hook timeout cannot be negative")
 }
 
 // ValidateApp
@@ -303,7 +303,7 @@ func retryPostRequest(httpClient *http.Client, endpoint string, headers map[stri
 		}
 
 		return httpClient.Do(req)
-	}, readResponseBody, defaultRetryStrategy)
+	}, readResponseBody, defaultRetryPolicy)
 }
 
 // a stringList is something that can be deserialized from a JSON
@@ -28,8 +28,8 @@ import (
 
 	. "gopkg.in/check.v1"
 	"gopkg.in/macaroon.v1"
-	"gopkg.in/retry.v1"
 
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -78,12 +78,13 @@ const mockStoreReturnNonce = `{"nonce": "the-nonce"}`
 const mockStoreReturnNoNonce = `{}`
 
 func (s *authTestSuite) SetUpTest(c *C) {
-	store.MockDefaultRetryStrategy(&s.BaseTest, retry.LimitCount(5, retry.LimitTime(1*time.Second,
-		retry.Exponential{
+	store.MockDefaultRetryPolicy(&s.BaseTest, retryutil.Policy{
+		Strategy: retryutil.Exponential{
 			Initial: 1 * time.Millisecond,
 			Factor:  1.1,
 		},
-	)))
+		Limits: retryutil.Limits{MaxAttempts: 5, MaxElapsed: 1 * time.Second},
+	})
 }
 
 func (s *authTestSuite) TestRequestStoreMacaroon(c *C) {
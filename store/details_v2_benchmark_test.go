@@ -0,0 +1,60 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+// not using store_test as this is a very low level benchmark
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// snapActionResponse builds a snap action response for n snaps, as
+// returned by the store when refreshing a system with many snaps.
+func snapActionResponse(n int) []byte {
+	results := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		snapJSON := strings.Replace(coreStoreJSON, `"name": "core"`, fmt.Sprintf(`"name": "snap-%d"`, i), 1)
+		results = append(results, fmt.Sprintf(`{"result": "refresh", "instance-key": "key-%d", "snap-id": "snap-id-%d", "name": "snap-%d", "effective-channel": "stable", "snap": %s}`, i, i, i, snapJSON))
+	}
+	return []byte(`{"results": [` + strings.Join(results, ",") + `]}`)
+}
+
+func BenchmarkDecodeSnapActionResults1000(b *testing.B) {
+	defer snap.MockSanitizePlugsSlots(func(snapInfo *snap.Info) {})()
+
+	data := snapActionResponse(1000)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var results snapActionResultList
+		if err := json.NewDecoder(bytes.NewReader(data)).Decode(&results); err != nil {
+			b.Fatal(err)
+		}
+		for _, res := range results.Results {
+			if _, err := infoFromStoreSnap(&res.Snap); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -22,6 +22,7 @@ package store
 import (
 	"errors"
 	"net/url"
+	"time"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/overlord/auth"
@@ -43,6 +44,14 @@ type DeviceAndAuthContext interface {
 	CloudInfo() (*auth.CloudInfo, error)
 
 	StoreOffline() (bool, error)
+
+	// BackupProxyStoreParams returns the URL of the backup proxy store
+	// to fail over to when the store is unreachable for longer than
+	// failoverTimeout, or a nil URL if none is set.
+	BackupProxyStoreParams() (backupURL *url.URL, failoverTimeout time.Duration, err error)
+
+	// Warnf records a warning about the store for the user.
+	Warnf(format string, v ...interface{})
 }
 
 // DeviceSessionRequestParams gathers the assertions and information to be sent to request a device session.
@@ -0,0 +1,124 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// quarantineMaxSize is the maximum total size in bytes of the downloads
+// kept in the quarantine directory, older ones are removed first.
+var quarantineMaxSize int64 = 1024 * 1024 * 1024
+
+var timeNow = time.Now
+
+const quarantineTimeFormat = "20060102T150405.000000000Z"
+
+// DownloadMismatch describes a download that failed digest verification.
+type DownloadMismatch struct {
+	Name string `json:"name"`
+	// URL is the URL the content was finally fetched from, after
+	// redirects.
+	URL string `json:"url"`
+	// RequestID identifies the download request, to be matched against
+	// the server logs.
+	RequestID        string    `json:"request-id,omitempty"`
+	Sha3_384         string    `json:"sha3-384"`
+	ExpectedSha3_384 string    `json:"expected-sha3-384"`
+	Size             int64     `json:"size"`
+	Time             time.Time `json:"time"`
+	// QuarantinePath is where the downloaded content was saved, if it
+	// could be saved.
+	QuarantinePath string `json:"-"`
+}
+
+func newDownloadMismatch(hashErr HashError, size int64) *DownloadMismatch {
+	return &DownloadMismatch{
+		Name:             hashErr.name,
+		URL:              hashErr.url,
+		RequestID:        hashErr.requestID,
+		Sha3_384:         hashErr.sha3_384,
+		ExpectedSha3_384: hashErr.targetSha3_384,
+		Size:             size,
+		Time:             timeNow().UTC(),
+	}
+}
+
+// quarantineDownload saves a copy of the content at path that failed
+// digest verification in the quarantine directory, together with the
+// details of the request, pruning older entries.
+func quarantineDownload(path string, mismatch *DownloadMismatch) error {
+	if mismatch.Size > quarantineMaxSize {
+		return fmt.Errorf("download size %d exceeds the quarantine limit of %d bytes", mismatch.Size, quarantineMaxSize)
+	}
+	if err := os.MkdirAll(dirs.SnapDownloadQuarantineDir, 0700); err != nil {
+		return err
+	}
+	base := filepath.Join(dirs.SnapDownloadQuarantineDir, fmt.Sprintf("%s_%s", mismatch.Time.Format(quarantineTimeFormat), mismatch.Name))
+	if err := osutil.CopyFile(path, base+".blob", osutil.CopyFlagOverwrite); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(mismatch)
+	if err != nil {
+		return err
+	}
+	if err := osutil.AtomicWriteFile(base+".json", meta, 0600, 0); err != nil {
+		return err
+	}
+	mismatch.QuarantinePath = base + ".blob"
+	pruneQuarantine()
+	return nil
+}
+
+// pruneQuarantine removes the oldest quarantined downloads so that the
+// total size of the kept ones is at most quarantineMaxSize.
+func pruneQuarantine() {
+	blobs, err := filepath.Glob(filepath.Join(dirs.SnapDownloadQuarantineDir, "*.blob"))
+	if err != nil {
+		return
+	}
+	// the names start with the time of the download, keep the newest
+	sort.Sort(sort.Reverse(sort.StringSlice(blobs)))
+	var total int64
+	for _, blob := range blobs {
+		if fi, err := os.Stat(blob); err == nil {
+			total += fi.Size()
+		}
+		if total <= quarantineMaxSize {
+			continue
+		}
+		base := strings.TrimSuffix(blob, ".blob")
+		for _, p := range []string{blob, base + ".json"} {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				logger.Noticef("cannot remove quarantined download %q: %v", p, err)
+			}
+		}
+	}
+}
@@ -35,12 +35,12 @@ import (
 
 	"github.com/juju/ratelimit"
 	. "gopkg.in/check.v1"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
@@ -57,10 +57,13 @@ var _ = Suite(&downloadSuite{})
 func (s *downloadSuite) SetUpTest(c *C) {
 	s.BaseTest.SetUpTest(c)
 
-	store.MockDownloadRetryStrategy(&s.BaseTest, retry.LimitCount(5, retry.Exponential{
-		Initial: time.Millisecond,
-		Factor:  2.5,
-	}))
+	store.MockDownloadRetryPolicy(&s.BaseTest, retryutil.Policy{
+		Strategy: retryutil.Exponential{
+			Initial: time.Millisecond,
+			Factor:  2.5,
+		},
+		Limits: retryutil.Limits{MaxAttempts: 5},
+	})
 
 	s.mockXdelta = testutil.MockCommand(c, "xdelta3", "")
 	s.AddCleanup(s.mockXdelta.Restore)
@@ -81,6 +81,30 @@ func (e *RevisionNotAvailableError) Error() string {
 	return "no snap revision available as specified"
 }
 
+// WithdrawnError is returned when the store reports that a snap, the
+// revision of it that is installed, or its publisher was withdrawn,
+// typically as a security measure.
+type WithdrawnError struct {
+	// Code is the store error code, one of "snap-withdrawn",
+	// "revision-withdrawn" or "publisher-terminated".
+	Code    string
+	Message string
+}
+
+func (e *WithdrawnError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	switch e.Code {
+	case "revision-withdrawn":
+		return "snap revision was withdrawn from the store"
+	case "publisher-terminated":
+		return "snap publisher account was terminated"
+	default:
+		return "snap was withdrawn from the store"
+	}
+}
+
 // DownloadError represents a download error
 type DownloadError struct {
 	Code int
@@ -281,6 +305,8 @@ func translateSnapActionError(action, snapChannel, code, message string, release
 		return e
 	case "id-not-found", "name-not-found":
 		return ErrSnapNotFound
+	case "snap-withdrawn", "revision-withdrawn", "publisher-terminated":
+		return &WithdrawnError{Code: code, Message: message}
 	case "user-authorization-needs-refresh":
 		return errUserAuthorizationNeedsRefresh
 	case "device-authorization-needs-refresh":
@@ -28,11 +28,11 @@ import (
 	"time"
 
 	"github.com/juju/ratelimit"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -71,28 +71,36 @@ func MockSnapdtoolCommandFromSystemSnap(f func(name string, args ...string) (*ex
 	}
 }
 
-// MockDefaultRetryStrategy mocks the retry strategy used by several store requests
-func MockDefaultRetryStrategy(t *testutil.BaseTest, strategy retry.Strategy) {
-	originalDefaultRetryStrategy := defaultRetryStrategy
-	defaultRetryStrategy = strategy
+func MockOsRename(f func(oldpath, newpath string) error) (restore func()) {
+	old := osRename
+	osRename = f
+	return func() {
+		osRename = old
+	}
+}
+
+// MockDefaultRetryPolicy mocks the retry policy used by several store requests
+func MockDefaultRetryPolicy(t *testutil.BaseTest, policy retryutil.Policy) {
+	originalDefaultRetryPolicy := defaultRetryPolicy
+	defaultRetryPolicy = policy
 	t.AddCleanup(func() {
-		defaultRetryStrategy = originalDefaultRetryStrategy
+		defaultRetryPolicy = originalDefaultRetryPolicy
 	})
 }
 
-func MockDownloadRetryStrategy(t *testutil.BaseTest, strategy retry.Strategy) {
-	originalDownloadRetryStrategy := downloadRetryStrategy
-	downloadRetryStrategy = strategy
+func MockDownloadRetryPolicy(t *testutil.BaseTest, policy retryutil.Policy) {
+	originalDownloadRetryPolicy := downloadRetryPolicy
+	downloadRetryPolicy = policy
 	t.AddCleanup(func() {
-		downloadRetryStrategy = originalDownloadRetryStrategy
+		downloadRetryPolicy = originalDownloadRetryPolicy
 	})
 }
 
-func MockConnCheckStrategy(t *testutil.BaseTest, strategy retry.Strategy) {
-	originalConnCheckStrategy := connCheckStrategy
-	connCheckStrategy = strategy
+func MockConnCheckPolicy(t *testutil.BaseTest, policy retryutil.Policy) {
+	originalConnCheckPolicy := connCheckPolicy
+	connCheckPolicy = policy
 	t.AddCleanup(func() {
-		connCheckStrategy = originalConnCheckStrategy
+		connCheckPolicy = originalConnCheckPolicy
 	})
 }
 
@@ -228,7 +236,7 @@ func (cfg *Config) SetBaseURL(u *url.URL) error {
 }
 
 func NewHashError(name, sha3_384, targetSha3_384 string) HashError {
-	return HashError{name, sha3_384, targetSha3_384}
+	return HashError{name: name, sha3_384: sha3_384, targetSha3_384: targetSha3_384}
 }
 
 func NewRequestOptions(mth string, url *url.URL) *requestOptions {
@@ -260,3 +268,21 @@ type (
 )
 
 var ReportFetchAssertionsError = reportFetchAssertionsError
+
+func MockQuarantineMaxSize(size int64) (restore func()) {
+	old := quarantineMaxSize
+	quarantineMaxSize = size
+	return func() {
+		quarantineMaxSize = old
+	}
+}
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() {
+		timeNow = old
+	}
+}
+
+var DownloadedBytes = downloadedBytes
@@ -0,0 +1,245 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+var (
+	// defaultStoreFailoverTimeout is for how long the store must be
+	// unreachable before failing over to the backup proxy store, unless
+	// configured otherwise with proxy.store-failover-timeout.
+	defaultStoreFailoverTimeout = 5 * time.Minute
+
+	// storeFailbackProbeInterval is how often the store is probed
+	// while failed over to the backup proxy store.
+	storeFailbackProbeInterval = 1 * time.Minute
+)
+
+// storeFailover tracks the reachability of the store so that requests
+// can be directed to the backup proxy store while it is unreachable.
+type storeFailover struct {
+	mu sync.Mutex
+	// unreachableSince is when requests to the store started
+	// failing, it is zero if the last request succeeded.
+	unreachableSince time.Time
+	// lastFailure is when the last request to the store failed.
+	lastFailure time.Time
+	// active is set while requests are directed to the backup proxy
+	// store.
+	active bool
+	// probing is set while the store is probed to fail back.
+	probing   bool
+	lastProbe time.Time
+}
+
+// primaryBaseURL returns the base URL of the store, or of the proxy
+// store if one is set, disregarding any failover.
+func (s *Store) primaryBaseURL(defaultURL *url.URL) *url.URL {
+	u := defaultURL
+	if s.dauthCtx != nil {
+		var err error
+		_, u, err = s.dauthCtx.ProxyStoreParams(defaultURL)
+		if err != nil {
+			logger.Debugf("cannot get proxy store parameters from state: %v", err)
+		}
+	}
+	if u != nil {
+		return u
+	}
+	return defaultURL
+}
+
+func (s *Store) backupProxyStoreParams() (*url.URL, time.Duration) {
+	if s.dauthCtx == nil {
+		return nil, 0
+	}
+	backupURL, failoverTimeout, err := s.dauthCtx.BackupProxyStoreParams()
+	if err != nil {
+		logger.Debugf("cannot get backup proxy store parameters from state: %v", err)
+		return nil, 0
+	}
+	if failoverTimeout == 0 {
+		failoverTimeout = defaultStoreFailoverTimeout
+	}
+	return backupURL, failoverTimeout
+}
+
+// failoverURL returns the URL of the backup proxy store if requests
+// meant for primaryURL should be directed to it, or nil otherwise.
+func (s *Store) failoverURL(primaryURL *url.URL) *url.URL {
+	s.failover.mu.Lock()
+	active := s.failover.active
+	s.failover.mu.Unlock()
+	if !active {
+		return nil
+	}
+
+	backupURL, _ := s.backupProxyStoreParams()
+	if backupURL == nil {
+		// the backup proxy store was unset meanwhile
+		s.failover.mu.Lock()
+		s.failover.active = false
+		s.failover.unreachableSince = time.Time{}
+		s.failover.mu.Unlock()
+		return nil
+	}
+
+	s.maybeProbeStore(primaryURL)
+	return backupURL
+}
+
+// failoverDownloadURL redirects the given download URL to the backup
+// proxy store if it points to the store while failed over.
+func (s *Store) failoverDownloadURL(downloadURL *url.URL) *url.URL {
+	s.failover.mu.Lock()
+	active := s.failover.active
+	s.failover.mu.Unlock()
+	if !active {
+		return downloadURL
+	}
+
+	primaryURL := s.primaryBaseURL(s.cfg.StoreBaseURL)
+	if primaryURL == nil || downloadURL.Host != primaryURL.Host {
+		// e.g. a CDN
+		return downloadURL
+	}
+	backupURL := s.failoverURL(primaryURL)
+	if backupURL == nil {
+		return downloadURL
+	}
+	u := *downloadURL
+	u.Scheme = backupURL.Scheme
+	u.Host = backupURL.Host
+	return &u
+}
+
+func (s *Store) isPrimaryStoreURL(u *url.URL) bool {
+	for _, base := range []*url.URL{s.cfg.StoreBaseURL, s.cfg.AssertionsBaseURL} {
+		if base != nil && s.primaryBaseURL(base).Host == u.Host {
+			return true
+		}
+	}
+	return false
+}
+
+// recordStoreReachability records the outcome of the given request,
+// failing over to the backup proxy store, if one is set, once the store
+// has been unreachable for long enough.
+func (s *Store) recordStoreReachability(req *http.Request, reqErr error) {
+	if req.Context().Err() != nil {
+		// cancelled by the caller, not an indication of the store
+		// being unreachable
+		return
+	}
+	if !s.isPrimaryStoreURL(req.URL) {
+		return
+	}
+
+	var backupURL *url.URL
+	var failoverTimeout time.Duration
+	if reqErr != nil {
+		backupURL, failoverTimeout = s.backupProxyStoreParams()
+	}
+
+	s.failover.mu.Lock()
+	if s.failover.active {
+		s.failover.mu.Unlock()
+		return
+	}
+	if reqErr == nil {
+		s.failover.unreachableSince = time.Time{}
+		s.failover.mu.Unlock()
+		return
+	}
+	now := timeNow()
+	// failures further apart than the failover timeout, with no
+	// requests in between, do not show the store being unreachable
+	// all along
+	if s.failover.unreachableSince.IsZero() || now.Sub(s.failover.lastFailure) >= failoverTimeout {
+		s.failover.unreachableSince = now
+	}
+	s.failover.lastFailure = now
+	since := s.failover.unreachableSince
+	if backupURL == nil || now.Sub(since) < failoverTimeout {
+		s.failover.mu.Unlock()
+		return
+	}
+	s.failover.active = true
+	s.failover.lastProbe = now
+	s.failover.mu.Unlock()
+
+	logger.Noticef("Store at %s unreachable since %s, failing over to backup proxy store at %s", req.URL.Host, since.Format(time.RFC3339), backupURL)
+	s.dauthCtx.Warnf("store at %s was unreachable since %s, failed over to backup proxy store at %s", req.URL.Host, since.Format(time.RFC3339), backupURL)
+}
+
+// maybeProbeStore checks in the background whether the store is
+// reachable again, if it was not probed recently, and fails back to it
+// if so.
+func (s *Store) maybeProbeStore(primaryURL *url.URL) {
+	s.failover.mu.Lock()
+	defer s.failover.mu.Unlock()
+	now := timeNow()
+	if s.failover.probing || now.Sub(s.failover.lastProbe) < storeFailbackProbeInterval {
+		return
+	}
+	s.failover.probing = true
+	s.failover.lastProbe = now
+
+	go func() {
+		reachable := s.probeStore(primaryURL)
+
+		s.failover.mu.Lock()
+		s.failover.probing = false
+		failBack := reachable && s.failover.active
+		if failBack {
+			s.failover.active = false
+			s.failover.unreachableSince = time.Time{}
+		}
+		s.failover.mu.Unlock()
+
+		if failBack {
+			logger.Noticef("Store at %s reachable again, failing back to it", primaryURL.Host)
+			s.dauthCtx.Warnf("store at %s is reachable again, failed back to it from the backup proxy store", primaryURL.Host)
+		}
+	}()
+}
+
+// probeStore returns whether the store at the given URL answers
+// requests, whatever the response.
+func (s *Store) probeStore(primaryURL *url.URL) bool {
+	req, err := http.NewRequest("HEAD", primaryURL.String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		logger.Debugf("store at %s still unreachable: %v", primaryURL.Host, err)
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
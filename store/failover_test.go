@@ -0,0 +1,232 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/store"
+)
+
+type storeFailoverSuite struct {
+	baseStoreSuite
+}
+
+var _ = Suite(&storeFailoverSuite{})
+
+// mockSectionsServer returns a server answering sections requests, or
+// dropping the connection while it is marked down.
+func (s *storeFailoverSuite) mockSectionsServer(c *C, down *bool, mu *sync.Mutex, hits *int) *httptest.Server {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		isDown := *down
+		if !isDown && r.Method == "GET" {
+			*hits++
+		}
+		mu.Unlock()
+		if isDown {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			c.Assert(err, IsNil)
+			conn.Close()
+			return
+		}
+		if r.Method == "HEAD" {
+			return
+		}
+		assertRequest(c, r, "GET", sectionsPath)
+		w.Header().Set("Content-Type", "application/hal+json")
+		io.WriteString(w, MockSectionsJSON)
+	}))
+	s.AddCleanup(mockServer.Close)
+	return mockServer
+}
+
+func (s *storeFailoverSuite) TestFailoverAndFailback(c *C) {
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(store.MockTimeNow(func() time.Time { return now }))
+
+	var mu sync.Mutex
+	primaryDown, backupDown := true, false
+	var primaryHits, backupHits int
+	primary := s.mockSectionsServer(c, &primaryDown, &mu, &primaryHits)
+	backup := s.mockSectionsServer(c, &backupDown, &mu, &backupHits)
+
+	primaryURL, _ := url.Parse(primary.URL)
+	backupURL, _ := url.Parse(backup.URL)
+	dauthCtx := &testDauthContext{
+		c:                   c,
+		device:              s.device,
+		backupProxyStoreURL: backupURL,
+		failoverTimeout:     time.Minute,
+	}
+	sto := store.New(&store.Config{StoreBaseURL: primaryURL}, dauthCtx)
+
+	// the store is unreachable, but not for long enough yet
+	_, err := sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	now = now.Add(30 * time.Second)
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	c.Check(dauthCtx.Warnings(), HasLen, 0)
+
+	// now it has been
+	now = now.Add(31 * time.Second)
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	c.Check(dauthCtx.Warnings(), DeepEquals, []string{
+		"store at " + primaryURL.Host + " was unreachable since 2023-06-01T10:00:00Z, failed over to backup proxy store at " + backup.URL,
+	})
+
+	// requests go to the backup proxy store
+	sections, err := sto.Sections(s.ctx, s.user)
+	c.Assert(err, IsNil)
+	c.Check(sections, DeepEquals, []string{"featured", "database"})
+	mu.Lock()
+	c.Check(backupHits, Equals, 1)
+	mu.Unlock()
+
+	// the store comes back, it is noticed once probed
+	mu.Lock()
+	primaryDown = false
+	mu.Unlock()
+	now = now.Add(2 * time.Minute)
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, IsNil)
+	for i := 0; i < 100 && len(dauthCtx.Warnings()) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(dauthCtx.Warnings(), HasLen, 2)
+	c.Check(dauthCtx.Warnings()[1], Equals, "store at "+primaryURL.Host+" is reachable again, failed back to it from the backup proxy store")
+
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, IsNil)
+	mu.Lock()
+	defer mu.Unlock()
+	c.Check(backupHits, Equals, 2)
+	c.Check(primaryHits, Equals, 1)
+}
+
+func (s *storeFailoverSuite) TestNoFailoverOnSporadicFailures(c *C) {
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(store.MockTimeNow(func() time.Time { return now }))
+
+	var mu sync.Mutex
+	primaryDown, backupDown := true, false
+	var primaryHits, backupHits int
+	primary := s.mockSectionsServer(c, &primaryDown, &mu, &primaryHits)
+	backup := s.mockSectionsServer(c, &backupDown, &mu, &backupHits)
+
+	primaryURL, _ := url.Parse(primary.URL)
+	backupURL, _ := url.Parse(backup.URL)
+	dauthCtx := &testDauthContext{
+		c:                   c,
+		device:              s.device,
+		backupProxyStoreURL: backupURL,
+		failoverTimeout:     time.Minute,
+	}
+	sto := store.New(&store.Config{StoreBaseURL: primaryURL}, dauthCtx)
+
+	// two failures further apart than the failover timeout
+	_, err := sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	now = now.Add(2 * time.Minute)
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	c.Check(dauthCtx.Warnings(), HasLen, 0)
+
+	// the store is considered unreachable since the latter
+	now = now.Add(30 * time.Second)
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	c.Check(dauthCtx.Warnings(), HasLen, 0)
+	now = now.Add(35 * time.Second)
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	c.Check(dauthCtx.Warnings(), DeepEquals, []string{
+		"store at " + primaryURL.Host + " was unreachable since 2023-06-01T10:02:00Z, failed over to backup proxy store at " + backup.URL,
+	})
+}
+
+func (s *storeFailoverSuite) TestNoFailoverWithoutBackup(c *C) {
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(store.MockTimeNow(func() time.Time { return now }))
+
+	var mu sync.Mutex
+	primaryDown := true
+	var primaryHits int
+	primary := s.mockSectionsServer(c, &primaryDown, &mu, &primaryHits)
+
+	primaryURL, _ := url.Parse(primary.URL)
+	dauthCtx := &testDauthContext{c: c, device: s.device}
+	sto := store.New(&store.Config{StoreBaseURL: primaryURL}, dauthCtx)
+
+	for i := 0; i < 3; i++ {
+		_, err := sto.Sections(s.ctx, s.user)
+		c.Assert(err, NotNil)
+		now = now.Add(10 * time.Minute)
+	}
+	c.Check(dauthCtx.Warnings(), HasLen, 0)
+
+	mu.Lock()
+	primaryDown = false
+	mu.Unlock()
+	_, err := sto.Sections(s.ctx, s.user)
+	c.Assert(err, IsNil)
+}
+
+func (s *storeFailoverSuite) TestFailoverDefaultTimeout(c *C) {
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(store.MockTimeNow(func() time.Time { return now }))
+
+	var mu sync.Mutex
+	primaryDown, backupDown := true, false
+	var primaryHits, backupHits int
+	primary := s.mockSectionsServer(c, &primaryDown, &mu, &primaryHits)
+	backup := s.mockSectionsServer(c, &backupDown, &mu, &backupHits)
+
+	primaryURL, _ := url.Parse(primary.URL)
+	backupURL, _ := url.Parse(backup.URL)
+	dauthCtx := &testDauthContext{c: c, device: s.device, backupProxyStoreURL: backupURL}
+	sto := store.New(&store.Config{StoreBaseURL: primaryURL}, dauthCtx)
+
+	_, err := sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	now = now.Add(4 * time.Minute)
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	c.Check(dauthCtx.Warnings(), HasLen, 0)
+	now = now.Add(time.Minute)
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, NotNil)
+	c.Check(dauthCtx.Warnings(), HasLen, 1)
+
+	_, err = sto.Sections(s.ctx, s.user)
+	c.Assert(err, IsNil)
+	mu.Lock()
+	defer mu.Unlock()
+	c.Check(backupHits, Equals, 1)
+}
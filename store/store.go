@@ -37,8 +37,6 @@ import (
 	"sync"
 	"time"
 
-	"gopkg.in/retry.v1"
-
 	"github.com/snapcore/snapd/arch"
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
@@ -48,6 +46,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/channel"
 	"github.com/snapcore/snapd/snap/naming"
@@ -70,21 +69,23 @@ const (
 
 var requestTimeout = 10 * time.Second
 
-// the LimitTime should be slightly more than 3 times of our http.Client
+// the MaxElapsed limit should be slightly more than 3 times of our http.Client
 // Timeout value
-var defaultRetryStrategy = retry.LimitCount(6, retry.LimitTime(38*time.Second,
-	retry.Exponential{
+var defaultRetryPolicy = retryutil.Policy{
+	Strategy: retryutil.Exponential{
 		Initial: 500 * time.Millisecond,
 		Factor:  2.5,
 	},
-))
+	Limits: retryutil.Limits{MaxAttempts: 6, MaxElapsed: 38 * time.Second},
+}
 
-var connCheckStrategy = retry.LimitCount(3, retry.LimitTime(38*time.Second,
-	retry.Exponential{
+var connCheckPolicy = retryutil.Policy{
+	Strategy: retryutil.Exponential{
 		Initial: 900 * time.Millisecond,
 		Factor:  1.3,
 	},
-))
+	Limits: retryutil.Limits{MaxAttempts: 3, MaxElapsed: 38 * time.Second},
+}
 
 // Config represents the configuration to access the snap store
 type Config struct {
@@ -176,6 +177,8 @@ type Store struct {
 	shouldUseDeltas *bool
 	// which xdelta3 we picked when we checked the deltas
 	xdelta3CmdFunc func(args ...string) *exec.Cmd
+
+	failover storeFailover
 }
 
 var ErrTooManyRequests = errors.New("too many requests")
@@ -487,18 +490,11 @@ func (s *Store) defaultSnapQuery() url.Values {
 }
 
 func (s *Store) baseURL(defaultURL *url.URL) *url.URL {
-	u := defaultURL
-	if s.dauthCtx != nil {
-		var err error
-		_, u, err = s.dauthCtx.ProxyStoreParams(defaultURL)
-		if err != nil {
-			logger.Debugf("cannot get proxy store parameters from state: %v", err)
-		}
+	u := s.primaryBaseURL(defaultURL)
+	if backupURL := s.failoverURL(u); backupURL != nil {
+		return backupURL
 	}
-	if u != nil {
-		return u
-	}
-	return defaultURL
+	return u
 }
 
 func (s *Store) endpointURL(p string, query url.Values) (*url.URL, error) {
@@ -715,7 +711,7 @@ func (s *Store) retryRequestDecodeJSON(ctx context.Context, reqOptions *requestO
 		return s.doRequest(ctx, s.client, reqOptions, user)
 	}, func(resp *http.Response) error {
 		return decodeJSONBody(resp, success, failure)
-	}, defaultRetryStrategy)
+	}, defaultRetryPolicy)
 }
 
 // doRequest does an authenticated request to the store handling a potential macaroon refresh required if needed
@@ -731,6 +727,7 @@ func (s *Store) doRequest(ctx context.Context, client *http.Client, reqOptions *
 		}
 
 		resp, err := client.Do(req)
+		s.recordStoreReachability(req, err)
 		if err != nil {
 			return nil, err
 		}
@@ -1052,6 +1049,13 @@ type Search struct {
 	Category string
 	Private  bool
 	Scope    string
+
+	// Publisher restricts the search to snaps from the publisher
+	// with the given username
+	Publisher string
+	// Interface restricts the search to snaps providing a slot of the
+	// given interface
+	Interface string
 }
 
 // Find finds  (installable) snaps from the store, matching the
@@ -1095,6 +1099,12 @@ func (s *Store) Find(ctx context.Context, search *Search, user *auth.UserState)
 	if search.Category != "" {
 		q.Set("category", search.Category)
 	}
+	if search.Publisher != "" {
+		q.Set("publisher", search.Publisher)
+	}
+	if search.Interface != "" {
+		q.Set("interface", search.Interface)
+	}
 
 	// with search v2 all risks are searched by default (same as scope=wide
 	// with v1) so we need to restrict channel if scope is not passed.
@@ -1138,7 +1148,7 @@ func (s *Store) Find(ctx context.Context, search *Search, user *auth.UserState)
 		}
 		return json.NewDecoder(resp.Body).Decode(&searchData)
 	}
-	resp, err := httputil.RetryRequest(u.String(), doRequest, readResponse, defaultRetryStrategy)
+	resp, err := httputil.RetryRequest(u.String(), doRequest, readResponse, defaultRetryPolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -1210,6 +1220,12 @@ func (s *Store) findV1(ctx context.Context, search *Search, user *auth.UserState
 	if search.Category != "" {
 		q.Set("section", search.Category)
 	}
+	if search.Publisher != "" {
+		q.Set("publisher", search.Publisher)
+	}
+	if search.Interface != "" {
+		q.Set("interface", search.Interface)
+	}
 	if search.Scope != "" {
 		q.Set("scope", search.Scope)
 	}
@@ -1362,7 +1378,7 @@ func (s *Store) WriteCatalogs(ctx context.Context, names io.Writer, adder SnapAd
 		return decodeCatalog(resp, names, adder)
 	}
 
-	resp, err := httputil.RetryRequest(u.String(), doRequest, readResponse, defaultRetryStrategy)
+	resp, err := httputil.RetryRequest(u.String(), doRequest, readResponse, defaultRetryPolicy)
 	if err != nil {
 		return err
 	}
@@ -1595,7 +1611,7 @@ func (s *Store) snapConnCheck() ([]string, error) {
 		}, nil)
 	}, func(resp *http.Response) error {
 		return decodeJSONBody(resp, &result, nil)
-	}, connCheckStrategy)
+	}, connCheckPolicy)
 
 	if err != nil {
 		return hosts, err
@@ -1627,7 +1643,7 @@ func (s *Store) snapConnCheck() ([]string, error) {
 		// account for redirect
 		hosts[len(hosts)-1] = resp.Request.URL.Host
 		return nil
-	}, connCheckStrategy)
+	}, connCheckPolicy)
 	if err != nil {
 		return hosts, err
 	}
@@ -223,7 +223,7 @@ func (s *Store) downloadAssertions(u *url.URL, decodeBody func(io.Reader) error,
 			}
 		}
 		return e
-	}, defaultRetryStrategy)
+	}, defaultRetryPolicy)
 
 	if err != nil {
 		return err
@@ -33,33 +33,40 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/juju/ratelimit"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/randutil"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snapdtool"
 )
 
 var commandFromSystemSnap = snapdtool.CommandFromSystemSnap
 
-var downloadRetryStrategy = retry.LimitCount(7, retry.LimitTime(90*time.Second,
-	retry.Exponential{
+var downloadRetryPolicy = retryutil.Policy{
+	Strategy: retryutil.Exponential{
 		Initial: 500 * time.Millisecond,
 		Factor:  2.5,
 	},
-))
+	Limits: retryutil.Limits{MaxAttempts: 7, MaxElapsed: 90 * time.Second},
+}
 
 var downloadSpeedMeasureWindow = 5 * time.Minute
 
+var downloadedBytes = metrics.NewCounter("snapd_store_downloaded_bytes_total",
+	"Number of bytes of snaps and deltas downloaded from the store.")
+
 // minimum average download speed (bytes/sec), measured over downloadSpeedMeasureWindow.
 var downloadSpeedMin = float64(4096)
 
@@ -175,6 +182,9 @@ type HashError struct {
 	name           string
 	sha3_384       string
 	targetSha3_384 string
+	// url and requestID identify where the content was fetched from
+	url       string
+	requestID string
 }
 
 func (e HashError) Error() string {
@@ -185,6 +195,17 @@ type DownloadOptions struct {
 	RateLimit           int64
 	Scheduled           bool
 	LeavePartialOnError bool
+	// StagingDir, if set, is where the download is written to until it
+	// is complete, instead of next to the target. The complete file is
+	// then moved into place, copying it if StagingDir is on another
+	// filesystem than the target.
+	StagingDir string
+	// OnHashMismatch is called when downloaded content fails digest
+	// verification, after it was saved to the quarantine directory.
+	OnHashMismatch func(mismatch *DownloadMismatch)
+
+	// noCDN bypasses the CDN when retrying a download
+	noCDN bool
 }
 
 // Download downloads the snap addressed by download info and returns its
@@ -221,7 +242,10 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		}
 	}
 
-	partialPath := targetPath + ".partial"
+	partialPath := dlOpts.stagingPath(targetPath) + ".partial"
+	if err := os.MkdirAll(filepath.Dir(partialPath), 0755); err != nil {
+		return err
+	}
 	w, err := os.OpenFile(partialPath, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
 		return err
@@ -265,12 +289,14 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		}
 		actualSha3 := fmt.Sprintf("%x", h.Sum(nil))
 		if downloadInfo.Sha3_384 != actualSha3 {
-			err = HashError{name, actualSha3, downloadInfo.Sha3_384}
+			err = HashError{name: name, sha3_384: actualSha3, targetSha3_384: downloadInfo.Sha3_384, url: url}
 		}
 	}
-	// If hashsum is incorrect retry once
-	if _, ok := err.(HashError); ok {
-		logger.Debugf("Hashsum error on download: %v", err.Error())
+	// If hashsum is incorrect retry once, bypassing the CDN in case it
+	// served corrupted content
+	if hashErr, ok := err.(HashError); ok {
+		logger.Noticef("Hashsum error on download: %v", err.Error())
+		s.reportHashMismatch(w, hashErr, dlOpts)
 		logger.Debugf("Truncating and trying again from scratch.")
 		err = w.Truncate(0)
 		if err != nil {
@@ -280,17 +306,20 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		if err != nil {
 			return err
 		}
-		err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, w, 0, pbar, nil)
+		err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, w, 0, pbar, &DownloadOptions{noCDN: true})
 		if err != nil {
 			logger.Debugf("download of %q failed: %#v", url, err)
 		}
+		if hashErr, ok := err.(HashError); ok {
+			s.reportHashMismatch(w, hashErr, dlOpts)
+		}
 	}
 
 	if err != nil {
 		return err
 	}
 
-	if err := os.Rename(w.Name(), targetPath); err != nil {
+	if err := moveFile(w.Name(), targetPath); err != nil {
 		return err
 	}
 
@@ -301,6 +330,48 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	return s.cacher.Put(downloadInfo.Sha3_384, targetPath)
 }
 
+// stagingPath returns where the download for targetPath should be
+// written to until it is complete, sans any suffix.
+func (dlOpts *DownloadOptions) stagingPath(targetPath string) string {
+	if dlOpts == nil || dlOpts.StagingDir == "" {
+		return targetPath
+	}
+	return filepath.Join(dlOpts.StagingDir, filepath.Base(targetPath))
+}
+
+var osRename = os.Rename
+
+// moveFile renames src to dst, falling back to copying src and removing
+// it if they are on different filesystems.
+func moveFile(src, dst string) error {
+	err := osRename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := osutil.CopyFile(src, dst, osutil.CopyFlagOverwrite|osutil.CopyFlagSync); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// reportHashMismatch saves the content in w that failed digest
+// verification to the quarantine directory and reports it via the
+// OnHashMismatch callback of the download options.
+func (s *Store) reportHashMismatch(w *os.File, hashErr HashError, dlOpts *DownloadOptions) {
+	var size int64
+	if fi, err := w.Stat(); err == nil {
+		size = fi.Size()
+	}
+	mismatch := newDownloadMismatch(hashErr, size)
+	if err := quarantineDownload(w.Name(), mismatch); err != nil {
+		logger.Noticef("cannot quarantine download of %q: %v", hashErr.name, err)
+	}
+	if dlOpts != nil && dlOpts.OnHashMismatch != nil {
+		dlOpts.OnHashMismatch(mismatch)
+	}
+}
+
 func downloadReqOpts(storeURL *url.URL, cdnHeader string, opts *DownloadOptions) *requestOptions {
 	reqOptions := requestOptions{
 		Method:       "GET",
@@ -441,19 +512,25 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 	if err != nil {
 		return err
 	}
+	storeURL = s.failoverDownloadURL(storeURL)
 
 	cdnHeader, err := s.cdnHeader()
 	if err != nil {
 		return err
 	}
+	if dlOpts.noCDN {
+		cdnHeader = "none"
+	}
+	requestID := randutil.RandomString(16)
 
 	tc, downloadCtx := NewTransferSpeedMonitoringWriterAndContext(ctx, downloadSpeedMeasureWindow, downloadSpeedMin)
 
 	var finalErr error
 	var dlSize float64
 	startTime := time.Now()
-	for attempt := retry.Start(downloadRetryStrategy, nil); attempt.Next(); {
+	for attempt := retryutil.Start(downloadRetryPolicy, nil); attempt.Next(); {
 		reqOptions := downloadReqOpts(storeURL, cdnHeader, dlOpts)
+		reqOptions.ExtraHeaders["X-Request-Id"] = requestID
 
 		httputil.MaybeLogRetryAttempt(reqOptions.URL.String(), attempt, startTime)
 
@@ -544,8 +621,10 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 		}
 
 		stopMonitorCh := tc.Monitor()
-		_, finalErr = io.Copy(mw, limiter)
+		var n int64
+		n, finalErr = io.Copy(mw, limiter)
 		close(stopMonitorCh)
+		downloadedBytes.Add(float64(n))
 		pbar.Finished()
 
 		if err := tc.Err(); err != nil {
@@ -572,7 +651,17 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 
 		actualSha3 := fmt.Sprintf("%x", h.Sum(nil))
 		if sha3_384 != "" && sha3_384 != actualSha3 {
-			finalErr = HashError{name, actualSha3, sha3_384}
+			// prefer the ID assigned by the server if any
+			if id := resp.Header.Get("X-Request-Id"); id != "" {
+				requestID = id
+			}
+			finalErr = HashError{
+				name:           name,
+				sha3_384:       actualSha3,
+				targetSha3_384: sha3_384,
+				url:            resp.Request.URL.String(),
+				requestID:      requestID,
+			}
 		}
 		break
 	}
@@ -623,6 +712,7 @@ func (s *Store) DownloadStream(ctx context.Context, name string, downloadInfo *s
 	if err != nil {
 		return nil, 0, err
 	}
+	storeURL = s.failoverDownloadURL(storeURL)
 
 	cdnHeader, err := s.cdnHeader()
 	if err != nil {
@@ -714,7 +804,7 @@ func (s *Store) applyDeltaImpl(name string, deltaPath string, deltaInfo *snap.De
 		if err := os.Remove(partialTargetPath); err != nil {
 			logger.Noticef("failed to remove partial delta target %q: %s", partialTargetPath, err)
 		}
-		return HashError{name, sha3_384, targetSha3_384}
+		return HashError{name: name, sha3_384: sha3_384, targetSha3_384: targetSha3_384}
 	}
 
 	if err := os.Rename(partialTargetPath, targetPath); err != nil {
@@ -728,7 +818,10 @@ func (s *Store) applyDeltaImpl(name string, deltaPath string, deltaInfo *snap.De
 func (s *Store) downloadAndApplyDelta(name, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, user *auth.UserState, dlOpts *DownloadOptions) error {
 	deltaInfo := &downloadInfo.Deltas[0]
 
-	deltaPath := fmt.Sprintf("%s.%s-%d-to-%d.partial", targetPath, deltaInfo.Format, deltaInfo.FromRevision, deltaInfo.ToRevision)
+	deltaPath := fmt.Sprintf("%s.%s-%d-to-%d.partial", dlOpts.stagingPath(targetPath), deltaInfo.Format, deltaInfo.FromRevision, deltaInfo.ToRevision)
+	if err := os.MkdirAll(filepath.Dir(deltaPath), 0755); err != nil {
+		return err
+	}
 	deltaName := fmt.Sprintf(i18n.G("%s (delta)"), name)
 
 	w, err := os.OpenFile(deltaPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
@@ -0,0 +1,70 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+)
+
+// BenchmarkDownloadAndVerify measures the throughput of downloading a snap
+// from a local server and verifying its sha3-384 digest.
+func BenchmarkDownloadAndVerify(b *testing.B) {
+	const size = 16 * 1024 * 1024
+	payload := bytes.Repeat([]byte("snapd"), size/5)
+	h := crypto.SHA3_384.New()
+	h.Write(payload)
+	digest := fmt.Sprintf("%x", h.Sum(nil))
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer mockServer.Close()
+
+	sto := store.New(&store.Config{}, nil)
+	downloadInfo := &snap.DownloadInfo{
+		DownloadURL: mockServer.URL,
+		Sha3_384:    digest,
+		Size:        int64(len(payload)),
+	}
+	targetFn := filepath.Join(b.TempDir(), "foo_1.0_all.snap")
+
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sto.Download(context.Background(), "foo", targetFn, downloadInfo, nil, nil, nil); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		if err := os.Remove(targetFn); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -31,17 +32,18 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"golang.org/x/crypto/sha3"
 	. "gopkg.in/check.v1"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
@@ -75,12 +77,13 @@ func (s *storeDownloadSuite) SetUpTest(c *C) {
 	s.mockXDelta = testutil.MockCommand(c, "xdelta3", "")
 	s.AddCleanup(s.mockXDelta.Restore)
 
-	store.MockDownloadRetryStrategy(&s.BaseTest, retry.LimitCount(5, retry.LimitTime(1*time.Second,
-		retry.Exponential{
+	store.MockDownloadRetryPolicy(&s.BaseTest, retryutil.Policy{
+		Strategy: retryutil.Exponential{
 			Initial: 1 * time.Millisecond,
 			Factor:  1,
 		},
-	)))
+		Limits: retryutil.Limits{MaxAttempts: 5, MaxElapsed: 1 * time.Second},
+	})
 }
 
 func (s *storeDownloadSuite) TestDownloadOK(c *C) {
@@ -106,6 +109,60 @@ func (s *storeDownloadSuite) TestDownloadOK(c *C) {
 	c.Assert(path, testutil.FileEquals, expectedContent)
 }
 
+func (s *storeDownloadSuite) TestDownloadStagingDir(c *C) {
+	expectedContent := []byte("I was downloaded")
+	stagingDir := filepath.Join(c.MkDir(), "staging")
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+
+	restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		c.Check(w.(*os.File).Name(), Equals, filepath.Join(stagingDir, "downloaded-file.partial"))
+		w.Write(expectedContent)
+		return nil
+	})
+	defer restore()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = "URL"
+	snap.Size = int64(len(expectedContent))
+
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{StagingDir: stagingDir})
+	c.Assert(err, IsNil)
+
+	c.Check(path, testutil.FileEquals, expectedContent)
+	c.Check(filepath.Join(stagingDir, "downloaded-file.partial"), testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) TestDownloadStagingDirOtherFilesystem(c *C) {
+	expectedContent := []byte("I was downloaded")
+	stagingDir := c.MkDir()
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+
+	restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		w.Write(expectedContent)
+		return nil
+	})
+	defer restore()
+	renames := 0
+	restore = store.MockOsRename(func(oldpath, newpath string) error {
+		renames++
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	})
+	defer restore()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = "URL"
+	snap.Size = int64(len(expectedContent))
+
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{StagingDir: stagingDir})
+	c.Assert(err, IsNil)
+
+	c.Check(renames, Equals, 1)
+	c.Check(path, testutil.FileEquals, expectedContent)
+	c.Check(filepath.Join(stagingDir, "downloaded-file.partial"), testutil.FileAbsent)
+}
+
 func (s *storeDownloadSuite) TestDownloadRangeRequest(c *C) {
 	partialContentStr := "partial content "
 	missingContentStr := "was downloaded"
@@ -190,11 +247,14 @@ func (s *storeDownloadSuite) TestDownloadEOFHandlesResumeHashCorrectly(c *C) {
 	snap.Sha3_384 = fmt.Sprintf("%x", h.Sum(nil))
 	snap.Size = 50000
 
+	downloadedBefore := store.DownloadedBytes.Value()
 	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
 	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
 	c.Assert(err, IsNil)
 	c.Assert(targetFn, testutil.FileEquals, buf)
 	c.Assert(s.logbuf.String(), Matches, "(?s).*Retrying .* attempt 2, .*")
+	// both the interrupted and the resumed downloads are counted
+	c.Check(store.DownloadedBytes.Value()-downloadedBefore, Equals, float64(len(buf)))
 }
 
 func (s *storeDownloadSuite) TestDownloadRetryHashErrorIsFullyRetried(c *C) {
@@ -341,6 +401,150 @@ func (s *storeDownloadSuite) TestDownloadRetryHashErrorIsFullyRetriedOnlyOnce(c
 	c.Assert(n, Equals, 2)
 }
 
+func (s *storeDownloadSuite) TestDownloadHashErrorQuarantinedAndRetriedWithoutCDN(c *C) {
+	n := 0
+	var mockServer *httptest.Server
+
+	buf := []byte("good snap content")
+	h := crypto.SHA3_384.New()
+	io.Copy(h, bytes.NewBuffer(buf))
+
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Header.Get("X-Request-Id"), Not(Equals), "")
+		switch n {
+		case 1:
+			c.Check(r.Header.Get("Snap-CDN"), Not(Equals), "none")
+			w.Header().Set("X-Request-Id", "cdn-request-id")
+			io.WriteString(w, "poisoned content!")
+		case 2:
+			c.Check(r.Header.Get("Snap-CDN"), Equals, "none")
+			w.Write(buf)
+		default:
+			c.Fatalf("unexpected request")
+		}
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	restore := store.MockTimeNow(func() time.Time {
+		return time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = fmt.Sprintf("%x", h.Sum(nil))
+	snap.Size = int64(len(buf))
+
+	var mismatches []*store.DownloadMismatch
+	dlOpts := &store.DownloadOptions{
+		OnHashMismatch: func(m *store.DownloadMismatch) {
+			mismatches = append(mismatches, m)
+		},
+	}
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, dlOpts)
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 2)
+	c.Check(targetFn, testutil.FileEquals, buf)
+
+	quarantined := filepath.Join(dirs.SnapDownloadQuarantineDir, "20230501T100000.000000000Z_foo")
+	c.Check(quarantined+".blob", testutil.FileEquals, "poisoned content!")
+	c.Assert(mismatches, HasLen, 1)
+	c.Check(mismatches[0].Name, Equals, "foo")
+	c.Check(mismatches[0].URL, Equals, mockServer.URL)
+	c.Check(mismatches[0].RequestID, Equals, "cdn-request-id")
+	c.Check(mismatches[0].ExpectedSha3_384, Equals, snap.Sha3_384)
+	c.Check(mismatches[0].Size, Equals, int64(len("poisoned content!")))
+	c.Check(mismatches[0].QuarantinePath, Equals, quarantined+".blob")
+
+	var meta map[string]interface{}
+	data, err := os.ReadFile(quarantined + ".json")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(data, &meta), IsNil)
+	c.Check(meta["request-id"], Equals, "cdn-request-id")
+	c.Check(meta["url"], Equals, mockServer.URL)
+	c.Check(meta["expected-sha3-384"], Equals, snap.Sha3_384)
+}
+
+func (s *storeDownloadSuite) TestDownloadHashErrorQuarantineIsBounded(c *C) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "something invalid")
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	// room for three blobs
+	restore := store.MockQuarantineMaxSize(3*int64(len("something invalid")) + 1)
+	defer restore()
+	i := 0
+	restore = store.MockTimeNow(func() time.Time {
+		i++
+		return time.Date(2023, 5, 1, 10, 0, i, 0, time.UTC)
+	})
+	defer restore()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = "invalid-hash"
+	snap.Size = int64(len("something invalid"))
+
+	for j := 0; j < 2; j++ {
+		targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+		err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+		c.Assert(err, FitsTypeOf, store.HashError{})
+	}
+
+	// each download is retried once, only the newest three blobs are kept
+	blobs, err := filepath.Glob(filepath.Join(dirs.SnapDownloadQuarantineDir, "*.blob"))
+	c.Assert(err, IsNil)
+	c.Check(blobs, DeepEquals, []string{
+		filepath.Join(dirs.SnapDownloadQuarantineDir, "20230501T100002.000000000Z_foo.blob"),
+		filepath.Join(dirs.SnapDownloadQuarantineDir, "20230501T100003.000000000Z_foo.blob"),
+		filepath.Join(dirs.SnapDownloadQuarantineDir, "20230501T100004.000000000Z_foo.blob"),
+	})
+	metas, err := filepath.Glob(filepath.Join(dirs.SnapDownloadQuarantineDir, "*.json"))
+	c.Assert(err, IsNil)
+	c.Check(metas, HasLen, 3)
+}
+
+func (s *storeDownloadSuite) TestDownloadHashErrorTooLargeForQuarantine(c *C) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "something invalid")
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	restore := store.MockQuarantineMaxSize(int64(len("something invalid")) - 1)
+	defer restore()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = "invalid-hash"
+	snap.Size = int64(len("something invalid"))
+
+	var mismatches []*store.DownloadMismatch
+	dlOpts := &store.DownloadOptions{
+		OnHashMismatch: func(m *store.DownloadMismatch) {
+			mismatches = append(mismatches, m)
+		},
+	}
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, dlOpts)
+	c.Assert(err, FitsTypeOf, store.HashError{})
+
+	// the mismatches are reported but nothing is kept
+	c.Assert(mismatches, HasLen, 2)
+	c.Check(mismatches[0].QuarantinePath, Equals, "")
+	entries, err := filepath.Glob(filepath.Join(dirs.SnapDownloadQuarantineDir, "*"))
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+}
+
 func (s *storeDownloadSuite) TestDownloadRangeRequestRetryOnHashError(c *C) {
 	expectedContentStr := "file was downloaded from scratch"
 	partialContentStr := "partial content "
@@ -40,7 +40,6 @@ import (
 
 	. "gopkg.in/check.v1"
 	"gopkg.in/macaroon.v1"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/advisor"
 	"github.com/snapcore/snapd/arch"
@@ -51,6 +50,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/channel"
 	"github.com/snapcore/snapd/snapdenv"
@@ -247,6 +247,12 @@ type testDauthContext struct {
 
 	storeOffline bool
 
+	backupProxyStoreURL *url.URL
+	failoverTimeout     time.Duration
+
+	warningsMu sync.Mutex
+	warnings   []string
+
 	cloudInfo *auth.CloudInfo
 }
 
@@ -321,6 +327,22 @@ func (dac *testDauthContext) StoreOffline() (bool, error) {
 	return dac.storeOffline, nil
 }
 
+func (dac *testDauthContext) BackupProxyStoreParams() (*url.URL, time.Duration, error) {
+	return dac.backupProxyStoreURL, dac.failoverTimeout, nil
+}
+
+func (dac *testDauthContext) Warnf(format string, v ...interface{}) {
+	dac.warningsMu.Lock()
+	defer dac.warningsMu.Unlock()
+	dac.warnings = append(dac.warnings, fmt.Sprintf(format, v...))
+}
+
+func (dac *testDauthContext) Warnings() []string {
+	dac.warningsMu.Lock()
+	defer dac.warningsMu.Unlock()
+	return dac.warnings
+}
+
 func (dac *testDauthContext) CloudInfo() (*auth.CloudInfo, error) {
 	return dac.cloudInfo, nil
 }
@@ -409,12 +431,13 @@ func (s *baseStoreSuite) SetUpTest(c *C) {
 	s.user, err = createTestUser(1, root, discharge)
 	c.Assert(err, IsNil)
 
-	store.MockDefaultRetryStrategy(&s.BaseTest, retry.LimitCount(5, retry.LimitTime(1*time.Second,
-		retry.Exponential{
+	store.MockDefaultRetryPolicy(&s.BaseTest, retryutil.Policy{
+		Strategy: retryutil.Exponential{
 			Initial: 1 * time.Millisecond,
 			Factor:  1,
 		},
-	)))
+		Limits: retryutil.Limits{MaxAttempts: 5, MaxElapsed: 1 * time.Second},
+	})
 }
 
 type storeTestSuite struct {
@@ -2895,6 +2918,46 @@ func (s *storeTestSuite) TestFindV2ErrorList(c *C) {
 	c.Check(err, ErrorMatches, `api error occurred`)
 }
 
+func (s *storeTestSuite) TestFindV2PublisherAndInterface(c *C) {
+	n := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertRequest(c, r, "GET", findPath)
+		query := r.URL.Query()
+		switch n {
+		case 0:
+			c.Check(query.Get("publisher"), Equals, "canonical")
+			c.Check(query.Get("interface"), Equals, "")
+		case 1:
+			c.Check(query.Get("publisher"), Equals, "")
+			c.Check(query.Get("interface"), Equals, "content")
+			c.Check(query.Get("q"), Equals, "gtk")
+		default:
+			c.Fatalf("what? %d", n)
+		}
+		n++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		io.WriteString(w, `{"results": []}`)
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	mockServerURL, _ := url.Parse(mockServer.URL)
+	cfg := store.Config{
+		StoreBaseURL: mockServerURL,
+		FindFields:   []string{},
+	}
+	sto := store.New(&cfg, nil)
+	for _, search := range []store.Search{
+		{Publisher: "canonical"},
+		{Query: "gtk", Interface: "content"},
+	} {
+		_, err := sto.Find(s.ctx, &search, nil)
+		c.Check(err, IsNil)
+	}
+	c.Check(n, Equals, 2)
+}
+
 func (s *storeTestSuite) TestFindFailures(c *C) {
 	// bad query check is done early in Find(), so the test covers both search
 	// v1 & v2
@@ -4339,10 +4402,13 @@ func (s *storeTestSuite) TestConnectivityCheckHappy(c *C) {
 }
 
 func (s *storeTestSuite) TestConnectivityCheckUnhappy(c *C) {
-	store.MockConnCheckStrategy(&s.BaseTest, retry.LimitCount(3, retry.Exponential{
-		Initial: time.Millisecond,
-		Factor:  1.3,
-	}))
+	store.MockConnCheckPolicy(&s.BaseTest, retryutil.Policy{
+		Strategy: retryutil.Exponential{
+			Initial: time.Millisecond,
+			Factor:  1.3,
+		},
+		Limits: retryutil.Limits{MaxAttempts: 3},
+	})
 
 	seenPaths := make(map[string]int, 2)
 	var mockServerURL *url.URL
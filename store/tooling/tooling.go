@@ -54,6 +54,10 @@ type ToolingStore struct {
 	cfg *store.Config
 
 	assertMaxFormats map[string]int
+
+	// localAsserts holds assertions provided locally which are used in
+	// preference to the ones from the store
+	localAsserts asserts.Backstore
 }
 
 // A StoreImpl can find metadata on snaps, download snaps and fetch assertions.
@@ -287,7 +291,13 @@ type CurrentSnap struct {
 
 type DownloadManyOptions struct {
 	BeforeDownloadFunc func(*snap.Info) (targetPath string, err error)
-	EnforceValidation  bool
+	// AfterDownloadFunc, if set, is called after each snap has been
+	// downloaded (or found already present with the right digest).
+	AfterDownloadFunc func(*DownloadedSnap) error
+	EnforceValidation bool
+	// LeavePartialOnError leaves the partial download of a snap in
+	// place if it fails, so that it can be resumed later.
+	LeavePartialOnError bool
 }
 
 // DownloadMany downloads the specified snaps.
@@ -357,11 +367,18 @@ func (tsto *ToolingStore) DownloadMany(toDownload []SnapToDownload, curSnaps []*
 		if err != nil {
 			return nil, err
 		}
-		dlSnap, err := tsto.snapDownload(targetPath, &sar, DownloadSnapOptions{})
+		dlSnap, err := tsto.snapDownload(targetPath, &sar, DownloadSnapOptions{
+			LeavePartialOnError: opts.LeavePartialOnError,
+		})
 		if err != nil {
 			return nil, err
 		}
 		downloadedSnaps[sar.SnapName()] = dlSnap
+		if opts.AfterDownloadFunc != nil {
+			if err := opts.AfterDownloadFunc(dlSnap); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return downloadedSnaps, nil
@@ -371,7 +388,7 @@ func (tsto *ToolingStore) DownloadMany(toDownload []SnapToDownload, curSnaps []*
 // add assertions in the given database and after that also call save for each of them.
 func (tsto *ToolingStore) AssertionFetcher(db *asserts.Database, save func(asserts.Assertion) error) asserts.Fetcher {
 	retrieve := func(ref *asserts.Ref) (asserts.Assertion, error) {
-		return tsto.sto.Assertion(ref.Type, ref.PrimaryKey, nil)
+		return tsto.assertion(ref.Type, ref.PrimaryKey)
 	}
 	save2 := func(a asserts.Assertion) error {
 		// for checking
@@ -392,7 +409,7 @@ func (tsto *ToolingStore) AssertionFetcher(db *asserts.Database, save func(asser
 // given db and call save for each of them.
 func (tsto *ToolingStore) AssertionSequenceFormingFetcher(db *asserts.Database, save func(asserts.Assertion) error) asserts.SequenceFormingFetcher {
 	retrieve := func(ref *asserts.Ref) (asserts.Assertion, error) {
-		return tsto.sto.Assertion(ref.Type, ref.PrimaryKey, nil)
+		return tsto.assertion(ref.Type, ref.PrimaryKey)
 	}
 	retrieveSeq := func(seq *asserts.AtSequence) (asserts.Assertion, error) {
 		return tsto.sto.SeqFormingAssertion(seq.Type, seq.SequenceKey, seq.Sequence, nil)
@@ -417,7 +434,40 @@ func (tsto *ToolingStore) Find(at *asserts.AssertionType, headers map[string]str
 	if err != nil {
 		return nil, err
 	}
-	return tsto.sto.Assertion(at, pk, nil)
+	return tsto.assertion(at, pk)
+}
+
+// AddLocalAssertions makes the tooling store use the given assertions, for
+// example the snap-declaration and snap-revision assertions of local snaps,
+// in preference to fetching them from the store.
+func (tsto *ToolingStore) AddLocalAssertions(as []asserts.Assertion) error {
+	if tsto.localAsserts == nil {
+		tsto.localAsserts = asserts.NewMemoryBackstore()
+	}
+	for _, a := range as {
+		if err := tsto.localAsserts.Put(a.Type(), a); err != nil {
+			if _, ok := err.(*asserts.RevisionError); ok {
+				continue
+			}
+			return fmt.Errorf("cannot add local assertion %v: %v", a.Ref(), err)
+		}
+	}
+	return nil
+}
+
+// assertion returns the local assertion with the given type and primary key
+// if there is one, otherwise the one from the store.
+func (tsto *ToolingStore) assertion(assertType *asserts.AssertionType, primaryKey []string) (asserts.Assertion, error) {
+	if tsto.localAsserts != nil {
+		a, err := tsto.localAsserts.Get(assertType, primaryKey, assertType.MaxSupportedFormat())
+		if err == nil {
+			return a, nil
+		}
+		if !errors.Is(err, &asserts.NotFoundError{}) {
+			return nil, err
+		}
+	}
+	return tsto.sto.Assertion(assertType, primaryKey, nil)
 }
 
 // SetAssertionMaxFormats sets the assertion max formats to use with Assertion and SnapAction.
@@ -22,6 +22,7 @@ package tooling_test
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -42,6 +43,7 @@ import (
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/seed/seedtest"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/store/tooling"
 	"github.com/snapcore/snapd/testutil"
@@ -56,6 +58,7 @@ type toolingSuite struct {
 	storeActionsBunchSizes []int
 	storeActions           []*store.SnapAction
 	curSnaps               [][]*store.CurrentSnap
+	downloadOpts           []*store.DownloadOptions
 
 	assertMaxFormats map[string]int
 
@@ -393,6 +396,50 @@ func (s *toolingSuite) TestDownloadSnap(c *C) {
 	c.Check(logbuf.String(), Matches, `.* DEBUG: Going to download snap "core" `+opts.String()+".\n")
 }
 
+func (s *toolingSuite) TestDownloadManyAfterDownload(c *C) {
+	s.setupSnaps(c, map[string]string{
+		"core": "canonical",
+	}, "")
+
+	dlDir := c.MkDir()
+	targetPath := filepath.Join(dlDir, "core_3.snap")
+	var after []*tooling.DownloadedSnap
+	dlSnaps, err := s.tsto.DownloadMany([]tooling.SnapToDownload{{
+		Snap: naming.Snap("core"),
+	}}, nil, tooling.DownloadManyOptions{
+		BeforeDownloadFunc: func(info *snap.Info) (string, error) {
+			return targetPath, nil
+		},
+		AfterDownloadFunc: func(dlSnap *tooling.DownloadedSnap) error {
+			c.Check(dlSnap.Path, testutil.FilePresent)
+			after = append(after, dlSnap)
+			return nil
+		},
+		LeavePartialOnError: true,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(after, HasLen, 1)
+	c.Check(after[0], Equals, dlSnaps["core"])
+	c.Check(after[0].Path, Equals, targetPath)
+	c.Assert(s.downloadOpts, HasLen, 1)
+	c.Check(s.downloadOpts[0].LeavePartialOnError, Equals, true)
+
+	// an error from the hook is returned
+	os.Remove(targetPath)
+	_, err = s.tsto.DownloadMany([]tooling.SnapToDownload{{
+		Snap: naming.Snap("core"),
+	}}, nil, tooling.DownloadManyOptions{
+		BeforeDownloadFunc: func(info *snap.Info) (string, error) {
+			return targetPath, nil
+		},
+		AfterDownloadFunc: func(dlSnap *tooling.DownloadedSnap) error {
+			return fmt.Errorf("boom")
+		},
+	})
+	c.Check(err, ErrorMatches, "boom")
+	c.Check(s.downloadOpts[1].LeavePartialOnError, Equals, false)
+}
+
 func (s *toolingSuite) TestSetAssertionMaxFormats(c *C) {
 	c.Check(s.tsto.AssertionMaxFormats(), IsNil)
 
@@ -450,6 +497,7 @@ func (s *toolingSuite) SnapAction(_ context.Context, curSnaps []*store.CurrentSn
 }
 
 func (s *toolingSuite) Download(ctx context.Context, name, targetFn string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, user *auth.UserState, dlOpts *store.DownloadOptions) error {
+	s.downloadOpts = append(s.downloadOpts, dlOpts)
 	return osutil.CopyFile(s.AssertedSnap(name), targetFn, 0)
 }
 
@@ -563,3 +611,38 @@ func (s *toolingSuite) TestAssertionSequenceFormingFetcherSimple(c *C) {
 	c.Check(vsa.(*asserts.ValidationSet).Name(), Equals, "base-set")
 	c.Check(vsa.(*asserts.ValidationSet).Sequence(), Equals, 1)
 }
+
+func (s *toolingSuite) TestAssertionFetcherPrefersLocalAssertions(c *C) {
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   s.StoreSigning.Trusted,
+	})
+	c.Assert(err, IsNil)
+
+	// an account only known locally
+	acct := assertstest.NewAccount(s.StoreSigning, "local-dev", nil, "")
+	c.Assert(s.tsto.AddLocalAssertions([]asserts.Assertion{acct}), IsNil)
+
+	var saved []*asserts.Ref
+	f := s.tsto.AssertionFetcher(db, func(a asserts.Assertion) error {
+		saved = append(saved, a.Ref())
+		return nil
+	})
+	err = f.Fetch(acct.Ref())
+	c.Assert(err, IsNil)
+	c.Assert(saved, Not(HasLen), 0)
+	c.Check(saved[len(saved)-1], DeepEquals, acct.Ref())
+
+	// the local assertion can also be found
+	a, err := s.tsto.Find(asserts.AccountType, map[string]string{
+		"account-id": acct.AccountID(),
+	})
+	c.Assert(err, IsNil)
+	c.Check(a.Ref(), DeepEquals, acct.Ref())
+
+	// others still come from the store
+	_, err = s.tsto.Find(asserts.AccountType, map[string]string{
+		"account-id": "missing",
+	})
+	c.Check(errors.Is(err, &asserts.NotFoundError{}), Equals, true)
+}
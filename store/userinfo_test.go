@@ -27,9 +27,9 @@ import (
 	"time"
 
 	"gopkg.in/check.v1"
-	"gopkg.in/retry.v1"
 
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -67,12 +67,13 @@ func (t *userInfoSuite) SetUpTest(c *check.C) {
 	_, t.restoreLogger = logger.MockLogger()
 	t.store = store.New(nil, nil)
 
-	store.MockDefaultRetryStrategy(&t.BaseTest, retry.LimitCount(6, retry.LimitTime(1*time.Second,
-		retry.Exponential{
+	store.MockDefaultRetryPolicy(&t.BaseTest, retryutil.Policy{
+		Strategy: retryutil.Exponential{
 			Initial: 1 * time.Millisecond,
 			Factor:  1.1,
 		},
-	)))
+		Limits: retryutil.Limits{MaxAttempts: 6, MaxElapsed: 1 * time.Second},
+	})
 }
 
 func (t *userInfoSuite) TearDownTest(c *check.C) {
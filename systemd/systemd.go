@@ -269,22 +269,62 @@ func EnsureAtLeast(requiredVersion int) error {
 
 var osutilStreamCommand = osutil.StreamCommand
 
+// LogOptions holds the options for reading the journal of services.
+type LogOptions struct {
+	// N is the number of lines to return initially, all of them if
+	// it is negative.
+	N int
+	// Follow makes the reader follow the log as it grows.
+	Follow bool
+	// Namespaces makes the reader include journal namespace logs, this
+	// is required to get logs for services which are in journal
+	// namespaces.
+	Namespaces bool
+	// Priority, if set, limits the log to entries of at least the
+	// given syslog priority, see ValidateLogPriority.
+	Priority string
+	// AfterCursor, if set, makes the reader start right after the
+	// journal entry with the given cursor.
+	AfterCursor string
+}
+
+var logPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// ValidateLogPriority checks that the given priority is a syslog
+// priority as understood by journalctl, either by name (e.g. "err")
+// or by number (0 to 7).
+func ValidateLogPriority(priority string) error {
+	if strutil.ListContains(logPriorities, priority) {
+		return nil
+	}
+	if n, err := strconv.Atoi(priority); err == nil && n >= 0 && n < len(logPriorities) {
+		return nil
+	}
+	return fmt.Errorf("invalid log priority %q, expected one of %s or 0 to %d", priority, strings.Join(logPriorities, ", "), len(logPriorities)-1)
+}
+
 // jctl calls journalctl to get the JSON logs of the given services.
-var jctl = func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
+var jctl = func(svcs []string, opts *LogOptions) (io.ReadCloser, error) {
 	// args will need two entries per service, plus a fixed number (give or take
 	// one) for the initial options.
-	args := make([]string, 0, 2*len(svcs)+7)        // We have at most 7 extra arguments
+	args := make([]string, 0, 2*len(svcs)+9)        // We have at most 9 extra arguments
 	args = append(args, "-o", "json", "--no-pager") //   3...
-	if n < 0 {
+	if opts.N < 0 {
 		args = append(args, "--no-tail") // < 2
 	} else {
-		args = append(args, "-n", strconv.Itoa(n)) // ... + 2 ...
+		args = append(args, "-n", strconv.Itoa(opts.N)) // ... + 2 ...
+	}
+	if opts.Follow {
+		args = append(args, "-f") // ... + 1 ...
 	}
-	if follow {
-		args = append(args, "-f") // ... + 1 == 6
+	if opts.Namespaces {
+		args = append(args, "--namespace=*") // ... + 1 ...
 	}
-	if namespaces {
-		args = append(args, "--namespace=*") // ... + 1 == 7
+	if opts.Priority != "" {
+		args = append(args, "--priority="+opts.Priority) // ... + 1 ...
+	}
+	if opts.AfterCursor != "" {
+		args = append(args, "--after-cursor="+opts.AfterCursor) // ... + 1 == 9
 	}
 
 	for i := range svcs {
@@ -294,7 +334,7 @@ var jctl = func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, e
 	return osutilStreamCommand("journalctl", args...)
 }
 
-func MockJournalctl(f func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error)) func() {
+func MockJournalctl(f func(svcs []string, opts *LogOptions) (io.ReadCloser, error)) func() {
 	oldJctl := jctl
 	jctl = f
 	return func() {
@@ -377,16 +417,16 @@ type Systemd interface {
 	// unit's transition to inactive.
 	// TODO: incorporate this result into Status instead?
 	InactiveEnterTimestamp(unit string) (time.Time, error)
+	// ServiceResult returns how the main process of the given service
+	// last exited and how many times it was restarted automatically.
+	ServiceResult(service string) (*ServiceResult, error)
 	// IsEnabled checks whether the given service is enabled.
 	IsEnabled(service string) (bool, error)
 	// IsActive checks whether the given service is Active
 	IsActive(service string) (bool, error)
-	// LogReader returns a reader for the given services' log.
-	// If follow is set to true, the reader returned will follow the log
-	// as it grows.
-	// If namespaces is set to true, the log reader will include journal namespace
-	// logs, and is required to get logs for services which are in journal namespaces.
-	LogReader(services []string, n int, follow, namespaces bool) (io.ReadCloser, error)
+	// LogReader returns a reader for the given services' log, see
+	// LogOptions for the details of what is read.
+	LogReader(services []string, opts *LogOptions) (io.ReadCloser, error)
 	// EnsureMountUnitFile adds/enables/starts a mount unit.
 	EnsureMountUnitFile(name, revision, what, where, fstype string) (string, error)
 	// EnsureMountUnitFileWithOptions adds/enables/starts a mount unit with options.
@@ -643,8 +683,8 @@ func (s *systemd) StartNoBlock(serviceNames []string) error {
 	return err
 }
 
-func (*systemd) LogReader(serviceNames []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
-	return jctl(serviceNames, n, follow, namespaces)
+func (*systemd) LogReader(serviceNames []string, opts *LogOptions) (io.ReadCloser, error) {
+	return jctl(serviceNames, opts)
 }
 
 var statusregex = regexp.MustCompile(`(?m)^(?:(.+?)=(.*)|(.*))?$`)
@@ -663,6 +703,8 @@ type UnitStatus struct {
 	Names   []string
 	Enabled bool
 	Active  bool
+	// Failed is true if the unit is in the failed state.
+	Failed bool
 	// Installed is false if the queried unit doesn't exist.
 	Installed bool
 	// NeedDaemonReload is true when systemd reports that the unit on disk
@@ -770,6 +812,7 @@ func (s *systemd) getUnitStatus(properties []string, unitNames []string) ([]*Uni
 		case "ActiveState":
 			// made to match “systemctl is-active” behaviour, at least at systemd 229
 			cur.Active = v == "active" || v == "reloading"
+			cur.Failed = v == "failed"
 		case "UnitFileState":
 			// "static" means it can't be disabled
 			cur.Enabled = v == "enabled" || v == "static"
@@ -911,6 +954,67 @@ func (s *systemd) InactiveEnterTimestamp(unit string) (time.Time, error) {
 	return inactiveEnterTime, nil
 }
 
+// ServiceResult describes how the main process of a service last exited.
+type ServiceResult struct {
+	// Result is "success" or the reason of the last failure of the
+	// service, e.g. "exit-code", "signal", "timeout" or "watchdog".
+	Result string
+	// ExitCode is the exit code, or signal number, of the main process
+	// when it last exited.
+	ExitCode int
+	// Restarts is how many times the service was restarted
+	// automatically since it was last started explicitly.
+	Restarts int
+}
+
+// Failed returns whether the service failed the last time it exited, or
+// had to be restarted.
+func (r *ServiceResult) Failed() bool {
+	return r.Result != "success" || r.Restarts > 0
+}
+
+func (s *systemd) ServiceResult(service string) (*ServiceResult, error) {
+	if s.mode == GlobalUserMode {
+		return nil, fmt.Errorf("cannot get the result of service %q in global user mode", service)
+	}
+	out, err := s.systemctl("show", "--property=Result,ExecMainStatus,NRestarts", service)
+	if err != nil {
+		return nil, osutil.OutputErr(out, err)
+	}
+	res := &ServiceResult{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("cannot get result of service %q: bad line %q in ‘systemctl show’ output", service, line)
+		}
+		k, v := kv[0], strings.TrimSpace(kv[1])
+		switch k {
+		case "Result":
+			res.Result = v
+		case "ExecMainStatus", "NRestarts":
+			// older versions of systemd do not know about NRestarts
+			if v == "" || v == "[not set]" {
+				continue
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get result of service %q: invalid %s %q", service, k, v)
+			}
+			if k == "ExecMainStatus" {
+				res.ExitCode = n
+			} else {
+				res.Restarts = n
+			}
+		default:
+			return nil, fmt.Errorf("cannot get result of service %q: unexpected field %q in ‘systemctl show’ output", service, k)
+		}
+	}
+	if res.Result == "" {
+		return nil, fmt.Errorf("cannot get result of service %q: missing Result in ‘systemctl show’ output", service)
+	}
+	return res, nil
+}
+
 func (s *systemd) Status(unitNames []string) ([]*UnitStatus, error) {
 	if s.mode == GlobalUserMode {
 		return s.getGlobalUserStatus(unitNames...)
@@ -1303,6 +1407,17 @@ func (l Log) SID() string {
 	return sid
 }
 
+// Cursor is the journal cursor of the Log, if any; otherwise, "".
+func (l Log) Cursor() string {
+	cursor, err := l.parseLogRawMessageString("__CURSOR", func([]string) (string, error) {
+		return "", fmt.Errorf("multiple cursors not supported")
+	})
+	if err != nil {
+		return ""
+	}
+	return cursor
+}
+
 // PID is the pid of the client pid, if any; otherwise, "-".
 func (l Log) PID() string {
 	// look for _PID first as that is underscored and thus "trusted" from
@@ -1462,6 +1577,13 @@ func hostFsTypeAndMountOptions(fstype string) (hostFsType string, options []stri
 	return hostFsType, options
 }
 
+// KernelSquashfsMountOptions returns the options of a mount unit for a
+// squashfs image that must be mounted by the kernel implementation, as it
+// is the case for images protected by dm-verity.
+func KernelSquashfsMountOptions() []string {
+	return append(fsMountOptions("squashfs"), squashfs.StandardOptions()...)
+}
+
 func (s *systemd) EnsureMountUnitFile(snapName, revision, what, where, fstype string) (string, error) {
 	hostFsType, options := hostFsTypeAndMountOptions(fstype)
 	if osutil.IsDirectory(what) {
@@ -164,14 +164,14 @@ func (s *SystemdTestSuite) myRun(args ...string) (out []byte, delay time.Duratio
 	return out, delayReq, err
 }
 
-func (s *SystemdTestSuite) myJctl(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
+func (s *SystemdTestSuite) myJctl(svcs []string, opts *LogOptions) (io.ReadCloser, error) {
 	var err error
 	var out []byte
 
-	s.jns = append(s.jns, strconv.Itoa(n))
+	s.jns = append(s.jns, strconv.Itoa(opts.N))
 	s.jsvcs = append(s.jsvcs, svcs)
-	s.jfollows = append(s.jfollows, follow)
-	s.jnamespaces = append(s.jnamespaces, namespaces)
+	s.jfollows = append(s.jfollows, opts.Follow)
+	s.jnamespaces = append(s.jnamespaces, opts.Namespaces)
 
 	if s.j < len(s.jouts) {
 		out = s.jouts[s.j]
@@ -461,7 +461,7 @@ NeedDaemonReload=no
 Type=potato
 Id=baz.service
 Names=baz.service
-ActiveState=inactive
+ActiveState=failed
 UnitFileState=disabled
 NeedDaemonReload=yes
 
@@ -530,6 +530,7 @@ NeedDaemonReload=yes
 			Name:             "baz.service",
 			Names:            []string{"baz.service"},
 			Active:           false,
+			Failed:           true,
 			Enabled:          false,
 			Installed:        true,
 			Id:               "baz.service",
@@ -926,7 +927,7 @@ func (s *SystemdTestSuite) TestKill(c *C) {
 func (s *SystemdTestSuite) TestLogErrJctl(c *C) {
 	s.jerrs = []error{errors.New("mock journalctl error")}
 
-	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false)
+	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, &LogOptions{N: 24})
 	c.Check(err, NotNil)
 	c.Check(reader, IsNil)
 	c.Check(s.jns, DeepEquals, []string{"24"})
@@ -942,7 +943,7 @@ func (s *SystemdTestSuite) TestLogs(c *C) {
 `
 	s.jouts = [][]byte{[]byte(expected)}
 
-	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false)
+	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, &LogOptions{N: 24})
 	c.Check(err, IsNil)
 	logs, err := ioutil.ReadAll(reader)
 	c.Assert(err, IsNil)
@@ -1528,23 +1529,40 @@ func (s *SystemdTestSuite) TestJctl(c *C) {
 	var args []string
 	var err error
 	MockOsutilStreamCommand(func(name string, myargs ...string) (io.ReadCloser, error) {
-		c.Check(cap(myargs) <= len(myargs)+3, Equals, true, Commentf("cap:%d, len:%d", cap(myargs), len(myargs)))
+		c.Check(cap(myargs) <= len(myargs)+5, Equals, true, Commentf("cap:%d, len:%d", cap(myargs), len(myargs)))
 		args = myargs
 		return nil, nil
 	})
 
-	_, err = Jctl([]string{"foo", "bar"}, 10, false, false)
+	_, err = Jctl([]string{"foo", "bar"}, &LogOptions{N: 10})
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "10", "-u", "foo", "-u", "bar"})
-	_, err = Jctl([]string{"foo", "bar", "baz"}, 99, true, false)
+	_, err = Jctl([]string{"foo", "bar", "baz"}, &LogOptions{N: 99, Follow: true})
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "99", "-f", "-u", "foo", "-u", "bar", "-u", "baz"})
-	_, err = Jctl([]string{"foo", "bar"}, -1, false, false)
+	_, err = Jctl([]string{"foo", "bar"}, &LogOptions{N: -1})
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "--no-tail", "-u", "foo", "-u", "bar"})
-	_, err = Jctl([]string{"foo", "bar"}, -1, false, true)
+	_, err = Jctl([]string{"foo", "bar"}, &LogOptions{N: -1, Namespaces: true})
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "--no-tail", "--namespace=*", "-u", "foo", "-u", "bar"})
+	_, err = Jctl([]string{"foo"}, &LogOptions{N: -1, Follow: true, Namespaces: true, Priority: "err", AfterCursor: "s=abc;i=1"})
+	c.Assert(err, IsNil)
+	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "--no-tail", "-f", "--namespace=*", "--priority=err", "--after-cursor=s=abc;i=1", "-u", "foo"})
+}
+
+func (s *SystemdTestSuite) TestValidateLogPriority(c *C) {
+	for _, p := range []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug", "0", "3", "7"} {
+		c.Check(ValidateLogPriority(p), IsNil, Commentf("%q", p))
+	}
+	for _, p := range []string{"", "error", "8", "-1", "err..info"} {
+		c.Check(ValidateLogPriority(p), ErrorMatches, `invalid log priority ".*", expected one of emerg, alert, crit, err, warning, notice, info, debug or 0 to 7`, Commentf("%q", p))
+	}
+}
+
+func (s *SystemdTestSuite) TestLogCursor(c *C) {
+	c.Check(Log{"__CURSOR": mustJSONMarshal("s=abc;i=1")}.Cursor(), Equals, "s=abc;i=1")
+	c.Check(Log{}.Cursor(), Equals, "")
 }
 
 func (s *SystemdTestSuite) TestIsActiveUnderRoot(c *C) {
@@ -2246,6 +2264,56 @@ func (s *SystemdTestSuite) TestCurrentUsageFamilyHappy(c *C) {
 	})
 }
 
+func (s *SystemdTestSuite) TestServiceResult(c *C) {
+	s.outs = [][]byte{
+		[]byte("Result=exit-code\nExecMainStatus=1\nNRestarts=3\n"),
+		[]byte("Result=success\nExecMainStatus=0\nNRestarts=0\n"),
+		// systemd before 235 does not report NRestarts
+		[]byte("Result=signal\nExecMainStatus=9\nNRestarts=\n"),
+	}
+	sysd := New(SystemMode, s.rep)
+
+	res, err := sysd.ServiceResult("foo.service")
+	c.Assert(err, IsNil)
+	c.Check(res, DeepEquals, &ServiceResult{Result: "exit-code", ExitCode: 1, Restarts: 3})
+	c.Check(res.Failed(), Equals, true)
+
+	res, err = sysd.ServiceResult("foo.service")
+	c.Assert(err, IsNil)
+	c.Check(res, DeepEquals, &ServiceResult{Result: "success"})
+	c.Check(res.Failed(), Equals, false)
+
+	res, err = sysd.ServiceResult("foo.service")
+	c.Assert(err, IsNil)
+	c.Check(res, DeepEquals, &ServiceResult{Result: "signal", ExitCode: 9})
+
+	c.Check(s.argses, DeepEquals, [][]string{
+		{"show", "--property=Result,ExecMainStatus,NRestarts", "foo.service"},
+		{"show", "--property=Result,ExecMainStatus,NRestarts", "foo.service"},
+		{"show", "--property=Result,ExecMainStatus,NRestarts", "foo.service"},
+	})
+}
+
+func (s *SystemdTestSuite) TestServiceResultErrors(c *C) {
+	for _, t := range []struct {
+		out string
+		err string
+	}{
+		{"Result=exit-code\nExecMainStatus=x\n", `cannot get result of service "foo.service": invalid ExecMainStatus "x"`},
+		{"Result=exit-code\nFoo=1\n", `cannot get result of service "foo.service": unexpected field "Foo" in ‘systemctl show’ output`},
+		{"ExecMainStatus=1\n", `cannot get result of service "foo.service": missing Result in ‘systemctl show’ output`},
+		{"garbage", `cannot get result of service "foo.service": bad line "garbage" in ‘systemctl show’ output`},
+	} {
+		s.outs = [][]byte{[]byte(t.out)}
+		s.i = 0
+		_, err := New(SystemMode, s.rep).ServiceResult("foo.service")
+		c.Check(err, ErrorMatches, t.err)
+	}
+
+	_, err := New(GlobalUserMode, s.rep).ServiceResult("foo.service")
+	c.Check(err, ErrorMatches, `cannot get the result of service "foo.service" in global user mode`)
+}
+
 func (s *SystemdTestSuite) TestInactiveEnterTimestampZero(c *C) {
 	s.outs = [][]byte{
 		[]byte(`InactiveEnterTimestamp=`),
@@ -0,0 +1,312 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package devicesvc implements a fake serial-vault, i.e. a device service
+// speaking the device registration protocol used by devicestate, with
+// configurable latency and failure injection.
+package devicesvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+)
+
+const (
+	// the paths used by the serial-vault, which are also the ones
+	// devicestate defaults to when using the store as device service
+	requestIDURLPath = "/api/v1/snaps/auth/request-id"
+	serialURLPath    = "/api/v1/snaps/auth/devices"
+
+	// short paths, usually set via the prepare-device hook
+	shortRequestIDURLPath = "/request-id"
+	shortSerialURLPath    = "/serial"
+
+	// the serial issued unless the proposed one is used, spread tests
+	// rely on it
+	defaultSerial = "7777"
+)
+
+// Behavior controls how the device service responds to requests.
+type Behavior struct {
+	// Latency is added before responding to any request.
+	Latency time.Duration
+	// FailCount is the number of requests that fail with FailStatus
+	// before the service starts responding normally.
+	FailCount int
+	// FailStatus is the HTTP status used for injected failures, it
+	// defaults to 503.
+	FailStatus int
+	// PollCount is the number of times a serial request is answered
+	// with 202, asking the device to poll again, before a serial is
+	// issued for it.
+	PollCount int
+	// UseProposedSerial makes the service issue the serial proposed in
+	// the serial-request, if any, instead of the default one. This can
+	// also be requested per request with the X-Use-Proposed: yes header.
+	UseProposedSerial bool
+
+	// AuthorityID is the account signing the serial assertions, it
+	// defaults to developer1.
+	AuthorityID string
+	// SigningKey is the key used to sign the serial assertions, it
+	// defaults to the developer1 test key.
+	SigningKey asserts.PrivateKey
+}
+
+// DeviceService is a fake serial-vault.
+type DeviceService struct {
+	bhv Behavior
+	db  *asserts.Database
+
+	mu       sync.Mutex
+	failures int
+	requests int
+	polls    map[string]int
+
+	addr string
+	srv  *http.Server
+	l    net.Listener
+}
+
+// New creates a new device service that will listen on the given address
+// and behave as described by bhv, which can be nil.
+func New(addr string, bhv *Behavior) (*DeviceService, error) {
+	s := &DeviceService{
+		addr:  addr,
+		polls: make(map[string]int),
+	}
+	if bhv != nil {
+		s.bhv = *bhv
+	}
+	if s.bhv.FailStatus == 0 {
+		s.bhv.FailStatus = 503
+	}
+	if s.bhv.AuthorityID == "" {
+		s.bhv.AuthorityID = "developer1"
+	}
+	if s.bhv.SigningKey == nil {
+		s.bhv.SigningKey, _ = assertstest.ReadPrivKey(assertstest.DevKey)
+	}
+
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot open signing database: %v", err)
+	}
+	if err := db.ImportKey(s.bhv.SigningKey); err != nil {
+		return nil, fmt.Errorf("cannot import signing key: %v", err)
+	}
+	s.db = db
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.rootEndpoint)
+	mux.HandleFunc(requestIDURLPath, s.requestIDEndpoint)
+	mux.HandleFunc(shortRequestIDURLPath, s.requestIDEndpoint)
+	mux.HandleFunc(serialURLPath, s.serialEndpoint)
+	mux.HandleFunc(shortSerialURLPath, s.serialEndpoint)
+	s.srv = &http.Server{Handler: mux}
+
+	return s, nil
+}
+
+// Handler returns the HTTP handler of the service, useful to serve it
+// with httptest.
+func (s *DeviceService) Handler() http.Handler {
+	return s.srv.Handler
+}
+
+// Start starts listening.
+func (s *DeviceService) Start() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.l = l
+	go s.srv.Serve(l)
+	return nil
+}
+
+// Stop stops the service.
+func (s *DeviceService) Stop() error {
+	return s.srv.Close()
+}
+
+// URL returns the base URL the service is listening on.
+func (s *DeviceService) URL() string {
+	if s.l != nil {
+		return fmt.Sprintf("http://%s", s.l.Addr())
+	}
+	return fmt.Sprintf("http://%s", s.addr)
+}
+
+// Requests returns the number of requests the service has received,
+// including the ones that failed.
+func (s *DeviceService) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func writeError(w http.ResponseWriter, status int, msg string, a ...interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error_list": []map[string]string{
+			{"message": fmt.Sprintf(msg, a...)},
+		},
+	})
+}
+
+// preflight applies the latency and failure injection, it returns false if
+// the request was answered with an injected failure.
+func (s *DeviceService) preflight(w http.ResponseWriter) bool {
+	if s.bhv.Latency > 0 {
+		time.Sleep(s.bhv.Latency)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.failures < s.bhv.FailCount {
+		s.failures++
+		writeError(w, s.bhv.FailStatus, "injected failure %d of %d", s.failures, s.bhv.FailCount)
+		return false
+	}
+	return true
+}
+
+func (s *DeviceService) rootEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	// devicestate checks the reachability of the service with a HEAD
+	// request
+	w.WriteHeader(200)
+}
+
+func (s *DeviceService) requestIDEndpoint(w http.ResponseWriter, r *http.Request) {
+	if !s.preflight(w) {
+		return
+	}
+
+	s.mu.Lock()
+	reqID := fmt.Sprintf("REQ-ID-%d", s.requests)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(map[string]string{"request-id": reqID})
+}
+
+func (s *DeviceService) serialEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, 405, "unexpected method %q", r.Method)
+		return
+	}
+	if !s.preflight(w) {
+		return
+	}
+
+	defer r.Body.Close()
+	dec := asserts.NewDecoder(r.Body)
+
+	a, err := dec.Decode()
+	if err != nil {
+		writeError(w, 400, "cannot decode request: %v", err)
+		return
+	}
+	serialReq, ok := a.(*asserts.SerialRequest)
+	if !ok {
+		writeError(w, 400, "expected serial-request")
+		return
+	}
+	if err := asserts.SignatureCheck(serialReq, serialReq.DeviceKey()); err != nil {
+		writeError(w, 400, "invalid serial-request self-signature: %v", err)
+		return
+	}
+
+	var extra []asserts.Assertion
+	for {
+		a, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, 400, "cannot decode request: %v", err)
+			return
+		}
+		extra = append(extra, a)
+	}
+	if len(extra) == 0 {
+		writeError(w, 400, "expected model after serial-request")
+		return
+	}
+	// on re-registration the model is followed by the original serial
+	mod, ok := extra[0].(*asserts.Model)
+	if !ok {
+		writeError(w, 400, "expected model after serial-request")
+		return
+	}
+	if mod.Model() != serialReq.Model() || mod.BrandID() != serialReq.BrandID() {
+		writeError(w, 400, "model and serial-request do not cross check")
+		return
+	}
+
+	s.mu.Lock()
+	reqID := serialReq.RequestID()
+	if s.polls[reqID] < s.bhv.PollCount {
+		s.polls[reqID]++
+		s.mu.Unlock()
+		w.WriteHeader(202)
+		return
+	}
+	s.mu.Unlock()
+
+	serialStr := defaultSerial
+
+	if serialReq.Serial() != "" && (s.bhv.UseProposedSerial || r.Header.Get("X-Use-Proposed") == "yes") {
+		serialStr = serialReq.Serial()
+	}
+
+	serial, err := s.db.Sign(asserts.SerialType, map[string]interface{}{
+		"authority-id":        s.bhv.AuthorityID,
+		"brand-id":            serialReq.BrandID(),
+		"model":               serialReq.Model(),
+		"serial":              serialStr,
+		"device-key":          serialReq.HeaderString("device-key"),
+		"device-key-sha3-384": serialReq.SignKeyID(),
+		"timestamp":           time.Now().Format(time.RFC3339),
+	}, serialReq.Body(), s.bhv.SigningKey.PublicKey().ID())
+	if err != nil {
+		writeError(w, 500, "cannot sign serial: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", asserts.MediaType)
+	w.WriteHeader(200)
+	w.Write(asserts.Encode(serial))
+}
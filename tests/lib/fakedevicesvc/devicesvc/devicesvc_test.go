@@ -0,0 +1,221 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicesvc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/tests/lib/fakedevicesvc/devicesvc"
+)
+
+func TestDeviceSvc(t *testing.T) { TestingT(t) }
+
+type deviceSvcSuite struct {
+	deviceKey asserts.PrivateKey
+	model     *asserts.Model
+}
+
+var _ = Suite(&deviceSvcSuite{})
+
+func (s *deviceSvcSuite) SetUpSuite(c *C) {
+	devPrivKey, _ := assertstest.ReadPrivKey(assertstest.DevKey)
+	brandSigning := assertstest.NewSigningDB("developer1", devPrivKey)
+	s.deviceKey, _ = assertstest.GenerateKey(752)
+
+	a, err := brandSigning.Sign(asserts.ModelType, map[string]interface{}{
+		"series":       "16",
+		"brand-id":     "developer1",
+		"model":        "my-model",
+		"architecture": "amd64",
+		"gadget":       "pc",
+		"kernel":       "pc-kernel",
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	s.model = a.(*asserts.Model)
+}
+
+func (s *deviceSvcSuite) serialRequest(c *C, reqID, serial string) []byte {
+	encodedPubKey, err := asserts.EncodePublicKey(s.deviceKey.PublicKey())
+	c.Assert(err, IsNil)
+	headers := map[string]interface{}{
+		"brand-id":   "developer1",
+		"model":      "my-model",
+		"request-id": reqID,
+		"device-key": string(encodedPubKey),
+	}
+	if serial != "" {
+		headers["serial"] = serial
+	}
+	serialReq, err := asserts.SignWithoutAuthority(asserts.SerialRequestType, headers, nil, s.deviceKey)
+	c.Assert(err, IsNil)
+
+	buf := bytes.NewBuffer(nil)
+	enc := asserts.NewEncoder(buf)
+	c.Assert(enc.Encode(serialReq), IsNil)
+	c.Assert(enc.Encode(s.model), IsNil)
+	return buf.Bytes()
+}
+
+func (s *deviceSvcSuite) requestID(c *C, srvURL string) (status int, reqID string) {
+	resp, err := http.Post(srvURL+"/api/v1/snaps/auth/request-id", "", nil)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return resp.StatusCode, ""
+	}
+	var res struct {
+		RequestID string `json:"request-id"`
+	}
+	c.Assert(json.NewDecoder(resp.Body).Decode(&res), IsNil)
+	return resp.StatusCode, res.RequestID
+}
+
+func (s *deviceSvcSuite) postSerial(c *C, srvURL string, body []byte) (status int, serial *asserts.Serial) {
+	resp, err := http.Post(srvURL+"/api/v1/snaps/auth/devices", asserts.MediaType, bytes.NewReader(body))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return resp.StatusCode, nil
+	}
+	c.Check(resp.Header.Get("Content-Type"), Equals, asserts.MediaType)
+	a, err := asserts.NewDecoder(resp.Body).Decode()
+	c.Assert(err, IsNil)
+	return resp.StatusCode, a.(*asserts.Serial)
+}
+
+func (s *deviceSvcSuite) newServer(c *C, bhv *devicesvc.Behavior) (*devicesvc.DeviceService, *httptest.Server) {
+	svc, err := devicesvc.New("", bhv)
+	c.Assert(err, IsNil)
+	srv := httptest.NewServer(svc.Handler())
+	return svc, srv
+}
+
+func (s *deviceSvcSuite) TestRegistration(c *C) {
+	_, srv := s.newServer(c, nil)
+	defer srv.Close()
+
+	status, reqID := s.requestID(c, srv.URL)
+	c.Assert(status, Equals, 200)
+	c.Check(reqID, Equals, "REQ-ID-1")
+
+	status, serial := s.postSerial(c, srv.URL, s.serialRequest(c, reqID, "proposed"))
+	c.Assert(status, Equals, 200)
+	c.Check(serial.AuthorityID(), Equals, "developer1")
+	c.Check(serial.BrandID(), Equals, "developer1")
+	c.Check(serial.Model(), Equals, "my-model")
+	c.Check(serial.Serial(), Equals, "7777")
+	c.Check(serial.DeviceKey().ID(), Equals, s.deviceKey.PublicKey().ID())
+
+	// the serial is signed with the developer1 key
+	devPrivKey, _ := assertstest.ReadPrivKey(assertstest.DevKey)
+	c.Check(asserts.SignatureCheck(serial, devPrivKey.PublicKey()), IsNil)
+}
+
+func (s *deviceSvcSuite) TestRegistrationShortPathsProposedSerial(c *C) {
+	_, srv := s.newServer(c, &devicesvc.Behavior{UseProposedSerial: true})
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/serial", asserts.MediaType, bytes.NewReader(s.serialRequest(c, "REQ-ID", "proposed")))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, 200)
+	a, err := asserts.NewDecoder(resp.Body).Decode()
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.Serial).Serial(), Equals, "proposed")
+}
+
+func (s *deviceSvcSuite) TestFailureInjection(c *C) {
+	svc, srv := s.newServer(c, &devicesvc.Behavior{FailCount: 2, FailStatus: 500})
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/v1/snaps/auth/request-id", "", nil)
+	c.Assert(err, IsNil)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, IsNil)
+	c.Check(resp.StatusCode, Equals, 500)
+	c.Check(string(body), Equals, `{"error_list":[{"message":"injected failure 1 of 2"}]}`+"\n")
+
+	status, _ := s.requestID(c, srv.URL)
+	c.Check(status, Equals, 500)
+	status, reqID := s.requestID(c, srv.URL)
+	c.Check(status, Equals, 200)
+	c.Check(reqID, Equals, "REQ-ID-3")
+	c.Check(svc.Requests(), Equals, 3)
+}
+
+func (s *deviceSvcSuite) TestPoll(c *C) {
+	_, srv := s.newServer(c, &devicesvc.Behavior{PollCount: 2})
+	defer srv.Close()
+
+	body := s.serialRequest(c, "REQ-ID-1", "")
+	for i := 0; i < 2; i++ {
+		status, _ := s.postSerial(c, srv.URL, body)
+		c.Check(status, Equals, 202)
+	}
+	status, serial := s.postSerial(c, srv.URL, body)
+	c.Assert(status, Equals, 200)
+	c.Check(serial.Serial(), Equals, "7777")
+
+	// polling is tracked per request-id
+	status, _ = s.postSerial(c, srv.URL, s.serialRequest(c, "REQ-ID-2", ""))
+	c.Check(status, Equals, 202)
+}
+
+func (s *deviceSvcSuite) TestLatency(c *C) {
+	_, srv := s.newServer(c, &devicesvc.Behavior{Latency: 50 * time.Millisecond})
+	defer srv.Close()
+
+	start := time.Now()
+	status, _ := s.requestID(c, srv.URL)
+	c.Check(status, Equals, 200)
+	c.Check(time.Since(start) >= 50*time.Millisecond, Equals, true)
+}
+
+func (s *deviceSvcSuite) TestBadRequests(c *C) {
+	_, srv := s.newServer(c, nil)
+	defer srv.Close()
+
+	status, _ := s.postSerial(c, srv.URL, asserts.Encode(s.model))
+	c.Check(status, Equals, 400)
+
+	// serial-request without model
+	body := s.serialRequest(c, "REQ-ID", "")
+	a, err := asserts.NewDecoder(bytes.NewReader(body)).Decode()
+	c.Assert(err, IsNil)
+	status, _ = s.postSerial(c, srv.URL, asserts.Encode(a))
+	c.Check(status, Equals, 400)
+
+	resp, err := http.Get(srv.URL + "/serial")
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Check(resp.StatusCode, Equals, 405)
+}
@@ -21,130 +21,58 @@ package main
 
 import (
 	"fmt"
-	"io"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/snapcore/snapd/asserts"
-	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/tests/lib/fakedevicesvc/devicesvc"
 )
 
-var devPrivKey, _ = assertstest.ReadPrivKey(assertstest.DevKey)
+type options struct {
+	Latency           time.Duration `long:"latency" description:"Delay added before responding to any request"`
+	FailCount         int           `long:"fail-count" description:"Number of requests to fail before responding normally"`
+	FailStatus        int           `long:"fail-status" default:"503" description:"HTTP status of the failed requests"`
+	PollCount         int           `long:"poll-count" description:"Number of times a serial request is asked to poll again before being served"`
+	UseProposedSerial bool          `long:"use-proposed-serial" description:"Issue the serial proposed by the device, if any"`
+
+	Positional struct {
+		Addr string `positional-arg-name:"<listening address>"`
+	} `positional-args:"yes" required:"yes"`
+}
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "no listening address arg\n")
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+}
+
+func run() error {
+	var opts options
+	if _, err := flags.Parse(&opts); err != nil {
+		return err
+	}
 
-	l, err := net.Listen("tcp", os.Args[1])
+	svc, err := devicesvc.New(opts.Positional.Addr, &devicesvc.Behavior{
+		Latency:           opts.Latency,
+		FailCount:         opts.FailCount,
+		FailStatus:        opts.FailStatus,
+		PollCount:         opts.PollCount,
+		UseProposedSerial: opts.UseProposedSerial,
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "cannot listen: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	if err := svc.Start(); err != nil {
+		return fmt.Errorf("cannot listen: %v", err)
 	}
-
-	s := &http.Server{Handler: http.HandlerFunc(handle)}
-	go s.Serve(l)
 
 	ch := make(chan os.Signal, 2)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
 	<-ch
 
-	l.Close()
-}
-
-func internalError(w http.ResponseWriter, msg string, a ...interface{}) {
-	http.Error(w, fmt.Sprintf(msg, a...), 500)
-}
-
-func badRequestError(w http.ResponseWriter, msg string, a ...interface{}) {
-	http.Error(w, fmt.Sprintf(msg, a...), 400)
-}
-
-func handle(w http.ResponseWriter, r *http.Request) {
-	switch r.URL.Path {
-	case "/request-id":
-		w.WriteHeader(200)
-		io.WriteString(w, `{"request-id": "REQ-ID"}`)
-	case "/serial":
-		db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{})
-		if err != nil {
-			internalError(w, "cannot open signing db: %v", err)
-			return
-		}
-		err = db.ImportKey(devPrivKey)
-		if err != nil {
-			internalError(w, "cannot import signing key: %v", err)
-			return
-		}
-
-		defer r.Body.Close()
-
-		dec := asserts.NewDecoder(r.Body)
-
-		a, err := dec.Decode()
-		if err != nil {
-			internalError(w, "cannot read/decode request: %v", err)
-			return
-		}
-
-		serialReq, ok := a.(*asserts.SerialRequest)
-		if !ok {
-			badRequestError(w, "request is not a serial-request")
-			return
-
-		}
-
-		a, err = dec.Decode()
-		if err != nil {
-			internalError(w, "cannot read/decode model: %v", err)
-			return
-		}
-
-		mod, ok := a.(*asserts.Model)
-		if !ok {
-			badRequestError(w, "expected model after serial-request")
-			return
-
-		}
-
-		if mod.Model() != serialReq.Model() || mod.BrandID() != serialReq.BrandID() {
-			badRequestError(w, "model and serial-request do not cross check")
-			return
-		}
-
-		err = asserts.SignatureCheck(serialReq, serialReq.DeviceKey())
-		if err != nil {
-			badRequestError(w, "bad serial-request: %v", err)
-			return
-		}
-
-		serialStr := "7777"
-		if r.Header.Get("X-Use-Proposed") == "yes" {
-			// use proposed serial
-			serialStr = serialReq.Serial()
-		}
-
-		serial, err := db.Sign(asserts.SerialType, map[string]interface{}{
-			"authority-id":        "developer1",
-			"brand-id":            "developer1",
-			"model":               serialReq.Model(),
-			"serial":              serialStr,
-			"device-key":          serialReq.HeaderString("device-key"),
-			"device-key-sha3-384": serialReq.SignKeyID(),
-			"timestamp":           time.Now().Format(time.RFC3339),
-		}, serialReq.Body(), devPrivKey.PublicKey().ID())
-		if err != nil {
-			internalError(w, "cannot sign serial: %v", err)
-			return
-		}
-
-		w.Header().Set("Content-Type", asserts.MediaType)
-		w.WriteHeader(200)
-		w.Write(asserts.Encode(serial))
-	}
+	return svc.Stop()
 }
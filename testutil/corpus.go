@@ -0,0 +1,103 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/check.v1"
+)
+
+// CorpusErrorSuffix is the suffix of the files holding the error
+// expected when validating the corpus fixture of the same name.
+const CorpusErrorSuffix = ".error"
+
+// CorpusFixture is a metadata sample, e.g. a snap.yaml, gadget.yaml or
+// model assertion, from a corpus of fixtures.
+type CorpusFixture struct {
+	// Name is the path of the fixture relative to the corpus directory.
+	Name    string
+	Content []byte
+	// ExpectedError is the regexp, as used by check.ErrorMatches, that
+	// the validation error of the fixture must match. It is read from
+	// the file named like the fixture with CorpusErrorSuffix appended,
+	// and is empty if the fixture is expected to be valid.
+	ExpectedError string
+}
+
+// LoadCorpus loads the fixtures found in dir, recursively, whose file
+// names match the given glob pattern. Contributing a sample that is not
+// handled correctly is then a matter of dropping it, together with its
+// expected error if any, in the corpus directory.
+func LoadCorpus(c *check.C, dir, pattern string) []CorpusFixture {
+	var fixtures []CorpusFixture
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || strings.HasSuffix(path, CorpusErrorSuffix) {
+			return nil
+		}
+		if ok, err := filepath.Match(pattern, fi.Name()); err != nil || !ok {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fixture := CorpusFixture{Name: name, Content: content}
+		expectedErr, err := os.ReadFile(path + CorpusErrorSuffix)
+		switch {
+		case err == nil:
+			fixture.ExpectedError = strings.TrimSpace(string(expectedErr))
+		case !os.IsNotExist(err):
+			return err
+		}
+		fixtures = append(fixtures, fixture)
+		return nil
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(fixtures, check.Not(check.HasLen), 0, check.Commentf("no fixtures matching %q in %s", pattern, dir))
+
+	sort.Slice(fixtures, func(i, j int) bool { return fixtures[i].Name < fixtures[j].Name })
+	return fixtures
+}
+
+// CheckCorpus runs validate over each of the fixtures and checks that
+// it fails with the expected error, or succeeds if none is expected.
+func CheckCorpus(c *check.C, fixtures []CorpusFixture, validate func(content []byte) error) {
+	for _, fixture := range fixtures {
+		comment := check.Commentf("fixture %s", fixture.Name)
+		err := validate(fixture.Content)
+		if fixture.ExpectedError == "" {
+			c.Check(err, check.IsNil, comment)
+		} else {
+			c.Check(err, check.ErrorMatches, fixture.ExpectedError, comment)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package testutil_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	. "github.com/snapcore/snapd/testutil"
+)
+
+type corpusSuite struct{}
+
+var _ = check.Suite(&corpusSuite{})
+
+func (*corpusSuite) TestLoadAndCheckCorpus(c *check.C) {
+	d := c.MkDir()
+	for name, content := range map[string]string{
+		"good.yaml":           "good",
+		"sub/bad.yaml":        "bad",
+		"sub/bad.yaml.error":  "cannot .* bad\n",
+		"ignored.txt":         "ignored",
+		"sub/other.yaml.orig": "ignored",
+	} {
+		p := filepath.Join(d, name)
+		c.Assert(os.MkdirAll(filepath.Dir(p), 0755), check.IsNil)
+		c.Assert(os.WriteFile(p, []byte(content), 0644), check.IsNil)
+	}
+
+	fixtures := LoadCorpus(c, d, "*.yaml")
+	c.Check(fixtures, check.DeepEquals, []CorpusFixture{
+		{Name: "good.yaml", Content: []byte("good")},
+		{Name: "sub/bad.yaml", Content: []byte("bad"), ExpectedError: "cannot .* bad"},
+	})
+
+	var seen []string
+	CheckCorpus(c, fixtures, func(content []byte) error {
+		seen = append(seen, string(content))
+		if string(content) == "bad" {
+			return errors.New("cannot validate bad")
+		}
+		return nil
+	})
+	c.Check(seen, check.DeepEquals, []string{"good", "bad"})
+}
@@ -0,0 +1,171 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+// Package clocktest provides a fake timeutil.TimeSource for tests.
+package clocktest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/timeutil"
+)
+
+type waiter struct {
+	deadline time.Time
+	// period is only set for tickers
+	period time.Duration
+	ch     chan time.Time
+	// stopped is only used by tickers
+	stopped bool
+}
+
+// FakeClock is a timeutil.TimeSource whose time only moves forward when
+// Advance is called.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	// changed is closed and replaced whenever waiters are added
+	changed chan struct{}
+}
+
+var _ timeutil.TimeSource = (*FakeClock)(nil)
+
+// New returns a FakeClock set at the given time.
+func New(now time.Time) *FakeClock {
+	return &FakeClock{
+		now:     now,
+		changed: make(chan struct{}),
+	}
+}
+
+// Now returns the current time of the fake clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) addWaiter(w *waiter) {
+	c.waiters = append(c.waiters, w)
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// After returns a channel that receives the time of the fake clock once it
+// has been advanced by at least d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &waiter{deadline: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- c.now
+		return w.ch
+	}
+	c.addWaiter(w)
+	return w.ch
+}
+
+type fakeTicker struct {
+	c *FakeClock
+	w *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	t.w.stopped = true
+}
+
+// NewTicker returns a ticker which ticks every time the fake clock is
+// advanced past a multiple of the period d. As with time.Ticker, ticks are
+// dropped if the receiver is not keeping up.
+func (c *FakeClock) NewTicker(d time.Duration) timeutil.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &waiter{deadline: c.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	c.addWaiter(w)
+	return &fakeTicker{c: c, w: w}
+}
+
+// Advance moves the time of the fake clock forward by d, firing the timers
+// and tickers whose deadline was reached.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.stopped {
+			continue
+		}
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		select {
+		case w.ch <- c.now:
+		default:
+		}
+		if w.period == 0 {
+			continue
+		}
+		for !w.deadline.After(c.now) {
+			w.deadline = w.deadline.Add(w.period)
+		}
+		pending = append(pending, w)
+	}
+	c.waiters = pending
+}
+
+// Waiters returns the number of timers and tickers currently waiting on
+// the fake clock.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, w := range c.waiters {
+		if !w.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+// BlockUntil blocks until at least n timers or tickers are waiting on the
+// fake clock. This is useful to synchronize with code running in another
+// go-routine before advancing the clock.
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		changed := c.changed
+		c.mu.Unlock()
+		if c.Waiters() >= n {
+			return
+		}
+		<-changed
+	}
+}
@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package clocktest_test
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/timeutil/clocktest"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type clockSuite struct{}
+
+var _ = Suite(&clockSuite{})
+
+var epoch = time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+
+func (s *clockSuite) TestNowAndAdvance(c *C) {
+	clk := clocktest.New(epoch)
+	c.Check(clk.Now(), Equals, epoch)
+	clk.Advance(time.Minute)
+	c.Check(clk.Now(), Equals, epoch.Add(time.Minute))
+}
+
+func (s *clockSuite) TestAfter(c *C) {
+	clk := clocktest.New(epoch)
+	ch := clk.After(10 * time.Second)
+	c.Check(clk.Waiters(), Equals, 1)
+
+	clk.Advance(5 * time.Second)
+	select {
+	case <-ch:
+		c.Fatal("fired too early")
+	default:
+	}
+
+	clk.Advance(5 * time.Second)
+	select {
+	case t := <-ch:
+		c.Check(t, Equals, epoch.Add(10*time.Second))
+	default:
+		c.Fatal("did not fire")
+	}
+	c.Check(clk.Waiters(), Equals, 0)
+}
+
+func (s *clockSuite) TestAfterNonPositive(c *C) {
+	clk := clocktest.New(epoch)
+	c.Check(<-clk.After(0), Equals, epoch)
+	c.Check(clk.Waiters(), Equals, 0)
+}
+
+func (s *clockSuite) TestTicker(c *C) {
+	clk := clocktest.New(epoch)
+	ticker := clk.NewTicker(time.Second)
+
+	clk.Advance(time.Second)
+	c.Check(<-ticker.C(), Equals, epoch.Add(time.Second))
+
+	// ticks are dropped when not consumed
+	clk.Advance(time.Second)
+	clk.Advance(time.Second)
+	c.Check(<-ticker.C(), Equals, epoch.Add(2*time.Second))
+	select {
+	case <-ticker.C():
+		c.Fatal("unexpected tick")
+	default:
+	}
+
+	ticker.Stop()
+	c.Check(clk.Waiters(), Equals, 0)
+	clk.Advance(time.Second)
+	select {
+	case <-ticker.C():
+		c.Fatal("unexpected tick after stop")
+	default:
+	}
+}
+
+func (s *clockSuite) TestBlockUntil(c *C) {
+	clk := clocktest.New(epoch)
+	done := make(chan time.Time)
+	go func() {
+		done <- <-clk.After(time.Hour)
+	}()
+	clk.BlockUntil(1)
+	clk.Advance(time.Hour)
+	c.Check(<-done, Equals, epoch.Add(time.Hour))
+}
@@ -533,11 +533,9 @@ var (
 	timeNow = time.Now
 )
 
-// Next returns the earliest event after last according to the provided
-// schedule but no later than maxDuration since last.
-func Next(schedule []*Schedule, last time.Time, maxDuration time.Duration) time.Duration {
-	now := timeNow()
-
+// NextWindow returns the earliest window after last according to the
+// provided schedule but starting no later than maxDuration since last.
+func NextWindow(schedule []*Schedule, last time.Time, maxDuration time.Duration) ScheduleWindow {
 	window := ScheduleWindow{
 		Start: last.Add(maxDuration),
 		End:   last.Add(maxDuration).Add(1 * time.Hour),
@@ -549,6 +547,15 @@ func Next(schedule []*Schedule, last time.Time, maxDuration time.Duration) time.
 			window = next
 		}
 	}
+	return window
+}
+
+// Next returns the earliest event after last according to the provided
+// schedule but no later than maxDuration since last.
+func Next(schedule []*Schedule, last time.Time, maxDuration time.Duration) time.Duration {
+	now := timeNow()
+
+	window := NextWindow(schedule, last, maxDuration)
 	if window.Start.Before(now) {
 		return 0
 	}
@@ -862,10 +869,18 @@ func parseEventSet(s string) (*Schedule, error) {
 // the schedule. A single time schedule eg. '10:00' is treated as spanning the
 // time [10:00, 10:01)
 func (sched *Schedule) Includes(t time.Time) bool {
+	// a time span like 23:00-1:00 that started the day before can still
+	// include t
+	return sched.includesFrom(t, t) || sched.includesFrom(t.Add(-24*time.Hour), t)
+}
+
+// includesFrom checks whether t falls inside one of the windows of the
+// schedule that start on the same day as base.
+func (sched *Schedule) includesFrom(base, t time.Time) bool {
 	if len(sched.WeekSpans) > 0 {
 		var weekMatch bool
 		for _, week := range sched.WeekSpans {
-			if week.Match(t) {
+			if week.Match(base) {
 				weekMatch = true
 				break
 			}
@@ -876,7 +891,7 @@ func (sched *Schedule) Includes(t time.Time) bool {
 	}
 
 	for _, tspan := range sched.flattenedClockSpans() {
-		window := tspan.Window(t)
+		window := tspan.Window(base)
 		if window.End.Equal(window.Start) {
 			// schedule granularity is a minute, a schedule '10:00'
 			// in fact is: [10:00, 10:01)
@@ -841,6 +841,37 @@ func (ts *timeutilSuite) TestScheduleNext(c *C) {
 	}
 }
 
+func (ts *timeutilSuite) TestNextWindow(c *C) {
+	const shortForm = "2006-01-02 15:04"
+
+	restore := testutil.Backup(&time.Local)
+	defer restore()
+	local, err := time.LoadLocation("UTC")
+	c.Assert(err, IsNil)
+	time.Local = local
+
+	restorer := timeutil.MockTimeNow(func() time.Time {
+		t, err := time.ParseInLocation(shortForm, "2017-02-06 09:00", time.Local)
+		c.Assert(err, IsNil)
+		return t
+	})
+	defer restorer()
+
+	last, err := time.ParseInLocation(shortForm, "2017-02-05 22:00", time.Local)
+	c.Assert(err, IsNil)
+
+	sched, err := timeutil.ParseSchedule("mon,10:00-12:00,,fri,15:00-16:00")
+	c.Assert(err, IsNil)
+	window := timeutil.NextWindow(sched, last, 24*time.Hour)
+	c.Check(window.Start.Format(shortForm), Equals, "2017-02-06 10:00")
+	c.Check(window.End.Format(shortForm), Equals, "2017-02-06 12:00")
+
+	// capped by the max duration
+	window = timeutil.NextWindow(sched, last, 2*time.Hour)
+	c.Check(window.Start.Format(shortForm), Equals, "2017-02-06 00:00")
+	c.Check(window.End.Format(shortForm), Equals, "2017-02-06 01:00")
+}
+
 func (ts *timeutilSuite) TestScheduleIncludes(c *C) {
 	const shortForm = "2006-01-02 15:04:05"
 
@@ -989,6 +1020,25 @@ func (ts *timeutilSuite) TestScheduleIncludes(c *C) {
 			// Tue, 9:30
 			now:       "2019-10-01 9:30:00",
 			expecting: true,
+		}, {
+			// window crossing midnight, started the day before
+			schedule:  "23:00-1:00",
+			now:       "2017-02-06 0:30:00",
+			expecting: true,
+		}, {
+			schedule:  "23:00-1:00",
+			now:       "2017-02-06 1:30:00",
+			expecting: false,
+		}, {
+			schedule: "mon,23:00-1:00",
+			// Tue, 0:30
+			now:       "2017-02-07 0:30:00",
+			expecting: true,
+		}, {
+			schedule: "mon,23:00-1:00",
+			// Mon, 0:30
+			now:       "2017-02-06 0:30:00",
+			expecting: false,
 		},
 	} {
 		c.Logf("trying %+v", t)
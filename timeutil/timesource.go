@@ -0,0 +1,71 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package timeutil
+
+import (
+	"time"
+)
+
+// TimeSource gives access to the current time and to timers. Code with
+// time-dependent behavior should use a TimeSource instead of the time
+// package directly, so that tests can use a fake clock (see
+// timeutil/clocktest) instead of sleeping.
+type TimeSource interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the
+	// current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+	// NewTicker returns a new Ticker delivering the time on its
+	// channel at each period.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks of a TimeSource at intervals.
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// RealTimeSource is the TimeSource backed by the time package.
+var RealTimeSource TimeSource = realTimeSource{}
+
+type realTimeSource struct{}
+
+func (realTimeSource) Now() time.Time {
+	return time.Now()
+}
+
+func (realTimeSource) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realTimeSource) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
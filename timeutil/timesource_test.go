@@ -0,0 +1,46 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package timeutil_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/timeutil"
+)
+
+type timeSourceSuite struct{}
+
+var _ = Suite(&timeSourceSuite{})
+
+func (s *timeSourceSuite) TestRealTimeSource(c *C) {
+	ts := timeutil.RealTimeSource
+
+	before := time.Now()
+	now := ts.Now()
+	c.Check(now.Before(before), Equals, false)
+
+	t := <-ts.After(time.Millisecond)
+	c.Check(t.After(now), Equals, true)
+
+	ticker := ts.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	c.Check((<-ticker.C()).After(t), Equals, true)
+}
@@ -21,6 +21,9 @@ package agent
 
 import (
 	"syscall"
+
+	"github.com/snapcore/snapd/desktop/notification"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 var (
@@ -28,6 +31,8 @@ var (
 	ServiceControlCmd             = serviceControlCmd
 	PendingRefreshNotificationCmd = pendingRefreshNotificationCmd
 	FinishRefreshNotificationCmd  = finishRefreshNotificationCmd
+	RebootRequiredNotificationCmd = rebootRequiredNotificationCmd
+	WarningNotificationCmd        = warningNotificationCmd
 )
 
 func MockUcred(ucred *syscall.Ucred, err error) (restore func()) {
@@ -43,8 +48,27 @@ func MockUcred(ucred *syscall.Ucred, err error) (restore func()) {
 // MockNoBus temporarily unsets the D-Bus connection of a SessionAgent
 func MockNoBus(agent *SessionAgent) (restore func()) {
 	bus := agent.bus
+	notificationMgr := agent.notificationMgr
 	agent.bus = nil
+	agent.notificationMgr = notification.NewNotificationManager(nil, "io.snapcraft.SessionAgent")
 	return func() {
 		agent.bus = bus
+		agent.notificationMgr = notificationMgr
+	}
+}
+
+func MockTimeSource(ts timeutil.TimeSource) (restore func()) {
+	old := timeSource
+	timeSource = ts
+	return func() {
+		timeSource = old
+	}
+}
+
+func MockHomeNotWritable(f func() (home, reason string)) (restore func()) {
+	old := homeNotWritable
+	homeNotWritable = f
+	return func() {
+		homeNotWritable = old
 	}
 }
@@ -24,16 +24,20 @@ import (
 	"fmt"
 	"mime"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/mvo5/goconfigparser"
+	"golang.org/x/sys/unix"
 
 	"github.com/snapcore/snapd/desktop/notification"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/usersession/client"
 )
@@ -44,6 +48,8 @@ var restApi = []*Command{
 	serviceControlCmd,
 	pendingRefreshNotificationCmd,
 	finishRefreshNotificationCmd,
+	rebootRequiredNotificationCmd,
+	warningNotificationCmd,
 }
 
 var (
@@ -71,6 +77,16 @@ var (
 		Path: "/v1/notifications/finish-refresh",
 		POST: postRefreshFinishedNotification,
 	}
+
+	rebootRequiredNotificationCmd = &Command{
+		Path: "/v1/notifications/reboot-required",
+		POST: postRebootRequiredNotification,
+	}
+
+	warningNotificationCmd = &Command{
+		Path: "/v1/notifications/warning",
+		POST: postWarningNotification,
+	}
 )
 
 func sessionInfo(c *Command, r *http.Request) Response {
@@ -85,6 +101,20 @@ type serviceInstruction struct {
 	Services []string `json:"services"`
 }
 
+// homeNotWritable returns the home directory of the user and why it cannot
+// be written to, if it is on a read-only filesystem or on NFS refusing
+// writes.
+var homeNotWritable = func() (home, reason string) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", ""
+	}
+	if err := unix.Access(home, unix.W_OK); err != nil {
+		return home, osutil.HomeNotWritableReason(err)
+	}
+	return home, ""
+}
+
 func serviceStart(inst *serviceInstruction, sysd systemd.Systemd) Response {
 	// Refuse to start non-snap services
 	for _, service := range inst.Services {
@@ -93,6 +123,13 @@ func serviceStart(inst *serviceInstruction, sysd systemd.Systemd) Response {
 		}
 	}
 
+	// the services would fail to create their per-user data directories,
+	// leave them stopped in this session instead of failing the change
+	if home, reason := homeNotWritable(); reason != "" {
+		logger.Noticef("not starting user services %s: home directory %q %s", strings.Join(inst.Services, ", "), home, reason)
+		return SyncResponse(nil)
+	}
+
 	startErrors := make(map[string]string)
 	var started []string
 	for _, service := range inst.Services {
@@ -233,17 +270,6 @@ func postPendingRefreshNotification(c *Command, r *http.Request) Response {
 		return BadRequest("cannot decode request body into pending snap refresh info: %v", err)
 	}
 
-	// Note that since the connection is shared, we are not closing it.
-	if c.s.bus == nil {
-		return SyncResponse(&resp{
-			Type:   ResponseTypeError,
-			Status: 500,
-			Result: &errorResult{
-				Message: "cannot connect to the session bus",
-			},
-		})
-	}
-
 	// TODO: this message needs to be crafted better as it's the only thing guaranteed to be delivered.
 	summary := fmt.Sprintf(i18n.G("Update available for %s."), refreshInfo.InstanceName)
 	var urgencyLevel notification.Urgency
@@ -315,22 +341,50 @@ func postRefreshFinishedNotification(c *Command, r *http.Request) Response {
 		return BadRequest("cannot decode request body into finish refresh notification info: %v", err)
 	}
 
-	// Note that since the connection is shared, we are not closing it.
-	if c.s.bus == nil {
+	summary := fmt.Sprintf(i18n.G("%s was updated."), finishRefresh.InstanceName)
+	body := i18n.G("Ready to launch.")
+	hints := []notification.Hint{
+		notification.WithDesktopEntry("io.snapcraft.SessionAgent"),
+		notification.WithUrgency(notification.LowUrgency),
+	}
+
+	msg := &notification.Message{
+		Title: summary,
+		Body:  body,
+		Hints: hints,
+	}
+	if err := c.s.notificationMgr.SendNotification(notification.ID(finishRefresh.InstanceName), msg); err != nil {
 		return SyncResponse(&resp{
 			Type:   ResponseTypeError,
 			Status: 500,
 			Result: &errorResult{
-				Message: "cannot connect to the session bus",
+				Message: fmt.Sprintf("cannot send notification message: %v", err),
 			},
 		})
 	}
+	return SyncResponse(nil)
+}
 
-	summary := fmt.Sprintf(i18n.G("%s was updated."), finishRefresh.InstanceName)
-	body := i18n.G("Ready to launch.")
+func postRebootRequiredNotification(c *Command, r *http.Request) Response {
+	if ok, resp := validateJSONRequest(r); !ok {
+		return resp
+	}
+
+	decoder := json.NewDecoder(r.Body)
+
+	var rebootInfo client.RebootRequiredInfo
+	if err := decoder.Decode(&rebootInfo); err != nil {
+		return BadRequest("cannot decode request body into reboot required notification info: %v", err)
+	}
+
+	summary := i18n.G("System restart required")
+	body := i18n.G("Restart the system to complete the update.")
+	if rebootInfo.InstanceName != "" {
+		body = fmt.Sprintf(i18n.G("Restart the system to complete the update of %s."), rebootInfo.InstanceName)
+	}
 	hints := []notification.Hint{
 		notification.WithDesktopEntry("io.snapcraft.SessionAgent"),
-		notification.WithUrgency(notification.LowUrgency),
+		notification.WithUrgency(notification.NormalUrgency),
 	}
 
 	msg := &notification.Message{
@@ -338,7 +392,54 @@ func postRefreshFinishedNotification(c *Command, r *http.Request) Response {
 		Body:  body,
 		Hints: hints,
 	}
-	if err := c.s.notificationMgr.SendNotification(notification.ID(finishRefresh.InstanceName), msg); err != nil {
+	if err := c.s.notificationMgr.SendNotification(notification.ID("reboot-required"), msg); err != nil {
+		return SyncResponse(&resp{
+			Type:   ResponseTypeError,
+			Status: 500,
+			Result: &errorResult{
+				Message: fmt.Sprintf("cannot send notification message: %v", err),
+			},
+		})
+	}
+	return SyncResponse(nil)
+}
+
+var warningUrgency = map[string]notification.Urgency{
+	"low":    notification.LowUrgency,
+	"normal": notification.NormalUrgency,
+	"high":   notification.CriticalUrgency,
+}
+
+func postWarningNotification(c *Command, r *http.Request) Response {
+	if ok, resp := validateJSONRequest(r); !ok {
+		return resp
+	}
+
+	decoder := json.NewDecoder(r.Body)
+
+	var warningInfo client.WarningInfo
+	if err := decoder.Decode(&warningInfo); err != nil {
+		return BadRequest("cannot decode request body into warning notification info: %v", err)
+	}
+	if warningInfo.Message == "" {
+		return BadRequest("cannot send a warning notification without a message")
+	}
+	urgency, ok := warningUrgency[warningInfo.Severity]
+	if !ok {
+		urgency = notification.NormalUrgency
+	}
+
+	hints := []notification.Hint{
+		notification.WithDesktopEntry("io.snapcraft.SessionAgent"),
+		notification.WithUrgency(urgency),
+	}
+
+	msg := &notification.Message{
+		Title: i18n.G("System warning"),
+		Body:  warningInfo.Message,
+		Hints: hints,
+	}
+	if err := c.s.notificationMgr.SendNotification(notification.ID("warning:"+warningInfo.Message), msg); err != nil {
 		return SyncResponse(&resp{
 			Type:   ResponseTypeError,
 			Status: 500,
@@ -35,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/desktop/notification"
 	"github.com/snapcore/snapd/desktop/notification/notificationtest"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/usersession/agent"
@@ -196,6 +197,30 @@ func (s *restSuite) TestServicesStartNonSnap(c *C) {
 	c.Check(s.sysdLog, HasLen, 0)
 }
 
+func (s *restSuite) TestServicesStartReadOnlyHome(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+	restore = agent.MockHomeNotWritable(func() (string, string) {
+		return "/home/user", "is on a read-only filesystem"
+	})
+	defer restore()
+
+	req := httptest.NewRequest("POST", "/v1/service-control", bytes.NewBufferString(`{"action":"start","services":["snap.foo.service", "snap.bar.service"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	agent.ServiceControlCmd.POST(agent.ServiceControlCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 200)
+
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Type, Equals, agent.ResponseTypeSync)
+	c.Check(rsp.Result, IsNil)
+
+	// the services were not started
+	c.Check(s.sysdLog, HasLen, 0)
+	c.Check(logbuf.String(), testutil.Contains, `not starting user services snap.foo.service, snap.bar.service: home directory "/home/user" is on a read-only filesystem`)
+}
+
 func (s *restSuite) TestServicesStartFailureStopsServices(c *C) {
 	var sysdLog [][]string
 	restore := systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
@@ -422,18 +447,19 @@ func (s *restSuite) TestPostPendingRefreshNotificationNoSessionBus(c *C) {
 	restore := agent.MockNoBus(s.agent)
 	defer restore()
 
+	// the notification is written to stdout instead
 	req := httptest.NewRequest("POST", "/v1/notifications/pending-refresh",
 		bytes.NewBufferString(`{"instance-name":"pkg"}`))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 	agent.PendingRefreshNotificationCmd.POST(agent.PendingRefreshNotificationCmd, req).ServeHTTP(rec, req)
-	c.Check(rec.Code, Equals, 500)
+	c.Check(rec.Code, Equals, 200)
 	c.Check(rec.Header().Get("Content-Type"), Equals, "application/json")
 
 	var rsp resp
 	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
-	c.Check(rsp.Type, Equals, agent.ResponseTypeError)
-	c.Check(rsp.Result, DeepEquals, map[string]interface{}{"message": "cannot connect to the session bus"})
+	c.Check(rsp.Type, Equals, agent.ResponseTypeSync)
+	c.Check(rsp.Result, IsNil)
 }
 
 func (s *restSuite) testPostPendingRefreshNotificationBody(c *C, refreshInfo *client.PendingSnapRefreshInfo) {
@@ -630,3 +656,63 @@ func (s *restSuite) TestPostCloseRefreshNotification(c *C) {
 		"desktop-entry": dbus.MakeVariant("io.snapcraft.SessionAgent"),
 	})
 }
+
+func (s *restSuite) TestPostRebootRequiredNotification(c *C) {
+	reqBody, err := json.Marshal(&client.RebootRequiredInfo{InstanceName: "pc-kernel"})
+	c.Assert(err, IsNil)
+	req := httptest.NewRequest("POST", "/v1/notifications/reboot-required", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	agent.RebootRequiredNotificationCmd.POST(agent.RebootRequiredNotificationCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 200)
+
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Type, Equals, agent.ResponseTypeSync)
+	c.Check(rsp.Result, IsNil)
+
+	notifications := s.notify.GetAll()
+	c.Assert(notifications, HasLen, 1)
+	n := notifications[0]
+	c.Check(n.Summary, Equals, "System restart required")
+	c.Check(n.Body, Equals, "Restart the system to complete the update of pc-kernel.")
+	c.Check(n.Hints, DeepEquals, map[string]dbus.Variant{
+		"urgency":       dbus.MakeVariant(byte(notification.NormalUrgency)),
+		"desktop-entry": dbus.MakeVariant("io.snapcraft.SessionAgent"),
+	})
+}
+
+func (s *restSuite) TestPostWarningNotification(c *C) {
+	reqBody, err := json.Marshal(&client.WarningInfo{Message: "something broke", Severity: "high"})
+	c.Assert(err, IsNil)
+	req := httptest.NewRequest("POST", "/v1/notifications/warning", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	agent.WarningNotificationCmd.POST(agent.WarningNotificationCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 200)
+
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Type, Equals, agent.ResponseTypeSync)
+	c.Check(rsp.Result, IsNil)
+
+	notifications := s.notify.GetAll()
+	c.Assert(notifications, HasLen, 1)
+	n := notifications[0]
+	c.Check(n.Summary, Equals, "System warning")
+	c.Check(n.Body, Equals, "something broke")
+	c.Check(n.Hints, DeepEquals, map[string]dbus.Variant{
+		"urgency":       dbus.MakeVariant(byte(notification.CriticalUrgency)),
+		"desktop-entry": dbus.MakeVariant("io.snapcraft.SessionAgent"),
+	})
+}
+
+func (s *restSuite) TestPostWarningNotificationNoMessage(c *C) {
+	req := httptest.NewRequest("POST", "/v1/notifications/warning", bytes.NewBufferString(`{"severity": "low"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	agent.WarningNotificationCmd.POST(agent.WarningNotificationCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 400)
+	c.Check(rec.Body.String(), testutil.Contains, "cannot send a warning notification without a message")
+	c.Check(s.notify.GetAll(), HasLen, 0)
+}
@@ -40,8 +40,13 @@ import (
 	"github.com/snapcore/snapd/netutil"
 	"github.com/snapcore/snapd/osutil/sys"
 	"github.com/snapcore/snapd/systemd"
+	"github.com/snapcore/snapd/timeutil"
 )
 
+// timeSource is used to track the idle time of the agent, it can be mocked
+// in tests.
+var timeSource timeutil.TimeSource = timeutil.RealTimeSource
+
 type SessionAgent struct {
 	Version         string
 	bus             *dbus.Conn
@@ -138,7 +143,7 @@ func (it *idleTracker) trackConn(conn net.Conn, state http.ConnState) {
 		delete(it.active, conn)
 	}
 	if len(it.active) == 0 && oldActive != 0 {
-		it.lastActive = time.Now()
+		it.lastActive = timeSource.Now()
 	}
 }
 
@@ -149,7 +154,7 @@ func (it *idleTracker) idleDuration() time.Duration {
 	if len(it.active) != 0 {
 		return 0
 	}
-	return time.Since(it.lastActive)
+	return timeSource.Now().Sub(it.lastActive)
 }
 
 const (
@@ -184,10 +189,9 @@ func (s *SessionAgent) Init() error {
 	}
 
 	// Set up notification manager
-	// Note that session bus may be nil, see the comment in tryConnectSessionBus.
-	if s.bus != nil {
-		s.notificationMgr = notification.NewNotificationManager(s.bus, "io.snapcraft.SessionAgent")
-	}
+	// Note that session bus may be nil, see the comment in tryConnectSessionBus,
+	// in which case notifications are written to stdout.
+	s.notificationMgr = notification.NewNotificationManager(s.bus, "io.snapcraft.SessionAgent")
 
 	agentSocket := fmt.Sprintf("%s/%d/snapd-session-agent.socket", dirs.XdgRuntimeDirBase, os.Getuid())
 	if l, err := netutil.GetListener(agentSocket, listenerMap); err != nil {
@@ -197,7 +201,7 @@ func (s *SessionAgent) Init() error {
 	}
 	s.idle = &idleTracker{
 		active:     make(map[net.Conn]struct{}),
-		lastActive: time.Now(),
+		lastActive: timeSource.Now(),
 	}
 	s.IdleTimeout = defaultIdleTimeout
 	s.addRoutes()
@@ -247,9 +251,7 @@ func (s *SessionAgent) Start() {
 	s.tomb.Go(s.runServer)
 	s.tomb.Go(s.shutdownServerOnKill)
 	s.tomb.Go(s.exitOnIdle)
-	if s.notificationMgr != nil {
-		s.tomb.Go(s.handleNotifications)
-	}
+	s.tomb.Go(s.handleNotifications)
 	systemd.SdNotify("READY=1")
 }
 
@@ -286,27 +288,24 @@ func (s *SessionAgent) shutdownServerOnKill() error {
 }
 
 func (s *SessionAgent) exitOnIdle() error {
-	timer := time.NewTimer(s.IdleTimeout)
+	wait := s.IdleTimeout
 Loop:
 	for {
 		select {
 		case <-s.tomb.Dying():
 			break Loop
-		case <-timer.C:
+		case <-timeSource.After(wait):
 			// Have we been idle? Consult idle duration from connection tracker
 			// and from notification manager, pick the lower one.
 			idleDuration := s.idle.idleDuration()
-			// notificationMgr may be nil if session bus is not available
-			if s.notificationMgr != nil {
-				if dur := s.notificationMgr.IdleDuration(); dur < idleDuration {
-					idleDuration = dur
-				}
+			if dur := s.notificationMgr.IdleDuration(); dur < idleDuration {
+				idleDuration = dur
 			}
 			if idleDuration >= s.IdleTimeout {
 				s.tomb.Kill(nil)
 				break Loop
 			} else {
-				timer.Reset(s.IdleTimeout - idleDuration)
+				wait = s.IdleTimeout - idleDuration
 			}
 		}
 	}
@@ -314,7 +313,6 @@ Loop:
 }
 
 // handleNotifications handles notifications in a blocking manner.
-// This should only be called when notificationMgr is available (i.e. s.bus is set).
 func (s *SessionAgent) handleNotifications() error {
 	err := s.notificationMgr.HandleNotifications(s.tomb.Context(context.Background()))
 	if err != nil {
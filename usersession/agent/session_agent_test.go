@@ -38,6 +38,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil/sys"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeutil/clocktest"
 	"github.com/snapcore/snapd/usersession/agent"
 )
 
@@ -154,6 +155,28 @@ func (s *sessionAgentSuite) TestExitOnIdle(c *C) {
 	}
 }
 
+func (s *sessionAgentSuite) TestExitOnIdleTimeSource(c *C) {
+	clock := clocktest.New(time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC))
+	restore := agent.MockTimeSource(clock)
+	defer restore()
+
+	agent, err := agent.New()
+	c.Assert(err, IsNil)
+	agent.IdleTimeout = time.Minute
+	agent.Start()
+	defer agent.Stop()
+
+	// wait for the idle timer to be set up
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	select {
+	case <-agent.Dying():
+	case <-time.After(2 * time.Second):
+		c.Fatal("agent did not exit after idle timeout expired")
+	}
+}
+
 func (s *sessionAgentSuite) TestFdoNotification(c *C) {
 	desktopFile := "[Desktop Entry]\nIcon=/path/appicon.png"
 	c.Assert(os.MkdirAll(filepath.Join(dirs.SnapDesktopFilesDir), 0755), IsNil)
@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -32,11 +33,20 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/retryutil"
 )
 
+// agentRequestRetry is used to retry requests to a session agent that
+// refused the connection, as it does while it is being restarted.
+var agentRequestRetry = retryutil.Policy{
+	Strategy: retryutil.Exponential{Initial: 100 * time.Millisecond},
+	Limits:   retryutil.Limits{MaxAttempts: 3},
+}
+
 // dialSessionAgent connects to a user's session agent
 //
 // The host portion of the address is interpreted as the numeric user
@@ -120,16 +130,22 @@ func (client *Client) sendRequest(ctx context.Context, socket string, method, ur
 		Path:     urlpath,
 		RawQuery: query.Encode(),
 	}
-	req, err := http.NewRequest(method, u.String(), bytes.NewBuffer(body))
-	if err != nil {
-		response.err = fmt.Errorf("internal error: %v", err)
-		return response
-	}
-	req = req.WithContext(ctx)
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
-	httpResp, err := client.doer.Do(req)
+	var httpResp *http.Response
+	err = retryutil.Do(ctx, agentRequestRetry.Strategy, agentRequestRetry.Limits, func() error {
+		req, err := http.NewRequest(method, u.String(), bytes.NewBuffer(body))
+		if err != nil {
+			return retryutil.Permanent(fmt.Errorf("internal error: %v", err))
+		}
+		req = req.WithContext(ctx)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		httpResp, err = client.doer.Do(req)
+		if err != nil && !errors.Is(err, syscall.ECONNREFUSED) {
+			return retryutil.Permanent(err)
+		}
+		return err
+	})
 	if err != nil {
 		response.err = err
 		return response
@@ -341,3 +357,39 @@ func (client *Client) FinishRefreshNotification(ctx context.Context, closeInfo *
 	_, err = client.doMany(ctx, "POST", "/v1/notifications/finish-refresh", nil, headers, reqBody)
 	return err
 }
+
+// RebootRequiredInfo holds information about a required system restart
+// provided to userd.
+type RebootRequiredInfo struct {
+	// InstanceName is the name of the snap that requires the restart,
+	// if any.
+	InstanceName string `json:"instance-name,omitempty"`
+}
+
+// RebootRequiredNotification broadcasts that a system restart is required.
+func (client *Client) RebootRequiredNotification(ctx context.Context, rebootInfo *RebootRequiredInfo) error {
+	headers := map[string]string{"Content-Type": "application/json"}
+	reqBody, err := json.Marshal(rebootInfo)
+	if err != nil {
+		return err
+	}
+	_, err = client.doMany(ctx, "POST", "/v1/notifications/reboot-required", nil, headers, reqBody)
+	return err
+}
+
+// WarningInfo holds information about a snapd warning provided to userd.
+type WarningInfo struct {
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// WarningNotification broadcasts a snapd warning.
+func (client *Client) WarningNotification(ctx context.Context, warningInfo *WarningInfo) error {
+	headers := map[string]string{"Content-Type": "application/json"}
+	reqBody, err := json.Marshal(warningInfo)
+	if err != nil {
+		return err
+	}
+	_, err = client.doMany(ctx, "POST", "/v1/notifications/warning", nil, headers, reqBody)
+	return err
+}
@@ -34,6 +34,7 @@ import (
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/retryutil"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/usersession/client"
 )
@@ -138,6 +139,46 @@ func (s *clientSuite) TestAgentTimeout(c *C) {
 	})
 }
 
+func (s *clientSuite) TestAgentConnectionRefusedRetried(c *C) {
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write([]byte(`{"type": "sync", "result": {"version": "42"}}`))
+	})
+
+	// the agent of uid 1234 is restarting, its socket is there but
+	// nothing is accepting connections on it yet
+	sock := fmt.Sprintf("%s/%d/snapd-session-agent.socket", dirs.XdgRuntimeDirBase, 1234)
+	c.Assert(os.MkdirAll(filepath.Dir(sock), 0755), IsNil)
+	l, err := net.Listen("unix", sock)
+	c.Assert(err, IsNil)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	c.Assert(l.Close(), IsNil)
+
+	retries := 0
+	restore := client.MockAgentRequestRetry(retryutil.Policy{
+		Strategy: retryutil.StrategyFunc(func(n int, prev time.Duration) time.Duration {
+			retries++
+			// the agent is up by the time of the retry
+			c.Assert(os.Remove(sock), IsNil)
+			l, err := net.Listen("unix", sock)
+			c.Assert(err, IsNil)
+			go s.server.Serve(l)
+			return 0
+		}),
+		Limits: retryutil.Limits{MaxAttempts: 3},
+	})
+	defer restore()
+
+	cli := client.NewForUids(1234)
+	si, err := cli.SessionInfo(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(si, DeepEquals, map[int]client.SessionInfo{
+		1234: {Version: "42"},
+	})
+	c.Check(retries, Equals, 1)
+}
+
 func (s *clientSuite) TestSessionInfo(c *C) {
 	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -479,6 +520,34 @@ func (s *clientSuite) TestFinishRefreshNotification(c *C) {
 	c.Check(atomic.LoadInt32(&n), Equals, int32(2))
 }
 
+func (s *clientSuite) TestRebootRequiredNotification(c *C) {
+	var n int32
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		c.Assert(r.URL.Path, Equals, "/v1/notifications/reboot-required")
+		body, err := ioutil.ReadAll(r.Body)
+		c.Check(err, IsNil)
+		c.Check(string(body), DeepEquals, `{"instance-name":"pc-kernel"}`)
+	})
+	err := s.cli.RebootRequiredNotification(context.Background(), &client.RebootRequiredInfo{InstanceName: "pc-kernel"})
+	c.Assert(err, IsNil)
+	c.Check(atomic.LoadInt32(&n), Equals, int32(2))
+}
+
+func (s *clientSuite) TestWarningNotification(c *C) {
+	var n int32
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		c.Assert(r.URL.Path, Equals, "/v1/notifications/warning")
+		body, err := ioutil.ReadAll(r.Body)
+		c.Check(err, IsNil)
+		c.Check(string(body), DeepEquals, `{"message":"something broke","severity":"high"}`)
+	})
+	err := s.cli.WarningNotification(context.Background(), &client.WarningInfo{Message: "something broke", Severity: "high"})
+	c.Assert(err, IsNil)
+	c.Check(atomic.LoadInt32(&n), Equals, int32(2))
+}
+
 func (s *clientSuite) TestPendingRefreshNotificationOneClient(c *C) {
 	cli := client.NewForUids(1000)
 	var n int32
@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package wrappers
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// ServiceOverrides holds the settings of a snap service unit that can be
+// overridden by the system administrator. They are written to a drop-in
+// file next to the generated unit, which is regenerated along with it.
+type ServiceOverrides struct {
+	// Nice is the scheduling priority of the service processes.
+	Nice *int `json:"nice,omitempty"`
+	// OOMScoreAdjust adjusts the likelihood of the service processes
+	// being killed by the OOM killer.
+	OOMScoreAdjust *int `json:"oom-score-adjust,omitempty"`
+	// Environment holds variables to set in the environment of the
+	// service processes.
+	Environment map[string]string `json:"environment,omitempty"`
+}
+
+const (
+	minServiceNice = -20
+	maxServiceNice = 19
+	// services cannot be made more vital than snapd itself, which
+	// runs with OOMScoreAdjust=-900
+	minServiceOOMScoreAdjust = -899
+	maxServiceOOMScoreAdjust = 1000
+)
+
+var validServiceEnvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate checks that the overrides can be safely written to a drop-in
+// file.
+func (o *ServiceOverrides) Validate() error {
+	if o.Nice != nil && (*o.Nice < minServiceNice || *o.Nice > maxServiceNice) {
+		return fmt.Errorf("nice must be between %d and %d, not %d", minServiceNice, maxServiceNice, *o.Nice)
+	}
+	if o.OOMScoreAdjust != nil && (*o.OOMScoreAdjust < minServiceOOMScoreAdjust || *o.OOMScoreAdjust > maxServiceOOMScoreAdjust) {
+		return fmt.Errorf("oom-score-adjust must be between %d and %d, not %d", minServiceOOMScoreAdjust, maxServiceOOMScoreAdjust, *o.OOMScoreAdjust)
+	}
+	for name, value := range o.Environment {
+		if !validServiceEnvName.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if strings.ContainsAny(value, "\n\r\x00") {
+			return fmt.Errorf("invalid value of environment variable %q: cannot contain newlines or NUL characters", name)
+		}
+	}
+	return nil
+}
+
+// escapeServiceEnvValue escapes the value so that systemd neither
+// splits it nor expands specifiers in it.
+func escapeServiceEnvValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// genServiceOverridesFile returns the content of the drop-in file for
+// the given overrides of the service of app, or nil if there are none.
+func genServiceOverridesFile(app *snap.AppInfo, overrides *ServiceOverrides) []byte {
+	if overrides == nil || (overrides.Nice == nil && overrides.OOMScoreAdjust == nil && len(overrides.Environment) == 0) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[Service]\n# Overrides of the settings of the %s service configured with\n# snap set system service-overrides.%s.%s\n", app, app.Snap.InstanceName(), app.Name)
+	if overrides.Nice != nil {
+		fmt.Fprintf(&buf, "Nice=%d\n", *overrides.Nice)
+	}
+	if overrides.OOMScoreAdjust != nil {
+		fmt.Fprintf(&buf, "OOMScoreAdjust=%d\n", *overrides.OOMScoreAdjust)
+	}
+	names := make([]string, 0, len(overrides.Environment))
+	for name := range overrides.Environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&buf, "Environment=\"%s=%s\"\n", name, escapeServiceEnvValue(overrides.Environment[name]))
+	}
+	return buf.Bytes()
+}
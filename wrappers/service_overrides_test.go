@@ -0,0 +1,146 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2023 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package wrappers_test
+
+import (
+	"fmt"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/wrappers"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func (s *servicesTestSuite) TestServiceOverridesValidate(c *C) {
+	for _, t := range []struct {
+		overrides wrappers.ServiceOverrides
+		err       string
+	}{
+		{wrappers.ServiceOverrides{}, ""},
+		{wrappers.ServiceOverrides{Nice: intPtr(-20), OOMScoreAdjust: intPtr(1000)}, ""},
+		{wrappers.ServiceOverrides{Nice: intPtr(19), OOMScoreAdjust: intPtr(-899)}, ""},
+		{wrappers.ServiceOverrides{Environment: map[string]string{"FOO_1": `a "b" %c`}}, ""},
+		{wrappers.ServiceOverrides{Nice: intPtr(20)}, `nice must be between -20 and 19, not 20`},
+		{wrappers.ServiceOverrides{Nice: intPtr(-21)}, `nice must be between -20 and 19, not -21`},
+		{wrappers.ServiceOverrides{OOMScoreAdjust: intPtr(-900)}, `oom-score-adjust must be between -899 and 1000, not -900`},
+		{wrappers.ServiceOverrides{OOMScoreAdjust: intPtr(1001)}, `oom-score-adjust must be between -899 and 1000, not 1001`},
+		{wrappers.ServiceOverrides{Environment: map[string]string{"1FOO": "a"}}, `invalid environment variable name "1FOO"`},
+		{wrappers.ServiceOverrides{Environment: map[string]string{"FOO BAR": "a"}}, `invalid environment variable name "FOO BAR"`},
+		{wrappers.ServiceOverrides{Environment: map[string]string{"FOO": "a\nExecStart=/bin/sh"}}, `invalid value of environment variable "FOO": cannot contain newlines or NUL characters`},
+	} {
+		err := t.overrides.Validate()
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf("%+v", t.overrides))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf("%+v", t.overrides))
+		}
+	}
+}
+
+func (s *servicesTestSuite) TestEnsureSnapServicesWithOverrides(c *C) {
+	info := snaptest.MockSnap(c, packageHello, &snap.SideInfo{Revision: snap.R(12)})
+	dropInFile := filepath.Join(s.tempdir, "/etc/systemd/system/snap.hello-snap.svc1.service.d/50-snap-overrides.conf")
+
+	seen := make(map[string]bool)
+	cb := func(app *snap.AppInfo, grp *quota.Group, unitType, name string, old, new string) {
+		seen[fmt.Sprintf("%s:%s:%s:%s", app.Snap.InstanceName(), app.Name, unitType, name)] = new == ""
+	}
+
+	m := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: {
+			ServiceOverrides: map[string]*wrappers.ServiceOverrides{
+				"svc1": {
+					Nice:           intPtr(5),
+					OOMScoreAdjust: intPtr(-100),
+					Environment:    map[string]string{"B": `x "y" 50%`, "A": "1"},
+				},
+				// not a service of the snap anymore
+				"gone": {Nice: intPtr(1)},
+			},
+		},
+	}
+	err := wrappers.EnsureSnapServices(m, nil, cb, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"daemon-reload"},
+	})
+	c.Check(seen, DeepEquals, map[string]bool{
+		"hello-snap:svc1:service:svc1":           false,
+		"hello-snap:svc1:service-overrides:svc1": false,
+	})
+	c.Check(dropInFile, testutil.FileEquals, `[Service]
+# Overrides of the settings of the hello-snap.svc1 service configured with
+# snap set system service-overrides.hello-snap.svc1
+Nice=5
+OOMScoreAdjust=-100
+Environment="A=1"
+Environment="B=x \"y\" 50%%"
+`)
+
+	// without overrides the drop-in is removed
+	s.sysdLog = nil
+	seen = make(map[string]bool)
+	m[info] = nil
+	err = wrappers.EnsureSnapServices(m, nil, cb, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"daemon-reload"},
+	})
+	c.Check(seen, DeepEquals, map[string]bool{
+		"hello-snap:svc1:service-overrides:svc1": true,
+	})
+	c.Check(dropInFile, testutil.FileAbsent)
+
+	// and nothing happens if there was none
+	s.sysdLog = nil
+	err = wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(s.sysdLog, HasLen, 0)
+}
+
+func (s *servicesTestSuite) TestRemoveSnapServicesRemovesOverrides(c *C) {
+	info := snaptest.MockSnap(c, packageHello, &snap.SideInfo{Revision: snap.R(12)})
+	dropInFile := filepath.Join(s.tempdir, "/etc/systemd/system/snap.hello-snap.svc1.service.d/50-snap-overrides.conf")
+
+	m := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: {
+			ServiceOverrides: map[string]*wrappers.ServiceOverrides{
+				"svc1": {Nice: intPtr(5)},
+			},
+		},
+	}
+	err := wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+	c.Assert(dropInFile, testutil.FilePresent)
+
+	err = wrappers.RemoveSnapServices(info, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(dropInFile, testutil.FileAbsent)
+	c.Check(filepath.Dir(dropInFile), testutil.FileAbsent)
+}
@@ -486,11 +486,16 @@ type SnapServiceOptions struct {
 
 	// QuotaGroup is the quota group for the specified snap.
 	QuotaGroup *quota.Group
+
+	// ServiceOverrides maps the names of the services of the snap to the
+	// overrides of their unit settings.
+	ServiceOverrides map[string]*ServiceOverrides
 }
 
 // ObserveChangeCallback can be invoked by EnsureSnapServices to observe
 // the previous content of a unit and the new on a change.
-// unitType can be "service", "socket", "timer". name is empty for a timer.
+// unitType can be "service", "service-overrides", "socket", "timer". name
+// is empty for a timer.
 type ObserveChangeCallback func(app *snap.AppInfo, grp *quota.Group, unitType string, name, old, new string)
 
 // EnsureSnapServicesOptions is the set of options applying to the
@@ -627,6 +632,35 @@ func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *sna
 		return nil
 	}
 
+	handleFileRemoval := func(app *snap.AppInfo, unitType string, name, path string) error {
+		content, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		st, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		if es.observeChange != nil {
+			es.observeChange(app, nil, unitType, name, string(content), "")
+		}
+		es.modifiedUnits[path] = &osutil.MemoryFileState{Content: content, Mode: st.Mode()}
+		switch app.DaemonScope {
+		case snap.SystemDaemon:
+			es.systemDaemonReloadNeeded = true
+		case snap.UserDaemon:
+			es.userDaemonReloadNeeded = true
+		}
+		return nil
+	}
+
 	// lets sort the service list before generating them for
 	// consistency when testing
 	services := snapInfo.Services()
@@ -679,6 +713,17 @@ func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *sna
 			return err
 		}
 
+		// Generate the drop-in overriding settings of the service, or
+		// remove it if there are no overrides anymore
+		overridesPath := svc.ServiceOverridesFile()
+		if content := genServiceOverridesFile(svc, opts.ServiceOverrides[svc.Name]); content != nil {
+			if err := handleFileModification(svc, "service-overrides", svc.Name, overridesPath, content); err != nil {
+				return err
+			}
+		} else if err := handleFileRemoval(svc, "service-overrides", svc.Name, overridesPath); err != nil {
+			return err
+		}
+
 		// Generate systemd .socket files if needed
 		socketFiles, err := generateSnapSocketFiles(svc)
 		if err != nil {
@@ -724,6 +769,7 @@ func (es *ensureSnapServicesContext) ensureSnapsSystemdServices() (*quota.QuotaG
 			RequireMountedSnapdSnap: es.opts.RequireMountedSnapdSnap,
 			VitalityRank:            snapSvcOpts.VitalityRank,
 			QuotaGroup:              snapSvcOpts.QuotaGroup,
+			ServiceOverrides:        snapSvcOpts.ServiceOverrides,
 		}
 		if snapSvcOpts.QuotaGroup != nil {
 			// AddAllNecessaryGroups also adds all sub-groups to the quota group set. So this
@@ -941,6 +987,10 @@ type generateSnapServicesOptions struct {
 	// the snapd snap being mounted, this is specific to systems like UC18 and
 	// UC20 which have the snapd snap and need to have units generated
 	RequireMountedSnapdSnap bool
+
+	// ServiceOverrides maps the names of the services of the snap to the
+	// overrides of their unit settings.
+	ServiceOverrides map[string]*ServiceOverrides
 }
 
 // StopServicesFlags carries extra flags for StopServices.
@@ -1107,7 +1157,7 @@ func RemoveSnapServices(s *snap.Info, inter Interacter) error {
 		case snap.UserDaemon:
 			userUnits = append(userUnits, serviceName)
 		}
-		systemUnitFiles = append(systemUnitFiles, app.ServiceFile())
+		systemUnitFiles = append(systemUnitFiles, app.ServiceFile(), app.ServiceOverridesFile())
 	}
 
 	// disable all collected systemd units
@@ -1125,6 +1175,10 @@ func RemoveSnapServices(s *snap.Info, inter Interacter) error {
 		if err := os.Remove(systemUnitFile); err != nil && !os.IsNotExist(err) {
 			logger.Noticef("Failed to remove socket file %q: %v", systemUnitFile, err)
 		}
+		if strings.HasSuffix(filepath.Dir(systemUnitFile), ".service.d") {
+			// the drop-in directory is removed only if empty
+			os.Remove(filepath.Dir(systemUnitFile))
+		}
 	}
 
 	// only reload if we actually had services
@@ -1878,6 +1932,9 @@ type RestartServicesFlags struct {
 	Reload bool
 	// AlsoEnabledNonActive set if we to restart also enabled but not running units
 	AlsoEnabledNonActive bool
+	// OnlyFailed set if only the units in the failed state are to be
+	// restarted, regardless of them being enabled or explicitly mentioned.
+	OnlyFailed bool
 }
 
 // Restart or reload active services in `svcs`.
@@ -1889,6 +1946,8 @@ type RestartServicesFlags struct {
 // restarted no matter it's state, it should be included in the
 // explicitServices list.
 // The list of explicitServices needs to use systemd unit names.
+// If the OnlyFailed flag is set, only the services in the failed state are
+// restarted.
 // TODO: change explicitServices format to be less unusual, more consistent
 // (introduce AppRef?)
 func RestartServices(apps []*snap.AppInfo, explicitServices []string,
@@ -1909,11 +1968,19 @@ func RestartServices(apps []*snap.AppInfo, explicitServices []string,
 		unitActive := st.service.Active
 		unitEnabled := st.isEnabled()
 
-		// If the unit was explicitly mentioned in the command line, restart it
-		// even if it is disabled; otherwise, we only restart units which are
-		// currently enabled or running. Reference:
-		// https://forum.snapcraft.io/t/command-line-interface-to-manipulate-services/262/47
-		if !unitActive && !strutil.ListContains(explicitServices, unitName) {
+		// If only failed units are requested, they are restarted
+		// regardless of them being enabled or explicitly mentioned.
+		if flags.OnlyFailed {
+			if !st.service.Failed {
+				logger.Debugf("not restarting unit %s which is not failed", unitName)
+				continue
+			}
+		} else if !unitActive && !strutil.ListContains(explicitServices, unitName) {
+			// If the unit was explicitly mentioned in the command line,
+			// restart it even if it is disabled; otherwise, we only
+			// restart units which are currently enabled or
+			// running. Reference:
+			// https://forum.snapcraft.io/t/command-line-interface-to-manipulate-services/262/47
 			if !flags.AlsoEnabledNonActive {
 				logger.Noticef("not restarting inactive unit %s", unitName)
 				continue